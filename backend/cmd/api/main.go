@@ -1,166 +1,236 @@
 package main
 
 import (
+	"context"
+	"fintu-tracking-backend/internal/config"
 	"fintu-tracking-backend/internal/database"
 	"fintu-tracking-backend/internal/handlers"
+	"fintu-tracking-backend/internal/logging"
 	"fintu-tracking-backend/internal/middleware"
 	"fintu-tracking-backend/internal/migrations"
+	"fintu-tracking-backend/internal/routes"
 	"fintu-tracking-backend/internal/services"
+	"fintu-tracking-backend/internal/telemetry"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/adaptor"
 	"github.com/gofiber/fiber/v3/middleware/cors"
-	"github.com/gofiber/fiber/v3/middleware/logger"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func main() {
 	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using system environment variables")
+	envErr := godotenv.Load()
+
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("Failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logging.New(os.Stdout, cfg.LogFormat, cfg.LogLevel))
+	if envErr != nil {
+		slog.Info("No .env file found, using system environment variables")
+	}
+
+	if err := run(cfg); err != nil {
+		slog.Error("Server failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+// run serves the API until SIGINT or SIGTERM, then drains in-flight requests
+// and background jobs within cfg.ShutdownTimeout before closing the database
+// and flushing traces.
+func run(cfg *config.Config) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Tracing exports spans only when an OTLP endpoint is configured.
+	shutdownTracing, err := telemetry.SetupTracing(context.Background())
+	if err != nil {
+		return fmt.Errorf("set up tracing: %w", err)
 	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), config.TelemetryShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("Failed to flush traces", "error", err)
+		}
+	}()
 
 	// Connect to database
-	if err := database.Connect(); err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+	if err := database.Connect(cfg.DatabaseURL); err != nil {
+		return fmt.Errorf("connect to database: %w", err)
 	}
 	defer database.Close()
 
 	// Run migrations before the app accepts traffic.
-	if err := runMigrations(); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+	if err := runMigrations(cfg.DatabaseURL); err != nil {
+		return fmt.Errorf("run migrations: %w", err)
 	}
+	migrationVersion, err := migrations.Latest(migrationsDir)
+	if err != nil {
+		return err
+	}
+
+	// Background jobs stop when jobsCtx is cancelled, after the HTTP server
+	// has drained.
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+	var jobs sync.WaitGroup
 
 	// Wire DB pool into service singletons
 	billingProvider := services.NewNoOpBillingProvider()
 	billingSvc := services.NewBillingService(database.GetPool(), billingProvider)
 	handlers.InitBillingService(billingSvc)
-	handlers.InitExchangeRateService()
-	handlers.InitTwelveDataService()
+	handlers.InitExchangeRateService(cfg.TwelveDataAPIKey)
+	handlers.InitTwelveDataService(cfg.TwelveDataAPIKey)
 	handlers.InitBrokerService(database.GetPool())
-	handlers.InitProfileService(database.GetPool())
+	profileSvc := handlers.InitProfileService(database.GetPool())
+	handlers.InitPortfolioService(database.GetPool())
+	handlers.InitBenchmarkService(database.GetPool())
+	handlers.InitAllocationService(database.GetPool())
+	dcaSvc := services.NewDCAService(database.GetPool())
+	handlers.InitDCAService(dcaSvc)
+	jobs.Go(func() { dcaSvc.RunScheduler(jobsCtx, config.DCASchedulerInterval) })
+	handlers.InitGoalService(database.GetPool())
+	notificationSvc := services.NewNotificationService(database.GetPool())
+	handlers.InitNotificationService(notificationSvc)
+	jobs.Go(func() { notificationSvc.RunListener(jobsCtx) })
+	webhookSvc := services.NewWebhookService(database.GetPool())
+	handlers.InitWebhookService(webhookSvc)
+	jobs.Go(func() { webhookSvc.RunDispatcher(jobsCtx, config.WebhookDispatchInterval) })
+	alertSvc := services.NewAlertService(database.GetPool(), notificationSvc, webhookSvc, cfg.SMTP)
+	handlers.InitAlertService(alertSvc)
+	jobs.Go(func() { alertSvc.RunEvaluator(jobsCtx, config.AlertEvaluationInterval) })
+	apiTokenSvc := services.NewAPITokenService(database.GetPool())
+	handlers.InitAPITokenService(apiTokenSvc)
+	rateLimiter := services.NewRateLimiter(services.NewPostgresRateLimitStore(database.GetPool()), billingSvc)
+	jobs.Go(func() { rateLimiter.RunPruner(jobsCtx, config.RateLimitPruneInterval) })
+	handlers.InitHealthService(services.NewHealthService(
+		services.NewPostgresHealthStore(database.GetPool()),
+		migrationVersion,
+		cfg.TwelveDataAPIKey != "",
+	))
+	authCfg := middleware.NewAuthConfig(cfg.Auth)
+	idempotent := middleware.Idempotency(services.NewPostgresIdempotencyStore(database.GetPool()))
 
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
-		ErrorHandler: func(c fiber.Ctx, err error) error {
-			code := fiber.StatusInternalServerError
-			if e, ok := err.(*fiber.Error); ok {
-				code = e.Code
-			}
-			return c.Status(code).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		},
+		ErrorHandler: middleware.ErrorHandler,
 	})
 
-	// Middleware
-	app.Use(logger.New())
+	// Middleware. RequestLogger renders handler errors, so Telemetry and the
+	// access log both record the final status; Locale picks their language.
+	app.Use(middleware.Telemetry())
+	app.Use(middleware.RequestLogger())
+	app.Use(middleware.Locale())
 	allowedOrigins := []string{"http://localhost:3000", "http://localhost:3001"}
-	if feURL := os.Getenv("FRONTEND_URL"); feURL != "" {
-		allowedOrigins = append(allowedOrigins, feURL)
+	if cfg.FrontendURL != "" {
+		allowedOrigins = append(allowedOrigins, cfg.FrontendURL)
 	}
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: allowedOrigins,
-		AllowHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		AllowMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowOrigins:  allowedOrigins,
+		AllowHeaders:  []string{"Origin", "Content-Type", "Accept", "Accept-Language", "Authorization", "Idempotency-Key", config.RequestIDHeader, "traceparent", "tracestate"},
+		ExposeHeaders: []string{config.RequestIDHeader, "Content-Language", "Idempotent-Replayed", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"},
+		AllowMethods:  []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 	}))
 
-	// Health check
-	app.Get("/health", func(c fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"status":  "ok",
-			"service": "fintu-tracking-api",
-		})
-	})
+	// Prometheus metrics
+	app.Get(config.MetricsPath,
+		middleware.MetricsAuth(cfg.MetricsToken),
+		adaptor.HTTPHandler(promhttp.HandlerFor(telemetry.Registry, promhttp.HandlerOpts{})),
+	)
+
+	// Probes and API routes, with the OpenAPI document describing them.
+	// Requests are validated against the document before their handlers run.
+	if _, err := routes.Register(app, routes.Middleware{
+		Auth: []any{
+			middleware.AuthMiddleware(authCfg, apiTokenSvc),
+			middleware.ProfileLocale(profileSvc),
+			middleware.RequireTokenScopes(config.APITokenScopeRules),
+			middleware.RateLimit(rateLimiter, config.RateLimitRules),
+		},
+		ActivePlan: middleware.RequireActivePlan(billingSvc),
+		Idempotent: idempotent,
+	}); err != nil {
+		return fmt.Errorf("register routes: %w", err)
+	}
 
-	// API routes
-	api := app.Group("/api")
-
-	// Authenticated routes that do not require an active subscription.
-	authOnly := api.Group("", middleware.AuthMiddleware())
-
-	// Current user / onboarding endpoints
-	authOnly.Get("/me", handlers.GetMe)
-	authOnly.Patch("/me/onboarding", handlers.UpdateOnboarding)
-	authOnly.Patch("/me/profile", handlers.UpdateProfile)
-
-	// Billing endpoints
-	authOnly.Get("/plans", handlers.ListPlans)
-	authOnly.Get("/subscriptions/current", handlers.GetSubscription)
-	authOnly.Post("/subscriptions", handlers.CreateSubscription)
-	authOnly.Patch("/subscriptions/:id/cancel", handlers.CancelSubscription)
-
-	// Broker endpoints are auth-only (not subscription-gated) so onboarding can
-	// create the user's first broker before a plan is selected.
-	authOnly.Get("/brokers", handlers.ListBrokers)
-	authOnly.Post("/brokers", handlers.CreateBroker)
-
-	// Protected routes - require authentication and an active subscription.
-	protected := authOnly.Group("", middleware.RequireActivePlan(billingSvc))
-
-	// FX Rates endpoints
-	protected.Get("/fx-rates/current", handlers.GetCurrentRate)
-	protected.Get("/fx-rates/chart", handlers.GetFxRateChart)
-	protected.Get("/fx-rates", handlers.ListFxRates)
-	protected.Post("/fx-rates", handlers.CreateFxRate)
-	protected.Put("/fx-rates/:id", handlers.UpdateFxRate)
-	protected.Delete("/fx-rates/:id", handlers.DeleteFxRate)
-
-	// Cash Flows endpoints
-	protected.Get("/cash-flows", handlers.ListCashFlows)
-	protected.Post("/cash-flows", handlers.CreateCashFlow)
-	protected.Put("/cash-flows/:id", handlers.UpdateCashFlow)
-	protected.Delete("/cash-flows/:id", handlers.DeleteCashFlow)
-
-	// Trades endpoints
-	protected.Get("/trade-tickers", handlers.ListTradeTickers)
-	protected.Get("/trades", handlers.ListTrades)
-	protected.Post("/trades", handlers.CreateTrade)
-	protected.Put("/trades/:id", handlers.UpdateTrade)
-	protected.Delete("/trades/:id", handlers.DeleteTrade)
-
-	// Market Prices endpoints
-	protected.Get("/market-prices", handlers.ListMarketPrices)
-	protected.Get("/market-prices/:ticker", handlers.GetMarketPrice)
-	protected.Post("/market-prices/refresh", handlers.RefreshMarketPrices)
-
-	// Portfolio endpoints
-	protected.Get("/portfolio/holdings", handlers.GetHoldings)
-
-	// Analytics endpoints
-	protected.Get("/analytics/fee-breakdown", handlers.GetFeeBreakdown)
-	protected.Get("/analytics/fee-impact", handlers.GetFeeImpact)
-	protected.Get("/analytics/fee-efficiency", handlers.GetFeeEfficiency)
-	protected.Get("/analytics/return-attribution", handlers.GetReturnAttribution)
-	protected.Get("/analytics/fx-impact", handlers.GetFXImpact)
-	protected.Get("/analytics/performance-time-series", handlers.GetPerformanceTimeSeries)
-	protected.Get("/analytics/net-worth", handlers.GetNetWorth)
-	protected.Get("/analytics/cash-reconciliation", handlers.GetCashReconciliation)
-
-	// Activity feed
-	protected.Get("/activity/feed", handlers.GetActivityFeed)
+	// End open notification streams when shutdown starts so their
+	// connections can drain.
+	app.Hooks().OnPreShutdown(func() error {
+		notificationSvc.CloseStreams()
+		return nil
+	})
 
 	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	listenErr := make(chan error, 1)
+	go func() {
+		slog.Info("Server starting", "port", cfg.Port)
+		listenErr <- app.Listen(":"+cfg.Port, fiber.ListenConfig{DisableStartupMessage: true})
+	}()
+
+	select {
+	case err := <-listenErr:
+		return err
+	case <-ctx.Done():
 	}
+	// Restore default signal handling so a second signal exits immediately.
+	stop()
+
+	slog.Info("Shutting down", "timeout", cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
 
-	fmt.Printf("Server starting on port %s\n", port)
-	log.Fatal(app.Listen(":" + port))
+	// Stop accepting requests and wait for in-flight ones, then stop the
+	// background jobs and let running alert evaluations finish.
+	if err := app.ShutdownWithContext(shutdownCtx); err != nil {
+		slog.Error("HTTP server did not drain", "error", err)
+	}
+	cancelJobs()
+	jobs.Go(alertSvc.Wait)
+	if err := waitFor(shutdownCtx, &jobs); err != nil {
+		slog.Error("Background jobs did not stop in time", "error", err)
+	}
+	slog.Info("Shutdown complete")
+	return nil
+}
+
+// waitFor waits for wg or for ctx to be done, whichever comes first.
+func waitFor(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
+// migrationsDir holds the SQL migrations, relative to the working directory.
+const migrationsDir = "migrations"
+
 // runMigrations opens a dedicated migration database connection, applies all
 // pending migrations, and closes the connection. Errors are fatal to startup
 // so the app never serves traffic against an out-of-date schema.
-func runMigrations() error {
-	migrationDB, err := database.OpenMigrationDB()
+func runMigrations(dbURL string) error {
+	migrationDB, err := database.OpenMigrationDB(dbURL)
 	if err != nil {
 		return err
 	}
 	defer migrationDB.Close()
 
-	return migrations.Up(migrationDB, "migrations")
+	return migrations.Up(migrationDB, migrationsDir)
 }
@@ -84,7 +84,11 @@ func migrationsDir() string {
 }
 
 func runMigrate(dir string, fn func(*sql.DB) error) error {
-	db, err := database.OpenMigrationDB()
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		return fmt.Errorf("DATABASE_URL environment variable not set")
+	}
+	db, err := database.OpenMigrationDB(dbURL)
 	if err != nil {
 		return err
 	}
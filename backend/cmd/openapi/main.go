@@ -0,0 +1,47 @@
+// Command openapi writes the OpenAPI document and the generated TypeScript
+// client of each API version. Run it from backend/ after changing routes or
+// models:
+//
+//	go run ./cmd/openapi
+//
+// The routes tests fail while any of the files is out of date.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"fintu-tracking-backend/internal/openapi"
+	"fintu-tracking-backend/internal/routes"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func main() {
+	specPath := flag.String("spec", "openapi.json", "where to write the v1 OpenAPI document")
+	tsPath := flag.String("ts", "../frontend/lib/api/generated.ts", "where to write the v1 TypeScript client")
+	specV2Path := flag.String("spec-v2", "openapi.v2.json", "where to write the v2 OpenAPI document")
+	tsV2Path := flag.String("ts-v2", "../frontend/lib/api/generated-v2.ts", "where to write the v2 TypeScript client")
+	flag.Parse()
+
+	docs, err := routes.Register(fiber.New(), routes.PassThrough())
+	if err != nil {
+		log.Fatalf("build documents: %v", err)
+	}
+	write(docs.V1, *specPath, *tsPath)
+	write(docs.V2, *specV2Path, *tsV2Path)
+}
+
+func write(doc *openapi.Document, specPath, tsPath string) {
+	spec, err := doc.JSON()
+	if err != nil {
+		log.Fatalf("encode document: %v", err)
+	}
+	if err := os.WriteFile(specPath, append(spec, '\n'), 0o644); err != nil {
+		log.Fatalf("write document: %v", err)
+	}
+	if err := os.WriteFile(tsPath, doc.TypeScript(), 0o644); err != nil {
+		log.Fatalf("write TypeScript client: %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+// Package apperror defines the application's error taxonomy. Services return
+// *Error values (usually wrapped with fmt.Errorf("%w: ...")) and the HTTP
+// error handler turns them into a status code and a stable JSON error code.
+// Errors without a Kind are internal: they are logged and clients only see a
+// generic message.
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Kind classifies an error for clients. Its string value is the JSON "code".
+type Kind string
+
+const (
+	// Validation means the request is malformed or breaks a business rule.
+	Validation Kind = "validation"
+	// NotFound means the resource does not exist or belongs to someone else.
+	NotFound Kind = "not_found"
+	// Conflict means the request clashes with the resource's current state.
+	Conflict Kind = "conflict"
+	// UpstreamUnavailable means an external provider failed or is not configured.
+	UpstreamUnavailable Kind = "upstream_unavailable"
+	// PlanLimit means the user's subscription does not allow the request.
+	PlanLimit Kind = "plan_limit"
+	// Unauthorized means the request has no valid credentials.
+	Unauthorized Kind = "unauthorized"
+	// Forbidden means the credentials do not grant access to the route.
+	Forbidden Kind = "forbidden"
+	// RateLimited means the caller must wait before retrying.
+	RateLimited Kind = "rate_limited"
+	// Internal is any other failure. Its details are never sent to clients.
+	Internal Kind = "internal"
+)
+
+// InternalMessage is the client message for internal errors.
+const InternalMessage = "Internal server error"
+
+// Status returns the HTTP status code for k.
+func (k Kind) Status() int {
+	switch k {
+	case Validation:
+		return http.StatusBadRequest
+	case NotFound:
+		return http.StatusNotFound
+	case Conflict:
+		return http.StatusConflict
+	case UpstreamUnavailable:
+		return http.StatusServiceUnavailable
+	case PlanLimit:
+		return http.StatusPaymentRequired
+	case Unauthorized:
+		return http.StatusUnauthorized
+	case Forbidden:
+		return http.StatusForbidden
+	case RateLimited:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// KindForStatus maps an HTTP status code to a Kind, for errors that only
+// carry a status (such as *fiber.Error).
+func KindForStatus(status int) Kind {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusRequestEntityTooLarge,
+		http.StatusUnsupportedMediaType, http.StatusMethodNotAllowed:
+		return Validation
+	case http.StatusNotFound:
+		return NotFound
+	case http.StatusConflict:
+		return Conflict
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return UpstreamUnavailable
+	case http.StatusPaymentRequired:
+		return PlanLimit
+	case http.StatusUnauthorized:
+		return Unauthorized
+	case http.StatusForbidden:
+		return Forbidden
+	case http.StatusTooManyRequests:
+		return RateLimited
+	default:
+		return Internal
+	}
+}
+
+// Error is a classified application error. Message is safe to show to
+// clients; Err, when set, is the underlying cause and is only logged. Format
+// and Args are set when Message was formatted, so the message can be
+// formatted again in the client's language.
+type Error struct {
+	Kind    Kind
+	Message string
+	Err     error
+	Format  string
+	Args    []any
+}
+
+// New returns an error of the given kind with a client-safe message.
+func New(kind Kind, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// Newf returns an error of the given kind whose client message is format
+// formatted with args.
+func Newf(kind Kind, format string, args ...any) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...), Format: format, Args: args}
+}
+
+// Wrap returns an error of the given kind whose client message is message
+// and whose cause is err.
+func Wrap(kind Kind, message string, err error) *Error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+// Classify returns err unchanged when it is already classified, and otherwise
+// an error of the given kind whose client message is err's text. Handlers use
+// it for their request parsers, whose errors are written for clients.
+func Classify(kind Kind, err error) error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return err
+	}
+	return New(kind, err.Error())
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Detail is a client-safe detail added to a classified error, written
+// "cause: detail" like fmt.Errorf("%w: ..."). It keeps the detail's format
+// and arguments so the message can be formatted again in the client's
+// language.
+type Detail struct {
+	Err    error
+	Format string
+	Args   []any
+}
+
+// Detailf adds a detail formatted from format and args to err. Use it
+// instead of fmt.Errorf("%w: ...", err, args...) when the detail has
+// arguments.
+func Detailf(err error, format string, args ...any) error {
+	return &Detail{Err: err, Format: format, Args: args}
+}
+
+func (d *Detail) Error() string {
+	return d.Err.Error() + ": " + fmt.Sprintf(d.Format, d.Args...)
+}
+
+func (d *Detail) Unwrap() error {
+	return d.Err
+}
+
+// KindOf returns the Kind of the first *Error in err's chain, or Internal.
+func KindOf(err error) Kind {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Kind
+	}
+	return Internal
+}
+
+// Message returns the text to show clients for err. Classified errors keep
+// their full wrapped text, which services build from client-safe parts (for
+// example "invalid goal: name is required"), except when the classified error
+// itself has a cause, whose details stay internal. Everything else becomes
+// InternalMessage.
+func Message(err error) string {
+	var appErr *Error
+	if !errors.As(err, &appErr) || appErr.Kind == Internal {
+		return InternalMessage
+	}
+	if appErr.Err != nil {
+		return appErr.Message
+	}
+	return err.Error()
+}
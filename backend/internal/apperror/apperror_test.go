@@ -0,0 +1,118 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestKindOfAndMessage(t *testing.T) {
+	t.Parallel()
+
+	errGoalNotFound := New(NotFound, "goal not found")
+	errInvalidGoal := New(Validation, "invalid goal")
+
+	tests := []struct {
+		name    string
+		err     error
+		kind    Kind
+		message string
+	}{
+		{
+			name:    "sentinel",
+			err:     errGoalNotFound,
+			kind:    NotFound,
+			message: "goal not found",
+		},
+		{
+			name:    "wrapped sentinel keeps the detail",
+			err:     fmt.Errorf("%w: name is required", errInvalidGoal),
+			kind:    Validation,
+			message: "invalid goal: name is required",
+		},
+		{
+			name:    "formatted detail",
+			err:     Detailf(errInvalidGoal, "target_date must be within %d years", 50),
+			kind:    Validation,
+			message: "invalid goal: target_date must be within 50 years",
+		},
+		{
+			name:    "formatted message",
+			err:     Newf(Validation, "limit must be between 1 and %d", 100),
+			kind:    Validation,
+			message: "limit must be between 1 and 100",
+		},
+		{
+			name:    "cause stays internal",
+			err:     Wrap(UpstreamUnavailable, "Exchange rate provider is unavailable", errors.New("dial tcp: i/o timeout")),
+			kind:    UpstreamUnavailable,
+			message: "Exchange rate provider is unavailable",
+		},
+		{
+			name:    "unclassified",
+			err:     errors.New(`ERROR: relation "trades" does not exist (SQLSTATE 42P01)`),
+			kind:    Internal,
+			message: InternalMessage,
+		},
+		{
+			name:    "explicit internal",
+			err:     New(Internal, "boom"),
+			kind:    Internal,
+			message: InternalMessage,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := KindOf(tc.err); got != tc.kind {
+				t.Errorf("KindOf = %q, want %q", got, tc.kind)
+			}
+			if got := Message(tc.err); got != tc.message {
+				t.Errorf("Message = %q, want %q", got, tc.message)
+			}
+		})
+	}
+}
+
+func TestErrorsIsMatchesSentinel(t *testing.T) {
+	t.Parallel()
+
+	sentinel := New(Conflict, "name taken")
+	if !errors.Is(fmt.Errorf("%w: %q", sentinel, "Retirement"), sentinel) {
+		t.Error("errors.Is did not match the wrapped sentinel")
+	}
+	if !errors.Is(Detailf(sentinel, "%q", "Retirement"), sentinel) {
+		t.Error("errors.Is did not match the sentinel behind a detail")
+	}
+	if errors.Is(New(Conflict, "name taken"), sentinel) {
+		t.Error("errors.Is matched a different error with the same message")
+	}
+}
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	parsed := Newf(Validation, "limit must be between 1 and %d", 100)
+	if got := Classify(Validation, parsed); got != error(parsed) {
+		t.Errorf("Classify replaced a classified error with %v", got)
+	}
+	got := Classify(Validation, errors.New("Invalid date format"))
+	if KindOf(got) != Validation || Message(got) != "Invalid date format" {
+		t.Errorf("Classify = %v (%s), want a validation error", got, KindOf(got))
+	}
+}
+
+func TestStatusRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, kind := range []Kind{Validation, NotFound, Conflict, UpstreamUnavailable, PlanLimit, Unauthorized, Forbidden, RateLimited, Internal} {
+		if got := KindForStatus(kind.Status()); got != kind {
+			t.Errorf("KindForStatus(%d) = %q, want %q", kind.Status(), got, kind)
+		}
+	}
+	if got := KindForStatus(http.StatusUnprocessableEntity); got != Validation {
+		t.Errorf("KindForStatus(422) = %q, want validation", got)
+	}
+}
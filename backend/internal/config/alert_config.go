@@ -0,0 +1,30 @@
+package config
+
+import "time"
+
+// Alert rule configuration.
+const (
+	// MaxAlertRules caps the number of alert rules per user.
+	MaxAlertRules = 50
+
+	// AlertEvaluationTimeout bounds one evaluation run started after a
+	// price or FX refresh.
+	AlertEvaluationTimeout = 30 * time.Second
+
+	// AlertEvaluationInterval is how often the background evaluator checks
+	// every user's active rules.
+	AlertEvaluationInterval = 15 * time.Minute
+
+	// DefaultAlertEventsLimit and MaxAlertEventsLimit bound GET /api/alerts/events.
+	DefaultAlertEventsLimit = 50
+	MaxAlertEventsLimit     = 200
+
+	// DefaultSMTPPort is used when SMTP_PORT is not set.
+	DefaultSMTPPort = "587"
+)
+
+// AlertKinds are the supported alert rule kinds.
+var AlertKinds = []string{"price_cross", "holding_pl", "fx_move", "drawdown"}
+
+// AlertChannels are the supported delivery channels.
+var AlertChannels = []string{"in_app", "email", "webhook"}
@@ -0,0 +1,18 @@
+package config
+
+// Target allocation and rebalancing configuration.
+const (
+	// CashAllocationKey is the target key for uninvested cash.
+	CashAllocationKey = "CASH"
+
+	// DefaultDriftBand is the absolute weight drift (0.05 = 5 percentage points)
+	// tolerated before a position is rebalanced.
+	DefaultDriftBand = "0.05"
+
+	// MaxAllocationTargets caps the number of entries in one target allocation.
+	MaxAllocationTargets = 50
+)
+
+// AllocationAssetTypes are the keys accepted by an asset_type allocation; they
+// match trades.asset_type.
+var AllocationAssetTypes = []string{"stock", "etf", "crypto"}
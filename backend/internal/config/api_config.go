@@ -0,0 +1,26 @@
+package config
+
+import "time"
+
+// Idempotency-Key defaults for retried write requests. A key is reserved for
+// IdempotencyKeyLease while its request runs, so a reservation left behind by a
+// crashed request stops blocking retries after a few minutes; completed
+// responses are kept for IdempotencyKeyTTL.
+const (
+	IdempotencyKeyHeader    = "Idempotency-Key"
+	IdempotencyKeyLease     = 5 * time.Minute
+	IdempotencyKeyTTL       = 24 * time.Hour
+	IdempotencyKeyMaxLength = 255
+)
+
+// Request ID propagation. A caller-supplied X-Request-ID is kept when it is a
+// short printable token; otherwise the API generates one.
+const (
+	RequestIDHeader    = "X-Request-ID"
+	RequestIDMaxLength = 128
+)
+
+// ProfileLocaleCacheTTL is how long a user's profile locale is cached for
+// picking the language of responses. Changes made through this instance take
+// effect at once; other instances pick them up within this time.
+const ProfileLocaleCacheTTL = time.Minute
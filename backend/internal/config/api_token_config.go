@@ -0,0 +1,86 @@
+package config
+
+import "time"
+
+// Personal API token configuration.
+const (
+	// APITokenPrefix starts every personal API token, which is how the auth
+	// middleware tells them apart from Supabase JWTs.
+	APITokenPrefix = "fintu_pat_"
+
+	// MaxAPITokens caps the number of unrevoked tokens per user.
+	MaxAPITokens = 20
+
+	// DefaultAPITokenExpiryDays and MaxAPITokenExpiryDays bound expires_in_days.
+	DefaultAPITokenExpiryDays = 90
+	MaxAPITokenExpiryDays     = 365
+
+	// APITokenLastUsedPrecision is how stale last_used_at may get before a
+	// request updates it, so busy scripts do not write on every call.
+	APITokenLastUsedPrecision = time.Minute
+)
+
+// API token scopes.
+const (
+	ScopeReadAccount        = "read:account"
+	ScopeReadPortfolio      = "read:portfolio"
+	ScopeWritePortfolio     = "write:portfolio"
+	ScopeWriteTrades        = "write:trades"
+	ScopeWriteCashFlows     = "write:cash_flows"
+	ScopeWriteMarketData    = "write:market_data"
+	ScopeWriteWebhooks      = "write:webhooks"
+	ScopeReadNotifications  = "read:notifications"
+	ScopeWriteNotifications = "write:notifications"
+)
+
+// APITokenScopes are the scopes a token can be granted.
+var APITokenScopes = []string{
+	ScopeReadAccount,
+	ScopeReadPortfolio,
+	ScopeWritePortfolio,
+	ScopeWriteTrades,
+	ScopeWriteCashFlows,
+	ScopeWriteMarketData,
+	ScopeWriteWebhooks,
+	ScopeReadNotifications,
+	ScopeWriteNotifications,
+}
+
+// APITokenScopeRule is the scope an API token needs for a route group: Read
+// for GET and HEAD, Write for every other method. An empty scope means API
+// tokens cannot use those routes at all.
+type APITokenScopeRule struct {
+	Prefix string
+	Read   string
+	Write  string
+}
+
+// APITokenScopeRules map /api route groups to scopes; /api/v2 paths match
+// without their version. The longest matching prefix wins and routes without
+// a rule are closed to API tokens, including token management itself.
+var APITokenScopeRules = []APITokenScopeRule{
+	{Prefix: "/api/me", Read: ScopeReadAccount},
+	{Prefix: "/api/plans", Read: ScopeReadAccount},
+	{Prefix: "/api/subscriptions", Read: ScopeReadAccount},
+	{Prefix: "/api/notifications", Read: ScopeReadNotifications, Write: ScopeWriteNotifications},
+	{Prefix: "/api/brokers", Read: ScopeReadPortfolio, Write: ScopeWritePortfolio},
+	{Prefix: "/api/broker-presets", Read: ScopeReadPortfolio},
+	{Prefix: "/api/fx-rates", Read: ScopeReadPortfolio, Write: ScopeWriteMarketData},
+	{Prefix: "/api/market-prices", Read: ScopeReadPortfolio, Write: ScopeWriteMarketData},
+	{Prefix: "/api/cash-flows", Read: ScopeReadPortfolio, Write: ScopeWriteCashFlows},
+	{Prefix: "/api/cash-flows/fee-quote", Read: ScopeReadPortfolio, Write: ScopeReadPortfolio},
+	{Prefix: "/api/trade-tickers", Read: ScopeReadPortfolio},
+	{Prefix: "/api/trades", Read: ScopeReadPortfolio, Write: ScopeWriteTrades},
+	{Prefix: "/api/portfolio", Read: ScopeReadPortfolio},
+	{Prefix: "/api/holdings", Read: ScopeReadPortfolio},
+	{Prefix: "/api/portfolios", Read: ScopeReadPortfolio, Write: ScopeWritePortfolio},
+	{Prefix: "/api/portfolios/transfers", Read: ScopeReadPortfolio, Write: ScopeWriteCashFlows},
+	{Prefix: "/api/analytics", Read: ScopeReadPortfolio},
+	{Prefix: "/api/benchmarks", Read: ScopeReadPortfolio, Write: ScopeWritePortfolio},
+	{Prefix: "/api/dca-plans", Read: ScopeReadPortfolio, Write: ScopeWritePortfolio},
+	{Prefix: "/api/dca-installments", Read: ScopeReadPortfolio, Write: ScopeWriteTrades},
+	{Prefix: "/api/goals", Read: ScopeReadPortfolio, Write: ScopeWritePortfolio},
+	{Prefix: "/api/alerts", Read: ScopeReadPortfolio, Write: ScopeWritePortfolio},
+	{Prefix: "/api/webhooks", Read: ScopeReadPortfolio, Write: ScopeWriteWebhooks},
+	{Prefix: "/api/activity", Read: ScopeReadPortfolio},
+}
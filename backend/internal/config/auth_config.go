@@ -0,0 +1,26 @@
+package config
+
+import "time"
+
+// Session JWT validation.
+const (
+	// JWKSCacheTTL is how long fetched signing keys are trusted before the
+	// JWKS is fetched again. JWKS_CACHE_TTL overrides it.
+	JWKSCacheTTL = time.Hour
+
+	// JWKSFetchTimeout bounds a single JWKS request.
+	JWKSFetchTimeout = 10 * time.Second
+
+	// JWKSMinRefreshInterval is the shortest gap between JWKS fetches, so
+	// tokens with unknown key IDs or an unreachable JWKS endpoint cannot make
+	// every request wait on a fetch.
+	JWKSMinRefreshInterval = 30 * time.Second
+
+	// JWTClockSkewLeeway is how far exp, nbf and iat may be off to allow for
+	// clock drift between Supabase and this server.
+	JWTClockSkewLeeway = 30 * time.Second
+
+	// DefaultJWTAudience is the aud claim Supabase puts in user session
+	// tokens. SUPABASE_JWT_AUDIENCE overrides it.
+	DefaultJWTAudience = "authenticated"
+)
@@ -0,0 +1,43 @@
+package config
+
+import "fintu-tracking-backend/internal/models"
+
+// BenchmarkPreset is a built-in benchmark users can compare their portfolio to.
+type BenchmarkPreset struct {
+	ID         string                      `json:"id"`
+	Name       string                      `json:"name"`
+	Components []models.BenchmarkComponent `json:"components"`
+}
+
+// DefaultBenchmarkID is used when a request does not pick any benchmark.
+const DefaultBenchmarkID = "spy"
+
+// MaxBenchmarksPerRequest caps how many benchmarks one chart or comparison loads.
+const MaxBenchmarksPerRequest = 5
+
+// BuiltInBenchmarkPresets are the benchmarks offered without any setup. GXG
+// (Global X MSCI Colombia) stands in for COLCAP, which has no USD-listed tracker.
+var BuiltInBenchmarkPresets = []BenchmarkPreset{
+	{ID: "spy", Name: "S&P 500 (SPY)", Components: []models.BenchmarkComponent{{Ticker: "SPY", Weight: "1"}}},
+	{ID: "qqq", Name: "Nasdaq 100 (QQQ)", Components: []models.BenchmarkComponent{{Ticker: "QQQ", Weight: "1"}}},
+	{ID: "vt", Name: "Total World (VT)", Components: []models.BenchmarkComponent{{Ticker: "VT", Weight: "1"}}},
+	{ID: "colcap", Name: "COLCAP proxy (GXG)", Components: []models.BenchmarkComponent{{Ticker: "GXG", Weight: "1"}}},
+	{
+		ID:   "60-40",
+		Name: "60/40 stocks/bonds",
+		Components: []models.BenchmarkComponent{
+			{Ticker: "VT", Weight: "0.6"},
+			{Ticker: "BND", Weight: "0.4"},
+		},
+	},
+}
+
+// GetBenchmarkPreset returns a built-in benchmark by ID, or nil if unknown.
+func GetBenchmarkPreset(id string) *BenchmarkPreset {
+	for i := range BuiltInBenchmarkPresets {
+		if BuiltInBenchmarkPresets[i].ID == id {
+			return &BuiltInBenchmarkPresets[i]
+		}
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ type BrokerFeeType string
 const (
 	BrokerFeeTypePercentage BrokerFeeType = "percentage"
 	BrokerFeeTypeFlat       BrokerFeeType = "flat"
+	BrokerFeeTypeTiered     BrokerFeeType = "tiered"
 	BrokerFeeTypeNone       BrokerFeeType = "none"
 )
 
@@ -0,0 +1,24 @@
+package config
+
+import "time"
+
+// Recurring contribution (DCA) plan configuration.
+const (
+	// MaxDCATargets caps the number of tickers in one DCA plan.
+	MaxDCATargets = 20
+
+	// MaxDCACatchUpInstallments caps how many past-due installments one
+	// scheduler run generates for a plan, e.g. after a backdated start date.
+	MaxDCACatchUpInstallments = 120
+
+	// DCASchedulerInterval is how often the background scheduler generates
+	// due installments.
+	DCASchedulerInterval = time.Hour
+)
+
+// DCAFrequencies are the supported contribution frequencies.
+var DCAFrequencies = []string{"weekly", "biweekly", "monthly"}
+
+// DCAAssetTypes are the asset types a DCA plan can buy; they match the trade
+// types accepted by POST /api/trades.
+var DCAAssetTypes = []string{"stock", "etf"}
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Server lifecycle defaults.
+const (
+	// DefaultPort is the HTTP port when PORT is unset.
+	DefaultPort = "8080"
+
+	// DefaultShutdownTimeout bounds draining in-flight requests and
+	// background jobs after SIGTERM. SHUTDOWN_TIMEOUT overrides it.
+	DefaultShutdownTimeout = 30 * time.Second
+
+	// ReadinessCheckTimeout bounds the database checks behind /readyz.
+	ReadinessCheckTimeout = 2 * time.Second
+)
+
+// Config is the process configuration read from the environment by Load.
+type Config struct {
+	Port            string
+	FrontendURL     string
+	DatabaseURL     string
+	ShutdownTimeout time.Duration
+
+	Auth AuthSettings
+	SMTP SMTPSettings
+
+	// TwelveDataAPIKey enables market price and exchange rate refreshes.
+	// Without it the API runs, but provider calls fail and /readyz reports
+	// market data as disabled.
+	TwelveDataAPIKey string
+
+	// MetricsToken, when set, is the bearer token required for /metrics.
+	MetricsToken string
+
+	LogLevel  string
+	LogFormat string
+}
+
+// AuthSettings configures session JWT validation.
+type AuthSettings struct {
+	// SupabaseURL enables asymmetric JWTs verified against the project JWKS.
+	SupabaseURL string
+	// JWTSecret enables HS256 JWTs.
+	JWTSecret string
+	// Audience and Issuer are the required aud and iss claims; empty skips
+	// the check.
+	Audience     string
+	Issuer       string
+	JWKSCacheTTL time.Duration
+}
+
+// SMTPSettings configures email alert delivery. Without Host every email
+// delivery is skipped.
+type SMTPSettings struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+var (
+	logLevels  = []string{"", "debug", "info", "warn", "warning", "error"}
+	logFormats = []string{"", "json", "text"}
+)
+
+// Load reads and validates the configuration from the environment. It
+// reports every invalid variable at once so a misconfigured deploy fails on
+// startup rather than on the first request that needs the value.
+func Load() (*Config, error) {
+	return load(os.LookupEnv)
+}
+
+func load(lookup func(string) (string, bool)) (*Config, error) {
+	get := func(key string) string {
+		value, _ := lookup(key)
+		return strings.TrimSpace(value)
+	}
+	var errs []error
+	invalid := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	cfg := &Config{
+		Port:             get("PORT"),
+		FrontendURL:      get("FRONTEND_URL"),
+		DatabaseURL:      get("DATABASE_URL"),
+		ShutdownTimeout:  DefaultShutdownTimeout,
+		TwelveDataAPIKey: get("TWELVE_DATA_API_KEY"),
+		MetricsToken:     get("METRICS_TOKEN"),
+		LogLevel:         strings.ToLower(get("LOG_LEVEL")),
+		LogFormat:        strings.ToLower(get("LOG_FORMAT")),
+		Auth: AuthSettings{
+			SupabaseURL:  strings.TrimSuffix(get("SUPABASE_URL"), "/"),
+			JWTSecret:    get("SUPABASE_JWT_SECRET"),
+			Audience:     DefaultJWTAudience,
+			JWKSCacheTTL: JWKSCacheTTL,
+		},
+		SMTP: SMTPSettings{
+			Host:     get("SMTP_HOST"),
+			Port:     get("SMTP_PORT"),
+			Username: get("SMTP_USERNAME"),
+			Password: get("SMTP_PASSWORD"),
+			From:     get("SMTP_FROM"),
+		},
+	}
+
+	if cfg.Port == "" {
+		cfg.Port = DefaultPort
+	} else if !validPort(cfg.Port) {
+		invalid("PORT", "must be a number between 1 and 65535, got %q", cfg.Port)
+	}
+	if cfg.DatabaseURL == "" {
+		invalid("DATABASE_URL", "is required")
+	}
+	if cfg.FrontendURL != "" && !validHTTPURL(cfg.FrontendURL) {
+		invalid("FRONTEND_URL", "must be an http(s) URL, got %q", cfg.FrontendURL)
+	}
+	if raw := get("SHUTDOWN_TIMEOUT"); raw != "" {
+		if d, err := time.ParseDuration(raw); err != nil || d <= 0 {
+			invalid("SHUTDOWN_TIMEOUT", "must be a positive duration such as 30s, got %q", raw)
+		} else {
+			cfg.ShutdownTimeout = d
+		}
+	}
+
+	// Auth: at least one way to verify session tokens, or every request
+	// would be rejected.
+	if cfg.Auth.SupabaseURL == "" && cfg.Auth.JWTSecret == "" {
+		invalid("SUPABASE_URL", "SUPABASE_URL or SUPABASE_JWT_SECRET is required")
+	}
+	if cfg.Auth.SupabaseURL != "" {
+		if !validHTTPURL(cfg.Auth.SupabaseURL) {
+			invalid("SUPABASE_URL", "must be an http(s) URL, got %q", cfg.Auth.SupabaseURL)
+		}
+		cfg.Auth.Issuer = cfg.Auth.SupabaseURL + "/auth/v1"
+	}
+	// An explicitly empty audience or issuer disables that check.
+	if aud, ok := lookup("SUPABASE_JWT_AUDIENCE"); ok {
+		cfg.Auth.Audience = strings.TrimSpace(aud)
+	}
+	if iss, ok := lookup("SUPABASE_JWT_ISSUER"); ok {
+		cfg.Auth.Issuer = strings.TrimSpace(iss)
+	}
+	if raw := get("JWKS_CACHE_TTL"); raw != "" {
+		if d, err := time.ParseDuration(raw); err != nil || d <= 0 {
+			invalid("JWKS_CACHE_TTL", "must be a positive duration such as 30m, got %q", raw)
+		} else {
+			cfg.Auth.JWKSCacheTTL = d
+		}
+	}
+
+	if cfg.SMTP.Port == "" {
+		cfg.SMTP.Port = DefaultSMTPPort
+	} else if !validPort(cfg.SMTP.Port) {
+		invalid("SMTP_PORT", "must be a number between 1 and 65535, got %q", cfg.SMTP.Port)
+	}
+	if cfg.SMTP.Host != "" && cfg.SMTP.From == "" {
+		invalid("SMTP_FROM", "is required when SMTP_HOST is set")
+	}
+
+	if !slices.Contains(logLevels, cfg.LogLevel) {
+		invalid("LOG_LEVEL", "must be debug, info, warn or error, got %q", cfg.LogLevel)
+	}
+	if !slices.Contains(logFormats, cfg.LogFormat) {
+		invalid("LOG_FORMAT", "must be json or text, got %q", cfg.LogFormat)
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return cfg, nil
+}
+
+func validPort(raw string) bool {
+	port, err := strconv.Atoi(raw)
+	return err == nil && port >= 1 && port <= 65535
+}
+
+func validHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
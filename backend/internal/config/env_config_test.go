@@ -0,0 +1,117 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func lookupFrom(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := load(lookupFrom(map[string]string{
+		"DATABASE_URL": "postgres://localhost/fintu",
+		"SUPABASE_URL": "https://abc.supabase.co/",
+	}))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Port != DefaultPort {
+		t.Errorf("Port = %q, want %q", cfg.Port, DefaultPort)
+	}
+	if cfg.ShutdownTimeout != DefaultShutdownTimeout {
+		t.Errorf("ShutdownTimeout = %v, want %v", cfg.ShutdownTimeout, DefaultShutdownTimeout)
+	}
+	if cfg.Auth.SupabaseURL != "https://abc.supabase.co" {
+		t.Errorf("SupabaseURL = %q, want the trailing slash trimmed", cfg.Auth.SupabaseURL)
+	}
+	if cfg.Auth.Issuer != "https://abc.supabase.co/auth/v1" {
+		t.Errorf("Issuer = %q", cfg.Auth.Issuer)
+	}
+	if cfg.Auth.Audience != DefaultJWTAudience {
+		t.Errorf("Audience = %q, want %q", cfg.Auth.Audience, DefaultJWTAudience)
+	}
+	if cfg.Auth.JWKSCacheTTL != JWKSCacheTTL {
+		t.Errorf("JWKSCacheTTL = %v, want %v", cfg.Auth.JWKSCacheTTL, JWKSCacheTTL)
+	}
+	if cfg.SMTP.Port != DefaultSMTPPort {
+		t.Errorf("SMTP.Port = %q, want %q", cfg.SMTP.Port, DefaultSMTPPort)
+	}
+}
+
+func TestLoad_Overrides(t *testing.T) {
+	cfg, err := load(lookupFrom(map[string]string{
+		"PORT":                  "9090",
+		"DATABASE_URL":          "postgres://localhost/fintu",
+		"SUPABASE_JWT_SECRET":   "secret",
+		"SUPABASE_JWT_AUDIENCE": "",
+		"JWKS_CACHE_TTL":        "15m",
+		"SHUTDOWN_TIMEOUT":      "10s",
+		"TWELVE_DATA_API_KEY":   "td-key",
+		"LOG_LEVEL":             "DEBUG",
+		"LOG_FORMAT":            "text",
+	}))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Port != "9090" {
+		t.Errorf("Port = %q, want 9090", cfg.Port)
+	}
+	if cfg.Auth.Audience != "" {
+		t.Errorf("Audience = %q, want empty to skip the check", cfg.Auth.Audience)
+	}
+	if cfg.Auth.Issuer != "" {
+		t.Errorf("Issuer = %q, want empty without SUPABASE_URL", cfg.Auth.Issuer)
+	}
+	if cfg.Auth.JWKSCacheTTL != 15*time.Minute {
+		t.Errorf("JWKSCacheTTL = %v, want 15m", cfg.Auth.JWKSCacheTTL)
+	}
+	if cfg.ShutdownTimeout != 10*time.Second {
+		t.Errorf("ShutdownTimeout = %v, want 10s", cfg.ShutdownTimeout)
+	}
+	if cfg.TwelveDataAPIKey != "td-key" {
+		t.Errorf("TwelveDataAPIKey = %q", cfg.TwelveDataAPIKey)
+	}
+	if cfg.LogLevel != "debug" || cfg.LogFormat != "text" {
+		t.Errorf("LogLevel, LogFormat = %q, %q", cfg.LogLevel, cfg.LogFormat)
+	}
+}
+
+func TestLoad_ReportsEveryInvalidVariable(t *testing.T) {
+	_, err := load(lookupFrom(map[string]string{
+		"PORT":             "http",
+		"FRONTEND_URL":     "localhost:3000",
+		"SUPABASE_URL":     "abc.supabase.co",
+		"JWKS_CACHE_TTL":   "-1m",
+		"SHUTDOWN_TIMEOUT": "soon",
+		"SMTP_HOST":        "smtp.example.com",
+		"SMTP_PORT":        "70000",
+		"LOG_LEVEL":        "verbose",
+		"LOG_FORMAT":       "xml",
+	}))
+	if err == nil {
+		t.Fatal("load succeeded, want an error")
+	}
+	for _, key := range []string{
+		"PORT", "DATABASE_URL", "FRONTEND_URL", "SUPABASE_URL", "JWKS_CACHE_TTL",
+		"SHUTDOWN_TIMEOUT", "SMTP_PORT", "SMTP_FROM", "LOG_LEVEL", "LOG_FORMAT",
+	} {
+		if !strings.Contains(err.Error(), key+":") {
+			t.Errorf("error does not mention %s:\n%v", key, err)
+		}
+	}
+}
+
+func TestLoad_RequiresAnAuthMethod(t *testing.T) {
+	_, err := load(lookupFrom(map[string]string{
+		"DATABASE_URL": "postgres://localhost/fintu",
+	}))
+	if err == nil || !strings.Contains(err.Error(), "SUPABASE_JWT_SECRET") {
+		t.Fatalf("err = %v, want an auth configuration error", err)
+	}
+}
@@ -0,0 +1,11 @@
+package config
+
+// Exposure analytics configuration.
+const (
+	// DefaultConcentrationThresholdPct is the share of holdings value (in percent)
+	// above which a single name triggers a concentration warning.
+	DefaultConcentrationThresholdPct = "20"
+
+	// UnclassifiedExposure labels value whose symbol metadata lacks a dimension.
+	UnclassifiedExposure = "Unclassified"
+)
@@ -0,0 +1,23 @@
+package config
+
+// Goal projection configuration. Projections run offline from the user's own
+// data; these defaults apply when the portfolio has too little price history.
+const (
+	// DefaultGoalSimulations is the number of Monte Carlo paths per projection.
+	DefaultGoalSimulations = 2000
+
+	// MaxGoalSimulations caps ?simulations= on the projection endpoint.
+	MaxGoalSimulations = 10000
+
+	// MinGoalReturnObservations is the number of daily portfolio returns (one
+	// trading year) needed before historical returns replace the defaults.
+	MinGoalReturnObservations = TradingDaysPerYear
+
+	// DefaultGoalAnnualReturn and DefaultGoalAnnualVolatility are long-run
+	// equity assumptions, as decimal fractions.
+	DefaultGoalAnnualReturn     = "0.07"
+	DefaultGoalAnnualVolatility = "0.15"
+
+	// MaxGoalHorizonYears caps how far out a goal's target date can be.
+	MaxGoalHorizonYears = 60
+)
@@ -28,5 +28,14 @@ const (
 	MaxFXRateDays         = 90
 )
 
+// Risk analytics defaults.
+const (
+	DefaultBenchmarkTicker = "SPY"
+	DefaultRiskFreeRate    = "0.04" // annual, as a decimal fraction
+	DefaultVaRConfidence   = "0.95"
+	TradingDaysPerYear     = 252
+	DailyPriceHistoryDays  = 730
+)
+
 // SupportedCurrencyPairs are the currency pairs the current FX handlers support.
 var SupportedCurrencyPairs = []string{DefaultCurrencyPair, InverseCurrencyPair}
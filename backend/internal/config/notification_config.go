@@ -0,0 +1,29 @@
+package config
+
+import "time"
+
+// Notification inbox configuration.
+const (
+	// NotificationStreamHeartbeat is how often the SSE stream sends a comment
+	// line so proxies keep idle connections open.
+	NotificationStreamHeartbeat = 25 * time.Second
+
+	// NotificationStreamBuffer is how many notifications a slow SSE client
+	// can fall behind before new ones are dropped from its stream; they stay
+	// in the inbox.
+	NotificationStreamBuffer = 16
+
+	// NotificationChannel is the Postgres LISTEN/NOTIFY channel that
+	// announces new notifications to every API instance.
+	NotificationChannel = "notifications"
+
+	// NotificationListenRetry is how long the listener waits before
+	// reconnecting after it loses its database connection.
+	NotificationListenRetry = 5 * time.Second
+
+	// Notification types.
+	NotificationTypeAlert              = "alert"
+	NotificationTypePriceRefreshFailed = "price_refresh_failed"
+	NotificationTypeReconciliation     = "reconciliation_issues"
+	NotificationTypeSubscriptionEnding = "subscription_ending"
+)
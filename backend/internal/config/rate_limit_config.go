@@ -0,0 +1,61 @@
+package config
+
+import "time"
+
+// Rate limit route groups. Each user has one token bucket per group.
+const (
+	RateLimitGroupDefault    = "default"
+	RateLimitGroupAnalytics  = "analytics"
+	RateLimitGroupMarketData = "market_data"
+)
+
+// RateLimitQuota is a token bucket: up to Burst requests at once, refilled at
+// PerMinute requests per minute.
+type RateLimitQuota struct {
+	Burst     int     `json:"burst"`
+	PerMinute float64 `json:"per_minute"`
+}
+
+// DefaultRateLimits apply when the user's plan has no "rate_limits" entry in
+// plans.features for a group. Plans override them per group, for example
+// {"rate_limits": {"analytics": {"burst": 60, "per_minute": 120}}}.
+var DefaultRateLimits = map[string]RateLimitQuota{
+	RateLimitGroupDefault:    {Burst: 120, PerMinute: 300},
+	RateLimitGroupAnalytics:  {Burst: 20, PerMinute: 30},
+	RateLimitGroupMarketData: {Burst: 3, PerMinute: 2},
+}
+
+// RateLimitRule assigns the routes under Prefix to a rate limit group.
+type RateLimitRule struct {
+	Prefix string
+	Group  string
+}
+
+// RateLimitRules map /api route prefixes to groups; the longest matching
+// prefix wins and everything else is RateLimitGroupDefault. /api/v2 paths
+// match without their version. Analytics covers the endpoints that scan a
+// user's full trade and cash flow history.
+var RateLimitRules = []RateLimitRule{
+	{Prefix: "/api/analytics", Group: RateLimitGroupAnalytics},
+	{Prefix: "/api/portfolio", Group: RateLimitGroupAnalytics},
+	{Prefix: "/api/holdings", Group: RateLimitGroupAnalytics},
+	{Prefix: "/api/portfolios/consolidated", Group: RateLimitGroupAnalytics},
+	{Prefix: "/api/activity", Group: RateLimitGroupAnalytics},
+	{Prefix: "/api/market-prices/refresh", Group: RateLimitGroupMarketData},
+	{Prefix: "/api/market-prices/history/refresh", Group: RateLimitGroupMarketData},
+	{Prefix: "/api/alerts/evaluate", Group: RateLimitGroupMarketData},
+}
+
+const (
+	// RateLimitPlanCacheTTL is how long a user's plan quotas are cached, so
+	// a plan change takes effect within this time.
+	RateLimitPlanCacheTTL = time.Minute
+
+	// RateLimitBucketTTL is how long an idle bucket is kept. It must be longer
+	// than the slowest bucket takes to refill, after which a new bucket is
+	// equivalent.
+	RateLimitBucketTTL = time.Hour
+
+	// RateLimitPruneInterval is how often idle buckets are deleted.
+	RateLimitPruneInterval = 15 * time.Minute
+)
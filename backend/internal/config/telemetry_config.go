@@ -0,0 +1,24 @@
+package config
+
+import "time"
+
+// Metrics and tracing configuration.
+const (
+	// TelemetryServiceName is the service.name of exported traces.
+	TelemetryServiceName = "fintu-tracking-api"
+
+	// MetricsPath serves Prometheus metrics. METRICS_TOKEN, when set, must be
+	// sent as a bearer token to read it.
+	MetricsPath = "/metrics"
+
+	// SlowQueryThreshold is the duration above which a database query is
+	// logged with its statement.
+	SlowQueryThreshold = 500 * time.Millisecond
+
+	// TracedStatementMaxLength truncates SQL recorded on spans and in slow
+	// query logs.
+	TracedStatementMaxLength = 1000
+
+	// TelemetryShutdownTimeout bounds flushing buffered spans on exit.
+	TelemetryShutdownTimeout = 5 * time.Second
+)
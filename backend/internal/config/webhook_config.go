@@ -0,0 +1,71 @@
+package config
+
+import "time"
+
+// Outbound webhook configuration.
+const (
+	// MaxWebhookEndpoints caps the number of webhook endpoints per user.
+	MaxWebhookEndpoints = 10
+
+	// WebhookTimeout bounds a single delivery attempt.
+	WebhookTimeout = 10 * time.Second
+
+	// WebhookMaxAttempts is how many times an event is sent before its
+	// delivery is marked failed. Pings are sent once.
+	WebhookMaxAttempts = 8
+
+	// WebhookRetryBaseDelay doubles after every failed attempt up to
+	// WebhookRetryMaxDelay: 30s, 1m, 2m, ... 32m, about an hour in total.
+	WebhookRetryBaseDelay = 30 * time.Second
+	WebhookRetryMaxDelay  = time.Hour
+
+	// WebhookDispatchInterval is how often the dispatcher looks for due
+	// retries; new events are sent right away.
+	WebhookDispatchInterval = 15 * time.Second
+
+	// WebhookDispatchBatch is how many due deliveries one dispatcher pass
+	// claims, and WebhookClaimLease how long they stay claimed. The lease
+	// must outlast a batch of timed-out attempts so other API instances do
+	// not send them twice.
+	WebhookDispatchBatch = 10
+	WebhookClaimLease    = 5 * time.Minute
+
+	// DefaultWebhookDeliveriesLimit and MaxWebhookDeliveriesLimit bound
+	// GET /api/webhooks/:id/deliveries.
+	DefaultWebhookDeliveriesLimit = 50
+	MaxWebhookDeliveriesLimit     = 200
+
+	// WebhookSignatureHeader carries "t=<unix seconds>,v1=<hex HMAC-SHA256>"
+	// over "<t>.<body>" with the endpoint secret.
+	WebhookSignatureHeader = "X-Fintu-Signature"
+)
+
+// Webhook event types.
+const (
+	WebhookEventTradeCreated        = "trade.created"
+	WebhookEventTradeUpdated        = "trade.updated"
+	WebhookEventTradeDeleted        = "trade.deleted"
+	WebhookEventCashFlowCreated     = "cash_flow.created"
+	WebhookEventCashFlowUpdated     = "cash_flow.updated"
+	WebhookEventCashFlowDeleted     = "cash_flow.deleted"
+	WebhookEventPriceRefreshed      = "price.refreshed"
+	WebhookEventSubscriptionChanged = "subscription.changed"
+	WebhookEventAlertTriggered      = "alert.triggered"
+
+	// WebhookEventPing is sent by POST /api/webhooks/:id/ping only.
+	WebhookEventPing = "ping"
+)
+
+// WebhookEvents are the events endpoints can subscribe to. Subscriptions may
+// also use a "<resource>.*" wildcard such as "cash_flow.*".
+var WebhookEvents = []string{
+	WebhookEventTradeCreated,
+	WebhookEventTradeUpdated,
+	WebhookEventTradeDeleted,
+	WebhookEventCashFlowCreated,
+	WebhookEventCashFlowUpdated,
+	WebhookEventCashFlowDeleted,
+	WebhookEventPriceRefreshed,
+	WebhookEventSubscriptionChanged,
+	WebhookEventAlertTriggered,
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"os"
+	"log/slog"
+
+	"fintu-tracking-backend/internal/telemetry"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -14,10 +16,9 @@ import (
 var pool *pgxpool.Pool
 
 // Connect initializes the database connection pool
-func Connect() error {
-	dbURL := os.Getenv("DATABASE_URL")
+func Connect(dbURL string) error {
 	if dbURL == "" {
-		return fmt.Errorf("DATABASE_URL environment variable not set")
+		return fmt.Errorf("database URL is empty")
 	}
 
 	config, err := pgxpool.ParseConfig(dbURL)
@@ -33,6 +34,9 @@ func Connect() error {
 	// This prevents "prepared statement already exists" errors
 	config.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
 
+	// Trace every query and record its latency
+	config.ConnConfig.Tracer = telemetry.QueryTracer{}
+
 	pool, err = pgxpool.NewWithConfig(context.Background(), config)
 	if err != nil {
 		return fmt.Errorf("failed to create connection pool: %w", err)
@@ -43,7 +47,9 @@ func Connect() error {
 		return fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	fmt.Println("✅ Database connected successfully")
+	telemetry.RegisterPoolMetrics(pool)
+
+	slog.Info("database connected")
 	return nil
 }
 
@@ -63,10 +69,9 @@ func Close() {
 // the runtime pool but using pgx/stdlib. This is the connection golang-migrate
 // uses; it disables prepared statements to stay compatible with the Supabase
 // transaction pooler.
-func OpenMigrationDB() (*sql.DB, error) {
-	dbURL := os.Getenv("DATABASE_URL")
+func OpenMigrationDB(dbURL string) (*sql.DB, error) {
 	if dbURL == "" {
-		return nil, fmt.Errorf("DATABASE_URL environment variable not set")
+		return nil, fmt.Errorf("database URL is empty")
 	}
 
 	config, err := pgx.ParseConfig(dbURL)
@@ -1,23 +1,29 @@
 package handlers
 
 import (
-	"fmt"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"fintu-tracking-backend/internal/apperror"
 	"fintu-tracking-backend/internal/config"
 	"fintu-tracking-backend/internal/database"
+	"fintu-tracking-backend/internal/i18n"
 	"fintu-tracking-backend/internal/middleware"
 	"fintu-tracking-backend/internal/models"
-	"strconv"
 
 	"github.com/gofiber/fiber/v3"
+	"github.com/shopspring/decimal"
 )
 
 // GetActivityFeed handles GET /api/activity/feed
 // Returns a unified feed of recent trades and cash flows, ordered by date DESC.
-// Query param: limit (default 8, max 20).
+// Query param: limit (default 8, max 20). GET /api/v2/activity (ListActivity)
+// is the full, paginated timeline.
 func GetActivityFeed(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
 	}
 
 	limit := 8
@@ -27,8 +33,9 @@ func GetActivityFeed(c fiber.Ctx) error {
 		}
 	}
 
-	query := fmt.Sprintf(`
-		SELECT id, date, kind, sub_kind, ticker, direction, amount_usd, details
+	query := `
+		SELECT id, date, kind, sub_kind, ticker, direction, amount_usd,
+			quantity, price, currency, amount, usd_amount
 		FROM (
 			(SELECT
 				id,
@@ -38,10 +45,14 @@ func GetActivityFeed(c fiber.Ctx) error {
 				ticker,
 				CASE WHEN side = 'buy' THEN 'out' ELSE 'in' END AS direction,
 				ABS(total)::text AS amount_usd,
-				side || ' ' || quantity || ' ' || ticker || ' @ $' || price AS details
+				quantity::text AS quantity,
+				price::text AS price,
+				'' AS currency,
+				'' AS amount,
+				'' AS usd_amount
 			FROM trades
 			WHERE user_id = $1
-			ORDER BY date DESC
+			ORDER BY date DESC, id DESC
 			LIMIT $2)
 
 			UNION ALL
@@ -49,47 +60,218 @@ func GetActivityFeed(c fiber.Ctx) error {
 			(SELECT
 				id,
 				date,
-				CASE
-					WHEN type = 'fee' AND related_type = 'trade' THEN 'fee'
-					ELSE type
-				END AS kind,
+				type AS kind,
 				COALESCE(fee_type, '') AS sub_kind,
 				'' AS ticker,
 				CASE
-					WHEN type = 'deposit' OR type = 'cash_adjustment' THEN 'in'
+					WHEN type IN ('deposit', 'cash_adjustment', 'transfer_in') THEN 'in'
 					ELSE 'out'
 				END AS direction,
 				ABS(usd_amount)::text AS amount_usd,
-				CASE
-					WHEN type = 'deposit' THEN 'Deposit: %s ' || amount
-					WHEN type = 'withdrawal' THEN 'Withdrawal: %s ' || amount
-					WHEN type = 'cash_adjustment' THEN 'Cash adjustment: $' || usd_amount
-					WHEN type = 'fee' THEN 'Fee (' || COALESCE(fee_type, 'other') || '): $' || usd_amount
-					ELSE type || ': $' || usd_amount
-				END AS details
+				'' AS quantity,
+				'' AS price,
+				currency,
+				amount::text,
+				usd_amount::text
 			FROM cash_flows
 			WHERE user_id = $1
-			ORDER BY date DESC
+			ORDER BY date DESC, id DESC
 			LIMIT $2)
 		) AS feed
-		ORDER BY date DESC
+		ORDER BY date DESC, id DESC
 		LIMIT $2
-	`, config.LocalCurrency, config.LocalCurrency)
+	`
 
 	rows, err := database.GetPool().Query(c.Context(), query, userID, limit)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 	defer rows.Close()
 
 	items := make([]models.ActivityItem, 0, limit)
 	for rows.Next() {
 		var item models.ActivityItem
-		if err := rows.Scan(&item.ID, &item.Date, &item.Kind, &item.SubKind, &item.Ticker, &item.Direction, &item.AmountUSD, &item.Details); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		var row activityFeedRow
+		if err := rows.Scan(&item.ID, &item.Date, &item.Kind, &item.SubKind, &item.Ticker, &item.Direction, &item.AmountUSD,
+			&row.quantity, &row.price, &row.currency, &row.amount, &row.usdAmount); err != nil {
+			return err
 		}
+		item.Details = row.details(middleware.GetLocale(c), item)
 		items = append(items, item)
 	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
 
 	return c.JSON(items)
 }
+
+// activityFeedRow holds the columns the v1 feed summarizes in Details.
+type activityFeedRow struct {
+	quantity, price  string
+	currency, amount string
+	usdAmount        string
+}
+
+// details is the one-line summary of a v1 feed item in the request's locale,
+// for example "buy 2 VOO @ $400.50" or "Depósito: $1.000.000".
+func (r activityFeedRow) details(loc i18n.Locale, item models.ActivityItem) string {
+	usd := loc.MoneyString(config.BaseCurrency, r.usdAmount)
+	switch item.Kind {
+	case "trade":
+		quantity := r.quantity
+		if q, err := decimal.NewFromString(quantity); err == nil {
+			quantity = loc.Number(q)
+		}
+		format := "buy %s %s @ %s"
+		if item.SubKind == "sell" {
+			format = "sell %s %s @ %s"
+		}
+		return loc.Sprintf(format, quantity, item.Ticker, loc.MoneyString(config.BaseCurrency, r.price))
+	case "deposit":
+		return loc.Sprintf("Deposit: %s", loc.MoneyString(r.currency, r.amount))
+	case "withdrawal":
+		return loc.Sprintf("Withdrawal: %s", loc.MoneyString(r.currency, r.amount))
+	case "cash_adjustment":
+		return loc.Sprintf("Cash adjustment: %s", usd)
+	case "fee":
+		feeType := item.SubKind
+		if feeType == "" {
+			feeType = "other"
+		}
+		return loc.Sprintf("Fee (%s): %s", loc.Translate(feeType), usd)
+	case "transfer_in":
+		return loc.Sprintf("Transfer in: %s", usd)
+	case "transfer_out":
+		return loc.Sprintf("Transfer out: %s", usd)
+	default:
+		return loc.Translate(item.Kind) + ": " + usd
+	}
+}
+
+// ListActivity handles GET /api/v2/activity, the activity timeline newest
+// first. ?kind= (comma-separated), ?ticker=, ?broker_id=, ?portfolio_id=,
+// ?from= and ?to= filter it; pages are keyed like trade lists.
+func ListActivity(c fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
+	}
+
+	filters, err := parseActivityListFilters(
+		c.Query("kind"),
+		c.Query("ticker"),
+		c.Query("broker_id"),
+		c.Query("from"),
+		c.Query("to"),
+	)
+	if err != nil {
+		return apperror.Classify(apperror.Validation, err)
+	}
+	filters.portfolio, err = resolvePortfolioScope(c, userID)
+	if err != nil {
+		return err
+	}
+	page, err := keysetPageFromQuery(c, listSort{})
+	if err != nil {
+		return err
+	}
+
+	query, args := buildListActivityQuery(userID, filters, page.order, page.limit+1)
+	rows, err := database.GetPool().Query(c.Context(), query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	entries := make([]models.ActivityEntry, 0, page.limit+1)
+	for rows.Next() {
+		entry, err := scanActivityEntry(rows)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return c.JSON(keysetPageOf(entries, page, func(e models.ActivityEntry) []string {
+		return listSort{}.cursorKey("", e.Date, e.CreatedAt, e.ID)
+	}))
+}
+
+type activityEntryScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanActivityEntry scans a row of buildListActivityQuery.
+func scanActivityEntry(row activityEntryScanner) (models.ActivityEntry, error) {
+	var entry models.ActivityEntry
+	var ticker, side, assetType, quantity, price *string
+	var currency, amount, usdAmount *string
+	var fxRate, feeType *string
+	var fees []byte
+	if err := row.Scan(&entry.ID, &entry.Date, &entry.CreatedAt, &entry.Kind, &entry.PortfolioID, &entry.BrokerID,
+		&ticker, &entry.AmountUSD, &side, &assetType, &quantity, &price,
+		&currency, &amount, &usdAmount, &fxRate, &feeType, &fees); err != nil {
+		return entry, err
+	}
+	if err := json.Unmarshal(fees, &entry.Fees); err != nil {
+		return entry, err
+	}
+
+	if entry.Kind == "trade" {
+		entry.Trade = &models.ActivityTrade{
+			Side:      deref(side),
+			Ticker:    deref(ticker),
+			AssetType: deref(assetType),
+			Quantity:  deref(quantity),
+			Price:     deref(price),
+		}
+	} else {
+		entry.CashFlow = &models.ActivityCashFlow{
+			Currency:  deref(currency),
+			Amount:    deref(amount),
+			UsdAmount: deref(usdAmount),
+			FxRate:    fxRate,
+			FeeType:   feeType,
+		}
+	}
+	entry.Direction = activityDirection(entry)
+	entry.FeesUSD = sumActivityFees(entry.Fees)
+	return entry, nil
+}
+
+// activityDirection tells whether an entry brought cash into the account.
+func activityDirection(entry models.ActivityEntry) string {
+	switch {
+	case entry.Trade != nil && entry.Trade.Side == "sell":
+		return "in"
+	case entry.Kind == "deposit", entry.Kind == "transfer_in":
+		return "in"
+	case entry.Kind == "cash_adjustment" && entry.CashFlow != nil && !strings.HasPrefix(entry.CashFlow.UsdAmount, "-"):
+		return "in"
+	default:
+		return "out"
+	}
+}
+
+func sumActivityFees(fees []models.ActivityFee) string {
+	total := decimal.Zero
+	for _, fee := range fees {
+		amount, err := decimal.NewFromString(fee.AmountUSD)
+		if err != nil {
+			continue
+		}
+		total = total.Add(amount)
+	}
+	return total.StringFixed(2)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"fintu-tracking-backend/internal/apperror"
+)
+
+// activityKinds are the entry kinds of the activity timeline.
+var activityKinds = []string{"trade", "deposit", "withdrawal", "fee", "cash_adjustment", "transfer_in", "transfer_out"}
+
+type activityListFilters struct {
+	kinds     []string
+	ticker    string
+	broker    string
+	portfolio string
+	from      *time.Time
+	to        *time.Time
+}
+
+// activityEntriesSQL lists trades and cash flows as timeline entries. Fees
+// recorded against a trade or a deposit/withdrawal are left out; they are
+// attached to their parent entry by activityFeesSQL.
+const activityEntriesSQL = `
+	SELECT id, user_id, date, created_at, 'trade' AS kind, portfolio_id, broker_id, ticker,
+		ABS(total) AS amount_usd, side, asset_type, quantity, price,
+		NULL::text AS currency, NULL::numeric AS amount, NULL::numeric AS usd_amount,
+		NULL::numeric AS fx_rate, NULL::text AS fee_type
+	FROM trades
+	UNION ALL
+	SELECT id, user_id, date, created_at, type, portfolio_id, broker_id, NULL,
+		ABS(usd_amount), NULL, NULL, NULL, NULL,
+		currency, amount, usd_amount, fx_rate, fee_type
+	FROM cash_flows
+	WHERE NOT (type = 'fee' AND (related_trade_id IS NOT NULL OR related_cash_flow_id IS NOT NULL))
+`
+
+// activityFeesSQL aggregates the fees grouped under an entry as a JSON array
+// of models.ActivityFee.
+const activityFeesSQL = `
+	COALESCE((
+		SELECT json_agg(json_build_object(
+			'id', f.id,
+			'fee_type', COALESCE(f.fee_type, 'other'),
+			'amount_usd', ABS(f.usd_amount)::text
+		) ORDER BY f.date, f.created_at, f.id)
+		FROM cash_flows f
+		WHERE f.user_id = activity.user_id AND f.type = 'fee'
+			AND CASE WHEN activity.kind = 'trade'
+				THEN f.related_trade_id = activity.id
+				ELSE f.related_cash_flow_id = activity.id
+			END
+	), '[]')
+`
+
+func appendActivityListFilters(query string, args []interface{}, filters activityListFilters) (string, []interface{}) {
+	argN := len(args)
+
+	if len(filters.kinds) > 0 {
+		argN++
+		query += fmt.Sprintf(" AND kind = ANY($%d)", argN)
+		args = append(args, filters.kinds)
+	}
+	if filters.ticker != "" {
+		argN++
+		query += fmt.Sprintf(" AND ticker = $%d", argN)
+		args = append(args, filters.ticker)
+	}
+	if filters.broker != "" {
+		argN++
+		query += fmt.Sprintf(" AND broker_id = $%d", argN)
+		args = append(args, filters.broker)
+	}
+	if filters.portfolio != "" {
+		argN++
+		query += fmt.Sprintf(" AND portfolio_id = $%d", argN)
+		args = append(args, filters.portfolio)
+	}
+	if filters.from != nil {
+		argN++
+		query += fmt.Sprintf(" AND date >= $%d", argN)
+		args = append(args, *filters.from)
+	}
+	if filters.to != nil {
+		argN++
+		query += fmt.Sprintf(" AND date <= $%d", argN)
+		args = append(args, *filters.to)
+	}
+
+	return query, args
+}
+
+func buildListActivityQuery(userID string, filters activityListFilters, order listOrder, limit int) (string, []interface{}) {
+	query := `
+		SELECT id, date, created_at, kind, portfolio_id, broker_id, ticker, amount_usd::text,
+			side, asset_type, quantity::text, price::text,
+			currency, amount::text, usd_amount::text, fx_rate::text, fee_type,
+			` + activityFeesSQL + `
+		FROM (` + activityEntriesSQL + `) AS activity
+		WHERE user_id = $1`
+	args := []interface{}{userID}
+	query, args = appendActivityListFilters(query, args, filters)
+	query, args = order.appendTo(query, args)
+
+	args = append(args, limit)
+	query += fmt.Sprintf(" LIMIT $%d", len(args))
+
+	return query, args
+}
+
+// parseActivityListFilters validates ?kind= (comma-separated), ?ticker=,
+// ?broker_id=, ?from= and ?to=.
+func parseActivityListFilters(kinds, ticker, broker, fromStr, toStr string) (activityListFilters, error) {
+	filters := activityListFilters{
+		ticker: strings.ToUpper(strings.TrimSpace(ticker)),
+		broker: strings.TrimSpace(broker),
+	}
+
+	for _, kind := range strings.Split(kinds, ",") {
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		if kind == "" {
+			continue
+		}
+		if !slices.Contains(activityKinds, kind) {
+			return filters, apperror.Newf(apperror.Validation, "invalid kind %q", kind)
+		}
+		if !slices.Contains(filters.kinds, kind) {
+			filters.kinds = append(filters.kinds, kind)
+		}
+	}
+
+	if fromStr != "" {
+		t, err := parseTradeDate(fromStr)
+		if err != nil {
+			return filters, fmt.Errorf("invalid from date")
+		}
+		filters.from = &t
+	}
+	if toStr != "" {
+		t, err := parseTradeDate(toStr)
+		if err != nil {
+			return filters, fmt.Errorf("invalid to date")
+		}
+		filters.to = &t
+	}
+
+	return filters, nil
+}
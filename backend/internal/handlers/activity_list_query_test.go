@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"fintu-tracking-backend/internal/i18n"
+	"fintu-tracking-backend/internal/models"
+)
+
+func TestParseActivityListFilters(t *testing.T) {
+	t.Parallel()
+
+	filters, err := parseActivityListFilters("trade, Deposit,trade", " voo ", "broker-1", "2026-01-01", "2026-01-31")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(filters.kinds, []string{"trade", "deposit"}) {
+		t.Errorf("kinds = %v", filters.kinds)
+	}
+	if filters.ticker != "VOO" || filters.broker != "broker-1" || filters.from == nil || filters.to == nil {
+		t.Errorf("filters = %+v", filters)
+	}
+
+	if _, err := parseActivityListFilters("gift", "", "", "", ""); err == nil {
+		t.Error("expected error for invalid kind")
+	}
+	if _, err := parseActivityListFilters("", "", "", "01/02/2026", ""); err == nil {
+		t.Error("expected error for invalid from date")
+	}
+}
+
+func TestBuildListActivityQuery(t *testing.T) {
+	t.Parallel()
+
+	filters := activityListFilters{kinds: []string{"trade"}, ticker: "VOO", broker: "broker-1"}
+	order := listOrder{after: []string{"2026-01-02", "2026-01-02T10:00:00Z", "a"}}
+	query, args := buildListActivityQuery("user-1", filters, order, 51)
+	for _, fragment := range []string{
+		"WHERE user_id = $1 AND kind = ANY($2) AND ticker = $3 AND broker_id = $4",
+		"(date, created_at, id) < ($5::date, $6::timestamptz, $7::uuid)",
+		"ORDER BY date DESC, created_at DESC, id DESC LIMIT $8",
+		"related_trade_id IS NOT NULL OR related_cash_flow_id IS NOT NULL",
+	} {
+		if !strings.Contains(query, fragment) {
+			t.Errorf("query missing %q:\n%s", fragment, query)
+		}
+	}
+	if len(args) != 8 || args[7] != 51 {
+		t.Errorf("args = %v", args)
+	}
+}
+
+func TestActivityFeedRowDetails(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		locale i18n.Locale
+		item   models.ActivityItem
+		row    activityFeedRow
+		want   string
+	}{
+		{i18n.English, models.ActivityItem{Kind: "trade", SubKind: "buy", Ticker: "VOO"}, activityFeedRow{quantity: "2.00000000", price: "400.5"}, "buy 2 VOO @ $400.50"},
+		{i18n.English, models.ActivityItem{Kind: "deposit"}, activityFeedRow{currency: "COP", amount: "1000000"}, "Deposit: COP 1,000,000"},
+		{i18n.English, models.ActivityItem{Kind: "fee"}, activityFeedRow{usdAmount: "1.50"}, "Fee (other): $1.50"},
+		{i18n.English, models.ActivityItem{Kind: "fee", SubKind: "maintenance"}, activityFeedRow{usdAmount: "3.00"}, "Fee (maintenance): $3.00"},
+		{i18n.English, models.ActivityItem{Kind: "transfer_out"}, activityFeedRow{usdAmount: "1250.00"}, "Transfer out: $1,250.00"},
+		{i18n.SpanishColombia, models.ActivityItem{Kind: "trade", SubKind: "sell", Ticker: "VOO"}, activityFeedRow{quantity: "1.5", price: "1400.5"}, "venta de 1,5 VOO a US$1.400,50"},
+		{i18n.SpanishColombia, models.ActivityItem{Kind: "deposit"}, activityFeedRow{currency: "COP", amount: "1000000"}, "Depósito: $1.000.000"},
+		{i18n.SpanishColombia, models.ActivityItem{Kind: "withdrawal"}, activityFeedRow{currency: "COP", amount: "2500000.50"}, "Retiro: $2.500.001"},
+		{i18n.SpanishColombia, models.ActivityItem{Kind: "fee", SubKind: "maintenance"}, activityFeedRow{usdAmount: "3.00"}, "Comisión (mantenimiento): US$3,00"},
+	}
+	for _, tc := range tests {
+		if got := tc.row.details(tc.locale, tc.item); got != tc.want {
+			t.Errorf("details(%s, %s) = %q, want %q", tc.locale, tc.item.Kind, got, tc.want)
+		}
+	}
+}
+
+func TestActivityDirectionAndFees(t *testing.T) {
+	t.Parallel()
+
+	sell := models.ActivityEntry{Kind: "trade", Trade: &models.ActivityTrade{Side: "sell"}}
+	buy := models.ActivityEntry{Kind: "trade", Trade: &models.ActivityTrade{Side: "buy"}}
+	credit := models.ActivityEntry{Kind: "cash_adjustment", CashFlow: &models.ActivityCashFlow{UsdAmount: "5.00"}}
+	debit := models.ActivityEntry{Kind: "cash_adjustment", CashFlow: &models.ActivityCashFlow{UsdAmount: "-5.00"}}
+	for entry, want := range map[*models.ActivityEntry]string{&sell: "in", &buy: "out", &credit: "in", &debit: "out"} {
+		if got := activityDirection(*entry); got != want {
+			t.Errorf("direction of %+v = %s, want %s", entry, got, want)
+		}
+	}
+
+	fees := []models.ActivityFee{{AmountUSD: "1.25"}, {AmountUSD: "0.75"}}
+	if got := sumActivityFees(fees); got != "2.00" {
+		t.Errorf("sumActivityFees = %s, want 2.00", got)
+	}
+	if got := sumActivityFees(nil); got != "0.00" {
+		t.Errorf("sumActivityFees(nil) = %s, want 0.00", got)
+	}
+}
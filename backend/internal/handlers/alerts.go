@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"strconv"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/middleware"
+	"fintu-tracking-backend/internal/models"
+	"fintu-tracking-backend/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+var alertService = services.NewAlertService(nil, nil, nil, config.SMTPSettings{})
+
+// InitAlertService sets the package-level alert service used by handlers.
+// It is called once from main.go, which also waits for its background
+// evaluations on shutdown.
+func InitAlertService(svc *services.AlertService) {
+	alertService = svc
+}
+
+// ListAlertRules handles GET /api/alerts.
+func ListAlertRules(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	rules, err := alertService.ListRules(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	return respondList(c, rules)
+}
+
+// GetAlertRule handles GET /api/alerts/:id.
+func GetAlertRule(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	rule, err := alertService.GetRule(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(rule)
+}
+
+// CreateAlertRule handles POST /api/alerts.
+func CreateAlertRule(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.CreateAlertRuleRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperror.New(apperror.Validation, "Invalid request body")
+	}
+
+	rule, err := alertService.CreateRule(c.Context(), userID, req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(rule)
+}
+
+// UpdateAlertRule handles PATCH /api/alerts/:id, including pausing a rule
+// with "active": false.
+func UpdateAlertRule(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.UpdateAlertRuleRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperror.New(apperror.Validation, "Invalid request body")
+	}
+
+	rule, err := alertService.UpdateRule(c.Context(), userID, c.Params("id"), req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(rule)
+}
+
+// DeleteAlertRule handles DELETE /api/alerts/:id.
+func DeleteAlertRule(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := alertService.DeleteRule(c.Context(), userID, c.Params("id")); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ListAlertEvents handles GET /api/alerts/events, the history of fired alerts
+// with their deliveries, newest first. ?rule_id= filters to one rule and
+// ?limit= caps the count.
+func ListAlertEvents(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	limit := config.DefaultAlertEventsLimit
+	if apiVersion(c) >= APIv2 {
+		// v2 pages through the most recent events; ?limit= is the page size.
+		limit = config.MaxAlertEventsLimit
+	} else if raw := c.Query("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > config.MaxAlertEventsLimit {
+			return apperror.Newf(apperror.Validation, "limit must be between 1 and %d", config.MaxAlertEventsLimit)
+		}
+	}
+
+	events, err := alertService.ListEvents(c.Context(), userID, c.Query("rule_id"), limit)
+	if err != nil {
+		return err
+	}
+	return respondList(c, events)
+}
+
+// EvaluateAlerts handles POST /api/alerts/evaluate, checking the user's rules
+// against the data already stored. Refreshes run the same evaluation in the
+// background.
+func EvaluateAlerts(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	result, err := alertService.Evaluate(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(result)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"strconv"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/middleware"
+	"fintu-tracking-backend/internal/models"
+	"fintu-tracking-backend/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var allocationService = services.NewAllocationService(nil)
+
+// InitAllocationService sets the package-level allocation service used by handlers.
+// It is called once from main.go after the DB pool is available.
+func InitAllocationService(pool *pgxpool.Pool) {
+	allocationService = services.NewAllocationService(pool)
+}
+
+// GetTargetAllocation returns a portfolio's target allocation.
+func GetTargetAllocation(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	allocation, err := allocationService.GetTargetAllocation(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(allocation)
+}
+
+// SetTargetAllocation creates or replaces a portfolio's target allocation.
+func SetTargetAllocation(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.SetTargetAllocationRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperror.New(apperror.Validation, "Invalid request body")
+	}
+
+	allocation, err := allocationService.SetTargetAllocation(c.Context(), userID, c.Params("id"), req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(allocation)
+}
+
+// DeleteTargetAllocation removes a portfolio's target allocation.
+func DeleteTargetAllocation(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := allocationService.DeleteTargetAllocation(c.Context(), userID, c.Params("id")); err != nil {
+		return err
+	}
+	return c.JSON(models.MessageResponse{Message: "Target allocation deleted successfully"})
+}
+
+// GetRebalancePlan suggests orders that bring a portfolio back to its target
+// allocation. ?allow_sells=false restricts the plan to buying with cash.
+func GetRebalancePlan(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	allowSells := true
+	if raw := c.Query("allow_sells"); raw != "" {
+		allowSells, err = strconv.ParseBool(raw)
+		if err != nil {
+			return apperror.New(apperror.Validation, "allow_sells must be true or false")
+		}
+	}
+
+	plan, err := allocationService.PlanRebalance(c.Context(), userID, c.Params("id"), allowSells)
+	if err != nil {
+		return err
+	}
+	return c.JSON(plan)
+}
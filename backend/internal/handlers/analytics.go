@@ -1,30 +1,41 @@
 package handlers
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
 	"fintu-tracking-backend/internal/database"
+	"fintu-tracking-backend/internal/i18n"
 	"fintu-tracking-backend/internal/middleware"
+	"fintu-tracking-backend/internal/models"
 	"fintu-tracking-backend/internal/services"
 
 	"github.com/gofiber/fiber/v3"
+	"github.com/shopspring/decimal"
 )
 
 // GetFeeBreakdown handles GET /api/analytics/fee-breakdown
 func GetFeeBreakdown(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
+	}
+
+	portfolioID, err := resolvePortfolioScope(c, userID)
+	if err != nil {
+		return err
 	}
 
 	dateRange := parseDateRange(c)
 
-	feeService := services.NewFeeService(database.GetPool())
+	feeService := services.NewFeeService(database.GetPool()).ForPortfolio(portfolioID)
 	breakdown, err := feeService.GetTotalFeesByType(c.Context(), userID, dateRange)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to calculate fee breakdown: " + err.Error(),
-		})
+		return err
 	}
 
 	return c.JSON(breakdown)
@@ -34,59 +45,110 @@ func GetFeeBreakdown(c fiber.Ctx) error {
 func GetFeeImpact(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
+	}
+
+	portfolioID, err := resolvePortfolioScope(c, userID)
+	if err != nil {
+		return err
 	}
 	ticker := c.Query("ticker")
 
 	if ticker == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Ticker parameter is required",
-		})
+		return apperror.New(apperror.Validation, "Ticker parameter is required")
 	}
 
-	feeService := services.NewFeeService(database.GetPool())
+	feeService := services.NewFeeService(database.GetPool()).ForPortfolio(portfolioID)
 	impact, err := feeService.GetFeeImpactOnReturn(c.Context(), userID, ticker)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to calculate fee impact: " + err.Error(),
-		})
+		return err
 	}
 
+	if apiVersion(c) < APIv2 {
+		return c.JSON(feeImpactV1(impact))
+	}
 	return c.JSON(impact)
 }
 
+// feeImpactV1 is the v1 body of GET /api/analytics/fee-impact: every value a
+// string, and no net_quantity for a ticker without trades.
+func feeImpactV1(impact models.FeeImpact) map[string]string {
+	body := map[string]string{
+		"total_fees":     impact.TotalFees,
+		"total_cost":     impact.TotalCost,
+		"fee_impact_pct": impact.FeeImpactPct,
+		"trade_count":    strconv.Itoa(impact.TradeCount),
+	}
+	if impact.TradeCount > 0 {
+		body["net_quantity"] = impact.NetQuantity
+	}
+	return body
+}
+
 // GetFeeEfficiency handles GET /api/analytics/fee-efficiency
 func GetFeeEfficiency(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
+	}
+
+	portfolioID, err := resolvePortfolioScope(c, userID)
+	if err != nil {
+		return err
 	}
 	groupBy := c.Query("group_by", "ticker")
+	if groupBy != "ticker" {
+		// v1 answered other groupings with an empty object.
+		if apiVersion(c) < APIv2 {
+			return c.JSON(fiber.Map{})
+		}
+		return apperror.New(apperror.Validation, "group_by must be 'ticker'")
+	}
 
-	feeService := services.NewFeeService(database.GetPool())
-	efficiency, err := feeService.GetFeeEfficiency(c.Context(), userID, groupBy)
+	feeService := services.NewFeeService(database.GetPool()).ForPortfolio(portfolioID)
+	efficiency, err := feeService.GetFeeEfficiency(c.Context(), userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to calculate fee efficiency: " + err.Error(),
-		})
+		return err
 	}
 
+	if apiVersion(c) < APIv2 {
+		return c.JSON(feeEfficiencyV1(efficiency))
+	}
 	return c.JSON(efficiency)
 }
 
+// feeEfficiencyV1 is the v1 body of GET /api/analytics/fee-efficiency, with
+// every value a string.
+func feeEfficiencyV1(efficiency models.FeeEfficiency) map[string]any {
+	tickers := make([]map[string]string, len(efficiency.ByTicker))
+	for i, t := range efficiency.ByTicker {
+		tickers[i] = map[string]string{
+			"ticker":      t.Ticker,
+			"trade_count": strconv.Itoa(t.TradeCount),
+			"total_fees":  t.TotalFees,
+			"total_value": t.TotalValue,
+			"avg_fee_pct": t.AvgFeePct,
+		}
+	}
+	return map[string]any{"by_ticker": tickers}
+}
+
 // GetReturnAttribution handles GET /api/analytics/return-attribution
 func GetReturnAttribution(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
 	}
 
-	analyticsService := services.NewAnalyticsService(database.GetPool())
+	portfolioID, err := resolvePortfolioScope(c, userID)
+	if err != nil {
+		return err
+	}
+
+	analyticsService := services.NewAnalyticsService(database.GetPool()).ForPortfolio(portfolioID)
 	attribution, err := analyticsService.CalculateReturnAttribution(c.Context(), userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to calculate return attribution: " + err.Error(),
-		})
+		return err
 	}
 
 	return c.JSON(attribution)
@@ -96,15 +158,18 @@ func GetReturnAttribution(c fiber.Ctx) error {
 func GetFXImpact(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
+	}
+
+	portfolioID, err := resolvePortfolioScope(c, userID)
+	if err != nil {
+		return err
 	}
 
-	analyticsService := services.NewAnalyticsService(database.GetPool())
+	analyticsService := services.NewAnalyticsService(database.GetPool()).ForPortfolio(portfolioID)
 	fxReport, err := analyticsService.CalculateFXImpact(c.Context(), userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to calculate FX impact: " + err.Error(),
-		})
+		return err
 	}
 
 	return c.JSON(fxReport)
@@ -114,16 +179,23 @@ func GetFXImpact(c fiber.Ctx) error {
 func GetPerformanceTimeSeries(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
+	}
+
+	portfolioID, err := resolvePortfolioScope(c, userID)
+	if err != nil {
+		return err
 	}
 	interval := c.Query("interval", "day")
+	benchmarks, err := resolveBenchmarkQuery(c, userID)
+	if err != nil {
+		return err
+	}
 
-	analyticsService := services.NewAnalyticsService(database.GetPool())
-	timeSeries, err := analyticsService.GetPerformanceTimeSeries(c.Context(), userID, interval)
+	analyticsService := services.NewAnalyticsService(database.GetPool()).ForPortfolio(portfolioID)
+	timeSeries, err := analyticsService.GetPerformanceTimeSeries(c.Context(), userID, interval, benchmarks)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to get performance time series: " + err.Error(),
-		})
+		return err
 	}
 
 	return c.JSON(timeSeries)
@@ -133,17 +205,23 @@ func GetPerformanceTimeSeries(c fiber.Ctx) error {
 func GetNetWorth(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
 	}
 
-	analyticsService := services.NewAnalyticsService(database.GetPool())
+	portfolioID, err := resolvePortfolioScope(c, userID)
+	if err != nil {
+		return err
+	}
+
+	analyticsService := services.NewAnalyticsService(database.GetPool()).ForPortfolio(portfolioID)
 	netWorth, err := analyticsService.GetNetWorthSummary(c.Context(), userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to calculate net worth: " + err.Error(),
-		})
+		return err
 	}
 
+	if apiVersion(c) >= APIv2 {
+		return c.JSON(netWorth.V2())
+	}
 	return c.JSON(netWorth)
 }
 
@@ -151,15 +229,107 @@ func GetNetWorth(c fiber.Ctx) error {
 func GetCashReconciliation(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
 	}
 
-	feeService := services.NewFeeService(database.GetPool())
+	portfolioID, err := resolvePortfolioScope(c, userID)
+	if err != nil {
+		return err
+	}
+
+	feeService := services.NewFeeService(database.GetPool()).ForPortfolio(portfolioID)
 	report, err := feeService.ReconcileCashFlowFees(c.Context(), userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to reconcile cash flows: " + err.Error(),
-		})
+		return err
+	}
+	if !report.IsReconciled {
+		notifyReconciliationIssues(middleware.GetLocale(c), userID, report)
+	}
+
+	return c.JSON(report)
+}
+
+// notifyReconciliationIssues puts unreconciled fees in the inbox. Re-running
+// the report does not add another notification until the last one is read.
+func notifyReconciliationIssues(loc i18n.Locale, userID string, report models.ReconciliationReport) {
+	notifyUser(userID, services.NotificationInput{
+		Type:  config.NotificationTypeReconciliation,
+		Title: loc.Sprintf("Trade fees and cash flows do not reconcile"),
+		Body: loc.Sprintf("%d trades without fee cash flows, %d orphaned and %d unlinked fee cash flows, %d fee discrepancies (difference %s).",
+			len(report.MissingLinks), len(report.OrphanedCashFlows), len(report.UnlinkedCashFlows),
+			len(report.Discrepancies), loc.MoneyString(config.BaseCurrency, report.Difference)),
+		SkipIfUnread: true,
+	})
+}
+
+// GetRiskMetrics handles GET /api/analytics/risk
+// Optional query params: risk_free_rate (annual decimal fraction, e.g. 0.045)
+// and confidence (VaR confidence level, e.g. 0.99).
+func GetRiskMetrics(c fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
+	}
+
+	opts, err := parseRiskOptions(c)
+	if err != nil {
+		return apperror.Classify(apperror.Validation, err)
+	}
+
+	portfolioID, err := resolvePortfolioScope(c, userID)
+	if err != nil {
+		return err
+	}
+
+	analyticsService := services.NewAnalyticsService(database.GetPool()).ForPortfolio(portfolioID)
+	report, err := analyticsService.GetRiskMetrics(c.Context(), userID, opts)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(report)
+}
+
+func parseRiskOptions(c fiber.Ctx) (services.RiskOptions, error) {
+	opts := services.DefaultRiskOptions()
+	if v := strings.TrimSpace(c.Query("risk_free_rate")); v != "" {
+		rate, err := decimal.NewFromString(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid risk_free_rate")
+		}
+		opts.RiskFreeRate = rate
+	}
+	if v := strings.TrimSpace(c.Query("confidence")); v != "" {
+		confidence, err := decimal.NewFromString(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid confidence")
+		}
+		opts.VaRConfidence = confidence
+	}
+	return opts, nil
+}
+
+// GetExposure handles GET /api/analytics/exposure
+func GetExposure(c fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
+	}
+
+	threshold, err := decimal.NewFromString(c.Query("concentration_threshold", config.DefaultConcentrationThresholdPct))
+	if err != nil {
+		return apperror.New(apperror.Validation, "invalid concentration_threshold")
+	}
+
+	portfolioID, err := resolvePortfolioScope(c, userID)
+	if err != nil {
+		return err
+	}
+
+	analyticsService := services.NewAnalyticsService(database.GetPool()).ForPortfolio(portfolioID)
+	report, err := analyticsService.GetExposure(c.Context(), userID, threshold)
+	if err != nil {
+		return err
 	}
 
 	return c.JSON(report)
@@ -167,8 +337,13 @@ func GetCashReconciliation(c fiber.Ctx) error {
 
 // Helper function to parse date range from query parameters
 func parseDateRange(c fiber.Ctx) *services.DateRange {
-	startDateStr := c.Query("start_date")
-	endDateStr := c.Query("end_date")
+	// v2 names date ranges from/to on every endpoint.
+	startKey, endKey := "start_date", "end_date"
+	if apiVersion(c) >= APIv2 {
+		startKey, endKey = "from", "to"
+	}
+	startDateStr := c.Query(startKey)
+	endDateStr := c.Query(endKey)
 
 	var dateRange *services.DateRange
 
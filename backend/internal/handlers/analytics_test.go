@@ -7,6 +7,8 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"fintu-tracking-backend/internal/models"
+
 	"github.com/gofiber/fiber/v3"
 )
 
@@ -27,13 +29,16 @@ func TestAnalyticsHandlers_ReturnUnauthorizedWhenUserIDMissing(t *testing.T) {
 		{"GetPerformanceTimeSeries", GetPerformanceTimeSeries, "/performance-time-series", "/performance-time-series"},
 		{"GetNetWorth", GetNetWorth, "/net-worth", "/net-worth"},
 		{"GetCashReconciliation", GetCashReconciliation, "/cash-reconciliation", "/cash-reconciliation"},
+		{"GetRiskMetrics", GetRiskMetrics, "/risk", "/risk"},
+		{"GetBenchmarkComparison", GetBenchmarkComparison, "/benchmark-comparison", "/benchmark-comparison"},
+		{"GetExposure", GetExposure, "/exposure", "/exposure"},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
-			app := fiber.New()
+			app := newTestApp()
 			app.Get(tc.route, tc.handler)
 
 			req := httptest.NewRequest(http.MethodGet, tc.request, nil)
@@ -62,3 +67,68 @@ func TestAnalyticsHandlers_ReturnUnauthorizedWhenUserIDMissing(t *testing.T) {
 		})
 	}
 }
+
+func TestGetRiskMetrics_RejectsInvalidOptions(t *testing.T) {
+	t.Parallel()
+
+	for _, query := range []string{"risk_free_rate=abc", "confidence=x"} {
+		app := newTestApp()
+		app.Get("/risk", withUser("user-1"), GetRiskMetrics)
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/risk?"+query, nil))
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		resp.Body.Close()
+		assertStatus(t, resp, http.StatusBadRequest)
+	}
+}
+
+func TestGetExposure_RejectsInvalidThreshold(t *testing.T) {
+	t.Parallel()
+
+	for _, query := range []string{"concentration_threshold=abc", "concentration_threshold=0", "concentration_threshold=150"} {
+		app := newTestApp()
+		app.Get("/exposure", withUser("user-1"), GetExposure)
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/exposure?"+query, nil))
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		resp.Body.Close()
+		assertStatus(t, resp, http.StatusBadRequest)
+	}
+}
+
+func TestFeeImpactV1(t *testing.T) {
+	t.Parallel()
+
+	none := feeImpactV1(models.FeeImpact{Ticker: "VOO", TotalFees: "0", TotalCost: "0", FeeImpactPct: "0"})
+	if _, ok := none["net_quantity"]; ok {
+		t.Errorf("net_quantity set without trades: %v", none)
+	}
+	if none["trade_count"] != "0" {
+		t.Errorf("trade_count = %q", none["trade_count"])
+	}
+
+	some := feeImpactV1(models.FeeImpact{Ticker: "VOO", TotalFees: "1.5", TradeCount: 2, NetQuantity: "3"})
+	if some["net_quantity"] != "3" || some["trade_count"] != "2" || some["total_fees"] != "1.5" {
+		t.Errorf("feeImpactV1 = %v", some)
+	}
+}
+
+func TestFeeEfficiencyV1(t *testing.T) {
+	t.Parallel()
+
+	body := feeEfficiencyV1(models.FeeEfficiency{ByTicker: []models.TickerFeeEfficiency{
+		{Ticker: "VOO", TradeCount: 4, TotalFees: "2", TotalValue: "400", AvgFeePct: "0.5"},
+	}})
+	raw, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"by_ticker":[{"avg_fee_pct":"0.5","ticker":"VOO","total_fees":"2","total_value":"400","trade_count":"4"}]}`
+	if string(raw) != want {
+		t.Errorf("feeEfficiencyV1 = %s, want %s", raw, want)
+	}
+}
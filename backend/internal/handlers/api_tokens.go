@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/middleware"
+	"fintu-tracking-backend/internal/models"
+	"fintu-tracking-backend/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+var apiTokenService = services.NewAPITokenService(nil)
+
+// InitAPITokenService sets the package-level API token service used by
+// handlers. It is called once from main.go with the service the auth
+// middleware verifies tokens with.
+func InitAPITokenService(svc *services.APITokenService) {
+	apiTokenService = svc
+}
+
+// ListAPITokens handles GET /api/tokens.
+func ListAPITokens(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := apiTokenService.ListTokens(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	return respondList(c, tokens)
+}
+
+// CreateAPIToken handles POST /api/tokens. The response is the only one that
+// includes the token itself.
+func CreateAPIToken(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.CreateAPITokenRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperror.New(apperror.Validation, "Invalid request body")
+	}
+
+	token, err := apiTokenService.CreateToken(c.Context(), userID, req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(token)
+}
+
+// RevokeAPIToken handles DELETE /api/tokens/:id. The token stays listed with
+// its revoked_at set.
+func RevokeAPIToken(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	token, err := apiTokenService.RevokeToken(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(token)
+}
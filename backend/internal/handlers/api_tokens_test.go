@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestAPITokenHandlers_Unauthorized(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		method  string
+		route   string
+		path    string
+		body    string
+		handler fiber.Handler
+	}{
+		{http.MethodGet, "/tokens", "/tokens", "", ListAPITokens},
+		{http.MethodPost, "/tokens", "/tokens", `{}`, CreateAPIToken},
+		{http.MethodDelete, "/tokens/:id", "/tokens/t-1", "", RevokeAPIToken},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			t.Parallel()
+
+			app := newTestApp()
+			app.Add([]string{tc.method}, tc.route, tc.handler)
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			defer resp.Body.Close()
+
+			assertStatus(t, resp, http.StatusUnauthorized)
+		})
+	}
+}
+
+func TestAPITokenHandlers_Validation(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		method  string
+		route   string
+		path    string
+		body    string
+		handler fiber.Handler
+		status  int
+	}{
+		{http.MethodDelete, "/tokens/:id", "/tokens/not-a-uuid", "", RevokeAPIToken, http.StatusNotFound},
+		{http.MethodPost, "/tokens", "/tokens", `not json`, CreateAPIToken, http.StatusBadRequest},
+		{http.MethodPost, "/tokens", "/tokens", `{"scopes":["read:portfolio"]}`, CreateAPIToken, http.StatusBadRequest},
+		{http.MethodPost, "/tokens", "/tokens", `{"name":"cron"}`, CreateAPIToken, http.StatusBadRequest},
+		{http.MethodPost, "/tokens", "/tokens", `{"name":"cron","scopes":["admin"]}`, CreateAPIToken, http.StatusBadRequest},
+		{http.MethodPost, "/tokens", "/tokens", `{"name":"cron","scopes":["read:portfolio"],"expires_in_days":0}`, CreateAPIToken, http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		app := newTestApp()
+		app.Add([]string{tc.method}, tc.route, withUser("user-1"), tc.handler)
+
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s %s: status = %d, want %d", tc.method, tc.path, resp.StatusCode, tc.status)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import "github.com/gofiber/fiber/v3"
+
+// API versions. Most handlers serve both /api and /api/v2; the ones whose
+// responses differ branch on the version of the route group that matched.
+const (
+	APIv1 = 1
+	APIv2 = 2
+)
+
+const apiVersionKey = "api_version"
+
+// APIVersion returns middleware that marks requests as served by version v.
+// Requests without it are v1.
+func APIVersion(v int) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		c.Locals(apiVersionKey, v)
+		return c.Next()
+	}
+}
+
+func apiVersion(c fiber.Ctx) int {
+	if v, ok := c.Locals(apiVersionKey).(int); ok {
+		return v
+	}
+	return APIv1
+}
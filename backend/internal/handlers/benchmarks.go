@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"strings"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/database"
+	"fintu-tracking-backend/internal/middleware"
+	"fintu-tracking-backend/internal/models"
+	"fintu-tracking-backend/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// InitBenchmarkService sets the package-level benchmark service used by handlers.
+// It is called once from main.go after the DB pool is available.
+func InitBenchmarkService(pool *pgxpool.Pool) {
+	benchmarkService = services.NewBenchmarkService(pool)
+}
+
+var benchmarkService *services.BenchmarkService
+
+// ListBenchmarks handles GET /api/benchmarks: built-in presets plus the user's
+// custom baskets.
+func ListBenchmarks(c fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
+	}
+
+	benchmarks, err := benchmarkService.ListBenchmarks(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	return respondList(c, benchmarks)
+}
+
+// CreateBenchmark handles POST /api/benchmarks, saving a custom weighted basket.
+func CreateBenchmark(c fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
+	}
+
+	var req models.CreateBenchmarkRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperror.New(apperror.Validation, "Invalid request body")
+	}
+
+	benchmark, err := benchmarkService.CreateCustomBenchmark(c.Context(), userID, req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(benchmark)
+}
+
+// DeleteBenchmark handles DELETE /api/benchmarks/:id for custom benchmarks.
+func DeleteBenchmark(c fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
+	}
+
+	if err := benchmarkService.DeleteCustomBenchmark(c.Context(), userID, c.Params("id")); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetBenchmarkComparison handles GET /api/analytics/benchmark-comparison. It
+// replays the portfolio's real cash flows into each requested benchmark.
+func GetBenchmarkComparison(c fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
+	}
+
+	portfolioID, err := resolvePortfolioScope(c, userID)
+	if err != nil {
+		return err
+	}
+	benchmarks, err := resolveBenchmarkQuery(c, userID)
+	if err != nil {
+		return err
+	}
+
+	analyticsService := services.NewAnalyticsService(database.GetPool()).ForPortfolio(portfolioID)
+	comparisons, err := analyticsService.CompareBenchmarks(c.Context(), userID, benchmarks)
+	if err != nil {
+		return err
+	}
+	return c.JSON(comparisons)
+}
+
+// resolveBenchmarkQuery reads the comma-separated ?benchmarks= list of preset
+// or custom benchmark IDs. An empty list selects the default benchmark.
+func resolveBenchmarkQuery(c fiber.Ctx, userID string) ([]models.Benchmark, error) {
+	var ids []string
+	if raw := strings.TrimSpace(c.Query("benchmarks")); raw != "" {
+		ids = strings.Split(raw, ",")
+	}
+	return benchmarkService.ResolveBenchmarks(c.Context(), userID, ids)
+}
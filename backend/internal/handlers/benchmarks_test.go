@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBenchmarkHandlers_Unauthorized(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp()
+	app.Get("/benchmarks", ListBenchmarks)
+	app.Post("/benchmarks", CreateBenchmark)
+	app.Delete("/benchmarks/:id", DeleteBenchmark)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/benchmarks", nil),
+		httptest.NewRequest(http.MethodPost, "/benchmarks", strings.NewReader(`{}`)),
+		httptest.NewRequest(http.MethodDelete, "/benchmarks/abc", nil),
+	} {
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		resp.Body.Close()
+		assertStatus(t, resp, http.StatusUnauthorized)
+	}
+}
+
+func TestGetPerformanceTimeSeries_RejectsBadBenchmarks(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		query  string
+		status int
+		error  string
+	}{
+		{"benchmarks=nope", http.StatusNotFound, "benchmark not found"},
+		{"benchmarks=spy,qqq,vt,colcap,60-40,00000000-0000-0000-0000-000000000001", http.StatusBadRequest, "at most 5"},
+	}
+
+	for _, tc := range cases {
+		app := newTestApp()
+		app.Get("/performance-time-series", withUser("user-1"), GetPerformanceTimeSeries)
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/performance-time-series?"+tc.query, nil))
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		assertStatus(t, resp, tc.status)
+		assertBodyContains(t, resp, tc.error)
+		resp.Body.Close()
+	}
+}
+
+func TestCreateBenchmark_RejectsInvalidBasket(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp()
+	app.Post("/benchmarks", withUser("user-1"), CreateBenchmark)
+
+	body := `{"name":"Tilt","components":[{"ticker":"VT","weight":"0.5"},{"ticker":"QQQ","weight":"0.3"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/benchmarks", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+	assertStatus(t, resp, http.StatusBadRequest)
+	assertBodyContains(t, resp, "weights must sum to 1")
+}
@@ -1,7 +1,9 @@
 package handlers
 
 import (
-	"errors"
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/i18n"
 	"fintu-tracking-backend/internal/middleware"
 	"fintu-tracking-backend/internal/models"
 	"fintu-tracking-backend/internal/services"
@@ -27,10 +29,10 @@ func ListPlans(c fiber.Ctx) error {
 
 	plans, err := billingService.ListPlans(c.Context(), userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
-	return c.JSON(plans)
+	return respondList(c, plans)
 }
 
 // GetSubscription returns the current user's subscription.
@@ -42,10 +44,10 @@ func GetSubscription(c fiber.Ctx) error {
 
 	subscription, err := billingService.GetSubscription(c.Context(), userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 	if subscription == nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "No subscription found"})
+		return apperror.New(apperror.NotFound, "No subscription found")
 	}
 
 	return c.JSON(subscription)
@@ -60,13 +62,14 @@ func CreateSubscription(c fiber.Ctx) error {
 
 	var req models.CreateSubscriptionRequest
 	if err := c.Bind().JSON(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		return apperror.New(apperror.Validation, "Invalid request body")
 	}
 
 	subscription, err := billingService.CreateSubscription(c.Context(), userID, req)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
+	publishWebhookEvent(userID, config.WebhookEventSubscriptionChanged, subscription)
 
 	return c.Status(fiber.StatusCreated).JSON(subscription)
 }
@@ -80,17 +83,32 @@ func CancelSubscription(c fiber.Ctx) error {
 
 	id := c.Params("id")
 	if id == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "subscription id is required"})
+		return apperror.New(apperror.Validation, "subscription id is required")
 	}
 
 	subscription, err := billingService.CancelSubscription(c.Context(), userID, id)
 	if err != nil {
-		status := fiber.StatusBadRequest
-		if errors.Is(err, services.ErrSubscriptionNotFound) {
-			status = fiber.StatusNotFound
-		}
-		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
+	notifySubscriptionEnding(middleware.GetLocale(c), userID, subscription)
+	publishWebhookEvent(userID, config.WebhookEventSubscriptionChanged, subscription)
 
 	return c.JSON(subscription)
 }
+
+// notifySubscriptionEnding tells the user when a cancelled subscription stops
+// giving access.
+func notifySubscriptionEnding(loc i18n.Locale, userID string, subscription *models.Subscription) {
+	body := loc.Sprintf("Your subscription ends at the end of the current billing period.")
+	if subscription.Status != models.SubscriptionStatusActive && subscription.Status != models.SubscriptionStatusTrialing {
+		body = loc.Sprintf("Your subscription has ended.")
+	} else if subscription.CurrentPeriodEnd != nil {
+		body = loc.Sprintf("Your subscription ends on %s.", subscription.CurrentPeriodEnd.Format("2006-01-02"))
+	}
+	notifyUser(userID, services.NotificationInput{
+		Type:  config.NotificationTypeSubscriptionEnding,
+		Title: loc.Sprintf("Subscription cancelled"),
+		Body:  body,
+		Data:  map[string]string{"subscription_id": subscription.ID, "plan_id": subscription.PlanID},
+	})
+}
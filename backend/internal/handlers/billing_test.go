@@ -11,13 +11,11 @@ import (
 
 	"fintu-tracking-backend/internal/database"
 	"fintu-tracking-backend/internal/services"
-
-	"github.com/gofiber/fiber/v3"
 )
 
 func TestListPlans_Unauthorized(t *testing.T) {
 	t.Parallel()
-	app := fiber.New()
+	app := newTestApp()
 	app.Get("/plans", ListPlans)
 
 	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/plans", nil))
@@ -31,7 +29,7 @@ func TestListPlans_Unauthorized(t *testing.T) {
 
 func TestGetSubscription_Unauthorized(t *testing.T) {
 	t.Parallel()
-	app := fiber.New()
+	app := newTestApp()
 	app.Get("/subscriptions/current", GetSubscription)
 
 	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/subscriptions/current", nil))
@@ -54,7 +52,7 @@ func TestGetSubscription_ReturnsClosedBeta(t *testing.T) {
 		t.Fatalf("create closed_beta subscription: %v", err)
 	}
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Use(withUser(userID))
 	app.Get("/subscriptions/current", GetSubscription)
 
@@ -82,7 +80,7 @@ func TestCreateSubscription_ValidationErrors(t *testing.T) {
 	billingSvc := services.NewBillingService(database.GetPool(), services.NewNoOpBillingProvider())
 	InitBillingService(billingSvc)
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Use(withUser(userID))
 	app.Post("/subscriptions", CreateSubscription)
 
@@ -111,7 +109,7 @@ func TestCreateSubscription_Isolation(t *testing.T) {
 		t.Fatalf("create subscription A: %v", err)
 	}
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Use(withUser(userB))
 	app.Post("/subscriptions", CreateSubscription)
 
@@ -150,7 +148,7 @@ func TestCancelSubscription_Success(t *testing.T) {
 		t.Fatalf("fetch subscription id: %v", err)
 	}
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Use(withUser(userID))
 	app.Patch("/subscriptions/:id/cancel", CancelSubscription)
 
@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"strings"
+
+	"fintu-tracking-backend/internal/apperror"
 	"fintu-tracking-backend/internal/config"
 	"fintu-tracking-backend/internal/middleware"
+	"fintu-tracking-backend/internal/models"
 	"fintu-tracking-backend/internal/services"
 
 	"github.com/gofiber/fiber/v3"
@@ -17,7 +21,8 @@ func InitBrokerService(pool *pgxpool.Pool) {
 
 var brokerService *services.BrokerService
 
-// ListBrokers returns the user's broker rows plus all available built-in presets.
+// ListBrokers returns the user's broker rows plus all available built-in
+// presets. v2 lists the presets separately at /api/v2/broker-presets.
 func ListBrokers(c fiber.Ctx) error {
 	userID, err := middleware.RequireUserID(c)
 	if err != nil {
@@ -26,21 +31,39 @@ func ListBrokers(c fiber.Ctx) error {
 
 	brokers, err := brokerService.ListBrokers(c.Context(), userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
-	return c.JSON(fiber.Map{
-		"brokers": brokers,
-		"presets": config.BuiltInBrokerPresets,
+	if apiVersion(c) >= APIv2 {
+		return respondList(c, brokers)
+	}
+	return c.JSON(ListBrokersResponse{
+		Brokers: brokers,
+		Presets: config.BuiltInBrokerPresets,
 	})
 }
 
-// CreateBrokerRequest selects or creates a broker from a built-in preset.
+// ListBrokerPresets handles GET /api/v2/broker-presets, the built-in brokers
+// a user can add.
+func ListBrokerPresets(c fiber.Ctx) error {
+	return respondList(c, config.BuiltInBrokerPresets)
+}
+
+// ListBrokersResponse is the body of GET /api/brokers.
+type ListBrokersResponse struct {
+	Brokers []models.Broker       `json:"brokers"`
+	Presets []config.BrokerPreset `json:"presets"`
+}
+
+// CreateBrokerRequest selects or creates a broker from a built-in preset, or
+// defines a custom broker when preset_id is empty or "custom".
 type CreateBrokerRequest struct {
 	PresetID string `json:"preset_id"`
+	models.CreateCustomBrokerRequest
 }
 
-// CreateBroker creates a broker row for the authenticated user from a preset.
+// CreateBroker creates a broker row for the authenticated user from a preset
+// or from a custom definition.
 func CreateBroker(c fiber.Ctx) error {
 	userID, err := middleware.RequireUserID(c)
 	if err != nil {
@@ -49,19 +72,107 @@ func CreateBroker(c fiber.Ctx) error {
 
 	var req CreateBrokerRequest
 	if err := c.Bind().JSON(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		return apperror.New(apperror.Validation, "Invalid request body")
 	}
-	if req.PresetID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "preset_id is required"})
+
+	if req.PresetID == "" || req.PresetID == "custom" {
+		if strings.TrimSpace(req.Name) == "" {
+			return apperror.New(apperror.Validation, "preset_id is required (or name for a custom broker)")
+		}
+		broker, err := brokerService.CreateCustomBroker(c.Context(), userID, req.CreateCustomBrokerRequest)
+		if err != nil {
+			return err
+		}
+		return c.Status(fiber.StatusCreated).JSON(broker)
 	}
+
 	if config.GetBrokerPreset(req.PresetID) == nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Unknown preset"})
+		return apperror.New(apperror.Validation, "Unknown preset")
 	}
 
 	broker, err := brokerService.GetOrCreateBrokerFromPreset(c.Context(), userID, req.PresetID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(broker)
 }
+
+// UpdateBroker renames a broker or changes its country or currencies.
+func UpdateBroker(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.UpdateBrokerRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperror.New(apperror.Validation, "Invalid request body")
+	}
+
+	broker, err := brokerService.UpdateBroker(c.Context(), userID, c.Params("id"), req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(broker)
+}
+
+// DeleteBroker deletes a broker that no trade or cash flow references.
+func DeleteBroker(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := brokerService.DeleteBroker(c.Context(), userID, c.Params("id")); err != nil {
+		return err
+	}
+	return c.JSON(models.MessageResponse{Message: "Broker deleted successfully"})
+}
+
+// ListBrokerFeeSchedules returns every fee schedule version of a broker, newest first.
+func ListBrokerFeeSchedules(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	schedules, err := brokerService.ListFeeSchedules(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return err
+	}
+	return respondList(c, schedules)
+}
+
+// CreateBrokerFeeSchedule adds a fee schedule version effective from a date.
+// Fees for earlier dates keep using the previous version.
+func CreateBrokerFeeSchedule(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.BrokerFeeScheduleInput
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperror.New(apperror.Validation, "Invalid request body")
+	}
+
+	schedule, err := brokerService.SaveFeeSchedule(c.Context(), userID, c.Params("id"), req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(schedule)
+}
+
+// DeleteBrokerFeeSchedule removes a fee schedule version.
+func DeleteBrokerFeeSchedule(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := brokerService.DeleteFeeSchedule(c.Context(), userID, c.Params("id"), c.Params("scheduleId")); err != nil {
+		return err
+	}
+	return c.JSON(models.MessageResponse{Message: "Fee schedule deleted successfully"})
+}
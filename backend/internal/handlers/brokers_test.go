@@ -36,7 +36,7 @@ func seedBroker(t *testing.T, userID, presetID string) string {
 func TestListBrokers_Unauthorized(t *testing.T) {
 	t.Parallel()
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Get("/brokers", ListBrokers)
 
 	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/brokers", nil))
@@ -52,7 +52,7 @@ func TestListBrokers_Unauthorized(t *testing.T) {
 func TestCreateBroker_Unauthorized(t *testing.T) {
 	t.Parallel()
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Post("/brokers", CreateBroker)
 
 	req := httptest.NewRequest(http.MethodPost, "/brokers", strings.NewReader(`{"preset_id":"hapi-colombia"}`))
@@ -96,7 +96,7 @@ func TestCreateBroker_Validation(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			app := fiber.New()
+			app := newTestApp()
 			app.Use(withUser(userID))
 			app.Post("/brokers", CreateBroker)
 
@@ -121,7 +121,7 @@ func TestCreateBroker_Success(t *testing.T) {
 	userID := newTestUserID(t)
 	InitBrokerService(database.GetPool())
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Use(withUser(userID))
 	app.Post("/brokers", CreateBroker)
 
@@ -166,7 +166,7 @@ func TestListBrokers_ReturnsUserBrokersAndPresets(t *testing.T) {
 	InitBrokerService(database.GetPool())
 	seedBroker(t, userID, "hapi-colombia")
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Use(withUser(userID))
 	app.Get("/brokers", ListBrokers)
 
@@ -209,7 +209,7 @@ func TestListBrokers_isolation(t *testing.T) {
 	InitBrokerService(database.GetPool())
 	seedBroker(t, userA, "hapi-colombia")
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Use(withUser(userB))
 	app.Get("/brokers", ListBrokers)
 
@@ -237,3 +237,89 @@ func TestListBrokers_isolation(t *testing.T) {
 		t.Errorf("userB saw %d brokers, want 0", len(payload.Brokers))
 	}
 }
+
+func TestBrokerEditHandlers_Unauthorized(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		method  string
+		route   string
+		path    string
+		handler fiber.Handler
+	}{
+		{http.MethodPatch, "/brokers/:id", "/brokers/b-1", UpdateBroker},
+		{http.MethodDelete, "/brokers/:id", "/brokers/b-1", DeleteBroker},
+		{http.MethodGet, "/brokers/:id/fee-schedules", "/brokers/b-1/fee-schedules", ListBrokerFeeSchedules},
+		{http.MethodPost, "/brokers/:id/fee-schedules", "/brokers/b-1/fee-schedules", CreateBrokerFeeSchedule},
+		{http.MethodDelete, "/brokers/:id/fee-schedules/:scheduleId", "/brokers/b-1/fee-schedules/s-1", DeleteBrokerFeeSchedule},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			t.Parallel()
+
+			app := newTestApp()
+			app.Add([]string{tc.method}, tc.route, tc.handler)
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(`{}`))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			defer resp.Body.Close()
+
+			assertStatus(t, resp, http.StatusUnauthorized)
+			assertBodyContains(t, resp, "Unauthorized")
+		})
+	}
+}
+
+func TestCreateBroker_CustomWithFeeSchedule(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userID := newTestUserID(t)
+	InitBrokerService(database.GetPool())
+
+	app := newTestApp()
+	app.Use(withUser(userID))
+	app.Post("/brokers", CreateBroker)
+
+	body := `{"name":"Interactive Brokers","fee_schedule":{"deposit_fee":{"type":"percentage","value":"0.01","max":"10"},"commissions":{"stock":{"type":"flat","value":"1"}},"fx_spread":"0.002"}}`
+	req := httptest.NewRequest(http.MethodPost, "/brokers", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	assertStatus(t, resp, http.StatusCreated)
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	var broker struct {
+		ID          string `json:"id"`
+		PresetID    string `json:"preset_id"`
+		IsCustom    bool   `json:"is_custom"`
+		FeeSchedule *struct {
+			FXSpread string `json:"fx_spread"`
+		} `json:"fee_schedule"`
+	}
+	if err := json.Unmarshal(raw, &broker); err != nil {
+		t.Fatalf("unmarshal body: %v", err)
+	}
+	t.Cleanup(func() {
+		execSQL(t, "DELETE FROM brokers WHERE id = $1", broker.ID)
+	})
+
+	if !broker.IsCustom || broker.PresetID != "custom" {
+		t.Errorf("broker = %+v, want custom broker", broker)
+	}
+	if broker.FeeSchedule == nil || !strings.HasPrefix(broker.FeeSchedule.FXSpread, "0.002") {
+		t.Errorf("fee_schedule = %+v, want fx_spread 0.002", broker.FeeSchedule)
+	}
+}
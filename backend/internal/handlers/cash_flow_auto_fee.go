@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/middleware"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// QuoteCashFlowFee previews the broker fee that auto_fee would generate for a
+// deposit or withdrawal. It takes the same body as CreateCashFlow and writes nothing.
+func QuoteCashFlowFee(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.CreateCashFlowRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperror.New(apperror.Validation, "Invalid request body")
+	}
+	if err := validateAutoFeeRequest(req); err != nil {
+		return apperror.Classify(apperror.Validation, err)
+	}
+	if req.Currency == "" {
+		req.Currency = config.LocalCurrency
+	}
+	if req.Currency != config.LocalCurrency {
+		return apperror.Newf(apperror.Validation, "Deposits and withdrawals must use %s", config.LocalCurrency)
+	}
+
+	date, _, grossUsd, err := parseCashFlowAmounts(req)
+	if err != nil {
+		return apperror.Classify(apperror.Validation, err)
+	}
+
+	quote, err := brokerService.QuoteTransferFee(c.Context(), userID, *req.BrokerID, req.Type, date, grossUsd)
+	if err != nil {
+		return err
+	}
+	return c.JSON(quote)
+}
+
+// parseCashFlowAmounts parses the date, FX rate and gross USD value of a new cash flow.
+func parseCashFlowAmounts(req models.CreateCashFlowRequest) (time.Time, *decimal.Decimal, decimal.Decimal, error) {
+	amount, err := decimal.NewFromString(req.Amount)
+	if err != nil {
+		return time.Time{}, nil, decimal.Zero, fmt.Errorf("Invalid amount format")
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return time.Time{}, nil, decimal.Zero, fmt.Errorf("Invalid date format")
+	}
+
+	var fxRate *decimal.Decimal
+	if req.Currency == config.LocalCurrency {
+		if req.FxRate == nil || *req.FxRate == "" {
+			return time.Time{}, nil, decimal.Zero, apperror.Newf(apperror.Validation, "FX rate required for %s transactions", config.LocalCurrency)
+		}
+		rate, err := decimal.NewFromString(*req.FxRate)
+		if err != nil {
+			return time.Time{}, nil, decimal.Zero, fmt.Errorf("Invalid FX rate format")
+		}
+		fxRate = &rate
+	}
+
+	grossUsd, err := computeGrossUsd(req.Currency, amount, fxRate)
+	if err != nil {
+		return time.Time{}, nil, decimal.Zero, err
+	}
+	return date, fxRate, grossUsd, nil
+}
+
+func validateAutoFeeRequest(req models.CreateCashFlowRequest) error {
+	if !isTransferParentType(req.Type) {
+		return fmt.Errorf("Broker fees can only be generated for deposits and withdrawals")
+	}
+	if req.BrokerID == nil || *req.BrokerID == "" {
+		return fmt.Errorf("broker_id is required to generate the broker fee")
+	}
+	return nil
+}
+
+// insertAutoFeeCashFlow records the quoted broker fee as a USD fee linked to
+// parent, in the same transaction as the parent. It returns nil when the
+// broker charges nothing for this transfer.
+func insertAutoFeeCashFlow(ctx context.Context, tx pgx.Tx, parent models.CashFlow, quote *models.TransferFeeQuote) (*models.CashFlow, error) {
+	fee, err := decimal.NewFromString(quote.FeeUSD)
+	if err != nil {
+		return nil, fmt.Errorf("parse quoted fee: %w", err)
+	}
+	if !fee.IsPositive() {
+		return nil, nil
+	}
+
+	var feeFlow models.CashFlow
+	err = tx.QueryRow(ctx, `
+		INSERT INTO cash_flows (id, user_id, date, type, currency, amount, usd_amount, broker_id, fee_type, related_cash_flow_id, related_type, portfolio_id)
+		VALUES ($1, $2, $3, 'fee', $4, $5, $5, $6, $7, $8, $7, $9)
+		RETURNING `+cashFlowListColumns,
+		uuid.New().String(), parent.UserID, parent.Date, config.BaseCurrency, fee.String(), parent.BrokerID,
+		parent.Type, parent.ID, parent.PortfolioID).
+		Scan(
+			&feeFlow.ID, &feeFlow.UserID, &feeFlow.Date, &feeFlow.Type, &feeFlow.Currency,
+			&feeFlow.Amount, &feeFlow.FxRate, &feeFlow.UsdAmount, &feeFlow.PortfolioID, &feeFlow.BrokerID,
+			&feeFlow.Notes, &feeFlow.FeeType, &feeFlow.RelatedTradeID, &feeFlow.RelatedCashFlowID,
+			&feeFlow.RelatedType, &feeFlow.TransferID, &feeFlow.CreatedAt, &feeFlow.UpdatedAt,
+		)
+	if err != nil {
+		return nil, fmt.Errorf("insert broker fee: %w", err)
+	}
+	return &feeFlow, nil
+}
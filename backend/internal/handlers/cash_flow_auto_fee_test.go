@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/database"
+	"fintu-tracking-backend/internal/models"
+	"fintu-tracking-backend/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestQuoteCashFlowFee_Unauthorized(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp()
+	app.Post("/cash-flows/fee-quote", QuoteCashFlowFee)
+
+	req := httptest.NewRequest(http.MethodPost, "/cash-flows/fee-quote", strings.NewReader(`{"type":"deposit"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	assertStatus(t, resp, http.StatusUnauthorized)
+}
+
+func TestQuoteCashFlowFee_Validation(t *testing.T) {
+	t.Parallel()
+
+	brokerID := uuid.New().String()
+	cases := []struct {
+		name  string
+		body  string
+		error string
+	}{
+		{"fee type", `{"type":"fee","broker_id":"` + brokerID + `","date":"2024-01-15","amount":"100","currency":"USD"}`, "deposits and withdrawals"},
+		{"missing broker", `{"type":"deposit","date":"2024-01-15","amount":"1000000","currency":"COP","fx_rate":"4000"}`, "broker_id is required"},
+		{"usd deposit", `{"type":"deposit","broker_id":"` + brokerID + `","date":"2024-01-15","amount":"100","currency":"USD"}`, "must use COP"},
+		{"missing fx rate", `{"type":"deposit","broker_id":"` + brokerID + `","date":"2024-01-15","amount":"1000000"}`, "FX rate required"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newTestApp()
+			app.Post("/cash-flows/fee-quote", withUser(uuid.New().String()), QuoteCashFlowFee)
+
+			req := httptest.NewRequest(http.MethodPost, "/cash-flows/fee-quote", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			defer resp.Body.Close()
+
+			assertStatus(t, resp, http.StatusBadRequest)
+			assertBodyContains(t, resp, tc.error)
+		})
+	}
+}
+
+func TestCreateCashFlow_AutoFeeLinksBrokerFee(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userID := newTestUserID(t)
+	brokerID := seedBroker(t, userID, "hapi-colombia")
+	t.Cleanup(func() {
+		execSQL(t, "DELETE FROM cash_flows WHERE user_id = $1", userID)
+	})
+	InitWebhookService(services.NewWebhookService(database.GetPool()))
+	execSQL(t, `
+		INSERT INTO webhook_endpoints (user_id, url, events, secret)
+		VALUES ($1, $2, $3, $4)
+	`, userID, "https://hooks.example.com/cash", []string{config.WebhookEventCashFlowCreated}, "whsec_test")
+
+	app := newTestApp()
+	app.Post("/cash-flows", withUser(userID), CreateCashFlow)
+
+	body := `{"type":"deposit","date":"2024-01-15","currency":"COP","amount":"4000000","fx_rate":"4000","broker_id":"` + brokerID + `","auto_fee":true}`
+	req := httptest.NewRequest(http.MethodPost, "/cash-flows", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+	assertStatus(t, resp, http.StatusCreated)
+
+	var created models.CashFlow
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if created.AutoFee == nil {
+		t.Fatal("auto_fee missing from response")
+	}
+	if created.AutoFee.UsdAmount != "9" {
+		t.Errorf("fee usd_amount = %s, want 9 (0.9%% of 1000)", created.AutoFee.UsdAmount)
+	}
+	if created.AutoFee.RelatedCashFlowID == nil || *created.AutoFee.RelatedCashFlowID != created.ID {
+		t.Errorf("fee related_cash_flow_id = %v, want %s", created.AutoFee.RelatedCashFlowID, created.ID)
+	}
+	if created.UsdAmount != "991" {
+		t.Errorf("deposit usd_amount = %s, want 991 net of the fee", created.UsdAmount)
+	}
+
+	rows, err := database.GetPool().Query(context.Background(), `
+		SELECT payload->'data'->>'id' FROM webhook_deliveries
+		WHERE user_id = $1 AND event_type = $2
+	`, userID, config.WebhookEventCashFlowCreated)
+	if err != nil {
+		t.Fatalf("query webhook deliveries: %v", err)
+	}
+	published, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		t.Fatalf("collect webhook deliveries: %v", err)
+	}
+	if !slices.Contains(published, created.ID) || !slices.Contains(published, created.AutoFee.ID) || len(published) != 2 {
+		t.Errorf("cash_flow.created published for %v, want the deposit %s and its fee %s", published, created.ID, created.AutoFee.ID)
+	}
+}
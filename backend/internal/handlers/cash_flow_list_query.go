@@ -13,6 +13,13 @@ type cashFlowListFilters struct {
 	flowType        string
 	currency        string
 	excludeMirrored bool
+	portfolio       string
+}
+
+// cashFlowSortColumns are the ?sort= keys of cash flow lists.
+var cashFlowSortColumns = map[string]sortColumn{
+	"date":       {"date", "date"},
+	"usd_amount": {"usd_amount", "numeric"},
 }
 
 func appendCashFlowListFilters(query string, args []interface{}, filters cashFlowListFilters) (string, []interface{}) {
@@ -38,6 +45,11 @@ func appendCashFlowListFilters(query string, args []interface{}, filters cashFlo
 		query += fmt.Sprintf(" AND currency = $%d", argN)
 		args = append(args, filters.currency)
 	}
+	if filters.portfolio != "" {
+		argN++
+		query += fmt.Sprintf(" AND portfolio_id = $%d", argN)
+		args = append(args, filters.portfolio)
+	}
 	if filters.excludeMirrored {
 		query += " AND NOT (type = 'fee' AND related_trade_id IS NOT NULL)"
 	}
@@ -52,14 +64,14 @@ func buildCountCashFlowsQuery(userID string, filters cashFlowListFilters) (strin
 	return query, args
 }
 
-func buildListCashFlowsQuery(userID string, filters cashFlowListFilters, limit, offset int) (string, []interface{}) {
+func buildListCashFlowsQuery(userID string, filters cashFlowListFilters, order listOrder, limit, offset int) (string, []interface{}) {
 	query := `
 		SELECT ` + cashFlowListColumns + `
 		FROM cash_flows
 		WHERE user_id = $1`
 	args := []interface{}{userID}
 	query, args = appendCashFlowListFilters(query, args, filters)
-	query += " ORDER BY date DESC"
+	query, args = order.appendTo(query, args)
 
 	if limit > 0 {
 		argN := len(args) + 1
@@ -80,7 +92,7 @@ func parseCashFlowListFilters(fromStr, toStr, flowType, currency, excludeMirrore
 		excludeMirrored: true,
 	}
 
-	if filters.flowType != "" && filters.flowType != "deposit" && filters.flowType != "withdrawal" && filters.flowType != "fee" && filters.flowType != "cash_adjustment" {
+	if filters.flowType != "" && !isValidCashFlowType(filters.flowType) && !isTransferLegType(filters.flowType) {
 		return filters, fmt.Errorf("invalid type")
 	}
 	if filters.currency != "" && !isValidCashFlowCurrency(filters.currency) {
@@ -40,7 +40,7 @@ func TestBuildListCashFlowsQuery_Filters(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	query, args := buildListCashFlowsQuery("user-1", filters, 10, 0)
+	query, args := buildListCashFlowsQuery("user-1", filters, listOrder{}, 10, 0)
 	if strings.Contains(query, "linked_transfer_fee_usd") {
 		t.Fatalf("query must not include linked_transfer_fee_usd subquery: %s", query)
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"fintu-tracking-backend/internal/apperror"
 	"fintu-tracking-backend/internal/config"
 	"fintu-tracking-backend/internal/database"
 
@@ -15,7 +16,7 @@ func computeGrossUsd(currency string, amount decimal.Decimal, fxRate *decimal.De
 		return amount, nil
 	}
 	if fxRate == nil {
-		return decimal.Zero, fmt.Errorf("FX rate required for %s transactions", config.LocalCurrency)
+		return decimal.Zero, apperror.Newf(apperror.Validation, "FX rate required for %s transactions", config.LocalCurrency)
 	}
 	if fxRate.IsZero() {
 		return decimal.Zero, fmt.Errorf("FX rate must be non-zero")
@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"errors"
+	"fintu-tracking-backend/internal/apperror"
 	"fintu-tracking-backend/internal/config"
 	"fintu-tracking-backend/internal/database"
 	"fintu-tracking-backend/internal/middleware"
@@ -18,17 +19,18 @@ import (
 )
 
 const cashFlowListColumns = `
-	id, user_id, date, type, currency, amount, fx_rate, usd_amount, broker_id, notes,
-	fee_type, related_trade_id, related_cash_flow_id, related_type, created_at, updated_at
+	id, user_id, date, type, currency, amount, fx_rate, usd_amount, portfolio_id, broker_id, notes,
+	fee_type, related_trade_id, related_cash_flow_id, related_type, transfer_id, created_at, updated_at
 `
 
 // ListCashFlows returns cash flows for the authenticated user.
 // Without page/page_size query params, returns a plain JSON array (legacy).
-// With page or page_size, returns models.PaginatedResponse.
+// With page or page_size, returns models.PaginatedResponse. v2 always returns
+// a models.Page.
 func ListCashFlows(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
 	}
 
 	filters, err := parseCashFlowListFilters(
@@ -39,7 +41,18 @@ func ListCashFlows(c fiber.Ctx) error {
 		c.Query("exclude_mirrored"),
 	)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return apperror.Classify(apperror.Validation, err)
+	}
+	filters.portfolio, err = resolvePortfolioScope(c, userID)
+	if err != nil {
+		return err
+	}
+	sort, err := parseListSort(c.Query("sort"), cashFlowSortColumns)
+	if err != nil {
+		return apperror.Classify(apperror.Validation, err)
+	}
+	if apiVersion(c) >= APIv2 {
+		return listCashFlowsPage(c, userID, filters, sort)
 	}
 
 	pageStr := c.Query("page")
@@ -53,7 +66,7 @@ func ListCashFlows(c fiber.Ctx) error {
 	if paginationRequested(pageStr, pageSizeStr) {
 		params, err := parsePaginationParams(pageStr, pageSizeStr)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+			return apperror.Classify(apperror.Validation, err)
 		}
 		page = params.page
 		pageSize = params.pageSize
@@ -64,28 +77,18 @@ func ListCashFlows(c fiber.Ctx) error {
 	var total int
 	if limit > 0 {
 		countQuery, countArgs := buildCountCashFlowsQuery(userID, filters)
-		if err := database.GetPool().QueryRow(context.Background(), countQuery, countArgs...).Scan(&total); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		if err := database.GetPool().QueryRow(c.Context(), countQuery, countArgs...).Scan(&total); err != nil {
+			return err
 		}
 		page = clampPage(page, total, pageSize)
 		offset = (page - 1) * pageSize
 	}
 
-	query, args := buildListCashFlowsQuery(userID, filters, limit, offset)
+	query, args := buildListCashFlowsQuery(userID, filters, listOrder{sort: sort}, limit, offset)
 
-	rows, err := database.GetPool().Query(context.Background(), query, args...)
+	cashFlows, err := queryCashFlows(c.Context(), query, args)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
-	}
-	defer rows.Close()
-
-	cashFlows := make([]models.CashFlow, 0)
-	for rows.Next() {
-		var cf models.CashFlow
-		if err := scanCashFlowListRow(rows, &cf); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
-		}
-		cashFlows = append(cashFlows, cf)
+		return err
 	}
 
 	if limit > 0 {
@@ -100,6 +103,53 @@ func ListCashFlows(c fiber.Ctx) error {
 	return c.JSON(cashFlows)
 }
 
+// listCashFlowsPage answers /api/v2/cash-flows with the page after ?cursor= in ?sort= order.
+// Pages are keyed on the sort column and (date, created_at, id), so rows
+// inserted while scrolling neither repeat nor shift later pages. The total is
+// only counted when ?include_total=true.
+func listCashFlowsPage(c fiber.Ctx, userID string, filters cashFlowListFilters, sort listSort) error {
+	page, err := keysetPageFromQuery(c, sort)
+	if err != nil {
+		return err
+	}
+	query, args := buildListCashFlowsQuery(userID, filters, page.order, page.limit+1, 0)
+	cashFlows, err := queryCashFlows(c.Context(), query, args)
+	if err != nil {
+		return err
+	}
+	out := keysetPageOf(cashFlows, page, func(cf models.CashFlow) []string {
+		return sort.cursorKey(cf.UsdAmount, cf.Date, cf.CreatedAt, cf.ID)
+	})
+
+	if c.Query("include_total") == "true" {
+		var total int
+		countQuery, countArgs := buildCountCashFlowsQuery(userID, filters)
+		if err := database.GetPool().QueryRow(c.Context(), countQuery, countArgs...).Scan(&total); err != nil {
+			return err
+		}
+		out.Total = &total
+	}
+	return c.JSON(out)
+}
+
+func queryCashFlows(ctx context.Context, query string, args []interface{}) ([]models.CashFlow, error) {
+	rows, err := database.GetPool().Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cashFlows := make([]models.CashFlow, 0)
+	for rows.Next() {
+		var cf models.CashFlow
+		if err := scanCashFlowListRow(rows, &cf); err != nil {
+			return nil, err
+		}
+		cashFlows = append(cashFlows, cf)
+	}
+	return cashFlows, rows.Err()
+}
+
 type cashFlowScanner interface {
 	Scan(dest ...any) error
 }
@@ -107,8 +157,8 @@ type cashFlowScanner interface {
 func scanCashFlowRow(row cashFlowScanner, cf *models.CashFlow) error {
 	return row.Scan(
 		&cf.ID, &cf.UserID, &cf.Date, &cf.Type, &cf.Currency, &cf.Amount, &cf.FxRate, &cf.UsdAmount,
-		&cf.BrokerID, &cf.Notes, &cf.FeeType, &cf.RelatedTradeID, &cf.RelatedCashFlowID, &cf.RelatedType,
-		&cf.CreatedAt, &cf.UpdatedAt,
+		&cf.PortfolioID, &cf.BrokerID, &cf.Notes, &cf.FeeType, &cf.RelatedTradeID, &cf.RelatedCashFlowID,
+		&cf.RelatedType, &cf.TransferID, &cf.CreatedAt, &cf.UpdatedAt,
 	)
 }
 
@@ -116,76 +166,81 @@ func scanCashFlowListRow(row cashFlowScanner, cf *models.CashFlow) error {
 	return scanCashFlowRow(row, cf)
 }
 
+// loadCashFlow returns one of the user's cash flows.
+func loadCashFlow(ctx context.Context, userID, id string) (models.CashFlow, error) {
+	var cf models.CashFlow
+	row := database.GetPool().QueryRow(ctx, `SELECT `+cashFlowListColumns+` FROM cash_flows WHERE id = $1 AND user_id = $2`, id, userID)
+	err := scanCashFlowRow(row, &cf)
+	return cf, err
+}
+
 // CreateCashFlow creates a new cash flow
 func CreateCashFlow(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
 	}
 
 	var req models.CreateCashFlowRequest
 	if err := c.Bind().JSON(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		return apperror.New(apperror.Validation, "Invalid request body")
 	}
 
 	if !isValidCashFlowType(req.Type) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid type"})
+		return apperror.New(apperror.Validation, "Invalid type")
 	}
 	if !isValidCashFlowCurrency(req.Currency) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid currency"})
+		return apperror.New(apperror.Validation, "Invalid currency")
 	}
 	if (req.Type == "deposit" || req.Type == "withdrawal") && req.Currency != config.LocalCurrency {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("Deposits and withdrawals must use %s", config.LocalCurrency)})
+		return apperror.Newf(apperror.Validation, "Deposits and withdrawals must use %s", config.LocalCurrency)
 	}
 	if req.Type == "cash_adjustment" {
 		if req.Currency != config.BaseCurrency {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("Cash adjustments must use %s", config.BaseCurrency)})
+			return apperror.Newf(apperror.Validation, "Cash adjustments must use %s", config.BaseCurrency)
 		}
 		if req.Notes == nil || strings.TrimSpace(*req.Notes) == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Notes are required for cash adjustments"})
+			return apperror.New(apperror.Validation, "Notes are required for cash adjustments")
 		}
 	}
 	if err := validateFeeLinkage(req.Type, req.RelatedCashFlowID, req.RelatedTradeID); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return apperror.Classify(apperror.Validation, err)
 	}
 	if err := validateBrokerID(c.Context(), userID, req.BrokerID); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
-
-	amount, err := decimal.NewFromString(req.Amount)
+	portfolioID, err := resolvePortfolioID(c.Context(), userID, req.PortfolioID)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid amount format"})
+		return err
 	}
 
-	date, err := time.Parse("2006-01-02", req.Date)
+	date, fxRate, grossUsd, err := parseCashFlowAmounts(req)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid date format"})
+		return apperror.Classify(apperror.Validation, err)
 	}
 
-	var fxRate *decimal.Decimal
-	if req.Currency == config.LocalCurrency {
-		if req.FxRate == nil || *req.FxRate == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("FX rate required for %s transactions", config.LocalCurrency)})
+	usdAmount := grossUsd
+
+	var feeQuote *models.TransferFeeQuote
+	if req.AutoFee {
+		if err := validateAutoFeeRequest(req); err != nil {
+			return apperror.Classify(apperror.Validation, err)
 		}
-		rate, err := decimal.NewFromString(*req.FxRate)
+		feeQuote, err = brokerService.QuoteTransferFee(c.Context(), userID, *req.BrokerID, req.Type, date, grossUsd)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid FX rate format"})
+			return err
+		}
+		usdAmount, err = decimal.NewFromString(feeQuote.NetUSD)
+		if err != nil {
+			return err
 		}
-		fxRate = &rate
-	}
-
-	grossUsd, err := computeGrossUsd(req.Currency, amount, fxRate)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	usdAmount := grossUsd
-
 	id := uuid.New().String()
 
 	query := `
-		INSERT INTO cash_flows (id, user_id, date, type, currency, amount, fx_rate, usd_amount, broker_id, notes, fee_type, related_trade_id, related_cash_flow_id, related_type)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		INSERT INTO cash_flows (id, user_id, date, type, currency, amount, fx_rate, usd_amount, broker_id, notes, fee_type, related_trade_id, related_cash_flow_id, related_type, portfolio_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 		RETURNING ` + cashFlowListColumns + `
 	`
 
@@ -196,25 +251,47 @@ func CreateCashFlow(c fiber.Ctx) error {
 		fxRateStr = &s
 	}
 
-	err = database.GetPool().QueryRow(context.Background(), query,
+	tx, err := database.GetPool().Begin(c.Context())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(c.Context())
+
+	err = tx.QueryRow(c.Context(), query,
 		id, userID, date, req.Type, req.Currency, req.Amount, fxRateStr, usdAmount.String(), req.BrokerID, req.Notes,
-		req.FeeType, req.RelatedTradeID, req.RelatedCashFlowID, req.RelatedType).
+		req.FeeType, req.RelatedTradeID, req.RelatedCashFlowID, req.RelatedType, portfolioID).
 		Scan(
 			&cashFlow.ID, &cashFlow.UserID, &cashFlow.Date, &cashFlow.Type, &cashFlow.Currency,
-			&cashFlow.Amount, &cashFlow.FxRate, &cashFlow.UsdAmount, &cashFlow.BrokerID, &cashFlow.Notes,
-			&cashFlow.FeeType, &cashFlow.RelatedTradeID, &cashFlow.RelatedCashFlowID, &cashFlow.RelatedType,
-			&cashFlow.CreatedAt, &cashFlow.UpdatedAt,
+			&cashFlow.Amount, &cashFlow.FxRate, &cashFlow.UsdAmount, &cashFlow.PortfolioID, &cashFlow.BrokerID,
+			&cashFlow.Notes, &cashFlow.FeeType, &cashFlow.RelatedTradeID, &cashFlow.RelatedCashFlowID,
+			&cashFlow.RelatedType, &cashFlow.TransferID, &cashFlow.CreatedAt, &cashFlow.UpdatedAt,
 		)
 
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
+	}
+
+	if feeQuote != nil {
+		autoFee, err := insertAutoFeeCashFlow(c.Context(), tx, cashFlow, feeQuote)
+		if err != nil {
+			return err
+		}
+		cashFlow.AutoFee = autoFee
+	}
+
+	if err := tx.Commit(c.Context()); err != nil {
+		return err
 	}
 
 	if req.Type == "fee" && req.RelatedCashFlowID != nil {
-		if err := recomputeTransferNetUSD(context.Background(), *req.RelatedCashFlowID, userID); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		if err := recomputeTransferNetUSD(c.Context(), *req.RelatedCashFlowID, userID); err != nil {
+			return err
 		}
 	}
+	publishWebhookEvent(userID, config.WebhookEventCashFlowCreated, cashFlow)
+	if cashFlow.AutoFee != nil {
+		publishWebhookEvent(userID, config.WebhookEventCashFlowCreated, cashFlow.AutoFee)
+	}
 
 	return c.Status(fiber.StatusCreated).JSON(cashFlow)
 }
@@ -223,22 +300,25 @@ func CreateCashFlow(c fiber.Ctx) error {
 func UpdateCashFlow(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
 	}
 
 	id := c.Params("id")
 	var req models.UpdateCashFlowRequest
 	if err := c.Bind().JSON(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		return apperror.New(apperror.Validation, "Invalid request body")
 	}
 
 	var existingCF models.CashFlow
-	query := `SELECT date, type, currency, amount, fx_rate, broker_id, fee_type, related_trade_id, related_cash_flow_id, related_type FROM cash_flows WHERE id = $1 AND user_id = $2`
-	err := database.GetPool().QueryRow(context.Background(), query, id, userID).
-		Scan(&existingCF.Date, &existingCF.Type, &existingCF.Currency, &existingCF.Amount, &existingCF.FxRate,
+	query := `SELECT date, type, currency, amount, fx_rate, portfolio_id, broker_id, fee_type, related_trade_id, related_cash_flow_id, related_type FROM cash_flows WHERE id = $1 AND user_id = $2`
+	err := database.GetPool().QueryRow(c.Context(), query, id, userID).
+		Scan(&existingCF.Date, &existingCF.Type, &existingCF.Currency, &existingCF.Amount, &existingCF.FxRate, &existingCF.PortfolioID,
 			&existingCF.BrokerID, &existingCF.FeeType, &existingCF.RelatedTradeID, &existingCF.RelatedCashFlowID, &existingCF.RelatedType)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Cash flow not found"})
+		return apperror.New(apperror.NotFound, "Cash flow not found")
+	}
+	if isTransferLegType(existingCF.Type) {
+		return apperror.New(apperror.Validation, "Portfolio transfers cannot be edited; delete and recreate the transfer")
 	}
 
 	originalType := existingCF.Type
@@ -247,19 +327,19 @@ func UpdateCashFlow(c fiber.Ctx) error {
 	if req.Date != nil {
 		parsedDate, err := time.Parse("2006-01-02", *req.Date)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid date format"})
+			return apperror.New(apperror.Validation, "Invalid date format")
 		}
 		existingCF.Date = parsedDate
 	}
 	if req.Type != nil {
 		if !isValidCashFlowType(*req.Type) {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid type"})
+			return apperror.New(apperror.Validation, "Invalid type")
 		}
 		existingCF.Type = *req.Type
 	}
 	if req.Currency != nil {
 		if !isValidCashFlowCurrency(*req.Currency) {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid currency"})
+			return apperror.New(apperror.Validation, "Invalid currency")
 		}
 		existingCF.Currency = *req.Currency
 	}
@@ -272,6 +352,12 @@ func UpdateCashFlow(c fiber.Ctx) error {
 	if req.BrokerID != nil {
 		existingCF.BrokerID = req.BrokerID
 	}
+	if req.PortfolioID != nil {
+		if _, err := portfolioService.GetPortfolio(c.Context(), userID, *req.PortfolioID); err != nil {
+			return err
+		}
+		existingCF.PortfolioID = *req.PortfolioID
+	}
 	if req.FeeType != nil {
 		existingCF.FeeType = req.FeeType
 	}
@@ -289,50 +375,50 @@ func UpdateCashFlow(c fiber.Ctx) error {
 	}
 
 	if err := validateBrokerID(c.Context(), userID, existingCF.BrokerID); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	if (existingCF.Type == "deposit" || existingCF.Type == "withdrawal") && existingCF.Currency != config.LocalCurrency {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("Deposits and withdrawals must use %s", config.LocalCurrency)})
+		return apperror.Newf(apperror.Validation, "Deposits and withdrawals must use %s", config.LocalCurrency)
 	}
 	if existingCF.Type == "cash_adjustment" {
 		if existingCF.Currency != config.BaseCurrency {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("Cash adjustments must use %s", config.BaseCurrency)})
+			return apperror.Newf(apperror.Validation, "Cash adjustments must use %s", config.BaseCurrency)
 		}
 		if existingCF.Notes == nil || strings.TrimSpace(*existingCF.Notes) == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Notes are required for cash adjustments"})
+			return apperror.New(apperror.Validation, "Notes are required for cash adjustments")
 		}
 	}
 	if err := validateFeeLinkage(existingCF.Type, existingCF.RelatedCashFlowID, existingCF.RelatedTradeID); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return apperror.Classify(apperror.Validation, err)
 	}
 
 	amount, err := decimal.NewFromString(existingCF.Amount)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid amount format"})
+		return apperror.New(apperror.Validation, "Invalid amount format")
 	}
 	var fxRateDec *decimal.Decimal
 	if existingCF.Currency == config.LocalCurrency {
 		if existingCF.FxRate == nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("FX rate required for %s", config.LocalCurrency)})
+			return apperror.Newf(apperror.Validation, "FX rate required for %s", config.LocalCurrency)
 		}
 		rate, err := decimal.NewFromString(*existingCF.FxRate)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid FX rate format"})
+			return apperror.New(apperror.Validation, "Invalid FX rate format")
 		}
 		fxRateDec = &rate
 	}
 
 	grossUsd, err := computeGrossUsd(existingCF.Currency, amount, fxRateDec)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return apperror.Classify(apperror.Validation, err)
 	}
 
 	usdAmount := grossUsd
 	if isTransferParentType(existingCF.Type) {
-		linkedFeesSum, err := sumLinkedTransferFeesUSD(context.Background(), id)
+		linkedFeesSum, err := sumLinkedTransferFeesUSD(c.Context(), id)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			return err
 		}
 		usdAmount = computeNetTransferUsd(grossUsd, []decimal.Decimal{linkedFeesSum})
 	}
@@ -340,27 +426,34 @@ func UpdateCashFlow(c fiber.Ctx) error {
 	updateQuery := `
 		UPDATE cash_flows
 		SET date = $1, type = $2, currency = $3, amount = $4, fx_rate = $5, usd_amount = $6, broker_id = $7, notes = $8,
-			fee_type = $9, related_trade_id = $10, related_cash_flow_id = $11, related_type = $12, updated_at = NOW()
-		WHERE id = $13 AND user_id = $14
+			fee_type = $9, related_trade_id = $10, related_cash_flow_id = $11, related_type = $12, portfolio_id = $13, updated_at = NOW()
+		WHERE id = $14 AND user_id = $15
 	`
 
-	result, err := database.GetPool().Exec(context.Background(), updateQuery,
+	result, err := database.GetPool().Exec(c.Context(), updateQuery,
 		existingCF.Date, existingCF.Type, existingCF.Currency, existingCF.Amount,
 		existingCF.FxRate, usdAmount.String(), existingCF.BrokerID, existingCF.Notes,
 		existingCF.FeeType, existingCF.RelatedTradeID, existingCF.RelatedCashFlowID, existingCF.RelatedType,
-		id, userID)
+		existingCF.PortfolioID, id, userID)
 
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	if result.RowsAffected() == 0 {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Cash flow not found"})
+		return apperror.New(apperror.NotFound, "Cash flow not found")
 	}
 
 	if isTransferParentType(existingCF.Type) {
-		if err := recomputeTransferNetUSD(context.Background(), id, userID); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		if err := recomputeTransferNetUSD(c.Context(), id, userID); err != nil {
+			return err
+		}
+		// Linked deposit/withdrawal fees follow their parent into its portfolio.
+		if _, err := database.GetPool().Exec(c.Context(), `
+			UPDATE cash_flows SET portfolio_id = $1, updated_at = NOW()
+			WHERE related_cash_flow_id = $2 AND user_id = $3 AND portfolio_id <> $1
+		`, existingCF.PortfolioID, id, userID); err != nil {
+			return err
 		}
 	}
 
@@ -373,56 +466,76 @@ func UpdateCashFlow(c fiber.Ctx) error {
 			parents[*existingCF.RelatedCashFlowID] = struct{}{}
 		}
 		for parentID := range parents {
-			if err := recomputeTransferNetUSD(context.Background(), parentID, userID); err != nil {
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			if err := recomputeTransferNetUSD(c.Context(), parentID, userID); err != nil {
+				return err
 			}
 		}
 	}
+	if cashFlow, err := loadCashFlow(c.Context(), userID, id); err == nil {
+		publishWebhookEvent(userID, config.WebhookEventCashFlowUpdated, cashFlow)
+	}
 
-	return c.JSON(fiber.Map{"message": "Cash flow updated successfully"})
+	return c.JSON(models.MessageResponse{Message: "Cash flow updated successfully"})
 }
 
 // DeleteCashFlow deletes a cash flow
 func DeleteCashFlow(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
 	}
 
 	id := c.Params("id")
 
 	var flowType string
 	var relatedParentID *string
-	err := database.GetPool().QueryRow(context.Background(),
-		`SELECT type, related_cash_flow_id FROM cash_flows WHERE id = $1 AND user_id = $2`, id, userID).
-		Scan(&flowType, &relatedParentID)
+	var transferID *string
+	err := database.GetPool().QueryRow(c.Context(),
+		`SELECT type, related_cash_flow_id, transfer_id FROM cash_flows WHERE id = $1 AND user_id = $2`, id, userID).
+		Scan(&flowType, &relatedParentID, &transferID)
 	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
+	}
+
+	// Deleting either leg of a portfolio transfer removes the whole transfer.
+	if transferID != nil {
+		if err := portfolioService.DeleteTransfer(c.Context(), userID, *transferID); err != nil {
+			return err
+		}
+		publishWebhookEvent(userID, config.WebhookEventCashFlowDeleted, fiber.Map{"id": id, "transfer_id": *transferID})
+		return c.JSON(models.MessageResponse{Message: "Cash flow deleted successfully"})
 	}
 
 	query := `DELETE FROM cash_flows WHERE id = $1 AND user_id = $2`
-	result, err := database.GetPool().Exec(context.Background(), query, id, userID)
+	result, err := database.GetPool().Exec(c.Context(), query, id, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fmt.Sprintf("Error: %v", err)})
+		return err
 	}
 
 	if result.RowsAffected() == 0 {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Cash flow not found"})
+		return apperror.New(apperror.NotFound, "Cash flow not found")
 	}
 
 	if flowType == "fee" && relatedParentID != nil {
-		if err := recomputeTransferNetUSD(context.Background(), *relatedParentID, userID); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		if err := recomputeTransferNetUSD(c.Context(), *relatedParentID, userID); err != nil {
+			return err
 		}
 	}
+	publishWebhookEvent(userID, config.WebhookEventCashFlowDeleted, fiber.Map{"id": id})
 
-	return c.JSON(fiber.Map{"message": "Cash flow deleted successfully"})
+	return c.JSON(models.MessageResponse{Message: "Cash flow deleted successfully"})
 }
 
 func isValidCashFlowType(flowType string) bool {
 	return flowType == "deposit" || flowType == "withdrawal" || flowType == "fee" || flowType == "cash_adjustment"
 }
 
+// isTransferLegType reports whether flowType is one side of a portfolio transfer.
+// Transfer legs are only created through POST /api/portfolios/transfers.
+func isTransferLegType(flowType string) bool {
+	return flowType == "transfer_in" || flowType == "transfer_out"
+}
+
 func isValidCashFlowCurrency(currency string) bool {
 	return currency == config.BaseCurrency || currency == config.LocalCurrency
 }
@@ -445,7 +558,7 @@ func validateBrokerID(ctx context.Context, userID string, brokerID *string) erro
 	).Scan(&found)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return fmt.Errorf("invalid broker_id")
+			return apperror.New(apperror.Validation, "invalid broker_id")
 		}
 		return fmt.Errorf("validating broker: %w", err)
 	}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/middleware"
+	"fintu-tracking-backend/internal/models"
+	"fintu-tracking-backend/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+var dcaService = services.NewDCAService(nil)
+
+// InitDCAService sets the package-level DCA service used by handlers. It is
+// called once from main.go with the service that also runs the scheduler.
+func InitDCAService(svc *services.DCAService) {
+	dcaService = svc
+}
+
+// ListDCAPlans handles GET /api/dca-plans.
+func ListDCAPlans(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	plans, err := dcaService.ListPlans(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	return respondList(c, plans)
+}
+
+// GetDCAPlan handles GET /api/dca-plans/:id.
+func GetDCAPlan(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	plan, err := dcaService.GetPlan(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(plan)
+}
+
+// CreateDCAPlan handles POST /api/dca-plans.
+func CreateDCAPlan(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.CreateDCAPlanRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperror.New(apperror.Validation, "Invalid request body")
+	}
+
+	plan, err := dcaService.CreatePlan(c.Context(), userID, req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(plan)
+}
+
+// UpdateDCAPlan handles PATCH /api/dca-plans/:id, including pausing (active
+// false) and resuming a plan.
+func UpdateDCAPlan(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.UpdateDCAPlanRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperror.New(apperror.Validation, "Invalid request body")
+	}
+
+	plan, err := dcaService.UpdatePlan(c.Context(), userID, c.Params("id"), req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(plan)
+}
+
+// DeleteDCAPlan handles DELETE /api/dca-plans/:id.
+func DeleteDCAPlan(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := dcaService.DeletePlan(c.Context(), userID, c.Params("id")); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ListDCAInstallments handles GET /api/dca-installments and
+// GET /api/dca-plans/:id/installments. ?status= filters by planned, confirmed
+// or skipped; ?status=planned lists the contributions awaiting confirmation.
+func ListDCAInstallments(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	status := c.Query("status")
+	if status != "" && status != "planned" && status != "confirmed" && status != "skipped" {
+		return apperror.New(apperror.Validation, "status must be planned, confirmed or skipped")
+	}
+
+	installments, err := dcaService.ListInstallments(c.Context(), userID, c.Params("id"), status)
+	if err != nil {
+		return err
+	}
+	return respondList(c, installments)
+}
+
+// GenerateDCAInstallments handles POST /api/dca-installments/generate. It
+// creates the user's due installments right away instead of waiting for the
+// next scheduler run.
+func GenerateDCAInstallments(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	created, err := dcaService.GenerateDueInstallments(c.Context(), userID, time.Now())
+	if err != nil {
+		return err
+	}
+	return c.JSON(models.GenerateDCAInstallmentsResponse{Created: created})
+}
+
+// ConfirmDCAInstallment handles POST /api/dca-installments/:id/confirm,
+// recording the deposit and buy trades of a planned installment.
+func ConfirmDCAInstallment(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.ConfirmDCAInstallmentRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperror.New(apperror.Validation, "Invalid request body")
+	}
+
+	installment, err := dcaService.ConfirmInstallment(c.Context(), userID, c.Params("id"), req)
+	if err != nil {
+		return err
+	}
+	publishDCAConfirmation(userID, installment)
+	return c.JSON(installment)
+}
+
+// publishDCAConfirmation sends the deposit and trades a confirmation created
+// to the user's webhooks, like entering them by hand would.
+func publishDCAConfirmation(userID string, installment *models.DCAInstallment) {
+	ctx := context.Background()
+	if installment.CashFlowID != nil {
+		if cashFlow, err := loadCashFlow(ctx, userID, *installment.CashFlowID); err == nil {
+			publishWebhookEvent(userID, config.WebhookEventCashFlowCreated, cashFlow)
+		}
+	}
+	for _, tradeID := range installment.TradeIDs {
+		if trade, err := loadTrade(ctx, userID, tradeID); err == nil {
+			publishWebhookEvent(userID, config.WebhookEventTradeCreated, trade)
+		}
+	}
+}
+
+// SkipDCAInstallment handles POST /api/dca-installments/:id/skip.
+func SkipDCAInstallment(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	installment, err := dcaService.SkipInstallment(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(installment)
+}
+
+// GetDCAReport handles GET /api/dca-plans/:id/report with optional ?from= and
+// ?to= dates (YYYY-MM-DD).
+func GetDCAReport(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	from, err := parseDCAReportDate(c.Query("from"))
+	if err != nil {
+		return apperror.New(apperror.Validation, "from must be YYYY-MM-DD")
+	}
+	to, err := parseDCAReportDate(c.Query("to"))
+	if err != nil {
+		return apperror.New(apperror.Validation, "to must be YYYY-MM-DD")
+	}
+
+	report, err := dcaService.GetAdherenceReport(c.Context(), userID, c.Params("id"), from, to)
+	if err != nil {
+		return err
+	}
+	return c.JSON(report)
+}
+
+// parseDCAReportDate parses an optional YYYY-MM-DD query value; empty is nil.
+func parseDCAReportDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
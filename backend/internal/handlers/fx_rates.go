@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"fmt"
+	"fintu-tracking-backend/internal/apperror"
 	"fintu-tracking-backend/internal/config"
 	"fintu-tracking-backend/internal/database"
 	"fintu-tracking-backend/internal/middleware"
@@ -19,19 +20,19 @@ import (
 
 // exchangeRateSvc is a package-level singleton so the in-memory cache persists
 // across requests for the lifetime of the process.
-var exchangeRateSvc = services.NewExchangeRateService(nil)
+var exchangeRateSvc = services.NewExchangeRateService(nil, "")
 
-// InitExchangeRateService wires the DB pool into the singleton. Call this after
-// database.Connect() in main.go.
-func InitExchangeRateService() {
-	exchangeRateSvc = services.NewExchangeRateService(database.GetPool())
+// InitExchangeRateService wires the DB pool and Twelve Data API key into the
+// singleton. Call this after database.Connect() in main.go.
+func InitExchangeRateService(apiKey string) {
+	exchangeRateSvc = services.NewExchangeRateService(database.GetPool(), apiKey)
 }
 
 // ListFxRates returns all FX rates for the authenticated user
 func ListFxRates(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
 	}
 
 	query := `
@@ -43,7 +44,7 @@ func ListFxRates(c fiber.Ctx) error {
 
 	rows, err := database.GetPool().Query(c.Context(), query, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 	defer rows.Close()
 
@@ -51,35 +52,35 @@ func ListFxRates(c fiber.Ctx) error {
 	for rows.Next() {
 		var rate models.FxRate
 		if err := rows.Scan(&rate.ID, &rate.UserID, &rate.Date, &rate.Rate, &rate.Source, &rate.CreatedAt); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			return err
 		}
 		fxRates = append(fxRates, rate)
 	}
 
-	return c.JSON(fxRates)
+	return respondList(c, fxRates)
 }
 
 // CreateFxRate creates a new FX rate
 func CreateFxRate(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
 	}
 
 	var req models.CreateFxRateRequest
 	if err := c.Bind().JSON(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		return apperror.New(apperror.Validation, "Invalid request body")
 	}
 
 	// Validate rate
 	if _, err := decimal.NewFromString(req.Rate); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid rate format"})
+		return apperror.New(apperror.Validation, "Invalid rate format")
 	}
 
 	// Parse date
 	date, err := time.Parse("2006-01-02", req.Date)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid date format"})
+		return apperror.New(apperror.Validation, "Invalid date format")
 	}
 
 	id := uuid.New().String()
@@ -101,8 +102,9 @@ func CreateFxRate(c fiber.Ctx) error {
 		Scan(&fxRate.ID, &fxRate.UserID, &fxRate.Date, &fxRate.Rate, &fxRate.Source, &fxRate.CreatedAt)
 
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
+	alertService.EvaluateInBackground(userID)
 
 	return c.Status(fiber.StatusCreated).JSON(fxRate)
 }
@@ -111,13 +113,13 @@ func CreateFxRate(c fiber.Ctx) error {
 func UpdateFxRate(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
 	}
 
 	id := c.Params("id")
 	var req models.UpdateFxRateRequest
 	if err := c.Bind().JSON(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		return apperror.New(apperror.Validation, "Invalid request body")
 	}
 
 	// Build dynamic update query using fmt.Sprintf for placeholder indices
@@ -129,7 +131,7 @@ func UpdateFxRate(c fiber.Ctx) error {
 	if req.Date != nil {
 		date, err := time.Parse("2006-01-02", *req.Date)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid date format"})
+			return apperror.New(apperror.Validation, "Invalid date format")
 		}
 		query += fmt.Sprintf("date = $%d, ", argCount)
 		args = append(args, date)
@@ -138,7 +140,7 @@ func UpdateFxRate(c fiber.Ctx) error {
 
 	if req.Rate != nil {
 		if _, err := decimal.NewFromString(*req.Rate); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid rate format"})
+			return apperror.New(apperror.Validation, "Invalid rate format")
 		}
 		query += fmt.Sprintf("rate = $%d, ", argCount)
 		args = append(args, *req.Rate)
@@ -152,7 +154,7 @@ func UpdateFxRate(c fiber.Ctx) error {
 	}
 
 	if len(args) == 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "No fields to update"})
+		return apperror.New(apperror.Validation, "No fields to update")
 	}
 
 	// Remove trailing ", " and append WHERE clause.
@@ -161,21 +163,21 @@ func UpdateFxRate(c fiber.Ctx) error {
 
 	result, err := database.GetPool().Exec(c.Context(), query, args...)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	if result.RowsAffected() == 0 {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "FX rate not found"})
+		return apperror.New(apperror.NotFound, "FX rate not found")
 	}
 
-	return c.JSON(fiber.Map{"message": "FX rate updated successfully"})
+	return c.JSON(models.MessageResponse{Message: "FX rate updated successfully"})
 }
 
 // DeleteFxRate deletes an FX rate
 func DeleteFxRate(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
 	}
 
 	id := c.Params("id")
@@ -183,21 +185,21 @@ func DeleteFxRate(c fiber.Ctx) error {
 	query := `DELETE FROM fx_rates WHERE id = $1 AND user_id = $2`
 	result, err := database.GetPool().Exec(c.Context(), query, id, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	if result.RowsAffected() == 0 {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "FX rate not found"})
+		return apperror.New(apperror.NotFound, "FX rate not found")
 	}
 
-	return c.JSON(fiber.Map{"message": "FX rate deleted successfully"})
+	return c.JSON(models.MessageResponse{Message: "FX rate deleted successfully"})
 }
 
 // GetFxRateChart returns daily USD/COP closes from Twelve Data for charting.
 func GetFxRateChart(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
 	}
 
 	days := 30
@@ -209,9 +211,7 @@ func GetFxRateChart(c fiber.Ctx) error {
 
 	points, err := exchangeRateSvc.FetchDailyHistory(c.Context(), days)
 	if err != nil {
-		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return c.JSON(points)
@@ -229,7 +229,7 @@ func GetFxRateChart(c fiber.Ctx) error {
 func GetCurrentRate(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
 	}
 
 	from := strings.ToUpper(strings.TrimSpace(c.Query("from", config.BaseCurrency)))
@@ -238,17 +238,18 @@ func GetCurrentRate(c fiber.Ctx) error {
 	// Validate supported pairs.
 	pair := from + "/" + to
 	if !slices.Contains(config.SupportedCurrencyPairs, pair) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": fmt.Sprintf("unsupported currency pair: only %s are supported", strings.Join(config.SupportedCurrencyPairs, ", ")),
-		})
+		return apperror.Newf(apperror.Validation, "unsupported currency pair: only %s are supported", strings.Join(config.SupportedCurrencyPairs, ", "))
 	}
 
 	// Always fetch the base USD→COP rate (cached; no extra API call for the inverse).
 	base, err := exchangeRateSvc.FetchCurrentRate(c.Context(), userID)
 	if err != nil {
-		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-			"error": "could not retrieve current exchange rate: " + err.Error(),
-		})
+		return err
+	}
+	// Cached and fallback rows carry CachedAt; a zero value means the rate was
+	// just fetched and stored.
+	if base.CachedAt.IsZero() {
+		alertService.EvaluateInBackground(userID)
 	}
 
 	rate := base.Rate
@@ -257,20 +258,28 @@ func GetCurrentRate(c fiber.Ctx) error {
 	if pair == config.InverseCurrencyPair {
 		baseDecimal, parseErr := decimal.NewFromString(base.Rate)
 		if parseErr != nil || baseDecimal.IsZero() {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "invalid base rate, cannot compute inverse",
-			})
+			return fmt.Errorf("invalid base rate %q, cannot compute inverse", base.Rate)
 		}
 		rate = decimal.NewFromInt(1).Div(baseDecimal).StringFixed(6)
 	}
 
 	// Use the date from the service result to avoid midnight skew between the
 	// cache-key computation in the service and the timestamp in this handler.
-	return c.JSON(fiber.Map{
-		"rate":   rate,
-		"date":   base.Date,
-		"source": base.Source,
-		"from":   from,
-		"to":     to,
+	return c.JSON(CurrentRateResponse{
+		Rate:   rate,
+		Date:   base.Date,
+		Source: base.Source,
+		From:   from,
+		To:     to,
 	})
 }
+
+// CurrentRateResponse is the body of GET /api/fx-rates/current: the rate
+// to convert one unit of From into To.
+type CurrentRateResponse struct {
+	Rate   string `json:"rate"`
+	Date   string `json:"date"`
+	Source string `json:"source"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"strconv"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/middleware"
+	"fintu-tracking-backend/internal/models"
+	"fintu-tracking-backend/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var goalService = services.NewGoalService(nil)
+
+// InitGoalService sets the package-level goal service used by handlers.
+// It is called once from main.go after the DB pool is available.
+func InitGoalService(pool *pgxpool.Pool) {
+	goalService = services.NewGoalService(pool)
+}
+
+// ListGoals handles GET /api/goals.
+func ListGoals(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	goals, err := goalService.ListGoals(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	return respondList(c, goals)
+}
+
+// GetGoal handles GET /api/goals/:id.
+func GetGoal(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	goal, err := goalService.GetGoal(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(goal)
+}
+
+// CreateGoal handles POST /api/goals.
+func CreateGoal(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.CreateGoalRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperror.New(apperror.Validation, "Invalid request body")
+	}
+
+	goal, err := goalService.CreateGoal(c.Context(), userID, req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(goal)
+}
+
+// UpdateGoal handles PATCH /api/goals/:id. An empty monthly_contribution clears
+// it so projections fall back to the active DCA plans.
+func UpdateGoal(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.UpdateGoalRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperror.New(apperror.Validation, "Invalid request body")
+	}
+
+	goal, err := goalService.UpdateGoal(c.Context(), userID, c.Params("id"), req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(goal)
+}
+
+// DeleteGoal handles DELETE /api/goals/:id.
+func DeleteGoal(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := goalService.DeleteGoal(c.Context(), userID, c.Params("id")); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetGoalProjection handles GET /api/goals/:id/projection with an optional
+// ?simulations= count for the Monte Carlo run.
+func GetGoalProjection(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	simulations := config.DefaultGoalSimulations
+	if raw := c.Query("simulations"); raw != "" {
+		simulations, err = strconv.Atoi(raw)
+		if err != nil || simulations < 1 || simulations > config.MaxGoalSimulations {
+			return apperror.Newf(apperror.Validation, "simulations must be between 1 and %d", config.MaxGoalSimulations)
+		}
+	}
+
+	projection, err := goalService.ProjectGoal(c.Context(), userID, c.Params("id"), simulations)
+	if err != nil {
+		return err
+	}
+	return c.JSON(projection)
+}
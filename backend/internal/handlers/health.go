@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"fintu-tracking-backend/internal/models"
+	"fintu-tracking-backend/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// healthService backs the readiness probe. It is initialized once from
+// main.go after migrations have run.
+var healthService *services.HealthService
+
+// InitHealthService sets the package-level health service used by Readyz.
+func InitHealthService(svc *services.HealthService) {
+	healthService = svc
+}
+
+// Livez handles GET /livez. It only reports that the process is serving
+// requests, so orchestrators restart it when it hangs but not when a
+// dependency is down.
+func Livez(c fiber.Ctx) error {
+	return c.JSON(models.Liveness{
+		Status:  services.ReadinessOK,
+		Service: "fintu-tracking-api",
+	})
+}
+
+// Readyz handles GET /readyz. It answers 503 when the database is
+// unreachable or its schema is behind this build, so load balancers stop
+// routing to the instance.
+func Readyz(c fiber.Ctx) error {
+	report := healthService.Readiness(c.Context())
+	status := fiber.StatusOK
+	if report.Status != services.ReadinessOK {
+		status = fiber.StatusServiceUnavailable
+	}
+	return c.Status(status).JSON(report)
+}
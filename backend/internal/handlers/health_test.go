@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fintu-tracking-backend/internal/services"
+)
+
+type stubHealthStore struct {
+	pingErr error
+}
+
+func (s stubHealthStore) Ping(context.Context) error {
+	return s.pingErr
+}
+
+func (s stubHealthStore) MigrationVersion(context.Context) (uint, bool, error) {
+	return 3, false, s.pingErr
+}
+
+func TestHealthProbes(t *testing.T) {
+	app := newTestApp()
+	app.Get("/livez", Livez)
+	app.Get("/readyz", Readyz)
+
+	tests := []struct {
+		name   string
+		store  stubHealthStore
+		path   string
+		status int
+		body   string
+	}{
+		{name: "live", store: stubHealthStore{pingErr: errors.New("down")}, path: "/livez", status: http.StatusOK, body: `"status":"ok"`},
+		{name: "ready", path: "/readyz", status: http.StatusOK, body: `"database":{"status":"ok"}`},
+		{name: "database down", store: stubHealthStore{pingErr: errors.New("down")}, path: "/readyz", status: http.StatusServiceUnavailable, body: `"status":"unavailable"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			InitHealthService(services.NewHealthService(tc.store, 3, true))
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, tc.path, nil))
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			defer resp.Body.Close()
+			assertStatus(t, resp, tc.status)
+			assertBodyContains(t, resp, tc.body)
+		})
+	}
+}
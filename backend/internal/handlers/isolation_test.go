@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -13,16 +14,24 @@ import (
 	"testing"
 	"time"
 
+	"fintu-tracking-backend/internal/config"
 	"fintu-tracking-backend/internal/database"
+	"fintu-tracking-backend/internal/middleware"
 	"fintu-tracking-backend/internal/models"
 	"fintu-tracking-backend/internal/services"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 var testDBOnce sync.Once
 
+// newTestApp returns an app that renders handler errors like the server does.
+func newTestApp() *fiber.App {
+	return fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+}
+
 func initTestDB(t *testing.T) {
 	t.Helper()
 	testDBOnce.Do(func() {
@@ -30,10 +39,7 @@ func initTestDB(t *testing.T) {
 		if testURL == "" {
 			return
 		}
-		if err := os.Setenv("DATABASE_URL", testURL); err != nil {
-			t.Fatalf("set DATABASE_URL: %v", err)
-		}
-		if err := database.Connect(); err != nil {
+		if err := database.Connect(testURL); err != nil {
 			t.Fatalf("connect to TEST_DATABASE_URL: %v", err)
 		}
 	})
@@ -161,6 +167,27 @@ func jsonLen(t *testing.T, resp *http.Response) int {
 	return len(arr)
 }
 
+// doJSON sends a request with a JSON body (empty for none) and closes the
+// response when the test ends.
+func doJSON(t *testing.T, app *fiber.App, method, path, body string) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func decodeJSON(t *testing.T, resp *http.Response, v any) {
+	t.Helper()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+}
+
 func TestListTrades_isolation(t *testing.T) {
 	skipIfNoTestDB(t)
 
@@ -169,7 +196,7 @@ func TestListTrades_isolation(t *testing.T) {
 	seedTrade(t, userA, "AAPL")
 	seedTrade(t, userB, "MSFT")
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Use(withUser(userA))
 	app.Get("/trades", ListTrades)
 
@@ -192,7 +219,7 @@ func TestUpdateTrade_isolation(t *testing.T) {
 	userB := newTestUserID(t)
 	tradeB := seedTrade(t, userB, "TSLA")
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Use(withUser(userA))
 	app.Put("/trades/:id", UpdateTrade)
 
@@ -217,7 +244,7 @@ func TestDeleteCashFlow_isolation(t *testing.T) {
 	userB := newTestUserID(t)
 	cashFlowB := seedCashFlow(t, userB)
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Use(withUser(userA))
 	app.Delete("/cash-flows/:id", DeleteCashFlow)
 
@@ -239,7 +266,7 @@ func TestListFxRates_isolation(t *testing.T) {
 	seedFxRate(t, userA)
 	seedFxRate(t, userB)
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Use(withUser(userA))
 	app.Get("/fx-rates", ListFxRates)
 
@@ -263,7 +290,7 @@ func TestActivityFeed_isolation(t *testing.T) {
 	seedTrade(t, userA, "AAPL")
 	seedCashFlow(t, userB)
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Use(withUser(userA))
 	app.Get("/activity/feed", GetActivityFeed)
 
@@ -279,6 +306,34 @@ func TestActivityFeed_isolation(t *testing.T) {
 	}
 }
 
+func TestListActivity_isolation(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userA := newTestUserID(t)
+	userB := newTestUserID(t)
+	seedTrade(t, userA, "AAPL")
+	seedCashFlow(t, userB)
+
+	app := newTestApp()
+	app.Use(withUser(userA))
+	app.Get("/activity", ListActivity)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/activity", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	assertStatus(t, resp, http.StatusOK)
+	var page models.Page[models.ActivityEntry]
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(page.Items) != 1 || page.Items[0].Trade == nil || page.Items[0].Trade.Ticker != "AAPL" {
+		t.Errorf("items = %+v, want user A's trade only", page.Items)
+	}
+}
+
 func seedSubscription(t *testing.T, userID string) string {
 	t.Helper()
 	id := uuid.New().String()
@@ -302,7 +357,7 @@ func TestGetSubscription_isolation(t *testing.T) {
 	billingSvc := services.NewBillingService(database.GetPool(), services.NewNoOpBillingProvider())
 	InitBillingService(billingSvc)
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Use(withUser(userB))
 	app.Get("/subscriptions/current", GetSubscription)
 
@@ -332,7 +387,7 @@ func TestCancelSubscription_isolation(t *testing.T) {
 	billingSvc := services.NewBillingService(database.GetPool(), services.NewNoOpBillingProvider())
 	InitBillingService(billingSvc)
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Use(withUser(userB))
 	app.Patch("/subscriptions/:id/cancel", CancelSubscription)
 
@@ -362,7 +417,7 @@ func TestRefreshMarketPrices_cooldownIsPerUser(t *testing.T) {
 		execSQL(t, "DELETE FROM market_price_refresh_log WHERE user_id = $1", userB)
 	})
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Use(withUser(userB))
 	app.Post("/market-prices/refresh", RefreshMarketPrices)
 
@@ -374,3 +429,1194 @@ func TestRefreshMarketPrices_cooldownIsPerUser(t *testing.T) {
 
 	assertStatus(t, resp, http.StatusOK)
 }
+
+func seedGoal(t *testing.T, userID string) string {
+	t.Helper()
+	id := uuid.New().String()
+	execSQL(t, `
+		INSERT INTO goals (id, user_id, name, target_amount, currency, target_date)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, id, userID, "House", "100000", "USD", time.Now().UTC().AddDate(5, 0, 0).Format("2006-01-02"))
+	return id
+}
+
+func TestGoals_isolation(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userA := newTestUserID(t)
+	userB := newTestUserID(t)
+	goalA := seedGoal(t, userA)
+	seedGoal(t, userB)
+	InitGoalService(database.GetPool())
+
+	app := newTestApp()
+	app.Use(withUser(userB))
+	app.Get("/goals", ListGoals)
+	app.Get("/goals/:id", GetGoal)
+	app.Patch("/goals/:id", UpdateGoal)
+	app.Delete("/goals/:id", DeleteGoal)
+	app.Get("/goals/:id/projection", GetGoalProjection)
+
+	resp := doJSON(t, app, http.MethodGet, "/goals", "")
+	assertStatus(t, resp, http.StatusOK)
+	if got := jsonLen(t, resp); got != 1 {
+		t.Errorf("returned %d goals, want 1", got)
+	}
+
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodGet, "/goals/" + goalA, ""},
+		{http.MethodPatch, "/goals/" + goalA, `{"name":"Mine now"}`},
+		{http.MethodGet, "/goals/" + goalA + "/projection", ""},
+		{http.MethodDelete, "/goals/" + goalA, ""},
+	} {
+		resp := doJSON(t, app, tc.method, tc.path, tc.body)
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s %s: status = %d, want 404", tc.method, tc.path, resp.StatusCode)
+		}
+	}
+
+	var name string
+	if err := database.GetPool().QueryRow(context.Background(), `SELECT name FROM goals WHERE id = $1`, goalA).Scan(&name); err != nil {
+		t.Fatalf("user A's goal is gone: %v", err)
+	}
+	if name != "House" {
+		t.Errorf("user A's goal name = %q, want House", name)
+	}
+}
+
+func TestGoals_lifecycle(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userID := newTestUserID(t)
+	InitGoalService(database.GetPool())
+
+	app := newTestApp()
+	app.Use(withUser(userID))
+	app.Post("/goals", CreateGoal)
+	app.Patch("/goals/:id", UpdateGoal)
+	app.Delete("/goals/:id", DeleteGoal)
+	app.Get("/goals/:id/projection", GetGoalProjection)
+
+	targetDate := time.Now().UTC().AddDate(10, 0, 0).Format("2006-01-02")
+	resp := doJSON(t, app, http.MethodPost, "/goals",
+		fmt.Sprintf(`{"name":"Retirement","target_amount":"250000","currency":"USD","target_date":%q,"monthly_contribution":"500"}`, targetDate))
+	assertStatus(t, resp, http.StatusCreated)
+	var goal models.Goal
+	decodeJSON(t, resp, &goal)
+	if goal.Name != "Retirement" || goal.Currency != "USD" || goal.MonthlyContribution == nil {
+		t.Fatalf("goal = %+v", goal)
+	}
+
+	resp = doJSON(t, app, http.MethodPost, "/goals",
+		fmt.Sprintf(`{"name":"Retirement","target_amount":"1","target_date":%q}`, targetDate))
+	assertStatus(t, resp, http.StatusConflict)
+
+	resp = doJSON(t, app, http.MethodGet, "/goals/"+goal.ID+"/projection?simulations=50", "")
+	assertStatus(t, resp, http.StatusOK)
+	var projection models.GoalProjection
+	decodeJSON(t, resp, &projection)
+	if projection.GoalID != goal.ID || projection.ContributionSource != "goal" || projection.MonthlyContributionUSD != "500.00" {
+		t.Errorf("projection = %+v", projection)
+	}
+
+	resp = doJSON(t, app, http.MethodPatch, "/goals/"+goal.ID, `{"monthly_contribution":""}`)
+	assertStatus(t, resp, http.StatusOK)
+	decodeJSON(t, resp, &goal)
+	if goal.MonthlyContribution != nil {
+		t.Errorf("monthly_contribution = %v, want cleared", *goal.MonthlyContribution)
+	}
+
+	assertStatus(t, doJSON(t, app, http.MethodDelete, "/goals/"+goal.ID, ""), http.StatusNoContent)
+	assertStatus(t, doJSON(t, app, http.MethodGet, "/goals/"+goal.ID+"/projection", ""), http.StatusNotFound)
+}
+
+func seedAlertRule(t *testing.T, userID string) string {
+	t.Helper()
+	id := uuid.New().String()
+	execSQL(t, `
+		INSERT INTO alert_rules (id, user_id, name, kind, direction, threshold)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, id, userID, "Drawdown", "drawdown", "above", "10")
+	return id
+}
+
+func initTestAlertService() {
+	pool := database.GetPool()
+	InitAlertService(services.NewAlertService(pool, services.NewNotificationService(pool), services.NewWebhookService(pool), config.SMTPSettings{}))
+}
+
+func TestAlertRules_isolation(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userA := newTestUserID(t)
+	userB := newTestUserID(t)
+	ruleA := seedAlertRule(t, userA)
+	seedAlertRule(t, userB)
+	initTestAlertService()
+
+	app := newTestApp()
+	app.Use(withUser(userB))
+	app.Get("/alerts", ListAlertRules)
+	app.Get("/alerts/:id", GetAlertRule)
+	app.Patch("/alerts/:id", UpdateAlertRule)
+	app.Delete("/alerts/:id", DeleteAlertRule)
+
+	resp := doJSON(t, app, http.MethodGet, "/alerts", "")
+	assertStatus(t, resp, http.StatusOK)
+	if got := jsonLen(t, resp); got != 1 {
+		t.Errorf("returned %d alert rules, want 1", got)
+	}
+
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodGet, "/alerts/" + ruleA, ""},
+		{http.MethodPatch, "/alerts/" + ruleA, `{"active":false}`},
+		{http.MethodDelete, "/alerts/" + ruleA, ""},
+	} {
+		resp := doJSON(t, app, tc.method, tc.path, tc.body)
+		assertStatus(t, resp, http.StatusNotFound)
+		assertBodyContains(t, resp, "alert rule not found")
+	}
+
+	var active bool
+	if err := database.GetPool().QueryRow(context.Background(), `SELECT active FROM alert_rules WHERE id = $1`, ruleA).Scan(&active); err != nil {
+		t.Fatalf("user A's alert rule is gone: %v", err)
+	}
+	if !active {
+		t.Error("user B paused user A's alert rule")
+	}
+}
+
+func TestAlertEvents_isolation(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userA := newTestUserID(t)
+	userB := newTestUserID(t)
+	ruleA := seedAlertRule(t, userA)
+	execSQL(t, `
+		INSERT INTO alert_events (rule_id, user_id, kind, message, value, threshold)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, ruleA, userA, "drawdown", "Drawdown reached 12%", "12", "10")
+	initTestAlertService()
+
+	app := newTestApp()
+	app.Use(withUser(userB))
+	app.Get("/alerts/events", ListAlertEvents)
+
+	resp := doJSON(t, app, http.MethodGet, "/alerts/events", "")
+	assertStatus(t, resp, http.StatusOK)
+	if got := jsonLen(t, resp); got != 0 {
+		t.Errorf("returned %d alert events, want 0", got)
+	}
+
+	resp = doJSON(t, app, http.MethodGet, "/alerts/events?rule_id="+ruleA, "")
+	assertStatus(t, resp, http.StatusNotFound)
+}
+
+func TestAlertRules_lifecycle(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userID := newTestUserID(t)
+	initTestAlertService()
+
+	app := newTestApp()
+	app.Use(withUser(userID))
+	app.Post("/alerts", CreateAlertRule)
+	app.Patch("/alerts/:id", UpdateAlertRule)
+	app.Delete("/alerts/:id", DeleteAlertRule)
+
+	resp := doJSON(t, app, http.MethodPost, "/alerts", `{"name":"COP move","kind":"fx_move","threshold":"2"}`)
+	assertStatus(t, resp, http.StatusCreated)
+	var rule models.AlertRule
+	decodeJSON(t, resp, &rule)
+	if rule.Kind != "fx_move" || rule.Direction != "any" || !rule.Active {
+		t.Fatalf("rule = %+v", rule)
+	}
+
+	resp = doJSON(t, app, http.MethodPatch, "/alerts/"+rule.ID, `{"threshold":"3","active":false}`)
+	assertStatus(t, resp, http.StatusOK)
+	decodeJSON(t, resp, &rule)
+	if rule.Active {
+		t.Error("rule is still active after pausing it")
+	}
+
+	// A webhook alert must use one of the user's own webhook endpoints.
+	resp = doJSON(t, app, http.MethodPatch, "/alerts/"+rule.ID, `{"channels":["webhook"],"webhook_url":"https://hooks.example.com/alerts"}`)
+	assertStatus(t, resp, http.StatusBadRequest)
+	assertBodyContains(t, resp, "webhook_url must be the url of one of your webhooks")
+
+	execSQL(t, `
+		INSERT INTO webhook_endpoints (user_id, url, events, secret)
+		VALUES ($1, $2, $3, $4)
+	`, userID, "https://hooks.example.com/alerts", []string{"alert.triggered"}, "whsec_test")
+	resp = doJSON(t, app, http.MethodPatch, "/alerts/"+rule.ID, `{"channels":["webhook"],"webhook_url":"https://hooks.example.com/alerts"}`)
+	assertStatus(t, resp, http.StatusOK)
+
+	assertStatus(t, doJSON(t, app, http.MethodDelete, "/alerts/"+rule.ID, ""), http.StatusNoContent)
+	assertStatus(t, doJSON(t, app, http.MethodDelete, "/alerts/"+rule.ID, ""), http.StatusNotFound)
+}
+
+func TestAlertRules_sharedPriceRefresh(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userA := newTestUserID(t)
+	userB := newTestUserID(t)
+	initTestAlertService()
+	ticker := "T" + strings.ToUpper(strings.ReplaceAll(uuid.New().String(), "-", "")[:7])
+	ruleB := uuid.New().String()
+	execSQL(t, `
+		INSERT INTO alert_rules (id, user_id, name, kind, ticker, direction, threshold)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, ruleB, userB, "Breakout", "price_cross", ticker, "above", "100")
+	t.Cleanup(func() {
+		execSQL(t, "DELETE FROM market_prices WHERE ticker = $1", ticker)
+	})
+
+	// User A's refresh stores the shared price and evaluates the rules on it.
+	execSQL(t, `
+		INSERT INTO market_prices (ticker, price, currency, updated_at)
+		VALUES ($1, $2, $3, NOW())
+	`, ticker, "120", "USD")
+	alertService.EvaluateTickersInBackground(userA, []string{ticker})
+	alertService.Wait()
+
+	var fired int
+	if err := database.GetPool().QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM alert_events WHERE rule_id = $1 AND user_id = $2`, ruleB, userB).Scan(&fired); err != nil {
+		t.Fatalf("count alert events: %v", err)
+	}
+	if fired != 1 {
+		t.Errorf("user B's rule fired %d times after user A's refresh, want 1", fired)
+	}
+}
+
+func seedNotification(t *testing.T, userID string) string {
+	t.Helper()
+	id := uuid.New().String()
+	execSQL(t, `
+		INSERT INTO notifications (id, user_id, type, title, body)
+		VALUES ($1, $2, $3, $4, $5)
+	`, id, userID, "alert", "VOO above 500", "VOO crossed above 500 USD")
+	return id
+}
+
+func TestNotifications_isolation(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userA := newTestUserID(t)
+	userB := newTestUserID(t)
+	notificationA := seedNotification(t, userA)
+	seedNotification(t, userB)
+	InitNotificationService(services.NewNotificationService(database.GetPool()))
+
+	app := newTestApp()
+	app.Use(withUser(userB))
+	app.Get("/notifications", ListNotifications)
+	app.Post("/notifications/read-all", MarkAllNotificationsRead)
+	app.Post("/notifications/:id/read", MarkNotificationRead)
+
+	resp := doJSON(t, app, http.MethodGet, "/notifications", "")
+	assertStatus(t, resp, http.StatusOK)
+	var page models.PaginatedResponse[models.Notification]
+	decodeJSON(t, resp, &page)
+	if page.Total != 1 || len(page.Items) != 1 || page.Items[0].ID == notificationA {
+		t.Errorf("page = %+v, want only user B's notification", page)
+	}
+
+	resp = doJSON(t, app, http.MethodPost, "/notifications/"+notificationA+"/read", "")
+	assertStatus(t, resp, http.StatusNotFound)
+	assertBodyContains(t, resp, "notification not found")
+
+	resp = doJSON(t, app, http.MethodPost, "/notifications/read-all", "")
+	assertStatus(t, resp, http.StatusOK)
+	var marked models.NotificationsMarkedRead
+	decodeJSON(t, resp, &marked)
+	if marked.Updated != 1 {
+		t.Errorf("updated = %d, want 1", marked.Updated)
+	}
+
+	var unread bool
+	if err := database.GetPool().QueryRow(context.Background(), `SELECT read_at IS NULL FROM notifications WHERE id = $1`, notificationA).Scan(&unread); err != nil {
+		t.Fatalf("query user A's notification: %v", err)
+	}
+	if !unread {
+		t.Error("user B marked user A's notification read")
+	}
+}
+
+func TestNotifications_markRead(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userID := newTestUserID(t)
+	first := seedNotification(t, userID)
+	seedNotification(t, userID)
+	seedNotification(t, userID)
+	InitNotificationService(services.NewNotificationService(database.GetPool()))
+
+	app := newTestApp()
+	app.Use(withUser(userID))
+	app.Get("/notifications", ListNotifications)
+	app.Get("/notifications/unread-count", GetUnreadNotificationCount)
+	app.Post("/notifications/read-all", MarkAllNotificationsRead)
+	app.Post("/notifications/:id/read", MarkNotificationRead)
+
+	unreadCount := func() int {
+		t.Helper()
+		resp := doJSON(t, app, http.MethodGet, "/notifications/unread-count", "")
+		assertStatus(t, resp, http.StatusOK)
+		var count models.UnreadNotificationCount
+		decodeJSON(t, resp, &count)
+		return count.Unread
+	}
+	if got := unreadCount(); got != 3 {
+		t.Fatalf("unread = %d, want 3", got)
+	}
+
+	resp := doJSON(t, app, http.MethodPost, "/notifications/"+first+"/read", "")
+	assertStatus(t, resp, http.StatusOK)
+	var notification models.Notification
+	decodeJSON(t, resp, &notification)
+	if notification.ID != first || notification.ReadAt == nil {
+		t.Errorf("notification = %+v, want read", notification)
+	}
+	if got := unreadCount(); got != 2 {
+		t.Errorf("unread after marking one read = %d, want 2", got)
+	}
+
+	resp = doJSON(t, app, http.MethodGet, "/notifications?unread=true", "")
+	assertStatus(t, resp, http.StatusOK)
+	var page models.PaginatedResponse[models.Notification]
+	decodeJSON(t, resp, &page)
+	if page.Total != 2 {
+		t.Errorf("unread total = %d, want 2", page.Total)
+	}
+
+	resp = doJSON(t, app, http.MethodPost, "/notifications/read-all", "")
+	assertStatus(t, resp, http.StatusOK)
+	var marked models.NotificationsMarkedRead
+	decodeJSON(t, resp, &marked)
+	if marked.Updated != 2 {
+		t.Errorf("updated = %d, want 2", marked.Updated)
+	}
+	if got := unreadCount(); got != 0 {
+		t.Errorf("unread after read-all = %d, want 0", got)
+	}
+}
+
+func TestNotifications_streamAcrossInstances(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userID := newTestUserID(t)
+	// Two services stand in for two API replicas sharing the database.
+	streaming := services.NewNotificationService(database.GetPool())
+	creating := services.NewNotificationService(database.GetPool())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go streaming.RunListener(ctx)
+	stream, unsubscribe := streaming.Subscribe(userID)
+	defer unsubscribe()
+
+	// The listener may not be listening yet, so notify until one arrives.
+	deadline := time.After(10 * time.Second)
+	for {
+		if _, err := creating.Notify(ctx, userID, services.NotificationInput{
+			Type:  config.NotificationTypeAlert,
+			Title: "VOO above 500",
+			Body:  "VOO crossed above 500 USD",
+		}); err != nil {
+			t.Fatalf("Notify: %v", err)
+		}
+		select {
+		case got := <-stream:
+			if got.Title != "VOO above 500" {
+				t.Errorf("streamed %+v", got)
+			}
+			return
+		case <-time.After(200 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("a notification created on another instance never reached the stream")
+		}
+	}
+}
+
+func seedWebhookEndpoint(t *testing.T, userID, url string) string {
+	t.Helper()
+	id := uuid.New().String()
+	execSQL(t, `
+		INSERT INTO webhook_endpoints (id, user_id, url, events, secret)
+		VALUES ($1, $2, $3, $4, $5)
+	`, id, userID, url, []string{"trade.created"}, "whsec_test")
+	return id
+}
+
+func TestWebhooks_isolation(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userA := newTestUserID(t)
+	userB := newTestUserID(t)
+	endpointA := seedWebhookEndpoint(t, userA, "https://hooks.example.com/a")
+	seedWebhookEndpoint(t, userB, "https://hooks.example.com/b")
+	InitWebhookService(services.NewWebhookService(database.GetPool()))
+
+	app := newTestApp()
+	app.Use(withUser(userB))
+	app.Get("/webhooks", ListWebhooks)
+	app.Get("/webhooks/:id", GetWebhook)
+	app.Patch("/webhooks/:id", UpdateWebhook)
+	app.Delete("/webhooks/:id", DeleteWebhook)
+	app.Get("/webhooks/:id/deliveries", ListWebhookDeliveries)
+	app.Post("/webhooks/:id/ping", PingWebhook)
+
+	resp := doJSON(t, app, http.MethodGet, "/webhooks", "")
+	assertStatus(t, resp, http.StatusOK)
+	if got := jsonLen(t, resp); got != 1 {
+		t.Errorf("returned %d webhook endpoints, want 1", got)
+	}
+
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodGet, "/webhooks/" + endpointA, ""},
+		{http.MethodPatch, "/webhooks/" + endpointA, `{"url":"https://attacker.example.com/hook"}`},
+		{http.MethodGet, "/webhooks/" + endpointA + "/deliveries", ""},
+		{http.MethodPost, "/webhooks/" + endpointA + "/ping", ""},
+		{http.MethodDelete, "/webhooks/" + endpointA, ""},
+	} {
+		resp := doJSON(t, app, tc.method, tc.path, tc.body)
+		assertStatus(t, resp, http.StatusNotFound)
+		assertBodyContains(t, resp, "webhook endpoint not found")
+	}
+
+	var url string
+	if err := database.GetPool().QueryRow(context.Background(), `SELECT url FROM webhook_endpoints WHERE id = $1`, endpointA).Scan(&url); err != nil {
+		t.Fatalf("user A's webhook endpoint is gone: %v", err)
+	}
+	if url != "https://hooks.example.com/a" {
+		t.Errorf("user A's webhook url = %q", url)
+	}
+}
+
+func TestWebhooks_lifecycle(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userID := newTestUserID(t)
+	InitWebhookService(services.NewWebhookService(database.GetPool()))
+
+	app := newTestApp()
+	app.Use(withUser(userID))
+	app.Post("/webhooks", CreateWebhook)
+	app.Get("/webhooks/:id", GetWebhook)
+	app.Patch("/webhooks/:id", UpdateWebhook)
+	app.Delete("/webhooks/:id", DeleteWebhook)
+	app.Get("/webhooks/:id/deliveries", ListWebhookDeliveries)
+
+	resp := doJSON(t, app, http.MethodPost, "/webhooks", `{"url":"http://localhost:9000/hook","events":["trade.created"]}`)
+	assertStatus(t, resp, http.StatusBadRequest)
+	assertBodyContains(t, resp, "url must point to a public address")
+
+	resp = doJSON(t, app, http.MethodPost, "/webhooks", `{"url":"https://hooks.example.com/trades","events":["trade.created"]}`)
+	assertStatus(t, resp, http.StatusCreated)
+	var endpoint models.WebhookEndpoint
+	decodeJSON(t, resp, &endpoint)
+	if endpoint.Secret == "" || !endpoint.Active || len(endpoint.Events) != 1 {
+		t.Fatalf("endpoint = %+v, want an active endpoint with its secret", endpoint)
+	}
+
+	resp = doJSON(t, app, http.MethodPost, "/webhooks", `{"url":"https://hooks.example.com/trades","events":["trade.created"]}`)
+	assertStatus(t, resp, http.StatusConflict)
+
+	resp = doJSON(t, app, http.MethodGet, "/webhooks/"+endpoint.ID, "")
+	assertStatus(t, resp, http.StatusOK)
+	var fetched models.WebhookEndpoint
+	decodeJSON(t, resp, &fetched)
+	if fetched.Secret != "" {
+		t.Error("GET returned the endpoint secret")
+	}
+
+	resp = doJSON(t, app, http.MethodPatch, "/webhooks/"+endpoint.ID, `{"active":false}`)
+	assertStatus(t, resp, http.StatusOK)
+	decodeJSON(t, resp, &fetched)
+	if fetched.Active {
+		t.Error("endpoint is still active after disabling it")
+	}
+
+	resp = doJSON(t, app, http.MethodGet, "/webhooks/"+endpoint.ID+"/deliveries", "")
+	assertStatus(t, resp, http.StatusOK)
+	if got := jsonLen(t, resp); got != 0 {
+		t.Errorf("returned %d deliveries, want 0", got)
+	}
+
+	assertStatus(t, doJSON(t, app, http.MethodDelete, "/webhooks/"+endpoint.ID, ""), http.StatusNoContent)
+	assertStatus(t, doJSON(t, app, http.MethodGet, "/webhooks/"+endpoint.ID, ""), http.StatusNotFound)
+}
+
+func TestAPITokens_isolation(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userA := newTestUserID(t)
+	userB := newTestUserID(t)
+	svc := services.NewAPITokenService(database.GetPool())
+	InitAPITokenService(svc)
+
+	appA := newTestApp()
+	appA.Use(withUser(userA))
+	appA.Get("/tokens", ListAPITokens)
+	appA.Post("/tokens", CreateAPIToken)
+	appA.Delete("/tokens/:id", RevokeAPIToken)
+
+	appB := newTestApp()
+	appB.Use(withUser(userB))
+	appB.Get("/tokens", ListAPITokens)
+	appB.Delete("/tokens/:id", RevokeAPIToken)
+
+	resp := doJSON(t, appA, http.MethodPost, "/tokens", `{"name":"CI","scopes":["read:portfolio"]}`)
+	assertStatus(t, resp, http.StatusCreated)
+	var token models.APIToken
+	decodeJSON(t, resp, &token)
+	if token.Token == "" {
+		t.Fatal("created token has no secret")
+	}
+
+	resp = doJSON(t, appB, http.MethodGet, "/tokens", "")
+	assertStatus(t, resp, http.StatusOK)
+	if got := jsonLen(t, resp); got != 0 {
+		t.Errorf("user B sees %d tokens, want 0", got)
+	}
+
+	resp = doJSON(t, appB, http.MethodDelete, "/tokens/"+token.ID, "")
+	assertStatus(t, resp, http.StatusNotFound)
+	assertBodyContains(t, resp, "API token not found")
+
+	owner, _, err := svc.VerifyAPIToken(context.Background(), token.Token)
+	if err != nil || owner != userA {
+		t.Fatalf("VerifyAPIToken after user B's revoke = %q, %v; want user A", owner, err)
+	}
+
+	assertStatus(t, doJSON(t, appA, http.MethodDelete, "/tokens/"+token.ID, ""), http.StatusOK)
+	if _, _, err := svc.VerifyAPIToken(context.Background(), token.Token); !errors.Is(err, services.ErrInvalidAPIToken) {
+		t.Errorf("VerifyAPIToken(revoked) error = %v, want ErrInvalidAPIToken", err)
+	}
+}
+
+func TestAPITokens_expired(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userID := newTestUserID(t)
+	svc := services.NewAPITokenService(database.GetPool())
+
+	token, err := svc.CreateToken(context.Background(), userID, models.CreateAPITokenRequest{
+		Name:   "Expired",
+		Scopes: []string{config.ScopeReadPortfolio},
+	})
+	if err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+	execSQL(t, `UPDATE api_tokens SET expires_at = NOW() - INTERVAL '1 minute' WHERE id = $1`, token.ID)
+
+	if _, _, err := svc.VerifyAPIToken(context.Background(), token.Token); !errors.Is(err, services.ErrInvalidAPIToken) {
+		t.Errorf("VerifyAPIToken(expired) error = %v, want ErrInvalidAPIToken", err)
+	}
+}
+
+// seedDCAPlan creates a monthly COP plan for VOO that started three months
+// ago, so creating it also generates its past installments.
+func seedDCAPlan(t *testing.T, userID string) models.DCAPlan {
+	t.Helper()
+	start := time.Now().UTC().AddDate(0, -3, 0).Format("2006-01-02")
+	plan, err := services.NewDCAService(database.GetPool()).CreatePlan(context.Background(), userID, models.CreateDCAPlanRequest{
+		Name:      "Monthly VOO",
+		Amount:    "1000000",
+		Currency:  "COP",
+		Frequency: "monthly",
+		StartDate: start,
+		Targets:   []models.DCATarget{{Ticker: "VOO", Weight: "1"}},
+	})
+	if err != nil {
+		t.Fatalf("CreatePlan: %v", err)
+	}
+	return *plan
+}
+
+func TestDCAPlans_isolation(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userA := newTestUserID(t)
+	userB := newTestUserID(t)
+	planA := seedDCAPlan(t, userA)
+	InitDCAService(services.NewDCAService(database.GetPool()))
+	InitWebhookService(services.NewWebhookService(database.GetPool()))
+
+	installments, err := dcaService.ListInstallments(context.Background(), userA, planA.ID, "planned")
+	if err != nil || len(installments) < 2 {
+		t.Fatalf("user A's installments = %d, %v; want at least 2", len(installments), err)
+	}
+	installmentA := installments[0].ID
+
+	app := newTestApp()
+	app.Use(withUser(userB))
+	app.Get("/dca-plans", ListDCAPlans)
+	app.Get("/dca-plans/:id", GetDCAPlan)
+	app.Patch("/dca-plans/:id", UpdateDCAPlan)
+	app.Delete("/dca-plans/:id", DeleteDCAPlan)
+	app.Get("/dca-plans/:id/installments", ListDCAInstallments)
+	app.Get("/dca-plans/:id/report", GetDCAReport)
+	app.Get("/dca-installments", ListDCAInstallments)
+	app.Post("/dca-installments/generate", GenerateDCAInstallments)
+	app.Post("/dca-installments/:id/confirm", ConfirmDCAInstallment)
+	app.Post("/dca-installments/:id/skip", SkipDCAInstallment)
+
+	resp := doJSON(t, app, http.MethodGet, "/dca-plans", "")
+	assertStatus(t, resp, http.StatusOK)
+	if got := jsonLen(t, resp); got != 0 {
+		t.Errorf("user B sees %d DCA plans, want 0", got)
+	}
+	resp = doJSON(t, app, http.MethodGet, "/dca-installments", "")
+	assertStatus(t, resp, http.StatusOK)
+	if got := jsonLen(t, resp); got != 0 {
+		t.Errorf("user B sees %d DCA installments, want 0", got)
+	}
+	resp = doJSON(t, app, http.MethodPost, "/dca-installments/generate", "")
+	assertStatus(t, resp, http.StatusOK)
+	var generated models.GenerateDCAInstallmentsResponse
+	decodeJSON(t, resp, &generated)
+	if generated.Created != 0 {
+		t.Errorf("user B's generate created %d installments, want 0", generated.Created)
+	}
+
+	for _, tc := range []struct{ method, path, body, message string }{
+		{http.MethodGet, "/dca-plans/" + planA.ID, "", "DCA plan not found"},
+		{http.MethodPatch, "/dca-plans/" + planA.ID, `{"name":"Hijacked","active":false}`, "DCA plan not found"},
+		{http.MethodGet, "/dca-plans/" + planA.ID + "/installments", "", "DCA plan not found"},
+		{http.MethodGet, "/dca-plans/" + planA.ID + "/report", "", "DCA plan not found"},
+		{http.MethodPost, "/dca-installments/" + installmentA + "/confirm", `{"fx_rate":"4000","fills":[{"ticker":"VOO","quantity":"0.5","price":"500"}]}`, "DCA installment not found"},
+		{http.MethodPost, "/dca-installments/" + installmentA + "/skip", "", "DCA installment not found"},
+		{http.MethodDelete, "/dca-plans/" + planA.ID, "", "DCA plan not found"},
+	} {
+		resp := doJSON(t, app, tc.method, tc.path, tc.body)
+		assertStatus(t, resp, http.StatusNotFound)
+		assertBodyContains(t, resp, tc.message)
+	}
+
+	var name, status string
+	var active bool
+	if err := database.GetPool().QueryRow(context.Background(), `SELECT name, active FROM dca_plans WHERE id = $1`, planA.ID).Scan(&name, &active); err != nil {
+		t.Fatalf("user A's DCA plan is gone: %v", err)
+	}
+	if name != "Monthly VOO" || !active {
+		t.Errorf("user A's DCA plan = %q, active %v", name, active)
+	}
+	if err := database.GetPool().QueryRow(context.Background(), `SELECT status FROM dca_installments WHERE id = $1`, installmentA).Scan(&status); err != nil {
+		t.Fatalf("user A's DCA installment is gone: %v", err)
+	}
+	if status != "planned" {
+		t.Errorf("user A's DCA installment status = %q, want planned", status)
+	}
+	var trades int
+	if err := database.GetPool().QueryRow(context.Background(), `SELECT COUNT(*) FROM trades WHERE user_id = $1`, userB).Scan(&trades); err != nil {
+		t.Fatalf("count user B's trades: %v", err)
+	}
+	if trades != 0 {
+		t.Errorf("user B has %d trades, want 0", trades)
+	}
+}
+
+func TestDCAPlans_lifecycle(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userID := newTestUserID(t)
+	InitDCAService(services.NewDCAService(database.GetPool()))
+	InitWebhookService(services.NewWebhookService(database.GetPool()))
+
+	app := newTestApp()
+	app.Use(withUser(userID))
+	app.Post("/dca-plans", CreateDCAPlan)
+	app.Get("/dca-plans/:id/installments", ListDCAInstallments)
+	app.Get("/dca-plans/:id/report", GetDCAReport)
+	app.Post("/dca-installments/generate", GenerateDCAInstallments)
+	app.Post("/dca-installments/:id/confirm", ConfirmDCAInstallment)
+	app.Post("/dca-installments/:id/skip", SkipDCAInstallment)
+
+	start := time.Now().UTC().AddDate(0, -2, 0).Format("2006-01-02")
+	resp := doJSON(t, app, http.MethodPost, "/dca-plans", `{"name":"Core","amount":"1000000","currency":"COP","start_date":"`+start+`",`+
+		`"targets":[{"ticker":"voo","weight":"0.6"},{"ticker":"BND","weight":"0.4"}]}`)
+	assertStatus(t, resp, http.StatusCreated)
+	var plan models.DCAPlan
+	decodeJSON(t, resp, &plan)
+
+	resp = doJSON(t, app, http.MethodPost, "/dca-installments/generate", "")
+	assertStatus(t, resp, http.StatusOK)
+	var generated models.GenerateDCAInstallmentsResponse
+	decodeJSON(t, resp, &generated)
+	if generated.Created != 0 {
+		t.Errorf("generate created %d installments the plan's creation already made", generated.Created)
+	}
+
+	resp = doJSON(t, app, http.MethodGet, "/dca-plans/"+plan.ID+"/installments?status=planned", "")
+	assertStatus(t, resp, http.StatusOK)
+	var installments []models.DCAInstallment
+	decodeJSON(t, resp, &installments)
+	if len(installments) < 2 {
+		t.Fatalf("plan has %d planned installments, want at least 2", len(installments))
+	}
+	first, second := installments[len(installments)-1], installments[len(installments)-2]
+	if len(first.PlannedOrders) != 2 || first.PlannedOrders[0].Ticker != "VOO" || first.PlannedOrders[0].Amount != "600000.00" {
+		t.Errorf("planned orders = %+v, want VOO 600000.00 and BND", first.PlannedOrders)
+	}
+
+	confirmPath := "/dca-installments/" + first.ID + "/confirm"
+	resp = doJSON(t, app, http.MethodPost, confirmPath, `{"fills":[{"ticker":"VOO","quantity":"0.3","price":"500"}]}`)
+	assertStatus(t, resp, http.StatusBadRequest)
+	assertBodyContains(t, resp, "fx_rate is required for COP plans")
+
+	resp = doJSON(t, app, http.MethodPost, confirmPath, `{"fx_rate":"4000","fills":[`+
+		`{"ticker":"VOO","quantity":"0.3","price":"500","trading_fee":"1"},{"ticker":"BND","quantity":"1.4","price":"70"}]}`)
+	assertStatus(t, resp, http.StatusOK)
+	var confirmed models.DCAInstallment
+	decodeJSON(t, resp, &confirmed)
+	if confirmed.Status != "confirmed" || confirmed.CashFlowID == nil || len(confirmed.TradeIDs) != 2 {
+		t.Fatalf("confirmed installment = %+v, want a deposit and two trades", confirmed)
+	}
+	if confirmed.UsdAmount == nil || *confirmed.UsdAmount != "250.00" {
+		t.Errorf("usd_amount = %v, want 250.00", confirmed.UsdAmount)
+	}
+
+	var depositType, depositUSD string
+	if err := database.GetPool().QueryRow(context.Background(), `
+		SELECT type, usd_amount::text FROM cash_flows WHERE id = $1 AND user_id = $2 AND portfolio_id = $3
+	`, *confirmed.CashFlowID, userID, plan.PortfolioID).Scan(&depositType, &depositUSD); err != nil {
+		t.Fatalf("DCA deposit not found in the plan's portfolio: %v", err)
+	}
+	if depositType != "deposit" || depositUSD != "250.00" {
+		t.Errorf("DCA deposit = %s %s, want deposit 250.00", depositType, depositUSD)
+	}
+	var trades int
+	if err := database.GetPool().QueryRow(context.Background(), `
+		SELECT COUNT(*) FROM trades
+		WHERE id = ANY($1::uuid[]) AND user_id = $2 AND portfolio_id = $3 AND side = 'buy' AND date = $4
+	`, confirmed.TradeIDs, userID, plan.PortfolioID, first.ScheduledDate).Scan(&trades); err != nil {
+		t.Fatalf("count DCA trades: %v", err)
+	}
+	if trades != 2 {
+		t.Errorf("found %d DCA buy trades in the plan's portfolio, want 2", trades)
+	}
+
+	resp = doJSON(t, app, http.MethodPost, confirmPath, `{"fx_rate":"4000","fills":[{"ticker":"VOO","quantity":"0.3","price":"500"}]}`)
+	assertStatus(t, resp, http.StatusConflict)
+	assertBodyContains(t, resp, "DCA installment is not pending")
+
+	resp = doJSON(t, app, http.MethodPost, "/dca-installments/"+second.ID+"/skip", "")
+	assertStatus(t, resp, http.StatusOK)
+	var skipped models.DCAInstallment
+	decodeJSON(t, resp, &skipped)
+	if skipped.Status != "skipped" || len(skipped.TradeIDs) != 0 {
+		t.Errorf("skipped installment = %+v", skipped)
+	}
+
+	resp = doJSON(t, app, http.MethodGet, "/dca-plans/"+plan.ID+"/report", "")
+	assertStatus(t, resp, http.StatusOK)
+	var report models.DCAAdherenceReport
+	decodeJSON(t, resp, &report)
+	if report.Confirmed != 1 || report.Skipped != 1 || report.ContributedUSD != "250.00" {
+		t.Errorf("report = %+v, want 1 confirmed, 1 skipped and 250.00 contributed", report)
+	}
+}
+
+// seedMarketPrice stores a shared market price for a ticker no other test
+// uses and returns the ticker.
+func seedMarketPrice(t *testing.T, price string) string {
+	t.Helper()
+	ticker := "T" + strings.ToUpper(strings.ReplaceAll(uuid.New().String(), "-", "")[:7])
+	execSQL(t, `
+		INSERT INTO market_prices (ticker, price, currency, updated_at)
+		VALUES ($1, $2, $3, NOW())
+	`, ticker, price, "USD")
+	t.Cleanup(func() {
+		execSQL(t, "DELETE FROM market_prices WHERE ticker = $1", ticker)
+	})
+	return ticker
+}
+
+// seedPortfolio creates a portfolio for userID, at brokerID when it is not
+// empty.
+func seedPortfolio(t *testing.T, userID, name, brokerID string) models.Portfolio {
+	t.Helper()
+	req := models.CreatePortfolioRequest{Name: name}
+	if brokerID != "" {
+		req.BrokerID = &brokerID
+	}
+	portfolio, err := services.NewPortfolioService(database.GetPool()).CreatePortfolio(context.Background(), userID, req)
+	if err != nil {
+		t.Fatalf("CreatePortfolio: %v", err)
+	}
+	return *portfolio
+}
+
+// seedPortfolioDeposit records a USD deposit into a portfolio.
+func seedPortfolioDeposit(t *testing.T, userID, portfolioID, usdAmount string) string {
+	t.Helper()
+	id := uuid.New().String()
+	execSQL(t, `
+		INSERT INTO cash_flows (id, user_id, portfolio_id, date, type, currency, amount, usd_amount, notes)
+		VALUES ($1, $2, $3, $4, 'deposit', 'USD', $5, $5, 'test deposit')
+	`, id, userID, portfolioID, time.Now().UTC().Format("2006-01-02"), usdAmount)
+	return id
+}
+
+// seedPortfolioBuy records a fee-free buy in a portfolio.
+func seedPortfolioBuy(t *testing.T, userID, portfolioID, ticker, assetType, quantity, price string) string {
+	t.Helper()
+	id := uuid.New().String()
+	execSQL(t, `
+		INSERT INTO trades (id, user_id, portfolio_id, date, ticker, asset_type, side, quantity, price, total_fees, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, 'buy', $7, $8, 0, 'test trade')
+	`, id, userID, portfolioID, time.Now().UTC().Format("2006-01-02"), ticker, assetType, quantity, price)
+	return id
+}
+
+func TestTargetAllocations_isolation(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userA := newTestUserID(t)
+	userB := newTestUserID(t)
+	InitAllocationService(database.GetPool())
+	portfolioA := seedPortfolio(t, userA, "Long term", "")
+	ticker := seedMarketPrice(t, "100")
+	if _, err := allocationService.SetTargetAllocation(context.Background(), userA, portfolioA.ID, models.SetTargetAllocationRequest{
+		Targets: []models.AllocationTarget{{Key: ticker, Weight: "1"}},
+	}); err != nil {
+		t.Fatalf("SetTargetAllocation: %v", err)
+	}
+
+	app := newTestApp()
+	app.Use(withUser(userB))
+	app.Get("/portfolios/:id/target-allocation", GetTargetAllocation)
+	app.Put("/portfolios/:id/target-allocation", SetTargetAllocation)
+	app.Delete("/portfolios/:id/target-allocation", DeleteTargetAllocation)
+	app.Get("/portfolios/:id/rebalance", GetRebalancePlan)
+
+	path := "/portfolios/" + portfolioA.ID + "/target-allocation"
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodGet, path, ""},
+		{http.MethodPut, path, `{"targets":[{"key":"VOO","weight":"1"}]}`},
+		{http.MethodGet, "/portfolios/" + portfolioA.ID + "/rebalance", ""},
+		{http.MethodDelete, path, ""},
+	} {
+		resp := doJSON(t, app, tc.method, tc.path, tc.body)
+		assertStatus(t, resp, http.StatusNotFound)
+		assertBodyContains(t, resp, "portfolio not found")
+	}
+
+	allocation, err := allocationService.GetTargetAllocation(context.Background(), userA, portfolioA.ID)
+	if err != nil {
+		t.Fatalf("user A's target allocation is gone: %v", err)
+	}
+	if len(allocation.Targets) != 1 || allocation.Targets[0].Key != ticker {
+		t.Errorf("user A's targets = %+v, want %s only", allocation.Targets, ticker)
+	}
+}
+
+func TestRebalancePlan_withBrokerCommissions(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userID := newTestUserID(t)
+	InitAllocationService(database.GetPool())
+	brokers := services.NewBrokerService(database.GetPool())
+	broker, err := brokers.CreateCustomBroker(context.Background(), userID, models.CreateCustomBrokerRequest{
+		Name: "Test broker",
+		FeeSchedule: &models.BrokerFeeScheduleInput{
+			Commissions: map[string]models.BrokerFeeRule{"etf": {Type: "flat", Value: "1"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateCustomBroker: %v", err)
+	}
+	portfolio := seedPortfolio(t, userID, "Rebalanced", broker.ID)
+
+	// 1000 USD deposited, 600 of it in held (at 100) and nothing in bought (at 50):
+	// held is 0.1 over its 0.5 target and bought 0.5 under.
+	held := seedMarketPrice(t, "100")
+	bought := seedMarketPrice(t, "50")
+	seedPortfolioDeposit(t, userID, portfolio.ID, "1000")
+	seedPortfolioBuy(t, userID, portfolio.ID, held, "etf", "6", "100")
+	// A holding in another portfolio must not affect this plan.
+	other := seedPortfolio(t, userID, "Other", "")
+	seedPortfolioDeposit(t, userID, other.ID, "5000")
+	seedPortfolioBuy(t, userID, other.ID, bought, "etf", "100", "50")
+
+	app := newTestApp()
+	app.Use(withUser(userID))
+	app.Put("/portfolios/:id/target-allocation", SetTargetAllocation)
+	app.Get("/portfolios/:id/rebalance", GetRebalancePlan)
+
+	resp := doJSON(t, app, http.MethodPut, "/portfolios/"+portfolio.ID+"/target-allocation",
+		`{"drift_band":"0.05","targets":[{"key":"`+held+`","weight":"0.5","asset_type":"etf"},{"key":"`+bought+`","weight":"0.5","asset_type":"etf"}]}`)
+	assertStatus(t, resp, http.StatusOK)
+
+	resp = doJSON(t, app, http.MethodGet, "/portfolios/"+portfolio.ID+"/rebalance", "")
+	assertStatus(t, resp, http.StatusOK)
+	var plan models.RebalancePlan
+	decodeJSON(t, resp, &plan)
+
+	if plan.TotalValue != "1000.00" || plan.CashBefore != "400.00" || !plan.NeedsRebalance {
+		t.Fatalf("plan = %+v, want 1000.00 total with 400.00 cash needing a rebalance", plan)
+	}
+	schedule, err := brokers.FeeScheduleAt(context.Background(), userID, broker.ID, time.Now())
+	if err != nil || schedule == nil {
+		t.Fatalf("FeeScheduleAt = %v, %v", schedule, err)
+	}
+	// Selling 1 held frees 100 - 1 fee; 499 then buys 9.96 bought at 50 plus its 1 fee.
+	want := []models.RebalanceOrder{
+		{Ticker: held, AssetType: "etf", Side: "sell", Quantity: "1", Price: "100", Amount: "100.00"},
+		{Ticker: bought, AssetType: "etf", Side: "buy", Quantity: "9.96", Price: "50", Amount: "498.00"},
+	}
+	if len(plan.Orders) != len(want) {
+		t.Fatalf("orders = %+v, want %d", plan.Orders, len(want))
+	}
+	for i, w := range want {
+		amount, _ := decimal.NewFromString(w.Amount)
+		fee, err := brokers.ComputeCommissionUSD(*schedule, w.AssetType, amount)
+		if err != nil {
+			t.Fatalf("ComputeCommissionUSD: %v", err)
+		}
+		w.EstimatedFee = fee.StringFixed(2)
+		if plan.Orders[i] != w {
+			t.Errorf("order %d = %+v, want %+v", i, plan.Orders[i], w)
+		}
+	}
+	if plan.EstimatedFees != "2.00" || plan.CashAfter != "0.00" {
+		t.Errorf("estimated fees = %s, cash after = %s; want 2.00 and 0.00", plan.EstimatedFees, plan.CashAfter)
+	}
+
+	resp = doJSON(t, app, http.MethodGet, "/portfolios/"+portfolio.ID+"/rebalance?allow_sells=maybe", "")
+	assertStatus(t, resp, http.StatusBadRequest)
+	assertBodyContains(t, resp, "allow_sells must be true or false")
+
+	resp = doJSON(t, app, http.MethodGet, "/portfolios/"+portfolio.ID+"/rebalance?allow_sells=false", "")
+	assertStatus(t, resp, http.StatusOK)
+	decodeJSON(t, resp, &plan)
+	if len(plan.Orders) != 1 || plan.Orders[0].Side != "buy" || plan.Orders[0].Quantity != "7.98" {
+		t.Errorf("buy-only orders = %+v, want one buy of 7.98", plan.Orders)
+	}
+}
+
+func TestPortfolios_isolation(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userA := newTestUserID(t)
+	userB := newTestUserID(t)
+	InitPortfolioService(database.GetPool())
+	InitWebhookService(services.NewWebhookService(database.GetPool()))
+	brokerA, err := services.NewBrokerService(database.GetPool()).CreateCustomBroker(context.Background(), userA, models.CreateCustomBrokerRequest{Name: "A's broker"})
+	if err != nil {
+		t.Fatalf("CreateCustomBroker: %v", err)
+	}
+	portfolioA := seedPortfolio(t, userA, "Brokerage", brokerA.ID)
+	otherA := seedPortfolio(t, userA, "Savings", "")
+	portfolioB := seedPortfolio(t, userB, "Mine", "")
+	seedPortfolioDeposit(t, userA, portfolioA.ID, "1000")
+	transferA, err := portfolioService.CreateTransfer(context.Background(), userA, models.CreatePortfolioTransferRequest{
+		FromPortfolioID: portfolioA.ID,
+		ToPortfolioID:   otherA.ID,
+		Date:            time.Now().UTC().Format("2006-01-02"),
+		Amount:          "100",
+	})
+	if err != nil {
+		t.Fatalf("CreateTransfer: %v", err)
+	}
+
+	app := newTestApp()
+	app.Use(withUser(userB))
+	app.Get("/portfolios", ListPortfolios)
+	app.Post("/portfolios", CreatePortfolio)
+	app.Post("/portfolios/transfers", CreatePortfolioTransfer)
+	app.Delete("/portfolios/transfers/:id", DeletePortfolioTransfer)
+	app.Patch("/portfolios/:id", UpdatePortfolio)
+	app.Delete("/portfolios/:id", DeletePortfolio)
+	app.Get("/analytics/net-worth", GetNetWorth)
+
+	resp := doJSON(t, app, http.MethodGet, "/portfolios", "")
+	assertStatus(t, resp, http.StatusOK)
+	var listed []models.Portfolio
+	decodeJSON(t, resp, &listed)
+	for _, p := range listed {
+		if p.ID == portfolioA.ID || p.ID == otherA.ID {
+			t.Errorf("user B sees user A's portfolio %q", p.Name)
+		}
+	}
+
+	today := time.Now().UTC().Format("2006-01-02")
+	for _, tc := range []struct {
+		method, path, body string
+		status             int
+		message            string
+	}{
+		{http.MethodGet, "/analytics/net-worth?portfolio_id=" + portfolioA.ID, "", http.StatusNotFound, "portfolio not found"},
+		{http.MethodPatch, "/portfolios/" + portfolioA.ID, `{"name":"Hijacked"}`, http.StatusNotFound, "portfolio not found"},
+		{http.MethodPost, "/portfolios/transfers", `{"from_portfolio_id":"` + portfolioA.ID + `","to_portfolio_id":"` + portfolioB.ID + `","date":"` + today + `","amount":"500"}`,
+			http.StatusNotFound, "portfolio not found"},
+		{http.MethodDelete, "/portfolios/transfers/" + transferA.TransferID, "", http.StatusNotFound, "transfer not found"},
+		{http.MethodDelete, "/portfolios/" + portfolioA.ID, "", http.StatusNotFound, "portfolio not found"},
+		{http.MethodPost, "/portfolios", `{"name":"Borrowed","broker_id":"` + brokerA.ID + `"}`, http.StatusBadRequest, "invalid broker_id"},
+		{http.MethodPatch, "/portfolios/" + portfolioB.ID, `{"broker_id":"` + brokerA.ID + `"}`, http.StatusBadRequest, "invalid broker_id"},
+	} {
+		resp := doJSON(t, app, tc.method, tc.path, tc.body)
+		assertStatus(t, resp, tc.status)
+		assertBodyContains(t, resp, tc.message)
+	}
+
+	var name string
+	if err := database.GetPool().QueryRow(context.Background(), `SELECT name FROM portfolios WHERE id = $1`, portfolioA.ID).Scan(&name); err != nil {
+		t.Fatalf("user A's portfolio is gone: %v", err)
+	}
+	if name != "Brokerage" {
+		t.Errorf("user A's portfolio name = %q", name)
+	}
+	var flowsA, flowsB int
+	if err := database.GetPool().QueryRow(context.Background(), `
+		SELECT COUNT(*) FILTER (WHERE user_id = $1), COUNT(*) FILTER (WHERE user_id = $2)
+		FROM cash_flows WHERE user_id IN ($1, $2)
+	`, userA, userB).Scan(&flowsA, &flowsB); err != nil {
+		t.Fatalf("count cash flows: %v", err)
+	}
+	if flowsA != 3 || flowsB != 0 {
+		t.Errorf("cash flows = %d for user A and %d for user B, want 3 and 0", flowsA, flowsB)
+	}
+	if p, err := portfolioService.GetPortfolio(context.Background(), userB, portfolioB.ID); err != nil || p.BrokerID != nil {
+		t.Errorf("user B's portfolio = %+v, %v; want no broker", p, err)
+	}
+}
+
+func TestPortfolios_scopeAndTransfers(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userID := newTestUserID(t)
+	InitPortfolioService(database.GetPool())
+	InitWebhookService(services.NewWebhookService(database.GetPool()))
+	ticker := seedMarketPrice(t, "100")
+	brokerage := seedPortfolio(t, userID, "Brokerage", "")
+	savings := seedPortfolio(t, userID, "Savings", "")
+	seedPortfolioDeposit(t, userID, brokerage.ID, "1000")
+	seedPortfolioBuy(t, userID, brokerage.ID, ticker, "etf", "2", "100")
+	seedPortfolioDeposit(t, userID, savings.ID, "500")
+
+	app := newTestApp()
+	app.Use(withUser(userID))
+	app.Get("/analytics/net-worth", GetNetWorth)
+	app.Get("/portfolio/holdings", GetHoldings)
+	app.Get("/portfolios/consolidated", GetConsolidatedPortfolios)
+	app.Post("/portfolios/transfers", CreatePortfolioTransfer)
+	app.Delete("/portfolios/transfers/:id", DeletePortfolioTransfer)
+
+	netWorth := func(scope string) models.NetWorthSummary {
+		t.Helper()
+		resp := doJSON(t, app, http.MethodGet, "/analytics/net-worth?portfolio_id="+scope, "")
+		assertStatus(t, resp, http.StatusOK)
+		var summary models.NetWorthSummary
+		decodeJSON(t, resp, &summary)
+		return summary
+	}
+	assertNetWorth := func(scope, holdings, cash, invested string) {
+		t.Helper()
+		summary := netWorth(scope)
+		for _, v := range []struct{ name, got, want string }{
+			{"holdings_value", summary.HoldingsValue, holdings},
+			{"cash_balance", summary.CashBalance, cash},
+			{"total_invested", summary.TotalInvested, invested},
+		} {
+			if got, err := decimal.NewFromString(v.got); err != nil || !got.Equal(decimal.RequireFromString(v.want)) {
+				t.Errorf("%q %s = %s, want %s", scope, v.name, v.got, v.want)
+			}
+		}
+	}
+
+	assertNetWorth(brokerage.ID, "200", "800", "1000")
+	assertNetWorth(savings.ID, "0", "500", "500")
+	assertNetWorth("all", "200", "1300", "1500")
+
+	resp := doJSON(t, app, http.MethodGet, "/portfolio/holdings?portfolio_id="+savings.ID, "")
+	assertStatus(t, resp, http.StatusOK)
+	if got := jsonLen(t, resp); got != 0 {
+		t.Errorf("savings has %d holdings, want 0", got)
+	}
+	resp = doJSON(t, app, http.MethodGet, "/portfolio/holdings?portfolio_id="+brokerage.ID, "")
+	assertStatus(t, resp, http.StatusOK)
+	if got := jsonLen(t, resp); got != 1 {
+		t.Errorf("brokerage has %d holdings, want 1", got)
+	}
+
+	resp = doJSON(t, app, http.MethodPost, "/portfolios/transfers", `{"from_portfolio_id":"`+brokerage.ID+`","to_portfolio_id":"`+savings.ID+
+		`","date":"`+time.Now().UTC().Format("2006-01-02")+`","amount":"300"}`)
+	assertStatus(t, resp, http.StatusCreated)
+	var transfer models.PortfolioTransfer
+	decodeJSON(t, resp, &transfer)
+	if transfer.Out.Type != "transfer_out" || transfer.In.Type != "transfer_in" || transfer.Out.UsdAmount != transfer.In.UsdAmount {
+		t.Fatalf("transfer = %+v, want matching out and in legs", transfer)
+	}
+
+	// The transfer moves cash between the portfolios and nets to zero overall.
+	assertNetWorth(brokerage.ID, "200", "500", "700")
+	assertNetWorth(savings.ID, "0", "800", "800")
+	assertNetWorth("all", "200", "1300", "1500")
+
+	resp = doJSON(t, app, http.MethodGet, "/portfolios/consolidated", "")
+	assertStatus(t, resp, http.StatusOK)
+	var view models.ConsolidatedPortfolioView
+	decodeJSON(t, resp, &view)
+	sum := decimal.Zero
+	for _, p := range view.Portfolios {
+		sum = sum.Add(decimal.RequireFromString(p.Summary.NetWorth))
+	}
+	if consolidated := decimal.RequireFromString(view.Consolidated.NetWorth); !sum.Equal(consolidated) || !consolidated.Equal(decimal.NewFromInt(1500)) {
+		t.Errorf("portfolios sum to %s against a consolidated %s, want 1500", sum, consolidated)
+	}
+
+	assertStatus(t, doJSON(t, app, http.MethodDelete, "/portfolios/transfers/"+transfer.TransferID, ""), http.StatusOK)
+	assertNetWorth(brokerage.ID, "200", "800", "1000")
+	assertNetWorth(savings.ID, "0", "500", "500")
+}
+
+func TestCustomBenchmarks_isolation(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userA := newTestUserID(t)
+	userB := newTestUserID(t)
+	InitBenchmarkService(database.GetPool())
+	benchmarkA, err := benchmarkService.CreateCustomBenchmark(context.Background(), userA, models.CreateBenchmarkRequest{
+		Name:       "Tilt",
+		Components: []models.BenchmarkComponent{{Ticker: "VT", Weight: "0.7"}, {Ticker: "QQQ", Weight: "0.3"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateCustomBenchmark: %v", err)
+	}
+
+	app := newTestApp()
+	app.Use(withUser(userB))
+	app.Get("/benchmarks", ListBenchmarks)
+	app.Delete("/benchmarks/:id", DeleteBenchmark)
+	app.Get("/performance-time-series", GetPerformanceTimeSeries)
+	app.Get("/benchmark-comparison", GetBenchmarkComparison)
+
+	resp := doJSON(t, app, http.MethodGet, "/benchmarks", "")
+	assertStatus(t, resp, http.StatusOK)
+	var benchmarks []models.Benchmark
+	decodeJSON(t, resp, &benchmarks)
+	for _, b := range benchmarks {
+		if b.IsCustom {
+			t.Errorf("user B sees custom benchmark %q", b.Name)
+		}
+	}
+
+	for _, path := range []string{
+		"/performance-time-series?benchmarks=spy," + benchmarkA.ID,
+		"/benchmark-comparison?benchmarks=" + benchmarkA.ID,
+	} {
+		resp := doJSON(t, app, http.MethodGet, path, "")
+		assertStatus(t, resp, http.StatusNotFound)
+		assertBodyContains(t, resp, "benchmark not found")
+	}
+	resp = doJSON(t, app, http.MethodDelete, "/benchmarks/"+benchmarkA.ID, "")
+	assertStatus(t, resp, http.StatusNotFound)
+	assertBodyContains(t, resp, "benchmark not found")
+
+	resolved, err := benchmarkService.ResolveBenchmarks(context.Background(), userA, []string{benchmarkA.ID})
+	if err != nil || len(resolved) != 1 || resolved[0].Name != "Tilt" {
+		t.Errorf("user A's benchmark after user B's requests = %+v, %v", resolved, err)
+	}
+}
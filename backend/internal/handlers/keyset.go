@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// sortColumn is a column a list can be ordered by, with the SQL type its
+// cursor values are cast to.
+type sortColumn struct {
+	name string
+	cast string
+}
+
+// Rows with the same sort value are ordered by date, then creation time, then
+// id, which is unique, so every row has a distinct position.
+var keysetTieBreakers = []sortColumn{{"date", "date"}, {"created_at", "timestamptz"}, {"id", "uuid"}}
+
+// listSort is a validated ?sort= value. The zero value sorts newest first.
+type listSort struct {
+	key    string // whitelisted column name; "" is date
+	column sortColumn
+	asc    bool
+}
+
+// parseListSort reads ?sort=: a key of columns, descending when prefixed
+// with "-". Empty means -date.
+func parseListSort(value string, columns map[string]sortColumn) (listSort, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return listSort{}, nil
+	}
+	key, desc := strings.CutPrefix(value, "-")
+	column, ok := columns[key]
+	if !ok {
+		names := slices.Sorted(maps.Keys(columns))
+		return listSort{}, apperror.Newf(apperror.Validation, "sort must be one of %s, optionally prefixed with -", strings.Join(names, ", "))
+	}
+	if key == "date" {
+		return listSort{asc: !desc}, nil
+	}
+	return listSort{key: key, column: column, asc: !desc}, nil
+}
+
+// String returns s as written in ?sort=.
+func (s listSort) String() string {
+	key := s.key
+	if key == "" {
+		key = "date"
+	}
+	if s.asc {
+		return key
+	}
+	return "-" + key
+}
+
+// columns returns the columns rows are ordered by, most significant first.
+func (s listSort) columns() []sortColumn {
+	if s.key == "" {
+		return keysetTieBreakers
+	}
+	return append([]sortColumn{s.column}, keysetTieBreakers...)
+}
+
+// cursorKey returns the cursor key of a row: value, its value in the sort column
+// (ignored when sorting by date), then its tie-breakers.
+func (s listSort) cursorKey(value string, date, createdAt time.Time, id string) []string {
+	key := []string{date.Format("2006-01-02"), createdAt.Format(time.RFC3339Nano), id}
+	if s.key == "" {
+		return key
+	}
+	return append([]string{value}, key...)
+}
+
+// listOrder orders a list query and, for a keyset page, starts it after the
+// last row of the previous page.
+type listOrder struct {
+	sort  listSort
+	after []string // sort key of the previous page's last row; nil for the first page
+}
+
+// appendTo adds the keyset condition and the ORDER BY clause to query.
+func (o listOrder) appendTo(query string, args []interface{}) (string, []interface{}) {
+	columns := o.sort.columns()
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.name
+	}
+
+	if o.after != nil {
+		params := make([]string, len(columns))
+		for i, col := range columns {
+			args = append(args, o.after[i])
+			params[i] = fmt.Sprintf("$%d::%s", len(args), col.cast)
+		}
+		op := "<"
+		if o.sort.asc {
+			op = ">"
+		}
+		query += fmt.Sprintf(" AND (%s) %s (%s)", strings.Join(names, ", "), op, strings.Join(params, ", "))
+	}
+
+	dir := " DESC"
+	if o.sort.asc {
+		dir = " ASC"
+	}
+	query += " ORDER BY " + strings.Join(names, dir+", ") + dir
+	return query, args
+}
+
+// keysetPage is the position and size of a keyset-paginated /api/v2 list.
+// Unlike offsets, keys stay valid when rows are inserted mid-scroll.
+type keysetPage struct {
+	limit int
+	order listOrder
+}
+
+// keysetCursor is the decoded form of a keyset ?cursor= value. It records
+// the sort it was issued for, since keys of one sort mean nothing in another.
+type keysetCursor struct {
+	Sort  string   `json:"s"`
+	After []string `json:"a"`
+}
+
+func parseKeysetPage(cursor, limit string, sort listSort) (keysetPage, error) {
+	n, err := parseListLimit(limit)
+	if err != nil {
+		return keysetPage{}, err
+	}
+	page := keysetPage{limit: n, order: listOrder{sort: sort}}
+	if cursor != "" {
+		var c keysetCursor
+		if err := decodeCursor(cursor, &c); err != nil || len(c.After) != len(sort.columns()) {
+			return keysetPage{}, errors.New("invalid cursor")
+		}
+		if c.Sort != sort.String() {
+			return keysetPage{}, errors.New("cursor was issued for another sort")
+		}
+		page.order.after = c.After
+	}
+	return page, nil
+}
+
+// keysetPageFromQuery reads ?cursor= and ?limit= for a list sorted by sort.
+func keysetPageFromQuery(c fiber.Ctx, sort listSort) (keysetPage, error) {
+	page, err := parseKeysetPage(c.Query("cursor"), c.Query("limit"), sort)
+	if err != nil {
+		return keysetPage{}, apperror.Classify(apperror.Validation, err)
+	}
+	return page, nil
+}
+
+// keysetPageOf builds a page from items fetched with one more row than
+// p.limit. key returns an item's values for p's sort columns.
+func keysetPageOf[T any](items []T, p keysetPage, key func(T) []string) models.Page[T] {
+	page := models.Page[T]{Items: items}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	if len(items) > p.limit {
+		page.Items = items[:p.limit]
+		next := encodeCursor(keysetCursor{Sort: p.order.sort.String(), After: key(page.Items[p.limit-1])})
+		page.NextCursor = &next
+	}
+	return page
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"fintu-tracking-backend/internal/models"
+)
+
+func TestParseListSort(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct{ value, want string }{
+		{"", "-date"},
+		{"-date", "-date"},
+		{"date", "date"},
+		{"ticker", "ticker"},
+		{"-total", "-total"},
+	} {
+		s, err := parseListSort(tc.value, tradeSortColumns)
+		if err != nil {
+			t.Fatalf("parseListSort(%q): %v", tc.value, err)
+		}
+		if s.String() != tc.want {
+			t.Errorf("parseListSort(%q) = %s, want %s", tc.value, s, tc.want)
+		}
+	}
+	if s, _ := parseListSort("-date", tradeSortColumns); s != (listSort{}) {
+		t.Errorf("-date = %+v, want the zero value", s)
+	}
+
+	_, err := parseListSort("ticker", cashFlowSortColumns)
+	if err == nil || !strings.Contains(err.Error(), "date, usd_amount") {
+		t.Errorf("err = %v, want the allowed columns", err)
+	}
+}
+
+func TestListOrderAppendTo(t *testing.T) {
+	t.Parallel()
+
+	query, args := listOrder{}.appendTo("", []interface{}{"user-1"})
+	if query != " ORDER BY date DESC, created_at DESC, id DESC" || len(args) != 1 {
+		t.Errorf("default order = %q %v", query, args)
+	}
+
+	sort, _ := parseListSort("ticker", tradeSortColumns)
+	after := []string{"VOO", "2026-01-02", "2026-01-02T10:00:00Z", "a"}
+	query, args = listOrder{sort: sort, after: after}.appendTo("", []interface{}{"user-1"})
+	want := " AND (ticker, date, created_at, id) > ($2::text, $3::date, $4::timestamptz, $5::uuid)" +
+		" ORDER BY ticker ASC, date ASC, created_at ASC, id ASC"
+	if query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	if len(args) != 5 || args[1] != "VOO" || args[4] != "a" {
+		t.Errorf("args = %v", args)
+	}
+}
+
+func TestKeysetPages(t *testing.T) {
+	t.Parallel()
+
+	sort, _ := parseListSort("-total", tradeSortColumns)
+	first, err := parseKeysetPage("", "2", sort)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+	created := time.Date(2026, 3, 4, 15, 4, 5, 123456000, time.UTC)
+	trades := []models.Trade{
+		{ID: "c", Total: "30", Date: day, CreatedAt: created},
+		{ID: "b", Total: "20", Date: day, CreatedAt: created},
+		{ID: "a", Total: "10", Date: day, CreatedAt: created},
+	}
+	key := func(t models.Trade) []string { return sort.cursorKey(t.Total, t.Date, t.CreatedAt, t.ID) }
+	page := keysetPageOf(trades, first, key)
+	if len(page.Items) != 2 || page.NextCursor == nil {
+		t.Fatalf("page = %+v", page)
+	}
+
+	next, err := parseKeysetPage(*page.NextCursor, "2", sort)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"20", "2026-03-04", "2026-03-04T15:04:05.123456Z", "b"}
+	if strings.Join(next.order.after, "|") != strings.Join(want, "|") {
+		t.Errorf("after = %v, want %v", next.order.after, want)
+	}
+
+	if _, err := parseKeysetPage(*page.NextCursor, "2", listSort{}); err == nil {
+		t.Error("cursor of -total accepted for -date")
+	}
+	if _, err := parseKeysetPage(encodeCursor(keysetCursor{Sort: "-date", After: []string{"x"}}), "", listSort{}); err == nil {
+		t.Error("cursor with a short key accepted")
+	}
+
+	last := keysetPageOf(trades[2:], next, key)
+	if last.NextCursor != nil {
+		t.Errorf("last page has a next cursor")
+	}
+}
+
+func TestBuildListTradesQuery_Keyset(t *testing.T) {
+	t.Parallel()
+
+	filters := tradeListFilters{ticker: "VOO"}
+	order := listOrder{after: []string{"2026-01-02", "2026-01-02T10:00:00Z", "a"}}
+	query, args := buildListTradesQuery("user-1", filters, order, 51, 0)
+	for _, fragment := range []string{
+		"ticker = $2",
+		"(date, created_at, id) < ($3::date, $4::timestamptz, $5::uuid)",
+		"ORDER BY date DESC, created_at DESC, id DESC LIMIT $6",
+	} {
+		if !strings.Contains(query, fragment) {
+			t.Errorf("query missing %q:\n%s", fragment, query)
+		}
+	}
+	if len(args) != 7 {
+		t.Errorf("len(args) = %d, want 7", len(args))
+	}
+}
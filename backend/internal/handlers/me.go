@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"strings"
+
+	"fintu-tracking-backend/internal/apperror"
 	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/i18n"
 	"fintu-tracking-backend/internal/middleware"
 	"fintu-tracking-backend/internal/models"
 	"fintu-tracking-backend/internal/services"
@@ -14,9 +18,11 @@ import (
 // It is initialized once from main.go after the DB pool is available.
 var profileService *services.ProfileService
 
-// InitProfileService sets the package-level profile service used by handlers.
-func InitProfileService(pool *pgxpool.Pool) {
+// InitProfileService sets the package-level profile service used by handlers
+// and returns it, so the locale middleware shares its locale cache.
+func InitProfileService(pool *pgxpool.Pool) *services.ProfileService {
 	profileService = services.NewProfileService(pool, billingService, services.NewBrokerService(pool))
+	return profileService
 }
 
 // GetMe returns the current user's profile. Creates a default profile row if missing.
@@ -28,7 +34,7 @@ func GetMe(c fiber.Ctx) error {
 
 	p, err := profileService.GetOrCreateProfile(c.Context(), userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	return c.JSON(p)
@@ -43,24 +49,25 @@ func UpdateOnboarding(c fiber.Ctx) error {
 
 	var req models.UpdateOnboardingRequest
 	if err := c.Bind().JSON(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		return apperror.New(apperror.Validation, "Invalid request body")
 	}
 	if req.Country == "" || req.BrokerPresetID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "country and broker_preset_id are required"})
+		return apperror.New(apperror.Validation, "country and broker_preset_id are required")
 	}
 	if config.GetBrokerPreset(req.BrokerPresetID) == nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Unknown broker preset"})
+		return apperror.New(apperror.Validation, "Unknown broker preset")
 	}
 
 	p, err := profileService.UpdateOnboarding(c.Context(), userID, req)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	return c.JSON(p)
 }
 
-// UpdateProfile updates country and broker preset without altering onboarding state.
+// UpdateProfile updates country and broker preset without altering onboarding
+// state, and the locale of server-generated text when the body sets one.
 func UpdateProfile(c fiber.Ctx) error {
 	userID, err := middleware.RequireUserID(c)
 	if err != nil {
@@ -69,19 +76,36 @@ func UpdateProfile(c fiber.Ctx) error {
 
 	var req models.UpdateProfileRequest
 	if err := c.Bind().JSON(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		return apperror.New(apperror.Validation, "Invalid request body")
 	}
 	if req.Country == "" || req.BrokerPresetID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "country and broker_preset_id are required"})
+		return apperror.New(apperror.Validation, "country and broker_preset_id are required")
 	}
 	if config.GetBrokerPreset(req.BrokerPresetID) == nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Unknown broker preset"})
+		return apperror.New(apperror.Validation, "Unknown broker preset")
+	}
+	if req.Locale != nil {
+		locale, ok := i18n.Parse(*req.Locale)
+		if !ok {
+			return apperror.Newf(apperror.Validation, "locale must be one of %s", localeCodes())
+		}
+		code := locale.String()
+		req.Locale = &code
 	}
 
 	p, err := profileService.UpdateProfile(c.Context(), userID, req)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	return c.JSON(p)
 }
+
+// localeCodes lists the supported locale codes for error messages.
+func localeCodes() string {
+	codes := make([]string, 0, len(i18n.Locales()))
+	for _, l := range i18n.Locales() {
+		codes = append(codes, l.String())
+	}
+	return strings.Join(codes, ", ")
+}
@@ -1,20 +1,19 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"fintu-tracking-backend/internal/database"
-
-	"github.com/gofiber/fiber/v3"
 )
 
 func TestGetMe_Unauthorized(t *testing.T) {
 	t.Parallel()
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Get("/me", GetMe)
 
 	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/me", nil))
@@ -32,7 +31,7 @@ func TestGetMe_CreatesProfileForNewUser(t *testing.T) {
 	userID := newTestUserID(t)
 	InitProfileService(database.GetPool())
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Use(withUser(userID))
 	app.Get("/me", GetMe)
 
@@ -82,11 +81,17 @@ func TestUpdateOnboarding_Validation(t *testing.T) {
 			want:  http.StatusBadRequest,
 			error: "Unknown broker preset",
 		},
+		{
+			name:  "unknown locale",
+			body:  `{"country":"co","broker_preset_id":"hapi-colombia","locale":"fr"}`,
+			want:  http.StatusBadRequest,
+			error: "locale must be one of en, es-CO",
+		},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			app := fiber.New()
+			app := newTestApp()
 			app.Use(withUser(userID))
 			app.Patch("/me/onboarding", UpdateOnboarding)
 
@@ -116,7 +121,7 @@ func TestUpdateOnboarding_Success(t *testing.T) {
 	userID := newTestUserID(t)
 	InitProfileService(database.GetPool())
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Use(withUser(userID))
 	app.Patch("/me/onboarding", UpdateOnboarding)
 
@@ -143,7 +148,7 @@ func TestUpdateOnboarding_Success(t *testing.T) {
 func TestUpdateProfile_Unauthorized(t *testing.T) {
 	t.Parallel()
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Patch("/me/profile", UpdateProfile)
 
 	resp, err := app.Test(httptest.NewRequest(http.MethodPatch, "/me/profile", nil))
@@ -189,7 +194,7 @@ func TestUpdateProfile_Validation(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			app := fiber.New()
+			app := newTestApp()
 			app.Use(withUser(userID))
 			app.Patch("/me/profile", UpdateProfile)
 
@@ -219,7 +224,7 @@ func TestUpdateProfile_Success(t *testing.T) {
 	userID := newTestUserID(t)
 	InitProfileService(database.GetPool())
 
-	onboardApp := fiber.New()
+	onboardApp := newTestApp()
 	onboardApp.Use(withUser(userID))
 	onboardApp.Patch("/me/onboarding", UpdateOnboarding)
 
@@ -232,7 +237,7 @@ func TestUpdateProfile_Success(t *testing.T) {
 	onboardResp.Body.Close()
 	assertStatus(t, onboardResp, http.StatusOK)
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Use(withUser(userID))
 	app.Patch("/me/profile", UpdateProfile)
 
@@ -257,6 +262,47 @@ func TestUpdateProfile_Success(t *testing.T) {
 	})
 }
 
+func TestUpdateProfile_Locale(t *testing.T) {
+	skipIfNoTestDB(t)
+
+	userID := newTestUserID(t)
+	profiles := InitProfileService(database.GetPool())
+
+	app := newTestApp()
+	app.Use(withUser(userID))
+	app.Patch("/me/profile", UpdateProfile)
+
+	patch := func(body string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPatch, "/me/profile", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		defer resp.Body.Close()
+		assertStatus(t, resp, http.StatusOK)
+		assertBodyContains(t, resp, `"locale":"es-CO"`)
+	}
+	patch(`{"country":"co","broker_preset_id":"hapi-colombia","locale":"es-co"}`)
+	// Omitting locale keeps it.
+	patch(`{"country":"co","broker_preset_id":"hapi-colombia"}`)
+
+	locale, err := profiles.ProfileLocale(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("ProfileLocale: %v", err)
+	}
+	if locale != "es-CO" {
+		t.Errorf("ProfileLocale = %q, want es-CO", locale)
+	}
+
+	t.Cleanup(func() {
+		execSQL(t, "DELETE FROM brokers WHERE user_id = $1", userID)
+		execSQL(t, "DELETE FROM subscriptions WHERE user_id = $1", userID)
+		execSQL(t, "DELETE FROM profiles WHERE user_id = $1", userID)
+	})
+}
+
 func TestUpdateProfile_isolation(t *testing.T) {
 	skipIfNoTestDB(t)
 
@@ -264,7 +310,7 @@ func TestUpdateProfile_isolation(t *testing.T) {
 	userB := newTestUserID(t)
 	InitProfileService(database.GetPool())
 
-	appA := fiber.New()
+	appA := newTestApp()
 	appA.Use(withUser(userA))
 	appA.Patch("/me/onboarding", UpdateOnboarding)
 	appA.Patch("/me/profile", UpdateProfile)
@@ -286,7 +332,7 @@ func TestUpdateProfile_isolation(t *testing.T) {
 	profileResp.Body.Close()
 	assertStatus(t, profileResp, http.StatusOK)
 
-	appB := fiber.New()
+	appB := newTestApp()
 	appB.Use(withUser(userB))
 	appB.Get("/me", GetMe)
 
@@ -315,7 +361,7 @@ func TestUpdateOnboarding_isolation(t *testing.T) {
 	InitProfileService(database.GetPool())
 
 	// Complete onboarding as user A.
-	appA := fiber.New()
+	appA := newTestApp()
 	appA.Use(withUser(userA))
 	appA.Patch("/me/onboarding", UpdateOnboarding)
 
@@ -330,7 +376,7 @@ func TestUpdateOnboarding_isolation(t *testing.T) {
 	assertStatus(t, respA, http.StatusOK)
 
 	// Fetch profile as user B; it should not be completed.
-	appB := fiber.New()
+	appB := newTestApp()
 	appB.Use(withUser(userB))
 	appB.Get("/me", GetMe)
 
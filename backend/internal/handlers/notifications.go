@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/middleware"
+	"fintu-tracking-backend/internal/models"
+	"fintu-tracking-backend/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+var notificationService = services.NewNotificationService(nil)
+
+// InitNotificationService sets the package-level notification service used by
+// handlers. It is called once from main.go with the service that alerts and
+// other producers notify through, so their notifications reach open streams.
+func InitNotificationService(svc *services.NotificationService) {
+	notificationService = svc
+}
+
+// ListNotifications handles GET /api/notifications and always returns
+// models.PaginatedResponse, newest first (a models.Page on v2). ?unread=true
+// lists unread only.
+func ListNotifications(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	unreadOnly := false
+	switch c.Query("unread") {
+	case "", "false":
+	case "true":
+		unreadOnly = true
+	default:
+		return apperror.New(apperror.Validation, "unread must be true or false")
+	}
+
+	if apiVersion(c) >= APIv2 {
+		page, err := listPageFromQuery(c)
+		if err != nil {
+			return err
+		}
+		notifications, err := notificationService.ListNotificationsFrom(c.Context(), userID, unreadOnly, page.offset, page.limit+1)
+		if err != nil {
+			return err
+		}
+		return c.JSON(pageOf(notifications, page))
+	}
+
+	params, err := parsePaginationParams(c.Query("page"), c.Query("page_size"))
+	if err != nil || params.pageSize > maxPageSize {
+		return apperror.New(apperror.Validation, "invalid page or page_size")
+	}
+
+	page, err := notificationService.ListNotifications(c.Context(), userID, unreadOnly, params.page, params.pageSize)
+	if err != nil {
+		return err
+	}
+	return c.JSON(page)
+}
+
+// GetUnreadNotificationCount handles GET /api/notifications/unread-count.
+func GetUnreadNotificationCount(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	count, err := notificationService.UnreadCount(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(models.UnreadNotificationCount{Unread: count})
+}
+
+// MarkNotificationRead handles POST /api/notifications/:id/read.
+func MarkNotificationRead(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	notification, err := notificationService.MarkRead(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(notification)
+}
+
+// MarkAllNotificationsRead handles POST /api/notifications/read-all.
+func MarkAllNotificationsRead(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	updated, err := notificationService.MarkAllRead(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(models.NotificationsMarkedRead{Updated: updated})
+}
+
+// StreamNotifications handles GET /api/notifications/stream, a Server-Sent
+// Events stream. It starts with a "ready" event carrying the unread count,
+// then sends a "notification" event for each new notification and a comment
+// line as heartbeat. The stream needs the usual Authorization header, so
+// browsers use a fetch-based SSE client rather than EventSource.
+func StreamNotifications(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	// Subscribe before counting so a notification created in between is
+	// still streamed.
+	notifications, cancel := notificationService.Subscribe(userID)
+	unread, err := notificationService.UnreadCount(c.Context(), userID)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		if err := writeSSEEvent(w, "ready", "", fiber.Map{"unread": unread}); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(config.NotificationStreamHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case notification, ok := <-notifications:
+				if !ok {
+					return
+				}
+				if err := writeSSEEvent(w, "notification", notification.ID, notification); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				// Writing fails once the client has gone away, which ends the stream.
+				if _, err := w.WriteString(": ping\n\n"); err != nil {
+					return
+				}
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+// writeSSEEvent writes one Server-Sent Event with a JSON data line and
+// flushes it to the client.
+func writeSSEEvent(w *bufio.Writer, event, id string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// notifyUser adds a notification for a producer that runs inside a request.
+// It uses a fresh context so the notification survives the request, and only
+// logs failures because the request itself succeeded.
+func notifyUser(userID string, in services.NotificationInput) {
+	if _, err := notificationService.Notify(context.Background(), userID, in); err != nil {
+		slog.Error("notification failed", "user_id", userID, "error", err)
+	}
+}
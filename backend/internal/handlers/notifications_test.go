@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestWriteSSEEvent(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	if err := writeSSEEvent(w, "notification", "n-1", fiber.Map{"title": "VOO above 500"}); err != nil {
+		t.Fatalf("writeSSEEvent: %v", err)
+	}
+	want := "id: n-1\nevent: notification\ndata: {\"title\":\"VOO above 500\"}\n\n"
+	if buf.String() != want {
+		t.Errorf("event = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := writeSSEEvent(w, "ready", "", fiber.Map{"unread": 3}); err != nil {
+		t.Fatalf("writeSSEEvent: %v", err)
+	}
+	if got := buf.String(); strings.Contains(got, "id:") || got != "event: ready\ndata: {\"unread\":3}\n\n" {
+		t.Errorf("event = %q", got)
+	}
+}
@@ -1,8 +1,16 @@
 package handlers
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/gofiber/fiber/v3"
 )
 
 const (
@@ -11,6 +19,13 @@ const (
 	exportPageSize  = 10000
 )
 
+// /api/v2 lists return defaultListLimit items per page unless ?limit= asks
+// for up to maxListLimit.
+const (
+	defaultListLimit = 50
+	maxListLimit     = 100
+)
+
 var allowedPageSizes = map[int]bool{10: true, 25: true, 50: true, 100: true}
 
 type paginationParams struct {
@@ -78,3 +93,93 @@ func parseExportPageSize(pageSizeStr string) (int, error) {
 	}
 	return ps, nil
 }
+
+// listPage is the position and size of a /api/v2 list request.
+type listPage struct {
+	offset int
+	limit  int
+}
+
+// pageCursor is the decoded form of the opaque ?cursor= value.
+type pageCursor struct {
+	Offset int `json:"o"`
+}
+
+func parseListLimit(limit string) (int, error) {
+	if limit == "" {
+		return defaultListLimit, nil
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 1 || n > maxListLimit {
+		return 0, apperror.Newf(apperror.Validation, "limit must be between 1 and %d", maxListLimit)
+	}
+	return n, nil
+}
+
+func parseListPage(cursor, limit string) (listPage, error) {
+	n, err := parseListLimit(limit)
+	if err != nil {
+		return listPage{}, err
+	}
+	page := listPage{limit: n}
+	if cursor != "" {
+		var c pageCursor
+		if err := decodeCursor(cursor, &c); err != nil || c.Offset < 0 {
+			return listPage{}, errors.New("invalid cursor")
+		}
+		page.offset = c.Offset
+	}
+	return page, nil
+}
+
+// listPageFromQuery reads ?cursor= and ?limit=.
+func listPageFromQuery(c fiber.Ctx) (listPage, error) {
+	page, err := parseListPage(c.Query("cursor"), c.Query("limit"))
+	if err != nil {
+		return listPage{}, apperror.Classify(apperror.Validation, err)
+	}
+	return page, nil
+}
+
+func encodeCursor(v any) string {
+	raw, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// pageOf builds the page at p from items fetched with one more row than
+// p.limit, the extra row telling whether another page follows.
+func pageOf[T any](items []T, p listPage) models.Page[T] {
+	page := models.Page[T]{Items: items}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	if len(items) > p.limit {
+		page.Items = items[:p.limit]
+		next := encodeCursor(pageCursor{Offset: p.offset + p.limit})
+		page.NextCursor = &next
+	}
+	return page
+}
+
+// respondList sends a complete list: as a JSON array for v1 and as the page
+// asked for by ?cursor= and ?limit= for v2.
+func respondList[T any](c fiber.Ctx, items []T) error {
+	if apiVersion(c) < APIv2 {
+		return c.JSON(items)
+	}
+	p, err := listPageFromQuery(c)
+	if err != nil {
+		return err
+	}
+	start := min(p.offset, len(items))
+	end := min(start+p.limit+1, len(items))
+	return c.JSON(pageOf(items[start:end], p))
+}
@@ -1,8 +1,13 @@
 package handlers
 
 import (
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/gofiber/fiber/v3"
 )
 
 func TestParsePaginationParams_Defaults(t *testing.T) {
@@ -40,7 +45,7 @@ func TestClampPage(t *testing.T) {
 func TestBuildListTradesQuery_LimitOffset(t *testing.T) {
 	t.Parallel()
 
-	query, args := buildListTradesQuery("user-1", tradeListFilters{}, 50, 100)
+	query, args := buildListTradesQuery("user-1", tradeListFilters{}, listOrder{}, 50, 100)
 	if !strings.Contains(query, "LIMIT $2") || !strings.Contains(query, "OFFSET $3") {
 		t.Fatalf("query missing limit/offset: %s", query)
 	}
@@ -48,3 +53,85 @@ func TestBuildListTradesQuery_LimitOffset(t *testing.T) {
 		t.Fatalf("args = %v", args)
 	}
 }
+
+func TestParseListPage(t *testing.T) {
+	t.Parallel()
+
+	page, err := parseListPage("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.offset != 0 || page.limit != defaultListLimit {
+		t.Fatalf("default page = %+v", page)
+	}
+
+	page, err = parseListPage(encodeCursor(pageCursor{Offset: 40}), "20")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.offset != 40 || page.limit != 20 {
+		t.Fatalf("page = %+v", page)
+	}
+
+	for _, tc := range []struct{ cursor, limit string }{
+		{"", "0"},
+		{"", "101"},
+		{"not base64!", ""},
+		{encodeCursor(pageCursor{Offset: -1}), ""},
+	} {
+		if _, err := parseListPage(tc.cursor, tc.limit); err == nil {
+			t.Errorf("parseListPage(%q, %q) succeeded", tc.cursor, tc.limit)
+		}
+	}
+}
+
+func TestPageOf(t *testing.T) {
+	t.Parallel()
+
+	page := pageOf([]int{1, 2, 3}, listPage{offset: 10, limit: 2})
+	if len(page.Items) != 2 || page.NextCursor == nil {
+		t.Fatalf("page = %+v", page)
+	}
+	next, err := parseListPage(*page.NextCursor, "2")
+	if err != nil || next.offset != 12 {
+		t.Fatalf("next page = %+v, %v", next, err)
+	}
+
+	last := pageOf([]int(nil), listPage{limit: 2})
+	if last.Items == nil || last.NextCursor != nil {
+		t.Fatalf("last page = %+v", last)
+	}
+}
+
+func TestRespondList(t *testing.T) {
+	app := newTestApp()
+	list := func(c fiber.Ctx) error { return respondList(c, []string{"a", "b", "c"}) }
+	app.Get("/v1", list)
+	app.Get("/v2", APIVersion(APIv2), list)
+
+	tests := []struct{ target, want string }{
+		{"/v1?limit=1", `["a","b","c"]`},
+		{"/v2", `{"items":["a","b","c"],"next_cursor":null}`},
+		{"/v2?limit=2", `{"items":["a","b"],"next_cursor":"` + encodeCursor(pageCursor{Offset: 2}) + `"}`},
+		{"/v2?limit=2&cursor=" + encodeCursor(pageCursor{Offset: 2}), `{"items":["c"],"next_cursor":null}`},
+		{"/v2?cursor=" + encodeCursor(pageCursor{Offset: 9}), `{"items":[],"next_cursor":null}`},
+	}
+	for _, tc := range tests {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, tc.target, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if got := string(body); got != tc.want {
+			t.Errorf("GET %s = %s, want %s", tc.target, got, tc.want)
+		}
+	}
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/v2?cursor=bad!", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("bad cursor status = %d, want 400", resp.StatusCode)
+	}
+}
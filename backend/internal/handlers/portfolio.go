@@ -1,112 +1,222 @@
 package handlers
 
 import (
-	"context"
 	"errors"
 	"fmt"
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
 	"fintu-tracking-backend/internal/database"
+	"fintu-tracking-backend/internal/i18n"
+	"fintu-tracking-backend/internal/logging"
 	"fintu-tracking-backend/internal/middleware"
 	"fintu-tracking-backend/internal/models"
 	"fintu-tracking-backend/internal/services"
+	"log/slog"
 	"math"
 	"strings"
 
 	"github.com/gofiber/fiber/v3"
 )
 
-var twelveDataSvc = services.NewTwelveDataService(nil)
+var twelveDataSvc = services.NewTwelveDataService(nil, "")
 
-// InitTwelveDataService wires the DB pool into the Twelve Data service singleton.
-func InitTwelveDataService() {
-	twelveDataSvc = services.NewTwelveDataService(database.GetPool())
+// InitTwelveDataService wires the DB pool and API key into the Twelve Data
+// service singleton.
+func InitTwelveDataService(apiKey string) {
+	twelveDataSvc = services.NewTwelveDataService(database.GetPool(), apiKey)
 }
 
 // RefreshMarketPrices fetches live quotes for held tickers and updates market_prices.
 func RefreshMarketPrices(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
 	}
 
-	result, err := twelveDataSvc.RefreshMarketPrices(context.Background(), userID)
+	result, err := twelveDataSvc.RefreshMarketPrices(c.Context(), userID)
+	if result.Updated > 0 {
+		alertService.EvaluateTickersInBackground(userID, result.Tickers)
+		publishWebhookEvent(userID, config.WebhookEventPriceRefreshed, fiber.Map{"source": "market", "result": result})
+	}
+	notifyRefreshErrors(middleware.GetLocale(c), userID, "Price refresh", result)
 	if err != nil {
-		var rateLimitErr *services.RateLimitError
-		if errors.As(err, &rateLimitErr) {
-			retryAfterSeconds := int(math.Ceil(rateLimitErr.RetryAfter.Seconds()))
-			c.Set("Retry-After", fmt.Sprintf("%d", retryAfterSeconds))
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":        err.Error(),
-				"retry_after":  retryAfterSeconds,
-				"updated":      result.Updated,
-				"tickers":      result.Tickers,
-				"errors":       result.Errors,
-			})
-		}
-		if strings.Contains(strings.ToLower(err.Error()), "rate limit") {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":   err.Error(),
-				"updated": result.Updated,
-				"tickers": result.Tickers,
-				"errors":  result.Errors,
-			})
-		}
-		if strings.Contains(err.Error(), "TWELVE_DATA_API_KEY") {
-			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": err.Error()})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   err.Error(),
-			"updated": result.Updated,
-			"tickers": result.Tickers,
-			"errors":  result.Errors,
-		})
+		return marketRefreshErrorResponse(c, result, err)
 	}
 
 	return c.JSON(result)
 }
 
+// RefreshDailyPrices backfills daily closing prices for held tickers and every
+// benchmark the user can chart, which risk metrics and benchmarks are computed from.
+func RefreshDailyPrices(c fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
+	}
+
+	benchmarks, err := benchmarkService.ListBenchmarks(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	result, err := twelveDataSvc.BackfillDailyPrices(c.Context(), userID, services.BenchmarkTickers(benchmarks))
+	if result.Updated > 0 {
+		alertService.EvaluateTickersInBackground(userID, result.Tickers)
+		publishWebhookEvent(userID, config.WebhookEventPriceRefreshed, fiber.Map{"source": "daily", "result": result})
+	}
+	notifyRefreshErrors(middleware.GetLocale(c), userID, "Daily price backfill", result)
+	if err != nil {
+		return marketRefreshErrorResponse(c, result, err)
+	}
+
+	return c.JSON(result)
+}
+
+// notifyRefreshErrors tells the user through the inbox which tickers a refresh
+// could not update, so failures are visible after the request is gone.
+func notifyRefreshErrors(loc i18n.Locale, userID, what string, result services.RefreshResult) {
+	if len(result.Errors) == 0 {
+		return
+	}
+	notifyUser(userID, services.NotificationInput{
+		Type:  config.NotificationTypePriceRefreshFailed,
+		Title: loc.Sprintf("%s failed for %d tickers", loc.Translate(what), len(result.Errors)),
+		Body:  strings.Join(result.Errors, "\n"),
+	})
+}
+
+func marketRefreshErrorResponse(c fiber.Ctx, result services.RefreshResult, err error) error {
+	kind := apperror.KindOf(err)
+	message := middleware.ErrorMessage(c, err)
+	body := fiber.Map{
+		"updated": result.Updated,
+		"tickers": result.Tickers,
+		"errors":  result.Errors,
+	}
+
+	var rateLimitErr *services.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		retryAfterSeconds := int(math.Ceil(rateLimitErr.RetryAfter.Seconds()))
+		c.Set("Retry-After", fmt.Sprintf("%d", retryAfterSeconds))
+		body["retry_after"] = retryAfterSeconds
+		kind = apperror.RateLimited
+		message = middleware.GetLocale(c).Sprintf("Rate limit exceeded, retry in %d seconds", retryAfterSeconds)
+	}
+	if kind == apperror.Internal {
+		slog.ErrorContext(c.Context(), "market price refresh failed", "error", err)
+	}
+
+	body["error"] = message
+	body["code"] = kind
+	body["request_id"] = logging.RequestID(c.Context())
+	return c.Status(kind.Status()).JSON(body)
+}
+
 // GetHoldings calculates and returns current holdings.
 // Without page/page_size query params, returns a plain JSON array (legacy).
 // With pagination params, returns models.PaginatedResponse sorted by market value descending.
+// With group_by=broker, returns holdings grouped per broker (not paginated).
 func GetHoldings(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
+	}
+
+	portfolioID, err := resolvePortfolioScope(c, userID)
+	if err != nil {
+		return err
 	}
 
 	pageStr := c.Query("page")
 	pageSizeStr := c.Query("page_size")
 
-	analyticsService := services.NewAnalyticsService(database.GetPool())
-	ctx := context.Background()
+	analyticsService := services.NewAnalyticsService(database.GetPool()).ForPortfolio(portfolioID)
+	ctx := c.Context()
+
+	switch c.Query("group_by") {
+	case "":
+	case "broker":
+		grouped, err := analyticsService.GetHoldingsByBroker(ctx, userID)
+		if err != nil {
+			return err
+		}
+		return c.JSON(grouped)
+	default:
+		return apperror.New(apperror.Validation, "group_by must be 'broker'")
+	}
 
 	if !paginationRequested(pageStr, pageSizeStr) {
 		holdings, err := analyticsService.GetCurrentHoldings(ctx, userID)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			return err
 		}
 		return c.JSON(holdings)
 	}
 
 	params, err := parsePaginationParams(pageStr, pageSizeStr)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return apperror.Classify(apperror.Validation, err)
 	}
 
 	holdings, err := analyticsService.GetCurrentHoldingsByMarketValue(ctx, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	return c.JSON(paginateHoldings(holdings, params.page, params.pageSize))
 }
 
+// ListHoldings handles GET /api/v2/holdings, current holdings by market
+// value, largest first.
+func ListHoldings(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+	portfolioID, err := resolvePortfolioScope(c, userID)
+	if err != nil {
+		return err
+	}
+
+	analyticsService := services.NewAnalyticsService(database.GetPool()).ForPortfolio(portfolioID)
+	holdings, err := analyticsService.GetCurrentHoldingsByMarketValue(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	return respondList(c, models.HoldingsV2(holdings))
+}
+
+// ListHoldingsByBroker handles GET /api/v2/holdings/by-broker, current
+// holdings grouped per broker.
+func ListHoldingsByBroker(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+	portfolioID, err := resolvePortfolioScope(c, userID)
+	if err != nil {
+		return err
+	}
+
+	analyticsService := services.NewAnalyticsService(database.GetPool()).ForPortfolio(portfolioID)
+	grouped, err := analyticsService.GetHoldingsByBroker(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	out := make([]models.BrokerHoldingsV2, len(grouped))
+	for i, g := range grouped {
+		out[i] = g.V2()
+	}
+	return respondList(c, out)
+}
+
 // ListMarketPrices returns all market prices
 func ListMarketPrices(c fiber.Ctx) error {
 	query := `SELECT ticker, price, currency, updated_at FROM market_prices ORDER BY ticker`
 
-	rows, err := database.GetPool().Query(context.Background(), query)
+	rows, err := database.GetPool().Query(c.Context(), query)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 	defer rows.Close()
 
@@ -114,12 +224,12 @@ func ListMarketPrices(c fiber.Ctx) error {
 	for rows.Next() {
 		var price models.MarketPrice
 		if err := rows.Scan(&price.Ticker, &price.Price, &price.Currency, &price.UpdatedAt); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			return err
 		}
 		prices = append(prices, price)
 	}
 
-	return c.JSON(prices)
+	return respondList(c, prices)
 }
 
 // GetMarketPrice returns a specific market price
@@ -129,11 +239,11 @@ func GetMarketPrice(c fiber.Ctx) error {
 	query := `SELECT ticker, price, currency, updated_at FROM market_prices WHERE ticker = $1`
 
 	var price models.MarketPrice
-	err := database.GetPool().QueryRow(context.Background(), query, ticker).
+	err := database.GetPool().QueryRow(c.Context(), query, ticker).
 		Scan(&price.Ticker, &price.Price, &price.Currency, &price.UpdatedAt)
 
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Market price not found"})
+		return apperror.New(apperror.NotFound, "Market price not found")
 	}
 
 	return c.JSON(price)
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/middleware"
+	"fintu-tracking-backend/internal/models"
+	"fintu-tracking-backend/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var portfolioService = services.NewPortfolioService(nil)
+
+// InitPortfolioService sets the package-level portfolio service used by handlers.
+// It is called once from main.go after the DB pool is available.
+func InitPortfolioService(pool *pgxpool.Pool) {
+	portfolioService = services.NewPortfolioService(pool)
+}
+
+// ListPortfolios returns the user's portfolios, default first.
+func ListPortfolios(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	portfolios, err := portfolioService.ListPortfolios(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	return respondList(c, portfolios)
+}
+
+// GetConsolidatedPortfolios returns each portfolio's net worth next to the consolidated total.
+func GetConsolidatedPortfolios(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	view, err := portfolioService.ConsolidatedView(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	if apiVersion(c) >= APIv2 {
+		return c.JSON(view.V2())
+	}
+	return c.JSON(view)
+}
+
+// CreatePortfolio creates a named portfolio.
+func CreatePortfolio(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.CreatePortfolioRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperror.New(apperror.Validation, "Invalid request body")
+	}
+	if err := validateBrokerID(c.Context(), userID, req.BrokerID); err != nil {
+		return err
+	}
+
+	portfolio, err := portfolioService.CreatePortfolio(c.Context(), userID, req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(portfolio)
+}
+
+// UpdatePortfolio renames a portfolio, changes its broker, or makes it the default.
+func UpdatePortfolio(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.UpdatePortfolioRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperror.New(apperror.Validation, "Invalid request body")
+	}
+	if err := validateBrokerID(c.Context(), userID, req.BrokerID); err != nil {
+		return err
+	}
+
+	portfolio, err := portfolioService.UpdatePortfolio(c.Context(), userID, c.Params("id"), req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(portfolio)
+}
+
+// DeletePortfolio deletes an empty, non-default portfolio.
+func DeletePortfolio(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := portfolioService.DeletePortfolio(c.Context(), userID, c.Params("id")); err != nil {
+		return err
+	}
+	return c.JSON(models.MessageResponse{Message: "Portfolio deleted successfully"})
+}
+
+// CreatePortfolioTransfer moves cash between two portfolios without counting it
+// as a deposit or withdrawal.
+func CreatePortfolioTransfer(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.CreatePortfolioTransferRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperror.New(apperror.Validation, "Invalid request body")
+	}
+
+	transfer, err := portfolioService.CreateTransfer(c.Context(), userID, req)
+	if err != nil {
+		return err
+	}
+	publishWebhookEvent(userID, config.WebhookEventCashFlowCreated, transfer.Out)
+	publishWebhookEvent(userID, config.WebhookEventCashFlowCreated, transfer.In)
+	return c.Status(fiber.StatusCreated).JSON(transfer)
+}
+
+// DeletePortfolioTransfer removes both legs of an internal transfer.
+func DeletePortfolioTransfer(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := portfolioService.DeleteTransfer(c.Context(), userID, c.Params("id")); err != nil {
+		return err
+	}
+	publishWebhookEvent(userID, config.WebhookEventCashFlowDeleted, fiber.Map{"transfer_id": c.Params("id")})
+	return c.JSON(models.MessageResponse{Message: "Transfer deleted successfully"})
+}
+
+// portfolioScopeQuery reads the optional portfolio_id query param used by
+// analytics endpoints. Empty or "all" selects the consolidated view.
+func portfolioScopeQuery(c fiber.Ctx) string {
+	value := strings.TrimSpace(c.Query("portfolio_id"))
+	if strings.EqualFold(value, "all") {
+		return ""
+	}
+	return value
+}
+
+// resolvePortfolioScope returns the portfolio ID selected by the request after
+// checking that it belongs to the user. An empty result is the consolidated view.
+func resolvePortfolioScope(c fiber.Ctx, userID string) (string, error) {
+	portfolioID := portfolioScopeQuery(c)
+	if portfolioID == "" {
+		return "", nil
+	}
+	if _, err := portfolioService.GetPortfolio(c.Context(), userID, portfolioID); err != nil {
+		return "", err
+	}
+	return portfolioID, nil
+}
+
+// resolvePortfolioID validates an explicit portfolio_id on a write request.
+// nil lets the database assign the user's default portfolio.
+func resolvePortfolioID(ctx context.Context, userID string, portfolioID *string) (*string, error) {
+	if portfolioID == nil || strings.TrimSpace(*portfolioID) == "" {
+		return nil, nil
+	}
+	if _, err := portfolioService.GetPortfolio(ctx, userID, *portfolioID); err != nil {
+		return nil, err
+	}
+	return portfolioID, nil
+}
+
+// resolveTradePortfolioID returns the validated portfolio for a new trade,
+// defaulting to the user's default portfolio. Trades need a concrete ID up front
+// because sells are checked against that portfolio's holdings.
+func resolveTradePortfolioID(ctx context.Context, userID string, portfolioID *string) (string, error) {
+	resolved, err := resolvePortfolioID(ctx, userID, portfolioID)
+	if err != nil {
+		return "", err
+	}
+	if resolved != nil {
+		return *resolved, nil
+	}
+	return portfolioService.EnsureDefaultPortfolio(ctx, userID)
+}
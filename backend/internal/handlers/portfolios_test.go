@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildListCashFlowsQuery_PortfolioFilter(t *testing.T) {
+	t.Parallel()
+
+	filters, err := parseCashFlowListFilters("", "", "transfer_in", "", "")
+	if err != nil {
+		t.Fatalf("transfer_in should be a valid list filter: %v", err)
+	}
+	filters.portfolio = "portfolio-1"
+
+	query, args := buildListCashFlowsQuery("user-1", filters, listOrder{}, 10, 0)
+	if !strings.Contains(query, "portfolio_id = $") {
+		t.Fatalf("query missing portfolio filter: %s", query)
+	}
+	found := false
+	for _, arg := range args {
+		if arg == "portfolio-1" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("args = %v, want portfolio-1", args)
+	}
+}
@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"context"
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
 	"fintu-tracking-backend/internal/database"
 	"fintu-tracking-backend/internal/middleware"
 	"fintu-tracking-backend/internal/models"
@@ -19,16 +21,17 @@ import (
 const tradeListColumns = `
 	id, user_id, date, ticker, asset_type, side, is_opening_position, quantity, price,
 	COALESCE(deposit_fee, 0), COALESCE(trading_fee, 0), COALESCE(closing_fee, 0),
-	COALESCE(total_fees, 0), total, broker_id, notes, created_at, updated_at
+	COALESCE(total_fees, 0), total, portfolio_id, broker_id, notes, created_at, updated_at
 `
 
 // ListTrades returns trades for the authenticated user with optional filters.
 // Without page/page_size query params, returns a plain JSON array (legacy).
-// With page or page_size, returns models.PaginatedResponse.
+// With page or page_size, returns models.PaginatedResponse. v2 always returns
+// a models.Page.
 func ListTrades(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
 	}
 
 	filters, err := parseTradeListFilters(
@@ -39,7 +42,18 @@ func ListTrades(c fiber.Ctx) error {
 		c.Query("ticker"),
 	)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return apperror.Classify(apperror.Validation, err)
+	}
+	filters.portfolio, err = resolvePortfolioScope(c, userID)
+	if err != nil {
+		return err
+	}
+	sort, err := parseListSort(c.Query("sort"), tradeSortColumns)
+	if err != nil {
+		return apperror.Classify(apperror.Validation, err)
+	}
+	if apiVersion(c) >= APIv2 {
+		return listTradesPage(c, userID, filters, sort)
 	}
 
 	pageStr := c.Query("page")
@@ -53,7 +67,7 @@ func ListTrades(c fiber.Ctx) error {
 	if paginationRequested(pageStr, pageSizeStr) {
 		params, err := parsePaginationParams(pageStr, pageSizeStr)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+			return apperror.Classify(apperror.Validation, err)
 		}
 		page = params.page
 		pageSize = params.pageSize
@@ -64,18 +78,73 @@ func ListTrades(c fiber.Ctx) error {
 	var total int
 	if limit > 0 {
 		countQuery, countArgs := buildCountTradesQuery(userID, filters)
-		if err := database.GetPool().QueryRow(context.Background(), countQuery, countArgs...).Scan(&total); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		if err := database.GetPool().QueryRow(c.Context(), countQuery, countArgs...).Scan(&total); err != nil {
+			return err
 		}
 		page = clampPage(page, total, pageSize)
 		offset = (page - 1) * pageSize
 	}
 
-	query, args := buildListTradesQuery(userID, filters, limit, offset)
+	query, args := buildListTradesQuery(userID, filters, listOrder{sort: sort}, limit, offset)
+
+	trades, err := queryTrades(c.Context(), userID, query, args)
+	if err != nil {
+		return err
+	}
+
+	if limit > 0 {
+		return c.JSON(models.PaginatedResponse[models.Trade]{
+			Items:    trades,
+			Total:    total,
+			Page:     page,
+			PageSize: pageSize,
+		})
+	}
+
+	return c.JSON(trades)
+}
+
+// listTradesPage answers /api/v2/trades with the page after ?cursor= in ?sort= order.
+// Pages are keyed on the sort column and (date, created_at, id), so rows
+// inserted while scrolling neither repeat nor shift later pages. The total is
+// only counted when ?include_total=true.
+func listTradesPage(c fiber.Ctx, userID string, filters tradeListFilters, sort listSort) error {
+	page, err := keysetPageFromQuery(c, sort)
+	if err != nil {
+		return err
+	}
+	query, args := buildListTradesQuery(userID, filters, page.order, page.limit+1, 0)
+	trades, err := queryTrades(c.Context(), userID, query, args)
+	if err != nil {
+		return err
+	}
+	out := keysetPageOf(trades, page, func(t models.Trade) []string {
+		var value string
+		switch sort.key {
+		case "ticker":
+			value = t.Ticker
+		case "total":
+			value = t.Total
+		}
+		return sort.cursorKey(value, t.Date, t.CreatedAt, t.ID)
+	})
+
+	if c.Query("include_total") == "true" {
+		var total int
+		countQuery, countArgs := buildCountTradesQuery(userID, filters)
+		if err := database.GetPool().QueryRow(c.Context(), countQuery, countArgs...).Scan(&total); err != nil {
+			return err
+		}
+		out.Total = &total
+	}
+	return c.JSON(out)
+}
 
-	rows, err := database.GetPool().Query(context.Background(), query, args...)
+// queryTrades runs a trade list query and fills in the realized P/L of sells.
+func queryTrades(ctx context.Context, userID, query string, args []interface{}) ([]models.Trade, error) {
+	rows, err := database.GetPool().Query(ctx, query, args...)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -83,12 +152,15 @@ func ListTrades(c fiber.Ctx) error {
 	for rows.Next() {
 		trade, err := scanTradeRow(rows)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			return nil, err
 		}
 		trades = append(trades, trade)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	realizedMap, realizedErr := services.NewAnalyticsService(database.GetPool()).RealizedPLByTradeID(c.Context(), userID)
+	realizedMap, realizedErr := services.NewAnalyticsService(database.GetPool()).RealizedPLByTradeID(ctx, userID)
 	if realizedErr == nil {
 		for i := range trades {
 			if trades[i].Side != "sell" {
@@ -102,31 +174,21 @@ func ListTrades(c fiber.Ctx) error {
 			trades[i].RealizedPL = &plStr
 		}
 	}
-
-	if limit > 0 {
-		return c.JSON(models.PaginatedResponse[models.Trade]{
-			Items:    trades,
-			Total:    total,
-			Page:     page,
-			PageSize: pageSize,
-		})
-	}
-
-	return c.JSON(trades)
+	return trades, nil
 }
 
 // ListTradeTickers returns distinct tickers for the authenticated user (filter combobox).
 func ListTradeTickers(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
 	}
 
-	rows, err := database.GetPool().Query(context.Background(), `
+	rows, err := database.GetPool().Query(c.Context(), `
 		SELECT DISTINCT ticker FROM trades WHERE user_id = $1 ORDER BY ticker ASC
 	`, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 	defer rows.Close()
 
@@ -134,80 +196,84 @@ func ListTradeTickers(c fiber.Ctx) error {
 	for rows.Next() {
 		var ticker string
 		if err := rows.Scan(&ticker); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			return err
 		}
 		tickers = append(tickers, ticker)
 	}
 
-	return c.JSON(tickers)
+	return respondList(c, tickers)
 }
 
 // CreateTrade creates a new trade
 func CreateTrade(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
 	}
 
 	var req models.CreateTradeRequest
 	if err := c.Bind().JSON(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		return apperror.New(apperror.Validation, "Invalid request body")
 	}
 
 	req.Ticker = strings.TrimSpace(strings.ToUpper(req.Ticker))
 	if req.Ticker == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Ticker is required"})
+		return apperror.New(apperror.Validation, "Ticker is required")
 	}
 
 	if req.AssetType != "stock" && req.AssetType != "etf" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid asset type"})
+		return apperror.New(apperror.Validation, "Invalid asset type")
 	}
 	if req.Side != "buy" && req.Side != "sell" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid side"})
+		return apperror.New(apperror.Validation, "Invalid side")
 	}
 	isOpeningPosition := req.IsOpeningPosition != nil && *req.IsOpeningPosition
 	if isOpeningPosition && req.Side != "buy" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Opening position must use buy side"})
+		return apperror.New(apperror.Validation, "Opening position must use buy side")
 	}
 	if isOpeningPosition && (req.Notes == nil || strings.TrimSpace(*req.Notes) == "") {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Notes are required for opening positions"})
+		return apperror.New(apperror.Validation, "Notes are required for opening positions")
 	}
 
 	quantity, err := decimal.NewFromString(req.Quantity)
 	if err != nil || !quantity.GreaterThan(decimal.Zero) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid quantity format"})
+		return apperror.New(apperror.Validation, "Invalid quantity format")
 	}
 
 	price, err := decimal.NewFromString(req.Price)
 	if err != nil || !price.GreaterThan(decimal.Zero) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid price format"})
+		return apperror.New(apperror.Validation, "Invalid price format")
 	}
 
 	date, err := parseTradeDate(req.Date)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid date format"})
+		return apperror.New(apperror.Validation, "Invalid date format")
 	}
 
 	depositFee, tradingFee, closingFee, err := parseSplitFees(req.DepositFee, req.TradingFee, req.ClosingFee)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return apperror.Classify(apperror.Validation, err)
 	}
 
 	depositFee, tradingFee, closingFee, err = applyLegacyFeeToTrading(req.Fee, depositFee, tradingFee, closingFee)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return apperror.Classify(apperror.Validation, err)
 	}
 	if isOpeningPosition && depositFee.Add(tradingFee).Add(closingFee).GreaterThan(decimal.Zero) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Opening position cannot include fees"})
+		return apperror.New(apperror.Validation, "Opening position cannot include fees")
 	}
 
+	portfolioID, err := resolveTradePortfolioID(c.Context(), userID, req.PortfolioID)
+	if err != nil {
+		return err
+	}
 	if req.Side == "sell" {
-		if err := validateSellQuantity(c.Context(), userID, req.Ticker, "", quantity); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		if err := validateSellQuantity(c.Context(), userID, portfolioID, req.Ticker, "", quantity); err != nil {
+			return err
 		}
 	}
 	if err := validateBrokerID(c.Context(), userID, req.BrokerID); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	id := uuid.New().String()
@@ -215,26 +281,27 @@ func CreateTrade(c fiber.Ctx) error {
 	query := `
 		INSERT INTO trades (
 			id, user_id, date, ticker, asset_type, side, is_opening_position, quantity, price, notes,
-			deposit_fee, trading_fee, closing_fee, broker_id
+			deposit_fee, trading_fee, closing_fee, broker_id, portfolio_id
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 		RETURNING ` + tradeListColumns
 
 	var trade models.Trade
-	err = database.GetPool().QueryRow(context.Background(), query,
+	err = database.GetPool().QueryRow(c.Context(), query,
 		id, userID, date, req.Ticker, req.AssetType, req.Side,
 		isOpeningPosition, req.Quantity, req.Price, req.Notes,
 		depositFee.StringFixed(2), tradingFee.StringFixed(2), closingFee.StringFixed(2),
-		req.BrokerID,
+		req.BrokerID, portfolioID,
 	).Scan(
 		&trade.ID, &trade.UserID, &trade.Date, &trade.Ticker, &trade.AssetType,
 		&trade.Side, &trade.IsOpeningPosition, &trade.Quantity, &trade.Price,
 		&trade.DepositFee, &trade.TradingFee, &trade.ClosingFee, &trade.TotalFees,
-		&trade.Total, &trade.BrokerID, &trade.Notes, &trade.CreatedAt, &trade.UpdatedAt,
+		&trade.Total, &trade.PortfolioID, &trade.BrokerID, &trade.Notes, &trade.CreatedAt, &trade.UpdatedAt,
 	)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
+	publishWebhookEvent(userID, config.WebhookEventTradeCreated, trade)
 
 	return c.Status(fiber.StatusCreated).JSON(trade)
 }
@@ -243,50 +310,50 @@ func CreateTrade(c fiber.Ctx) error {
 func UpdateTrade(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
 	}
 
 	id := c.Params("id")
 	var req models.UpdateTradeRequest
 	if err := c.Bind().JSON(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		return apperror.New(apperror.Validation, "Invalid request body")
 	}
 
 	var existing models.Trade
 	loadQuery := `SELECT ` + tradeListColumns + ` FROM trades WHERE id = $1 AND user_id = $2`
-	err := database.GetPool().QueryRow(context.Background(), loadQuery, id, userID).Scan(
+	err := database.GetPool().QueryRow(c.Context(), loadQuery, id, userID).Scan(
 		&existing.ID, &existing.UserID, &existing.Date, &existing.Ticker, &existing.AssetType,
 		&existing.Side, &existing.IsOpeningPosition, &existing.Quantity, &existing.Price,
 		&existing.DepositFee, &existing.TradingFee, &existing.ClosingFee, &existing.TotalFees,
-		&existing.Total, &existing.BrokerID, &existing.Notes, &existing.CreatedAt, &existing.UpdatedAt,
+		&existing.Total, &existing.PortfolioID, &existing.BrokerID, &existing.Notes, &existing.CreatedAt, &existing.UpdatedAt,
 	)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Trade not found"})
+		return apperror.New(apperror.NotFound, "Trade not found")
 	}
 
 	if req.Date != nil {
 		parsed, err := parseTradeDate(*req.Date)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid date format"})
+			return apperror.New(apperror.Validation, "Invalid date format")
 		}
 		existing.Date = parsed
 	}
 	if req.Ticker != nil {
 		ticker := strings.TrimSpace(strings.ToUpper(*req.Ticker))
 		if ticker == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Ticker is required"})
+			return apperror.New(apperror.Validation, "Ticker is required")
 		}
 		existing.Ticker = ticker
 	}
 	if req.AssetType != nil {
 		if *req.AssetType != "stock" && *req.AssetType != "etf" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid asset type"})
+			return apperror.New(apperror.Validation, "Invalid asset type")
 		}
 		existing.AssetType = *req.AssetType
 	}
 	if req.Side != nil {
 		if *req.Side != "buy" && *req.Side != "sell" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid side"})
+			return apperror.New(apperror.Validation, "Invalid side")
 		}
 		existing.Side = *req.Side
 	}
@@ -294,7 +361,7 @@ func UpdateTrade(c fiber.Ctx) error {
 		existing.IsOpeningPosition = *req.IsOpeningPosition
 	}
 	if existing.IsOpeningPosition && existing.Side != "buy" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Opening position must use buy side"})
+		return apperror.New(apperror.Validation, "Opening position must use buy side")
 	}
 	if req.Quantity != nil {
 		existing.Quantity = *req.Quantity
@@ -302,13 +369,19 @@ func UpdateTrade(c fiber.Ctx) error {
 	if req.BrokerID != nil {
 		existing.BrokerID = req.BrokerID
 	}
+	if req.PortfolioID != nil {
+		if _, err := portfolioService.GetPortfolio(c.Context(), userID, *req.PortfolioID); err != nil {
+			return err
+		}
+		existing.PortfolioID = *req.PortfolioID
+	}
 	if err := validateBrokerID(c.Context(), userID, existing.BrokerID); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 	if req.Price != nil {
 		price, err := decimal.NewFromString(*req.Price)
 		if err != nil || !price.GreaterThan(decimal.Zero) {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid price format"})
+			return apperror.New(apperror.Validation, "Invalid price format")
 		}
 		existing.Price = *req.Price
 	}
@@ -329,38 +402,38 @@ func UpdateTrade(c fiber.Ctx) error {
 	if req.DepositFee != nil {
 		depositFee, err = parseOptionalFee(req.DepositFee)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid deposit_fee format"})
+			return apperror.New(apperror.Validation, "invalid deposit_fee format")
 		}
 	}
 	if req.TradingFee != nil {
 		tradingFee, err = parseOptionalFee(req.TradingFee)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid trading_fee format"})
+			return apperror.New(apperror.Validation, "invalid trading_fee format")
 		}
 	}
 	if req.ClosingFee != nil {
 		closingFee, err = parseOptionalFee(req.ClosingFee)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid closing_fee format"})
+			return apperror.New(apperror.Validation, "invalid closing_fee format")
 		}
 	}
 
 	depositFee, tradingFee, closingFee, err = applyLegacyFeeToTrading(req.Fee, depositFee, tradingFee, closingFee)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return apperror.Classify(apperror.Validation, err)
 	}
 	if existing.IsOpeningPosition && depositFee.Add(tradingFee).Add(closingFee).GreaterThan(decimal.Zero) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Opening position cannot include fees"})
+		return apperror.New(apperror.Validation, "Opening position cannot include fees")
 	}
 
 	quantity, err := decimal.NewFromString(existing.Quantity)
 	if err != nil || !quantity.GreaterThan(decimal.Zero) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid quantity format"})
+		return apperror.New(apperror.Validation, "Invalid quantity format")
 	}
 
 	if existing.Side == "sell" {
-		if err := validateSellQuantity(c.Context(), userID, existing.Ticker, id, quantity); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		if err := validateSellQuantity(c.Context(), userID, existing.PortfolioID, existing.Ticker, id, quantity); err != nil {
+			return err
 		}
 	}
 
@@ -369,7 +442,7 @@ func UpdateTrade(c fiber.Ctx) error {
 		notes = req.Notes
 	}
 	if existing.IsOpeningPosition && (notes == nil || strings.TrimSpace(*notes) == "") {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Notes are required for opening positions"})
+		return apperror.New(apperror.Validation, "Notes are required for opening positions")
 	}
 
 	updateQuery := `
@@ -377,36 +450,39 @@ func UpdateTrade(c fiber.Ctx) error {
 		SET date = $1, ticker = $2, asset_type = $3, side = $4, is_opening_position = $5, quantity = $6,
 		    price = $7, notes = $8, broker_id = $9,
 		    deposit_fee = $10, trading_fee = $11, closing_fee = $12,
-		    updated_at = NOW()
-		WHERE id = $13 AND user_id = $14
+		    portfolio_id = $13, updated_at = NOW()
+		WHERE id = $14 AND user_id = $15
 	`
 
-	result, err := database.GetPool().Exec(context.Background(), updateQuery,
+	result, err := database.GetPool().Exec(c.Context(), updateQuery,
 		existing.Date, existing.Ticker, existing.AssetType, existing.Side, existing.IsOpeningPosition,
 		existing.Quantity, existing.Price, notes, existing.BrokerID,
 		depositFee.StringFixed(2), tradingFee.StringFixed(2), closingFee.StringFixed(2),
-		id, userID,
+		existing.PortfolioID, id, userID,
 	)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	if result.RowsAffected() == 0 {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Trade not found"})
+		return apperror.New(apperror.NotFound, "Trade not found")
+	}
+	if trade, err := loadTrade(c.Context(), userID, id); err == nil {
+		publishWebhookEvent(userID, config.WebhookEventTradeUpdated, trade)
 	}
 
-	return c.JSON(fiber.Map{"message": "Trade updated successfully"})
+	return c.JSON(models.MessageResponse{Message: "Trade updated successfully"})
 }
 
 // DeleteTrade deletes a trade and linked fee cash flows created for that trade.
 func DeleteTrade(c fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperror.New(apperror.Unauthorized, "Unauthorized")
 	}
 
 	id := c.Params("id")
-	ctx := context.Background()
+	ctx := c.Context()
 
 	_, err := database.GetPool().Exec(ctx, `
 		DELETE FROM cash_flows
@@ -416,19 +492,36 @@ func DeleteTrade(c fiber.Ctx) error {
 		  AND related_type = 'trade'
 	`, userID, id)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	result, err := database.GetPool().Exec(ctx, `DELETE FROM trades WHERE id = $1 AND user_id = $2`, id, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	if result.RowsAffected() == 0 {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Trade not found"})
+		return apperror.New(apperror.NotFound, "Trade not found")
 	}
+	publishWebhookEvent(userID, config.WebhookEventTradeDeleted, fiber.Map{"id": id})
 
-	return c.JSON(fiber.Map{"message": "Trade deleted successfully"})
+	return c.JSON(models.MessageResponse{Message: "Trade deleted successfully"})
+}
+
+// loadTrade returns one of the user's trades with its computed fee totals.
+func loadTrade(ctx context.Context, userID, id string) (models.Trade, error) {
+	rows, err := database.GetPool().Query(ctx, `SELECT `+tradeListColumns+` FROM trades WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return models.Trade{}, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return models.Trade{}, err
+		}
+		return models.Trade{}, pgx.ErrNoRows
+	}
+	return scanTradeRow(rows)
 }
 
 func scanTradeRow(rows pgx.Rows) (models.Trade, error) {
@@ -437,7 +530,7 @@ func scanTradeRow(rows pgx.Rows) (models.Trade, error) {
 		&trade.ID, &trade.UserID, &trade.Date, &trade.Ticker, &trade.AssetType,
 		&trade.Side, &trade.IsOpeningPosition, &trade.Quantity, &trade.Price,
 		&trade.DepositFee, &trade.TradingFee, &trade.ClosingFee, &trade.TotalFees,
-		&trade.Total, &trade.BrokerID, &trade.Notes, &trade.CreatedAt, &trade.UpdatedAt,
+		&trade.Total, &trade.PortfolioID, &trade.BrokerID, &trade.Notes, &trade.CreatedAt, &trade.UpdatedAt,
 	)
 	return trade, err
 }
@@ -501,17 +594,19 @@ func parseOptionalDecimalPtr(value *string) (*string, error) {
 	return &s, nil
 }
 
-func validateSellQuantity(ctx context.Context, userID, ticker, excludeTradeID string, sellQty decimal.Decimal) error {
+// validateSellQuantity checks the sell against holdings of the same portfolio;
+// positions held in another portfolio cannot be sold from this one.
+func validateSellQuantity(ctx context.Context, userID, portfolioID, ticker, excludeTradeID string, sellQty decimal.Decimal) error {
 	query := `
 		SELECT COALESCE(SUM(
 			CASE WHEN side = 'buy' THEN quantity ELSE -quantity END
 		), 0)
 		FROM trades
-		WHERE user_id = $1 AND ticker = $2
+		WHERE user_id = $1 AND portfolio_id = $2 AND ticker = $3
 	`
-	args := []any{userID, ticker}
+	args := []any{userID, portfolioID, ticker}
 	if excludeTradeID != "" {
-		query += ` AND id != $3`
+		query += ` AND id != $4`
 		args = append(args, excludeTradeID)
 	}
 
@@ -526,8 +621,8 @@ func validateSellQuantity(ctx context.Context, userID, ticker, excludeTradeID st
 
 func validateSellQuantityAgainstNetHoldings(ticker string, netQty, sellQty decimal.Decimal) error {
 	if sellQty.GreaterThan(netQty) {
-		return fmt.Errorf("insufficient holdings: have %s %s, selling %s",
-			netQty.String(), ticker, sellQty.String())
+		return apperror.Newf(apperror.Validation, "insufficient holdings: have %s %s, selling %s",
+			netQty, ticker, sellQty)
 	}
 	return nil
 }
@@ -539,5 +634,5 @@ func parseTradeDate(value string) (time.Time, error) {
 			return t, nil
 		}
 	}
-	return time.Time{}, fmt.Errorf("invalid date: %q", value)
+	return time.Time{}, apperror.Newf(apperror.Validation, "invalid date: %q", value)
 }
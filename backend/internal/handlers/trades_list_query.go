@@ -12,6 +12,14 @@ type tradeListFilters struct {
 	side      string
 	assetType string
 	ticker    string
+	portfolio string
+}
+
+// tradeSortColumns are the ?sort= keys of trade lists.
+var tradeSortColumns = map[string]sortColumn{
+	"date":   {"date", "date"},
+	"ticker": {"ticker", "text"},
+	"total":  {"total", "numeric"},
 }
 
 func appendTradeListFilters(query string, args []interface{}, filters tradeListFilters) (string, []interface{}) {
@@ -42,6 +50,11 @@ func appendTradeListFilters(query string, args []interface{}, filters tradeListF
 		query += fmt.Sprintf(" AND ticker = $%d", argN)
 		args = append(args, filters.ticker)
 	}
+	if filters.portfolio != "" {
+		argN++
+		query += fmt.Sprintf(" AND portfolio_id = $%d", argN)
+		args = append(args, filters.portfolio)
+	}
 
 	return query, args
 }
@@ -53,14 +66,14 @@ func buildCountTradesQuery(userID string, filters tradeListFilters) (string, []i
 	return query, args
 }
 
-func buildListTradesQuery(userID string, filters tradeListFilters, limit, offset int) (string, []interface{}) {
+func buildListTradesQuery(userID string, filters tradeListFilters, order listOrder, limit, offset int) (string, []interface{}) {
 	query := `
 		SELECT ` + tradeListColumns + `
 		FROM trades
 		WHERE user_id = $1`
 	args := []interface{}{userID}
 	query, args = appendTradeListFilters(query, args, filters)
-	query += " ORDER BY date DESC"
+	query, args = order.appendTo(query, args)
 
 	if limit > 0 {
 		argN := len(args) + 1
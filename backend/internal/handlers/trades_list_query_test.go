@@ -9,7 +9,7 @@ import (
 func TestBuildListTradesQuery_NoFilters(t *testing.T) {
 	t.Parallel()
 
-	query, args := buildListTradesQuery("user-1", tradeListFilters{}, 0, 0)
+	query, args := buildListTradesQuery("user-1", tradeListFilters{}, listOrder{}, 0, 0)
 	if len(args) != 1 || args[0] != "user-1" {
 		t.Fatalf("args = %v, want [user-1]", args)
 	}
@@ -34,7 +34,7 @@ func TestBuildListTradesQuery_AllFilters(t *testing.T) {
 		ticker:    "AAPL",
 	}
 
-	query, args := buildListTradesQuery("user-1", filters, 50, 0)
+	query, args := buildListTradesQuery("user-1", filters, listOrder{}, 50, 0)
 	if len(args) != 8 {
 		t.Fatalf("len(args) = %d, want 8", len(args))
 	}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/middleware"
+	"fintu-tracking-backend/internal/models"
+	"fintu-tracking-backend/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+var webhookService = services.NewWebhookService(nil)
+
+// InitWebhookService sets the package-level webhook service used by handlers.
+// It is called once from main.go with the service whose dispatcher sends the
+// queued deliveries.
+func InitWebhookService(svc *services.WebhookService) {
+	webhookService = svc
+}
+
+// ListWebhooks handles GET /api/webhooks.
+func ListWebhooks(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	endpoints, err := webhookService.ListEndpoints(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	return respondList(c, endpoints)
+}
+
+// GetWebhook handles GET /api/webhooks/:id.
+func GetWebhook(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	endpoint, err := webhookService.GetEndpoint(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(endpoint)
+}
+
+// CreateWebhook handles POST /api/webhooks. The response is the only one that
+// includes the signing secret.
+func CreateWebhook(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.CreateWebhookEndpointRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperror.New(apperror.Validation, "Invalid request body")
+	}
+
+	endpoint, err := webhookService.CreateEndpoint(c.Context(), userID, req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(endpoint)
+}
+
+// UpdateWebhook handles PATCH /api/webhooks/:id, including pausing an
+// endpoint with "active": false.
+func UpdateWebhook(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.UpdateWebhookEndpointRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperror.New(apperror.Validation, "Invalid request body")
+	}
+
+	endpoint, err := webhookService.UpdateEndpoint(c.Context(), userID, c.Params("id"), req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(endpoint)
+}
+
+// DeleteWebhook handles DELETE /api/webhooks/:id.
+func DeleteWebhook(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := webhookService.DeleteEndpoint(c.Context(), userID, c.Params("id")); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ListWebhookDeliveries handles GET /api/webhooks/:id/deliveries, the
+// endpoint's delivery log newest first. ?limit= caps the count.
+func ListWebhookDeliveries(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	limit := config.DefaultWebhookDeliveriesLimit
+	if apiVersion(c) >= APIv2 {
+		// v2 pages through the most recent deliveries; ?limit= is the page size.
+		limit = config.MaxWebhookDeliveriesLimit
+	} else if raw := c.Query("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > config.MaxWebhookDeliveriesLimit {
+			return apperror.Newf(apperror.Validation, "limit must be between 1 and %d", config.MaxWebhookDeliveriesLimit)
+		}
+	}
+
+	deliveries, err := webhookService.ListDeliveries(c.Context(), userID, c.Params("id"), limit)
+	if err != nil {
+		return err
+	}
+	return respondList(c, deliveries)
+}
+
+// PingWebhook handles POST /api/webhooks/:id/ping, sending a signed "ping"
+// event right away and returning its delivery.
+func PingWebhook(c fiber.Ctx) error {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	delivery, err := webhookService.Ping(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(delivery)
+}
+
+// publishWebhookEvent queues an event for the user's webhook endpoints. It
+// runs after the change is saved, so failures are logged rather than returned.
+func publishWebhookEvent(userID, eventType string, data any) {
+	if err := webhookService.Publish(context.Background(), userID, eventType, data); err != nil {
+		slog.Error("webhook publish failed", "user_id", userID, "event", eventType, "error", err)
+	}
+}
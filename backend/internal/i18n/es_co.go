@@ -0,0 +1,311 @@
+package i18n
+
+// esCO is the Colombian Spanish catalog. Keys are the English messages and
+// format strings exactly as the server writes them; translations keep the
+// same verbs, which may be reordered with explicit indexes (%[2]s).
+var esCO = map[string]string{
+	// Activity feed summaries.
+	"buy %s %s @ %s":      "compra de %s %s a %s",
+	"sell %s %s @ %s":     "venta de %s %s a %s",
+	"Deposit: %s":         "Depósito: %s",
+	"Withdrawal: %s":      "Retiro: %s",
+	"Cash adjustment: %s": "Ajuste de efectivo: %s",
+	"Fee (%s): %s":        "Comisión (%s): %s",
+	"Transfer in: %s":     "Transferencia recibida: %s",
+	"Transfer out: %s":    "Transferencia enviada: %s",
+	"deposit":             "depósito",
+	"withdrawal":          "retiro",
+	"trading":             "negociación",
+	"closing":             "cierre",
+	"maintenance":         "mantenimiento",
+	"other":               "otra",
+	"trade":               "operación",
+	"cash_adjustment":     "ajuste de efectivo",
+	"fee":                 "comisión",
+	"transfer_in":         "transferencia recibida",
+	"transfer_out":        "transferencia enviada",
+	"Trade fee (%s) doesn't match cash flow fees (%s)": "La comisión de la operación (%s) no coincide con las comisiones registradas en el flujo de caja (%s)",
+
+	// Notifications.
+	"Trade fees and cash flows do not reconcile": "Las comisiones de las operaciones no cuadran con el flujo de caja",
+	"%d trades without fee cash flows, %d orphaned and %d unlinked fee cash flows, %d fee discrepancies (difference %s).": "%d operaciones sin comisión en el flujo de caja, %d comisiones huérfanas y %d sin vincular, %d diferencias de comisión (diferencia de %s).",
+	"Subscription cancelled": "Suscripción cancelada",
+	"Your subscription ends at the end of the current billing period.": "Su suscripción termina al final del periodo de facturación actual.",
+	"Your subscription has ended.":                                     "Su suscripción terminó.",
+	"Your subscription ends on %s.":                                    "Su suscripción termina el %s.",
+	"%s failed for %d tickers":                                         "%s falló para %d tickers",
+	"Price refresh":                                                    "La actualización de precios",
+	"Daily price backfill":                                             "La carga de precios diarios",
+
+	// Alert and exposure messages.
+	"%s crossed above %s USD (now %s)":                                    "%s cruzó por encima de %s USD (ahora %s)",
+	"%s crossed below %s USD (now %s)":                                    "%s cruzó por debajo de %s USD (ahora %s)",
+	"%s unrealized P/L is %s%%, above %s%%":                               "La ganancia/pérdida no realizada de %s es %s%%, por encima de %s%%",
+	"%s unrealized P/L is %s%%, below %s%%":                               "La ganancia/pérdida no realizada de %s es %s%%, por debajo de %s%%",
+	"USD/COP moved %s%% from %s to %s":                                    "USD/COP se movió %s%% de %s a %s",
+	"USD/COP moved %s%% to %s":                                            "USD/COP se movió %s%% a %s",
+	"Portfolio drawdown reached %s%% (limit %s%%)":                        "El drawdown del portafolio llegó a %s%% (límite %s%%)",
+	"%s is %s%% of your holdings, above the %s%% concentration threshold": "%s es el %s%% de sus posiciones, por encima del umbral de concentración de %s%%",
+
+	// Authentication, plans, tokens and rate limits.
+	"Unauthorized":                                                 "No autorizado",
+	"Missing authorization header":                                 "Falta el encabezado de autorización",
+	"Invalid authorization header format":                          "Formato de encabezado de autorización inválido",
+	"Invalid or expired token":                                     "Token inválido o vencido",
+	"Invalid token claims":                                         "Datos del token inválidos",
+	"Invalid metrics token":                                        "Token de métricas inválido",
+	"invalid, expired or revoked API token":                        "token de API inválido, vencido o revocado",
+	"API tokens are not enabled":                                   "Los tokens de API no están habilitados",
+	"API tokens cannot access this endpoint":                       "Los tokens de API no pueden acceder a este endpoint",
+	"API token is missing the %s scope":                            "Al token de API le falta el alcance %s",
+	"API token not found":                                          "Token de API no encontrado",
+	"Active subscription required":                                 "Se requiere una suscripción activa",
+	"Rate limit exceeded, retry in %d seconds":                     "Límite de solicitudes superado, intente de nuevo en %d segundos",
+	"Internal server error":                                        "Error interno del servidor",
+	"Idempotency-Key is too long":                                  "El Idempotency-Key es demasiado largo",
+	"Idempotency-Key was already used with a different request":    "El Idempotency-Key ya se usó con otra solicitud",
+	"A request with this Idempotency-Key is still being processed": "Una solicitud con este Idempotency-Key todavía se está procesando",
+
+	// Request validation.
+	"Request body is required":                           "El cuerpo de la solicitud es obligatorio",
+	"Invalid request body":                               "Cuerpo de la solicitud inválido",
+	"No fields to update":                                "No hay campos para actualizar",
+	"%s is required":                                     "%s es obligatorio",
+	"%s must be an integer":                              "%s debe ser un número entero",
+	"%s must be a number":                                "%s debe ser un número",
+	"%s must be true or false":                           "%s debe ser true o false",
+	"%q is not a decimal number":                         "%q no es un número decimal",
+	"invalid cursor":                                     "cursor inválido",
+	"cursor was issued for another sort":                 "el cursor se emitió para otro orden",
+	"sort must be one of %s, optionally prefixed with -": "sort debe ser uno de %s, opcionalmente con el prefijo -",
+	"limit must be between 1 and %d":                     "limit debe estar entre 1 y %d",
+	"simulations must be between 1 and %d":               "simulations debe estar entre 1 y %d",
+	"invalid page":                                       "page inválido",
+	"invalid page_size":                                  "page_size inválido",
+	"invalid page or page_size":                          "page o page_size inválido",
+	"invalid from date":                                  "fecha from inválida",
+	"invalid to date":                                    "fecha to inválida",
+	"from must be YYYY-MM-DD":                            "from debe tener el formato AAAA-MM-DD",
+	"to must be YYYY-MM-DD":                              "to debe tener el formato AAAA-MM-DD",
+	"invalid kind %q":                                    "tipo %q inválido",
+	"invalid side":                                       "lado inválido",
+	"invalid asset_type":                                 "asset_type inválido",
+	"invalid type":                                       "tipo inválido",
+	"invalid currency":                                   "moneda inválida",
+	"invalid exclude_mirrored":                           "exclude_mirrored inválido",
+	"invalid date":                                       "fecha inválida",
+	"invalid date: %q":                                   "fecha inválida: %q",
+	"invalid decimal":                                    "número decimal inválido",
+	"invalid risk_free_rate":                             "risk_free_rate inválido",
+	"invalid confidence":                                 "confidence inválido",
+	"invalid concentration threshold":                    "umbral de concentración inválido",
+	"invalid concentration_threshold":                    "concentration_threshold inválido",
+	"invalid broker_id":                                  "broker_id inválido",
+	"group_by must be 'broker'":                          "group_by debe ser 'broker'",
+	"group_by must be 'ticker'":                          "group_by debe ser 'ticker'",
+	"allow_sells must be true or false":                  "allow_sells debe ser true o false",
+	"unread must be true or false":                       "unread debe ser true o false",
+	"status must be planned, confirmed or skipped":       "status debe ser planned, confirmed o skipped",
+	"Ticker is required":                                 "El ticker es obligatorio",
+	"Ticker parameter is required":                       "El parámetro ticker es obligatorio",
+
+	// Trades and cash flows.
+	"Invalid quantity format":                       "Formato de cantidad inválido",
+	"Invalid price format":                          "Formato de precio inválido",
+	"Invalid amount format":                         "Formato de monto inválido",
+	"Invalid FX rate format":                        "Formato de tasa de cambio inválido",
+	"Invalid rate format":                           "Formato de tasa inválido",
+	"Invalid date format":                           "Formato de fecha inválido",
+	"Invalid side":                                  "Lado inválido",
+	"Invalid asset type":                            "Tipo de activo inválido",
+	"Invalid type":                                  "Tipo inválido",
+	"Invalid currency":                              "Moneda inválida",
+	"invalid fee format":                            "formato de comisión inválido",
+	"invalid deposit_fee format":                    "formato de deposit_fee inválido",
+	"invalid trading_fee format":                    "formato de trading_fee inválido",
+	"invalid closing_fee format":                    "formato de closing_fee inválido",
+	"fee cannot be negative":                        "la comisión no puede ser negativa",
+	"insufficient holdings: have %s %s, selling %s": "posición insuficiente: tiene %s %s y está vendiendo %s",
+	"Opening position must use buy side":            "La posición de apertura debe ser una compra",
+	"Opening position cannot include fees":          "La posición de apertura no puede incluir comisiones",
+	"Notes are required for opening positions":      "Las notas son obligatorias para las posiciones de apertura",
+	"Notes are required for cash adjustments":       "Las notas son obligatorias para los ajustes de efectivo",
+	"Deposits and withdrawals must use %s":          "Los depósitos y retiros deben estar en %s",
+	"Cash adjustments must use %s":                  "Los ajustes de efectivo deben estar en %s",
+	"FX rate required for %s":                       "Se requiere la tasa de cambio para %s",
+	"FX rate required for %s transactions":          "Se requiere la tasa de cambio para las transacciones en %s",
+	"FX rate must be non-zero":                      "La tasa de cambio no puede ser cero",
+	"Standalone fees are not supported; fees must be linked to a deposit, withdrawal, or trade": "No se admiten comisiones sueltas; las comisiones deben estar vinculadas a un depósito, un retiro o una operación",
+	"Broker fees can only be generated for deposits and withdrawals":                            "Las comisiones del bróker solo se pueden generar para depósitos y retiros",
+	"broker_id is required to generate the broker fee":                                          "broker_id es obligatorio para generar la comisión del bróker",
+	"Portfolio transfers cannot be edited; delete and recreate the transfer":                    "Las transferencias entre portafolios no se pueden editar; elimine la transferencia y créela de nuevo",
+	"transfer must move a positive amount between two different portfolios":                     "la transferencia debe mover un monto positivo entre dos portafolios distintos",
+	"unsupported currency pair: only %s are supported":                                          "par de monedas no admitido: solo se admiten %s",
+	"invalid base rate %q, cannot compute inverse":                                              "tasa base %q inválida, no se puede calcular la inversa",
+	"a COP/USD rate is required to project COP amounts; record an FX rate first":                "se requiere una tasa COP/USD para proyectar montos en COP; registre primero una tasa de cambio",
+
+	// Not found and conflicts.
+	"Trade not found":                                 "Operación no encontrada",
+	"Cash flow not found":                             "Flujo de caja no encontrado",
+	"FX rate not found":                               "Tasa de cambio no encontrada",
+	"Market price not found":                          "Precio de mercado no encontrado",
+	"DCA plan not found":                              "Plan de DCA no encontrado",
+	"DCA installment not found":                       "Cuota de DCA no encontrada",
+	"DCA installment is not pending":                  "La cuota de DCA no está pendiente",
+	"No subscription found":                           "No se encontró una suscripción",
+	"Unknown broker preset":                           "Preset de bróker desconocido",
+	"Unknown preset":                                  "Preset desconocido",
+	"alert rule not found":                            "regla de alerta no encontrada",
+	"benchmark not found":                             "benchmark no encontrado",
+	"broker not found":                                "bróker no encontrado",
+	"fee schedule not found":                          "tarifario no encontrado",
+	"goal not found":                                  "meta no encontrada",
+	"notification not found":                          "notificación no encontrada",
+	"portfolio not found":                             "portafolio no encontrado",
+	"subscription not found":                          "suscripción no encontrada",
+	"transfer not found":                              "transferencia no encontrada",
+	"webhook endpoint not found":                      "endpoint de webhook no encontrado",
+	"portfolio has no target allocation":              "el portafolio no tiene una asignación objetivo",
+	"broker has trades or cash flows":                 "el bróker tiene operaciones o flujos de caja",
+	"portfolio still has trades or cash flows":        "el portafolio todavía tiene operaciones o flujos de caja",
+	"the default portfolio cannot be deleted":         "el portafolio predeterminado no se puede eliminar",
+	"mark another portfolio as default instead":       "marque otro portafolio como predeterminado",
+	"a broker must keep at least one fee schedule":    "un bróker debe conservar al menos un tarifario",
+	"a DCA plan with that name already exists":        "ya existe un plan de DCA con ese nombre",
+	"a benchmark with that name already exists":       "ya existe un benchmark con ese nombre",
+	"a goal with that name already exists":            "ya existe una meta con ese nombre",
+	"a portfolio with that name already exists":       "ya existe un portafolio con ese nombre",
+	"a webhook endpoint with that url already exists": "ya existe un endpoint de webhook con esa url",
+	"an alert rule with that name already exists":     "ya existe una regla de alerta con ese nombre",
+	"at most %d active API tokens are allowed":        "se permiten como máximo %d tokens de API activos",
+	"at most %d webhook endpoints are allowed":        "se permiten como máximo %d endpoints de webhook",
+	"at most %d alert rules are allowed":              "se permiten como máximo %d reglas de alerta",
+
+	// Market data.
+	"market data provider is not configured: TWELVE_DATA_API_KEY is not set": "el proveedor de datos de mercado no está configurado: falta TWELVE_DATA_API_KEY",
+	"market data provider is unavailable":                                    "el proveedor de datos de mercado no está disponible",
+	"market data provider rate limit reached":                                "se alcanzó el límite de solicitudes del proveedor de datos de mercado",
+	"market data provider rejected the request":                              "el proveedor de datos de mercado rechazó la solicitud",
+
+	// Profile and onboarding.
+	"country and broker_preset_id are required":           "country y broker_preset_id son obligatorios",
+	"locale must be one of %s":                            "locale debe ser uno de %s",
+	"preset_id is required (or name for a custom broker)": "preset_id es obligatorio (o name para un bróker personalizado)",
+	"portfolio name is required":                          "el nombre del portafolio es obligatorio",
+	"subscription id is required":                         "el id de la suscripción es obligatorio",
+
+	// Service validation errors, written "<cause>: <detail>".
+	"invalid API token request":                                       "solicitud de token de API inválida",
+	"invalid DCA confirmation":                                        "confirmación de DCA inválida",
+	"invalid DCA plan":                                                "plan de DCA inválido",
+	"invalid DCA report period":                                       "periodo del reporte de DCA inválido",
+	"invalid alert rule":                                              "regla de alerta inválida",
+	"invalid benchmark":                                               "benchmark inválido",
+	"invalid broker":                                                  "bróker inválido",
+	"invalid fee schedule":                                            "tarifario inválido",
+	"invalid goal":                                                    "meta inválida",
+	"invalid risk options":                                            "opciones de riesgo inválidas",
+	"invalid subscription":                                            "suscripción inválida",
+	"invalid target allocation":                                       "asignación objetivo inválida",
+	"invalid webhook endpoint":                                        "endpoint de webhook inválido",
+	"name is required":                                                "el nombre es obligatorio",
+	"name cannot be empty":                                            "el nombre no puede estar vacío",
+	"name must be at most %d characters":                              "el nombre debe tener como máximo %d caracteres",
+	"name is required (at most %d characters)":                        "el nombre es obligatorio (máximo %d caracteres)",
+	"description must be at most %d characters":                       "la descripción debe tener como máximo %d caracteres",
+	"%s appears more than once":                                       "%s aparece más de una vez",
+	"%s must be YYYY-MM-DD":                                           "%s debe tener el formato AAAA-MM-DD",
+	"%q is not planned for this installment":                          "%q no está planeado para esta cuota",
+	"at least one scope is required":                                  "se requiere al menos un alcance",
+	"at least one channel is required":                                "se requiere al menos un canal",
+	"at least one event is required":                                  "se requiere al menos un evento",
+	"at least one fill is required":                                   "se requiere al menos una ejecución",
+	"unknown scope %q (supported: %s)":                                "alcance %q desconocido (admitidos: %s)",
+	"unknown event %q (supported: %s)":                                "evento %q desconocido (admitidos: %s)",
+	"expires_in_days must be between 1 and %d":                        "expires_in_days debe estar entre 1 y %d",
+	"at most %d benchmarks per request":                               "como máximo %d benchmarks por solicitud",
+	"a benchmark needs between 1 and %d components":                   "un benchmark necesita entre 1 y %d componentes",
+	"component ticker is required":                                    "el ticker del componente es obligatorio",
+	"weight for %s must be a positive number":                         "el peso de %s debe ser un número positivo",
+	"weights must sum to 1 (got %s)":                                  "los pesos deben sumar 1 (suman %s)",
+	"risk_free_rate must be a decimal fraction between -1 and 1":      "risk_free_rate debe ser una fracción decimal entre -1 y 1",
+	"confidence must be between 0.5 and 1":                            "confidence debe estar entre 0.5 y 1",
+	"basis must be ticker or asset_type":                              "basis debe ser ticker o asset_type",
+	"between 1 and %d targets are required":                           "se requieren entre 1 y %d objetivos",
+	"target key is required":                                          "la clave del objetivo es obligatoria",
+	"target ticker is required":                                       "el ticker del objetivo es obligatorio",
+	"asset type must be one of %s":                                    "el tipo de activo debe ser uno de %s",
+	"asset_type for %s must be one of %s":                             "asset_type de %s debe ser uno de %s",
+	"drift_band must be a fraction between 0 and 1":                   "drift_band debe ser una fracción entre 0 y 1",
+	"fees can only be quoted for deposits and withdrawals":            "las comisiones solo se pueden cotizar para depósitos y retiros",
+	"currency must be %s or %s":                                       "la moneda debe ser %s o %s",
+	"frequency must be one of %s":                                     "la frecuencia debe ser una de %s",
+	"start_date is required":                                          "start_date es obligatorio",
+	"end_date must be on or after start_date":                         "end_date debe ser igual o posterior a start_date",
+	"date must be YYYY-MM-DD":                                         "date debe tener el formato AAAA-MM-DD",
+	"amount must be a positive number":                                "el monto debe ser un número positivo",
+	"fx_rate is required for %s plans":                                "fx_rate es obligatorio para los planes en %s",
+	"fx_rate must be a positive number":                               "fx_rate debe ser un número positivo",
+	"fx_rate only applies to %s plans":                                "fx_rate solo aplica a los planes en %s",
+	"it is already %s":                                                "ya está en estado %s",
+	"from must be on or before to":                                    "from debe ser igual o anterior a to",
+	"effective_from must be YYYY-MM-DD":                               "effective_from debe tener el formato AAAA-MM-DD",
+	"commissions: unknown asset type %q":                              "commissions: tipo de activo %q desconocido",
+	"%s: %s must be a non-negative number":                            "%s: %s debe ser un número no negativo",
+	"%s: tiers are required":                                          "%s: los tramos son obligatorios",
+	"%s: only the last tier may omit up_to":                           "%s: solo el último tramo puede omitir up_to",
+	"%s: tier up_to values must be increasing":                        "%s: los valores up_to de los tramos deben ser crecientes",
+	"%s: unsupported fee type %q":                                     "%s: tipo de comisión %q no admitido",
+	"%s: min must not exceed max":                                     "%s: min no puede ser mayor que max",
+	"must be a percentage between 0 and 100":                          "debe ser un porcentaje entre 0 y 100",
+	"fx_spread must be a fraction between 0 and 1":                    "fx_spread debe ser una fracción entre 0 y 1",
+	"monthly_contribution must be zero or positive":                   "monthly_contribution debe ser cero o positivo",
+	"target_amount must be a positive number":                         "target_amount debe ser un número positivo",
+	"target_date must be YYYY-MM-DD":                                  "target_date debe tener el formato AAAA-MM-DD",
+	"target_date must be in the future":                               "target_date debe ser una fecha futura",
+	"target_date must be within %d years":                             "target_date debe estar dentro de los próximos %d años",
+	"price for %s must be a positive number":                          "el precio de %s debe ser un número positivo",
+	"quantity for %s must be a positive number":                       "la cantidad de %s debe ser un número positivo",
+	"trading_fee for %s must be zero or positive":                     "trading_fee de %s debe ser cero o positivo",
+	"kind must be one of %s":                                          "kind debe ser uno de %s",
+	"direction must be one of %s":                                     "direction debe ser uno de %s",
+	"direction must be above or below":                                "direction debe ser above o below",
+	"channels must be among %s":                                       "channels debe estar entre %s",
+	"threshold must be a number":                                      "threshold debe ser un número",
+	"threshold must be positive":                                      "threshold debe ser positivo",
+	"ticker is required for %s rules":                                 "el ticker es obligatorio para las reglas %s",
+	"drawdown rules only fire above the threshold":                    "las reglas de drawdown solo se activan por encima del umbral",
+	"drawdown threshold must be between 0 and 100 (percent)":          "el umbral de drawdown debe estar entre 0 y 100 (por ciento)",
+	"webhook_url must be the url of one of your webhooks":             "webhook_url debe ser la url de uno de sus webhooks",
+	"webhook_url must point to a public address":                      "webhook_url debe apuntar a una dirección pública",
+	"webhook_url must be an http(s) URL":                              "webhook_url debe ser una URL http(s)",
+	"url must be an http(s) URL":                                      "url debe ser una URL http(s)",
+	"url must point to a public address":                              "url debe apuntar a una dirección pública",
+	"email is not a valid address":                                    "email no es una dirección válida",
+	"billing_provider is required":                                    "billing_provider es obligatorio",
+	"billing provider %q is not supported in Milestone 1":             "el proveedor de facturación %q no se admite en el Milestone 1",
+	"paid plans cannot be activated with the manual billing provider": "los planes pagos no se pueden activar con el proveedor de facturación manual",
+	"plan %q does not exist":                                          "el plan %q no existe",
+	"plan_id is required":                                             "plan_id es obligatorio",
+
+	// JSON Schema validation, as worded by github.com/santhosh-tekuri/jsonschema.
+	"missing property %s":                  "falta la propiedad %s",
+	"missing properties %s":                "faltan las propiedades %s",
+	"additional properties %s not allowed": "no se permiten las propiedades adicionales %s",
+	"got %s, want %s":                      "se recibió %s, se esperaba %s",
+	"value must be %s":                     "el valor debe ser %s",
+	"value must be one of %s":              "el valor debe ser uno de %s",
+	"%s is not valid %s: %v":               "%s no es un %s válido: %v",
+	"minimum: got %v, want %v":             "mínimo: se recibió %v, se esperaba %v",
+	"maximum: got %v, want %v":             "máximo: se recibió %v, se esperaba %v",
+	"exclusiveMinimum: got %v, want %v":    "mínimo exclusivo: se recibió %v, se esperaba %v",
+	"exclusiveMaximum: got %v, want %v":    "máximo exclusivo: se recibió %v, se esperaba %v",
+	"minLength: got %d, want %d":           "longitud mínima: se recibió %d, se esperaba %d",
+	"maxLength: got %d, want %d":           "longitud máxima: se recibió %d, se esperaba %d",
+	"minItems: got %d, want %d":            "mínimo de elementos: se recibió %d, se esperaba %d",
+	"maxItems: got %d, want %d":            "máximo de elementos: se recibió %d, se esperaba %d",
+	"%s does not match pattern %s":         "%s no coincide con el patrón %s",
+	"'oneOf' failed, none matched":         "'oneOf' falló, ninguno coincidió",
+	"'anyOf' failed":                       "'anyOf' falló",
+	"validation failed":                    "la validación falló",
+}
@@ -0,0 +1,100 @@
+package i18n
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// separators are the thousands and decimal separators of each locale.
+var separators = []struct{ group, point string }{
+	English:         {",", "."},
+	SpanishColombia: {".", ","},
+}
+
+// currencySymbols replace the currency code in Money. Currencies without a
+// symbol in a locale are written as "COP 1,000".
+var currencySymbols = []map[string]string{
+	English:         {"USD": "$"},
+	SpanishColombia: {"COP": "$", "USD": "US$"},
+}
+
+// currencyPlaces is the number of decimals Money shows for currencies that do
+// not use two. Peso amounts are shown in whole pesos.
+var currencyPlaces = map[string]int32{"COP": 0}
+
+// Number formats value with all of its significant decimals and the locale's
+// separators: 1234.5 is "1,234.5" in English and "1.234,5" in es-CO.
+func (l Locale) Number(value decimal.Decimal) string {
+	return l.group(value.String())
+}
+
+// Decimal formats value rounded to places decimals with the locale's
+// separators: 1234567.891 with 2 places is "1,234,567.89" in English and
+// "1.234.567,89" in es-CO.
+func (l Locale) Decimal(value decimal.Decimal, places int32) string {
+	return l.group(value.StringFixed(places))
+}
+
+// Money formats an amount of currency for display, for example "$1,234.50"
+// and "COP 1,000,000" in English, and "US$1.234,50" and "$1.000.000" in
+// es-CO.
+func (l Locale) Money(currency string, amount decimal.Decimal) string {
+	places, ok := currencyPlaces[currency]
+	if !ok {
+		places = 2
+	}
+	amount = amount.Round(places)
+	sign := ""
+	if amount.IsNegative() {
+		sign = "-"
+	}
+	number := l.Decimal(amount.Abs(), places)
+	if symbol, ok := currencySymbols[l.valid()][currency]; ok {
+		return sign + symbol + number
+	}
+	return sign + currency + " " + number
+}
+
+// MoneyString is Money for an amount held as a decimal string, as amounts
+// are throughout the API. Text that is not a number is returned unchanged.
+func (l Locale) MoneyString(currency, amount string) string {
+	d, err := decimal.NewFromString(strings.TrimSpace(amount))
+	if err != nil {
+		return amount
+	}
+	return l.Money(currency, d)
+}
+
+// group rewrites a plain decimal string ("-1234567.89") with the locale's
+// separators.
+func (l Locale) group(s string) string {
+	sep := separators[l.valid()]
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, frac, hasFrac := strings.Cut(s, ".")
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(sep.group)
+		}
+		b.WriteRune(digit)
+	}
+	if hasFrac {
+		b.WriteString(sep.point)
+		b.WriteString(frac)
+	}
+	return b.String()
+}
+
+// valid returns l, or Default when l is not a supported locale.
+func (l Locale) valid() Locale {
+	if l < 0 || int(l) >= len(tags) {
+		return Default
+	}
+	return l
+}
@@ -0,0 +1,81 @@
+// Package i18n localizes the text the server writes for people: error
+// messages, activity summaries, reconciliation descriptions and
+// notifications. Message keys are the English text, so English needs no
+// catalog and untranslated text falls back to English. The request's Locale
+// travels in context.Context, set by the locale middleware from the user's
+// profile or the Accept-Language header.
+package i18n
+
+import (
+	"context"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Locale is a supported locale. The zero value is English.
+type Locale int
+
+const (
+	English Locale = iota
+	SpanishColombia
+)
+
+// Default is used when neither the profile nor Accept-Language names a
+// supported locale.
+const Default = English
+
+// tags are the language tags of the locales, indexed by Locale.
+var tags = []language.Tag{
+	English:         language.English,
+	SpanishColombia: language.MustParse("es-CO"),
+}
+
+var matcher = language.NewMatcher(tags)
+
+// Locales returns every supported locale, Default first.
+func Locales() []Locale {
+	return []Locale{English, SpanishColombia}
+}
+
+// Tag returns the locale's language tag.
+func (l Locale) Tag() language.Tag {
+	return tags[l.valid()]
+}
+
+// String returns the locale's BCP 47 code, "en" or "es-CO".
+func (l Locale) String() string {
+	return l.Tag().String()
+}
+
+// Parse returns the supported locale with the given code, ignoring case.
+func Parse(code string) (Locale, bool) {
+	for _, l := range Locales() {
+		if strings.EqualFold(code, l.String()) {
+			return l, true
+		}
+	}
+	return Default, false
+}
+
+// Match returns the supported locale closest to an Accept-Language header,
+// so "es", "es-MX" and "es-419" all pick es-CO. Anything else is Default.
+func Match(acceptLanguage string) Locale {
+	_, index := language.MatchStrings(matcher, acceptLanguage)
+	return Locale(index)
+}
+
+type localeKey struct{}
+
+// WithLocale returns a copy of ctx carrying the locale.
+func WithLocale(ctx context.Context, l Locale) context.Context {
+	return context.WithValue(ctx, localeKey{}, l)
+}
+
+// FromContext returns the locale carried by ctx, or Default.
+func FromContext(ctx context.Context) Locale {
+	if l, ok := ctx.Value(localeKey{}).(Locale); ok {
+		return l
+	}
+	return Default
+}
@@ -0,0 +1,290 @@
+package i18n
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]Locale{
+		"":                          English,
+		"en-US,en;q=0.9":            English,
+		"es-CO":                     SpanishColombia,
+		"es":                        SpanishColombia,
+		"es-MX,es;q=0.9,en;q=0.8":   SpanishColombia,
+		"fr-FR":                     English,
+		"en;q=0.5,es-CO;q=0.9":      SpanishColombia,
+		"not a language header ;;;": English,
+	}
+	for header, want := range tests {
+		if got := Match(header); got != want {
+			t.Errorf("Match(%q) = %s, want %s", header, got, want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	for code, want := range map[string]Locale{"en": English, "es-CO": SpanishColombia, "es-co": SpanishColombia} {
+		if got, ok := Parse(code); !ok || got != want {
+			t.Errorf("Parse(%q) = %s, %v; want %s", code, got, ok, want)
+		}
+	}
+	for _, code := range []string{"", "es", "fr"} {
+		if _, ok := Parse(code); ok {
+			t.Errorf("Parse(%q) accepted an unsupported locale", code)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	t.Parallel()
+
+	if got := FromContext(context.Background()); got != Default {
+		t.Errorf("FromContext(empty) = %s, want %s", got, Default)
+	}
+	ctx := WithLocale(context.Background(), SpanishColombia)
+	if got := FromContext(ctx); got != SpanishColombia {
+		t.Errorf("FromContext = %s, want es-CO", got)
+	}
+}
+
+func TestSprintf(t *testing.T) {
+	t.Parallel()
+
+	if got := English.Sprintf("Deposit: %s", "COP 5"); got != "Deposit: COP 5" {
+		t.Errorf("English = %q", got)
+	}
+	if got := SpanishColombia.Sprintf("Deposit: %s", "$5"); got != "Depósito: $5" {
+		t.Errorf("es-CO = %q", got)
+	}
+	if got := SpanishColombia.Sprintf("Rate limit exceeded, retry in %d seconds", 1500); got != "Límite de solicitudes superado, intente de nuevo en 1.500 segundos" {
+		t.Errorf("es-CO number = %q", got)
+	}
+	if got := SpanishColombia.Sprintf("no translation for %s", "this"); got != "no translation for this" {
+		t.Errorf("fallback = %q", got)
+	}
+	got := SpanishColombia.Sprintf("insufficient holdings: have %s %s, selling %s",
+		decimal.NewFromInt(10), "AAPL", decimal.RequireFromString("1234.5"))
+	if got != "posición insuficiente: tiene 10 AAPL y está vendiendo 1.234,5" {
+		t.Errorf("es-CO decimal = %q", got)
+	}
+	if got := English.Sprintf("weights must sum to 1 (got %s)", decimal.RequireFromString("1.05")); got != "weights must sum to 1 (got 1.05)" {
+		t.Errorf("English decimal = %q", got)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in, want string
+	}{
+		{"Invalid quantity format", "Formato de cantidad inválido"},
+		{"invalid goal: name is required", "meta inválida: el nombre es obligatorio"},
+		{"invalid date: \"2026-13-01\"", "fecha inválida: \"2026-13-01\""},
+		{"Portfolio transfers cannot be edited; delete and recreate the transfer", "Las transferencias entre portafolios no se pueden editar; elimine la transferencia y créela de nuevo"},
+		{"a COP/USD rate is required to project COP amounts; record an FX rate first", "se requiere una tasa COP/USD para proyectar montos en COP; registre primero una tasa de cambio"},
+		{"something new", "something new"},
+		{"prefix: something new", "prefix: something new"},
+	}
+	for _, tc := range tests {
+		if got := SpanishColombia.Translate(tc.in); got != tc.want {
+			t.Errorf("Translate(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+		if got := English.Translate(tc.in); got != tc.in {
+			t.Errorf("English.Translate(%q) = %q", tc.in, got)
+		}
+	}
+}
+
+// TestCatalogVerbs checks that every translation uses the verbs of its key,
+// so formatting and after-the-fact translation keep every argument.
+func TestCatalogVerbs(t *testing.T) {
+	t.Parallel()
+
+	plain := regexp.MustCompile(`%(?:\[\d+\])?`)
+	for l, messages := range catalogs {
+		for key, msg := range messages {
+			keyVerbs := verb.FindAllString(key, -1)
+			msgVerbs := verb.FindAllString(msg, -1)
+			for i, v := range msgVerbs {
+				msgVerbs[i] = plain.ReplaceAllString(v, "%")
+			}
+			slices.Sort(keyVerbs)
+			slices.Sort(msgVerbs)
+			if !slices.Equal(keyVerbs, msgVerbs) {
+				t.Errorf("%s: %q translates to %q with verbs %v, want %v", l, key, msg, msgVerbs, keyVerbs)
+			}
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	n := decimal.RequireFromString("-1234567.891")
+	tests := []struct {
+		got, want string
+	}{
+		{English.Decimal(n, 2), "-1,234,567.89"},
+		{SpanishColombia.Decimal(n, 2), "-1.234.567,89"},
+		{SpanishColombia.Number(decimal.RequireFromString("2.50000")), "2,5"},
+		{English.Number(decimal.RequireFromString("123")), "123"},
+		{English.Money("USD", decimal.RequireFromString("400.5")), "$400.50"},
+		{English.Money("COP", decimal.RequireFromString("1000000")), "COP 1,000,000"},
+		{SpanishColombia.Money("COP", decimal.RequireFromString("1000000.4")), "$1.000.000"},
+		{SpanishColombia.Money("USD", decimal.RequireFromString("-1234.5")), "-US$1.234,50"},
+		{SpanishColombia.Money("EUR", decimal.RequireFromString("10")), "EUR 10,00"},
+		{SpanishColombia.MoneyString("COP", "2500000"), "$2.500.000"},
+		{SpanishColombia.MoneyString("COP", ""), ""},
+	}
+	for i, tc := range tests {
+		if tc.got != tc.want {
+			t.Errorf("case %d = %q, want %q", i, tc.got, tc.want)
+		}
+	}
+}
+
+// TestCatalogCoversErrors checks that every client error message the server
+// builds has a Colombian Spanish entry. Messages must be string literals in
+// the catalog; messages with arguments are built with apperror.Newf or
+// apperror.Detailf so they are formatted in the client's language.
+func TestCatalogCoversErrors(t *testing.T) {
+	t.Parallel()
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	paths := map[*ast.File]string{}
+	for _, dir := range []string{"../handlers", "../middleware", "../openapi", "../services"} {
+		names, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range names {
+			if strings.HasSuffix(name, "_test.go") {
+				continue
+			}
+			f, err := parser.ParseFile(fset, name, nil, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files = append(files, f)
+			paths[f] = dir
+		}
+	}
+
+	// Sentinels are the classified errors declared with apperror.New.
+	sentinels := map[string]bool{}
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			if spec, ok := n.(*ast.ValueSpec); ok {
+				for i, value := range spec.Values {
+					if name := apperrorCall(value); name == "New" || name == "Newf" {
+						sentinels[spec.Names[i].Name] = true
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	check := func(pos token.Pos, expr ast.Expr, formatted bool) {
+		lit, ok := expr.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			if call, ok := expr.(*ast.CallExpr); ok && isSelector(call.Fun, "fmt", "Sprintf") {
+				t.Errorf("%s: message built with fmt.Sprintf; use apperror.Newf", fset.Position(pos))
+			}
+			if _, ok := expr.(*ast.BinaryExpr); ok {
+				t.Errorf("%s: message built by concatenation; use apperror.Newf", fset.Position(pos))
+			}
+			return
+		}
+		msg, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.TrimSpace(verb.ReplaceAllString(msg, "")) == "" {
+			// Only arguments, such as a provider's own error text.
+			return
+		}
+		if hasVerb(msg) && !formatted {
+			t.Errorf("%s: %q has arguments; use apperror.Newf or apperror.Detailf", fset.Position(pos), msg)
+			return
+		}
+		if _, ok := esCO[msg]; !ok {
+			t.Errorf("%s: %q has no es-CO entry", fset.Position(pos), msg)
+		}
+	}
+
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			// Handlers report their parsers' unclassified errors to clients;
+			// the exported route handlers' own ones are internal.
+			fn, ok := decl.(*ast.FuncDecl)
+			parser := paths[f] == "../handlers" && ok && !fn.Name.IsExported()
+			ast.Inspect(decl, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				switch name := apperrorCall(call); {
+				case name == "New" || name == "Wrap":
+					check(call.Pos(), call.Args[1], false)
+				case name == "Newf" || name == "Detailf":
+					check(call.Pos(), call.Args[1], true)
+				case isSelector(call.Fun, "fmt", "Errorf") || isSelector(call.Fun, "errors", "New"):
+					lit, ok := call.Args[0].(*ast.BasicLit)
+					if !ok {
+						return true
+					}
+					format, _ := strconv.Unquote(lit.Value)
+					if detail, ok := strings.CutPrefix(format, "%w: "); ok {
+						// A detail added to a sentinel is part of the client message.
+						if ident, ok := call.Args[1].(*ast.Ident); ok && sentinels[ident.Name] {
+							check(call.Pos(), &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(detail)}, false)
+						}
+					} else if parser && !strings.Contains(format, "%w") {
+						check(call.Pos(), lit, false)
+					}
+				}
+				return true
+			})
+		}
+	}
+}
+
+// apperrorCall returns the name of the apperror function expr calls, or "".
+func apperrorCall(expr ast.Expr) string {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return ""
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || !isSelector(sel, "apperror", sel.Sel.Name) {
+		return ""
+	}
+	return sel.Sel.Name
+}
+
+func isSelector(expr ast.Expr, pkg, name string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != name {
+		return false
+	}
+	ident, ok := sel.X.(*ast.Ident)
+	return ok && ident.Name == pkg
+}
@@ -0,0 +1,106 @@
+package i18n
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+// catalogs holds the translations of each non-English locale, keyed by the
+// English message or format string.
+var catalogs = map[Locale]map[string]string{
+	SpanishColombia: esCO,
+}
+
+var printers = buildPrinters()
+
+func buildPrinters() []*message.Printer {
+	b := catalog.NewBuilder(catalog.Fallback(tags[Default]))
+	for l, messages := range catalogs {
+		for key, msg := range messages {
+			if err := b.SetString(l.Tag(), key, msg); err != nil {
+				panic(fmt.Sprintf("i18n: %s message %q: %v", l, key, err))
+			}
+		}
+	}
+	out := make([]*message.Printer, len(tags))
+	for _, l := range Locales() {
+		out[l] = message.NewPrinter(l.Tag(), message.Catalog(b))
+	}
+	return out
+}
+
+// Printer returns a printer that translates format strings through the
+// locale's catalog and formats numeric arguments the locale's way.
+func (l Locale) Printer() *message.Printer {
+	return printers[l.valid()]
+}
+
+// Sprintf translates format and formats args with it. Numbers, including
+// decimal.Decimal values, are written with the locale's separators.
+func (l Locale) Sprintf(format string, args ...any) string {
+	// args may be an error's stored arguments, so they are not changed.
+	localized := make([]any, len(args))
+	for i, arg := range args {
+		if d, ok := arg.(decimal.Decimal); ok {
+			arg = l.Number(d)
+		}
+		localized[i] = arg
+	}
+	return l.Printer().Sprintf(format, localized...)
+}
+
+// verb matches a fmt verb, optionally with an explicit argument index, or an
+// escaped percent sign ("%%"), which is not a verb.
+var verb = regexp.MustCompile(`%(?:\[(\d+)\])?[-+# 0]*\d*(?:\.\d+)?[a-zA-Z]|%%`)
+
+// hasVerb reports whether s is a format string with at least one verb.
+func hasVerb(s string) bool {
+	for _, v := range verb.FindAllString(s, -1) {
+		if v != "%%" {
+			return true
+		}
+	}
+	return false
+}
+
+// Translate translates a message that has no arguments, such as a sentinel
+// error's text. Messages that are not in the catalog as a whole but are
+// joined with "; " are translated one by one, and a wrapped "cause: detail"
+// message is translated part by part. Messages with arguments are formatted
+// with Sprintf where they are built instead, so their numbers are written
+// the locale's way. Text with no translation is returned unchanged.
+func (l Locale) Translate(text string) string {
+	messages, ok := catalogs[l]
+	if !ok {
+		return text
+	}
+	// Some messages contain "; " themselves.
+	if msg, ok := messages[text]; ok && !hasVerb(text) {
+		return msg
+	}
+	parts := strings.Split(text, "; ")
+	for i, part := range parts {
+		parts[i] = l.translate(part)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (l Locale) translate(text string) string {
+	messages := catalogs[l]
+	if msg, ok := messages[text]; ok && !hasVerb(text) {
+		return msg
+	}
+	prefix, rest, wrapped := strings.Cut(text, ": ")
+	if !wrapped {
+		return text
+	}
+	if msg, ok := messages[prefix]; ok && !hasVerb(prefix) {
+		prefix = msg
+	}
+	return prefix + ": " + l.translate(rest)
+}
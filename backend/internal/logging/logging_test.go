@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestNewAddsRequestID(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := New(&buf, "json", "info")
+
+	ctx := WithRequestID(context.Background(), "req-123")
+	logger.InfoContext(ctx, "hello", "user_id", "user-1")
+	logger.DebugContext(ctx, "hidden")
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("decode log line %q: %v", buf.String(), err)
+	}
+	if record["msg"] != "hello" {
+		t.Errorf("msg = %v, want hello", record["msg"])
+	}
+	if record["request_id"] != "req-123" {
+		t.Errorf("request_id = %v, want req-123", record["request_id"])
+	}
+	if record["user_id"] != "user-1" {
+		t.Errorf("user_id = %v, want user-1", record["user_id"])
+	}
+	if bytes.Count(buf.Bytes(), []byte("\n")) != 1 {
+		t.Errorf("debug record written at info level: %s", buf.String())
+	}
+}
+
+func TestNewWithoutRequestID(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	New(&buf, "json", "debug").With("component", "test").Info("hello")
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("decode log line %q: %v", buf.String(), err)
+	}
+	if _, ok := record["request_id"]; ok {
+		t.Errorf("request_id set without a request context: %v", record)
+	}
+	if record["component"] != "test" {
+		t.Errorf("component = %v, want test", record["component"])
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"":        "INFO",
+		"debug":   "DEBUG",
+		"WARN":    "WARN",
+		"warning": "WARN",
+		"error":   "ERROR",
+		"verbose": "INFO",
+	}
+	for in, want := range tests {
+		if got := parseLevel(in).String(); got != want {
+			t.Errorf("parseLevel(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// APITokenVerifier resolves a personal API token to its owner and scopes.
+// services.APITokenService implements it.
+type APITokenVerifier interface {
+	VerifyAPIToken(ctx context.Context, token string) (userID string, scopes []string, err error)
+}
+
+// authenticateAPIToken sets the request user from a personal API token.
+func authenticateAPIToken(c fiber.Ctx, tokens APITokenVerifier, token string) error {
+	if tokens == nil {
+		return apperror.New(apperror.Unauthorized, "API tokens are not enabled")
+	}
+	userID, scopes, err := tokens.VerifyAPIToken(c.Context(), token)
+	if err != nil {
+		return err
+	}
+	c.Locals("user_id", userID)
+	c.Locals("token_scopes", scopes)
+	return c.Next()
+}
+
+// GetTokenScopes returns the scopes of the API token that authenticated the
+// request. ok is false for browser sessions, which are not scoped.
+func GetTokenScopes(c fiber.Ctx) (scopes []string, ok bool) {
+	scopes, ok = c.Locals("token_scopes").([]string)
+	return scopes, ok
+}
+
+// RequireTokenScopes returns a middleware that checks API token requests
+// against the scope rules; session requests pass through. It must run after
+// AuthMiddleware.
+func RequireTokenScopes(rules []config.APITokenScopeRule) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		scopes, ok := GetTokenScopes(c)
+		if !ok {
+			return c.Next()
+		}
+		required := requiredTokenScope(rules, c.Method(), c.Path())
+		if required == "" {
+			return apperror.New(apperror.Forbidden, "API tokens cannot access this endpoint")
+		}
+		if !slices.Contains(scopes, required) {
+			body := errorBody(c, apperror.Forbidden, "API token is missing the %s scope", required)
+			body["required_scope"] = required
+			return c.Status(fiber.StatusForbidden).JSON(body)
+		}
+		return c.Next()
+	}
+}
+
+// requiredTokenScope returns the scope of the longest rule prefix matching
+// path, or "" when API tokens may not call it.
+func requiredTokenScope(rules []config.APITokenScopeRule, method, path string) string {
+	var match *config.APITokenScopeRule
+	for i, rule := range rules {
+		if !hasRoutePrefix(path, rule.Prefix) {
+			continue
+		}
+		if match == nil || len(rule.Prefix) > len(match.Prefix) {
+			match = &rules[i]
+		}
+	}
+	if match == nil {
+		return ""
+	}
+	if method == http.MethodGet || method == http.MethodHead {
+		return match.Read
+	}
+	return match.Write
+}
+
+// hasRoutePrefix reports whether path is prefix or below it, matching whole
+// path segments so /api/portfolio does not match /api/portfolios. Rules name
+// /api paths; /api/v2 paths match them as if unversioned.
+func hasRoutePrefix(path, prefix string) bool {
+	path = strings.TrimSuffix(path, "/")
+	if rest, ok := strings.CutPrefix(path, "/api/v2"); ok && (rest == "" || rest[0] == '/') {
+		path = "/api" + rest
+	}
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
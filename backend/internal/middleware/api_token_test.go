@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type stubAPITokenVerifier map[string][]string
+
+func (s stubAPITokenVerifier) VerifyAPIToken(_ context.Context, token string) (string, []string, error) {
+	if token == config.APITokenPrefix+"broken" {
+		return "", nil, errors.New("database is down")
+	}
+	scopes, ok := s[token]
+	if !ok {
+		return "", nil, services.ErrInvalidAPIToken
+	}
+	return "user-1", scopes, nil
+}
+
+func newAPITokenTestApp(tokens APITokenVerifier) *fiber.App {
+	app := newTestApp()
+	app.Use(AuthMiddleware(AuthConfig{}, tokens), RequireTokenScopes(config.APITokenScopeRules))
+	handler := func(c fiber.Ctx) error {
+		return c.SendString(GetUserID(c))
+	}
+	app.Get("/api/trades", handler)
+	app.Post("/api/trades", handler)
+	app.Post("/api/market-prices/refresh", handler)
+	app.Get("/api/tokens", handler)
+	return app
+}
+
+func TestAuthMiddleware_APITokens(t *testing.T) {
+	t.Parallel()
+
+	tokens := stubAPITokenVerifier{
+		config.APITokenPrefix + "reader": {config.ScopeReadPortfolio},
+		config.APITokenPrefix + "prices": {config.ScopeWriteMarketData},
+	}
+	app := newAPITokenTestApp(tokens)
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		status int
+	}{
+		{"read scope", http.MethodGet, "/api/trades", "reader", http.StatusOK},
+		{"missing write scope", http.MethodPost, "/api/trades", "reader", http.StatusForbidden},
+		{"write scope", http.MethodPost, "/api/market-prices/refresh", "prices", http.StatusOK},
+		{"read not implied", http.MethodGet, "/api/trades", "prices", http.StatusForbidden},
+		{"token management closed", http.MethodGet, "/api/tokens", "reader", http.StatusForbidden},
+		{"unknown token", http.MethodGet, "/api/trades", "revoked", http.StatusUnauthorized},
+		{"verifier error", http.MethodGet, "/api/trades", "broken", http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("Authorization", "Bearer "+config.APITokenPrefix+tc.token)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s: status = %d, want %d", tc.name, resp.StatusCode, tc.status)
+		}
+	}
+}
+
+func TestAuthMiddleware_APITokensDisabled(t *testing.T) {
+	t.Parallel()
+
+	app := newAPITokenTestApp(nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/trades", nil)
+	req.Header.Set("Authorization", "Bearer "+config.APITokenPrefix+"reader")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestRequireTokenScopes_SkipsSessions(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp()
+	app.Use(withUser("user-1"), RequireTokenScopes(config.APITokenScopeRules))
+	app.Get("/api/tokens", func(c fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/tokens", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestRequiredTokenScope(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{http.MethodGet, "/api/portfolio/holdings", config.ScopeReadPortfolio},
+		{http.MethodPost, "/api/portfolios", config.ScopeWritePortfolio},
+		{http.MethodPost, "/api/portfolios/transfers", config.ScopeWriteCashFlows},
+		{http.MethodDelete, "/api/portfolios/transfers/t-1", config.ScopeWriteCashFlows},
+		{http.MethodPost, "/api/cash-flows/", config.ScopeWriteCashFlows},
+		{http.MethodPost, "/api/cash-flows/fee-quote", config.ScopeReadPortfolio},
+		{http.MethodHead, "/api/me", config.ScopeReadAccount},
+		{http.MethodPatch, "/api/me/profile", ""},
+		{http.MethodGet, "/api/metrics", ""},
+		{http.MethodGet, "/api/trades-export", ""},
+		{http.MethodPost, "/api/tokens", ""},
+		{http.MethodGet, "/api/v2/holdings/by-broker", config.ScopeReadPortfolio},
+		{http.MethodPost, "/api/v2/trades", config.ScopeWriteTrades},
+		{http.MethodGet, "/api/v2/broker-presets", config.ScopeReadPortfolio},
+		{http.MethodPost, "/api/v2/tokens", ""},
+		{http.MethodGet, "/api/v2x/trades", ""},
+	}
+	for _, tc := range cases {
+		if got := requiredTokenScope(config.APITokenScopeRules, tc.method, tc.path); got != tc.want {
+			t.Errorf("requiredTokenScope(%s %s) = %q, want %q", tc.method, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestAPITokenScopeRules_UseKnownScopes(t *testing.T) {
+	t.Parallel()
+
+	for _, rule := range config.APITokenScopeRules {
+		for _, scope := range []string{rule.Read, rule.Write} {
+			if scope != "" && !slices.Contains(config.APITokenScopes, scope) {
+				t.Errorf("rule %s uses unknown scope %q", rule.Prefix, scope)
+			}
+		}
+	}
+}
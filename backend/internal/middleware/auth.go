@@ -1,179 +1,125 @@
 package middleware
 
 import (
-	"crypto/ecdsa"
-	"crypto/elliptic"
-	"encoding/base64"
-	"encoding/json"
 	"fmt"
-	"io"
-	"math/big"
-	"net/http"
-	"os"
 	"strings"
 	"time"
 
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+
 	"github.com/gofiber/fiber/v3"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// publicKeyCache holds all keys from the JWKS indexed by kid
-var publicKeyCache map[string]*ecdsa.PublicKey
-var publicKeyCacheTime time.Time
-
-// JWK represents a JSON Web Key
-type JWK struct {
-	Kid string `json:"kid"`
-	Kty string `json:"kty"`
-	Crv string `json:"crv"`
-	X   string `json:"x"`
-	Y   string `json:"y"`
-	Alg string `json:"alg"`
-	Use string `json:"use"`
-}
-
-// JWKS represents a set of JSON Web Keys
-type JWKS struct {
-	Keys []JWK `json:"keys"`
+// AuthConfig configures session JWT validation.
+type AuthConfig struct {
+	// Keys verifies asymmetrically signed tokens (ES256, ES384, RS256, ...).
+	// If nil, those tokens are rejected.
+	Keys *KeySet
+	// HMACSecret verifies HS256 tokens. If empty, those tokens are rejected.
+	HMACSecret string
+	// Audience and Issuer are the required aud and iss claims; empty skips
+	// the check.
+	Audience string
+	Issuer   string
+	// Leeway allows for clock skew when checking exp, nbf and iat.
+	Leeway time.Duration
 }
 
-// getSupabasePublicKey fetches and caches all JWKS keys, returning the one matching kid.
-// If kid is empty, the first key is returned for backwards compatibility.
-func getSupabasePublicKey(kid string) (*ecdsa.PublicKey, error) {
-	// Refresh cache if empty or older than 1 hour
-	if publicKeyCache == nil || time.Since(publicKeyCacheTime) >= time.Hour {
-		if err := refreshJWKSCache(); err != nil {
-			// If refresh fails but we have a stale cache, keep using it
-			if publicKeyCache == nil {
-				return nil, err
-			}
-		}
+// NewAuthConfig builds the Supabase AuthConfig from validated settings. A
+// Supabase URL enables JWKS-verified tokens from <url>/auth/v1.
+func NewAuthConfig(settings config.AuthSettings) AuthConfig {
+	cfg := AuthConfig{
+		HMACSecret: settings.JWTSecret,
+		Audience:   settings.Audience,
+		Issuer:     settings.Issuer,
+		Leeway:     config.JWTClockSkewLeeway,
 	}
-
-	if kid != "" {
-		if key, ok := publicKeyCache[kid]; ok {
-			return key, nil
-		}
-		// kid not found in cache — force a refresh and try once more
-		if err := refreshJWKSCache(); err != nil {
-			return nil, fmt.Errorf("failed to refresh JWKS after unknown kid %q: %w", kid, err)
-		}
-		if key, ok := publicKeyCache[kid]; ok {
-			return key, nil
-		}
-		return nil, fmt.Errorf("no JWKS key found for kid %q", kid)
-	}
-
-	// Fallback: return any key (first inserted)
-	for _, key := range publicKeyCache {
-		return key, nil
+	if settings.SupabaseURL != "" {
+		cfg.Keys = NewKeySet(KeySetConfig{
+			URL: settings.SupabaseURL + "/auth/v1/.well-known/jwks.json",
+			TTL: settings.JWKSCacheTTL,
+		})
 	}
-	return nil, fmt.Errorf("JWKS cache is empty")
+	return cfg
 }
 
-// refreshJWKSCache fetches the JWKS from Supabase and rebuilds the cache.
-func refreshJWKSCache() error {
-	supabaseURL := os.Getenv("SUPABASE_URL")
-	if supabaseURL == "" {
-		return fmt.Errorf("SUPABASE_URL not set")
-	}
-
-	jwksURL := supabaseURL + "/auth/v1/.well-known/jwks.json"
-
-	resp, err := http.Get(jwksURL)
-	if err != nil {
-		return fmt.Errorf("failed to fetch JWKS from %s: %w", jwksURL, err)
+// jwtSigningMethods are the algorithms AuthMiddleware accepts.
+var jwtSigningMethods = []string{"HS256", "ES256", "ES384", "RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}
+
+// newJWTParser returns a parser that enforces the configured algorithms,
+// audience, issuer and leeway.
+func newJWTParser(cfg AuthConfig) *jwt.Parser {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(jwtSigningMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(cfg.Leeway),
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("JWKS endpoint returned status %d", resp.StatusCode)
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
 	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read JWKS response: %w", err)
-	}
-
-	var jwks JWKS
-	if err := json.Unmarshal(body, &jwks); err != nil {
-		return fmt.Errorf("failed to parse JWKS: %w", err)
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
 	}
+	return jwt.NewParser(opts...)
+}
 
-	if len(jwks.Keys) == 0 {
-		return fmt.Errorf("no keys found in JWKS response")
-	}
+// jwtKeyFunc selects the verification key for a token by its algorithm and
+// kid header.
+func jwtKeyFunc(cfg AuthConfig) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			if cfg.HMACSecret == "" {
+				return nil, fmt.Errorf("SUPABASE_JWT_SECRET not set")
+			}
+			return []byte(cfg.HMACSecret), nil
 
-	newCache := make(map[string]*ecdsa.PublicKey, len(jwks.Keys))
-	for _, jwk := range jwks.Keys {
-		xBytes, err := base64.RawURLEncoding.DecodeString(jwk.X)
-		if err != nil {
-			return fmt.Errorf("failed to decode X for kid %q: %w", jwk.Kid, err)
-		}
-		yBytes, err := base64.RawURLEncoding.DecodeString(jwk.Y)
-		if err != nil {
-			return fmt.Errorf("failed to decode Y for kid %q: %w", jwk.Kid, err)
-		}
+		case *jwt.SigningMethodECDSA, *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			if cfg.Keys == nil {
+				return nil, fmt.Errorf("SUPABASE_URL not set")
+			}
+			// Extract kid from token header for correct key selection
+			kid, _ := token.Header["kid"].(string)
+			pubKey, err := cfg.Keys.Key(kid)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get public key: %w", err)
+			}
+			return pubKey, nil
 
-		pubKey := &ecdsa.PublicKey{
-			Curve: elliptic.P256(),
-			X:     new(big.Int).SetBytes(xBytes),
-			Y:     new(big.Int).SetBytes(yBytes),
+		default:
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		newCache[jwk.Kid] = pubKey
 	}
-
-	publicKeyCache = newCache
-	publicKeyCacheTime = time.Now()
-	return nil
 }
 
-// AuthMiddleware validates Supabase JWT tokens (supports both HS256 and ES256)
-func AuthMiddleware() fiber.Handler {
+// AuthMiddleware validates Supabase JWTs (HS256, or EC and RSA keys from the
+// JWKS) and personal API tokens, which are checked with tokens.
+func AuthMiddleware(cfg AuthConfig, tokens APITokenVerifier) fiber.Handler {
+	parser := newJWTParser(cfg)
+	keyFunc := jwtKeyFunc(cfg)
+
 	return func(c fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Missing authorization header",
-			})
+			return apperror.New(apperror.Unauthorized, "Missing authorization header")
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Invalid authorization header format",
-			})
+			return apperror.New(apperror.Unauthorized, "Invalid authorization header format")
 		}
 
 		tokenString := parts[1]
+		if strings.HasPrefix(tokenString, config.APITokenPrefix) {
+			return authenticateAPIToken(c, tokens, tokenString)
+		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			switch token.Method.(type) {
-			case *jwt.SigningMethodHMAC:
-				secret := os.Getenv("SUPABASE_JWT_SECRET")
-				if secret == "" {
-					return nil, fmt.Errorf("SUPABASE_JWT_SECRET not set")
-				}
-				return []byte(secret), nil
-
-			case *jwt.SigningMethodECDSA:
-				// Extract kid from token header for correct key selection
-				kid, _ := token.Header["kid"].(string)
-				pubKey, err := getSupabasePublicKey(kid)
-				if err != nil {
-					return nil, fmt.Errorf("failed to get public key: %w", err)
-				}
-				return pubKey, nil
-
-			default:
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-		})
-
+		token, err := parser.Parse(tokenString, keyFunc)
 		if err != nil || !token.Valid {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": fmt.Sprintf("Invalid or expired token: %v", err),
-			})
+			return apperror.Wrap(apperror.Unauthorized, "Invalid or expired token", err)
 		}
 
 		if claims, ok := token.Claims.(jwt.MapClaims); ok {
@@ -183,9 +129,7 @@ func AuthMiddleware() fiber.Handler {
 			}
 		}
 
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Invalid token claims",
-		})
+		return apperror.New(apperror.Unauthorized, "Invalid token claims")
 	}
 }
 
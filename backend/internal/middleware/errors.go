@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/i18n"
+	"fintu-tracking-backend/internal/logging"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// ErrorHandler is the app's fiber.ErrorHandler. Handlers return errors and
+// it renders them as models.ErrorResponse: apperror kinds and *fiber.Error
+// statuses map to a status code and stable code, and anything else is logged
+// and reported as a generic internal error so database and provider details
+// never reach clients. Messages are translated to the request's locale.
+func ErrorHandler(c fiber.Ctx, err error) error {
+	kind, status := classifyError(err)
+
+	message := ErrorMessage(c, err)
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) && kind != apperror.Internal {
+		message = GetLocale(c).Translate(fiberErr.Message)
+	}
+	if kind == apperror.Internal {
+		slog.ErrorContext(c.Context(), "request failed",
+			"method", c.Method(),
+			"path", c.Path(),
+			"error", err,
+		)
+	}
+
+	return c.Status(status).JSON(models.ErrorResponse{
+		Error:     message,
+		Code:      string(kind),
+		RequestID: logging.RequestID(c.Context()),
+	})
+}
+
+// ErrorMessage returns the client message for err in the request's locale.
+// Messages built with apperror.Newf and apperror.Detailf are formatted again
+// from their format strings; others are looked up in the catalog.
+func ErrorMessage(c fiber.Ctx, err error) string {
+	return localizedMessage(GetLocale(c), err)
+}
+
+func localizedMessage(loc i18n.Locale, err error) string {
+	message := apperror.Message(err)
+	if message != err.Error() {
+		// Internal, or a classified error whose cause stays internal.
+		return loc.Translate(message)
+	}
+	switch e := err.(type) {
+	case *apperror.Detail:
+		return localizedMessage(loc, e.Err) + ": " + loc.Sprintf(e.Format, e.Args...)
+	case *apperror.Error:
+		if e.Format != "" {
+			return loc.Sprintf(e.Format, e.Args...)
+		}
+	default:
+		// fmt.Errorf("%w: detail", cause)
+		if cause := errors.Unwrap(err); cause != nil {
+			if detail, ok := strings.CutPrefix(message, cause.Error()+": "); ok {
+				return localizedMessage(loc, cause) + ": " + loc.Translate(detail)
+			}
+		}
+	}
+	return loc.Translate(message)
+}
+
+// errorBody returns the ErrorResponse fields for responses that need extra
+// keys, such as retry_after on 429s. The message is formatted from format
+// and args in the request's locale.
+func errorBody(c fiber.Ctx, kind apperror.Kind, format string, args ...any) fiber.Map {
+	body := fiber.Map{"error": GetLocale(c).Sprintf(format, args...), "code": kind}
+	if id := logging.RequestID(c.Context()); id != "" {
+		body["request_id"] = id
+	}
+	return body
+}
+
+// classifyError returns the kind and HTTP status for err.
+func classifyError(err error) (apperror.Kind, int) {
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return appErr.Kind, appErr.Kind.Status()
+	}
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return apperror.KindForStatus(fiberErr.Code), fiberErr.Code
+	}
+	return apperror.Internal, fiber.StatusInternalServerError
+}
@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/shopspring/decimal"
+)
+
+// newTestApp returns an app that renders errors like the server does.
+func newTestApp() *fiber.App {
+	return fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+}
+
+func TestErrorHandler(t *testing.T) {
+	t.Parallel()
+
+	errGoalNotFound := apperror.New(apperror.NotFound, "goal not found")
+
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		code    apperror.Kind
+		message string
+	}{
+		{
+			name:    "not found",
+			err:     errGoalNotFound,
+			status:  http.StatusNotFound,
+			code:    apperror.NotFound,
+			message: "goal not found",
+		},
+		{
+			name:    "wrapped validation",
+			err:     fmt.Errorf("%w: name is required", apperror.New(apperror.Validation, "invalid goal")),
+			status:  http.StatusBadRequest,
+			code:    apperror.Validation,
+			message: "invalid goal: name is required",
+		},
+		{
+			name:    "plan limit",
+			err:     apperror.New(apperror.PlanLimit, "Active subscription required"),
+			status:  http.StatusPaymentRequired,
+			code:    apperror.PlanLimit,
+			message: "Active subscription required",
+		},
+		{
+			name:    "upstream hides cause",
+			err:     apperror.Wrap(apperror.UpstreamUnavailable, "market data provider is unavailable", errors.New("dial tcp 10.0.0.1:443: i/o timeout")),
+			status:  http.StatusServiceUnavailable,
+			code:    apperror.UpstreamUnavailable,
+			message: "market data provider is unavailable",
+		},
+		{
+			name:    "fiber error",
+			err:     fiber.NewError(fiber.StatusUnauthorized, "Unauthorized"),
+			status:  http.StatusUnauthorized,
+			code:    apperror.Unauthorized,
+			message: "Unauthorized",
+		},
+		{
+			name:    "database error is not leaked",
+			err:     errors.New(`ERROR: duplicate key value violates unique constraint "goals_pkey" (SQLSTATE 23505)`),
+			status:  http.StatusInternalServerError,
+			code:    apperror.Internal,
+			message: apperror.InternalMessage,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			app := newTestApp()
+			app.Use(RequestLogger())
+			app.Get("/test", func(c fiber.Ctx) error {
+				return tc.err
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set(config.RequestIDHeader, "req-abc")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tc.status)
+			}
+			var body models.ErrorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if body.Code != string(tc.code) {
+				t.Errorf("code = %q, want %q", body.Code, tc.code)
+			}
+			if body.Error != tc.message {
+				t.Errorf("error = %q, want %q", body.Error, tc.message)
+			}
+			if body.RequestID != "req-abc" {
+				t.Errorf("request_id = %q, want req-abc", body.RequestID)
+			}
+		})
+	}
+}
+
+func TestRequestLogger_RequestID(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp()
+	app.Use(RequestLogger())
+	app.Get("/test", func(c fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		echoed bool
+	}{
+		{name: "caller supplied", header: "client-req-1", echoed: true},
+		{name: "missing"},
+		{name: "too long", header: strings.Repeat("a", config.RequestIDMaxLength+1)},
+		{name: "not printable", header: "bad id"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tc.header != "" {
+				req.Header.Set(config.RequestIDHeader, tc.header)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			resp.Body.Close()
+
+			got := resp.Header.Get(config.RequestIDHeader)
+			if tc.echoed && got != tc.header {
+				t.Errorf("request ID = %q, want %q", got, tc.header)
+			}
+			if !tc.echoed && (got == "" || got == tc.header) {
+				t.Errorf("request ID = %q, want a generated ID", got)
+			}
+		})
+	}
+}
+
+func TestErrorHandlerFormatsInLocale(t *testing.T) {
+	t.Parallel()
+
+	errInvalidGoal := apperror.New(apperror.Validation, "invalid goal")
+	tests := []struct {
+		name    string
+		err     error
+		message string
+	}{
+		{
+			name: "formatted message",
+			err: apperror.Newf(apperror.Validation, "insufficient holdings: have %s %s, selling %s",
+				decimal.NewFromInt(10), "AAPL", decimal.RequireFromString("1234.5")),
+			message: "posición insuficiente: tiene 10 AAPL y está vendiendo 1.234,5",
+		},
+		{
+			name:    "formatted detail",
+			err:     apperror.Detailf(errInvalidGoal, "target_date must be within %d years", 1000),
+			message: "meta inválida: target_date debe estar dentro de los próximos 1.000 años",
+		},
+		{
+			name:    "wrapped formatted sentinel",
+			err:     fmt.Errorf("%w: name is required", apperror.Newf(apperror.Conflict, "at most %d alert rules are allowed", 1000)),
+			message: "se permiten como máximo 1.000 reglas de alerta: el nombre es obligatorio",
+		},
+		{
+			name:    "wrapped detail",
+			err:     fmt.Errorf("%w: name is required", errInvalidGoal),
+			message: "meta inválida: el nombre es obligatorio",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			app := newTestApp()
+			app.Use(Locale())
+			app.Get("/test", func(c fiber.Ctx) error {
+				return tc.err
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set(fiber.HeaderAcceptLanguage, "es-CO")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			defer resp.Body.Close()
+
+			var body models.ErrorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if body.Error != tc.message {
+				t.Errorf("error = %q, want %q", body.Error, tc.message)
+			}
+		})
+	}
+}
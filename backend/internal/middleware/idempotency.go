@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// Idempotency returns a middleware that honours the Idempotency-Key header on
+// write endpoints. The first request for a (user, key) pair runs normally and its
+// response is stored; retries with the same body and locale replay that response,
+// and a reused key with a different body or locale is rejected, since the stored
+// body is in the first request's language. Requests without the header are
+// passed through unchanged. It must run after AuthMiddleware.
+func Idempotency(store services.IdempotencyStore) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		key := c.Get(config.IdempotencyKeyHeader)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > config.IdempotencyKeyMaxLength {
+			return apperror.New(apperror.Validation, "Idempotency-Key is too long")
+		}
+
+		userID, err := RequireUserID(c)
+		if err != nil {
+			return err
+		}
+
+		rec := services.IdempotencyRecord{
+			UserID:        userID,
+			Key:           key,
+			RequestMethod: c.Method(),
+			RequestPath:   c.Path(),
+			RequestHash:   idempotencyRequestHash(c.Method(), c.Path(), GetLocale(c).String(), c.Body()),
+			ExpiresAt:     time.Now().Add(config.IdempotencyKeyLease),
+		}
+
+		existing, created, err := store.Reserve(c.Context(), rec)
+		if err != nil {
+			return err
+		}
+
+		if !created {
+			return replayIdempotentResponse(c, rec, existing)
+		}
+
+		if err := c.Next(); err != nil {
+			_ = store.Release(c.Context(), userID, key)
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			// Server failures are not cached so the client can retry them.
+			if err := store.Release(c.Context(), userID, key); err != nil {
+				return err
+			}
+			return nil
+		}
+
+		body := bytes.Clone(c.Response().Body())
+		contentType := string(c.Response().Header.ContentType())
+		expiresAt := time.Now().Add(config.IdempotencyKeyTTL)
+		if err := store.Complete(c.Context(), userID, key, status, contentType, body, expiresAt); err != nil {
+			return err
+		}
+		return nil
+	}
+}
+
+// replayIdempotentResponse answers a request whose key was already reserved.
+func replayIdempotentResponse(c fiber.Ctx, rec, existing services.IdempotencyRecord) error {
+	if existing.RequestHash != rec.RequestHash {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(
+			errorBody(c, apperror.Validation, "Idempotency-Key was already used with a different request"),
+		)
+	}
+	if existing.State != services.IdempotencyStateCompleted {
+		return apperror.New(apperror.Conflict, "A request with this Idempotency-Key is still being processed")
+	}
+
+	c.Set("Idempotent-Replayed", "true")
+	if existing.ContentType != "" {
+		c.Set(fiber.HeaderContentType, existing.ContentType)
+	}
+	return c.Status(existing.StatusCode).Send(existing.ResponseBody)
+}
+
+// idempotencyRequestHash fingerprints a request so a reused key can be matched
+// against the original method, path, locale and body.
+func idempotencyRequestHash(method, path, locale string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write([]byte(locale))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
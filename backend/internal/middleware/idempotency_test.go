@@ -0,0 +1,237 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/i18n"
+	"fintu-tracking-backend/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]services.IdempotencyRecord
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: make(map[string]services.IdempotencyRecord)}
+}
+
+func (s *memoryIdempotencyStore) Reserve(_ context.Context, rec services.IdempotencyRecord) (services.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := rec.UserID + "|" + rec.Key
+	if existing, ok := s.records[id]; ok && existing.ExpiresAt.After(time.Now()) {
+		return existing, false, nil
+	}
+	rec.State = services.IdempotencyStateInProgress
+	s.records[id] = rec
+	return rec, true, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(_ context.Context, userID, key string, statusCode int, contentType string, body []byte, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := userID + "|" + key
+	rec := s.records[id]
+	rec.State = services.IdempotencyStateCompleted
+	rec.StatusCode = statusCode
+	rec.ContentType = contentType
+	rec.ResponseBody = body
+	rec.ExpiresAt = expiresAt
+	s.records[id] = rec
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(_ context.Context, userID, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, userID+"|"+key)
+	return nil
+}
+
+func newIdempotencyTestApp(store services.IdempotencyStore, calls *int, status int) *fiber.App {
+	app := newTestApp()
+	app.Use(withUser("user-1"))
+	app.Post("/trades", Idempotency(store), func(c fiber.Ctx) error {
+		*calls++
+		return c.Status(status).JSON(fiber.Map{"call": *calls})
+	})
+	return app
+}
+
+func postWithKey(t *testing.T, app *fiber.App, key, body string) (*http.Response, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/trades", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return resp, string(raw)
+}
+
+func TestIdempotency_ReplaysStoredResponse(t *testing.T) {
+	calls := 0
+	app := newIdempotencyTestApp(newMemoryIdempotencyStore(), &calls, http.StatusCreated)
+
+	first, firstBody := postWithKey(t, app, "key-1", `{"ticker":"VOO"}`)
+	second, secondBody := postWithKey(t, app, "key-1", `{"ticker":"VOO"}`)
+
+	if calls != 1 {
+		t.Fatalf("handler calls = %d, want 1", calls)
+	}
+	if first.StatusCode != http.StatusCreated || second.StatusCode != http.StatusCreated {
+		t.Fatalf("status = %d/%d, want 201/201", first.StatusCode, second.StatusCode)
+	}
+	if firstBody != secondBody {
+		t.Fatalf("replayed body = %q, want %q", secondBody, firstBody)
+	}
+	if second.Header.Get("Idempotent-Replayed") != "true" {
+		t.Fatal("expected Idempotent-Replayed header on retry")
+	}
+}
+
+func TestIdempotency_RejectsDifferentBody(t *testing.T) {
+	calls := 0
+	app := newIdempotencyTestApp(newMemoryIdempotencyStore(), &calls, http.StatusCreated)
+
+	postWithKey(t, app, "key-1", `{"ticker":"VOO"}`)
+	resp, _ := postWithKey(t, app, "key-1", `{"ticker":"QQQ"}`)
+
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusUnprocessableEntity)
+	}
+	if calls != 1 {
+		t.Fatalf("handler calls = %d, want 1", calls)
+	}
+}
+
+func TestIdempotency_RejectsDifferentLocale(t *testing.T) {
+	calls := 0
+	app := newTestApp()
+	app.Use(Locale(), withUser("user-1"))
+	app.Post("/trades", Idempotency(newMemoryIdempotencyStore()), func(c fiber.Ctx) error {
+		calls++
+		return c.Status(http.StatusCreated).JSON(fiber.Map{"call": calls})
+	})
+
+	post := func(lang string) int {
+		req := httptest.NewRequest(http.MethodPost, "/trades", strings.NewReader(`{"ticker":"VOO"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "key-1")
+		req.Header.Set("Accept-Language", lang)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := post("en"); status != http.StatusCreated {
+		t.Fatalf("first status = %d, want 201", status)
+	}
+	if status := post("es-CO"); status != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d", status, http.StatusUnprocessableEntity)
+	}
+	if calls != 1 {
+		t.Fatalf("handler calls = %d, want 1", calls)
+	}
+}
+
+func TestIdempotency_InProgressConflict(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	hash := idempotencyRequestHash(http.MethodPost, "/trades", i18n.Default.String(), []byte(`{}`))
+	store.records["user-1|key-1"] = services.IdempotencyRecord{
+		UserID: "user-1", Key: "key-1", RequestHash: hash, State: services.IdempotencyStateInProgress,
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+	calls := 0
+	app := newIdempotencyTestApp(store, &calls, http.StatusCreated)
+
+	resp, _ := postWithKey(t, app, "key-1", `{}`)
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusConflict)
+	}
+}
+
+func TestIdempotency_AbandonedReservationExpires(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	hash := idempotencyRequestHash(http.MethodPost, "/trades", i18n.Default.String(), []byte(`{}`))
+	store.records["user-1|key-1"] = services.IdempotencyRecord{
+		UserID: "user-1", Key: "key-1", RequestHash: hash, State: services.IdempotencyStateInProgress,
+		ExpiresAt: time.Now().Add(-time.Second),
+	}
+	calls := 0
+	app := newIdempotencyTestApp(store, &calls, http.StatusCreated)
+
+	resp, _ := postWithKey(t, app, "key-1", `{}`)
+	if resp.StatusCode != http.StatusCreated || calls != 1 {
+		t.Fatalf("status = %d, calls = %d; want 201 and 1", resp.StatusCode, calls)
+	}
+}
+
+func TestIdempotency_LeaseThenTTL(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	var leased time.Time
+	app := newTestApp()
+	app.Use(withUser("user-1"))
+	app.Post("/trades", Idempotency(store), func(c fiber.Ctx) error {
+		store.mu.Lock()
+		leased = store.records["user-1|key-1"].ExpiresAt
+		store.mu.Unlock()
+		return c.SendStatus(http.StatusCreated)
+	})
+
+	start := time.Now()
+	postWithKey(t, app, "key-1", `{}`)
+
+	if leased.After(start.Add(config.IdempotencyKeyLease + time.Minute)) {
+		t.Errorf("in-progress reservation expires at %v, want within the %v lease", leased, config.IdempotencyKeyLease)
+	}
+	if completed := store.records["user-1|key-1"].ExpiresAt; completed.Before(start.Add(config.IdempotencyKeyTTL)) {
+		t.Errorf("completed response expires at %v, want after the %v TTL", completed, config.IdempotencyKeyTTL)
+	}
+}
+
+func TestIdempotency_ServerErrorsAreNotStored(t *testing.T) {
+	calls := 0
+	app := newIdempotencyTestApp(newMemoryIdempotencyStore(), &calls, http.StatusInternalServerError)
+
+	postWithKey(t, app, "key-1", `{}`)
+	postWithKey(t, app, "key-1", `{}`)
+
+	if calls != 2 {
+		t.Fatalf("handler calls = %d, want 2", calls)
+	}
+}
+
+func TestIdempotency_NoHeaderPassesThrough(t *testing.T) {
+	calls := 0
+	app := newIdempotencyTestApp(newMemoryIdempotencyStore(), &calls, http.StatusCreated)
+
+	postWithKey(t, app, "", `{}`)
+	postWithKey(t, app, "", `{}`)
+
+	if calls != 2 {
+		t.Fatalf("handler calls = %d, want 2", calls)
+	}
+}
@@ -0,0 +1,290 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"fintu-tracking-backend/internal/config"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// minRSAKeyBits rejects RSA keys too short to trust.
+const minRSAKeyBits = 2048
+
+// JWK represents a JSON Web Key. EC keys use Crv, X and Y; RSA keys use N and E.
+type JWK struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+}
+
+// JWKS represents a set of JSON Web Keys
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// KeySetConfig configures a KeySet. Zero durations and a nil client fall back
+// to the config package defaults.
+type KeySetConfig struct {
+	URL                string
+	TTL                time.Duration
+	MinRefreshInterval time.Duration
+	HTTPClient         *http.Client
+}
+
+// KeySet caches the public keys of a JWKS endpoint by key ID. It is safe for
+// concurrent use: concurrent refreshes share one fetch, a key ID missing from
+// the cache triggers a refresh so signing key rotation is picked up, and if a
+// refresh fails the previous keys stay in use.
+type KeySet struct {
+	url        string
+	ttl        time.Duration
+	minRefresh time.Duration
+	client     *http.Client
+	now        func() time.Time
+	group      singleflight.Group
+
+	mu          sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	firstKid    string
+	fetchedAt   time.Time
+	lastAttempt time.Time
+	lastErr     error
+}
+
+// NewKeySet creates a KeySet for the JWKS at cfg.URL. Keys are fetched on
+// first use.
+func NewKeySet(cfg KeySetConfig) *KeySet {
+	ks := &KeySet{
+		url:        cfg.URL,
+		ttl:        cfg.TTL,
+		minRefresh: cfg.MinRefreshInterval,
+		client:     cfg.HTTPClient,
+		now:        time.Now,
+	}
+	if ks.ttl <= 0 {
+		ks.ttl = config.JWKSCacheTTL
+	}
+	if ks.minRefresh <= 0 {
+		ks.minRefresh = config.JWKSMinRefreshInterval
+	}
+	if ks.client == nil {
+		ks.client = &http.Client{Timeout: config.JWKSFetchTimeout}
+	}
+	return ks
+}
+
+// Key returns the public key for kid: an *ecdsa.PublicKey or *rsa.PublicKey.
+// If kid is empty, the first key in the JWKS is returned for backwards
+// compatibility.
+func (ks *KeySet) Key(kid string) (crypto.PublicKey, error) {
+	key, found, fresh := ks.lookup(kid)
+	if found && fresh {
+		return key, nil
+	}
+	// Refresh when the cache is stale or the kid is unknown, which is how a
+	// rotated signing key shows up. A failed refresh keeps the old keys.
+	if err := ks.refreshIfAllowed(); err != nil && !found {
+		return nil, err
+	}
+	if key, found, _ = ks.lookup(kid); found {
+		return key, nil
+	}
+	if kid == "" {
+		return nil, errors.New("JWKS has no keys")
+	}
+	return nil, fmt.Errorf("no JWKS key found for kid %q", kid)
+}
+
+// Refresh fetches the JWKS now. Concurrent callers share a single request.
+func (ks *KeySet) Refresh() error {
+	_, err, _ := ks.group.Do("refresh", func() (any, error) {
+		return nil, ks.fetch()
+	})
+	return err
+}
+
+// lookup reads kid from the cache and reports whether the cache is fresh.
+func (ks *KeySet) lookup(kid string) (key crypto.PublicKey, found, fresh bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if kid == "" {
+		kid = ks.firstKid
+	}
+	key, found = ks.keys[kid]
+	fresh = ks.keys != nil && ks.now().Sub(ks.fetchedAt) < ks.ttl
+	return key, found, fresh
+}
+
+// refreshIfAllowed refreshes unless the last attempt was under minRefresh
+// ago, in which case it returns that attempt's error.
+func (ks *KeySet) refreshIfAllowed() error {
+	ks.mu.RLock()
+	throttled := !ks.lastAttempt.IsZero() && ks.now().Sub(ks.lastAttempt) < ks.minRefresh
+	lastErr := ks.lastErr
+	ks.mu.RUnlock()
+	if throttled {
+		return lastErr
+	}
+	return ks.Refresh()
+}
+
+// fetch downloads and parses the JWKS and replaces the cached keys.
+func (ks *KeySet) fetch() error {
+	keys, firstKid, err := ks.download()
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.lastAttempt = ks.now()
+	ks.lastErr = err
+	if err != nil {
+		return err
+	}
+	ks.keys = keys
+	ks.firstKid = firstKid
+	ks.fetchedAt = ks.lastAttempt
+	return nil
+}
+
+func (ks *KeySet) download() (map[string]crypto.PublicKey, string, error) {
+	if ks.url == "" {
+		return nil, "", errors.New("JWKS URL not configured (set SUPABASE_URL)")
+	}
+
+	resp, err := ks.client.Get(ks.url)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch JWKS from %s: %w", ks.url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("JWKS endpoint returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read JWKS response: %w", err)
+	}
+
+	var jwks JWKS
+	if err := json.Unmarshal(body, &jwks); err != nil {
+		return nil, "", fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+	var firstKid string
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := parseJWK(jwk)
+		if err != nil {
+			// One unusable key (an unsupported type, say) should not take
+			// the others down with it.
+			slog.Warn("auth: skipping JWKS key", "kid", jwk.Kid, "error", err)
+			continue
+		}
+		if len(keys) == 0 {
+			firstKid = jwk.Kid
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, "", errors.New("no usable keys found in JWKS response")
+	}
+	return keys, firstKid, nil
+}
+
+// parseJWK converts an EC (P-256, P-384) or RSA JWK to a public key.
+func parseJWK(jwk JWK) (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported EC curve %q", jwk.Crv)
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		x, err := decodeJWKField(jwk.X, "x")
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeJWKField(jwk.Y, "y")
+		if err != nil {
+			return nil, err
+		}
+		if len(x) > size || len(y) > size {
+			return nil, fmt.Errorf("EC coordinates too long for %s", jwk.Crv)
+		}
+		point := make([]byte, 1+2*size)
+		point[0] = 4 // uncompressed
+		copy(point[1+size-len(x):1+size], x)
+		copy(point[1+2*size-len(y):], y)
+		key, err := ecdsa.ParseUncompressedPublicKey(curve, point)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC key: %w", err)
+		}
+		return key, nil
+
+	case "RSA":
+		n, err := decodeJWKField(jwk.N, "n")
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeJWKField(jwk.E, "e")
+		if err != nil {
+			return nil, err
+		}
+		if len(e) > 4 {
+			return nil, errors.New("RSA exponent too large")
+		}
+		key := &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+		if key.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key shorter than %d bits", minRSAKeyBits)
+		}
+		if key.E < 3 || key.E%2 == 0 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return key, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+}
+
+func decodeJWKField(value, name string) ([]byte, error) {
+	if value == "" {
+		return nil, fmt.Errorf("missing %q", name)
+	}
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %q: %w", name, err)
+	}
+	return b, nil
+}
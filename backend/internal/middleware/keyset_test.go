@@ -0,0 +1,372 @@
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testIssuer = "https://project.supabase.co/auth/v1"
+
+// jwksServer serves a mutable JWKS and counts fetches.
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	keys    []JWK
+	status  int
+	delay   time.Duration
+	fetches atomic.Int32
+}
+
+func newJWKSServer(t *testing.T, keys ...JWK) *jwksServer {
+	t.Helper()
+	s := &jwksServer{keys: keys, status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		s.mu.Lock()
+		keys, status, delay := s.keys, s.status, s.delay
+		s.mu.Unlock()
+		time.Sleep(delay)
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(JWKS{Keys: keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) set(status int, keys ...JWK) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+	if keys != nil {
+		s.keys = keys
+	}
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func ecJWK(t *testing.T, kid string, curve elliptic.Curve) (*ecdsa.PrivateKey, JWK) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey: %v", err)
+	}
+	size := (curve.Params().BitSize + 7) / 8
+	return key, JWK{
+		Kid: kid, Kty: "EC", Crv: curve.Params().Name, Use: "sig",
+		X: b64(key.X.FillBytes(make([]byte, size))),
+		Y: b64(key.Y.FillBytes(make([]byte, size))),
+	}
+}
+
+func rsaJWK(t *testing.T, kid string) (*rsa.PrivateKey, JWK) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey: %v", err)
+	}
+	return key, JWK{
+		Kid: kid, Kty: "RSA", Alg: "RS256",
+		N: b64(key.N.Bytes()),
+		E: b64(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	return signed
+}
+
+func validClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"sub": "user-1",
+		"aud": "authenticated",
+		"iss": testIssuer,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+}
+
+func authStatus(t *testing.T, cfg AuthConfig, token string) int {
+	t.Helper()
+	app := newTestApp()
+	app.Use(AuthMiddleware(cfg, nil))
+	app.Get("/", func(c fiber.Ctx) error {
+		return c.SendString(GetUserID(c))
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestAuthMiddleware_KeyTypes(t *testing.T) {
+	t.Parallel()
+
+	p256, p256JWK := ecJWK(t, "ec-256", elliptic.P256())
+	p384, p384JWK := ecJWK(t, "ec-384", elliptic.P384())
+	rsaKey, rsaKeyJWK := rsaJWK(t, "rsa")
+	server := newJWKSServer(t, p256JWK, p384JWK, rsaKeyJWK)
+	cfg := AuthConfig{
+		Keys:       NewKeySet(KeySetConfig{URL: server.URL}),
+		HMACSecret: "hs-secret",
+		Audience:   "authenticated",
+		Issuer:     testIssuer,
+		Leeway:     30 * time.Second,
+	}
+
+	cases := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"ES256", signToken(t, jwt.SigningMethodES256, "ec-256", p256, validClaims()), http.StatusOK},
+		{"ES384", signToken(t, jwt.SigningMethodES384, "ec-384", p384, validClaims()), http.StatusOK},
+		{"RS256", signToken(t, jwt.SigningMethodRS256, "rsa", rsaKey, validClaims()), http.StatusOK},
+		{"PS256", signToken(t, jwt.SigningMethodPS256, "rsa", rsaKey, validClaims()), http.StatusOK},
+		{"HS256", signToken(t, jwt.SigningMethodHS256, "", []byte("hs-secret"), validClaims()), http.StatusOK},
+		{"no kid uses first key", signToken(t, jwt.SigningMethodES256, "", p256, validClaims()), http.StatusOK},
+		{"wrong curve for kid", signToken(t, jwt.SigningMethodES384, "ec-256", p384, validClaims()), http.StatusUnauthorized},
+		{"RSA token with EC kid", signToken(t, jwt.SigningMethodRS256, "ec-256", rsaKey, validClaims()), http.StatusUnauthorized},
+		{"HS256 wrong secret", signToken(t, jwt.SigningMethodHS256, "", []byte("other"), validClaims()), http.StatusUnauthorized},
+		{"none algorithm", signToken(t, jwt.SigningMethodNone, "", jwt.UnsafeAllowNoneSignatureType, validClaims()), http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		if got := authStatus(t, cfg, tc.token); got != tc.want {
+			t.Errorf("%s: status = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestAuthMiddleware_Claims(t *testing.T) {
+	t.Parallel()
+
+	key, jwk := ecJWK(t, "k1", elliptic.P256())
+	server := newJWKSServer(t, jwk)
+	cfg := AuthConfig{
+		Keys:     NewKeySet(KeySetConfig{URL: server.URL}),
+		Audience: "authenticated",
+		Issuer:   testIssuer,
+		Leeway:   30 * time.Second,
+	}
+	with := func(changes jwt.MapClaims) string {
+		claims := validClaims()
+		for k, v := range changes {
+			if v == nil {
+				delete(claims, k)
+				continue
+			}
+			claims[k] = v
+		}
+		return signToken(t, jwt.SigningMethodES256, "k1", key, claims)
+	}
+	now := time.Now()
+
+	cases := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"wrong audience", with(jwt.MapClaims{"aud": "anon"}), http.StatusUnauthorized},
+		{"audience list", with(jwt.MapClaims{"aud": []string{"other", "authenticated"}}), http.StatusOK},
+		{"wrong issuer", with(jwt.MapClaims{"iss": "https://evil.example.com/auth/v1"}), http.StatusUnauthorized},
+		{"missing exp", with(jwt.MapClaims{"exp": nil}), http.StatusUnauthorized},
+		{"expired within leeway", with(jwt.MapClaims{"exp": now.Add(-10 * time.Second).Unix()}), http.StatusOK},
+		{"expired past leeway", with(jwt.MapClaims{"exp": now.Add(-time.Minute).Unix()}), http.StatusUnauthorized},
+		{"issued slightly in the future", with(jwt.MapClaims{"iat": now.Add(10 * time.Second).Unix()}), http.StatusOK},
+		{"not valid yet", with(jwt.MapClaims{"nbf": now.Add(time.Minute).Unix()}), http.StatusUnauthorized},
+		{"missing sub", with(jwt.MapClaims{"sub": nil}), http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		if got := authStatus(t, cfg, tc.token); got != tc.want {
+			t.Errorf("%s: status = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestKeySet_RotationAndTTL(t *testing.T) {
+	t.Parallel()
+
+	_, first := ecJWK(t, "first", elliptic.P256())
+	_, second := ecJWK(t, "second", elliptic.P256())
+	server := newJWKSServer(t, first)
+	ks := NewKeySet(KeySetConfig{URL: server.URL, TTL: time.Hour, MinRefreshInterval: time.Minute})
+	now := time.Now()
+	ks.now = func() time.Time { return now }
+
+	if _, err := ks.Key("first"); err != nil {
+		t.Fatalf("Key(first): %v", err)
+	}
+	if _, err := ks.Key("first"); err != nil || server.fetches.Load() != 1 {
+		t.Fatalf("cached Key(first) err = %v, fetches = %d", err, server.fetches.Load())
+	}
+
+	// A rotated key is fetched once the refresh throttle allows it.
+	server.set(http.StatusOK, first, second)
+	if _, err := ks.Key("second"); err == nil {
+		t.Error("Key(second) inside the refresh throttle succeeded")
+	}
+	now = now.Add(time.Minute)
+	if _, err := ks.Key("second"); err != nil {
+		t.Fatalf("Key(second) after rotation: %v", err)
+	}
+	if got := server.fetches.Load(); got != 2 {
+		t.Errorf("fetches = %d, want 2", got)
+	}
+
+	// Once the TTL passes, keys are fetched again; a failed fetch keeps the
+	// old keys in use.
+	now = now.Add(time.Hour)
+	server.set(http.StatusServiceUnavailable)
+	if _, err := ks.Key("first"); err != nil {
+		t.Errorf("Key(first) with JWKS down: %v", err)
+	}
+	if got := server.fetches.Load(); got != 3 {
+		t.Errorf("fetches = %d, want 3", got)
+	}
+	if _, err := ks.Key("first"); err != nil || server.fetches.Load() != 3 {
+		t.Errorf("Key(first) retried inside the throttle: err = %v, fetches = %d", err, server.fetches.Load())
+	}
+}
+
+func TestKeySet_ColdStartFailure(t *testing.T) {
+	t.Parallel()
+
+	server := newJWKSServer(t)
+	server.set(http.StatusInternalServerError)
+	ks := NewKeySet(KeySetConfig{URL: server.URL})
+	if _, err := ks.Key("k1"); err == nil {
+		t.Error("Key with no JWKS succeeded")
+	}
+	if _, err := NewKeySet(KeySetConfig{}).Key("k1"); err == nil {
+		t.Error("Key with no URL succeeded")
+	}
+}
+
+func TestKeySet_ConcurrentRefreshSharesFetch(t *testing.T) {
+	t.Parallel()
+
+	key, jwk := ecJWK(t, "k1", elliptic.P256())
+	server := newJWKSServer(t, jwk)
+	server.delay = 50 * time.Millisecond
+	cfg := AuthConfig{Keys: NewKeySet(KeySetConfig{URL: server.URL})}
+	token := signToken(t, jwt.SigningMethodES256, "k1", key, jwt.MapClaims{
+		"sub": "user-1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+
+	app := newTestApp()
+	app.Use(AuthMiddleware(cfg, nil))
+	app.Get("/", func(c fiber.Ctx) error {
+		return c.SendString(GetUserID(c))
+	})
+
+	const requests = 50
+	var wg sync.WaitGroup
+	errs := make(chan string, requests)
+	for range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			resp, err := app.Test(req)
+			if err != nil {
+				errs <- err.Error()
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				errs <- http.StatusText(resp.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("request failed: %s", err)
+	}
+	if got := server.fetches.Load(); got != 1 {
+		t.Errorf("fetches = %d, want 1", got)
+	}
+}
+
+func TestParseJWK(t *testing.T) {
+	t.Parallel()
+
+	_, valid := ecJWK(t, "k1", elliptic.P256())
+	offCurve := valid
+	offCurve.Y = valid.X
+	unsupported := valid
+	unsupported.Crv = "P-521"
+	_, rsaValid := rsaJWK(t, "r1")
+	shortRSA := rsaValid
+	shortRSA.N = b64(big.NewInt(1).Lsh(big.NewInt(1), 1023).Bytes())
+
+	if _, err := parseJWK(valid); err != nil {
+		t.Errorf("parseJWK(valid EC): %v", err)
+	}
+	if _, err := parseJWK(rsaValid); err != nil {
+		t.Errorf("parseJWK(valid RSA): %v", err)
+	}
+	for name, jwk := range map[string]JWK{
+		"point off curve": offCurve,
+		"unsupported crv": unsupported,
+		"short RSA":       shortRSA,
+		"missing x":       {Kty: "EC", Crv: "P-256", Y: valid.Y},
+		"oct key":         {Kty: "oct"},
+	} {
+		if _, err := parseJWK(jwk); err == nil {
+			t.Errorf("parseJWK(%s) succeeded", name)
+		}
+	}
+}
+
+func TestKeySet_SkipsUnusableKeys(t *testing.T) {
+	t.Parallel()
+
+	_, good := ecJWK(t, "good", elliptic.P256())
+	server := newJWKSServer(t, JWK{Kid: "oct", Kty: "oct"}, JWK{Kid: "enc", Kty: "RSA", Use: "enc"}, good)
+	ks := NewKeySet(KeySetConfig{URL: server.URL})
+
+	if _, err := ks.Key("good"); err != nil {
+		t.Fatalf("Key(good): %v", err)
+	}
+	if _, err := ks.Key(""); err != nil {
+		t.Errorf("Key(\"\"): %v", err)
+	}
+	if _, err := ks.Key("enc"); err == nil {
+		t.Error("Key(enc) returned an encryption key")
+	}
+}
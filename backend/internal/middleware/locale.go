@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+
+	"fintu-tracking-backend/internal/i18n"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// Locale returns a middleware that picks the language of server-generated
+// text from the Accept-Language header, carries it in c.Context() and
+// announces it in Content-Language. ProfileLocale overrides it once the user
+// is known.
+func Locale() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		c.Vary(fiber.HeaderAcceptLanguage)
+		setLocale(c, i18n.Match(c.Get(fiber.HeaderAcceptLanguage)))
+		return c.Next()
+	}
+}
+
+// LocaleSource returns the locale code a user saved in their profile, or ""
+// when they have not chosen one. ProfileService implements it.
+type LocaleSource interface {
+	ProfileLocale(ctx context.Context, userID string) (string, error)
+}
+
+// ProfileLocale returns a middleware that switches to the locale saved in
+// the user's profile, which wins over Accept-Language. It must run after
+// AuthMiddleware. A failed lookup keeps the Accept-Language locale.
+func ProfileLocale(source LocaleSource) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		userID := GetUserID(c)
+		if userID == "" {
+			return c.Next()
+		}
+		code, err := source.ProfileLocale(c.Context(), userID)
+		if err != nil {
+			slog.WarnContext(c.Context(), "profile locale lookup failed", "error", err)
+			return c.Next()
+		}
+		if locale, ok := i18n.Parse(code); ok {
+			setLocale(c, locale)
+		}
+		return c.Next()
+	}
+}
+
+// GetLocale returns the locale of the request, i18n.Default when no locale
+// middleware ran.
+func GetLocale(c fiber.Ctx) i18n.Locale {
+	return i18n.FromContext(c.Context())
+}
+
+func setLocale(c fiber.Ctx, locale i18n.Locale) {
+	c.SetContext(i18n.WithLocale(c.Context(), locale))
+	c.Set(fiber.HeaderContentLanguage, locale.String())
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type fakeLocaleSource map[string]string
+
+func (s fakeLocaleSource) ProfileLocale(_ context.Context, userID string) (string, error) {
+	if userID == "broken" {
+		return "", errors.New("database is down")
+	}
+	return s[userID], nil
+}
+
+func TestLocale(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp()
+	app.Use(RequestLogger())
+	app.Use(Locale())
+	app.Use(func(c fiber.Ctx) error {
+		c.Locals("user_id", c.Get("X-Test-User"))
+		return c.Next()
+	})
+	app.Use(ProfileLocale(fakeLocaleSource{"colombian": "es-CO", "english": "en"}))
+	app.Get("/test", func(c fiber.Ctx) error {
+		return apperror.New(apperror.Validation, "Invalid quantity format")
+	})
+
+	tests := []struct {
+		name, acceptLanguage, user string
+		contentLanguage, message   string
+	}{
+		{name: "default", contentLanguage: "en", message: "Invalid quantity format"},
+		{name: "accept language", acceptLanguage: "es-CO,es;q=0.9", contentLanguage: "es-CO", message: "Formato de cantidad inválido"},
+		{name: "close match", acceptLanguage: "es-MX", contentLanguage: "es-CO", message: "Formato de cantidad inválido"},
+		{name: "profile wins", acceptLanguage: "en-US", user: "colombian", contentLanguage: "es-CO", message: "Formato de cantidad inválido"},
+		{name: "profile english", acceptLanguage: "es-CO", user: "english", contentLanguage: "en", message: "Invalid quantity format"},
+		{name: "no profile locale", acceptLanguage: "es-CO", user: "someone", contentLanguage: "es-CO", message: "Formato de cantidad inválido"},
+		{name: "lookup failure", acceptLanguage: "es-CO", user: "broken", contentLanguage: "es-CO", message: "Formato de cantidad inválido"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tc.acceptLanguage != "" {
+				req.Header.Set(fiber.HeaderAcceptLanguage, tc.acceptLanguage)
+			}
+			req.Header.Set("X-Test-User", tc.user)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if got := resp.Header.Get(fiber.HeaderContentLanguage); got != tc.contentLanguage {
+				t.Errorf("Content-Language = %q, want %q", got, tc.contentLanguage)
+			}
+			if got := resp.Header.Get(fiber.HeaderVary); got != fiber.HeaderAcceptLanguage {
+				t.Errorf("Vary = %q, want Accept-Language", got)
+			}
+			var body models.ErrorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if body.Error != tc.message {
+				t.Errorf("error = %q, want %q", body.Error, tc.message)
+			}
+		})
+	}
+}
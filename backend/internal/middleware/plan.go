@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"fintu-tracking-backend/internal/apperror"
 	"fintu-tracking-backend/internal/services"
 
 	"github.com/gofiber/fiber/v3"
@@ -18,15 +19,11 @@ func RequireActivePlan(svc *services.BillingService) fiber.Handler {
 
 		active, err := svc.HasActiveSubscription(c.Context(), userID)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": err.Error(),
-			})
+			return err
 		}
 
 		if !active {
-			return c.Status(fiber.StatusPaymentRequired).JSON(fiber.Map{
-				"error": "Active subscription required",
-			})
+			return apperror.New(apperror.PlanLimit, "Active subscription required")
 		}
 
 		return c.Next()
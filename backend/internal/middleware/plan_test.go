@@ -22,7 +22,7 @@ func TestRequireActivePlan_AllowsActiveSubscription(t *testing.T) {
 		t.Fatalf("create subscription: %v", err)
 	}
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Use(withUser(userID))
 	app.Use(RequireActivePlan(svc))
 	app.Get("/test", func(c fiber.Ctx) error {
@@ -52,7 +52,7 @@ func TestRequireActivePlan_BlocksMissingSubscription(t *testing.T) {
 
 	svc := services.NewBillingService(database.GetPool(), services.NewNoOpBillingProvider())
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Use(withUser(userID))
 	app.Use(RequireActivePlan(svc))
 	app.Get("/test", func(c fiber.Ctx) error {
@@ -87,7 +87,7 @@ func TestRequireActivePlan_BlocksCanceledSubscription(t *testing.T) {
 		t.Fatalf("cancel subscription: %v", err)
 	}
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Use(withUser(userID))
 	app.Use(RequireActivePlan(svc))
 	app.Get("/test", func(c fiber.Ctx) error {
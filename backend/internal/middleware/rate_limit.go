@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"log/slog"
+	"strconv"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/services"
+	"fintu-tracking-backend/internal/telemetry"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// RateLimit returns a middleware that draws one token per request from the
+// user's bucket for the route group and answers 429 once it is empty. Every
+// response carries RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset
+// (seconds until the bucket is full); 429s add Retry-After. It must run after
+// AuthMiddleware.
+func RateLimit(limiter *services.RateLimiter, rules []config.RateLimitRule) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		userID, err := RequireUserID(c)
+		if err != nil {
+			return err
+		}
+
+		group := rateLimitGroup(rules, c.Path())
+		result, err := limiter.Allow(c.Context(), userID, group)
+		if err != nil {
+			// Fail open: the limiter protects the database, so a database
+			// hiccup should not also reject every request.
+			slog.WarnContext(c.Context(), "rate limit check failed", "group", group, "error", err)
+			return c.Next()
+		}
+
+		if result.Allowed {
+			telemetry.RateLimitDecisions.WithLabelValues(group, "allowed").Inc()
+		} else {
+			telemetry.RateLimitDecisions.WithLabelValues(group, "limited").Inc()
+		}
+
+		c.Set("RateLimit-Limit", strconv.Itoa(result.Quota.Burst))
+		c.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Set("RateLimit-Reset", strconv.Itoa(int(result.Reset.Seconds())))
+		if !result.Allowed {
+			retryAfter := int(result.RetryAfter.Seconds())
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+			body := errorBody(c, apperror.RateLimited, "Rate limit exceeded, retry in %d seconds", retryAfter)
+			body["retry_after"] = retryAfter
+			return c.Status(fiber.StatusTooManyRequests).JSON(body)
+		}
+		return c.Next()
+	}
+}
+
+// rateLimitGroup returns the group of the longest rule prefix matching path,
+// or config.RateLimitGroupDefault.
+func rateLimitGroup(rules []config.RateLimitRule, path string) string {
+	group, matched := config.RateLimitGroupDefault, ""
+	for _, rule := range rules {
+		if hasRoutePrefix(path, rule.Prefix) && len(rule.Prefix) > len(matched) {
+			group, matched = rule.Group, rule.Prefix
+		}
+	}
+	return group
+}
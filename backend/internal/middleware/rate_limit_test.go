@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// memoryRateLimitStore is a token bucket store without refills, so tests
+// control exactly how many requests fit.
+type memoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]float64
+	err     error
+}
+
+func newMemoryRateLimitStore() *memoryRateLimitStore {
+	return &memoryRateLimitStore{buckets: make(map[string]float64)}
+}
+
+func (s *memoryRateLimitStore) Take(_ context.Context, key string, quota config.RateLimitQuota) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return 0, false, s.err
+	}
+	tokens, ok := s.buckets[key]
+	if !ok {
+		tokens = float64(quota.Burst)
+	}
+	if tokens < 1 {
+		return tokens, false, nil
+	}
+	s.buckets[key] = tokens - 1
+	return tokens - 1, true, nil
+}
+
+func (s *memoryRateLimitStore) Prune(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+type stubPlanFeatures json.RawMessage
+
+func (p stubPlanFeatures) PlanFeatures(context.Context, string) (json.RawMessage, error) {
+	return json.RawMessage(p), nil
+}
+
+func newRateLimitTestApp(store services.RateLimitStore, features string) *fiber.App {
+	limiter := services.NewRateLimiter(store, stubPlanFeatures(features))
+	app := newTestApp()
+	app.Use(func(c fiber.Ctx) error {
+		c.Locals("user_id", c.Get("X-Test-User"))
+		return c.Next()
+	})
+	app.Use(RateLimit(limiter, config.RateLimitRules))
+	handler := func(c fiber.Ctx) error {
+		return c.SendString("ok")
+	}
+	app.Get("/api/analytics/risk", handler)
+	app.Get("/api/trades", handler)
+	return app
+}
+
+func rateLimitRequest(t *testing.T, app *fiber.App, userID, path string) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("X-Test-User", userID)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestRateLimit_BucketsPerUserAndGroup(t *testing.T) {
+	t.Parallel()
+
+	app := newRateLimitTestApp(newMemoryRateLimitStore(), `{"rate_limits": {"analytics": {"burst": 2, "per_minute": 6}}}`)
+
+	for i := range 2 {
+		resp := rateLimitRequest(t, app, "user-1", "/api/analytics/risk")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want 200", i+1, resp.StatusCode)
+		}
+	}
+
+	resp := rateLimitRequest(t, app, "user-1", "/api/analytics/risk")
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", resp.StatusCode)
+	}
+	for header, want := range map[string]string{
+		"RateLimit-Limit":     "2",
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "20",
+		"Retry-After":         "10",
+	} {
+		if got := resp.Header.Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	// Other groups and other users have their own buckets.
+	resp = rateLimitRequest(t, app, "user-1", "/api/trades")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("default group status = %d, want 200", resp.StatusCode)
+	}
+	if got, want := resp.Header.Get("RateLimit-Limit"), "120"; got != want {
+		t.Errorf("default group RateLimit-Limit = %q, want %q", got, want)
+	}
+	if resp.Header.Get("Retry-After") != "" {
+		t.Error("allowed response has Retry-After")
+	}
+	if resp := rateLimitRequest(t, app, "user-2", "/api/analytics/risk"); resp.StatusCode != http.StatusOK {
+		t.Errorf("other user status = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestRateLimit_FailsOpen(t *testing.T) {
+	t.Parallel()
+
+	store := newMemoryRateLimitStore()
+	store.err = errors.New("database is down")
+	app := newRateLimitTestApp(store, `{}`)
+
+	resp := rateLimitRequest(t, app, "user-1", "/api/trades")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if resp.Header.Get("RateLimit-Limit") != "" {
+		t.Error("RateLimit-Limit set without a limiter result")
+	}
+}
+
+func TestRateLimit_RequiresUser(t *testing.T) {
+	t.Parallel()
+
+	app := newRateLimitTestApp(newMemoryRateLimitStore(), `{}`)
+	if resp := rateLimitRequest(t, app, "", "/api/trades"); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestRateLimitGroup(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"/api/analytics/risk":                 config.RateLimitGroupAnalytics,
+		"/api/portfolio/holdings":             config.RateLimitGroupAnalytics,
+		"/api/portfolios/consolidated":        config.RateLimitGroupAnalytics,
+		"/api/portfolios":                     config.RateLimitGroupDefault,
+		"/api/market-prices/refresh":          config.RateLimitGroupMarketData,
+		"/api/market-prices/AAPL":             config.RateLimitGroupDefault,
+		"/api/alerts/evaluate":                config.RateLimitGroupMarketData,
+		"/api/trades":                         config.RateLimitGroupDefault,
+		"/api/market-prices/history/refresh/": config.RateLimitGroupMarketData,
+		"/api/v2/analytics/risk":              config.RateLimitGroupAnalytics,
+		"/api/v2/holdings":                    config.RateLimitGroupAnalytics,
+		"/api/v2/activity":                    config.RateLimitGroupAnalytics,
+		"/api/v2/trades":                      config.RateLimitGroupDefault,
+	}
+	for path, want := range cases {
+		if got := rateLimitGroup(config.RateLimitRules, path); got != want {
+			t.Errorf("rateLimitGroup(%s) = %q, want %q", path, got, want)
+		}
+	}
+}
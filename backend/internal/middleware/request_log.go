@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/logging"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// RequestLogger returns a middleware that assigns each request an ID, threads
+// it through c.Context() for logging, echoes it in the X-Request-ID response
+// header and writes one structured access log line per request. Errors
+// returned further down the chain are rendered here with the app's error
+// handler, so the access log and Telemetry see the final status.
+func RequestLogger() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		start := time.Now()
+
+		id := c.Get(config.RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		c.Set(config.RequestIDHeader, id)
+		c.SetContext(logging.WithRequestID(c.Context(), id))
+
+		if err := c.Next(); err != nil {
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				return handlerErr
+			}
+		}
+
+		status := c.Response().StatusCode()
+		level := slog.LevelInfo
+		if status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("ip", c.IP()),
+		}
+		if c.Matched() {
+			attrs = append(attrs, slog.String("route", c.FullPath()))
+		}
+		if userID := GetUserID(c); userID != "" {
+			attrs = append(attrs, slog.String("user_id", userID))
+		}
+		slog.LogAttrs(c.Context(), level, "request", attrs...)
+		return nil
+	}
+}
+
+// validRequestID accepts caller-supplied IDs that are short and printable,
+// so they are safe to log and echo.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > config.RequestIDMaxLength {
+		return false
+	}
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strconv"
+	"time"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/telemetry"
+
+	"github.com/gofiber/fiber/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// unmatchedRoute labels requests that did not resolve to a registered route,
+// so probes of random paths cannot grow the metric's cardinality.
+const unmatchedRoute = "unmatched"
+
+// Telemetry returns a middleware that opens a server span for each request,
+// continuing any W3C traceparent sent by the caller, and records
+// fintu_http_requests_total and fintu_http_request_duration_seconds labelled
+// by route template. Handlers that pass c.Context() to services get child
+// spans down to pgx and outbound HTTP. It must be the first middleware.
+func Telemetry() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		start := time.Now()
+		method := c.Method()
+
+		ctx := otel.GetTextMapPropagator().Extract(c.Context(), headerCarrier{c})
+		ctx, span := telemetry.StartSpan(ctx, method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", method),
+				attribute.String("url.path", c.Path()),
+			),
+		)
+		defer span.End()
+		c.SetContext(ctx)
+
+		err := c.Next()
+
+		status := responseStatus(c, err)
+		route := routeLabel(c, status)
+		span.SetName(method + " " + route)
+		span.SetAttributes(
+			attribute.String("http.route", route),
+			attribute.Int("http.response.status_code", status),
+		)
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, "")
+			if err != nil {
+				span.RecordError(err)
+			}
+		}
+
+		telemetry.HTTPRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+		telemetry.HTTPRequestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// responseStatus is the status the client will see. A returned error is only
+// turned into a response by the app's ErrorHandler, after this middleware.
+func responseStatus(c fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+	_, status := classifyError(err)
+	return status
+}
+
+// routeLabel returns the matched route template (e.g. /api/trades/:id). A
+// request stopped by group middleware before reaching its route, such as a
+// 401 or 429, is labelled with the group's prefix; 404s are unmatchedRoute.
+func routeLabel(c fiber.Ctx, status int) string {
+	if c.Matched() {
+		return c.FullPath()
+	}
+	if status == fiber.StatusNotFound {
+		return unmatchedRoute
+	}
+	if path := c.FullPath(); path != "" && path != "/" {
+		return path
+	}
+	return unmatchedRoute
+}
+
+// MetricsAuth guards the metrics endpoint with a static bearer token. An
+// empty token leaves it open, for scrapers on a private network.
+func MetricsAuth(token string) fiber.Handler {
+	expected := []byte("Bearer " + token)
+	return func(c fiber.Ctx) error {
+		if token == "" {
+			return c.Next()
+		}
+		if subtle.ConstantTimeCompare([]byte(c.Get("Authorization")), expected) != 1 {
+			return apperror.New(apperror.Unauthorized, "Invalid metrics token")
+		}
+		return c.Next()
+	}
+}
+
+// headerCarrier reads trace propagation headers from a Fiber request.
+type headerCarrier struct {
+	c fiber.Ctx
+}
+
+func (h headerCarrier) Get(key string) string { return h.c.Get(key) }
+
+func (h headerCarrier) Set(string, string) {}
+
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0)
+	for key := range h.c.GetReqHeaders() {
+		keys = append(keys, key)
+	}
+	return keys
+}
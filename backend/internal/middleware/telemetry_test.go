@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fintu-tracking-backend/internal/telemetry"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTelemetryTestApp() *fiber.App {
+	app := newTestApp()
+	app.Use(Telemetry())
+	api := app.Group("/telemetry-test", func(c fiber.Ctx) error {
+		if c.Get("X-Deny") != "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "denied"})
+		}
+		return c.Next()
+	})
+	api.Get("/trades/:id", func(c fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	api.Post("/conflict", func(c fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusConflict, "conflict")
+	})
+	return app
+}
+
+func TestTelemetry_LabelsRouteTemplate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		deny   bool
+		route  string
+		status string
+	}{
+		{name: "matched route", method: http.MethodGet, path: "/telemetry-test/trades/123", route: "/telemetry-test/trades/:id", status: "200"},
+		{name: "returned error", method: http.MethodPost, path: "/telemetry-test/conflict", route: "/telemetry-test/conflict", status: "409"},
+		{name: "rejected by group middleware", method: http.MethodGet, path: "/telemetry-test/trades/456", deny: true, route: "/telemetry-test", status: "401"},
+		{name: "unknown path", method: http.MethodGet, path: "/telemetry-test/nope/789", route: unmatchedRoute, status: "404"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			counter := telemetry.HTTPRequests.WithLabelValues(tc.method, tc.route, tc.status)
+			before := testutil.ToFloat64(counter)
+
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.deny {
+				req.Header.Set("X-Deny", "1")
+			}
+			resp, err := newTelemetryTestApp().Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			resp.Body.Close()
+
+			if got := testutil.ToFloat64(counter) - before; got != 1 {
+				t.Errorf("fintu_http_requests_total{method=%q,route=%q,status=%q} grew by %v, want 1", tc.method, tc.route, tc.status, got)
+			}
+		})
+	}
+}
+
+func TestTelemetry_ContinuesIncomingTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	var handlerTraceID string
+	app := newTestApp()
+	app.Use(Telemetry())
+	app.Get("/items/:id", func(c fiber.Ctx) error {
+		handlerTraceID = trace.SpanContextFromContext(c.Context()).TraceID().String()
+		return c.SendString("ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	resp.Body.Close()
+
+	if handlerTraceID != traceID {
+		t.Errorf("handler trace ID = %q, want %q", handlerTraceID, traceID)
+	}
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("ended spans = %d, want 1", len(spans))
+	}
+	if got := spans[0].Name(); got != "GET /items/:id" {
+		t.Errorf("span name = %q, want %q", got, "GET /items/:id")
+	}
+	if got := spans[0].SpanKind(); got != trace.SpanKindServer {
+		t.Errorf("span kind = %v, want server", got)
+	}
+}
+
+func TestMetricsAuth(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		want   int
+	}{
+		{name: "open when no token is configured", want: http.StatusOK},
+		{name: "valid token", token: "s3cret", header: "Bearer s3cret", want: http.StatusOK},
+		{name: "missing header", token: "s3cret", want: http.StatusUnauthorized},
+		{name: "wrong token", token: "s3cret", header: "Bearer nope", want: http.StatusUnauthorized},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			app := newTestApp()
+			app.Get("/metrics", MetricsAuth(tc.token), func(c fiber.Ctx) error {
+				return c.SendString("ok")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tc.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tc.want)
+			}
+		})
+	}
+}
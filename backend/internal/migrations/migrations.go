@@ -93,6 +93,16 @@ func Status(db *sql.DB, dir string) (version uint, dirty bool, err error) {
 	return version, dirty, nil
 }
 
+// Latest returns the highest migration version in dir, or 0 when it has
+// none. Readiness compares it with the applied version.
+func Latest(dir string) (uint, error) {
+	next, err := nextVersion(dir)
+	if err != nil {
+		return 0, fmt.Errorf("read migrations: %w", err)
+	}
+	return uint(next - 1), nil
+}
+
 // Create writes a new paired migration file in dir using the next sequential
 // version number (e.g. 000002_add_table.up.sql and .down.sql).
 func Create(dir, name string) error {
@@ -36,6 +36,49 @@ type PaginatedResponse[T any] struct {
 	PageSize int `json:"page_size"`
 }
 
+// Page is the envelope of every /api/v2 list endpoint. NextCursor is set
+// when more items follow; pass it back as ?cursor= to fetch them. Total is
+// only counted by the lists that take ?include_total=true.
+type Page[T any] struct {
+	Items      []T     `json:"items"`
+	NextCursor *string `json:"next_cursor"`
+	Total      *int    `json:"total,omitempty"`
+}
+
+// ErrorResponse is the body of every error response. Code is one of the
+// apperror kinds (validation, not_found, conflict, ...) and is stable;
+// Error is a human-readable message.
+type ErrorResponse struct {
+	Error     string `json:"error"`
+	Code      string `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// MessageResponse is the body of updates and deletes that return no resource.
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// Liveness is the body of GET /livez.
+type Liveness struct {
+	Status  string `json:"status"`
+	Service string `json:"service"`
+}
+
+// Readiness is the body of GET /readyz. Status is "ok" when every required
+// check passes and "unavailable" otherwise.
+type Readiness struct {
+	Status string                    `json:"status"`
+	Checks map[string]ReadinessCheck `json:"checks"`
+}
+
+// ReadinessCheck is one dependency check. Status is "ok", "failed" or
+// "disabled" (an optional dependency that is not configured).
+type ReadinessCheck struct {
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
 // FxRate represents a foreign exchange rate record
 type FxRate struct {
 	ID        string    `json:"id" db:"id"`
@@ -52,19 +95,22 @@ type CashFlow struct {
 	ID                string    `json:"id" db:"id"`
 	UserID            string    `json:"user_id" db:"user_id"`
 	Date              time.Time `json:"date" db:"date"`
-	Type              string    `json:"type" db:"type"`         // deposit, withdrawal, fee, cash_adjustment
+	Type              string    `json:"type" db:"type"`         // deposit, withdrawal, fee, cash_adjustment, transfer_in, transfer_out
 	Currency          string    `json:"currency" db:"currency"` // COP, USD
 	Amount            string    `json:"amount" db:"amount"`
 	FxRate            *string   `json:"fx_rate" db:"fx_rate"`
 	UsdAmount         string    `json:"usd_amount" db:"usd_amount"`
+	PortfolioID       string    `json:"portfolio_id" db:"portfolio_id"`
 	BrokerID          *string   `json:"broker_id" db:"broker_id"`
 	Notes             *string   `json:"notes" db:"notes"`
 	FeeType           *string   `json:"fee_type" db:"fee_type"` // deposit, trading, closing, maintenance, other, withdrawal
 	RelatedTradeID    *string   `json:"related_trade_id" db:"related_trade_id"`
 	RelatedCashFlowID *string   `json:"related_cash_flow_id" db:"related_cash_flow_id"`
 	RelatedType       *string   `json:"related_type" db:"related_type"` // trade, deposit, withdrawal, standalone
+	TransferID        *string   `json:"transfer_id,omitempty" db:"transfer_id"`
 	CreatedAt         time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+	AutoFee           *CashFlow `json:"auto_fee,omitempty" db:"-"` // fee generated with the flow via auto_fee
 }
 
 // Profile holds per-user onboarding state, UI preferences, and cached subscription info.
@@ -73,6 +119,7 @@ type Profile struct {
 	UserID              string    `json:"user_id" db:"user_id"`
 	Country             string    `json:"country" db:"country"`
 	BrokerPresetID      *string   `json:"broker_preset_id,omitempty" db:"broker_preset_id"`
+	Locale              *string   `json:"locale" db:"locale"` // en or es-CO; nil follows Accept-Language
 	OnboardingCompleted bool      `json:"onboarding_completed" db:"onboarding_completed"`
 	OnboardingStep      string    `json:"onboarding_step" db:"onboarding_step"`
 	PlanID              *string   `json:"plan_id,omitempty" db:"plan_id"`
@@ -87,10 +134,12 @@ type UpdateOnboardingRequest struct {
 	BrokerPresetID string `json:"broker_preset_id"`
 }
 
-// UpdateProfileRequest is the body for PATCH /api/me/profile.
+// UpdateProfileRequest is the body for PATCH /api/me/profile. Locale is the
+// language of server-generated text; omitting it keeps the current one.
 type UpdateProfileRequest struct {
-	Country        string `json:"country"`
-	BrokerPresetID string `json:"broker_preset_id"`
+	Country        string  `json:"country"`
+	BrokerPresetID string  `json:"broker_preset_id"`
+	Locale         *string `json:"locale,omitempty" enum:"en,es-CO"`
 }
 
 // Plan represents a subscription tier and its feature limits.
@@ -145,8 +194,588 @@ type Broker struct {
 	DepositFeeValue    string    `json:"deposit_fee_value" db:"deposit_fee_value"`
 	WithdrawalFeeType  string    `json:"withdrawal_fee_type" db:"withdrawal_fee_type"`
 	WithdrawalFeeValue string    `json:"withdrawal_fee_value" db:"withdrawal_fee_value"`
+	IsCustom           bool      `json:"is_custom" db:"is_custom"`
 	CreatedAt          time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt          time.Time `json:"updated_at" db:"updated_at"`
+	// FeeSchedule is the schedule in force today. It takes precedence over the
+	// legacy *_fee_type/*_fee_value columns when computing fees.
+	FeeSchedule *BrokerFeeSchedule `json:"fee_schedule,omitempty" db:"-"`
+}
+
+// BrokerFeeRule describes how a fee is charged on an amount.
+//   - none: no fee
+//   - flat: Value in USD
+//   - percentage: amount * Value (a fraction, 0.009 = 0.9%), clamped to Min/Max
+//   - tiered: the first tier whose UpTo covers the amount applies Rate (plus
+//     its Flat) to the whole amount, clamped to Min/Max
+type BrokerFeeRule struct {
+	Type  string          `json:"type,omitempty"`
+	Value string          `json:"value,omitempty"`
+	Min   *string         `json:"min,omitempty"`
+	Max   *string         `json:"max,omitempty"`
+	Tiers []BrokerFeeTier `json:"tiers,omitempty"`
+}
+
+// BrokerFeeTier is one bracket of a tiered fee. A nil UpTo covers any amount
+// and must be the last tier.
+type BrokerFeeTier struct {
+	UpTo *string `json:"up_to"`
+	Rate string  `json:"rate"`
+	Flat string  `json:"flat,omitempty"`
+}
+
+// BrokerFeeSchedule is one version of a broker's fees, in force from
+// EffectiveFrom until the next version's date.
+type BrokerFeeSchedule struct {
+	ID            string                   `json:"id" db:"id"`
+	BrokerID      string                   `json:"broker_id" db:"broker_id"`
+	UserID        string                   `json:"user_id" db:"user_id"`
+	EffectiveFrom time.Time                `json:"effective_from" db:"effective_from"`
+	DepositFee    BrokerFeeRule            `json:"deposit_fee" db:"deposit_fee"`
+	WithdrawalFee BrokerFeeRule            `json:"withdrawal_fee" db:"withdrawal_fee"`
+	Commissions   map[string]BrokerFeeRule `json:"commissions" db:"commissions"` // keyed by asset type
+	FXSpread      string                   `json:"fx_spread" db:"fx_spread"`
+	Notes         *string                  `json:"notes" db:"notes"`
+	CreatedAt     time.Time                `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time                `json:"updated_at" db:"updated_at"`
+}
+
+// BrokerFeeScheduleInput is the body for adding a fee schedule version.
+type BrokerFeeScheduleInput struct {
+	EffectiveFrom string                   `json:"effective_from" format:"date"` // YYYY-MM-DD
+	DepositFee    BrokerFeeRule            `json:"deposit_fee,omitzero"`
+	WithdrawalFee BrokerFeeRule            `json:"withdrawal_fee,omitzero"`
+	Commissions   map[string]BrokerFeeRule `json:"commissions,omitempty"`
+	FXSpread      string                   `json:"fx_spread,omitempty"`
+	Notes         *string                  `json:"notes"`
+}
+
+// CreateCustomBrokerRequest defines a broker that is not in the built-in presets.
+type CreateCustomBrokerRequest struct {
+	Name          string                  `json:"name,omitempty"`
+	Country       string                  `json:"country,omitempty"`
+	BaseCurrency  string                  `json:"base_currency,omitempty"`
+	LocalCurrency string                  `json:"local_currency,omitempty"`
+	FeeSchedule   *BrokerFeeScheduleInput `json:"fee_schedule"`
+}
+
+// UpdateBrokerRequest is the body for PATCH /api/brokers/:id. Fees are changed
+// by adding a fee schedule version instead.
+type UpdateBrokerRequest struct {
+	Name          *string `json:"name"`
+	Country       *string `json:"country"`
+	BaseCurrency  *string `json:"base_currency"`
+	LocalCurrency *string `json:"local_currency"`
+}
+
+// Portfolio is a named bucket ("Retirement", "Kids", one per broker, ...) that
+// owns trades and cash flows. Each user has exactly one default portfolio.
+type Portfolio struct {
+	ID        string    `json:"id" db:"id"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	Name      string    `json:"name" db:"name"`
+	BrokerID  *string   `json:"broker_id" db:"broker_id"`
+	IsDefault bool      `json:"is_default" db:"is_default"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreatePortfolioRequest is the body for POST /api/portfolios.
+type CreatePortfolioRequest struct {
+	Name      string  `json:"name"`
+	BrokerID  *string `json:"broker_id"`
+	IsDefault bool    `json:"is_default,omitempty"`
+}
+
+// UpdatePortfolioRequest is the body for PATCH /api/portfolios/:id.
+type UpdatePortfolioRequest struct {
+	Name      *string `json:"name"`
+	BrokerID  *string `json:"broker_id"`
+	IsDefault *bool   `json:"is_default"`
+}
+
+// CreatePortfolioTransferRequest moves USD cash between two of the user's portfolios.
+type CreatePortfolioTransferRequest struct {
+	FromPortfolioID string  `json:"from_portfolio_id"`
+	ToPortfolioID   string  `json:"to_portfolio_id"`
+	Date            string  `json:"date" format:"date"`
+	Amount          string  `json:"amount"`
+	Notes           *string `json:"notes"`
+}
+
+// PortfolioTransfer is the pair of cash flow legs written for one internal transfer.
+type PortfolioTransfer struct {
+	TransferID string   `json:"transfer_id"`
+	Out        CashFlow `json:"out"`
+	In         CashFlow `json:"in"`
+}
+
+// PortfolioNetWorth pairs a portfolio with its own net worth summary.
+type PortfolioNetWorth struct {
+	Portfolio Portfolio       `json:"portfolio"`
+	Summary   NetWorthSummary `json:"summary"`
+}
+
+// ConsolidatedPortfolioView lists every portfolio side by side with the
+// consolidated total across all of them.
+type ConsolidatedPortfolioView struct {
+	Consolidated NetWorthSummary     `json:"consolidated"`
+	Portfolios   []PortfolioNetWorth `json:"portfolios"`
+}
+
+// AllocationTarget is one entry of a target allocation. Key is a ticker or an
+// asset type depending on the allocation's basis, or "CASH" for uninvested cash.
+type AllocationTarget struct {
+	Key       string  `json:"key"`
+	Weight    string  `json:"weight"`               // decimal fraction
+	DriftBand *string `json:"drift_band,omitempty"` // overrides the allocation's band
+	AssetType string  `json:"asset_type,omitempty"` // commission class for tickers not held yet
+}
+
+// TargetAllocation is a portfolio's desired mix, with a drift band (absolute
+// weight difference) inside which positions are left alone.
+type TargetAllocation struct {
+	PortfolioID      string             `json:"portfolio_id" db:"portfolio_id"`
+	Basis            string             `json:"basis" db:"basis"` // ticker, asset_type
+	DriftBand        string             `json:"drift_band" db:"drift_band"`
+	FractionalShares bool               `json:"fractional_shares" db:"fractional_shares"`
+	Targets          []AllocationTarget `json:"targets" db:"targets"`
+	CreatedAt        time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// SetTargetAllocationRequest is the body for PUT /api/portfolios/:id/target-allocation.
+type SetTargetAllocationRequest struct {
+	Basis            string             `json:"basis,omitempty"`
+	DriftBand        string             `json:"drift_band,omitempty"`
+	FractionalShares *bool              `json:"fractional_shares"`
+	Targets          []AllocationTarget `json:"targets"`
+}
+
+// RebalancePosition compares one target (or untargeted holding) with the
+// portfolio today. Weights are decimal fractions of holdings plus cash.
+type RebalancePosition struct {
+	Key           string `json:"key"`
+	CurrentValue  string `json:"current_value"`
+	CurrentWeight string `json:"current_weight"`
+	TargetWeight  string `json:"target_weight"`
+	Drift         string `json:"drift"`
+	DriftBand     string `json:"drift_band"`
+	OutOfBand     bool   `json:"out_of_band"`
+}
+
+// RebalanceOrder is a suggested trade. Amount excludes EstimatedFee.
+type RebalanceOrder struct {
+	Ticker       string `json:"ticker"`
+	AssetType    string `json:"asset_type"`
+	Side         string `json:"side"` // buy, sell
+	Quantity     string `json:"quantity"`
+	Price        string `json:"price"`
+	Amount       string `json:"amount"`
+	EstimatedFee string `json:"estimated_fee"`
+}
+
+// RebalancePlan is the response of GET /api/portfolios/:id/rebalance.
+type RebalancePlan struct {
+	PortfolioID      string              `json:"portfolio_id"`
+	Basis            string              `json:"basis"`
+	TotalValue       string              `json:"total_value"`
+	CashBefore       string              `json:"cash_before"`
+	CashAfter        string              `json:"cash_after"`
+	AllowSells       bool                `json:"allow_sells"`
+	FractionalShares bool                `json:"fractional_shares"`
+	NeedsRebalance   bool                `json:"needs_rebalance"`
+	Positions        []RebalancePosition `json:"positions"`
+	Orders           []RebalanceOrder    `json:"orders"`
+	EstimatedFees    string              `json:"estimated_fees"`
+	Warnings         []string            `json:"warnings"`
+}
+
+// DCATarget is one ticker of a DCA plan; weights sum to 1.
+type DCATarget struct {
+	Ticker    string `json:"ticker"`
+	Weight    string `json:"weight"`               // decimal fraction
+	AssetType string `json:"asset_type,omitempty"` // stock, etf
+}
+
+// DCAPlan is a recurring contribution: Amount in Currency every period, split
+// across Targets. NextRunDate is nil once the plan is paused or has ended.
+type DCAPlan struct {
+	ID                string      `json:"id" db:"id"`
+	PortfolioID       string      `json:"portfolio_id" db:"portfolio_id"`
+	BrokerID          *string     `json:"broker_id" db:"broker_id"`
+	Name              string      `json:"name" db:"name"`
+	Amount            string      `json:"amount" db:"amount"`
+	Currency          string      `json:"currency" db:"currency"`   // COP, USD
+	Frequency         string      `json:"frequency" db:"frequency"` // weekly, biweekly, monthly
+	StartDate         time.Time   `json:"start_date" db:"start_date"`
+	EndDate           *time.Time  `json:"end_date" db:"end_date"`
+	Targets           []DCATarget `json:"targets" db:"targets"`
+	Active            bool        `json:"active" db:"active"`
+	LastScheduledDate *time.Time  `json:"last_scheduled_date" db:"last_scheduled_date"`
+	NextRunDate       *time.Time  `json:"next_run_date" db:"-"`
+	CreatedAt         time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// CreateDCAPlanRequest is the body for POST /api/dca-plans. PortfolioID
+// defaults to the default portfolio and BrokerID to the portfolio's broker.
+type CreateDCAPlanRequest struct {
+	Name        string      `json:"name"`
+	PortfolioID *string     `json:"portfolio_id"`
+	BrokerID    *string     `json:"broker_id"`
+	Amount      string      `json:"amount"`
+	Currency    string      `json:"currency,omitempty"`
+	Frequency   string      `json:"frequency,omitempty"`
+	StartDate   string      `json:"start_date" format:"date"`
+	EndDate     *string     `json:"end_date"`
+	Targets     []DCATarget `json:"targets"`
+}
+
+// UpdateDCAPlanRequest is the body for PATCH /api/dca-plans/:id. Changes apply
+// to installments generated afterwards. An empty EndDate clears it.
+type UpdateDCAPlanRequest struct {
+	Name    *string     `json:"name"`
+	Amount  *string     `json:"amount"`
+	EndDate *string     `json:"end_date"`
+	Targets []DCATarget `json:"targets,omitempty"`
+	Active  *bool       `json:"active"`
+}
+
+// DCAPlannedOrder is an installment's planned buy of one target, in the plan currency.
+type DCAPlannedOrder struct {
+	Ticker    string `json:"ticker"`
+	AssetType string `json:"asset_type"`
+	Weight    string `json:"weight"`
+	Amount    string `json:"amount"`
+}
+
+// DCAInstallment is one scheduled contribution of a plan. It stays "planned"
+// until the user confirms it with actual fills (or skips it).
+type DCAInstallment struct {
+	ID            string            `json:"id" db:"id"`
+	PlanID        string            `json:"plan_id" db:"plan_id"`
+	ScheduledDate time.Time         `json:"scheduled_date" db:"scheduled_date"`
+	Status        string            `json:"status" db:"status"` // planned, confirmed, skipped
+	PlannedAmount string            `json:"planned_amount" db:"planned_amount"`
+	Currency      string            `json:"currency" db:"currency"`
+	PlannedOrders []DCAPlannedOrder `json:"planned_orders" db:"planned_orders"`
+	ExecutedDate  *time.Time        `json:"executed_date" db:"executed_date"`
+	ActualAmount  *string           `json:"actual_amount" db:"actual_amount"`
+	FxRate        *string           `json:"fx_rate" db:"fx_rate"`
+	UsdAmount     *string           `json:"usd_amount" db:"usd_amount"`
+	CashFlowID    *string           `json:"cash_flow_id" db:"cash_flow_id"`
+	TradeIDs      []string          `json:"trade_ids" db:"trade_ids"`
+	ConfirmedAt   *time.Time        `json:"confirmed_at" db:"confirmed_at"`
+	CreatedAt     time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// GenerateDCAInstallmentsResponse reports how many due installments an explicit
+// generation run created.
+type GenerateDCAInstallmentsResponse struct {
+	Created int `json:"created"`
+}
+
+// DCAFill is an actual buy made for an installment.
+type DCAFill struct {
+	Ticker     string  `json:"ticker"`
+	Quantity   string  `json:"quantity"`
+	Price      string  `json:"price"`
+	TradingFee *string `json:"trading_fee"`
+}
+
+// ConfirmDCAInstallmentRequest is the body for POST
+// /api/dca-installments/:id/confirm. Date defaults to the scheduled date and
+// Amount to the planned amount; FxRate is required for COP plans.
+type ConfirmDCAInstallmentRequest struct {
+	Date   *string   `json:"date"`
+	Amount *string   `json:"amount"`
+	FxRate *string   `json:"fx_rate"`
+	Fills  []DCAFill `json:"fills,omitempty"`
+	Notes  *string   `json:"notes"`
+}
+
+// DCATargetAdherence compares a target's weight with its share of the USD
+// actually invested by confirmed installments.
+type DCATargetAdherence struct {
+	Ticker       string `json:"ticker"`
+	TargetWeight string `json:"target_weight"`
+	ActualWeight string `json:"actual_weight"`
+	InvestedUSD  string `json:"invested_usd"`
+}
+
+// DCAAdherenceReport is the response of GET /api/dca-plans/:id/report. Rates
+// are COP per USD; a negative RateDifferencePct means the plan bought dollars
+// cheaper than the period's average rate.
+type DCAAdherenceReport struct {
+	PlanID            string               `json:"plan_id"`
+	From              time.Time            `json:"from"`
+	To                time.Time            `json:"to"`
+	Currency          string               `json:"currency"`
+	Scheduled         int                  `json:"scheduled"`
+	Confirmed         int                  `json:"confirmed"`
+	Skipped           int                  `json:"skipped"`
+	Pending           int                  `json:"pending"`
+	AdherencePct      string               `json:"adherence_pct"` // confirmed / scheduled
+	PlannedAmount     string               `json:"planned_amount"`
+	ContributedAmount string               `json:"contributed_amount"`
+	ContributedUSD    string               `json:"contributed_usd"`
+	AvgAchievedRate   *string              `json:"avg_achieved_rate"` // COP plans only
+	AvgMarketRate     *string              `json:"avg_market_rate"`   // mean of fx_rates in the period
+	RateDifferencePct *string              `json:"rate_difference_pct"`
+	Targets           []DCATargetAdherence `json:"targets"`
+}
+
+// Goal is a target amount to reach by a date, tracked against one portfolio or,
+// when PortfolioID is nil, the consolidated net worth.
+type Goal struct {
+	ID                  string    `json:"id" db:"id"`
+	PortfolioID         *string   `json:"portfolio_id" db:"portfolio_id"`
+	Name                string    `json:"name" db:"name"`
+	TargetAmount        string    `json:"target_amount" db:"target_amount"`
+	Currency            string    `json:"currency" db:"currency"` // COP, USD
+	TargetDate          time.Time `json:"target_date" db:"target_date"`
+	MonthlyContribution *string   `json:"monthly_contribution" db:"monthly_contribution"` // nil: from DCA plans
+	CreatedAt           time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreateGoalRequest is the body for POST /api/goals.
+type CreateGoalRequest struct {
+	Name                string  `json:"name"`
+	PortfolioID         *string `json:"portfolio_id"`
+	TargetAmount        string  `json:"target_amount"`
+	Currency            string  `json:"currency,omitempty"`
+	TargetDate          string  `json:"target_date"`
+	MonthlyContribution *string `json:"monthly_contribution"`
+}
+
+// UpdateGoalRequest is the body for PATCH /api/goals/:id. An empty
+// MonthlyContribution goes back to contributions from DCA plans.
+type UpdateGoalRequest struct {
+	Name                *string `json:"name"`
+	TargetAmount        *string `json:"target_amount"`
+	TargetDate          *string `json:"target_date"`
+	MonthlyContribution *string `json:"monthly_contribution"`
+}
+
+// GoalAssumptions are the return assumptions behind a projection, as annual
+// percentages. Source is "history" (the portfolio's daily returns) or "default".
+type GoalAssumptions struct {
+	Source                  string `json:"source"`
+	Observations            int    `json:"observations"`
+	ExpectedAnnualReturnPct string `json:"expected_annual_return_pct"`
+	AnnualVolatilityPct     string `json:"annual_volatility_pct"`
+}
+
+// GoalProjectionPoint is the projected value at the end of one month: the
+// deterministic path and Monte Carlo percentiles, in USD.
+type GoalProjectionPoint struct {
+	Date          time.Time `json:"date"`
+	Deterministic string    `json:"deterministic"`
+	P10           string    `json:"p10"`
+	P50           string    `json:"p50"`
+	P90           string    `json:"p90"`
+}
+
+// GoalProjection is the response of GET /api/goals/:id/projection. USD amounts
+// use the latest recorded COP/USD rate for COP goals and contributions.
+type GoalProjection struct {
+	GoalID                         string                `json:"goal_id"`
+	AsOf                           time.Time             `json:"as_of"`
+	TargetDate                     time.Time             `json:"target_date"`
+	Months                         int                   `json:"months"`
+	Currency                       string                `json:"currency"`
+	TargetAmount                   string                `json:"target_amount"`
+	TargetAmountUSD                string                `json:"target_amount_usd"`
+	FxRate                         *string               `json:"fx_rate"`
+	CurrentValueUSD                string                `json:"current_value_usd"`
+	MonthlyContributionUSD         string                `json:"monthly_contribution_usd"`
+	ContributionSource             string                `json:"contribution_source"` // goal, dca_plans, none
+	Assumptions                    GoalAssumptions       `json:"assumptions"`
+	ProjectedValueUSD              string                `json:"projected_value_usd"` // deterministic
+	MedianValueUSD                 string                `json:"median_value_usd"`
+	Simulations                    int                   `json:"simulations"`
+	OnTrackProbabilityPct          string                `json:"on_track_probability_pct"`
+	OnTrack                        bool                  `json:"on_track"` // deterministic path reaches the target
+	RequiredMonthlyContributionUSD *string               `json:"required_monthly_contribution_usd"`
+	RequiredMonthlyContribution    *string               `json:"required_monthly_contribution"` // goal currency
+	Series                         []GoalProjectionPoint `json:"series"`
+}
+
+// AlertRule is a condition checked after every price/FX refresh. Threshold is
+// a USD price (price_cross), an unrealized P/L percent (holding_pl), a percent
+// move of USD/COP from ReferenceValue (fx_move) or a percent drawdown from the
+// peak (drawdown).
+type AlertRule struct {
+	ID              string     `json:"id" db:"id"`
+	PortfolioID     *string    `json:"portfolio_id" db:"portfolio_id"`
+	Name            string     `json:"name" db:"name"`
+	Kind            string     `json:"kind" db:"kind"`
+	Ticker          *string    `json:"ticker" db:"ticker"`
+	Direction       string     `json:"direction" db:"direction"` // above, below, any
+	Threshold       string     `json:"threshold" db:"threshold"`
+	Channels        []string   `json:"channels" db:"channels"`
+	Email           *string    `json:"email" db:"email"`
+	WebhookURL      *string    `json:"webhook_url" db:"webhook_url"`
+	Active          bool       `json:"active" db:"active"`
+	Triggered       bool       `json:"triggered" db:"triggered"`
+	ReferenceValue  *string    `json:"reference_value" db:"reference_value"`
+	LastValue       *string    `json:"last_value" db:"last_value"`
+	LastEvaluatedAt *time.Time `json:"last_evaluated_at" db:"last_evaluated_at"`
+	LastTriggeredAt *time.Time `json:"last_triggered_at" db:"last_triggered_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// CreateAlertRuleRequest is the body of POST /api/alerts.
+type CreateAlertRuleRequest struct {
+	Name        string   `json:"name"`
+	PortfolioID *string  `json:"portfolio_id"`
+	Kind        string   `json:"kind"`
+	Ticker      *string  `json:"ticker"`
+	Direction   string   `json:"direction,omitempty"`
+	Threshold   string   `json:"threshold"`
+	Channels    []string `json:"channels,omitempty"`
+	Email       *string  `json:"email"`
+	WebhookURL  *string  `json:"webhook_url"`
+}
+
+// UpdateAlertRuleRequest is the body of PATCH /api/alerts/:id. Changing the
+// direction or threshold re-arms the rule.
+type UpdateAlertRuleRequest struct {
+	Name       *string  `json:"name"`
+	Direction  *string  `json:"direction"`
+	Threshold  *string  `json:"threshold"`
+	Channels   []string `json:"channels,omitempty"`
+	Email      *string  `json:"email"`
+	WebhookURL *string  `json:"webhook_url"`
+	Active     *bool    `json:"active"`
+}
+
+// AlertDelivery is the outcome of delivering an alert event on one channel.
+type AlertDelivery struct {
+	Channel string `json:"channel"`
+	Status  string `json:"status"` // sent, skipped, failed
+	Error   string `json:"error,omitempty"`
+}
+
+// AlertEvent records one firing of an alert rule.
+type AlertEvent struct {
+	ID          string          `json:"id" db:"id"`
+	RuleID      string          `json:"rule_id" db:"rule_id"`
+	Kind        string          `json:"kind" db:"kind"`
+	Message     string          `json:"message" db:"message"`
+	Value       string          `json:"value" db:"value"`
+	Threshold   string          `json:"threshold" db:"threshold"`
+	Deliveries  []AlertDelivery `json:"deliveries" db:"deliveries"`
+	TriggeredAt time.Time       `json:"triggered_at" db:"triggered_at"`
+}
+
+// AlertEvaluation summarizes one evaluation run over the user's active rules.
+type AlertEvaluation struct {
+	Evaluated int          `json:"evaluated"`
+	Skipped   int          `json:"skipped"` // no data yet, e.g. ticker without a price
+	Triggered []AlertEvent `json:"triggered"`
+}
+
+// Notification is an entry of the in-app inbox. Data holds type-specific
+// references such as the alert rule and event IDs.
+type Notification struct {
+	ID        string            `json:"id" db:"id"`
+	Type      string            `json:"type" db:"type"`
+	Title     string            `json:"title" db:"title"`
+	Body      string            `json:"body" db:"body"`
+	Data      map[string]string `json:"data" db:"data"`
+	ReadAt    *time.Time        `json:"read_at" db:"read_at"`
+	CreatedAt time.Time         `json:"created_at" db:"created_at"`
+}
+
+// UnreadNotificationCount is the body of GET /api/notifications/unread-count.
+type UnreadNotificationCount struct {
+	Unread int `json:"unread"`
+}
+
+// NotificationsMarkedRead is the body of POST /api/notifications/read-all.
+type NotificationsMarkedRead struct {
+	Updated int64 `json:"updated"`
+}
+
+// WebhookEndpoint is a user-registered URL that receives signed event
+// payloads. Secret is only returned when the endpoint is created.
+type WebhookEndpoint struct {
+	ID          string    `json:"id" db:"id"`
+	URL         string    `json:"url" db:"url"`
+	Description string    `json:"description" db:"description"`
+	Events      []string  `json:"events" db:"events"`
+	Active      bool      `json:"active" db:"active"`
+	Secret      string    `json:"secret,omitempty" db:"-"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreateWebhookEndpointRequest is the body of POST /api/webhooks.
+type CreateWebhookEndpointRequest struct {
+	URL         string   `json:"url"`
+	Description *string  `json:"description"`
+	Events      []string `json:"events,omitempty"`
+}
+
+// UpdateWebhookEndpointRequest is the body of PATCH /api/webhooks/:id.
+type UpdateWebhookEndpointRequest struct {
+	URL         *string  `json:"url"`
+	Description *string  `json:"description"`
+	Events      []string `json:"events,omitempty"`
+	Active      *bool    `json:"active"`
+}
+
+// WebhookDelivery records one event sent to one endpoint. NextAttemptAt is
+// set while the delivery is pending a retry.
+type WebhookDelivery struct {
+	ID             string          `json:"id" db:"id"`
+	EndpointID     string          `json:"endpoint_id" db:"endpoint_id"`
+	EventID        string          `json:"event_id" db:"event_id"`
+	EventType      string          `json:"event_type" db:"event_type"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`
+	Status         string          `json:"status" db:"status"` // pending, succeeded, failed
+	Attempts       int             `json:"attempts" db:"attempts"`
+	ResponseStatus *int            `json:"response_status" db:"response_status"`
+	Error          *string         `json:"error" db:"error"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at" db:"next_attempt_at"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at" db:"last_attempt_at"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+}
+
+// WebhookEvent is the JSON body POSTed to webhook endpoints.
+type WebhookEvent struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// APIToken is a personal access token for scripts. Token holds the plaintext
+// value and is only returned when the token is created; Prefix identifies it
+// afterwards.
+type APIToken struct {
+	ID         string     `json:"id" db:"id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at" db:"revoked_at"`
+	Token      string     `json:"token,omitempty" db:"-"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreateAPITokenRequest is the body of POST /api/tokens. ExpiresInDays
+// defaults to config.DefaultAPITokenExpiryDays.
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays *int     `json:"expires_in_days"`
 }
 
 // Trade represents a stock, ETF, or crypto trade with detailed fee breakdown
@@ -165,6 +794,7 @@ type Trade struct {
 	ClosingFee        string    `json:"closing_fee" db:"closing_fee"`
 	TotalFees         string    `json:"total_fees" db:"total_fees"`
 	Total             string    `json:"total" db:"total"`
+	PortfolioID       string    `json:"portfolio_id" db:"portfolio_id"`
 	BrokerID          *string   `json:"broker_id" db:"broker_id"`
 	Notes             *string   `json:"notes" db:"notes"`
 	RealizedPL        *string   `json:"realized_pl,omitempty"`
@@ -181,6 +811,34 @@ type MarketPrice struct {
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// DailyPrice is a ticker's closing price for one trading day.
+type DailyPrice struct {
+	Ticker   string    `json:"ticker" db:"ticker"`
+	Date     time.Time `json:"date" db:"price_date"`
+	Close    string    `json:"close" db:"close"`
+	Currency string    `json:"currency" db:"currency"`
+}
+
+// SymbolMetadata classifies a ticker for exposure analytics. Optional fields are
+// nil when unknown or not applicable (e.g. market cap for a bond fund).
+type SymbolMetadata struct {
+	Ticker     string  `json:"ticker" db:"ticker"`
+	Name       string  `json:"name" db:"name"`
+	AssetClass string  `json:"asset_class" db:"asset_class"` // equity, fixed_income, crypto, multi_asset
+	Sector     *string `json:"sector" db:"sector"`
+	Country    *string `json:"country" db:"country"`
+	Region     *string `json:"region" db:"region"`
+	MarketCap  *string `json:"market_cap" db:"market_cap"` // mega, large, mid, small
+	Currency   string  `json:"currency" db:"currency"`
+}
+
+// ETFConstituent is one holding of an ETF as a decimal fraction of the fund.
+type ETFConstituent struct {
+	ETFTicker string `json:"etf_ticker" db:"etf_ticker"`
+	Ticker    string `json:"ticker" db:"constituent_ticker"`
+	Weight    string `json:"weight" db:"weight"`
+}
+
 // PortfolioSnapshot represents a historical snapshot of portfolio state
 type PortfolioSnapshot struct {
 	ID               string    `json:"id" db:"id"`
@@ -237,6 +895,46 @@ type FeeBreakdown struct {
 	OtherFees       string            `json:"other_fees"`
 	TotalFees       string            `json:"total_fees"`
 	FeesByMonth     map[string]string `json:"fees_by_month"`
+	ByBroker        []BrokerFees      `json:"by_broker"`
+}
+
+// BrokerFees is the fee breakdown for one broker. BrokerID is nil for fees
+// recorded without a broker.
+type BrokerFees struct {
+	BrokerID        *string `json:"broker_id"`
+	BrokerName      string  `json:"broker_name"`
+	DepositFees     string  `json:"deposit_fees"`
+	TradingFees     string  `json:"trading_fees"`
+	ClosingFees     string  `json:"closing_fees"`
+	MaintenanceFees string  `json:"maintenance_fees"`
+	OtherFees       string  `json:"other_fees"`
+	TotalFees       string  `json:"total_fees"`
+}
+
+// FeeImpact is the fee drag on one ticker's purchases. TradeCount is zero and
+// NetQuantity empty when the ticker has no trades.
+type FeeImpact struct {
+	Ticker       string `json:"ticker"`
+	TotalFees    string `json:"total_fees"`
+	TotalCost    string `json:"total_cost"`
+	FeeImpactPct string `json:"fee_impact_pct"` // fees as % of purchase cost
+	TradeCount   int    `json:"trade_count"`
+	NetQuantity  string `json:"net_quantity"`
+}
+
+// FeeEfficiency compares the fees paid per ticker, highest fees first.
+type FeeEfficiency struct {
+	ByTicker []TickerFeeEfficiency `json:"by_ticker"`
+}
+
+// TickerFeeEfficiency is the fee efficiency of the trades in one ticker that
+// paid fees.
+type TickerFeeEfficiency struct {
+	Ticker     string `json:"ticker"`
+	TradeCount int    `json:"trade_count"`
+	TotalFees  string `json:"total_fees"`
+	TotalValue string `json:"total_value"`
+	AvgFeePct  string `json:"avg_fee_pct"` // mean of each trade's fees as % of its value
 }
 
 // ReturnAttribution decomposes portfolio returns into components
@@ -275,29 +973,154 @@ type PerformancePoint struct {
 	NetReturn          string    `json:"net_return"`
 	NetReturnPct       string    `json:"net_return_pct"`
 	SpyIndexed         string    `json:"spy_indexed,omitempty"`
+	// Benchmarks maps each requested benchmark ID to its level, indexed to 100
+	// at the first point.
+	Benchmarks map[string]string `json:"benchmarks,omitempty"`
+}
+
+// BenchmarkComponent is one ticker of a benchmark basket; weights sum to 1.
+type BenchmarkComponent struct {
+	Ticker string `json:"ticker"`
+	Weight string `json:"weight"`
+}
+
+// Benchmark is a built-in or user-defined basket to compare performance against.
+type Benchmark struct {
+	ID         string               `json:"id" db:"id"`
+	Name       string               `json:"name" db:"name"`
+	IsCustom   bool                 `json:"is_custom" db:"-"`
+	Components []BenchmarkComponent `json:"components" db:"components"`
+	CreatedAt  *time.Time           `json:"created_at,omitempty" db:"created_at"`
+	UpdatedAt  *time.Time           `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// CreateBenchmarkRequest is the body for creating a custom benchmark basket.
+type CreateBenchmarkRequest struct {
+	Name       string               `json:"name"`
+	Components []BenchmarkComponent `json:"components"`
+}
+
+// BenchmarkComparison answers "what if every deposit and withdrawal had bought
+// or sold the benchmark instead" for one benchmark.
+type BenchmarkComparison struct {
+	Benchmark      Benchmark              `json:"benchmark"`
+	NetContributed string                 `json:"net_contributed"`
+	BenchmarkValue string                 `json:"benchmark_value"`
+	PortfolioValue string                 `json:"portfolio_value"`
+	Difference     string                 `json:"difference"` // portfolio minus benchmark
+	BenchmarkXIRR  string                 `json:"benchmark_xirr"`
+	PortfolioXIRR  string                 `json:"portfolio_xirr"`
+	MissingPrices  []string               `json:"missing_prices,omitempty"` // components without daily closes
+	SkippedFlows   int                    `json:"skipped_flows,omitempty"`  // flows dated before the benchmark's price history
+	Series         []BenchmarkWhatIfPoint `json:"series"`
+}
+
+// BenchmarkWhatIfPoint is the hypothetical benchmark portfolio on one day.
+type BenchmarkWhatIfPoint struct {
+	Date           time.Time `json:"date"`
+	NetContributed string    `json:"net_contributed"`
+	BenchmarkValue string    `json:"benchmark_value"`
+}
+
+// RiskMetrics summarizes the risk of a daily return series. Percentages are
+// annualized where noted; ratios are unitless.
+type RiskMetrics struct {
+	Observations            int        `json:"observations"` // daily returns used
+	AnnualizedReturnPct     string     `json:"annualized_return_pct"`
+	AnnualizedVolatilityPct string     `json:"annualized_volatility_pct"`
+	MaxDrawdownPct          string     `json:"max_drawdown_pct"`
+	MaxDrawdownPeakDate     *time.Time `json:"max_drawdown_peak_date"`
+	MaxDrawdownTroughDate   *time.Time `json:"max_drawdown_trough_date"`
+	SharpeRatio             string     `json:"sharpe_ratio"`
+	SortinoRatio            string     `json:"sortino_ratio"`
+	Beta                    string     `json:"beta"`
+	Correlation             string     `json:"correlation"`
+	ValueAtRiskPct          string     `json:"value_at_risk_pct"` // one-day historical VaR
+	ValueAtRiskUSD          string     `json:"value_at_risk_usd"`
+}
+
+// HoldingRiskMetrics is RiskMetrics for a single open position, from its price history.
+type HoldingRiskMetrics struct {
+	Ticker      string `json:"ticker"`
+	MarketValue string `json:"market_value"`
+	RiskMetrics
+}
+
+// RiskReport is the response of GET /api/analytics/risk.
+type RiskReport struct {
+	AsOf            time.Time            `json:"as_of"`
+	Benchmark       string               `json:"benchmark"`
+	RiskFreeRatePct string               `json:"risk_free_rate_pct"`
+	VaRConfidence   string               `json:"var_confidence"`
+	Portfolio       RiskMetrics          `json:"portfolio"`
+	Holdings        []HoldingRiskMetrics `json:"holdings"`
+}
+
+// ExposureBucket is the share of holdings value in one sector, region, etc.
+type ExposureBucket struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	Percent string `json:"percent"`
+}
+
+// ConcentrationWarning flags a single name above the concentration threshold.
+type ConcentrationWarning struct {
+	Ticker    string `json:"ticker"`
+	Percent   string `json:"percent"`
+	Threshold string `json:"threshold"`
+	Message   string `json:"message"`
+}
+
+// ExposureReport is the response of GET /api/analytics/exposure. Percentages are
+// of holdings value (cash excluded), after looking through ETFs with known
+// constituents. Buckets are sorted by value, largest first.
+type ExposureReport struct {
+	HoldingsValue string                 `json:"holdings_value"`
+	BySector      []ExposureBucket       `json:"by_sector"`
+	ByCountry     []ExposureBucket       `json:"by_country"`
+	ByRegion      []ExposureBucket       `json:"by_region"`
+	ByMarketCap   []ExposureBucket       `json:"by_market_cap"`
+	ByCurrency    []ExposureBucket       `json:"by_currency"`
+	ByAssetClass  []ExposureBucket       `json:"by_asset_class"`
+	ByName        []ExposureBucket       `json:"by_name"`        // direct plus look-through positions
+	LookedThrough []string               `json:"looked_through"` // ETFs expanded into constituents
+	Unclassified  []string               `json:"unclassified"`   // tickers without metadata
+	Warnings      []ConcentrationWarning `json:"warnings"`
 }
 
 // ReconciliationReport checks data integrity between trades and cash flows
 type ReconciliationReport struct {
-	IsReconciled      bool                  `json:"is_reconciled"`
-	TotalTradeFees    string                `json:"total_trade_fees"`
-	TotalCashFlowFees string                `json:"total_cash_flow_fees"`
-	Difference        string                `json:"difference"`
-	MissingLinks      []string              `json:"missing_links"`       // Trade IDs without cash flows
-	OrphanedCashFlows []string              `json:"orphaned_cash_flows"` // Cash flow IDs without trades
-	UnlinkedCashFlows []string              `json:"unlinked_cash_flows"` // Trade fee cash flows with no related_trade_id
-	Discrepancies     []ReconciliationIssue `json:"discrepancies"`
+	IsReconciled      bool                   `json:"is_reconciled"`
+	TotalTradeFees    string                 `json:"total_trade_fees"`
+	TotalCashFlowFees string                 `json:"total_cash_flow_fees"`
+	Difference        string                 `json:"difference"`
+	MissingLinks      []string               `json:"missing_links"`       // Trade IDs without cash flows
+	OrphanedCashFlows []string               `json:"orphaned_cash_flows"` // Cash flow IDs without trades
+	UnlinkedCashFlows []string               `json:"unlinked_cash_flows"` // Trade fee cash flows with no related_trade_id
+	Discrepancies     []ReconciliationIssue  `json:"discrepancies"`
+	ByBroker          []BrokerReconciliation `json:"by_broker"`
+}
+
+// BrokerReconciliation compares trade fees with their fee cash flows for one broker.
+type BrokerReconciliation struct {
+	BrokerID          *string `json:"broker_id"`
+	BrokerName        string  `json:"broker_name"`
+	IsReconciled      bool    `json:"is_reconciled"`
+	TotalTradeFees    string  `json:"total_trade_fees"`
+	TotalCashFlowFees string  `json:"total_cash_flow_fees"`
+	Difference        string  `json:"difference"`
 }
 
 // ReconciliationIssue represents a specific reconciliation problem
 type ReconciliationIssue struct {
-	TradeID            string `json:"trade_id"`
-	Ticker             string `json:"ticker"`
-	Date               string `json:"date"`
-	ExpectedFees       string `json:"expected_fees"`
-	ActualCashFlowFees string `json:"actual_cash_flow_fees"`
-	Difference         string `json:"difference"`
-	Description        string `json:"description"`
+	TradeID            string  `json:"trade_id"`
+	Ticker             string  `json:"ticker"`
+	Date               string  `json:"date"`
+	BrokerID           *string `json:"broker_id"`
+	ExpectedFees       string  `json:"expected_fees"`
+	ActualCashFlowFees string  `json:"actual_cash_flow_fees"`
+	Difference         string  `json:"difference"`
+	Description        string  `json:"description"`
 }
 
 // NetWorthSummary provides a complete picture of user's financial position
@@ -320,36 +1143,78 @@ type NetWorthBreakdown struct {
 	ByAssetType map[string]string `json:"by_asset_type"` // stock, etf, crypto
 	ByTicker    map[string]string `json:"by_ticker"`
 	TopHoldings []Holding         `json:"top_holdings"`
+	ByBroker    []BrokerNetWorth  `json:"by_broker"`
+}
+
+// BrokerNetWorth is the slice of the user's net worth held at one broker.
+// BrokerID is nil for trades and cash flows recorded without a broker.
+type BrokerNetWorth struct {
+	BrokerID      *string `json:"broker_id"`
+	BrokerName    string  `json:"broker_name"`
+	HoldingsValue string  `json:"holdings_value"`
+	CashBalance   string  `json:"cash_balance"`
+	NetWorth      string  `json:"net_worth"`
+	TotalInvested string  `json:"total_invested"`
+	TotalFees     string  `json:"total_fees"`
+	XIRR          string  `json:"xirr"`
+	FeeDragPct    string  `json:"fee_drag_pct"` // Fees as % of net invested capital
+}
+
+// BrokerHoldings groups current holdings by the broker the trades were placed with.
+type BrokerHoldings struct {
+	BrokerID    *string   `json:"broker_id"`
+	BrokerName  string    `json:"broker_name"`
+	MarketValue string    `json:"market_value"`
+	Holdings    []Holding `json:"holdings"`
 }
 
 // CreateFxRateRequest for creating a new FX rate
 type CreateFxRateRequest struct {
-	Date   string `json:"date"`
+	Date   string `json:"date" format:"date"`
 	Rate   string `json:"rate"`
-	Source string `json:"source"`
+	Source string `json:"source,omitempty"`
 }
 
 // CreateCashFlowRequest for creating a new cash flow with enhanced fee tracking
 type CreateCashFlowRequest struct {
-	Date              string  `json:"date"`
-	Type              string  `json:"type"`
-	Currency          string  `json:"currency"`
+	Date              string  `json:"date" format:"date"`
+	Type              string  `json:"type" enum:"deposit,withdrawal,fee,cash_adjustment"`
+	Currency          string  `json:"currency,omitempty"`
 	Amount            string  `json:"amount"`
 	FxRate            *string `json:"fx_rate"`
+	PortfolioID       *string `json:"portfolio_id"`
 	BrokerID          *string `json:"broker_id"`
 	Notes             *string `json:"notes"`
 	FeeType           *string `json:"fee_type"`
 	RelatedTradeID    *string `json:"related_trade_id"`
 	RelatedCashFlowID *string `json:"related_cash_flow_id"`
 	RelatedType       *string `json:"related_type"`
+	// AutoFee generates the linked broker fee for a deposit or withdrawal from
+	// the broker's fee schedule in effect on Date.
+	AutoFee bool `json:"auto_fee,omitempty"`
+}
+
+// TransferFeeQuote previews the broker fee applied to a deposit or withdrawal.
+// FeeUSD is the fee rule's amount plus FXSpreadUSD, the schedule's FX spread
+// on the converted amount.
+type TransferFeeQuote struct {
+	Type          string        `json:"type"` // deposit, withdrawal
+	BrokerID      string        `json:"broker_id"`
+	FeeScheduleID *string       `json:"fee_schedule_id"`
+	EffectiveFrom *time.Time    `json:"effective_from"`
+	FeeRule       BrokerFeeRule `json:"fee_rule"`
+	GrossUSD      string        `json:"gross_usd"`
+	FXSpreadUSD   string        `json:"fx_spread_usd"`
+	FeeUSD        string        `json:"fee_usd"`
+	NetUSD        string        `json:"net_usd"`
 }
 
 // CreateTradeRequest for creating a new trade with detailed fees
 type CreateTradeRequest struct {
 	Date              string  `json:"date"`
 	Ticker            string  `json:"ticker"`
-	AssetType         string  `json:"asset_type"`
-	Side              string  `json:"side"`
+	AssetType         string  `json:"asset_type" enum:"stock,etf"`
+	Side              string  `json:"side" enum:"buy,sell"`
 	IsOpeningPosition *bool   `json:"is_opening_position"`
 	Quantity          string  `json:"quantity"`
 	Price             string  `json:"price"`
@@ -357,6 +1222,7 @@ type CreateTradeRequest struct {
 	DepositFee        *string `json:"deposit_fee"`
 	TradingFee        *string `json:"trading_fee"`
 	ClosingFee        *string `json:"closing_fee"`
+	PortfolioID       *string `json:"portfolio_id"`
 	BrokerID          *string `json:"broker_id"`
 	Notes             *string `json:"notes"`
 }
@@ -371,10 +1237,11 @@ type UpdateFxRateRequest struct {
 // UpdateCashFlowRequest for updating a cash flow
 type UpdateCashFlowRequest struct {
 	Date              *string `json:"date"`
-	Type              *string `json:"type"`
+	Type              *string `json:"type" enum:"deposit,withdrawal,fee,cash_adjustment"`
 	Currency          *string `json:"currency"`
 	Amount            *string `json:"amount"`
 	FxRate            *string `json:"fx_rate"`
+	PortfolioID       *string `json:"portfolio_id"`
 	BrokerID          *string `json:"broker_id"`
 	Notes             *string `json:"notes"`
 	FeeType           *string `json:"fee_type"`
@@ -387,8 +1254,8 @@ type UpdateCashFlowRequest struct {
 type UpdateTradeRequest struct {
 	Date              *string `json:"date"`
 	Ticker            *string `json:"ticker"`
-	AssetType         *string `json:"asset_type"`
-	Side              *string `json:"side"`
+	AssetType         *string `json:"asset_type" enum:"stock,etf"`
+	Side              *string `json:"side" enum:"buy,sell"`
 	IsOpeningPosition *bool   `json:"is_opening_position"`
 	Quantity          *string `json:"quantity"`
 	Price             *string `json:"price"`
@@ -396,6 +1263,7 @@ type UpdateTradeRequest struct {
 	DepositFee        *string `json:"deposit_fee"`
 	TradingFee        *string `json:"trading_fee"`
 	ClosingFee        *string `json:"closing_fee"`
+	PortfolioID       *string `json:"portfolio_id"`
 	BrokerID          *string `json:"broker_id"`
 	Notes             *string `json:"notes"`
 }
@@ -404,7 +1272,7 @@ type UpdateTradeRequest struct {
 type ActivityItem struct {
 	ID        string    `json:"id"`
 	Date      time.Time `json:"date"`
-	Kind      string    `json:"kind"`       // "trade" | "deposit" | "withdrawal" | "fee" | "cash_adjustment"
+	Kind      string    `json:"kind"`       // "trade" | "deposit" | "withdrawal" | "fee" | "cash_adjustment" | "transfer_in" | "transfer_out"
 	SubKind   string    `json:"sub_kind"`   // trade: "buy"/"sell"; fee: fee_type; deposit/withdrawal/cash_adjustment: ""
 	Ticker    string    `json:"ticker"`     // only for trades
 	Direction string    `json:"direction"`  // "in" (deposit/buy/credit) or "out" (withdrawal/sell/fee)
@@ -412,6 +1280,51 @@ type ActivityItem struct {
 	Details   string    `json:"details"`    // human-readable summary line
 }
 
+// ActivityEntry is one entry of the activity timeline (GET /api/v2/activity).
+// Details are structured so clients can format and localize them. A trade
+// carries the fee cash flows recorded against it, and a deposit or
+// withdrawal its transfer fee, in Fees; those fees are not entries of their
+// own.
+type ActivityEntry struct {
+	ID          string            `json:"id"`
+	Date        time.Time         `json:"date"`
+	CreatedAt   time.Time         `json:"created_at"`
+	Kind        string            `json:"kind" enum:"trade,deposit,withdrawal,fee,cash_adjustment,transfer_in,transfer_out"`
+	Direction   string            `json:"direction" enum:"in,out"` // buys, withdrawals, fees and transfers out are "out"
+	AmountUSD   string            `json:"amount_usd"`              // absolute USD amount, fees excluded
+	FeesUSD     string            `json:"fees_usd"`                // sum of Fees
+	PortfolioID string            `json:"portfolio_id"`
+	BrokerID    *string           `json:"broker_id"`
+	Trade       *ActivityTrade    `json:"trade,omitempty"`     // set when Kind is trade
+	CashFlow    *ActivityCashFlow `json:"cash_flow,omitempty"` // set for every other kind
+	Fees        []ActivityFee     `json:"fees"`
+}
+
+// ActivityTrade is the trade of an ActivityEntry.
+type ActivityTrade struct {
+	Side      string `json:"side" enum:"buy,sell"`
+	Ticker    string `json:"ticker"`
+	AssetType string `json:"asset_type"`
+	Quantity  string `json:"quantity"`
+	Price     string `json:"price"`
+}
+
+// ActivityCashFlow is the cash flow of an ActivityEntry.
+type ActivityCashFlow struct {
+	Currency  string  `json:"currency" enum:"USD,COP"`
+	Amount    string  `json:"amount"` // in Currency, signed as recorded
+	UsdAmount string  `json:"usd_amount"`
+	FxRate    *string `json:"fx_rate"`
+	FeeType   *string `json:"fee_type"` // fees only
+}
+
+// ActivityFee is a fee grouped under the ActivityEntry it was charged for.
+type ActivityFee struct {
+	ID        string `json:"id"`
+	FeeType   string `json:"fee_type"`
+	AmountUSD string `json:"amount_usd"`
+}
+
 // AnalyticsQuery represents a request for analytics with date range filtering
 type AnalyticsQuery struct {
 	StartDate *string `json:"start_date"`
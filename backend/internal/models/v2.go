@@ -0,0 +1,138 @@
+package models
+
+// /api/v2 uses snake_case for every JSON field. The types below replace the
+// v1 types that use camelCase (Holding) or embed one (NetWorthSummary and the
+// views built on it); V2 converts a v1 value.
+
+// HoldingV2 is a current holding as returned by /api/v2.
+type HoldingV2 struct {
+	Ticker                string  `json:"ticker"`
+	AssetType             string  `json:"asset_type"`
+	Quantity              string  `json:"quantity"`
+	AvgCost               string  `json:"avg_cost"`                 // pure price average (no fees)
+	AvgCostWithFees       string  `json:"avg_cost_with_fees"`       // includes pro-rated fees
+	TotalInvested         string  `json:"total_invested"`           // pure capital invested (no fees)
+	TotalInvestedWithFees string  `json:"total_invested_with_fees"` // includes pro-rated fees
+	TotalFees             string  `json:"total_fees"`
+	MarketValue           string  `json:"market_value"`
+	UnrealizedPL          string  `json:"unrealized_pl"`
+	UnrealizedPLPct       string  `json:"unrealized_pl_pct"`
+	FeeImpactPct          string  `json:"fee_impact_pct"` // fees as % of pure invested capital
+	PriceAsOf             *string `json:"price_as_of"`
+}
+
+// V2 returns h with /api/v2 field names. The deprecated avgCostWithoutFees
+// is dropped; it always equals AvgCost.
+func (h Holding) V2() HoldingV2 {
+	return HoldingV2{
+		Ticker:                h.Ticker,
+		AssetType:             h.AssetType,
+		Quantity:              h.Quantity,
+		AvgCost:               h.AvgCost,
+		AvgCostWithFees:       h.AvgCostWithFees,
+		TotalInvested:         h.TotalInvested,
+		TotalInvestedWithFees: h.TotalInvestedWithFees,
+		TotalFees:             h.TotalFees,
+		MarketValue:           h.MarketValue,
+		UnrealizedPL:          h.UnrealizedPL,
+		UnrealizedPLPct:       h.UnrealizedPLPercent,
+		FeeImpactPct:          h.FeeImpactPercent,
+		PriceAsOf:             h.PriceAsOf,
+	}
+}
+
+// HoldingsV2 converts a list of holdings.
+func HoldingsV2(holdings []Holding) []HoldingV2 {
+	out := make([]HoldingV2, len(holdings))
+	for i, h := range holdings {
+		out[i] = h.V2()
+	}
+	return out
+}
+
+// BrokerHoldingsV2 groups current holdings by broker for /api/v2.
+type BrokerHoldingsV2 struct {
+	BrokerID    *string     `json:"broker_id"`
+	BrokerName  string      `json:"broker_name"`
+	MarketValue string      `json:"market_value"`
+	Holdings    []HoldingV2 `json:"holdings"`
+}
+
+// V2 returns b with /api/v2 holdings.
+func (b BrokerHoldings) V2() BrokerHoldingsV2 {
+	return BrokerHoldingsV2{
+		BrokerID:    b.BrokerID,
+		BrokerName:  b.BrokerName,
+		MarketValue: b.MarketValue,
+		Holdings:    HoldingsV2(b.Holdings),
+	}
+}
+
+// NetWorthSummaryV2 is NetWorthSummary for /api/v2.
+type NetWorthSummaryV2 struct {
+	HoldingsValue     string              `json:"holdings_value"`
+	CashBalance       string              `json:"cash_balance"`
+	NetWorth          string              `json:"net_worth"`
+	TotalInvested     string              `json:"total_invested"`
+	TotalFees         string              `json:"total_fees"`
+	TotalGainLoss     string              `json:"total_gain_loss"`
+	TotalGainLossPct  string              `json:"total_gain_loss_pct"`
+	XIRR              string              `json:"xirr"`
+	TotalDepositedCOP string              `json:"total_deposited_cop"`
+	TotalWithdrawnCOP string              `json:"total_withdrawn_cop"`
+	Breakdown         NetWorthBreakdownV2 `json:"breakdown"`
+}
+
+// NetWorthBreakdownV2 is NetWorthBreakdown for /api/v2.
+type NetWorthBreakdownV2 struct {
+	ByAssetType map[string]string `json:"by_asset_type"`
+	ByTicker    map[string]string `json:"by_ticker"`
+	TopHoldings []HoldingV2       `json:"top_holdings"`
+	ByBroker    []BrokerNetWorth  `json:"by_broker"`
+}
+
+// V2 returns s with /api/v2 holdings.
+func (s NetWorthSummary) V2() NetWorthSummaryV2 {
+	return NetWorthSummaryV2{
+		HoldingsValue:     s.HoldingsValue,
+		CashBalance:       s.CashBalance,
+		NetWorth:          s.NetWorth,
+		TotalInvested:     s.TotalInvested,
+		TotalFees:         s.TotalFees,
+		TotalGainLoss:     s.TotalGainLoss,
+		TotalGainLossPct:  s.TotalGainLossPct,
+		XIRR:              s.XIRR,
+		TotalDepositedCOP: s.TotalDepositedCOP,
+		TotalWithdrawnCOP: s.TotalWithdrawnCOP,
+		Breakdown: NetWorthBreakdownV2{
+			ByAssetType: s.Breakdown.ByAssetType,
+			ByTicker:    s.Breakdown.ByTicker,
+			TopHoldings: HoldingsV2(s.Breakdown.TopHoldings),
+			ByBroker:    s.Breakdown.ByBroker,
+		},
+	}
+}
+
+// ConsolidatedPortfolioViewV2 is ConsolidatedPortfolioView for /api/v2.
+type ConsolidatedPortfolioViewV2 struct {
+	Consolidated NetWorthSummaryV2     `json:"consolidated"`
+	Portfolios   []PortfolioNetWorthV2 `json:"portfolios"`
+}
+
+// PortfolioNetWorthV2 is PortfolioNetWorth for /api/v2.
+type PortfolioNetWorthV2 struct {
+	Portfolio Portfolio         `json:"portfolio"`
+	Summary   NetWorthSummaryV2 `json:"summary"`
+}
+
+// V2 returns v with /api/v2 summaries.
+func (v ConsolidatedPortfolioView) V2() ConsolidatedPortfolioViewV2 {
+	out := ConsolidatedPortfolioViewV2{
+		Consolidated: v.Consolidated.V2(),
+		Portfolios:   make([]PortfolioNetWorthV2, len(v.Portfolios)),
+	}
+	for i, p := range v.Portfolios {
+		out.Portfolios[i] = PortfolioNetWorthV2{Portfolio: p.Portfolio, Summary: p.Summary.V2()}
+	}
+	return out
+}
@@ -0,0 +1,246 @@
+// Package openapi builds the API's OpenAPI 3.1 document from the Go models
+// and the routes that serve them, and validates requests against it.
+//
+// Routes are registered through a Router, which records an operation for
+// every Fiber route, so the document cannot list a route the server does not
+// serve or miss one it does. Schemas are reflected from the request and
+// response types, so a model change is a spec change.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// Version is the OpenAPI version of the generated document.
+const Version = "3.1.0"
+
+// bearerAuth is the security scheme of authenticated operations: a Supabase
+// session JWT or a personal API token.
+const bearerAuth = "bearerAuth"
+
+// Document is an OpenAPI 3.1 document.
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Paths      map[string]*PathItem  `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []SecurityRequirement `json:"security"`
+
+	types  map[string]reflect.Type
+	routes []*route
+
+	// compiler holds the compiled document; compileMu guards its lazily
+	// compiled response schemas.
+	compiler  *jsonschema.Compiler
+	compileMu sync.Mutex
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations of one path, keyed by lowercase method.
+type PathItem map[string]*Operation
+
+// Operation is one method on one path.
+type Operation struct {
+	OperationID string                 `json:"operationId"`
+	Summary     string                 `json:"summary,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+	Parameters  []*Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody           `json:"requestBody,omitempty"`
+	Responses   map[string]*Response   `json:"responses"`
+	Security    *[]SecurityRequirement `json:"security,omitempty"`
+}
+
+// Parameter is a path, query or header parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is a JSON request body.
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response is one response of an operation. Content is empty for 204.
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the reusable schemas and security schemes.
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+// SecurityScheme describes how requests authenticate.
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// SecurityRequirement names the schemes an operation accepts.
+type SecurityRequirement map[string][]string
+
+// NewDocument creates an empty document. Operations require bearer
+// authentication unless registered as public.
+func NewDocument(info Info) *Document {
+	d := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   map[string]*PathItem{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]*SecurityScheme{
+				bearerAuth: {
+					Type:        "http",
+					Scheme:      "bearer",
+					Description: "A Supabase session JWT, or a personal API token (fintu_...) limited to its scopes.",
+				},
+			},
+		},
+		Security: []SecurityRequirement{{bearerAuth: {}}},
+		types:    map[string]reflect.Type{},
+	}
+	// Every error response has the same body.
+	d.schemaFor(reflect.TypeFor[models.ErrorResponse]())
+	return d
+}
+
+// JSON returns the indented document.
+func (d *Document) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// Operation returns the operation for a method and an OpenAPI path template
+// such as /api/trades/{id}, or nil.
+func (d *Document) Operation(method, path string) *Operation {
+	item, ok := d.Paths[path]
+	if !ok {
+		return nil
+	}
+	return (*item)[strings.ToLower(method)]
+}
+
+// fiberParam matches Fiber path parameters such as :id.
+var fiberParam = regexp.MustCompile(`:(\w+)`)
+
+// PathTemplate converts a Fiber route path to an OpenAPI path template.
+func PathTemplate(fiberPath string) string {
+	return fiberParam.ReplaceAllString(fiberPath, "{$1}")
+}
+
+// addOperation records op as method on the Fiber route path.
+func (d *Document) addOperation(method, fiberPath string, op *Op) *route {
+	path := PathTemplate(fiberPath)
+	item, ok := d.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+	key := strings.ToLower(method)
+	if _, exists := (*item)[key]; exists {
+		panic(fmt.Sprintf("openapi: %s %s is registered twice", method, path))
+	}
+	for _, r := range d.routes {
+		if r.operation.OperationID == op.id {
+			panic(fmt.Sprintf("openapi: operation id %q is used by %s %s and %s %s", op.id, r.method, r.path, method, path))
+		}
+	}
+
+	operation := &Operation{
+		OperationID: op.id,
+		Summary:     op.summary,
+		Description: op.description,
+		Tags:        op.tags,
+		Responses:   map[string]*Response{},
+	}
+	if op.public {
+		operation.Security = &[]SecurityRequirement{}
+	}
+	for _, name := range fiberParam.FindAllStringSubmatch(fiberPath, -1) {
+		operation.Parameters = append(operation.Parameters, &Parameter{
+			Name: name[1], In: "path", Required: true, Schema: String(),
+		})
+	}
+	for _, p := range op.query {
+		operation.Parameters = append(operation.Parameters, &Parameter{
+			Name: p.Name, In: "query", Description: p.Description, Required: p.Required, Schema: p.Schema,
+		})
+	}
+	if op.idempotent {
+		operation.Parameters = append(operation.Parameters, &Parameter{
+			Name:        "Idempotency-Key",
+			In:          "header",
+			Description: "Replays the first response for retries with the same key for 24 hours.",
+			Schema:      String(),
+		})
+	}
+	if op.body != nil {
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: d.schemaFor(reflect.TypeOf(op.body))}},
+		}
+	}
+	for _, resp := range op.responses {
+		operation.Responses[fmt.Sprint(resp.status)] = d.response(resp)
+	}
+	operation.Responses["default"] = &Response{
+		Description: "Error",
+		Content:     jsonContent(&Schema{Ref: "#/components/schemas/ErrorResponse"}),
+	}
+	(*item)[key] = operation
+
+	r := &route{method: method, path: path, operation: operation}
+	d.routes = append(d.routes, r)
+	return r
+}
+
+func (d *Document) response(resp response) *Response {
+	out := &Response{Description: http.StatusText(resp.status)}
+	switch {
+	case resp.contentType != "":
+		out.Content = map[string]*MediaType{resp.contentType: {Schema: String()}}
+	case len(resp.bodies) == 1:
+		out.Content = jsonContent(d.schemaFor(reflect.TypeOf(resp.bodies[0])))
+	case len(resp.bodies) > 1:
+		schema := &Schema{}
+		for _, body := range resp.bodies {
+			schema.OneOf = append(schema.OneOf, d.schemaFor(reflect.TypeOf(body)))
+		}
+		out.Content = jsonContent(schema)
+	}
+	return out
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
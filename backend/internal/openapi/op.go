@@ -0,0 +1,110 @@
+package openapi
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"unicode"
+)
+
+// Op describes a route for the document: its summary, parameters, request
+// body and responses. Build one with NewOp and pass it to a Router method.
+type Op struct {
+	id          string
+	summary     string
+	description string
+	tags        []string
+	query       []Param
+	body        any
+	responses   []response
+	idempotent  bool
+	public      bool
+}
+
+type response struct {
+	status      int
+	bodies      []any
+	contentType string
+}
+
+// Param is a query parameter.
+type Param struct {
+	Name        string
+	Description string
+	Required    bool
+	Schema      *Schema
+}
+
+// QueryParam returns an optional query parameter.
+func QueryParam(name string, schema *Schema, description string) Param {
+	return Param{Name: name, Schema: schema, Description: description}
+}
+
+// NewOp starts an operation under tag. The operation id defaults to the
+// handler's function name, so handlers.CreateTrade becomes createTrade.
+func NewOp(tag, summary string) *Op {
+	return &Op{tags: []string{tag}, summary: summary}
+}
+
+// ID overrides the operation id, for handlers served on more than one route.
+func (o *Op) ID(id string) *Op {
+	o.id = id
+	return o
+}
+
+// Describe sets the operation description.
+func (o *Op) Describe(description string) *Op {
+	o.description = description
+	return o
+}
+
+// Query adds query parameters.
+func (o *Op) Query(params ...Param) *Op {
+	o.query = append(o.query, params...)
+	return o
+}
+
+// Body sets the JSON request body to the type of v, such as
+// models.CreateTradeRequest{}.
+func (o *Op) Body(v any) *Op {
+	o.body = v
+	return o
+}
+
+// Returns adds a JSON response with the type of body. Several bodies mean
+// the response is one of them; none means the response has no content.
+func (o *Op) Returns(status int, bodies ...any) *Op {
+	o.responses = append(o.responses, response{status: status, bodies: bodies})
+	return o
+}
+
+// Streams adds a non-JSON response, such as text/event-stream.
+func (o *Op) Streams(status int, contentType string) *Op {
+	o.responses = append(o.responses, response{status: status, contentType: contentType})
+	return o
+}
+
+// Idempotent documents the Idempotency-Key header.
+func (o *Op) Idempotent() *Op {
+	o.idempotent = true
+	return o
+}
+
+// Public marks the operation as not requiring authentication.
+func (o *Op) Public() *Op {
+	o.public = true
+	return o
+}
+
+// handlerID derives an operation id from a handler function's name.
+func handlerID(handler any) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(handler).Pointer())
+	if fn == nil {
+		return ""
+	}
+	name := fn.Name()
+	name = name[strings.LastIndex(name, ".")+1:]
+	runes := []rune(name)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
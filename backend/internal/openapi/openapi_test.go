@@ -0,0 +1,201 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"fintu-tracking-backend/internal/middleware"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type testBase struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type testItem struct {
+	testBase
+	Name  string   `json:"name"`
+	Side  string   `json:"side" enum:"buy,sell"`
+	Date  string   `json:"date" format:"date"`
+	Note  *string  `json:"note"`
+	Kind  *string  `json:"kind,omitempty" enum:"a,b"`
+	Tags  []string `json:"tags,omitempty"`
+	Count int      `json:"count,omitzero"`
+	skip  string
+}
+
+func TestStructSchema(t *testing.T) {
+	d := NewDocument(Info{Title: "test", Version: "1"})
+	ref := d.schemaFor(reflect.TypeFor[testItem]())
+	if ref.Ref != "#/components/schemas/testItem" {
+		t.Fatalf("ref = %q", ref.Ref)
+	}
+	s := d.Components.Schemas["testItem"]
+
+	wantRequired := []string{"id", "created_at", "name", "side", "date"}
+	if !slices.Equal(s.Required, wantRequired) {
+		t.Errorf("required = %v, want %v", s.Required, wantRequired)
+	}
+	if _, ok := s.Properties["skip"]; ok {
+		t.Error("unexported field is documented")
+	}
+	if got := s.Properties["created_at"].Format; got != "date-time" {
+		t.Errorf("created_at format = %q", got)
+	}
+	if got := s.Properties["side"].Enum; !slices.Equal(got, []string{"buy", "sell"}) {
+		t.Errorf("side enum = %v", got)
+	}
+	if got := s.Properties["date"].Format; got != "date" {
+		t.Errorf("date format = %q", got)
+	}
+	if got, ok := s.Properties["note"].Type.([]string); !ok || !slices.Equal(got, []string{"string", "null"}) {
+		t.Errorf("note type = %v", s.Properties["note"].Type)
+	}
+	// A nullable enum cannot list null among its values, so it uses anyOf.
+	if kind := s.Properties["kind"]; len(kind.AnyOf) != 2 || !slices.Equal(kind.AnyOf[0].Enum, []string{"a", "b"}) {
+		t.Errorf("kind = %+v", kind)
+	}
+}
+
+func TestComponentNames(t *testing.T) {
+	d := NewDocument(Info{Title: "test", Version: "1"})
+	ref := d.schemaFor(reflect.TypeFor[models.PaginatedResponse[models.Trade]]())
+	if ref.Ref != "#/components/schemas/PaginatedResponseTrade" {
+		t.Errorf("ref = %q", ref.Ref)
+	}
+	if _, ok := d.Components.Schemas["Trade"]; !ok {
+		t.Error("Trade is not a component")
+	}
+	if ref := d.schemaFor(reflect.TypeFor[models.Page[string]]()); ref.Ref != "#/components/schemas/PageString" {
+		t.Errorf("ref = %q", ref.Ref)
+	}
+}
+
+func newValidatedApp(t *testing.T) (*fiber.App, *Document) {
+	t.Helper()
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	app.Use(middleware.Locale())
+	doc := NewDocument(Info{Title: "test", Version: "1"})
+	r := NewRouter(doc, app)
+	ok := func(c fiber.Ctx) error { return c.JSON(testItem{Name: "ok", Side: "buy", Date: "2024-01-02"}) }
+	r.Post("/items", NewOp("Items", "Create").ID("createItem").
+		Body(testItem{}).Returns(http.StatusCreated, testItem{}), ok)
+	r.Get("/items", NewOp("Items", "List").ID("listItems").
+		Query(QueryParam("limit", Integer(1), ""), QueryParam("amount", Decimal(), "")).
+		Returns(http.StatusOK, []testItem{}), ok)
+	if err := doc.Compile(); err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	return app, doc
+}
+
+func TestValidate(t *testing.T) {
+	app, _ := newValidatedApp(t)
+
+	valid := `{"id":"1","created_at":"2024-01-02T00:00:00Z","name":"x","side":"buy","date":"2024-01-02","note":null}`
+	tests := []struct {
+		name, method, target, body string
+		status                     int
+		want                       string
+	}{
+		{"valid body", http.MethodPost, "/items", valid, http.StatusOK, ""},
+		{"bad enum", http.MethodPost, "/items", strings.Replace(valid, `"buy"`, `"hold"`, 1), http.StatusBadRequest, "side:"},
+		{"bad date", http.MethodPost, "/items", strings.Replace(valid, `"2024-01-02"`, `"2024-13-02"`, 1), http.StatusBadRequest, "date:"},
+		{"missing field", http.MethodPost, "/items", `{}`, http.StatusBadRequest, "missing properties"},
+		{"valid query", http.MethodGet, "/items?limit=5&amount=1.5", "", http.StatusOK, ""},
+		{"integer query", http.MethodGet, "/items?limit=x", "", http.StatusBadRequest, "limit must be an integer"},
+		{"query minimum", http.MethodGet, "/items?limit=0", "", http.StatusBadRequest, "limit:"},
+		{"decimal query", http.MethodGet, "/items?amount=abc", "", http.StatusBadRequest, "amount:"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body)))
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tc.status {
+				t.Fatalf("status = %d, want %d; body %s", resp.StatusCode, tc.status, body)
+			}
+			if tc.want == "" {
+				return
+			}
+			var got models.ErrorResponse
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !strings.Contains(got.Error, tc.want) {
+				t.Errorf("error = %q, want it to contain %q", got.Error, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateLocalized(t *testing.T) {
+	app, _ := newValidatedApp(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/items?limit=0&amount=abc", nil)
+	req.Header.Set("Accept-Language", "es-CO")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	var got models.ErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if want := "limit: mínimo: se recibió 0, se esperaba 1; amount: "; !strings.HasPrefix(got.Error, want) {
+		t.Errorf("error = %q, want it to start with %q", got.Error, want)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/items?limit=x", nil)
+	req.Header.Set("Accept-Language", "es-CO")
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Error != "limit debe ser un número entero" {
+		t.Errorf("error = %q", got.Error)
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	_, doc := newValidatedApp(t)
+
+	if err := doc.ValidateResponse(http.MethodGet, "/items", http.StatusOK, []byte(`[]`)); err != nil {
+		t.Errorf("empty list: %v", err)
+	}
+	if err := doc.ValidateResponse(http.MethodGet, "/items", http.StatusOK, []byte(`[{"name":"x"}]`)); err == nil {
+		t.Error("item without required fields was accepted")
+	}
+	if err := doc.ValidateResponse(http.MethodGet, "/items", http.StatusNotFound, []byte(`{"error":"x","code":"not_found"}`)); err != nil {
+		t.Errorf("error response: %v", err)
+	}
+}
+
+func TestDuplicateRoutePanics(t *testing.T) {
+	doc := NewDocument(Info{Title: "test", Version: "1"})
+	r := NewRouter(doc, fiber.New())
+	handler := func(c fiber.Ctx) error { return nil }
+	r.Get("/a", NewOp("A", "A").ID("a").Returns(http.StatusNoContent), handler)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("duplicate operation id did not panic")
+		}
+	}()
+	r.Get("/b", NewOp("A", "B").ID("a").Returns(http.StatusNoContent), handler)
+}
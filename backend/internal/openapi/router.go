@@ -0,0 +1,88 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// Router registers Fiber routes and records each one in a Document. Every
+// route with parameters or a body also gets a validation handler that runs
+// before its own handlers.
+type Router struct {
+	doc    *Document
+	router fiber.Router
+	prefix string
+}
+
+// NewRouter returns a Router that registers routes on r.
+func NewRouter(doc *Document, r fiber.Router) *Router {
+	return &Router{doc: doc, router: r}
+}
+
+// Group returns a Router for a path prefix with group middleware.
+func (r *Router) Group(prefix string, handlers ...any) *Router {
+	return &Router{doc: r.doc, router: r.router.Group(prefix, handlers...), prefix: r.prefix + prefix}
+}
+
+// Get registers a GET route.
+func (r *Router) Get(path string, op *Op, handlers ...any) {
+	r.add(http.MethodGet, path, op, handlers)
+}
+
+// Post registers a POST route.
+func (r *Router) Post(path string, op *Op, handlers ...any) {
+	r.add(http.MethodPost, path, op, handlers)
+}
+
+// Put registers a PUT route.
+func (r *Router) Put(path string, op *Op, handlers ...any) {
+	r.add(http.MethodPut, path, op, handlers)
+}
+
+// Patch registers a PATCH route.
+func (r *Router) Patch(path string, op *Op, handlers ...any) {
+	r.add(http.MethodPatch, path, op, handlers)
+}
+
+// Delete registers a DELETE route.
+func (r *Router) Delete(path string, op *Op, handlers ...any) {
+	r.add(http.MethodDelete, path, op, handlers)
+}
+
+func (r *Router) add(method, path string, op *Op, handlers []any) {
+	if len(handlers) == 0 {
+		panic(fmt.Sprintf("openapi: %s %s has no handler", method, path))
+	}
+	if op.id == "" {
+		op.id = handlerID(handlers[len(handlers)-1])
+	}
+	if op.id == "" || strings.Contains(op.id, "func") {
+		panic(fmt.Sprintf("openapi: %s %s needs an explicit operation id", method, path))
+	}
+	if len(op.responses) == 0 {
+		panic(fmt.Sprintf("openapi: %s %s documents no response", method, path))
+	}
+
+	route := r.doc.addOperation(method, r.prefix+path, op)
+	if route.validates() {
+		handlers = append([]any{route.validate}, handlers...)
+	}
+	r.router.Add([]string{method}, path, handlers[0], handlers[1:]...)
+}
+
+// Handler serves the document as JSON. The document must be complete.
+func (d *Document) Handler() fiber.Handler {
+	body := sync.OnceValues(d.JSON)
+	return func(c fiber.Ctx) error {
+		raw, err := body()
+		if err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+		return c.Send(raw)
+	}
+}
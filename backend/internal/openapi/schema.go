@@ -0,0 +1,222 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON Schema (draft 2020-12), the schema dialect of OpenAPI 3.1.
+// Type is a string, or a []string when the value is nullable.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Maximum              *int               `json:"maximum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+}
+
+// Scalar schema constructors for parameters and hand-written schemas.
+
+// String returns a string schema.
+func String() *Schema { return &Schema{Type: "string"} }
+
+// Date returns a YYYY-MM-DD string schema.
+func Date() *Schema { return &Schema{Type: "string", Format: "date"} }
+
+// Decimal returns a schema for a decimal number sent as a string, as every
+// amount and rate in the API is.
+func Decimal() *Schema { return &Schema{Type: "string", Format: "decimal"} }
+
+// Integer returns an integer schema, optionally bounded below.
+func Integer(minimum ...int) *Schema {
+	s := &Schema{Type: "integer"}
+	if len(minimum) > 0 {
+		s.Minimum = &minimum[0]
+	}
+	return s
+}
+
+// IntegerBetween returns an integer schema bounded on both sides.
+func IntegerBetween(minimum, maximum int) *Schema {
+	return &Schema{Type: "integer", Minimum: &minimum, Maximum: &maximum}
+}
+
+// Boolean returns a boolean schema.
+func Boolean() *Schema { return &Schema{Type: "boolean"} }
+
+// Enum returns a string schema limited to values.
+func Enum(values ...string) *Schema { return &Schema{Type: "string", Enum: values} }
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+	marshalerType  = reflect.TypeFor[json.Marshaler]()
+)
+
+// componentName turns a Go type name into a component name. Generic
+// instantiations such as PaginatedResponse[pkg/models.Trade] become
+// PaginatedResponseTrade, and Page[string] becomes PageString.
+var (
+	typeArgPackage = regexp.MustCompile(`[\w./-]+\.`)
+	typeArgStart   = regexp.MustCompile(`[\[,]\*?[a-z]`)
+)
+
+func componentName(t reflect.Type) string {
+	name := typeArgPackage.ReplaceAllString(t.Name(), "")
+	name = typeArgStart.ReplaceAllStringFunc(name, strings.ToUpper)
+	return strings.NewReplacer("[", "", "]", "", ",", "", "*", "").Replace(name)
+}
+
+// schemaFor returns the schema of a Go type. Named structs are added to the
+// document components and referenced.
+func (d *Document) schemaFor(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Pointer {
+		return nullable(d.schemaFor(t.Elem()))
+	}
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &Schema{}
+	}
+	// Types with their own JSON encoding (decimal.Decimal, ...) are strings.
+	if t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) {
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: d.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaFor(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.structSchema(t)
+		}
+		return d.component(t)
+	}
+	panic(fmt.Sprintf("openapi: unsupported type %s", t))
+}
+
+// component registers a named struct under components/schemas and returns a
+// reference to it. Two different Go types may not share a name.
+func (d *Document) component(t reflect.Type) *Schema {
+	name := componentName(t)
+	ref := &Schema{Ref: "#/components/schemas/" + name}
+	if existing, ok := d.types[name]; ok {
+		if existing != t {
+			panic(fmt.Sprintf("openapi: %s and %s are both named %s", existing, t, name))
+		}
+		return ref
+	}
+	d.types[name] = t
+	// Register before building the schema so recursive types terminate.
+	d.Components.Schemas[name] = &Schema{}
+	*d.Components.Schemas[name] = *d.structSchema(t)
+	return ref
+}
+
+// structSchema builds an object schema from the struct's JSON fields.
+// Fields without omitempty are always encoded, so they are required; request
+// types mark the fields the server defaults with omitempty. Pointer fields
+// are nullable. Embedded structs are flattened as encoding/json does.
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() && !f.Anonymous {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded := d.structSchema(ft)
+				for prop, schema := range embedded.Properties {
+					s.Properties[prop] = schema
+				}
+				s.Required = append(s.Required, embedded.Required...)
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		// The enum and format tags document request fields the handlers
+		// check; they apply to the value, not to a null pointer.
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		prop := d.schemaFor(ft)
+		if strings.Contains(opts, "string") {
+			prop = &Schema{Type: "string"}
+		}
+		if enum := f.Tag.Get("enum"); enum != "" {
+			prop.Enum = strings.Split(enum, ",")
+		}
+		if format := f.Tag.Get("format"); format != "" {
+			prop.Format = format
+		}
+		if f.Type.Kind() == reflect.Pointer {
+			prop = nullable(prop)
+		}
+		s.Properties[name] = prop
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") && f.Type.Kind() != reflect.Pointer {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+// nullable allows null in addition to s.
+func nullable(s *Schema) *Schema {
+	switch typ := s.Type.(type) {
+	case string:
+		if len(s.Enum) > 0 {
+			break // enum would reject null
+		}
+		n := *s
+		n.Type = []string{typ, "null"}
+		return &n
+	case nil:
+		if s.Ref == "" && len(s.AnyOf) == 0 {
+			return s // already accepts anything
+		}
+	}
+	return &Schema{AnyOf: []*Schema{s, {Type: "null"}}}
+}
@@ -0,0 +1,231 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// TypeScript renders the component schemas as TypeScript types and every
+// JSON operation as a function on the frontend ApiClient, so the frontend
+// can call the API without hand-written request and response types.
+func (d *Document) TypeScript() []byte {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by go run ./cmd/openapi. DO NOT EDIT.\n")
+	fmt.Fprintf(&b, "// Types and client functions for every operation of %s %s.\n\n", d.Info.Title, d.Info.Version)
+	b.WriteString("import { apiClient, type RequestOptions } from \"./client\"\n")
+
+	names := make([]string, 0, len(d.Components.Schemas))
+	for name := range d.Components.Schemas {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		schema := d.Components.Schemas[name]
+		b.WriteString("\n")
+		if schema.Properties != nil {
+			fmt.Fprintf(&b, "export interface %s %s\n", name, tsObject(schema, ""))
+		} else {
+			fmt.Fprintf(&b, "export type %s = %s\n", name, tsType(schema))
+		}
+	}
+
+	b.WriteString(`
+type QueryValue = string | number | boolean | null | undefined
+
+function toQuery(query?: Record<string, QueryValue>): string {
+  const search = new URLSearchParams()
+  for (const [key, value] of Object.entries(query ?? {})) {
+    if (value !== undefined && value !== null && value !== "") search.set(key, String(value))
+  }
+  const encoded = search.toString()
+  return encoded ? ` + "`?${encoded}`" + ` : ""
+}
+`)
+	for _, r := range d.routes {
+		d.writeClientFunction(&b, r)
+	}
+	return b.Bytes()
+}
+
+func (d *Document) writeClientFunction(b *bytes.Buffer, r *route) {
+	op := r.operation
+	result, ok := tsResult(op)
+	if !ok {
+		return // not JSON, such as an event stream
+	}
+	name := op.OperationID
+	queryType := strings.ToUpper(name[:1]) + name[1:] + "Query"
+
+	var args, query []string
+	var hasQuery, idempotent bool
+	for _, p := range op.Parameters {
+		switch p.In {
+		case "path":
+			args = append(args, p.Name+": string")
+		case "query":
+			hasQuery = true
+			query = append(query, fmt.Sprintf("  %s?: %s", tsProperty(p.Name), tsType(p.Schema)))
+		case "header":
+			idempotent = idempotent || p.Name == "Idempotency-Key"
+		}
+	}
+	if op.RequestBody != nil {
+		args = append(args, "body: "+tsType(op.RequestBody.Content["application/json"].Schema))
+	}
+	if hasQuery {
+		// A type alias rather than an interface, so it is assignable to the
+		// Record that toQuery takes.
+		fmt.Fprintf(b, "\nexport type %s = {\n%s\n}\n", queryType, strings.Join(query, "\n"))
+		args = append(args, "query?: "+queryType)
+	}
+	if idempotent {
+		args = append(args, "options?: RequestOptions")
+	}
+
+	path := "`" + fiberParam.ReplaceAllString(strings.NewReplacer("{", ":", "}", "").Replace(r.path), "$${encodeURIComponent($1)}")
+	if hasQuery {
+		path += "${toQuery(query)}"
+	}
+	path += "`"
+	call := []string{fmt.Sprintf("%q", r.method), path}
+	switch {
+	case op.RequestBody != nil:
+		call = append(call, "body")
+	case idempotent:
+		call = append(call, "undefined")
+	}
+	if idempotent {
+		call = append(call, "options")
+	}
+
+	b.WriteString("\n")
+	if op.Summary != "" {
+		fmt.Fprintf(b, "/** %s */\n", op.Summary)
+	}
+	fmt.Fprintf(b, "export function %s(%s): Promise<%s> {\n", name, strings.Join(args, ", "), result)
+	fmt.Fprintf(b, "  return apiClient.send<%s>(%s)\n}\n", result, strings.Join(call, ", "))
+}
+
+// tsResult is the TypeScript type of an operation's successful responses.
+func tsResult(op *Operation) (string, bool) {
+	var types []string
+	for status, resp := range op.Responses {
+		if !strings.HasPrefix(status, "2") {
+			continue
+		}
+		if len(resp.Content) == 0 {
+			types = append(types, "void")
+			continue
+		}
+		media, ok := resp.Content["application/json"]
+		if !ok {
+			return "", false
+		}
+		types = append(types, tsType(media.Schema))
+	}
+	slices.Sort(types)
+	return strings.Join(slices.Compact(types), " | "), true
+}
+
+func tsType(s *Schema) string {
+	switch {
+	case s.Ref != "":
+		return strings.TrimPrefix(s.Ref, "#/components/schemas/")
+	case len(s.AnyOf) > 0:
+		return tsUnion(s.AnyOf)
+	case len(s.OneOf) > 0:
+		return tsUnion(s.OneOf)
+	}
+
+	types, ok := s.Type.([]string)
+	if !ok {
+		if typ, isString := s.Type.(string); isString {
+			types = []string{typ}
+		}
+	}
+	var out []string
+	for _, typ := range types {
+		out = append(out, tsScalar(s, typ))
+	}
+	if len(out) == 0 {
+		return "unknown"
+	}
+	return strings.Join(out, " | ")
+}
+
+func tsScalar(s *Schema, typ string) string {
+	switch typ {
+	case "string":
+		if len(s.Enum) > 0 {
+			quoted := make([]string, len(s.Enum))
+			for i, v := range s.Enum {
+				quoted[i] = fmt.Sprintf("%q", v)
+			}
+			return strings.Join(quoted, " | ")
+		}
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "null":
+		return "null"
+	case "array":
+		item := tsType(s.Items)
+		if strings.Contains(item, " ") {
+			item = "(" + item + ")"
+		}
+		return item + "[]"
+	case "object":
+		if s.Properties != nil {
+			return tsObject(s, "")
+		}
+		if s.AdditionalProperties != nil {
+			return "Record<string, " + tsType(s.AdditionalProperties) + ">"
+		}
+		return "Record<string, unknown>"
+	}
+	return "unknown"
+}
+
+func tsUnion(schemas []*Schema) string {
+	out := make([]string, len(schemas))
+	for i, s := range schemas {
+		out[i] = tsType(s)
+	}
+	return strings.Join(out, " | ")
+}
+
+// tsObject renders an object schema's properties; optional properties are
+// the ones the JSON may omit.
+func tsObject(s *Schema, indent string) string {
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, name := range names {
+		optional := "?"
+		if slices.Contains(s.Required, name) {
+			optional = ""
+		}
+		fmt.Fprintf(&b, "%s  %s%s: %s\n", indent, tsProperty(name), optional, tsType(s.Properties[name]))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func tsProperty(name string) string {
+	if tsIdentifier.MatchString(name) {
+		return name
+	}
+	return fmt.Sprintf("%q", name)
+}
@@ -0,0 +1,267 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/i18n"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// documentURL is the URL the document is compiled under; schema locations
+// are JSON pointers into it.
+const documentURL = "openapi.json"
+
+var printer = message.NewPrinter(language.English)
+
+// route is a registered operation and its compiled request schemas.
+type route struct {
+	method    string
+	path      string
+	operation *Operation
+
+	compiled bool
+	query    []queryParam
+	body     *jsonschema.Schema
+}
+
+type queryParam struct {
+	*Parameter
+	schema *jsonschema.Schema
+}
+
+// validates reports whether the route checks anything before its handlers.
+func (r *route) validates() bool {
+	if r.operation.RequestBody != nil {
+		return true
+	}
+	for _, p := range r.operation.Parameters {
+		if p.In == "query" {
+			return true
+		}
+	}
+	return false
+}
+
+// Compile compiles the request schemas of every operation. Call it once
+// after registering every route and before serving requests.
+func (d *Document) Compile() error {
+	raw, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	c := jsonschema.NewCompiler()
+	c.DefaultDraft(jsonschema.Draft2020)
+	c.AssertFormat()
+	c.RegisterFormat(&jsonschema.Format{Name: "decimal", Validate: validateDecimal})
+	if err := c.AddResource(documentURL, doc); err != nil {
+		return err
+	}
+	d.compiler = c
+
+	for _, r := range d.routes {
+		base := "/paths/" + escapePointer(r.path) + "/" + strings.ToLower(r.method)
+		r.query = r.query[:0]
+		for i, p := range r.operation.Parameters {
+			if p.In != "query" {
+				continue
+			}
+			schema, err := c.Compile(fmt.Sprintf("%s#%s/parameters/%d/schema", documentURL, base, i))
+			if err != nil {
+				return fmt.Errorf("%s %s: query parameter %s: %w", r.method, r.path, p.Name, err)
+			}
+			r.query = append(r.query, queryParam{Parameter: p, schema: schema})
+		}
+		if r.operation.RequestBody != nil {
+			r.body, err = c.Compile(documentURL + "#" + base + "/requestBody/content/application~1json/schema")
+			if err != nil {
+				return fmt.Errorf("%s %s: request body: %w", r.method, r.path, err)
+			}
+		}
+		r.compiled = true
+	}
+	return nil
+}
+
+// validate checks the query parameters and JSON body against the operation
+// and rejects the request with a validation error listing every problem.
+func (r *route) validate(c fiber.Ctx) error {
+	if !r.compiled {
+		return fmt.Errorf("openapi: %s %s is not compiled", r.method, r.path)
+	}
+
+	// Problems are written in the client's language as they are found.
+	loc := i18n.FromContext(c.Context())
+	var problems []string
+	for _, p := range r.query {
+		raw := c.Query(p.Name)
+		if raw == "" {
+			if p.Required {
+				problems = append(problems, loc.Sprintf("%s is required", p.Name))
+			}
+			continue
+		}
+		value, problem := queryValue(raw, p.Schema)
+		if problem != "" {
+			problems = append(problems, loc.Sprintf(problem, p.Name))
+			continue
+		}
+		if err := p.schema.Validate(value); err != nil {
+			problems = append(problems, describe(p.Name, err, loc.Printer())...)
+		}
+	}
+
+	if r.body != nil {
+		body := c.Body()
+		if len(bytes.TrimSpace(body)) == 0 {
+			return apperror.New(apperror.Validation, "Request body is required")
+		}
+		value, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+		if err != nil {
+			return apperror.New(apperror.Validation, "Invalid request body")
+		}
+		if err := r.body.Validate(value); err != nil {
+			problems = append(problems, describe("", err, loc.Printer())...)
+		}
+	}
+
+	if len(problems) > 0 {
+		return apperror.New(apperror.Validation, strings.Join(problems, "; "))
+	}
+	return c.Next()
+}
+
+// ValidateResponse checks a response body against the operation registered
+// for method and the OpenAPI path template, for contract tests. Statuses
+// without their own response are checked against the default error response.
+func (d *Document) ValidateResponse(method, path string, status int, body []byte) error {
+	op := d.Operation(method, path)
+	if op == nil {
+		return fmt.Errorf("%s %s is not documented", method, path)
+	}
+	if d.compiler == nil {
+		return errors.New("openapi: document is not compiled")
+	}
+	key := strconv.Itoa(status)
+	resp, ok := op.Responses[key]
+	if !ok {
+		key, resp = "default", op.Responses["default"]
+	}
+	if len(resp.Content) == 0 {
+		if len(bytes.TrimSpace(body)) > 0 {
+			return fmt.Errorf("%s %s: %d documents no body", method, path, status)
+		}
+		return nil
+	}
+	if _, ok := resp.Content["application/json"]; !ok {
+		return nil // a stream; its events are not described
+	}
+
+	location := fmt.Sprintf("%s#/paths/%s/%s/responses/%s/content/application~1json/schema",
+		documentURL, escapePointer(path), strings.ToLower(method), key)
+	d.compileMu.Lock()
+	schema, err := d.compiler.Compile(location)
+	d.compileMu.Unlock()
+	if err != nil {
+		return err
+	}
+	value, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s %s: %d body is not JSON: %w", method, path, status, err)
+	}
+	if err := schema.Validate(value); err != nil {
+		return fmt.Errorf("%s %s: %d body does not match the document: %s",
+			method, path, status, strings.Join(describe("", err, printer), "; "))
+	}
+	return nil
+}
+
+// queryValue converts a query string value to the JSON type its schema
+// expects. When raw does not convert, it returns the problem's message
+// format, which takes the parameter name.
+func queryValue(raw string, schema *Schema) (any, string) {
+	switch schema.Type {
+	case "integer":
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, "%s must be an integer"
+		}
+		return json.Number(strconv.FormatInt(n, 10)), ""
+	case "number":
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return nil, "%s must be a number"
+		}
+		return json.Number(raw), ""
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, "%s must be true or false"
+		}
+		return b, ""
+	}
+	return raw, ""
+}
+
+// describe flattens a validation error into one message per failed leaf,
+// prefixed with the location of the offending value and written with p.
+func describe(prefix string, err error, p *message.Printer) []string {
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return []string{err.Error()}
+	}
+	var out []string
+	var walk func(*jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) > 0 {
+			for _, cause := range e.Causes {
+				walk(cause)
+			}
+			return
+		}
+		location := strings.Join(append(splitNonEmpty(prefix), e.InstanceLocation...), ".")
+		message := e.ErrorKind.LocalizedString(p)
+		if location != "" {
+			message = location + ": " + message
+		}
+		out = append(out, message)
+	}
+	walk(verr)
+	return out
+}
+
+func splitNonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
+
+// escapePointer escapes a path segment for a JSON pointer in a URL fragment.
+func escapePointer(s string) string {
+	s = strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+	return strings.NewReplacer("{", "%7B", "}", "%7D").Replace(s)
+}
+
+func validateDecimal(v any) error {
+	s, ok := v.(string)
+	if !ok {
+		return nil
+	}
+	if _, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err != nil {
+		return fmt.Errorf("%q is not a decimal number", s)
+	}
+	return nil
+}
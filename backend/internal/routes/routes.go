@@ -0,0 +1,95 @@
+// Package routes registers every HTTP route of the API and builds the
+// OpenAPI documents describing them: one for the original /api routes (v1)
+// and one for /api/v2.
+package routes
+
+import (
+	"fintu-tracking-backend/internal/openapi"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// Paths of the OpenAPI documents. They are public so tooling can fetch them.
+const (
+	SpecPathV1 = "/api/openapi.json"
+	SpecPathV2 = "/api/v2/openapi.json"
+)
+
+// Middleware is the per-group request pipeline. main wires the real
+// middleware; tests and the spec generator pass through.
+type Middleware struct {
+	// Auth runs on every /api route except the specs: authentication, API
+	// token scopes and rate limiting.
+	Auth []any
+	// ActivePlan gates the routes that need an active subscription.
+	ActivePlan any
+	// Idempotent replays responses to retried creates.
+	Idempotent any
+}
+
+// PassThrough returns middleware that lets every request through, for
+// generating the documents and for tests.
+func PassThrough() Middleware {
+	next := func(c fiber.Ctx) error { return c.Next() }
+	return Middleware{Auth: []any{next}, ActivePlan: next, Idempotent: next}
+}
+
+// Documents are the compiled OpenAPI documents of each API version.
+type Documents struct {
+	V1 *openapi.Document
+	V2 *openapi.Document
+}
+
+// All returns every document, oldest version first.
+func (d *Documents) All() []*openapi.Document {
+	return []*openapi.Document{d.V1, d.V2}
+}
+
+// Register registers every route on app and returns the compiled documents
+// describing them.
+func Register(app fiber.Router, mw Middleware) (*Documents, error) {
+	// v2 is registered first. Fiber matches group middleware by prefix, so the
+	// middleware of v1's "/api" groups would also run for /api/v2 routes
+	// registered after it.
+	docs := &Documents{}
+	docs.V2 = registerV2(app, mw)
+	docs.V1 = registerV1(app, mw)
+	for _, doc := range docs.All() {
+		if err := doc.Compile(); err != nil {
+			return nil, err
+		}
+	}
+	return docs, nil
+}
+
+// Shared query parameters.
+var (
+	portfolioScope = openapi.QueryParam("portfolio_id", openapi.String(),
+		"Limit to one portfolio. Omit for every portfolio.")
+	pageParams = []openapi.Param{
+		openapi.QueryParam("page", openapi.Integer(1), "1-based page number. Setting page or page_size returns a page envelope."),
+		openapi.QueryParam("page_size", openapi.Integer(1), "Items per page (10, 25, 50 or 100; up to 10000 for exports)."),
+	}
+	cursorParams = []openapi.Param{
+		openapi.QueryParam("cursor", openapi.String(), "Opaque cursor from next_cursor of the previous page. Omit for the first page."),
+		openapi.QueryParam("limit", openapi.IntegerBetween(1, 100), "Items per page (default 50)."),
+	}
+	tradeSort = openapi.QueryParam("sort", openapi.Enum("date", "-date", "ticker", "-ticker", "total", "-total"),
+		"Sort column, descending when prefixed with - (default -date).")
+	cashFlowSort = openapi.QueryParam("sort", openapi.Enum("date", "-date", "usd_amount", "-usd_amount"),
+		"Sort column, descending when prefixed with - (default -date).")
+	includeTotal = openapi.QueryParam("include_total", openapi.Boolean(),
+		"Also count every matching item into total. Costs a second query.")
+	dateRange = []openapi.Param{
+		openapi.QueryParam("start_date", openapi.Date(), "First day to include."),
+		openapi.QueryParam("end_date", openapi.Date(), "Last day to include."),
+	}
+	fromTo = []openapi.Param{
+		openapi.QueryParam("from", openapi.Date(), "First day to include."),
+		openapi.QueryParam("to", openapi.Date(), "Last day to include."),
+	}
+	benchmarkIDs = openapi.QueryParam("benchmarks", openapi.String(),
+		"Comma-separated benchmark ids to compare against.")
+	feeImpactTicker = openapi.Param{Name: "ticker", Required: true, Schema: openapi.String(),
+		Description: "Ticker to report on."}
+)
@@ -0,0 +1,367 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"fintu-tracking-backend/internal/database"
+	"fintu-tracking-backend/internal/handlers"
+	"fintu-tracking-backend/internal/middleware"
+	"fintu-tracking-backend/internal/models"
+	"fintu-tracking-backend/internal/openapi"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// newTestApp registers every route with the given middleware, rendering
+// errors like the server does.
+func newTestApp(t *testing.T, mw Middleware) (*fiber.App, *Documents) {
+	t.Helper()
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	docs, err := Register(app, mw)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	return app, docs
+}
+
+// docFor returns the document describing path.
+func (d *Documents) docFor(path string) *openapi.Document {
+	if strings.HasPrefix(path, "/api/v2/") {
+		return d.V2
+	}
+	return d.V1
+}
+
+func TestGeneratedFilesAreUpToDate(t *testing.T) {
+	_, docs := newTestApp(t, PassThrough())
+	specV1, err := docs.V1.JSON()
+	if err != nil {
+		t.Fatalf("JSON: %v", err)
+	}
+	specV2, err := docs.V2.JSON()
+	if err != nil {
+		t.Fatalf("JSON: %v", err)
+	}
+
+	files := []struct {
+		path string
+		want []byte
+	}{
+		{"../../openapi.json", append(specV1, '\n')},
+		{"../../openapi.v2.json", append(specV2, '\n')},
+		{"../../../frontend/lib/api/generated.ts", docs.V1.TypeScript()},
+		{"../../../frontend/lib/api/generated-v2.ts", docs.V2.TypeScript()},
+	}
+	for _, f := range files {
+		got, err := os.ReadFile(f.path)
+		if err != nil {
+			t.Fatalf("read %s: %v", f.path, err)
+		}
+		if !bytes.Equal(got, f.want) {
+			t.Errorf("%s is out of date; run go run ./cmd/openapi from backend/", f.path)
+		}
+	}
+}
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	app, docs := newTestApp(t, PassThrough())
+
+	served := map[string]bool{}
+	for _, r := range app.GetRoutes(true) {
+		if r.Method == http.MethodHead {
+			continue
+		}
+		path := openapi.PathTemplate(r.Path)
+		key := r.Method + " " + path
+		served[key] = true
+		if docs.docFor(path).Operation(r.Method, path) == nil {
+			t.Errorf("%s is served but not documented", key)
+		}
+	}
+	for _, doc := range docs.All() {
+		for path, item := range doc.Paths {
+			for method := range *item {
+				key := strings.ToUpper(method) + " " + path
+				if !served[key] {
+					t.Errorf("%s is documented but not served", key)
+				}
+			}
+		}
+	}
+}
+
+func TestOperationIDsAreUnique(t *testing.T) {
+	_, docs := newTestApp(t, PassThrough())
+	for _, doc := range docs.All() {
+		var ids []string
+		for _, item := range doc.Paths {
+			for _, op := range *item {
+				ids = append(ids, op.OperationID)
+			}
+		}
+		slices.Sort(ids)
+		if dup := len(ids) - len(slices.Compact(ids)); dup != 0 {
+			t.Errorf("%s: %d duplicate operation ids", doc.Info.Version, dup)
+		}
+	}
+}
+
+// TestVersionMiddleware checks that each request runs the middleware of its
+// own version only: v1 group middleware matches every path under /api.
+func TestVersionMiddleware(t *testing.T) {
+	var auth, activePlan int
+	mw := PassThrough()
+	mw.Auth = []any{func(c fiber.Ctx) error {
+		auth++
+		return c.Next()
+	}}
+	mw.ActivePlan = func(c fiber.Ctx) error {
+		activePlan++
+		return c.Next()
+	}
+	app, _ := newTestApp(t, mw)
+
+	tests := []struct {
+		target           string
+		status           int
+		auth, activePlan int
+	}{
+		{"/api/v2/me", http.StatusUnauthorized, 1, 0},
+		{"/api/v2/trades?limit=0", http.StatusBadRequest, 1, 1},
+		{"/api/me", http.StatusUnauthorized, 1, 0},
+		{"/api/trades?page=0", http.StatusBadRequest, 1, 1},
+		{SpecPathV2, http.StatusOK, 0, 0},
+	}
+	for _, tc := range tests {
+		auth, activePlan = 0, 0
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, tc.target, nil))
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		if resp.StatusCode != tc.status {
+			t.Errorf("GET %s = %d, want %d", tc.target, resp.StatusCode, tc.status)
+		}
+		if auth != tc.auth || activePlan != tc.activePlan {
+			t.Errorf("GET %s ran auth %d and active plan %d times, want %d and %d",
+				tc.target, auth, activePlan, tc.auth, tc.activePlan)
+		}
+	}
+}
+
+func TestRequestValidation(t *testing.T) {
+	app, docs := newTestApp(t, PassThrough())
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   string
+	}{
+		{"missing body", http.MethodPost, "/api/trades", "", "Request body is required"},
+		{"malformed body", http.MethodPost, "/api/trades", "{", "Invalid request body"},
+		{"missing fields", http.MethodPost, "/api/trades", `{"ticker":"VOO"}`, "missing propert"},
+		{"bad enum", http.MethodPost, "/api/cash-flows", `{"type":"gift","amount":"10","date":"2024-01-02"}`, "type:"},
+		{"bad date", http.MethodPost, "/api/cash-flows", `{"type":"deposit","amount":"10","date":"02/01/2024"}`, "date:"},
+		{"wrong type", http.MethodPost, "/api/goals", `{"name":"House","target_amount":5}`, "target_amount:"},
+		{"integer query", http.MethodGet, "/api/trades?page=abc", "", "page must be an integer"},
+		{"query minimum", http.MethodGet, "/api/trades?page_size=0", "", "page_size:"},
+		{"boolean query", http.MethodGet, "/api/cash-flows?exclude_mirrored=maybe", "", "exclude_mirrored must be true or false"},
+		{"query enum", http.MethodGet, "/api/notifications?unread=yes", "", "unread:"},
+		{"v2 limit minimum", http.MethodGet, "/api/v2/trades?limit=0", "", "limit:"},
+		{"v2 limit maximum", http.MethodGet, "/api/v2/cash-flows?limit=101", "", "limit:"},
+		{"activity limit", http.MethodGet, "/api/v2/activity?limit=500", "", "limit:"},
+		{"sort column", http.MethodGet, "/api/v2/cash-flows?sort=ticker", "", "sort:"},
+		{"v2 required query", http.MethodGet, "/api/v2/analytics/fee-impact", "", "ticker"},
+		{"v2 query enum", http.MethodGet, "/api/v2/analytics/fee-efficiency?group_by=broker", "", "group_by:"},
+		{"locale enum", http.MethodPatch, "/api/me/profile", `{"country":"co","broker_preset_id":"hapi-colombia","locale":"fr"}`, "locale:"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400; body %s", resp.StatusCode, body)
+			}
+			var got models.ErrorResponse
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if got.Code != "validation" || !strings.Contains(got.Error, tc.want) {
+				t.Errorf("error = %q (%s), want validation containing %q", got.Error, got.Code, tc.want)
+			}
+			path := strings.Split(tc.target, "?")[0]
+			if err := docs.docFor(path).ValidateResponse(tc.method, path, resp.StatusCode, body); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// TestRequestValidationLocalized checks that validation errors follow
+// Accept-Language.
+func TestRequestValidationLocalized(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	app.Use(middleware.Locale())
+	if _, err := Register(app, PassThrough()); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	tests := []struct {
+		method, target, body, acceptLanguage, want string
+	}{
+		{http.MethodPost, "/api/trades", "", "es-CO", "El cuerpo de la solicitud es obligatorio"},
+		{http.MethodPost, "/api/trades", "", "en-US", "Request body is required"},
+		{http.MethodGet, "/api/trades?page=abc", "", "es", "page debe ser un número entero"},
+		{http.MethodGet, "/api/v2/trades?limit=0", "", "es-CO", "limit: mínimo: se recibió 0, se esperaba 1"},
+		{http.MethodPost, "/api/trades", `{"ticker":"VOO"}`, "es-CO", "falta"},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(fiber.HeaderAcceptLanguage, tc.acceptLanguage)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		var got models.ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		resp.Body.Close()
+		if !strings.Contains(got.Error, tc.want) {
+			t.Errorf("%s %s (%s): error = %q, want it to contain %q", tc.method, tc.target, tc.acceptLanguage, got.Error, tc.want)
+		}
+	}
+}
+
+func TestPublicRoutesMatchDocument(t *testing.T) {
+	app, docs := newTestApp(t, PassThrough())
+
+	for _, path := range []string{"/livez", SpecPathV1, SpecPathV2} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s = %d, want 200", path, resp.StatusCode)
+		}
+		if err := docs.docFor(path).ValidateResponse(http.MethodGet, path, resp.StatusCode, body); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// TestResponsesMatchDocument calls read and create endpoints against the
+// test database and checks every response body against the document, so a
+// handler or model change that is not reflected in the spec fails here.
+func TestResponsesMatchDocument(t *testing.T) {
+	testURL := os.Getenv("TEST_DATABASE_URL")
+	if testURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	if err := database.Connect(testURL); err != nil {
+		t.Fatalf("connect to TEST_DATABASE_URL: %v", err)
+	}
+	pool := database.GetPool()
+	handlers.InitProfileService(pool)
+	handlers.InitBrokerService(pool)
+	handlers.InitPortfolioService(pool)
+	handlers.InitBenchmarkService(pool)
+	handlers.InitGoalService(pool)
+
+	userID := uuid.New().String()
+	email := fmt.Sprintf("contract-%s@example.com", strings.ReplaceAll(userID, "-", "")[:16])
+	if _, err := pool.Exec(context.Background(), `
+		INSERT INTO auth.users (
+			id, instance_id, aud, role, email, encrypted_password,
+			email_confirmed_at, created_at, updated_at
+		)
+		VALUES ($1, '00000000-0000-0000-0000-000000000000', 'authenticated', 'authenticated', $2, '', NOW(), NOW(), NOW())
+	`, userID, email); err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), "DELETE FROM auth.users WHERE id = $1", userID)
+	})
+
+	mw := PassThrough()
+	mw.Auth = []any{func(c fiber.Ctx) error {
+		c.Locals("user_id", userID)
+		return c.Next()
+	}}
+	app, docs := newTestApp(t, mw)
+
+	calls := []struct {
+		method, path, target, body string
+	}{
+		{http.MethodGet, "/api/me", "/api/me", ""},
+		{http.MethodGet, "/api/brokers", "/api/brokers", ""},
+		{http.MethodPost, "/api/portfolios", "/api/portfolios", `{"name":"Contract"}`},
+		{http.MethodGet, "/api/portfolios", "/api/portfolios", ""},
+		{http.MethodGet, "/api/portfolios/consolidated", "/api/portfolios/consolidated", ""},
+		{http.MethodGet, "/api/trades", "/api/trades", ""},
+		{http.MethodGet, "/api/trades", "/api/trades?page=1", ""},
+		{http.MethodGet, "/api/trade-tickers", "/api/trade-tickers", ""},
+		{http.MethodGet, "/api/cash-flows", "/api/cash-flows?page=1&page_size=10", ""},
+		{http.MethodGet, "/api/portfolio/holdings", "/api/portfolio/holdings", ""},
+		{http.MethodGet, "/api/analytics/net-worth", "/api/analytics/net-worth", ""},
+		{http.MethodGet, "/api/analytics/fee-breakdown", "/api/analytics/fee-breakdown", ""},
+		{http.MethodGet, "/api/analytics/cash-reconciliation", "/api/analytics/cash-reconciliation", ""},
+		{http.MethodGet, "/api/benchmarks", "/api/benchmarks", ""},
+		{http.MethodGet, "/api/goals", "/api/goals", ""},
+		{http.MethodGet, "/api/activity/feed", "/api/activity/feed", ""},
+		{http.MethodGet, "/api/goals/{id}", "/api/goals/" + uuid.NewString(), ""},
+		{http.MethodGet, "/api/v2/brokers", "/api/v2/brokers", ""},
+		{http.MethodGet, "/api/v2/broker-presets", "/api/v2/broker-presets?limit=2", ""},
+		{http.MethodGet, "/api/v2/portfolios/consolidated", "/api/v2/portfolios/consolidated", ""},
+		{http.MethodGet, "/api/v2/trades", "/api/v2/trades?limit=10", ""},
+		{http.MethodGet, "/api/v2/trades", "/api/v2/trades?sort=-total&include_total=true", ""},
+		{http.MethodGet, "/api/trades", "/api/trades?sort=ticker&page=1", ""},
+		{http.MethodGet, "/api/v2/trades/tickers", "/api/v2/trades/tickers", ""},
+		{http.MethodGet, "/api/v2/cash-flows", "/api/v2/cash-flows", ""},
+		{http.MethodGet, "/api/v2/cash-flows", "/api/v2/cash-flows?sort=usd_amount&limit=1", ""},
+		{http.MethodGet, "/api/v2/holdings", "/api/v2/holdings", ""},
+		{http.MethodGet, "/api/v2/holdings/by-broker", "/api/v2/holdings/by-broker", ""},
+		{http.MethodGet, "/api/v2/notifications", "/api/v2/notifications", ""},
+		{http.MethodGet, "/api/v2/analytics/net-worth", "/api/v2/analytics/net-worth", ""},
+		{http.MethodGet, "/api/v2/analytics/fee-impact", "/api/v2/analytics/fee-impact?ticker=VOO", ""},
+		{http.MethodGet, "/api/v2/analytics/fee-efficiency", "/api/v2/analytics/fee-efficiency", ""},
+		{http.MethodGet, "/api/v2/activity", "/api/v2/activity", ""},
+		{http.MethodGet, "/api/v2/activity", "/api/v2/activity?kind=trade,deposit&ticker=VOO&limit=5", ""},
+	}
+	for _, call := range calls {
+		t.Run(call.method+" "+call.target, func(t *testing.T) {
+			req := httptest.NewRequest(call.method, call.target, strings.NewReader(call.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode >= http.StatusInternalServerError {
+				t.Fatalf("status = %d; body %s", resp.StatusCode, body)
+			}
+			if err := docs.docFor(call.path).ValidateResponse(call.method, call.path, resp.StatusCode, body); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,399 @@
+package routes
+
+import (
+	"net/http"
+
+	"fintu-tracking-backend/internal/handlers"
+	"fintu-tracking-backend/internal/models"
+	"fintu-tracking-backend/internal/openapi"
+	"fintu-tracking-backend/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// registerV1 registers the probes and the /api routes and returns the
+// document describing them. v1 is kept for existing clients; its list
+// endpoints return plain arrays unless paginated and some bodies use
+// camelCase.
+func registerV1(app fiber.Router, mw Middleware) *openapi.Document {
+	doc := openapi.NewDocument(openapi.Info{
+		Title:   "Fintu Tracking API",
+		Version: "1.0.0",
+		Description: "Portfolio tracking for Colombian investors in US stocks and ETFs. Amounts and rates are decimal strings. " +
+			"This version is kept for compatibility; new clients should use /api/v2.",
+	})
+	r := openapi.NewRouter(doc, app)
+	idempotent := mw.Idempotent
+
+	// Probes. /livez only checks the process; /readyz also checks the
+	// database and schema. /health is kept for existing monitors.
+	r.Get("/livez", openapi.NewOp("Health", "Liveness probe").Public().
+		Returns(http.StatusOK, models.Liveness{}), handlers.Livez)
+	r.Get("/readyz", openapi.NewOp("Health", "Readiness probe").Public().
+		Returns(http.StatusOK, models.Readiness{}).
+		Returns(http.StatusServiceUnavailable, models.Readiness{}), handlers.Readyz)
+	r.Get("/health", openapi.NewOp("Health", "Readiness probe (legacy path)").ID("health").Public().
+		Returns(http.StatusOK, models.Readiness{}).
+		Returns(http.StatusServiceUnavailable, models.Readiness{}), handlers.Readyz)
+
+	api := r.Group("/api")
+	api.Get("/openapi.json", openapi.NewOp("Meta", "This OpenAPI document").ID("getOpenAPI").Public().
+		Returns(http.StatusOK, map[string]any{}), doc.Handler())
+
+	// Authenticated routes that do not require an active subscription. Session
+	// JWTs and personal API tokens are both accepted; API tokens are limited to
+	// the route groups their scopes cover. Every user is rate limited per
+	// route group.
+	authOnly := api.Group("", mw.Auth...)
+
+	// Current user / onboarding endpoints
+	authOnly.Get("/me", openapi.NewOp("Profile", "Get or create the current user's profile").
+		Returns(http.StatusOK, models.Profile{}), handlers.GetMe)
+	authOnly.Patch("/me/onboarding", openapi.NewOp("Profile", "Complete onboarding").
+		Body(models.UpdateOnboardingRequest{}).
+		Returns(http.StatusOK, models.Profile{}), handlers.UpdateOnboarding)
+	authOnly.Patch("/me/profile", openapi.NewOp("Profile", "Update the profile").
+		Body(models.UpdateProfileRequest{}).
+		Returns(http.StatusOK, models.Profile{}), handlers.UpdateProfile)
+
+	// Billing endpoints
+	authOnly.Get("/plans", openapi.NewOp("Billing", "List plans").
+		Returns(http.StatusOK, []models.Plan{}), handlers.ListPlans)
+	authOnly.Get("/subscriptions/current", openapi.NewOp("Billing", "Get the current subscription").
+		Returns(http.StatusOK, models.Subscription{}), handlers.GetSubscription)
+	authOnly.Post("/subscriptions", openapi.NewOp("Billing", "Subscribe to a plan").
+		Body(models.CreateSubscriptionRequest{}).
+		Returns(http.StatusCreated, models.Subscription{}), handlers.CreateSubscription)
+	authOnly.Patch("/subscriptions/:id/cancel", openapi.NewOp("Billing", "Cancel a subscription").
+		Returns(http.StatusOK, models.Subscription{}), handlers.CancelSubscription)
+
+	// Broker endpoints are auth-only (not subscription-gated) so onboarding can
+	// create the user's first broker before a plan is selected.
+	authOnly.Get("/brokers", openapi.NewOp("Brokers", "List the user's brokers and the built-in presets").
+		Returns(http.StatusOK, handlers.ListBrokersResponse{}), handlers.ListBrokers)
+	authOnly.Post("/brokers", openapi.NewOp("Brokers", "Add a broker from a preset or a custom definition").
+		Body(handlers.CreateBrokerRequest{}).
+		Returns(http.StatusCreated, models.Broker{}), handlers.CreateBroker)
+	authOnly.Patch("/brokers/:id", openapi.NewOp("Brokers", "Update a broker").
+		Body(models.UpdateBrokerRequest{}).
+		Returns(http.StatusOK, models.Broker{}), handlers.UpdateBroker)
+	authOnly.Delete("/brokers/:id", openapi.NewOp("Brokers", "Delete a broker").
+		Returns(http.StatusOK, models.MessageResponse{}), handlers.DeleteBroker)
+	authOnly.Get("/brokers/:id/fee-schedules", openapi.NewOp("Brokers", "List a broker's fee schedule versions").
+		Returns(http.StatusOK, []models.BrokerFeeSchedule{}), handlers.ListBrokerFeeSchedules)
+	authOnly.Post("/brokers/:id/fee-schedules", openapi.NewOp("Brokers", "Add a fee schedule version").
+		Body(models.BrokerFeeScheduleInput{}).
+		Returns(http.StatusCreated, models.BrokerFeeSchedule{}), handlers.CreateBrokerFeeSchedule)
+	authOnly.Delete("/brokers/:id/fee-schedules/:scheduleId", openapi.NewOp("Brokers", "Delete a fee schedule version").
+		Returns(http.StatusOK, models.MessageResponse{}), handlers.DeleteBrokerFeeSchedule)
+
+	// The notification inbox is auth-only so subscription notices stay readable
+	// after the plan lapses.
+	authOnly.Get("/notifications", openapi.NewOp("Notifications", "List notifications").
+		Query(pageParams...).
+		Query(openapi.QueryParam("unread", openapi.Enum("true", "false"), "Only unread notifications.")).
+		Returns(http.StatusOK, models.PaginatedResponse[models.Notification]{}), handlers.ListNotifications)
+	authOnly.Get("/notifications/unread-count", openapi.NewOp("Notifications", "Count unread notifications").
+		Returns(http.StatusOK, models.UnreadNotificationCount{}), handlers.GetUnreadNotificationCount)
+	authOnly.Get("/notifications/stream", openapi.NewOp("Notifications", "Stream new notifications as server-sent events").
+		Streams(http.StatusOK, "text/event-stream"), handlers.StreamNotifications)
+	authOnly.Post("/notifications/read-all", openapi.NewOp("Notifications", "Mark every notification read").
+		Returns(http.StatusOK, models.NotificationsMarkedRead{}), handlers.MarkAllNotificationsRead)
+	authOnly.Post("/notifications/:id/read", openapi.NewOp("Notifications", "Mark a notification read").
+		Returns(http.StatusOK, models.Notification{}), handlers.MarkNotificationRead)
+
+	// Personal API tokens. API tokens themselves cannot call these routes.
+	authOnly.Get("/tokens", openapi.NewOp("API tokens", "List API tokens").
+		Returns(http.StatusOK, []models.APIToken{}), handlers.ListAPITokens)
+	authOnly.Post("/tokens", openapi.NewOp("API tokens", "Create an API token").
+		Describe("The token value is only returned in this response.").
+		Body(models.CreateAPITokenRequest{}).
+		Returns(http.StatusCreated, models.APIToken{}), handlers.CreateAPIToken)
+	authOnly.Delete("/tokens/:id", openapi.NewOp("API tokens", "Revoke an API token").
+		Returns(http.StatusOK, models.APIToken{}), handlers.RevokeAPIToken)
+
+	// Protected routes - require authentication and an active subscription.
+	protected := authOnly.Group("", mw.ActivePlan)
+
+	// FX Rates endpoints
+	protected.Get("/fx-rates/current", openapi.NewOp("FX rates", "Get the current USD/COP or COP/USD rate").
+		Query(
+			openapi.QueryParam("from", openapi.String(), "Source currency (default USD)."),
+			openapi.QueryParam("to", openapi.String(), "Target currency (default COP)."),
+		).
+		Returns(http.StatusOK, handlers.CurrentRateResponse{}), handlers.GetCurrentRate)
+	protected.Get("/fx-rates/chart", openapi.NewOp("FX rates", "Daily USD/COP closes for charting").
+		Query(openapi.QueryParam("days", openapi.Integer(), "Number of days (default 30).")).
+		Returns(http.StatusOK, []services.FxRateChartPoint{}), handlers.GetFxRateChart)
+	protected.Get("/fx-rates", openapi.NewOp("FX rates", "List recorded FX rates").
+		Returns(http.StatusOK, []models.FxRate{}), handlers.ListFxRates)
+	protected.Post("/fx-rates", openapi.NewOp("FX rates", "Record an FX rate").
+		Body(models.CreateFxRateRequest{}).
+		Returns(http.StatusCreated, models.FxRate{}), handlers.CreateFxRate)
+	protected.Put("/fx-rates/:id", openapi.NewOp("FX rates", "Update an FX rate").
+		Body(models.UpdateFxRateRequest{}).
+		Returns(http.StatusOK, models.MessageResponse{}), handlers.UpdateFxRate)
+	protected.Delete("/fx-rates/:id", openapi.NewOp("FX rates", "Delete an FX rate").
+		Returns(http.StatusOK, models.MessageResponse{}), handlers.DeleteFxRate)
+
+	// Cash Flows endpoints
+	protected.Get("/cash-flows", openapi.NewOp("Cash flows", "List cash flows").
+		Query(
+			openapi.QueryParam("from", openapi.String(), "First date (YYYY-MM-DD) to include."),
+			openapi.QueryParam("to", openapi.String(), "Last date (YYYY-MM-DD) to include."),
+			openapi.QueryParam("type", openapi.String(), "deposit, withdrawal, fee, cash_adjustment, transfer_in or transfer_out."),
+			openapi.QueryParam("currency", openapi.String(), "USD or COP."),
+			openapi.QueryParam("exclude_mirrored", openapi.Boolean(), "Hide fee rows mirrored from trades (default true)."),
+			cashFlowSort,
+			portfolioScope,
+		).
+		Query(pageParams...).
+		Returns(http.StatusOK, []models.CashFlow{}, models.PaginatedResponse[models.CashFlow]{}), handlers.ListCashFlows)
+	protected.Post("/cash-flows", openapi.NewOp("Cash flows", "Record a cash flow").
+		Idempotent().
+		Body(models.CreateCashFlowRequest{}).
+		Returns(http.StatusCreated, models.CashFlow{}), idempotent, handlers.CreateCashFlow)
+	protected.Post("/cash-flows/fee-quote", openapi.NewOp("Cash flows", "Quote the broker fee of a deposit or withdrawal").
+		Body(models.CreateCashFlowRequest{}).
+		Returns(http.StatusOK, models.TransferFeeQuote{}), handlers.QuoteCashFlowFee)
+	protected.Put("/cash-flows/:id", openapi.NewOp("Cash flows", "Update a cash flow").
+		Body(models.UpdateCashFlowRequest{}).
+		Returns(http.StatusOK, models.MessageResponse{}), handlers.UpdateCashFlow)
+	protected.Delete("/cash-flows/:id", openapi.NewOp("Cash flows", "Delete a cash flow").
+		Returns(http.StatusOK, models.MessageResponse{}), handlers.DeleteCashFlow)
+
+	// Trades endpoints
+	protected.Get("/trade-tickers", openapi.NewOp("Trades", "List the tickers the user has traded").
+		Returns(http.StatusOK, []string{}), handlers.ListTradeTickers)
+	protected.Get("/trades", openapi.NewOp("Trades", "List trades").
+		Query(
+			openapi.QueryParam("from", openapi.String(), "First date (YYYY-MM-DD) to include."),
+			openapi.QueryParam("to", openapi.String(), "Last date (YYYY-MM-DD) to include."),
+			openapi.QueryParam("side", openapi.String(), "buy or sell."),
+			openapi.QueryParam("asset_type", openapi.String(), "stock, etf or crypto."),
+			openapi.QueryParam("ticker", openapi.String(), "Exact ticker."),
+			tradeSort,
+			portfolioScope,
+		).
+		Query(pageParams...).
+		Returns(http.StatusOK, []models.Trade{}, models.PaginatedResponse[models.Trade]{}), handlers.ListTrades)
+	protected.Post("/trades", openapi.NewOp("Trades", "Record a trade").
+		Idempotent().
+		Body(models.CreateTradeRequest{}).
+		Returns(http.StatusCreated, models.Trade{}), idempotent, handlers.CreateTrade)
+	protected.Put("/trades/:id", openapi.NewOp("Trades", "Update a trade").
+		Body(models.UpdateTradeRequest{}).
+		Returns(http.StatusOK, models.MessageResponse{}), handlers.UpdateTrade)
+	protected.Delete("/trades/:id", openapi.NewOp("Trades", "Delete a trade").
+		Returns(http.StatusOK, models.MessageResponse{}), handlers.DeleteTrade)
+
+	// Market Prices endpoints
+	protected.Get("/market-prices", openapi.NewOp("Market data", "List cached market prices").
+		Returns(http.StatusOK, []models.MarketPrice{}), handlers.ListMarketPrices)
+	protected.Get("/market-prices/:ticker", openapi.NewOp("Market data", "Get a ticker's cached price").
+		Returns(http.StatusOK, models.MarketPrice{}), handlers.GetMarketPrice)
+	protected.Post("/market-prices/refresh", openapi.NewOp("Market data", "Refresh quotes for held tickers").
+		Returns(http.StatusOK, services.RefreshResult{}), handlers.RefreshMarketPrices)
+	protected.Post("/market-prices/history/refresh", openapi.NewOp("Market data", "Backfill daily closes").
+		Returns(http.StatusOK, services.RefreshResult{}), handlers.RefreshDailyPrices)
+
+	// Portfolio endpoints
+	protected.Get("/portfolio/holdings", openapi.NewOp("Portfolios", "Current holdings").
+		Query(
+			openapi.QueryParam("group_by", openapi.Enum("broker"), "Group holdings per broker (not paginated)."),
+			portfolioScope,
+		).
+		Query(pageParams...).
+		Returns(http.StatusOK, []models.Holding{}, []models.BrokerHoldings{}, models.PaginatedResponse[models.Holding]{}),
+		handlers.GetHoldings)
+
+	// Named portfolios. Static paths are registered before /:id.
+	protected.Get("/portfolios", openapi.NewOp("Portfolios", "List portfolios").
+		Returns(http.StatusOK, []models.Portfolio{}), handlers.ListPortfolios)
+	protected.Get("/portfolios/consolidated", openapi.NewOp("Portfolios", "Every portfolio side by side with totals").
+		Returns(http.StatusOK, models.ConsolidatedPortfolioView{}), handlers.GetConsolidatedPortfolios)
+	protected.Post("/portfolios", openapi.NewOp("Portfolios", "Create a portfolio").
+		Body(models.CreatePortfolioRequest{}).
+		Returns(http.StatusCreated, models.Portfolio{}), handlers.CreatePortfolio)
+	protected.Post("/portfolios/transfers", openapi.NewOp("Portfolios", "Move USD cash between portfolios").
+		Idempotent().
+		Body(models.CreatePortfolioTransferRequest{}).
+		Returns(http.StatusCreated, models.PortfolioTransfer{}), idempotent, handlers.CreatePortfolioTransfer)
+	protected.Delete("/portfolios/transfers/:id", openapi.NewOp("Portfolios", "Delete a transfer").
+		Query(portfolioScope).
+		Returns(http.StatusOK, models.MessageResponse{}), handlers.DeletePortfolioTransfer)
+	protected.Patch("/portfolios/:id", openapi.NewOp("Portfolios", "Update a portfolio").
+		Body(models.UpdatePortfolioRequest{}).
+		Returns(http.StatusOK, models.Portfolio{}), handlers.UpdatePortfolio)
+	protected.Delete("/portfolios/:id", openapi.NewOp("Portfolios", "Delete a portfolio").
+		Returns(http.StatusOK, models.MessageResponse{}), handlers.DeletePortfolio)
+	protected.Get("/portfolios/:id/target-allocation", openapi.NewOp("Allocations", "Get a portfolio's target allocation").
+		Returns(http.StatusOK, models.TargetAllocation{}), handlers.GetTargetAllocation)
+	protected.Put("/portfolios/:id/target-allocation", openapi.NewOp("Allocations", "Set a portfolio's target allocation").
+		Body(models.SetTargetAllocationRequest{}).
+		Returns(http.StatusOK, models.TargetAllocation{}), handlers.SetTargetAllocation)
+	protected.Delete("/portfolios/:id/target-allocation", openapi.NewOp("Allocations", "Delete a portfolio's target allocation").
+		Returns(http.StatusOK, models.MessageResponse{}), handlers.DeleteTargetAllocation)
+	protected.Get("/portfolios/:id/rebalance", openapi.NewOp("Allocations", "Plan trades back to the target allocation").
+		Query(openapi.QueryParam("allow_sells", openapi.Boolean(), "Allow selling overweight positions (default true).")).
+		Returns(http.StatusOK, models.RebalancePlan{}), handlers.GetRebalancePlan)
+
+	// Analytics endpoints
+	protected.Get("/analytics/fee-breakdown", openapi.NewOp("Analytics", "Fees by type").
+		Query(dateRange...).Query(portfolioScope).
+		Returns(http.StatusOK, models.FeeBreakdown{}), handlers.GetFeeBreakdown)
+	protected.Get("/analytics/fee-impact", openapi.NewOp("Analytics", "Fee impact on return").
+		Query(feeImpactTicker, portfolioScope).
+		Returns(http.StatusOK, map[string]string{}), handlers.GetFeeImpact)
+	protected.Get("/analytics/fee-efficiency", openapi.NewOp("Analytics", "Fees relative to invested capital").
+		Query(openapi.QueryParam("group_by", openapi.String(), "ticker (default) or broker."), portfolioScope).
+		Returns(http.StatusOK, map[string]any{}), handlers.GetFeeEfficiency)
+	protected.Get("/analytics/return-attribution", openapi.NewOp("Analytics", "Return split into price, FX and fees").
+		Query(portfolioScope).
+		Returns(http.StatusOK, models.ReturnAttribution{}), handlers.GetReturnAttribution)
+	protected.Get("/analytics/fx-impact", openapi.NewOp("Analytics", "Effect of USD/COP moves on the portfolio").
+		Query(portfolioScope).
+		Returns(http.StatusOK, models.FXImpactReport{}), handlers.GetFXImpact)
+	protected.Get("/analytics/performance-time-series", openapi.NewOp("Analytics", "Portfolio value over time").
+		Query(
+			openapi.QueryParam("interval", openapi.String(), "day (default), week or month."),
+			benchmarkIDs,
+			portfolioScope,
+		).
+		Returns(http.StatusOK, []models.PerformancePoint{}), handlers.GetPerformanceTimeSeries)
+	protected.Get("/analytics/net-worth", openapi.NewOp("Analytics", "Net worth summary").
+		Query(portfolioScope).
+		Returns(http.StatusOK, models.NetWorthSummary{}), handlers.GetNetWorth)
+	protected.Get("/analytics/cash-reconciliation", openapi.NewOp("Analytics", "Check cash flows against trades").
+		Query(portfolioScope).
+		Returns(http.StatusOK, models.ReconciliationReport{}), handlers.GetCashReconciliation)
+	protected.Get("/analytics/risk", openapi.NewOp("Analytics", "Volatility, drawdown and value at risk").
+		Query(
+			openapi.QueryParam("risk_free_rate", openapi.Decimal(), "Annual risk-free rate as a fraction."),
+			openapi.QueryParam("confidence", openapi.Decimal(), "Value at risk confidence level."),
+			portfolioScope,
+		).
+		Returns(http.StatusOK, models.RiskReport{}), handlers.GetRiskMetrics)
+	protected.Get("/analytics/benchmark-comparison", openapi.NewOp("Analytics", "Compare returns with benchmarks").
+		Query(benchmarkIDs, portfolioScope).
+		Returns(http.StatusOK, []models.BenchmarkComparison{}), handlers.GetBenchmarkComparison)
+	protected.Get("/analytics/exposure", openapi.NewOp("Analytics", "Exposure by asset type, sector and currency").
+		Query(
+			openapi.QueryParam("concentration_threshold", openapi.Decimal(), "Percent of the portfolio above which a position is flagged."),
+			portfolioScope,
+		).
+		Returns(http.StatusOK, models.ExposureReport{}), handlers.GetExposure)
+
+	// Benchmarks (built-in presets and custom baskets)
+	protected.Get("/benchmarks", openapi.NewOp("Benchmarks", "List benchmarks").
+		Returns(http.StatusOK, []models.Benchmark{}), handlers.ListBenchmarks)
+	protected.Post("/benchmarks", openapi.NewOp("Benchmarks", "Create a custom benchmark").
+		Body(models.CreateBenchmarkRequest{}).
+		Returns(http.StatusCreated, models.Benchmark{}), handlers.CreateBenchmark)
+	protected.Delete("/benchmarks/:id", openapi.NewOp("Benchmarks", "Delete a custom benchmark").
+		Returns(http.StatusNoContent), handlers.DeleteBenchmark)
+
+	// Recurring contribution (DCA) plans and their installments
+	protected.Get("/dca-plans", openapi.NewOp("DCA", "List DCA plans").
+		Returns(http.StatusOK, []models.DCAPlan{}), handlers.ListDCAPlans)
+	protected.Post("/dca-plans", openapi.NewOp("DCA", "Create a DCA plan").
+		Idempotent().
+		Body(models.CreateDCAPlanRequest{}).
+		Returns(http.StatusCreated, models.DCAPlan{}), idempotent, handlers.CreateDCAPlan)
+	protected.Get("/dca-plans/:id", openapi.NewOp("DCA", "Get a DCA plan").
+		Returns(http.StatusOK, models.DCAPlan{}), handlers.GetDCAPlan)
+	protected.Patch("/dca-plans/:id", openapi.NewOp("DCA", "Update a DCA plan").
+		Body(models.UpdateDCAPlanRequest{}).
+		Returns(http.StatusOK, models.DCAPlan{}), handlers.UpdateDCAPlan)
+	protected.Delete("/dca-plans/:id", openapi.NewOp("DCA", "Delete a DCA plan").
+		Returns(http.StatusNoContent), handlers.DeleteDCAPlan)
+	protected.Get("/dca-plans/:id/installments", openapi.NewOp("DCA", "List a plan's installments").ID("listDCAPlanInstallments").
+		Query(openapi.QueryParam("status", openapi.String(), "pending, confirmed or skipped.")).
+		Returns(http.StatusOK, []models.DCAInstallment{}), handlers.ListDCAInstallments)
+	protected.Get("/dca-plans/:id/report", openapi.NewOp("DCA", "Adherence report").
+		Query(
+			openapi.QueryParam("from", openapi.Date(), "First scheduled date to include."),
+			openapi.QueryParam("to", openapi.Date(), "Last scheduled date to include."),
+		).
+		Returns(http.StatusOK, models.DCAAdherenceReport{}), handlers.GetDCAReport)
+	protected.Get("/dca-installments", openapi.NewOp("DCA", "List installments of every plan").
+		Query(openapi.QueryParam("status", openapi.String(), "pending, confirmed or skipped.")).
+		Returns(http.StatusOK, []models.DCAInstallment{}), handlers.ListDCAInstallments)
+	protected.Post("/dca-installments/generate", openapi.NewOp("DCA", "Create due installments now").
+		Returns(http.StatusOK, models.GenerateDCAInstallmentsResponse{}), handlers.GenerateDCAInstallments)
+	protected.Post("/dca-installments/:id/confirm", openapi.NewOp("DCA", "Confirm an installment and record its trades").
+		Idempotent().
+		Body(models.ConfirmDCAInstallmentRequest{}).
+		Returns(http.StatusOK, models.DCAInstallment{}), idempotent, handlers.ConfirmDCAInstallment)
+	protected.Post("/dca-installments/:id/skip", openapi.NewOp("DCA", "Skip an installment").
+		Returns(http.StatusOK, models.DCAInstallment{}), handlers.SkipDCAInstallment)
+
+	// Investment goals and their projections
+	protected.Get("/goals", openapi.NewOp("Goals", "List goals").
+		Returns(http.StatusOK, []models.Goal{}), handlers.ListGoals)
+	protected.Post("/goals", openapi.NewOp("Goals", "Create a goal").
+		Idempotent().
+		Body(models.CreateGoalRequest{}).
+		Returns(http.StatusCreated, models.Goal{}), idempotent, handlers.CreateGoal)
+	protected.Get("/goals/:id", openapi.NewOp("Goals", "Get a goal").
+		Returns(http.StatusOK, models.Goal{}), handlers.GetGoal)
+	protected.Patch("/goals/:id", openapi.NewOp("Goals", "Update a goal").
+		Body(models.UpdateGoalRequest{}).
+		Returns(http.StatusOK, models.Goal{}), handlers.UpdateGoal)
+	protected.Delete("/goals/:id", openapi.NewOp("Goals", "Delete a goal").
+		Returns(http.StatusNoContent), handlers.DeleteGoal)
+	protected.Get("/goals/:id/projection", openapi.NewOp("Goals", "Monte Carlo projection of a goal").
+		Query(openapi.QueryParam("simulations", openapi.Integer(1), "Number of simulated paths.")).
+		Returns(http.StatusOK, models.GoalProjection{}), handlers.GetGoalProjection)
+
+	// Price, P/L, FX and drawdown alerts
+	protected.Get("/alerts", openapi.NewOp("Alerts", "List alert rules").
+		Returns(http.StatusOK, []models.AlertRule{}), handlers.ListAlertRules)
+	protected.Post("/alerts", openapi.NewOp("Alerts", "Create an alert rule").
+		Idempotent().
+		Body(models.CreateAlertRuleRequest{}).
+		Returns(http.StatusCreated, models.AlertRule{}), idempotent, handlers.CreateAlertRule)
+	protected.Get("/alerts/events", openapi.NewOp("Alerts", "List fired alerts").
+		Query(
+			openapi.QueryParam("rule_id", openapi.String(), "Limit to one rule."),
+			openapi.QueryParam("limit", openapi.Integer(1), "Maximum number of events."),
+		).
+		Returns(http.StatusOK, []models.AlertEvent{}), handlers.ListAlertEvents)
+	protected.Post("/alerts/evaluate", openapi.NewOp("Alerts", "Evaluate the user's alert rules now").
+		Returns(http.StatusOK, models.AlertEvaluation{}), handlers.EvaluateAlerts)
+	protected.Get("/alerts/:id", openapi.NewOp("Alerts", "Get an alert rule").
+		Returns(http.StatusOK, models.AlertRule{}), handlers.GetAlertRule)
+	protected.Patch("/alerts/:id", openapi.NewOp("Alerts", "Update an alert rule").
+		Body(models.UpdateAlertRuleRequest{}).
+		Returns(http.StatusOK, models.AlertRule{}), handlers.UpdateAlertRule)
+	protected.Delete("/alerts/:id", openapi.NewOp("Alerts", "Delete an alert rule").
+		Returns(http.StatusNoContent), handlers.DeleteAlertRule)
+
+	// Outbound webhooks for user integrations
+	protected.Get("/webhooks", openapi.NewOp("Webhooks", "List webhook endpoints").
+		Returns(http.StatusOK, []models.WebhookEndpoint{}), handlers.ListWebhooks)
+	protected.Post("/webhooks", openapi.NewOp("Webhooks", "Register a webhook endpoint").
+		Describe("The signing secret is only returned in this response.").
+		Idempotent().
+		Body(models.CreateWebhookEndpointRequest{}).
+		Returns(http.StatusCreated, models.WebhookEndpoint{}), idempotent, handlers.CreateWebhook)
+	protected.Get("/webhooks/:id", openapi.NewOp("Webhooks", "Get a webhook endpoint").
+		Returns(http.StatusOK, models.WebhookEndpoint{}), handlers.GetWebhook)
+	protected.Patch("/webhooks/:id", openapi.NewOp("Webhooks", "Update a webhook endpoint").
+		Body(models.UpdateWebhookEndpointRequest{}).
+		Returns(http.StatusOK, models.WebhookEndpoint{}), handlers.UpdateWebhook)
+	protected.Delete("/webhooks/:id", openapi.NewOp("Webhooks", "Delete a webhook endpoint").
+		Returns(http.StatusNoContent), handlers.DeleteWebhook)
+	protected.Get("/webhooks/:id/deliveries", openapi.NewOp("Webhooks", "List recent deliveries").
+		Query(openapi.QueryParam("limit", openapi.Integer(1), "Maximum number of deliveries.")).
+		Returns(http.StatusOK, []models.WebhookDelivery{}), handlers.ListWebhookDeliveries)
+	protected.Post("/webhooks/:id/ping", openapi.NewOp("Webhooks", "Send a test delivery").
+		Returns(http.StatusOK, models.WebhookDelivery{}), handlers.PingWebhook)
+
+	// Activity feed
+	protected.Get("/activity/feed", openapi.NewOp("Activity", "Recent trades and cash flows").
+		Query(openapi.QueryParam("limit", openapi.Integer(1), "Number of items (at most 20).")).
+		Returns(http.StatusOK, []models.ActivityItem{}), handlers.GetActivityFeed)
+
+	return doc
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// errAlertChannelNotConfigured marks a delivery skipped because the channel
+// has nowhere to deliver to, e.g. SMTP is not configured.
+var errAlertChannelNotConfigured = errors.New("channel is not configured")
+
+// AlertChannel delivers a fired alert to the user.
+type AlertChannel interface {
+	Deliver(ctx context.Context, userID string, rule models.AlertRule, event models.AlertEvent) error
+}
+
+// inAppAlertChannel adds the alert to the user's notification inbox, which
+// also pushes it to open notification streams.
+type inAppAlertChannel struct {
+	notifications *NotificationService
+}
+
+func (c inAppAlertChannel) Deliver(ctx context.Context, userID string, rule models.AlertRule, event models.AlertEvent) error {
+	if c.notifications == nil {
+		return fmt.Errorf("%w: no notification inbox", errAlertChannelNotConfigured)
+	}
+	_, err := c.notifications.Notify(ctx, userID, NotificationInput{
+		Type:  config.NotificationTypeAlert,
+		Title: rule.Name,
+		Body:  event.Message,
+		Data:  map[string]string{"rule_id": rule.ID, "event_id": event.ID, "kind": rule.Kind},
+	})
+	return err
+}
+
+// smtpSendFunc matches smtp.SendMail so tests can capture messages.
+type smtpSendFunc func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+
+// SMTPAlertChannel emails alerts through the SMTP server configured by
+// SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD and SMTP_FROM. Alerts go
+// to the rule's email, or else to the account's email.
+type SMTPAlertChannel struct {
+	pool     *pgxpool.Pool
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+	send     smtpSendFunc
+}
+
+// NewSMTPAlertChannel creates an email channel. Without a host every delivery
+// is skipped.
+func NewSMTPAlertChannel(pool *pgxpool.Pool, settings config.SMTPSettings) *SMTPAlertChannel {
+	return &SMTPAlertChannel{
+		pool:     pool,
+		host:     settings.Host,
+		port:     settings.Port,
+		username: settings.Username,
+		password: settings.Password,
+		from:     settings.From,
+		send:     smtp.SendMail,
+	}
+}
+
+// Deliver sends the alert as a plain-text email.
+func (c *SMTPAlertChannel) Deliver(ctx context.Context, userID string, rule models.AlertRule, event models.AlertEvent) error {
+	if c.host == "" || c.from == "" {
+		return fmt.Errorf("%w: SMTP_HOST and SMTP_FROM are required", errAlertChannelNotConfigured)
+	}
+	to, err := c.recipient(ctx, userID, rule)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if c.username != "" {
+		auth = smtp.PlainAuth("", c.username, c.password, c.host)
+	}
+	msg := buildAlertEmail(c.from, to, rule, event)
+	if err := c.send(net.JoinHostPort(c.host, c.port), auth, c.from, []string{to}, msg); err != nil {
+		return fmt.Errorf("sending email: %w", err)
+	}
+	return nil
+}
+
+func (c *SMTPAlertChannel) recipient(ctx context.Context, userID string, rule models.AlertRule) (string, error) {
+	if rule.Email != nil {
+		return *rule.Email, nil
+	}
+	if c.pool == nil {
+		return "", fmt.Errorf("%w: no email address", errAlertChannelNotConfigured)
+	}
+	var email *string
+	err := c.pool.QueryRow(ctx, `SELECT email FROM auth.users WHERE id = $1`, userID).Scan(&email)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("looking up account email: %w", err)
+	}
+	if email == nil || *email == "" {
+		return "", fmt.Errorf("%w: no email address", errAlertChannelNotConfigured)
+	}
+	return *email, nil
+}
+
+// buildAlertEmail renders the RFC 5322 message for an alert.
+func buildAlertEmail(from, to string, rule models.AlertRule, event models.AlertEvent) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: Alert: %s\r\n", rule.Name)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	fmt.Fprintf(&b, "%s\r\n\r\n", event.Message)
+	fmt.Fprintf(&b, "Triggered at %s UTC.\r\n", event.TriggeredAt.UTC().Format("2006-01-02 15:04"))
+	return []byte(b.String())
+}
+
+// webhookAlertChannel sends the alert as an alert.triggered event through the
+// user's webhook endpoints, so it is signed, retried and logged like every
+// other webhook. A rule with webhook_url goes to the endpoint registered with
+// that url; otherwise every active endpoint subscribed to alert.triggered
+// receives it.
+type webhookAlertChannel struct {
+	webhooks *WebhookService
+}
+
+// alertWebhookData is the data of alert.triggered events.
+type alertWebhookData struct {
+	Rule  models.AlertRule  `json:"rule"`
+	Event models.AlertEvent `json:"event"`
+}
+
+func (c webhookAlertChannel) Deliver(ctx context.Context, userID string, rule models.AlertRule, event models.AlertEvent) error {
+	if c.webhooks == nil || c.webhooks.pool == nil {
+		return fmt.Errorf("%w: no webhook service", errAlertChannelNotConfigured)
+	}
+	queued, err := c.webhooks.queue(ctx, userID, config.WebhookEventAlertTriggered, alertWebhookData{Rule: rule, Event: event}, rule.WebhookURL)
+	if err != nil {
+		return err
+	}
+	if queued == 0 {
+		if rule.WebhookURL != nil {
+			return fmt.Errorf("%w: no active webhook endpoint has the rule's webhook_url", errAlertChannelNotConfigured)
+		}
+		return fmt.Errorf("%w: no active webhook endpoint receives %s", errAlertChannelNotConfigured, config.WebhookEventAlertTriggered)
+	}
+	return nil
+}
+
+// deliverAlert runs every channel of the rule and reports each outcome.
+// Unknown channels are skipped rather than failing the other deliveries.
+func deliverAlert(ctx context.Context, channels map[string]AlertChannel, userID string, rule models.AlertRule, event models.AlertEvent) []models.AlertDelivery {
+	deliveries := make([]models.AlertDelivery, 0, len(rule.Channels))
+	for _, name := range rule.Channels {
+		delivery := models.AlertDelivery{Channel: name, Status: "sent"}
+		channel, ok := channels[name]
+		if !ok {
+			delivery.Status = "skipped"
+			delivery.Error = errAlertChannelNotConfigured.Error()
+			deliveries = append(deliveries, delivery)
+			continue
+		}
+		if err := channel.Deliver(ctx, userID, rule, event); err != nil {
+			delivery.Status = "failed"
+			if errors.Is(err, errAlertChannelNotConfigured) {
+				delivery.Status = "skipped"
+			}
+			delivery.Error = err.Error()
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"fintu-tracking-backend/internal/models"
+)
+
+func TestWebhookAlertChannel_RequiresWebhookService(t *testing.T) {
+	t.Parallel()
+
+	err := webhookAlertChannel{}.Deliver(context.Background(), "user-1", models.AlertRule{}, models.AlertEvent{})
+	if !errors.Is(err, errAlertChannelNotConfigured) {
+		t.Errorf("error = %v, want errAlertChannelNotConfigured", err)
+	}
+}
+
+func TestSMTPAlertChannel_SendsToRuleEmail(t *testing.T) {
+	t.Parallel()
+
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotMsg []byte
+	channel := &SMTPAlertChannel{
+		host: "smtp.example.com", port: "587", from: "alerts@example.com",
+		send: func(addr string, _ smtp.Auth, from string, to []string, msg []byte) error {
+			gotAddr, gotFrom, gotTo, gotMsg = addr, from, to, msg
+			return nil
+		},
+	}
+	email := "me@example.com"
+	rule := models.AlertRule{Name: "VOO above 500", Email: &email}
+	event := models.AlertEvent{Message: "VOO crossed above 500 USD (now 501.00)", TriggeredAt: time.Date(2024, 3, 1, 15, 4, 0, 0, time.UTC)}
+
+	if err := channel.Deliver(context.Background(), "user-1", rule, event); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if gotAddr != "smtp.example.com:587" || gotFrom != "alerts@example.com" || len(gotTo) != 1 || gotTo[0] != email {
+		t.Errorf("sent %s from %s to %v", gotAddr, gotFrom, gotTo)
+	}
+	msg := string(gotMsg)
+	if !strings.Contains(msg, "Subject: Alert: VOO above 500\r\n") || !strings.Contains(msg, event.Message) ||
+		!strings.Contains(msg, "Triggered at 2024-03-01 15:04 UTC.") {
+		t.Errorf("message = %q", msg)
+	}
+}
+
+func TestSMTPAlertChannel_NotConfigured(t *testing.T) {
+	t.Parallel()
+
+	err := (&SMTPAlertChannel{}).Deliver(context.Background(), "user-1", models.AlertRule{}, models.AlertEvent{})
+	if !errors.Is(err, errAlertChannelNotConfigured) {
+		t.Errorf("error = %v, want errAlertChannelNotConfigured", err)
+	}
+}
+
+type stubAlertChannel struct{ err error }
+
+func (c stubAlertChannel) Deliver(context.Context, string, models.AlertRule, models.AlertEvent) error {
+	return c.err
+}
+
+func TestDeliverAlert_ReportsEachChannel(t *testing.T) {
+	t.Parallel()
+
+	channels := map[string]AlertChannel{
+		"in_app":  stubAlertChannel{},
+		"email":   stubAlertChannel{err: errAlertChannelNotConfigured},
+		"webhook": stubAlertChannel{err: errors.New("webhook returned HTTP 500")},
+	}
+	rule := models.AlertRule{Channels: []string{"in_app", "email", "webhook"}}
+	deliveries := deliverAlert(context.Background(), channels, "user-1", rule, models.AlertEvent{})
+
+	want := []string{"sent", "skipped", "failed"}
+	if len(deliveries) != len(want) {
+		t.Fatalf("deliveries = %+v", deliveries)
+	}
+	for i, d := range deliveries {
+		if d.Channel != rule.Channels[i] || d.Status != want[i] {
+			t.Errorf("delivery %d = %+v, want %s %s", i, d, rule.Channels[i], want[i])
+		}
+	}
+	if deliveries[0].Error != "" || deliveries[2].Error == "" {
+		t.Errorf("errors = %q / %q", deliveries[0].Error, deliveries[2].Error)
+	}
+}
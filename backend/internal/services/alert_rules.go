@@ -0,0 +1,239 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"slices"
+	"strings"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/i18n"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// alertRuleNameMaxLength caps alert rule names.
+const alertRuleNameMaxLength = 100
+
+// alertCheck is the outcome of checking one rule against an observed value.
+type alertCheck struct {
+	value     decimal.Decimal  // compared with the threshold; the percent move for fx_move
+	fire      bool             // the rule fires now
+	triggered bool             // new armed state of level rules
+	reference *decimal.Decimal // new fx_move reference rate
+}
+
+// checkAlertRule decides whether a rule fires for observed: the USD price
+// (price_cross), the unrealized P/L percent (holding_pl), the USD/COP rate
+// (fx_move) or the current drawdown percent (drawdown).
+//
+// Level rules fire once when their condition becomes true and re-arm when it
+// is false again. fx_move rules measure the move from the reference rate; the
+// first observation only sets the reference, and firing moves it to the
+// current rate so the next alert needs another full move.
+func checkAlertRule(kind, direction string, threshold decimal.Decimal, triggered bool, reference *decimal.Decimal, observed decimal.Decimal) alertCheck {
+	if kind == "fx_move" {
+		if reference == nil || !reference.IsPositive() {
+			return alertCheck{value: decimal.Zero, reference: &observed}
+		}
+		move := observed.Sub(*reference).Div(*reference).Mul(decimal.NewFromInt(100))
+		met := false
+		switch direction {
+		case "above":
+			met = move.GreaterThanOrEqual(threshold)
+		case "below":
+			met = move.LessThanOrEqual(threshold.Neg())
+		default:
+			met = move.Abs().GreaterThanOrEqual(threshold)
+		}
+		if met {
+			return alertCheck{value: move, fire: true, reference: &observed}
+		}
+		return alertCheck{value: move, reference: reference}
+	}
+
+	met := observed.GreaterThanOrEqual(threshold)
+	if direction == "below" {
+		met = observed.LessThanOrEqual(threshold)
+	}
+	return alertCheck{value: observed, fire: met && !triggered, triggered: met}
+}
+
+// currentDrawdown returns how far the compounded return index is below its
+// running peak after the last return, as a positive fraction.
+func currentDrawdown(returns []float64) float64 {
+	index, peak := 1.0, 1.0
+	for _, r := range returns {
+		index *= 1 + r
+		if index > peak {
+			peak = index
+		}
+	}
+	return 1 - index/peak
+}
+
+// alertMessage describes a fired rule for every delivery channel, in the
+// user's locale.
+func alertMessage(loc i18n.Locale, rule models.AlertRule, check alertCheck, observed decimal.Decimal, previousReference *decimal.Decimal) string {
+	threshold := loc.Number(decimal.RequireFromString(rule.Threshold))
+	ticker := ""
+	if rule.Ticker != nil {
+		ticker = *rule.Ticker
+	}
+	switch rule.Kind {
+	case "price_cross":
+		if rule.Direction == "below" {
+			return loc.Sprintf("%s crossed below %s USD (now %s)", ticker, threshold, loc.Decimal(observed, 2))
+		}
+		return loc.Sprintf("%s crossed above %s USD (now %s)", ticker, threshold, loc.Decimal(observed, 2))
+	case "holding_pl":
+		if rule.Direction == "below" {
+			return loc.Sprintf("%s unrealized P/L is %s%%, below %s%%", ticker, loc.Decimal(observed, 2), threshold)
+		}
+		return loc.Sprintf("%s unrealized P/L is %s%%, above %s%%", ticker, loc.Decimal(observed, 2), threshold)
+	case "fx_move":
+		if previousReference != nil {
+			return loc.Sprintf("USD/COP moved %s%% from %s to %s", loc.Decimal(check.value, 2), loc.Decimal(*previousReference, 2), loc.Decimal(observed, 2))
+		}
+		return loc.Sprintf("USD/COP moved %s%% to %s", loc.Decimal(check.value, 2), loc.Decimal(observed, 2))
+	default:
+		return loc.Sprintf("Portfolio drawdown reached %s%% (limit %s%%)", loc.Decimal(observed, 2), threshold)
+	}
+}
+
+// normalizeAlertRule validates a new rule and fills in defaults: in_app
+// delivery, "any" direction for fx_move and "above" for drawdown.
+func normalizeAlertRule(req models.CreateAlertRuleRequest) (models.AlertRule, error) {
+	rule := models.AlertRule{
+		Kind:      strings.ToLower(strings.TrimSpace(req.Kind)),
+		Direction: strings.ToLower(strings.TrimSpace(req.Direction)),
+		Active:    true,
+	}
+	if !slices.Contains(config.AlertKinds, rule.Kind) {
+		return rule, apperror.Detailf(ErrInvalidAlertRule, "kind must be one of %s", strings.Join(config.AlertKinds, ", "))
+	}
+
+	switch rule.Kind {
+	case "price_cross", "holding_pl":
+		if req.Ticker == nil || strings.TrimSpace(*req.Ticker) == "" {
+			return rule, apperror.Detailf(ErrInvalidAlertRule, "ticker is required for %s rules", rule.Kind)
+		}
+		ticker := strings.ToUpper(strings.TrimSpace(*req.Ticker))
+		rule.Ticker = &ticker
+		if rule.Direction != "above" && rule.Direction != "below" {
+			return rule, fmt.Errorf("%w: direction must be above or below", ErrInvalidAlertRule)
+		}
+	case "fx_move":
+		if rule.Direction == "" {
+			rule.Direction = "any"
+		}
+	case "drawdown":
+		if rule.Direction == "" {
+			rule.Direction = "above"
+		}
+		if rule.Direction != "above" {
+			return rule, fmt.Errorf("%w: drawdown rules only fire above the threshold", ErrInvalidAlertRule)
+		}
+	}
+
+	name := req.Name
+	threshold := req.Threshold
+	direction := rule.Direction
+	if err := applyAlertRuleFields(&rule, models.UpdateAlertRuleRequest{
+		Name:       &name,
+		Direction:  &direction,
+		Threshold:  &threshold,
+		Channels:   req.Channels,
+		Email:      req.Email,
+		WebhookURL: req.WebhookURL,
+	}); err != nil {
+		return rule, err
+	}
+	if len(rule.Channels) == 0 {
+		rule.Channels = []string{"in_app"}
+	}
+	return rule, nil
+}
+
+// applyAlertRuleFields validates and applies the editable fields that are set.
+// Kind and ticker are fixed at creation.
+func applyAlertRuleFields(rule *models.AlertRule, req models.UpdateAlertRuleRequest) error {
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || len(name) > alertRuleNameMaxLength {
+			return apperror.Detailf(ErrInvalidAlertRule, "name is required (at most %d characters)", alertRuleNameMaxLength)
+		}
+		rule.Name = name
+	}
+	if req.Direction != nil {
+		direction := strings.ToLower(strings.TrimSpace(*req.Direction))
+		allowed := []string{"above", "below"}
+		switch rule.Kind {
+		case "fx_move":
+			allowed = append(allowed, "any")
+		case "drawdown":
+			allowed = []string{"above"}
+		}
+		if !slices.Contains(allowed, direction) {
+			return apperror.Detailf(ErrInvalidAlertRule, "direction must be one of %s", strings.Join(allowed, ", "))
+		}
+		rule.Direction = direction
+	}
+	if req.Threshold != nil {
+		threshold, err := decimal.NewFromString(strings.TrimSpace(*req.Threshold))
+		if err != nil {
+			return fmt.Errorf("%w: threshold must be a number", ErrInvalidAlertRule)
+		}
+		switch rule.Kind {
+		case "price_cross", "fx_move":
+			if !threshold.IsPositive() {
+				return fmt.Errorf("%w: threshold must be positive", ErrInvalidAlertRule)
+			}
+		case "drawdown":
+			if !threshold.IsPositive() || threshold.GreaterThanOrEqual(decimal.NewFromInt(100)) {
+				return fmt.Errorf("%w: drawdown threshold must be between 0 and 100 (percent)", ErrInvalidAlertRule)
+			}
+		}
+		rule.Threshold = threshold.String()
+	}
+	if req.Email != nil {
+		rule.Email = nil
+		if email := strings.TrimSpace(*req.Email); email != "" {
+			if _, err := mail.ParseAddress(email); err != nil {
+				return fmt.Errorf("%w: email is not a valid address", ErrInvalidAlertRule)
+			}
+			rule.Email = &email
+		}
+	}
+	if req.WebhookURL != nil {
+		rule.WebhookURL = nil
+		if raw := strings.TrimSpace(*req.WebhookURL); raw != "" {
+			if _, err := checkPublicURL(raw); errors.Is(err, errNonPublicAddress) {
+				return fmt.Errorf("%w: webhook_url must point to a public address", ErrInvalidAlertRule)
+			} else if err != nil {
+				return fmt.Errorf("%w: webhook_url must be an http(s) URL", ErrInvalidAlertRule)
+			}
+			rule.WebhookURL = &raw
+		}
+	}
+	if req.Channels != nil {
+		channels := make([]string, 0, len(req.Channels))
+		for _, ch := range req.Channels {
+			ch = strings.ToLower(strings.TrimSpace(ch))
+			if !slices.Contains(config.AlertChannels, ch) {
+				return apperror.Detailf(ErrInvalidAlertRule, "channels must be among %s", strings.Join(config.AlertChannels, ", "))
+			}
+			if !slices.Contains(channels, ch) {
+				channels = append(channels, ch)
+			}
+		}
+		if len(channels) == 0 {
+			return fmt.Errorf("%w: at least one channel is required", ErrInvalidAlertRule)
+		}
+		rule.Channels = channels
+	}
+	return nil
+}
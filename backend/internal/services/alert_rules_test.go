@@ -0,0 +1,183 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"fintu-tracking-backend/internal/i18n"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCheckAlertRule_LevelRulesFireOncePerCrossing(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		direction     string
+		triggered     bool
+		observed      string
+		wantFire      bool
+		wantTriggered bool
+	}{
+		{"crosses above", "above", false, "501", true, true},
+		{"stays above", "above", true, "505", false, true},
+		{"falls back re-arms", "above", true, "499", false, false},
+		{"crosses below", "below", false, "499", true, true},
+		{"not yet below", "below", false, "500.01", false, false},
+		{"touching the threshold counts", "above", false, "500", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			check := checkAlertRule("price_cross", tt.direction, dec("500"), tt.triggered, nil, dec(tt.observed))
+			if check.fire != tt.wantFire || check.triggered != tt.wantTriggered {
+				t.Errorf("fire/triggered = %v/%v, want %v/%v", check.fire, check.triggered, tt.wantFire, tt.wantTriggered)
+			}
+			if !check.value.Equal(dec(tt.observed)) {
+				t.Errorf("value = %s, want %s", check.value, tt.observed)
+			}
+		})
+	}
+}
+
+func TestCheckAlertRule_FxMove(t *testing.T) {
+	t.Parallel()
+
+	// The first observation only sets the reference.
+	check := checkAlertRule("fx_move", "any", dec("2"), false, nil, dec("4000"))
+	if check.fire || check.reference == nil || !check.reference.Equal(dec("4000")) {
+		t.Fatalf("first check = %+v, want reference 4000 without firing", check)
+	}
+
+	ref := dec("4000")
+	tests := []struct {
+		direction string
+		observed  string
+		wantFire  bool
+		wantMove  string
+	}{
+		{"any", "4080", true, "2"},
+		{"any", "3920", true, "-2"},
+		{"any", "4040", false, "1"},
+		{"above", "3900", false, "-2.5"},
+		{"below", "3900", true, "-2.5"},
+	}
+	for _, tt := range tests {
+		check := checkAlertRule("fx_move", tt.direction, dec("2"), false, &ref, dec(tt.observed))
+		if check.fire != tt.wantFire || !check.value.Equal(dec(tt.wantMove)) {
+			t.Errorf("%s %s: fire=%v move=%s, want %v %s", tt.direction, tt.observed, check.fire, check.value, tt.wantFire, tt.wantMove)
+		}
+		wantRef := ref
+		if tt.wantFire {
+			wantRef = dec(tt.observed)
+		}
+		if check.reference == nil || !check.reference.Equal(wantRef) {
+			t.Errorf("%s %s: reference = %v, want %s", tt.direction, tt.observed, check.reference, wantRef)
+		}
+	}
+}
+
+func TestCurrentDrawdown(t *testing.T) {
+	t.Parallel()
+
+	// 100 -> 110 -> 99 -> 104.5: 5% below the 110 peak.
+	got := currentDrawdown([]float64{0.10, -0.10, 104.5/99 - 1})
+	if math.Abs(got-0.05) > 1e-9 {
+		t.Errorf("drawdown = %v, want 0.05", got)
+	}
+	if got := currentDrawdown([]float64{0.01, 0.02}); got != 0 {
+		t.Errorf("drawdown at a new high = %v, want 0", got)
+	}
+}
+
+func TestAlertMessage(t *testing.T) {
+	t.Parallel()
+
+	ticker := "VOO"
+	rule := models.AlertRule{Kind: "price_cross", Ticker: &ticker, Direction: "above", Threshold: "500"}
+	if got := alertMessage(i18n.English, rule, alertCheck{}, dec("1501.237"), nil); got != "VOO crossed above 500 USD (now 1,501.24)" {
+		t.Errorf("price message = %q", got)
+	}
+	if got := alertMessage(i18n.SpanishColombia, rule, alertCheck{}, dec("1501.237"), nil); got != "VOO cruzó por encima de 500 USD (ahora 1.501,24)" {
+		t.Errorf("es-CO price message = %q", got)
+	}
+
+	ref := dec("4000")
+	rule = models.AlertRule{Kind: "fx_move", Direction: "any", Threshold: "2"}
+	if got := alertMessage(i18n.English, rule, alertCheck{value: dec("2.5")}, dec("4100"), &ref); got != "USD/COP moved 2.50% from 4,000.00 to 4,100.00" {
+		t.Errorf("fx message = %q", got)
+	}
+	if got := alertMessage(i18n.SpanishColombia, rule, alertCheck{value: dec("2.5")}, dec("4100"), &ref); got != "USD/COP se movió 2,50% de 4.000,00 a 4.100,00" {
+		t.Errorf("es-CO fx message = %q", got)
+	}
+
+	rule = models.AlertRule{Kind: "drawdown", Direction: "above", Threshold: "12.5"}
+	if got := alertMessage(i18n.SpanishColombia, rule, alertCheck{}, dec("13"), nil); got != "El drawdown del portafolio llegó a 13,00% (límite 12,5%)" {
+		t.Errorf("es-CO drawdown message = %q", got)
+	}
+}
+
+func TestNormalizeAlertRule(t *testing.T) {
+	t.Parallel()
+
+	rule, err := normalizeAlertRule(models.CreateAlertRuleRequest{
+		Name: " VOO above 500 ", Kind: "price_cross", Ticker: strPtr(" voo "), Direction: "Above", Threshold: "500.50",
+	})
+	if err != nil {
+		t.Fatalf("normalizeAlertRule: %v", err)
+	}
+	if rule.Name != "VOO above 500" || *rule.Ticker != "VOO" || rule.Direction != "above" || rule.Threshold != "500.5" ||
+		len(rule.Channels) != 1 || rule.Channels[0] != "in_app" || !rule.Active {
+		t.Errorf("rule = %+v", rule)
+	}
+
+	rule, err = normalizeAlertRule(models.CreateAlertRuleRequest{
+		Name: "COP", Kind: "fx_move", Threshold: "3", Channels: []string{"email", "EMAIL", "webhook"},
+		WebhookURL: strPtr("https://example.com/hook"),
+	})
+	if err != nil {
+		t.Fatalf("normalizeAlertRule: %v", err)
+	}
+	if rule.Direction != "any" || len(rule.Channels) != 2 || rule.Ticker != nil {
+		t.Errorf("fx rule = %+v", rule)
+	}
+
+	invalid := []models.CreateAlertRuleRequest{
+		{Name: "x", Kind: "volume", Threshold: "1"},
+		{Name: "x", Kind: "price_cross", Direction: "above", Threshold: "1"},
+		{Name: "x", Kind: "price_cross", Ticker: strPtr("VOO"), Threshold: "1"},
+		{Name: "x", Kind: "price_cross", Ticker: strPtr("VOO"), Direction: "above", Threshold: "0"},
+		{Name: "x", Kind: "drawdown", Threshold: "120"},
+		{Name: "x", Kind: "drawdown", Direction: "below", Threshold: "10"},
+		{Name: "x", Kind: "fx_move", Threshold: "abc"},
+		{Name: "", Kind: "fx_move", Threshold: "2"},
+		{Name: "x", Kind: "fx_move", Threshold: "2", Channels: []string{"sms"}},
+		{Name: "x", Kind: "fx_move", Threshold: "2", Channels: []string{"webhook"}, WebhookURL: strPtr("ftp://example.com")},
+		{Name: "x", Kind: "fx_move", Threshold: "2", Channels: []string{"webhook"}, WebhookURL: strPtr("http://169.254.169.254/")},
+		{Name: "x", Kind: "fx_move", Threshold: "2", Email: strPtr("not-an-email")},
+	}
+	for _, req := range invalid {
+		if _, err := normalizeAlertRule(req); !errors.Is(err, ErrInvalidAlertRule) {
+			t.Errorf("normalizeAlertRule(%+v) error = %v, want ErrInvalidAlertRule", req, err)
+		}
+	}
+}
+
+func TestApplyAlertRuleFields_HoldingPLAllowsNegativeThreshold(t *testing.T) {
+	t.Parallel()
+
+	rule := models.AlertRule{Kind: "holding_pl", Direction: "above", Threshold: "20"}
+	if err := applyAlertRuleFields(&rule, models.UpdateAlertRuleRequest{Direction: strPtr("below"), Threshold: strPtr("-10")}); err != nil {
+		t.Fatalf("applyAlertRuleFields: %v", err)
+	}
+	if rule.Direction != "below" || !decimal.RequireFromString(rule.Threshold).Equal(dec("-10")) {
+		t.Errorf("rule = %+v", rule)
+	}
+	if err := applyAlertRuleFields(&rule, models.UpdateAlertRuleRequest{Direction: strPtr("any")}); !errors.Is(err, ErrInvalidAlertRule) {
+		t.Errorf("direction any on holding_pl error = %v, want ErrInvalidAlertRule", err)
+	}
+}
@@ -0,0 +1,576 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/i18n"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrAlertRuleNotFound  = apperror.New(apperror.NotFound, "alert rule not found")
+	ErrInvalidAlertRule   = apperror.New(apperror.Validation, "invalid alert rule")
+	ErrAlertRuleNameTaken = apperror.New(apperror.Conflict, "an alert rule with that name already exists")
+	ErrAlertRuleLimit     = apperror.Newf(apperror.Conflict, "at most %d alert rules are allowed", config.MaxAlertRules)
+)
+
+const alertRuleColumns = `id, portfolio_id, name, kind, ticker, direction, threshold::text AS threshold,
+	channels, email, webhook_url, active, triggered, reference_value::text AS reference_value,
+	last_value::text AS last_value, last_evaluated_at, last_triggered_at, created_at, updated_at`
+
+const alertEventColumns = `id, rule_id, kind, message, value::text AS value, threshold::text AS threshold,
+	deliveries, triggered_at`
+
+// AlertService manages alert rules and evaluates them against the latest
+// prices, holdings, FX rate and portfolio drawdown.
+type AlertService struct {
+	pool       *pgxpool.Pool
+	portfolios *PortfolioService
+	webhooks   *WebhookService
+	channels   map[string]AlertChannel
+	background sync.WaitGroup
+}
+
+// NewAlertService creates an AlertService delivering to the notification
+// inbox, email (through the given SMTP server) and the user's webhook
+// endpoints.
+func NewAlertService(pool *pgxpool.Pool, notifications *NotificationService, webhooks *WebhookService, smtpSettings config.SMTPSettings) *AlertService {
+	return &AlertService{
+		pool:       pool,
+		portfolios: NewPortfolioService(pool),
+		webhooks:   webhooks,
+		channels: map[string]AlertChannel{
+			"in_app":  inAppAlertChannel{notifications: notifications},
+			"email":   NewSMTPAlertChannel(pool, smtpSettings),
+			"webhook": webhookAlertChannel{webhooks: webhooks},
+		},
+	}
+}
+
+// ListRules returns the user's alert rules ordered by name.
+func (s *AlertService) ListRules(ctx context.Context, userID string) ([]models.AlertRule, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+alertRuleColumns+`
+		FROM alert_rules
+		WHERE user_id = $1
+		ORDER BY name
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying alert rules: %w", err)
+	}
+	rules, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.AlertRule])
+	if err != nil {
+		return nil, fmt.Errorf("collecting alert rules: %w", err)
+	}
+	return rules, nil
+}
+
+// GetRule returns one of the user's alert rules.
+func (s *AlertService) GetRule(ctx context.Context, userID, ruleID string) (*models.AlertRule, error) {
+	if _, err := uuid.Parse(ruleID); err != nil {
+		return nil, ErrAlertRuleNotFound
+	}
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+alertRuleColumns+`
+		FROM alert_rules
+		WHERE id = $1 AND user_id = $2
+	`, ruleID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying alert rule: %w", err)
+	}
+	rule, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.AlertRule])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAlertRuleNotFound
+		}
+		return nil, fmt.Errorf("collecting alert rule: %w", err)
+	}
+	return &rule, nil
+}
+
+// CreateRule saves an alert rule. Rules without portfolio_id watch the
+// consolidated holdings and drawdown. fx_move rules start from the latest
+// recorded USD/COP rate.
+func (s *AlertService) CreateRule(ctx context.Context, userID string, req models.CreateAlertRuleRequest) (*models.AlertRule, error) {
+	rule, err := normalizeAlertRule(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.PortfolioID != nil && *req.PortfolioID != "" {
+		portfolio, err := s.portfolios.GetPortfolio(ctx, userID, *req.PortfolioID)
+		if err != nil {
+			return nil, err
+		}
+		rule.PortfolioID = &portfolio.ID
+	}
+	if err := s.checkWebhookURL(ctx, userID, rule); err != nil {
+		return nil, err
+	}
+
+	var count int
+	if err := s.pool.QueryRow(ctx, `SELECT COUNT(*) FROM alert_rules WHERE user_id = $1`, userID).Scan(&count); err != nil {
+		return nil, fmt.Errorf("counting alert rules: %w", err)
+	}
+	if count >= config.MaxAlertRules {
+		return nil, ErrAlertRuleLimit
+	}
+
+	if rule.Kind == "fx_move" {
+		rate, ok, err := NewPostgresMarketDataStore(s.pool).GetLatestFxRate(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			rule.ReferenceValue = &rate.Rate
+		}
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		INSERT INTO alert_rules (user_id, portfolio_id, name, kind, ticker, direction, threshold,
+			channels, email, webhook_url, reference_value)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING `+alertRuleColumns,
+		userID, rule.PortfolioID, rule.Name, rule.Kind, rule.Ticker, rule.Direction, rule.Threshold,
+		rule.Channels, rule.Email, rule.WebhookURL, rule.ReferenceValue)
+	if err != nil {
+		return nil, fmt.Errorf("creating alert rule: %w", err)
+	}
+	created, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.AlertRule])
+	if err != nil {
+		return nil, mapAlertRuleWriteError(err)
+	}
+	return &created, nil
+}
+
+// UpdateRule changes a rule's name, condition, channels or active state.
+// Changing the direction or threshold re-arms the rule.
+func (s *AlertService) UpdateRule(ctx context.Context, userID, ruleID string, req models.UpdateAlertRuleRequest) (*models.AlertRule, error) {
+	rule, err := s.GetRule(ctx, userID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyAlertRuleFields(rule, req); err != nil {
+		return nil, err
+	}
+	if req.WebhookURL != nil {
+		if err := s.checkWebhookURL(ctx, userID, *rule); err != nil {
+			return nil, err
+		}
+	}
+	if req.Active != nil {
+		rule.Active = *req.Active
+	}
+	rearm := req.Direction != nil || req.Threshold != nil
+
+	rows, err := s.pool.Query(ctx, `
+		UPDATE alert_rules SET name = $3, direction = $4, threshold = $5, channels = $6, email = $7,
+			webhook_url = $8, active = $9, triggered = triggered AND NOT $10
+		WHERE id = $1 AND user_id = $2
+		RETURNING `+alertRuleColumns,
+		ruleID, userID, rule.Name, rule.Direction, rule.Threshold, rule.Channels, rule.Email,
+		rule.WebhookURL, rule.Active, rearm)
+	if err != nil {
+		return nil, fmt.Errorf("updating alert rule: %w", err)
+	}
+	updated, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.AlertRule])
+	if err != nil {
+		return nil, mapAlertRuleWriteError(err)
+	}
+	return &updated, nil
+}
+
+// checkWebhookURL requires a rule's webhook_url to be the url of one of the
+// user's webhook endpoints, which is where the webhook channel delivers.
+func (s *AlertService) checkWebhookURL(ctx context.Context, userID string, rule models.AlertRule) error {
+	if rule.WebhookURL == nil {
+		return nil
+	}
+	if s.webhooks == nil {
+		return fmt.Errorf("%w: webhook_url must be the url of one of your webhooks", ErrInvalidAlertRule)
+	}
+	exists, err := s.webhooks.hasEndpointURL(ctx, userID, *rule.WebhookURL)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: webhook_url must be the url of one of your webhooks", ErrInvalidAlertRule)
+	}
+	return nil
+}
+
+// DeleteRule removes an alert rule and its events.
+func (s *AlertService) DeleteRule(ctx context.Context, userID, ruleID string) error {
+	if _, err := uuid.Parse(ruleID); err != nil {
+		return ErrAlertRuleNotFound
+	}
+	tag, err := s.pool.Exec(ctx, `DELETE FROM alert_rules WHERE id = $1 AND user_id = $2`, ruleID, userID)
+	if err != nil {
+		return fmt.Errorf("deleting alert rule: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrAlertRuleNotFound
+	}
+	return nil
+}
+
+// ListEvents returns the most recent alert events, optionally for one rule.
+func (s *AlertService) ListEvents(ctx context.Context, userID, ruleID string, limit int) ([]models.AlertEvent, error) {
+	var ruleFilter *string
+	if ruleID != "" {
+		if _, err := s.GetRule(ctx, userID, ruleID); err != nil {
+			return nil, err
+		}
+		ruleFilter = &ruleID
+	}
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+alertEventColumns+`
+		FROM alert_events
+		WHERE user_id = $1 AND ($2::uuid IS NULL OR rule_id = $2::uuid)
+		ORDER BY triggered_at DESC
+		LIMIT $3
+	`, userID, ruleFilter, limit)
+	if err != nil {
+		return nil, fmt.Errorf("querying alert events: %w", err)
+	}
+	events, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.AlertEvent])
+	if err != nil {
+		return nil, fmt.Errorf("collecting alert events: %w", err)
+	}
+	return events, nil
+}
+
+// EvaluateInBackground evaluates the user's rules without blocking the caller.
+// FX rate handlers call it once a rate is stored; rates belong to one user.
+func (s *AlertService) EvaluateInBackground(userID string) {
+	s.background.Go(func() {
+		s.evaluateUser(userID)
+	})
+}
+
+// EvaluateTickersInBackground evaluates, without blocking the caller, the
+// rules of userID and of every user with an active price_cross or holding_pl
+// rule on one of tickers. Market prices are shared, so one user's refresh can
+// fire another user's rules.
+func (s *AlertService) EvaluateTickersInBackground(userID string, tickers []string) {
+	s.background.Go(func() {
+		s.evaluateUser(userID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), config.AlertEvaluationTimeout)
+		defer cancel()
+		rows, err := s.pool.Query(ctx, `
+			SELECT DISTINCT user_id
+			FROM alert_rules
+			WHERE active AND kind IN ('price_cross', 'holding_pl') AND ticker = ANY($1) AND user_id <> $2
+		`, tickers, userID)
+		if err != nil {
+			slog.ErrorContext(ctx, "querying alert rules on refreshed tickers failed", "error", err)
+			return
+		}
+		others, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			slog.ErrorContext(ctx, "collecting alert rules on refreshed tickers failed", "error", err)
+			return
+		}
+		for _, other := range others {
+			s.evaluateUser(other)
+		}
+	})
+}
+
+// RunEvaluator evaluates the rules of every user with an active rule every
+// interval until ctx is done. It catches what no refresh announces, such as
+// a drawdown after another user's daily price backfill.
+func (s *AlertService) RunEvaluator(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		rows, err := s.pool.Query(ctx, `SELECT DISTINCT user_id FROM alert_rules WHERE active`)
+		if err != nil {
+			slog.ErrorContext(ctx, "alert evaluator failed", "error", err)
+			continue
+		}
+		userIDs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			slog.ErrorContext(ctx, "alert evaluator failed", "error", err)
+			continue
+		}
+		for _, userID := range userIDs {
+			if ctx.Err() != nil {
+				return
+			}
+			s.evaluateUser(userID)
+		}
+	}
+}
+
+// evaluateUser evaluates the user's rules in their profile locale and logs
+// the outcome.
+func (s *AlertService) evaluateUser(userID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), config.AlertEvaluationTimeout)
+	defer cancel()
+	ctx = i18n.WithLocale(ctx, s.profileLocale(ctx, userID))
+	if result, err := s.Evaluate(ctx, userID); err != nil {
+		slog.ErrorContext(ctx, "alert evaluation failed", "user_id", userID, "error", err)
+	} else if len(result.Triggered) > 0 {
+		slog.InfoContext(ctx, "alert rules fired", "user_id", userID, "count", len(result.Triggered))
+	}
+}
+
+// profileLocale returns the locale saved in the user's profile, which alert
+// messages are written in when no request carries one. It falls back to
+// i18n.Default.
+func (s *AlertService) profileLocale(ctx context.Context, userID string) i18n.Locale {
+	var code *string
+	err := s.pool.QueryRow(ctx, `SELECT locale FROM profiles WHERE user_id = $1`, userID).Scan(&code)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		slog.WarnContext(ctx, "alert locale lookup failed", "user_id", userID, "error", err)
+	}
+	if code != nil {
+		if locale, ok := i18n.Parse(*code); ok {
+			return locale
+		}
+	}
+	return i18n.Default
+}
+
+// Wait blocks until background evaluations started by EvaluateInBackground
+// and EvaluateTickersInBackground have finished. Shutdown calls it after the HTTP server has drained.
+func (s *AlertService) Wait() {
+	s.background.Wait()
+}
+
+// Evaluate checks the user's active rules against stored data, records an
+// event for every rule that fires and delivers it on the rule's channels.
+func (s *AlertService) Evaluate(ctx context.Context, userID string) (*models.AlertEvaluation, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+alertRuleColumns+`
+		FROM alert_rules
+		WHERE user_id = $1 AND active
+		ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying active alert rules: %w", err)
+	}
+	rules, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.AlertRule])
+	if err != nil {
+		return nil, fmt.Errorf("collecting active alert rules: %w", err)
+	}
+
+	result := &models.AlertEvaluation{Triggered: []models.AlertEvent{}}
+	inputs := newAlertInputs(s.pool, userID, rules)
+	for _, rule := range rules {
+		observed, ok, err := inputs.observe(ctx, rule)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			result.Skipped++
+			continue
+		}
+		result.Evaluated++
+
+		event, err := s.applyCheck(ctx, userID, rule, observed)
+		if err != nil {
+			return nil, err
+		}
+		if event != nil {
+			result.Triggered = append(result.Triggered, *event)
+		}
+	}
+	return result, nil
+}
+
+// applyCheck stores the rule's new state and, when it fires, records and
+// delivers the event. The state update only applies if no concurrent
+// evaluation changed the rule first, so a rule never fires twice for one move.
+func (s *AlertService) applyCheck(ctx context.Context, userID string, rule models.AlertRule, observed decimal.Decimal) (*models.AlertEvent, error) {
+	threshold := decimal.RequireFromString(rule.Threshold)
+	var reference *decimal.Decimal
+	if rule.ReferenceValue != nil {
+		ref := decimal.RequireFromString(*rule.ReferenceValue)
+		reference = &ref
+	}
+	check := checkAlertRule(rule.Kind, rule.Direction, threshold, rule.Triggered, reference, observed)
+
+	var newReference *string
+	if check.reference != nil {
+		value := check.reference.String()
+		newReference = &value
+	}
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE alert_rules
+		SET triggered = $3, reference_value = $4, last_value = $5, last_evaluated_at = NOW(),
+			last_triggered_at = CASE WHEN $6 THEN NOW() ELSE last_triggered_at END
+		WHERE id = $1 AND user_id = $2 AND triggered = $7
+			AND reference_value IS NOT DISTINCT FROM $8::numeric
+	`, rule.ID, userID, check.triggered, newReference, check.value.String(), check.fire,
+		rule.Triggered, rule.ReferenceValue)
+	if err != nil {
+		return nil, fmt.Errorf("updating alert rule state: %w", err)
+	}
+	if !check.fire || tag.RowsAffected() == 0 {
+		return nil, nil
+	}
+
+	message := alertMessage(i18n.FromContext(ctx), rule, check, observed, reference)
+	rows, err := s.pool.Query(ctx, `
+		INSERT INTO alert_events (rule_id, user_id, kind, message, value, threshold)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+alertEventColumns,
+		rule.ID, userID, rule.Kind, message, check.value.String(), rule.Threshold)
+	if err != nil {
+		return nil, fmt.Errorf("recording alert event: %w", err)
+	}
+	event, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.AlertEvent])
+	if err != nil {
+		return nil, fmt.Errorf("collecting alert event: %w", err)
+	}
+
+	event.Deliveries = deliverAlert(ctx, s.channels, userID, rule, event)
+	if _, err := s.pool.Exec(ctx, `UPDATE alert_events SET deliveries = $2 WHERE id = $1`,
+		event.ID, event.Deliveries); err != nil {
+		return nil, fmt.Errorf("recording alert deliveries: %w", err)
+	}
+	return &event, nil
+}
+
+// alertInputs loads each kind of observed value once per evaluation run.
+type alertInputs struct {
+	pool      *pgxpool.Pool
+	userID    string
+	tickers   []string // price_cross tickers
+	prices    map[string]decimal.Decimal
+	holdings  map[string]map[string]models.Holding // by portfolio scope, then ticker
+	fxRate    *decimal.Decimal
+	fxLoaded  bool
+	drawdowns map[string]*decimal.Decimal // by portfolio scope; nil without history
+}
+
+func newAlertInputs(pool *pgxpool.Pool, userID string, rules []models.AlertRule) *alertInputs {
+	in := &alertInputs{
+		pool:      pool,
+		userID:    userID,
+		holdings:  map[string]map[string]models.Holding{},
+		drawdowns: map[string]*decimal.Decimal{},
+	}
+	for _, rule := range rules {
+		if rule.Kind == "price_cross" {
+			in.tickers = appendUniqueTicker(in.tickers, *rule.Ticker)
+		}
+	}
+	return in
+}
+
+// loadPrices reads the cached market price of every price_cross ticker.
+func (in *alertInputs) loadPrices(ctx context.Context) error {
+	prices, err := NewPostgresMarketDataStore(in.pool).GetMarketPrices(ctx, in.tickers)
+	if err != nil {
+		return err
+	}
+	in.prices = make(map[string]decimal.Decimal, len(prices))
+	for _, p := range prices {
+		if v, err := decimal.NewFromString(p.Price); err == nil {
+			in.prices[p.Ticker] = v
+		}
+	}
+	return nil
+}
+
+// observe returns the value a rule is checked against, and false when there
+// is no data for it yet.
+func (in *alertInputs) observe(ctx context.Context, rule models.AlertRule) (decimal.Decimal, bool, error) {
+	scope := ""
+	if rule.PortfolioID != nil {
+		scope = *rule.PortfolioID
+	}
+	switch rule.Kind {
+	case "price_cross":
+		if in.prices == nil {
+			if err := in.loadPrices(ctx); err != nil {
+				return decimal.Zero, false, err
+			}
+		}
+		price, ok := in.prices[*rule.Ticker]
+		return price, ok, nil
+	case "holding_pl":
+		byTicker, ok := in.holdings[scope]
+		if !ok {
+			holdings, err := NewAnalyticsService(in.pool).ForPortfolio(scope).GetCurrentHoldings(ctx, in.userID)
+			if err != nil {
+				return decimal.Zero, false, err
+			}
+			byTicker = make(map[string]models.Holding, len(holdings))
+			for _, h := range holdings {
+				byTicker[h.Ticker] = h
+			}
+			in.holdings[scope] = byTicker
+		}
+		holding, ok := byTicker[*rule.Ticker]
+		if !ok || holding.PriceAsOf == nil {
+			return decimal.Zero, false, nil
+		}
+		pct, err := decimal.NewFromString(holding.UnrealizedPLPercent)
+		return pct, err == nil, nil
+	case "fx_move":
+		if !in.fxLoaded {
+			in.fxLoaded = true
+			rate, ok, err := NewPostgresMarketDataStore(in.pool).GetLatestFxRate(ctx, in.userID)
+			if err != nil {
+				return decimal.Zero, false, err
+			}
+			if v, err := decimal.NewFromString(rate.Rate); ok && err == nil && v.IsPositive() {
+				in.fxRate = &v
+			}
+		}
+		if in.fxRate == nil {
+			return decimal.Zero, false, nil
+		}
+		return *in.fxRate, true, nil
+	default:
+		drawdown, ok := in.drawdowns[scope]
+		if !ok {
+			returns, err := NewAnalyticsService(in.pool).ForPortfolio(scope).dailyPortfolioReturns(ctx, in.userID, dcaDate(time.Now()))
+			if err != nil {
+				return decimal.Zero, false, err
+			}
+			if len(returns) > 0 {
+				pct := decimal.NewFromFloat(currentDrawdown(returns) * 100).Round(4)
+				drawdown = &pct
+			}
+			in.drawdowns[scope] = drawdown
+		}
+		if drawdown == nil {
+			return decimal.Zero, false, nil
+		}
+		return *drawdown, true, nil
+	}
+}
+
+func mapAlertRuleWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrAlertRuleNameTaken
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrAlertRuleNotFound
+	}
+	return fmt.Errorf("writing alert rule: %w", err)
+}
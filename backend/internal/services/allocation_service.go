@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrTargetAllocationNotFound = apperror.New(apperror.NotFound, "portfolio has no target allocation")
+	ErrInvalidTargetAllocation  = apperror.New(apperror.Validation, "invalid target allocation")
+)
+
+const targetAllocationColumns = `portfolio_id, basis, drift_band::text AS drift_band, fractional_shares, targets, created_at, updated_at`
+
+// AllocationService stores per-portfolio target allocations and plans the
+// trades that bring a portfolio back to its targets.
+type AllocationService struct {
+	pool       *pgxpool.Pool
+	portfolios *PortfolioService
+	brokers    *BrokerService
+}
+
+// NewAllocationService creates an AllocationService backed by the given DB pool.
+func NewAllocationService(pool *pgxpool.Pool) *AllocationService {
+	return &AllocationService{
+		pool:       pool,
+		portfolios: NewPortfolioService(pool),
+		brokers:    NewBrokerService(pool),
+	}
+}
+
+// GetTargetAllocation returns the portfolio's target allocation.
+func (s *AllocationService) GetTargetAllocation(ctx context.Context, userID, portfolioID string) (*models.TargetAllocation, error) {
+	if _, err := s.portfolios.GetPortfolio(ctx, userID, portfolioID); err != nil {
+		return nil, err
+	}
+	return s.loadTargetAllocation(ctx, userID, portfolioID)
+}
+
+func (s *AllocationService) loadTargetAllocation(ctx context.Context, userID, portfolioID string) (*models.TargetAllocation, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+targetAllocationColumns+`
+		FROM target_allocations
+		WHERE portfolio_id = $1 AND user_id = $2
+	`, portfolioID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying target allocation: %w", err)
+	}
+	allocation, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.TargetAllocation])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTargetAllocationNotFound
+		}
+		return nil, fmt.Errorf("collecting target allocation: %w", err)
+	}
+	return &allocation, nil
+}
+
+// SetTargetAllocation creates or replaces the portfolio's target allocation.
+func (s *AllocationService) SetTargetAllocation(ctx context.Context, userID, portfolioID string, req models.SetTargetAllocationRequest) (*models.TargetAllocation, error) {
+	if _, err := s.portfolios.GetPortfolio(ctx, userID, portfolioID); err != nil {
+		return nil, err
+	}
+	normalized, err := normalizeTargetAllocation(req)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		INSERT INTO target_allocations (portfolio_id, user_id, basis, drift_band, fractional_shares, targets)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (portfolio_id) DO UPDATE SET
+			basis = EXCLUDED.basis,
+			drift_band = EXCLUDED.drift_band,
+			fractional_shares = EXCLUDED.fractional_shares,
+			targets = EXCLUDED.targets
+		RETURNING `+targetAllocationColumns,
+		portfolioID, userID, normalized.Basis, normalized.DriftBand, normalized.FractionalShares, normalized.Targets)
+	if err != nil {
+		return nil, fmt.Errorf("saving target allocation: %w", err)
+	}
+	allocation, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.TargetAllocation])
+	if err != nil {
+		return nil, fmt.Errorf("collecting target allocation: %w", err)
+	}
+	return &allocation, nil
+}
+
+// DeleteTargetAllocation removes the portfolio's target allocation.
+func (s *AllocationService) DeleteTargetAllocation(ctx context.Context, userID, portfolioID string) error {
+	if _, err := s.portfolios.GetPortfolio(ctx, userID, portfolioID); err != nil {
+		return err
+	}
+	tag, err := s.pool.Exec(ctx, `DELETE FROM target_allocations WHERE portfolio_id = $1 AND user_id = $2`, portfolioID, userID)
+	if err != nil {
+		return fmt.Errorf("deleting target allocation: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTargetAllocationNotFound
+	}
+	return nil
+}
+
+// PlanRebalance compares the portfolio's holdings and cash with its target
+// allocation and suggests orders. With allowSells false only cash is deployed.
+// Fees are estimated with the commissions of the portfolio's broker.
+func (s *AllocationService) PlanRebalance(ctx context.Context, userID, portfolioID string, allowSells bool) (*models.RebalancePlan, error) {
+	portfolio, err := s.portfolios.GetPortfolio(ctx, userID, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	allocation, err := s.loadTargetAllocation(ctx, userID, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	analytics := NewAnalyticsService(s.pool).ForPortfolio(portfolioID)
+	holdings, err := analytics.GetCurrentHoldings(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load holdings: %w", err)
+	}
+	cash, err := analytics.cashBalance(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	prices, err := s.targetPrices(ctx, allocation)
+	if err != nil {
+		return nil, err
+	}
+
+	input := rebalanceInput{
+		allocation: *allocation,
+		holdings:   holdings,
+		cash:       cash,
+		prices:     prices,
+		allowSells: allowSells,
+	}
+	if portfolio.BrokerID != nil {
+		schedule, err := s.brokers.FeeScheduleAt(ctx, userID, *portfolio.BrokerID, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if schedule != nil {
+			input.commission = func(assetType string, notional decimal.Decimal) decimal.Decimal {
+				fee, err := s.brokers.ComputeCommissionUSD(*schedule, assetType, notional)
+				if err != nil {
+					return decimal.Zero
+				}
+				return fee
+			}
+		}
+	}
+
+	plan := planRebalance(input)
+	plan.PortfolioID = portfolioID
+	if input.commission == nil {
+		plan.Warnings = append(plan.Warnings, "portfolio has no broker fee schedule; fees are not estimated")
+	}
+	return plan, nil
+}
+
+// targetPrices loads the latest market price of every ticker target, so tickers
+// that are not held yet can be bought.
+func (s *AllocationService) targetPrices(ctx context.Context, allocation *models.TargetAllocation) (map[string]decimal.Decimal, error) {
+	prices := make(map[string]decimal.Decimal)
+	if allocation.Basis != "ticker" {
+		return prices, nil
+	}
+	tickers := make([]string, 0, len(allocation.Targets))
+	for _, t := range allocation.Targets {
+		if t.Key != config.CashAllocationKey {
+			tickers = append(tickers, t.Key)
+		}
+	}
+	quotes, err := NewPostgresMarketDataStore(s.pool).GetMarketPrices(ctx, tickers)
+	if err != nil {
+		return nil, err
+	}
+	for _, q := range quotes {
+		if price, err := decimal.NewFromString(q.Price); err == nil && price.IsPositive() {
+			prices[q.Ticker] = price
+		}
+	}
+	return prices, nil
+}
+
+// normalizeTargetAllocation validates a request and fills in defaults: keys are
+// upper-cased tickers (or lower-case asset types), weights are positive and sum
+// to 1, and drift bands lie strictly between 0 and 1.
+func normalizeTargetAllocation(req models.SetTargetAllocationRequest) (models.TargetAllocation, error) {
+	out := models.TargetAllocation{
+		Basis:            strings.ToLower(strings.TrimSpace(req.Basis)),
+		DriftBand:        strings.TrimSpace(req.DriftBand),
+		FractionalShares: true,
+	}
+	if out.Basis == "" {
+		out.Basis = "ticker"
+	}
+	if out.Basis != "ticker" && out.Basis != "asset_type" {
+		return out, fmt.Errorf("%w: basis must be ticker or asset_type", ErrInvalidTargetAllocation)
+	}
+	if out.DriftBand == "" {
+		out.DriftBand = config.DefaultDriftBand
+	}
+	band, err := parseDriftBand(out.DriftBand)
+	if err != nil {
+		return out, err
+	}
+	out.DriftBand = band.String()
+	if req.FractionalShares != nil {
+		out.FractionalShares = *req.FractionalShares
+	}
+
+	if len(req.Targets) == 0 || len(req.Targets) > config.MaxAllocationTargets {
+		return out, apperror.Detailf(ErrInvalidTargetAllocation, "between 1 and %d targets are required", config.MaxAllocationTargets)
+	}
+
+	seen := make(map[string]bool, len(req.Targets))
+	total := decimal.Zero
+	for _, t := range req.Targets {
+		key := strings.ToUpper(strings.TrimSpace(t.Key))
+		if key == "" {
+			return out, fmt.Errorf("%w: target key is required", ErrInvalidTargetAllocation)
+		}
+		if key != config.CashAllocationKey && out.Basis == "asset_type" {
+			key = strings.ToLower(key)
+			if !slices.Contains(config.AllocationAssetTypes, key) {
+				return out, apperror.Detailf(ErrInvalidTargetAllocation, "asset type must be one of %s", strings.Join(config.AllocationAssetTypes, ", "))
+			}
+		}
+		if seen[key] {
+			return out, apperror.Detailf(ErrInvalidTargetAllocation, "%s appears more than once", key)
+		}
+		seen[key] = true
+
+		weight, err := decimal.NewFromString(strings.TrimSpace(t.Weight))
+		if err != nil || !weight.IsPositive() {
+			return out, apperror.Detailf(ErrInvalidTargetAllocation, "weight for %s must be a positive number", key)
+		}
+		total = total.Add(weight)
+
+		target := models.AllocationTarget{Key: key, Weight: weight.String()}
+		if t.DriftBand != nil && strings.TrimSpace(*t.DriftBand) != "" {
+			band, err := parseDriftBand(*t.DriftBand)
+			if err != nil {
+				return out, err
+			}
+			value := band.String()
+			target.DriftBand = &value
+		}
+		if out.Basis == "ticker" && key != config.CashAllocationKey {
+			target.AssetType = strings.ToLower(strings.TrimSpace(t.AssetType))
+			if target.AssetType == "" {
+				target.AssetType = "stock"
+			}
+			if !slices.Contains(config.AllocationAssetTypes, target.AssetType) {
+				return out, apperror.Detailf(ErrInvalidTargetAllocation, "asset_type for %s must be one of %s", key, strings.Join(config.AllocationAssetTypes, ", "))
+			}
+		}
+		out.Targets = append(out.Targets, target)
+	}
+	if !total.Equal(decimal.NewFromInt(1)) {
+		return out, apperror.Detailf(ErrInvalidTargetAllocation, "weights must sum to 1 (got %s)", total)
+	}
+	return out, nil
+}
+
+func parseDriftBand(value string) (decimal.Decimal, error) {
+	band, err := decimal.NewFromString(strings.TrimSpace(value))
+	if err != nil || !band.IsPositive() || !band.LessThan(decimal.NewFromInt(1)) {
+		return decimal.Zero, fmt.Errorf("%w: drift_band must be a fraction between 0 and 1", ErrInvalidTargetAllocation)
+	}
+	return band, nil
+}
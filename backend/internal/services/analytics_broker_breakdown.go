@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"fintu-tracking-backend/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+// unassignedBrokerName labels trades and cash flows recorded without a broker.
+const unassignedBrokerName = "Unassigned"
+
+// cashFlowBrokerExpr resolves the broker a cash_flows row belongs to. Linked
+// deposit/withdrawal fees are often saved without a broker and inherit the
+// broker of the transfer they belong to.
+const cashFlowBrokerExpr = `COALESCE(broker_id, (
+    SELECT parent.broker_id FROM cash_flows parent WHERE parent.id = cash_flows.related_cash_flow_id
+  ))`
+
+// transferFeesCaseExpr picks the deposit/withdrawal fees counted in net worth TotalFees.
+const transferFeesCaseExpr = `
+  CASE
+    WHEN type = 'fee' AND (related_cash_flow_id IS NOT NULL OR fee_type IN ('deposit', 'withdrawal')) THEN usd_amount
+    ELSE 0
+  END`
+
+// brokerSumSQL sums valueExpr over table per broker, scoped like every other
+// analytics query ($1 user, $2 portfolio).
+func brokerSumSQL(table, brokerExpr, valueExpr string) string {
+	return fmt.Sprintf(`SELECT %s AS broker_id, COALESCE(SUM(%s), 0) FROM %s WHERE user_id = $1 AND %s GROUP BY 1`,
+		brokerExpr, valueExpr, table, portfolioScopeSQL("portfolio_id", 2))
+}
+
+func brokerXIRRFlowsSQL() string {
+	return fmt.Sprintf(`
+		SELECT %s AS broker_id, date, type, usd_amount
+		FROM cash_flows
+		WHERE user_id = $1 AND %s
+		  AND type IN ('deposit', 'withdrawal', 'transfer_in', 'transfer_out')
+		ORDER BY date ASC
+	`, cashFlowBrokerExpr, portfolioScopeSQL("portfolio_id", 2))
+}
+
+func brokerKey(brokerID *string) string {
+	if brokerID == nil {
+		return ""
+	}
+	return *brokerID
+}
+
+type brokerTotals struct {
+	brokerID      *string
+	holdingsValue decimal.Decimal
+	cash          decimal.Decimal
+	invested      decimal.Decimal
+	fees          decimal.Decimal
+	flows         []xirrCashFlow
+}
+
+type brokerTotalsByKey map[string]*brokerTotals
+
+func (m brokerTotalsByKey) get(brokerID *string) *brokerTotals {
+	key := brokerKey(brokerID)
+	if t, ok := m[key]; ok {
+		return t
+	}
+	t := &brokerTotals{brokerID: brokerID}
+	m[key] = t
+	return t
+}
+
+// GetBrokerBreakdown splits cash, holdings, fees, XIRR and fee drag by broker so
+// users holding accounts at several brokers can compare them side by side.
+func (s *AnalyticsService) GetBrokerBreakdown(ctx context.Context, userID string) ([]models.BrokerNetWorth, error) {
+	names, err := loadBrokerNames(ctx, s.pool, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := brokerTotalsByKey{}
+
+	trades, err := s.loadHoldingTrades(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	prices, err := s.loadMarketPrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groupHoldingTradesByBroker(trades) {
+		t := totals.get(group[0].BrokerID)
+		for _, h := range computeHoldingsFromTrades(group, prices) {
+			value, err := decimal.NewFromString(h.MarketValue)
+			if err != nil {
+				continue
+			}
+			t.holdingsValue = t.holdingsValue.Add(value)
+		}
+	}
+
+	sums := []struct {
+		sql   string
+		apply func(t *brokerTotals, v decimal.Decimal)
+	}{
+		{brokerSumSQL("cash_flows", cashFlowBrokerExpr, cashFlowsBalanceCaseExpr), func(t *brokerTotals, v decimal.Decimal) { t.cash = t.cash.Add(v) }},
+		{brokerSumSQL("trades", "broker_id", netTradeCashFlowCaseExpr), func(t *brokerTotals, v decimal.Decimal) { t.cash = t.cash.Sub(v) }},
+		{brokerSumSQL("cash_flows", cashFlowBrokerExpr, netInvestedCaseExpr), func(t *brokerTotals, v decimal.Decimal) { t.invested = t.invested.Add(v) }},
+		{brokerSumSQL("cash_flows", cashFlowBrokerExpr, transferFeesCaseExpr), func(t *brokerTotals, v decimal.Decimal) { t.fees = t.fees.Add(v) }},
+		{brokerSumSQL("trades", "broker_id", "COALESCE(total_fees, 0)"), func(t *brokerTotals, v decimal.Decimal) { t.fees = t.fees.Add(v) }},
+	}
+	for _, sum := range sums {
+		if err := s.scanBrokerSums(ctx, sum.sql, userID, totals, sum.apply); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.loadBrokerXIRRFlows(ctx, userID, totals); err != nil {
+		return nil, err
+	}
+
+	return buildBrokerNetWorth(totals, names, time.Now()), nil
+}
+
+// GetHoldingsByBroker returns current holdings grouped by the broker the trades
+// were placed with. A ticker bought at two brokers appears under both.
+func (s *AnalyticsService) GetHoldingsByBroker(ctx context.Context, userID string) ([]models.BrokerHoldings, error) {
+	names, err := loadBrokerNames(ctx, s.pool, userID)
+	if err != nil {
+		return nil, err
+	}
+	trades, err := s.loadHoldingTrades(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	prices, err := s.loadMarketPrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.BrokerHoldings, 0)
+	for _, group := range groupHoldingTradesByBroker(trades) {
+		byTicker := computeHoldingsFromTrades(group, prices)
+		if len(byTicker) == 0 {
+			continue
+		}
+
+		holdings := make([]models.Holding, 0, len(byTicker))
+		total := decimal.Zero
+		for _, h := range byTicker {
+			holdings = append(holdings, h)
+			if value, err := decimal.NewFromString(h.MarketValue); err == nil {
+				total = total.Add(value)
+			}
+		}
+		sort.Slice(holdings, func(i, j int) bool {
+			return holdings[i].Ticker < holdings[j].Ticker
+		})
+
+		brokerID := group[0].BrokerID
+		result = append(result, models.BrokerHoldings{
+			BrokerID:    brokerID,
+			BrokerName:  brokerDisplayName(names, brokerID),
+			MarketValue: total.String(),
+			Holdings:    holdings,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return brokerLess(result[i].BrokerID, result[i].BrokerName, result[j].BrokerID, result[j].BrokerName)
+	})
+	return result, nil
+}
+
+func (s *AnalyticsService) scanBrokerSums(ctx context.Context, query, userID string, totals brokerTotalsByKey, apply func(*brokerTotals, decimal.Decimal)) error {
+	rows, err := s.pool.Query(ctx, query, userID, s.portfolioArg())
+	if err != nil {
+		return fmt.Errorf("query broker totals: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var brokerID *string
+		var amount string
+		if err := rows.Scan(&brokerID, &amount); err != nil {
+			return fmt.Errorf("scan broker totals: %w", err)
+		}
+		value, err := decimal.NewFromString(amount)
+		if err != nil {
+			return fmt.Errorf("parse broker total %q: %w", amount, err)
+		}
+		apply(totals.get(brokerID), value)
+	}
+	return rows.Err()
+}
+
+func (s *AnalyticsService) loadBrokerXIRRFlows(ctx context.Context, userID string, totals brokerTotalsByKey) error {
+	rows, err := s.pool.Query(ctx, brokerXIRRFlowsSQL(), userID, s.portfolioArg())
+	if err != nil {
+		return fmt.Errorf("load broker cash flows for xirr: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var brokerID *string
+		var date time.Time
+		var flowType, amount string
+		if err := rows.Scan(&brokerID, &date, &flowType, &amount); err != nil {
+			return fmt.Errorf("scan broker xirr flow: %w", err)
+		}
+		amt, err := decimal.NewFromString(amount)
+		if err != nil {
+			continue
+		}
+		if contribution := netInvestedContribution(flowType, amt, nil); !contribution.IsZero() {
+			t := totals.get(brokerID)
+			t.flows = append(t.flows, xirrCashFlow{date: date, amount: contribution})
+		}
+	}
+	return rows.Err()
+}
+
+func buildBrokerNetWorth(totals brokerTotalsByKey, names map[string]string, asOf time.Time) []models.BrokerNetWorth {
+	hundred := decimal.NewFromInt(100)
+	result := make([]models.BrokerNetWorth, 0, len(totals))
+	for _, t := range totals {
+		netWorth := portfolioNetWorth(t.holdingsValue, t.cash)
+
+		xirr := "0"
+		if rate := xirrWithTerminalValue(t.flows, netWorth, asOf); !rate.IsZero() {
+			xirr = rate.Mul(hundred).StringFixed(2)
+		}
+
+		feeDrag := "0"
+		if t.invested.GreaterThan(decimal.Zero) {
+			feeDrag = t.fees.Div(t.invested).Mul(hundred).StringFixed(2)
+		}
+
+		result = append(result, models.BrokerNetWorth{
+			BrokerID:      t.brokerID,
+			BrokerName:    brokerDisplayName(names, t.brokerID),
+			HoldingsValue: t.holdingsValue.String(),
+			CashBalance:   t.cash.String(),
+			NetWorth:      netWorth.String(),
+			TotalInvested: t.invested.String(),
+			TotalFees:     t.fees.String(),
+			XIRR:          xirr,
+			FeeDragPct:    feeDrag,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return brokerLess(result[i].BrokerID, result[i].BrokerName, result[j].BrokerID, result[j].BrokerName)
+	})
+	return result
+}
+
+// groupHoldingTradesByBroker splits trades by broker, keeping each group in
+// input order so computeHoldingsFromTrades sees the same sequence.
+func groupHoldingTradesByBroker(trades []holdingTradeRow) map[string][]holdingTradeRow {
+	groups := make(map[string][]holdingTradeRow)
+	for _, tr := range trades {
+		key := brokerKey(tr.BrokerID)
+		groups[key] = append(groups[key], tr)
+	}
+	return groups
+}
+
+// brokerLess orders brokers by name with unassigned rows last.
+func brokerLess(aID *string, aName string, bID *string, bName string) bool {
+	if (aID == nil) != (bID == nil) {
+		return bID == nil
+	}
+	if aName != bName {
+		return aName < bName
+	}
+	return brokerKey(aID) < brokerKey(bID)
+}
+
+func brokerDisplayName(names map[string]string, brokerID *string) string {
+	if brokerID == nil {
+		return unassignedBrokerName
+	}
+	if name, ok := names[*brokerID]; ok {
+		return name
+	}
+	return *brokerID
+}
+
+func loadBrokerNames(ctx context.Context, pool *pgxpool.Pool, userID string) (map[string]string, error) {
+	rows, err := pool.Query(ctx, `SELECT id, name FROM brokers WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("load broker names: %w", err)
+	}
+	defer rows.Close()
+
+	names := make(map[string]string)
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("scan broker name: %w", err)
+		}
+		names[id] = name
+	}
+	return names, rows.Err()
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGroupHoldingTradesByBroker_SplitsSameTicker(t *testing.T) {
+	t.Parallel()
+
+	hapi := "broker-hapi"
+	etoro := "broker-etoro"
+	day := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	trades := []holdingTradeRow{
+		{Date: day, Ticker: "AAPL", AssetType: "stock", Side: "buy", Quantity: dec("2"), Price: dec("100"), BrokerID: &hapi},
+		{Date: day, Ticker: "AAPL", AssetType: "stock", Side: "buy", Quantity: dec("3"), Price: dec("100"), BrokerID: &etoro},
+		{Date: day, Ticker: "VOO", AssetType: "etf", Side: "buy", Quantity: dec("1"), Price: dec("400")},
+	}
+	prices := map[string]marketPriceInfo{"AAPL": {price: dec("110")}}
+
+	groups := groupHoldingTradesByBroker(trades)
+	if len(groups) != 3 {
+		t.Fatalf("groups = %d, want 3 (hapi, etoro, unassigned)", len(groups))
+	}
+
+	if got := computeHoldingsFromTrades(groups[hapi], prices)["AAPL"].MarketValue; got != "220" {
+		t.Errorf("hapi AAPL market value = %s, want 220", got)
+	}
+	if got := computeHoldingsFromTrades(groups[etoro], prices)["AAPL"].MarketValue; got != "330" {
+		t.Errorf("etoro AAPL market value = %s, want 330", got)
+	}
+	if got := computeHoldingsFromTrades(groups[""], prices)["VOO"].MarketValue; got != "400" {
+		t.Errorf("unassigned VOO market value = %s, want 400", got)
+	}
+}
+
+func TestBuildBrokerNetWorth_FeeDragAndOrdering(t *testing.T) {
+	t.Parallel()
+
+	hapi := "broker-hapi"
+	etoro := "broker-etoro"
+	totals := brokerTotalsByKey{}
+	h := totals.get(&hapi)
+	h.holdingsValue = dec("900")
+	h.cash = dec("100")
+	h.invested = dec("1000")
+	h.fees = dec("12.5")
+
+	e := totals.get(&etoro)
+	e.cash = dec("50")
+	e.invested = dec("50")
+
+	u := totals.get(nil)
+	u.cash = dec("5")
+
+	names := map[string]string{hapi: "Hapi", etoro: "eToro"}
+	got := buildBrokerNetWorth(totals, names, time.Now())
+	if len(got) != 3 {
+		t.Fatalf("len = %d, want 3", len(got))
+	}
+
+	wantOrder := []string{"Hapi", "eToro", unassignedBrokerName}
+	for i, name := range wantOrder {
+		if got[i].BrokerName != name {
+			t.Fatalf("order[%d] = %s, want %s", i, got[i].BrokerName, name)
+		}
+	}
+	if got[0].NetWorth != "1000" {
+		t.Errorf("hapi net worth = %s, want 1000", got[0].NetWorth)
+	}
+	if got[0].FeeDragPct != "1.25" {
+		t.Errorf("hapi fee drag = %s, want 1.25", got[0].FeeDragPct)
+	}
+	if got[2].BrokerID != nil || got[2].FeeDragPct != "0" {
+		t.Errorf("unassigned = %+v, want nil broker and zero fee drag", got[2])
+	}
+}
+
+func TestBrokerBreakdownSQLFragments(t *testing.T) {
+	t.Parallel()
+
+	assertSQLFragments(t, brokerSumSQL("cash_flows", cashFlowBrokerExpr, cashFlowsBalanceCaseExpr), []string{
+		"parent.id = cash_flows.related_cash_flow_id",
+		"GROUP BY 1",
+		"$2::uuid IS NULL",
+	})
+	assertSQLFragments(t, feesByBrokerSQL(), []string{
+		"parent.broker_id",
+		"type = 'fee'",
+	})
+	assertSQLFragments(t, reconcileByBrokerSQL(), []string{
+		"COALESCE(t.broker_id, cf.broker_id)",
+		"related_type = 'trade'",
+		"GROUP BY broker_id",
+	})
+}
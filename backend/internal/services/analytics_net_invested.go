@@ -10,15 +10,19 @@ const netInvestedCaseExpr = `
   CASE
     WHEN type = 'deposit' THEN usd_amount
     WHEN type = 'withdrawal' THEN -usd_amount
+    WHEN type = 'transfer_in' THEN usd_amount
+    WHEN type = 'transfer_out' THEN -usd_amount
     ELSE 0
   END`
 
 func netInvestedSQL() string {
-	return fmt.Sprintf(`SELECT COALESCE(SUM(%s), 0) FROM cash_flows WHERE user_id = $1`, netInvestedCaseExpr)
+	return fmt.Sprintf(`SELECT COALESCE(SUM(%s), 0) FROM cash_flows WHERE user_id = $1 AND %s`,
+		netInvestedCaseExpr, portfolioScopeSQL("portfolio_id", 2))
 }
 
 func netInvestedSQLAsOfDate() string {
-	return fmt.Sprintf(`SELECT COALESCE(SUM(%s), 0) FROM cash_flows WHERE user_id = $1 AND date <= $2`, netInvestedCaseExpr)
+	return fmt.Sprintf(`SELECT COALESCE(SUM(%s), 0) FROM cash_flows WHERE user_id = $1 AND date <= $2 AND %s`,
+		netInvestedCaseExpr, portfolioScopeSQL("portfolio_id", 3))
 }
 
 type netInvestedFlow struct {
@@ -27,11 +31,13 @@ type netInvestedFlow struct {
 	RelatedCashFlowID *string
 }
 
+// netInvestedContribution mirrors netInvestedCaseExpr. Transfers move capital
+// between portfolios, so a transfer pair nets to zero in the consolidated view.
 func netInvestedContribution(flowType string, usdAmount decimal.Decimal, _ *string) decimal.Decimal {
 	switch flowType {
-	case "deposit":
+	case "deposit", "transfer_in":
 		return usdAmount
-	case "withdrawal":
+	case "withdrawal", "transfer_out":
 		return usdAmount.Neg()
 	}
 	return decimal.Zero
@@ -7,15 +7,49 @@ import (
 	"time"
 
 	"fintu-tracking-backend/internal/models"
+	"fintu-tracking-backend/internal/telemetry"
 	"github.com/shopspring/decimal"
 )
 
 // GetPerformanceTimeSeries returns portfolio performance over time.
 // Uses portfolio_snapshots when present; otherwise builds points from trades and cash flows.
+// Snapshots are consolidated, so a portfolio-scoped service always rebuilds from activity.
 // interval buckets points as day (default), week, month, or year (last activity date per bucket).
-func (s *AnalyticsService) GetPerformanceTimeSeries(ctx context.Context, userID, interval string) ([]models.PerformancePoint, error) {
+// Each point carries every benchmark in benchmarks indexed to 100 at the first point.
+func (s *AnalyticsService) GetPerformanceTimeSeries(ctx context.Context, userID, interval string, benchmarks []models.Benchmark) ([]models.PerformancePoint, error) {
+	ctx, span := telemetry.StartSpan(ctx, "AnalyticsService.GetPerformanceTimeSeries")
+	defer span.End()
+
 	interval = normalizePerformanceInterval(interval)
 
+	var points []models.PerformancePoint
+	var err error
+	if s.portfolioID == "" {
+		points, err = s.loadSnapshotPerformancePoints(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(points) == 0 {
+		points, err = s.generatePerformancePoints(ctx, userID, interval)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate performance points: %w", err)
+		}
+	} else {
+		points = aggregatePerformancePointsByInterval(points, interval)
+	}
+
+	indexes, err := loadBenchmarkIndexes(ctx, s.pool, benchmarks)
+	if err != nil {
+		return nil, err
+	}
+
+	return attachBenchmarks(points, indexes), nil
+}
+
+// loadSnapshotPerformancePoints reads stored consolidated snapshots, oldest first.
+func (s *AnalyticsService) loadSnapshotPerformancePoints(ctx context.Context, userID string) ([]models.PerformancePoint, error) {
 	query := `
 		SELECT 
 			snapshot_date,
@@ -70,20 +104,6 @@ func (s *AnalyticsService) GetPerformanceTimeSeries(ctx context.Context, userID,
 		points = append(points, point)
 	}
 
-	if len(points) == 0 {
-		points, err = s.generatePerformancePoints(ctx, userID, interval)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate performance points: %w", err)
-		}
-	} else {
-		points = aggregatePerformancePointsByInterval(points, interval)
-	}
-
-	points, err = attachSPYBenchmark(ctx, s.pool, points)
-	if err != nil {
-		return nil, err
-	}
-
 	return points, nil
 }
 
@@ -101,9 +121,9 @@ func (s *AnalyticsService) loadPerformanceActivity(ctx context.Context, userID s
 	cfRows, err := s.pool.Query(ctx, `
 		SELECT date, type, usd_amount, related_trade_id, related_cash_flow_id
 		FROM cash_flows
-		WHERE user_id = $1
+		WHERE user_id = $1 AND `+portfolioScopeSQL("portfolio_id", 2)+`
 		ORDER BY date ASC
-	`, userID)
+	`, userID, s.portfolioArg())
 	if err != nil {
 		return activity, fmt.Errorf("load cash flows: %w", err)
 	}
@@ -124,7 +144,7 @@ func (s *AnalyticsService) loadPerformanceActivity(ctx context.Context, userID s
 		return activity, fmt.Errorf("iterate cash flows: %w", err)
 	}
 
-	trRows, err := s.pool.Query(ctx, performanceTradeLoadSQL(), userID)
+	trRows, err := s.pool.Query(ctx, performanceTradeLoadSQL(), userID, s.portfolioArg())
 	if err != nil {
 		return activity, fmt.Errorf("load trades: %w", err)
 	}
@@ -291,6 +311,12 @@ func (a performanceActivity) metricsAsOf(asOf time.Time) (invested, fees, portfo
 			investedDec = investedDec.Add(netInvestedContribution(cf.Type, cf.USDAmount, cf.RelatedCashFlowID))
 		case "cash_adjustment":
 			cashDec = cashDec.Add(cf.USDAmount)
+		case "transfer_in":
+			cashDec = cashDec.Add(cf.USDAmount)
+			investedDec = investedDec.Add(netInvestedContribution(cf.Type, cf.USDAmount, cf.RelatedCashFlowID))
+		case "transfer_out":
+			cashDec = cashDec.Sub(cf.USDAmount)
+			investedDec = investedDec.Add(netInvestedContribution(cf.Type, cf.USDAmount, cf.RelatedCashFlowID))
 		case "fee":
 			if cf.RelatedTradeID == nil && cf.RelatedCashFlowID == nil {
 				cashDec = cashDec.Sub(cf.USDAmount)
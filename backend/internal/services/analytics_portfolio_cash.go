@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/shopspring/decimal"
@@ -11,6 +12,8 @@ const cashFlowsBalanceCaseExpr = `
     WHEN type = 'deposit' THEN usd_amount
     WHEN type = 'withdrawal' THEN -usd_amount
     WHEN type = 'cash_adjustment' THEN usd_amount
+    WHEN type = 'transfer_in' THEN usd_amount
+    WHEN type = 'transfer_out' THEN -usd_amount
     WHEN type = 'fee' AND related_trade_id IS NULL AND related_cash_flow_id IS NULL THEN -usd_amount
     ELSE 0
   END`
@@ -23,11 +26,13 @@ const netTradeCashFlowCaseExpr = `
   END`
 
 func cashFlowsBalanceSQL() string {
-	return fmt.Sprintf(`SELECT COALESCE(SUM(%s), 0) FROM cash_flows WHERE user_id = $1`, cashFlowsBalanceCaseExpr)
+	return fmt.Sprintf(`SELECT COALESCE(SUM(%s), 0) FROM cash_flows WHERE user_id = $1 AND %s`,
+		cashFlowsBalanceCaseExpr, portfolioScopeSQL("portfolio_id", 2))
 }
 
 func netTradeCashFlowSQL() string {
-	return fmt.Sprintf(`SELECT COALESCE(SUM(%s), 0) FROM trades WHERE user_id = $1`, netTradeCashFlowCaseExpr)
+	return fmt.Sprintf(`SELECT COALESCE(SUM(%s), 0) FROM trades WHERE user_id = $1 AND %s`,
+		netTradeCashFlowCaseExpr, portfolioScopeSQL("portfolio_id", 2))
 }
 
 func portfolioCashAfterTrades(cashFromFlows, tradeCosts decimal.Decimal) decimal.Decimal {
@@ -53,8 +58,10 @@ func sumCashFlowsBalance(flows []cashFlowBalanceRow) decimal.Decimal {
 			total = total.Add(f.USDAmount)
 		case "withdrawal":
 			total = total.Sub(f.USDAmount)
-		case "cash_adjustment":
+		case "cash_adjustment", "transfer_in":
 			total = total.Add(f.USDAmount)
+		case "transfer_out":
+			total = total.Sub(f.USDAmount)
 		case "fee":
 			if f.RelatedTradeID != nil || f.RelatedCashFlowID != nil {
 				continue
@@ -89,3 +96,24 @@ func sumNetTradeCashFlow(trades []tradeCashFlowRow) decimal.Decimal {
 	}
 	return total
 }
+
+// cashBalance returns the uninvested USD cash in scope: cash flows net of trade
+// purchases and sale proceeds.
+func (s *AnalyticsService) cashBalance(ctx context.Context, userID string) (decimal.Decimal, error) {
+	var flows, tradeCosts string
+	if err := s.pool.QueryRow(ctx, cashFlowsBalanceSQL(), userID, s.portfolioArg()).Scan(&flows); err != nil {
+		return decimal.Zero, fmt.Errorf("sum cash flows: %w", err)
+	}
+	if err := s.pool.QueryRow(ctx, netTradeCashFlowSQL(), userID, s.portfolioArg()).Scan(&tradeCosts); err != nil {
+		return decimal.Zero, fmt.Errorf("sum trade cash flows: %w", err)
+	}
+	cashFromFlows, err := decimal.NewFromString(flows)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("parse cash flows balance: %w", err)
+	}
+	costs, err := decimal.NewFromString(tradeCosts)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("parse trade cash flows: %w", err)
+	}
+	return portfolioCashAfterTrades(cashFromFlows, costs), nil
+}
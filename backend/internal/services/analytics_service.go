@@ -6,10 +6,21 @@ import (
 
 // AnalyticsService handles performance attribution and analysis
 type AnalyticsService struct {
-	pool *pgxpool.Pool
+	pool        *pgxpool.Pool
+	portfolioID string
 }
 
 // NewAnalyticsService creates a new analytics service
 func NewAnalyticsService(pool *pgxpool.Pool) *AnalyticsService {
 	return &AnalyticsService{pool: pool}
 }
+
+// ForPortfolio returns a copy of the service whose queries only see trades and
+// cash flows owned by portfolioID. An empty ID keeps the consolidated view.
+func (s *AnalyticsService) ForPortfolio(portfolioID string) *AnalyticsService {
+	return &AnalyticsService{pool: s.pool, portfolioID: portfolioID}
+}
+
+func (s *AnalyticsService) portfolioArg() any {
+	return portfolioScopeArg(s.portfolioID)
+}
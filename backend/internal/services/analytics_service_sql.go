@@ -15,7 +15,7 @@ func returnAttributionHoldingsSQL() string {
 			)) as current_price
 		FROM trades t
 		LEFT JOIN market_prices mp ON t.ticker = mp.ticker
-		WHERE t.user_id = $1
+		WHERE t.user_id = $1 AND ` + portfolioScopeSQL("t.portfolio_id", 2) + `
 		GROUP BY t.ticker, mp.price
 		HAVING SUM(CASE WHEN t.side = 'buy' THEN t.quantity ELSE -t.quantity END) > 0
 	`
@@ -38,7 +38,7 @@ func netWorthHoldingsSQL() string {
 			)) as current_price
 		FROM trades t
 		LEFT JOIN market_prices mp ON t.ticker = mp.ticker
-		WHERE t.user_id = $1
+		WHERE t.user_id = $1 AND ` + portfolioScopeSQL("t.portfolio_id", 2) + `
 		GROUP BY t.ticker, t.asset_type, mp.price
 		HAVING SUM(CASE WHEN t.side = 'buy' THEN t.quantity ELSE -t.quantity END) > 0
 	`
@@ -48,7 +48,7 @@ func performanceTradeLoadSQL() string {
 	return `
 		SELECT date, side, ticker, quantity, price, COALESCE(total_fees, 0), COALESCE(is_opening_position, false)
 		FROM trades
-		WHERE user_id = $1
+		WHERE user_id = $1 AND ` + portfolioScopeSQL("portfolio_id", 2) + `
 		ORDER BY date ASC
 	`
 }
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrAPITokenNotFound       = apperror.New(apperror.NotFound, "API token not found")
+	ErrInvalidAPITokenRequest = apperror.New(apperror.Validation, "invalid API token request")
+	ErrInvalidAPIToken        = apperror.New(apperror.Unauthorized, "invalid, expired or revoked API token")
+	ErrAPITokenLimit          = apperror.Newf(apperror.Conflict, "at most %d active API tokens are allowed", config.MaxAPITokens)
+)
+
+const apiTokenColumns = `id, name, prefix, scopes, expires_at, last_used_at, revoked_at, created_at`
+
+// APITokenService manages personal API tokens and verifies them for the auth
+// middleware.
+type APITokenService struct {
+	pool *pgxpool.Pool
+}
+
+// NewAPITokenService creates an APITokenService backed by the given DB pool.
+func NewAPITokenService(pool *pgxpool.Pool) *APITokenService {
+	return &APITokenService{pool: pool}
+}
+
+// ListTokens returns the user's tokens, newest first, including revoked and
+// expired ones.
+func (s *APITokenService) ListTokens(ctx context.Context, userID string) ([]models.APIToken, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+apiTokenColumns+`
+		FROM api_tokens
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying API tokens: %w", err)
+	}
+	tokens, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.APIToken])
+	if err != nil {
+		return nil, fmt.Errorf("collecting API tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// CreateToken issues a new token. The plaintext is returned only in this
+// response; just its hash is stored.
+func (s *APITokenService) CreateToken(ctx context.Context, userID string, req models.CreateAPITokenRequest) (*models.APIToken, error) {
+	valid, err := validateAPITokenRequest(req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	token, prefix, err := newAPIToken()
+	if err != nil {
+		return nil, err
+	}
+
+	var count int
+	if err := s.pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM api_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+	`, userID).Scan(&count); err != nil {
+		return nil, fmt.Errorf("counting API tokens: %w", err)
+	}
+	if count >= config.MaxAPITokens {
+		return nil, ErrAPITokenLimit
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		INSERT INTO api_tokens (user_id, name, prefix, token_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+apiTokenColumns,
+		userID, valid.Name, prefix, hashAPIToken(token), valid.Scopes, valid.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("creating API token: %w", err)
+	}
+	created, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.APIToken])
+	if err != nil {
+		return nil, fmt.Errorf("creating API token: %w", err)
+	}
+	created.Token = token
+	return &created, nil
+}
+
+// RevokeToken stops a token from authenticating. Revoking an already revoked
+// token keeps its original revoked_at.
+func (s *APITokenService) RevokeToken(ctx context.Context, userID, tokenID string) (*models.APIToken, error) {
+	if _, err := uuid.Parse(tokenID); err != nil {
+		return nil, ErrAPITokenNotFound
+	}
+	rows, err := s.pool.Query(ctx, `
+		UPDATE api_tokens SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE id = $1 AND user_id = $2
+		RETURNING `+apiTokenColumns,
+		tokenID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("revoking API token: %w", err)
+	}
+	revoked, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.APIToken])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAPITokenNotFound
+		}
+		return nil, fmt.Errorf("revoking API token: %w", err)
+	}
+	return &revoked, nil
+}
+
+// VerifyAPIToken returns the owner and scopes of an active token and records
+// that it was used. last_used_at is only written when it is older than
+// config.APITokenLastUsedPrecision.
+func (s *APITokenService) VerifyAPIToken(ctx context.Context, token string) (string, []string, error) {
+	hash := hashAPIToken(token)
+	var (
+		userID     string
+		scopes     []string
+		lastUsedAt *time.Time
+	)
+	err := s.pool.QueryRow(ctx, `
+		SELECT user_id, scopes, last_used_at
+		FROM api_tokens
+		WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > NOW()
+	`, hash).Scan(&userID, &scopes, &lastUsedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil, ErrInvalidAPIToken
+		}
+		return "", nil, fmt.Errorf("verifying API token: %w", err)
+	}
+
+	if lastUsedAt == nil || time.Since(*lastUsedAt) >= config.APITokenLastUsedPrecision {
+		if _, err := s.pool.Exec(ctx, `UPDATE api_tokens SET last_used_at = NOW() WHERE token_hash = $1`, hash); err != nil {
+			return "", nil, fmt.Errorf("recording API token use: %w", err)
+		}
+	}
+	return userID, scopes, nil
+}
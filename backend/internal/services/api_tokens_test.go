@@ -0,0 +1,86 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/models"
+)
+
+func TestValidateAPITokenRequest(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	got, err := validateAPITokenRequest(models.CreateAPITokenRequest{
+		Name:   "  Nightly refresh ",
+		Scopes: []string{"write:market_data", "READ:portfolio", "write:market_data"},
+	}, now)
+	if err != nil {
+		t.Fatalf("validateAPITokenRequest: %v", err)
+	}
+	if got.Name != "Nightly refresh" {
+		t.Errorf("name = %q", got.Name)
+	}
+	if want := []string{config.ScopeReadPortfolio, config.ScopeWriteMarketData}; !slices.Equal(got.Scopes, want) {
+		t.Errorf("scopes = %v, want %v", got.Scopes, want)
+	}
+	if want := now.AddDate(0, 0, config.DefaultAPITokenExpiryDays); !got.ExpiresAt.Equal(want) {
+		t.Errorf("expires_at = %v, want %v", got.ExpiresAt, want)
+	}
+
+	days := 7
+	got, err = validateAPITokenRequest(models.CreateAPITokenRequest{Name: "ci", Scopes: []string{"read:account"}, ExpiresInDays: &days}, now)
+	if err != nil {
+		t.Fatalf("validateAPITokenRequest: %v", err)
+	}
+	if want := now.AddDate(0, 0, 7); !got.ExpiresAt.Equal(want) {
+		t.Errorf("expires_at = %v, want %v", got.ExpiresAt, want)
+	}
+}
+
+func TestValidateAPITokenRequest_Invalid(t *testing.T) {
+	t.Parallel()
+
+	zero, tooLong := 0, config.MaxAPITokenExpiryDays+1
+	cases := []models.CreateAPITokenRequest{
+		{Name: " ", Scopes: []string{"read:portfolio"}},
+		{Name: strings.Repeat("x", apiTokenNameMaxLength+1), Scopes: []string{"read:portfolio"}},
+		{Name: "script"},
+		{Name: "script", Scopes: []string{"admin"}},
+		{Name: "script", Scopes: []string{"read:portfolio"}, ExpiresInDays: &zero},
+		{Name: "script", Scopes: []string{"read:portfolio"}, ExpiresInDays: &tooLong},
+	}
+	for _, req := range cases {
+		if _, err := validateAPITokenRequest(req, time.Now()); !errors.Is(err, ErrInvalidAPITokenRequest) {
+			t.Errorf("validateAPITokenRequest(%+v) error = %v, want ErrInvalidAPITokenRequest", req, err)
+		}
+	}
+}
+
+func TestNewAPIToken(t *testing.T) {
+	t.Parallel()
+
+	token, prefix, err := newAPIToken()
+	if err != nil {
+		t.Fatalf("newAPIToken: %v", err)
+	}
+	if !strings.HasPrefix(token, config.APITokenPrefix) || !strings.HasPrefix(token, prefix) || len(prefix) >= len(token) {
+		t.Errorf("token = %q, prefix = %q", token, prefix)
+	}
+	other, _, err := newAPIToken()
+	if err != nil {
+		t.Fatalf("newAPIToken: %v", err)
+	}
+	if other == token {
+		t.Error("newAPIToken returned the same token twice")
+	}
+
+	hash := hashAPIToken(token)
+	if hash == token || len(hash) != 64 || hash != hashAPIToken(token) || hash == hashAPIToken(other) {
+		t.Errorf("hashAPIToken(%q) = %q", token, hash)
+	}
+}
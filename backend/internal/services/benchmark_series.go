@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+// benchmarkIndex values a benchmark basket rebalanced to its weights at every
+// daily close, so a 60/40 blend stays 60/40 instead of drifting with the
+// components' returns. The basket has no level before every component's
+// history has started.
+type benchmarkIndex struct {
+	benchmark models.Benchmark
+	weights   map[string]decimal.Decimal
+	series    map[string][]tickerClose
+	levels    []tickerClose // basket level per date, 1 on the first
+	missing   []string      // components without any daily close
+}
+
+func loadBenchmarkIndexes(ctx context.Context, pool *pgxpool.Pool, benchmarks []models.Benchmark) ([]benchmarkIndex, error) {
+	if len(benchmarks) == 0 {
+		return nil, nil
+	}
+	closes, err := NewPostgresMarketDataStore(pool).GetDailyPrices(ctx, BenchmarkTickers(benchmarks), time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	byTicker := groupDailyPrices(closes)
+
+	indexes := make([]benchmarkIndex, 0, len(benchmarks))
+	for _, b := range benchmarks {
+		indexes = append(indexes, newBenchmarkIndex(b, byTicker))
+	}
+	return indexes, nil
+}
+
+func newBenchmarkIndex(benchmark models.Benchmark, byTicker map[string][]tickerClose) benchmarkIndex {
+	ix := benchmarkIndex{
+		benchmark: benchmark,
+		weights:   make(map[string]decimal.Decimal, len(benchmark.Components)),
+		series:    make(map[string][]tickerClose, len(benchmark.Components)),
+	}
+	for _, c := range benchmark.Components {
+		weight, err := decimal.NewFromString(c.Weight)
+		if err != nil {
+			weight = decimal.Zero
+		}
+		ix.weights[c.Ticker] = weight
+		if series := byTicker[c.Ticker]; len(series) > 0 {
+			ix.series[c.Ticker] = series
+		} else {
+			ix.missing = append(ix.missing, c.Ticker)
+		}
+	}
+	if len(ix.missing) == 0 {
+		ix.levels = ix.rebalancedLevels()
+	}
+	return ix
+}
+
+// rebalancedLevels chains the basket's daily returns from the first date every
+// component has a close: each day the basket grows by the weighted sum of the
+// components' close-to-close returns. A component without a close on a day
+// keeps its previous one.
+func (ix benchmarkIndex) rebalancedLevels() []tickerClose {
+	var start time.Time
+	for _, series := range ix.series {
+		if series[0].date.After(start) {
+			start = series[0].date
+		}
+	}
+	seen := make(map[time.Time]bool)
+	var dates []time.Time
+	for _, series := range ix.series {
+		for _, p := range series {
+			if !p.date.Before(start) && !seen[p.date] {
+				seen[p.date] = true
+				dates = append(dates, p.date)
+			}
+		}
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	levels := make([]tickerClose, 0, len(dates))
+	level := decimal.NewFromInt(1)
+	for i, date := range dates {
+		if i > 0 {
+			growth := decimal.Zero
+			for ticker, series := range ix.series {
+				before, _ := closeOnOrBefore(series, dates[i-1])
+				now, _ := closeOnOrBefore(series, date)
+				if before.IsZero() {
+					growth = growth.Add(ix.weights[ticker])
+					continue
+				}
+				growth = growth.Add(ix.weights[ticker].Mul(now).Div(before))
+			}
+			level = level.Mul(growth)
+		}
+		levels = append(levels, tickerClose{date: date, close: level})
+	}
+	return levels
+}
+
+// level returns the basket level on asOf (1 at the start of its history). ok is
+// false when a component has no prices at all or none on or before asOf.
+func (ix benchmarkIndex) level(asOf time.Time) (decimal.Decimal, bool) {
+	if len(ix.missing) > 0 {
+		return decimal.Zero, false
+	}
+	return closeOnOrBefore(ix.levels, asOf)
+}
+
+// closeOnOrBefore returns the latest close on or before asOf. ok is false when
+// asOf predates the series, so no later price is used for an earlier day.
+// series must be sorted by date.
+func closeOnOrBefore(series []tickerClose, asOf time.Time) (decimal.Decimal, bool) {
+	asOf = truncateToUTCDate(asOf)
+	i := sort.Search(len(series), func(i int) bool { return series[i].date.After(asOf) })
+	if i == 0 {
+		return decimal.Zero, false
+	}
+	return series[i-1].close, true
+}
+
+// attachBenchmarks sets Benchmarks on each point, every benchmark indexed to 100
+// at the first point it has a level for; earlier points are left without it.
+// SpyIndexed is kept for older clients when SPY is requested.
+func attachBenchmarks(points []models.PerformancePoint, indexes []benchmarkIndex) []models.PerformancePoint {
+	if len(points) == 0 || len(indexes) == 0 {
+		return points
+	}
+
+	hundred := decimal.NewFromInt(100)
+	out := make([]models.PerformancePoint, len(points))
+	copy(out, points)
+	for _, ix := range indexes {
+		base := decimal.Zero
+		for i := range out {
+			level, ok := ix.level(out[i].Date)
+			if !ok {
+				continue
+			}
+			if base.IsZero() {
+				base = level
+			}
+			if base.IsZero() {
+				continue
+			}
+			indexed := level.Div(base).Mul(hundred).StringFixed(2)
+			if out[i].Benchmarks == nil {
+				out[i].Benchmarks = make(map[string]string, len(indexes))
+			}
+			out[i].Benchmarks[ix.benchmark.ID] = indexed
+			if ix.benchmark.ID == "spy" {
+				out[i].SpyIndexed = indexed
+			}
+		}
+	}
+	return out
+}
+
+// CompareBenchmarks replays the portfolio's real deposits, withdrawals and
+// transfers into each benchmark on the day they happened and compares the
+// resulting value and XIRR with the actual portfolio.
+func (s *AnalyticsService) CompareBenchmarks(ctx context.Context, userID string, benchmarks []models.Benchmark) ([]models.BenchmarkComparison, error) {
+	flows, err := loadExternalCashFlows(ctx, s.pool, userID, s.portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := s.GetNetWorthSummary(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	portfolioValue, err := decimal.NewFromString(summary.NetWorth)
+	if err != nil {
+		portfolioValue = decimal.Zero
+	}
+	indexes, err := loadBenchmarkIndexes(ctx, s.pool, benchmarks)
+	if err != nil {
+		return nil, err
+	}
+
+	asOf := time.Now()
+	result := make([]models.BenchmarkComparison, 0, len(indexes))
+	for _, ix := range indexes {
+		comparison := simulateBenchmarkWhatIf(ix, flows, asOf)
+		comparison.PortfolioValue = portfolioValue.String()
+		comparison.PortfolioXIRR = summary.XIRR
+		if value, err := decimal.NewFromString(comparison.BenchmarkValue); err == nil {
+			comparison.Difference = portfolioValue.Sub(value).String()
+		}
+		result = append(result, comparison)
+	}
+	return result, nil
+}
+
+// simulateBenchmarkWhatIf buys benchmark units with every inflow and sells
+// units with every outflow at that day's level, then values the units at asOf.
+// Flows dated before the benchmark's price history are left out and counted in
+// SkippedFlows rather than priced with a later close.
+func simulateBenchmarkWhatIf(ix benchmarkIndex, flows []xirrCashFlow, asOf time.Time) models.BenchmarkComparison {
+	comparison := models.BenchmarkComparison{
+		Benchmark:      ix.benchmark,
+		NetContributed: "0",
+		BenchmarkValue: "0",
+		Difference:     "0",
+		BenchmarkXIRR:  "0",
+		MissingPrices:  ix.missing,
+		Series:         []models.BenchmarkWhatIfPoint{},
+	}
+	if len(ix.missing) > 0 {
+		return comparison
+	}
+
+	units := decimal.Zero
+	contributed := decimal.Zero
+	priced := make([]xirrCashFlow, 0, len(flows))
+	for _, f := range flows {
+		level, ok := ix.level(f.date)
+		if !ok || level.IsZero() {
+			comparison.SkippedFlows++
+			continue
+		}
+		priced = append(priced, f)
+		units = units.Add(f.amount.Div(level))
+		contributed = contributed.Add(f.amount)
+		comparison.Series = append(comparison.Series, models.BenchmarkWhatIfPoint{
+			Date:           truncateToUTCDate(f.date),
+			NetContributed: contributed.String(),
+			BenchmarkValue: units.Mul(level).StringFixed(2),
+		})
+	}
+
+	finalLevel, _ := ix.level(asOf)
+	value := units.Mul(finalLevel)
+	comparison.Series = append(comparison.Series, models.BenchmarkWhatIfPoint{
+		Date:           truncateToUTCDate(asOf),
+		NetContributed: contributed.String(),
+		BenchmarkValue: value.StringFixed(2),
+	})
+	comparison.NetContributed = contributed.String()
+	comparison.BenchmarkValue = value.StringFixed(2)
+	if rate := xirrWithTerminalValue(priced, value, asOf); !rate.IsZero() {
+		comparison.BenchmarkXIRR = rate.Mul(decimal.NewFromInt(100)).StringFixed(2)
+	}
+	return comparison
+}
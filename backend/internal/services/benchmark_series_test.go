@@ -0,0 +1,166 @@
+package services
+
+import (
+	"testing"
+
+	"fintu-tracking-backend/internal/models"
+)
+
+func TestAttachBenchmarks_IndexesBlendToFirstPoint(t *testing.T) {
+	t.Parallel()
+
+	blend := models.Benchmark{ID: "60-40", Components: []models.BenchmarkComponent{
+		{Ticker: "VT", Weight: "0.6"},
+		{Ticker: "BND", Weight: "0.4"},
+	}}
+	ix := newBenchmarkIndex(blend, groupDailyPrices([]models.DailyPrice{
+		{Ticker: "VT", Date: riskDay(1), Close: "100"},
+		{Ticker: "VT", Date: riskDay(5), Close: "110"},
+		{Ticker: "BND", Date: riskDay(1), Close: "50"},
+		{Ticker: "BND", Date: riskDay(5), Close: "45"},
+	}))
+
+	points := attachBenchmarks([]models.PerformancePoint{
+		{Date: riskDay(1)},
+		{Date: riskDay(3)},
+		{Date: riskDay(6)},
+	}, []benchmarkIndex{ix})
+
+	want := []string{"100.00", "100.00", "102.00"} // 0.6*1.1 + 0.4*0.9
+	for i, p := range points {
+		if got := p.Benchmarks["60-40"]; got != want[i] {
+			t.Errorf("point %d = %q, want %s", i, got, want[i])
+		}
+		if p.SpyIndexed != "" {
+			t.Errorf("point %d SpyIndexed = %q, want empty for a non-SPY benchmark", i, p.SpyIndexed)
+		}
+	}
+}
+
+func TestBenchmarkIndex_RebalancesToWeights(t *testing.T) {
+	t.Parallel()
+
+	blend := models.Benchmark{ID: "60-40", Components: []models.BenchmarkComponent{
+		{Ticker: "VT", Weight: "0.6"},
+		{Ticker: "BND", Weight: "0.4"},
+	}}
+	ix := newBenchmarkIndex(blend, groupDailyPrices([]models.DailyPrice{
+		{Ticker: "VT", Date: riskDay(1), Close: "100"},
+		{Ticker: "VT", Date: riskDay(2), Close: "110"},
+		{Ticker: "VT", Date: riskDay(3), Close: "99"},
+		{Ticker: "BND", Date: riskDay(1), Close: "50"},
+		{Ticker: "BND", Date: riskDay(2), Close: "45"},
+		{Ticker: "BND", Date: riskDay(3), Close: "49.5"},
+	}))
+
+	// Back at 60/40 after day 2, day 3 is 0.6*0.9 + 0.4*1.1 = 0.98 of 1.02.
+	// Buy-and-hold would be 0.6*0.99 + 0.4*0.99 = 0.99.
+	level, ok := ix.level(riskDay(3))
+	if !ok || !level.Equal(dec("0.9996")) {
+		t.Errorf("level = %s, %v; want 0.9996", level, ok)
+	}
+	if _, ok := ix.level(riskDay(0)); ok {
+		t.Error("level before the basket's history is available")
+	}
+}
+
+func TestAttachBenchmarks_SkipsBenchmarkWithoutPrices(t *testing.T) {
+	t.Parallel()
+
+	spy := models.Benchmark{ID: "spy", Components: []models.BenchmarkComponent{{Ticker: "SPY", Weight: "1"}}}
+	points := attachBenchmarks([]models.PerformancePoint{{Date: riskDay(1)}}, []benchmarkIndex{newBenchmarkIndex(spy, nil)})
+	if points[0].Benchmarks != nil || points[0].SpyIndexed != "" {
+		t.Errorf("point = %+v, want no benchmark values", points[0])
+	}
+}
+
+func TestSimulateBenchmarkWhatIf_BuysOnDepositDates(t *testing.T) {
+	t.Parallel()
+
+	spy := models.Benchmark{ID: "spy", Components: []models.BenchmarkComponent{{Ticker: "SPY", Weight: "1"}}}
+	ix := newBenchmarkIndex(spy, groupDailyPrices([]models.DailyPrice{
+		{Ticker: "SPY", Date: riskDay(1), Close: "100"},
+		{Ticker: "SPY", Date: riskDay(10), Close: "200"},
+		{Ticker: "SPY", Date: riskDay(20), Close: "150"},
+	}))
+	flows := []xirrCashFlow{
+		{date: riskDay(1), amount: dec("1000")},  // 10 units at 1.0
+		{date: riskDay(10), amount: dec("1000")}, // 5 units at 2.0
+		{date: riskDay(12), amount: dec("-400")}, // sells 2 units at 2.0
+	}
+
+	got := simulateBenchmarkWhatIf(ix, flows, riskDay(25))
+	if got.NetContributed != "1600" {
+		t.Errorf("net contributed = %s, want 1600", got.NetContributed)
+	}
+	if got.BenchmarkValue != "1950.00" {
+		t.Errorf("benchmark value = %s, want 1950.00 (13 units x 1.5)", got.BenchmarkValue)
+	}
+	if len(got.Series) != 4 {
+		t.Fatalf("series len = %d, want 4 (three flows and today)", len(got.Series))
+	}
+	if got.Series[1].BenchmarkValue != "3000.00" {
+		t.Errorf("value after second deposit = %s, want 3000.00", got.Series[1].BenchmarkValue)
+	}
+	if got.BenchmarkXIRR == "0" {
+		t.Error("benchmark XIRR not computed")
+	}
+}
+
+func TestSimulateBenchmarkWhatIf_SkipsFlowsBeforeHistory(t *testing.T) {
+	t.Parallel()
+
+	spy := models.Benchmark{ID: "spy", Components: []models.BenchmarkComponent{{Ticker: "SPY", Weight: "1"}}}
+	ix := newBenchmarkIndex(spy, groupDailyPrices([]models.DailyPrice{
+		{Ticker: "SPY", Date: riskDay(5), Close: "100"},
+		{Ticker: "SPY", Date: riskDay(10), Close: "200"},
+	}))
+	flows := []xirrCashFlow{
+		{date: riskDay(1), amount: dec("1000")}, // before the first close
+		{date: riskDay(5), amount: dec("500")},  // 5 units at 1.0
+	}
+
+	got := simulateBenchmarkWhatIf(ix, flows, riskDay(12))
+	if got.SkippedFlows != 1 {
+		t.Errorf("skipped flows = %d, want 1", got.SkippedFlows)
+	}
+	if got.NetContributed != "500" || got.BenchmarkValue != "1000.00" {
+		t.Errorf("contributed/value = %s/%s, want 500/1000.00", got.NetContributed, got.BenchmarkValue)
+	}
+}
+
+func TestAttachBenchmarks_StartsWithBenchmarkHistory(t *testing.T) {
+	t.Parallel()
+
+	spy := models.Benchmark{ID: "spy", Components: []models.BenchmarkComponent{{Ticker: "SPY", Weight: "1"}}}
+	ix := newBenchmarkIndex(spy, groupDailyPrices([]models.DailyPrice{
+		{Ticker: "SPY", Date: riskDay(3), Close: "100"},
+		{Ticker: "SPY", Date: riskDay(6), Close: "120"},
+	}))
+
+	points := attachBenchmarks([]models.PerformancePoint{
+		{Date: riskDay(1)},
+		{Date: riskDay(4)},
+		{Date: riskDay(7)},
+	}, []benchmarkIndex{ix})
+
+	if _, ok := points[0].Benchmarks["spy"]; ok {
+		t.Errorf("point 0 = %v, want no level before the first close", points[0].Benchmarks)
+	}
+	if points[1].Benchmarks["spy"] != "100.00" || points[2].Benchmarks["spy"] != "120.00" {
+		t.Errorf("levels = %v, %v; want 100.00, 120.00", points[1].Benchmarks, points[2].Benchmarks)
+	}
+}
+
+func TestSimulateBenchmarkWhatIf_ReportsMissingPrices(t *testing.T) {
+	t.Parallel()
+
+	qqq := models.Benchmark{ID: "qqq", Components: []models.BenchmarkComponent{{Ticker: "QQQ", Weight: "1"}}}
+	got := simulateBenchmarkWhatIf(newBenchmarkIndex(qqq, nil), []xirrCashFlow{{date: riskDay(1), amount: dec("100")}}, riskDay(2))
+	if len(got.MissingPrices) != 1 || got.MissingPrices[0] != "QQQ" {
+		t.Errorf("missing prices = %v, want [QQQ]", got.MissingPrices)
+	}
+	if got.BenchmarkValue != "0" {
+		t.Errorf("benchmark value = %s, want 0", got.BenchmarkValue)
+	}
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrBenchmarkNotFound  = apperror.New(apperror.NotFound, "benchmark not found")
+	ErrInvalidBenchmark   = apperror.New(apperror.Validation, "invalid benchmark")
+	ErrBenchmarkNameTaken = apperror.New(apperror.Conflict, "a benchmark with that name already exists")
+)
+
+// maxBenchmarkComponents caps the size of a custom basket.
+const maxBenchmarkComponents = 10
+
+const customBenchmarkColumns = `id, name, components, created_at, updated_at`
+
+// BenchmarkService manages the benchmarks performance is compared against:
+// built-in presets from config plus each user's custom weighted baskets.
+type BenchmarkService struct {
+	pool *pgxpool.Pool
+}
+
+// NewBenchmarkService creates a new benchmark service.
+func NewBenchmarkService(pool *pgxpool.Pool) *BenchmarkService {
+	return &BenchmarkService{pool: pool}
+}
+
+// ListBenchmarks returns the built-in benchmarks followed by the user's custom ones.
+func (s *BenchmarkService) ListBenchmarks(ctx context.Context, userID string) ([]models.Benchmark, error) {
+	benchmarks := make([]models.Benchmark, 0, len(config.BuiltInBenchmarkPresets))
+	for _, preset := range config.BuiltInBenchmarkPresets {
+		benchmarks = append(benchmarks, benchmarkFromPreset(preset))
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+customBenchmarkColumns+`
+		FROM custom_benchmarks
+		WHERE user_id = $1
+		ORDER BY name
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying custom benchmarks: %w", err)
+	}
+	custom, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.Benchmark])
+	if err != nil {
+		return nil, fmt.Errorf("collecting custom benchmarks: %w", err)
+	}
+	for i := range custom {
+		custom[i].IsCustom = true
+	}
+	return append(benchmarks, custom...), nil
+}
+
+// CreateCustomBenchmark saves a weighted basket of tickers under a unique name.
+func (s *BenchmarkService) CreateCustomBenchmark(ctx context.Context, userID string, req models.CreateBenchmarkRequest) (*models.Benchmark, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidBenchmark)
+	}
+	components, err := normalizeBenchmarkComponents(req.Components)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		INSERT INTO custom_benchmarks (user_id, name, components)
+		VALUES ($1, $2, $3)
+		RETURNING `+customBenchmarkColumns,
+		userID, name, components)
+	if err != nil {
+		return nil, fmt.Errorf("creating benchmark: %w", err)
+	}
+	benchmark, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Benchmark])
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrBenchmarkNameTaken
+		}
+		return nil, fmt.Errorf("collecting benchmark: %w", err)
+	}
+	benchmark.IsCustom = true
+	return &benchmark, nil
+}
+
+// DeleteCustomBenchmark removes one of the user's custom benchmarks.
+func (s *BenchmarkService) DeleteCustomBenchmark(ctx context.Context, userID, benchmarkID string) error {
+	if _, err := uuid.Parse(benchmarkID); err != nil {
+		return ErrBenchmarkNotFound
+	}
+	tag, err := s.pool.Exec(ctx, `DELETE FROM custom_benchmarks WHERE id = $1 AND user_id = $2`, benchmarkID, userID)
+	if err != nil {
+		return fmt.Errorf("deleting benchmark: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrBenchmarkNotFound
+	}
+	return nil
+}
+
+// ResolveBenchmarks looks up benchmarks by preset ID or custom benchmark ID,
+// keeping request order and dropping duplicates. No IDs selects the default.
+func (s *BenchmarkService) ResolveBenchmarks(ctx context.Context, userID string, ids []string) ([]models.Benchmark, error) {
+	if len(ids) == 0 {
+		ids = []string{config.DefaultBenchmarkID}
+	}
+
+	seen := make(map[string]bool, len(ids))
+	result := make([]models.Benchmark, 0, len(ids))
+	for _, id := range ids {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		if len(result) == config.MaxBenchmarksPerRequest {
+			return nil, apperror.Detailf(ErrInvalidBenchmark, "at most %d benchmarks per request", config.MaxBenchmarksPerRequest)
+		}
+
+		if preset := config.GetBenchmarkPreset(id); preset != nil {
+			result = append(result, benchmarkFromPreset(*preset))
+			continue
+		}
+		benchmark, err := s.getCustomBenchmark(ctx, userID, id)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *benchmark)
+	}
+	return result, nil
+}
+
+func (s *BenchmarkService) getCustomBenchmark(ctx context.Context, userID, benchmarkID string) (*models.Benchmark, error) {
+	if _, err := uuid.Parse(benchmarkID); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBenchmarkNotFound, benchmarkID)
+	}
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+customBenchmarkColumns+`
+		FROM custom_benchmarks
+		WHERE id = $1 AND user_id = $2
+	`, benchmarkID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying benchmark: %w", err)
+	}
+	benchmark, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Benchmark])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", ErrBenchmarkNotFound, benchmarkID)
+		}
+		return nil, fmt.Errorf("collecting benchmark: %w", err)
+	}
+	benchmark.IsCustom = true
+	return &benchmark, nil
+}
+
+func benchmarkFromPreset(preset config.BenchmarkPreset) models.Benchmark {
+	return models.Benchmark{ID: preset.ID, Name: preset.Name, Components: slices.Clone(preset.Components)}
+}
+
+// normalizeBenchmarkComponents upper-cases tickers and checks that each ticker
+// appears once with a positive weight and that the weights sum to 1.
+func normalizeBenchmarkComponents(components []models.BenchmarkComponent) ([]models.BenchmarkComponent, error) {
+	if len(components) == 0 || len(components) > maxBenchmarkComponents {
+		return nil, apperror.Detailf(ErrInvalidBenchmark, "a benchmark needs between 1 and %d components", maxBenchmarkComponents)
+	}
+
+	seen := make(map[string]bool, len(components))
+	total := decimal.Zero
+	out := make([]models.BenchmarkComponent, 0, len(components))
+	for _, c := range components {
+		ticker := strings.ToUpper(strings.TrimSpace(c.Ticker))
+		if ticker == "" {
+			return nil, fmt.Errorf("%w: component ticker is required", ErrInvalidBenchmark)
+		}
+		if seen[ticker] {
+			return nil, apperror.Detailf(ErrInvalidBenchmark, "%s appears more than once", ticker)
+		}
+		seen[ticker] = true
+
+		weight, err := decimal.NewFromString(strings.TrimSpace(c.Weight))
+		if err != nil || !weight.IsPositive() {
+			return nil, apperror.Detailf(ErrInvalidBenchmark, "weight for %s must be a positive number", ticker)
+		}
+		total = total.Add(weight)
+		out = append(out, models.BenchmarkComponent{Ticker: ticker, Weight: weight.String()})
+	}
+	if !total.Equal(decimal.NewFromInt(1)) {
+		return nil, apperror.Detailf(ErrInvalidBenchmark, "weights must sum to 1 (got %s)", total)
+	}
+	return out, nil
+}
+
+// BenchmarkTickers returns the distinct component tickers of benchmarks.
+func BenchmarkTickers(benchmarks []models.Benchmark) []string {
+	tickers := make([]string, 0)
+	for _, b := range benchmarks {
+		for _, c := range b.Components {
+			tickers = appendUniqueTicker(tickers, c.Ticker)
+		}
+	}
+	return tickers
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/models"
+)
+
+func TestNormalizeBenchmarkComponents(t *testing.T) {
+	t.Parallel()
+
+	got, err := normalizeBenchmarkComponents([]models.BenchmarkComponent{
+		{Ticker: " vt ", Weight: "0.70"},
+		{Ticker: "bnd", Weight: "0.3"},
+	})
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if got[0].Ticker != "VT" || got[0].Weight != "0.7" || got[1].Ticker != "BND" {
+		t.Errorf("components = %+v, want upper-cased tickers and normalized weights", got)
+	}
+
+	invalid := [][]models.BenchmarkComponent{
+		nil,
+		{{Ticker: "VT", Weight: "0.5"}},
+		{{Ticker: "VT", Weight: "0.5"}, {Ticker: "vt", Weight: "0.5"}},
+		{{Ticker: "VT", Weight: "1.5"}, {Ticker: "BND", Weight: "-0.5"}},
+		{{Ticker: "", Weight: "1"}},
+	}
+	for i, components := range invalid {
+		if _, err := normalizeBenchmarkComponents(components); !errors.Is(err, ErrInvalidBenchmark) {
+			t.Errorf("case %d: err = %v, want ErrInvalidBenchmark", i, err)
+		}
+	}
+}
+
+func TestBuiltInBenchmarkPresetWeightsSumToOne(t *testing.T) {
+	t.Parallel()
+
+	for _, preset := range config.BuiltInBenchmarkPresets {
+		if _, err := normalizeBenchmarkComponents(benchmarkFromPreset(preset).Components); err != nil {
+			t.Errorf("preset %s: %v", preset.ID, err)
+		}
+	}
+}
+
+func TestResolveBenchmarks_Presets(t *testing.T) {
+	t.Parallel()
+
+	svc := NewBenchmarkService(nil)
+
+	got, err := svc.ResolveBenchmarks(context.Background(), "user-1", nil)
+	if err != nil || len(got) != 1 || got[0].ID != config.DefaultBenchmarkID {
+		t.Fatalf("default = %+v, %v; want [%s]", got, err, config.DefaultBenchmarkID)
+	}
+
+	got, err = svc.ResolveBenchmarks(context.Background(), "user-1", []string{"QQQ", "60-40", "qqq"})
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "qqq" || got[1].ID != "60-40" {
+		t.Errorf("benchmarks = %+v, want qqq then 60-40 without duplicates", got)
+	}
+
+	if _, err := svc.ResolveBenchmarks(context.Background(), "user-1", []string{"not-a-benchmark"}); !errors.Is(err, ErrBenchmarkNotFound) {
+		t.Errorf("unknown id err = %v, want ErrBenchmarkNotFound", err)
+	}
+
+	tooMany := []string{"spy", "qqq", "vt", "colcap", "60-40", "spy", "extra"}
+	if _, err := svc.ResolveBenchmarks(context.Background(), "user-1", tooMany); !errors.Is(err, ErrInvalidBenchmark) {
+		t.Errorf("too many err = %v, want ErrInvalidBenchmark", err)
+	}
+}
@@ -2,9 +2,11 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 
+	"fintu-tracking-backend/internal/apperror"
 	"fintu-tracking-backend/internal/models"
 
 	"github.com/jackc/pgx/v5"
@@ -13,7 +15,11 @@ import (
 
 // ErrSubscriptionNotFound is returned when a subscription does not exist or does
 // not belong to the requesting user.
-var ErrSubscriptionNotFound = errors.New("subscription not found")
+var ErrSubscriptionNotFound = apperror.New(apperror.NotFound, "subscription not found")
+
+// ErrInvalidSubscription is returned when a subscription request is incomplete
+// or asks for a plan or provider that cannot be activated.
+var ErrInvalidSubscription = apperror.New(apperror.Validation, "invalid subscription")
 
 // BillingService manages subscription plans and per-user subscriptions.
 type BillingService struct {
@@ -149,27 +155,27 @@ func (s *BillingService) GetOrCreateClosedBetaSubscription(ctx context.Context,
 // activated through it.
 func (s *BillingService) CreateSubscription(ctx context.Context, userID string, req models.CreateSubscriptionRequest) (*models.Subscription, error) {
 	if req.PlanID == "" {
-		return nil, fmt.Errorf("plan_id is required")
+		return nil, fmt.Errorf("%w: plan_id is required", ErrInvalidSubscription)
 	}
 	if req.BillingProvider == "" {
-		return nil, fmt.Errorf("billing_provider is required")
+		return nil, fmt.Errorf("%w: billing_provider is required", ErrInvalidSubscription)
 	}
 
 	// Milestone 1 only supports manual provider.
 	if req.BillingProvider != models.BillingProviderManual {
-		return nil, fmt.Errorf("billing provider %q is not supported in Milestone 1", req.BillingProvider)
+		return nil, apperror.Detailf(ErrInvalidSubscription, "billing provider %q is not supported in Milestone 1", req.BillingProvider)
 	}
 
 	// Verify the plan exists and whether it is a paid plan.
 	var priceMonthly, priceAnnual *float64
 	if err := s.pool.QueryRow(ctx, `SELECT price_monthly_usd, price_annual_usd FROM plans WHERE id = $1`, req.PlanID).Scan(&priceMonthly, &priceAnnual); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("plan %q does not exist", req.PlanID)
+			return nil, apperror.Detailf(ErrInvalidSubscription, "plan %q does not exist", req.PlanID)
 		}
 		return nil, fmt.Errorf("checking plan: %w", err)
 	}
 	if (priceMonthly != nil && *priceMonthly > 0) || (priceAnnual != nil && *priceAnnual > 0) {
-		return nil, fmt.Errorf("paid plans cannot be activated with the manual billing provider")
+		return nil, fmt.Errorf("%w: paid plans cannot be activated with the manual billing provider", ErrInvalidSubscription)
 	}
 
 	providerSubID, err := s.provider.CreateSubscription(ctx, userID, req.PlanID)
@@ -298,6 +304,25 @@ func (s *BillingService) HasActiveSubscription(ctx context.Context, userID strin
 	return active, nil
 }
 
+// PlanFeatures returns the features of the user's current plan, or nil when
+// the user has no subscription. It is used for plan-aware rate limits.
+func (s *BillingService) PlanFeatures(ctx context.Context, userID string) (json.RawMessage, error) {
+	var features json.RawMessage
+	err := s.pool.QueryRow(ctx, `
+		SELECT p.features
+		FROM subscriptions s
+		JOIN plans p ON p.id = s.plan_id
+		WHERE s.user_id = $1
+	`, userID).Scan(&features)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fetching plan features: %w", err)
+	}
+	return features, nil
+}
+
 func (s *BillingService) reactivateClosedBetaSubscription(ctx context.Context, userID string) (*models.Subscription, error) {
 	rows, err := s.pool.Query(ctx, `
 		UPDATE subscriptions
@@ -0,0 +1,460 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrFeeScheduleNotFound = apperror.New(apperror.NotFound, "fee schedule not found")
+	ErrInvalidFeeSchedule  = apperror.New(apperror.Validation, "invalid fee schedule")
+	ErrLastFeeSchedule     = apperror.New(apperror.Conflict, "a broker must keep at least one fee schedule")
+)
+
+// legacyScheduleEffectiveFrom dates the schedule created for brokers that
+// predate versioned fees, so it applies to every historical transaction.
+var legacyScheduleEffectiveFrom = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const feeScheduleColumns = `
+	id, broker_id, user_id, effective_from, deposit_fee, withdrawal_fee,
+	commissions, fx_spread, notes, created_at, updated_at
+`
+
+// ListFeeSchedules returns every fee schedule version for a broker, newest first.
+func (s *BrokerService) ListFeeSchedules(ctx context.Context, userID, brokerID string) ([]models.BrokerFeeSchedule, error) {
+	if err := s.requireBroker(ctx, userID, brokerID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+feeScheduleColumns+`
+		FROM broker_fee_schedules
+		WHERE broker_id = $1 AND user_id = $2
+		ORDER BY effective_from DESC
+	`, brokerID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying fee schedules: %w", err)
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.BrokerFeeSchedule])
+}
+
+// FeeScheduleAt returns the schedule in force for brokerID on date, or nil when
+// the broker has no schedule starting on or before that date.
+func (s *BrokerService) FeeScheduleAt(ctx context.Context, userID, brokerID string, date time.Time) (*models.BrokerFeeSchedule, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+feeScheduleColumns+`
+		FROM broker_fee_schedules
+		WHERE broker_id = $1 AND user_id = $2 AND effective_from <= $3
+		ORDER BY effective_from DESC
+		LIMIT 1
+	`, brokerID, userID, date)
+	if err != nil {
+		return nil, fmt.Errorf("querying fee schedule: %w", err)
+	}
+	defer rows.Close()
+
+	schedule, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.BrokerFeeSchedule])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("collecting fee schedule: %w", err)
+	}
+	return &schedule, nil
+}
+
+// SaveFeeSchedule adds a fee schedule version. Saving a version with the same
+// effective date as an existing one replaces it.
+func (s *BrokerService) SaveFeeSchedule(ctx context.Context, userID, brokerID string, input models.BrokerFeeScheduleInput) (*models.BrokerFeeSchedule, error) {
+	effectiveFrom, err := validateFeeScheduleInput(&input)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireBroker(ctx, userID, brokerID); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin fee schedule: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	schedule, err := upsertFeeSchedule(ctx, tx, userID, brokerID, effectiveFrom, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := syncLegacyBrokerFees(ctx, tx, brokerID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit fee schedule: %w", err)
+	}
+	return schedule, nil
+}
+
+// DeleteFeeSchedule removes one fee schedule version. The last remaining
+// version cannot be deleted.
+func (s *BrokerService) DeleteFeeSchedule(ctx context.Context, userID, brokerID, scheduleID string) error {
+	if err := s.requireBroker(ctx, userID, brokerID); err != nil {
+		return err
+	}
+	if _, err := uuid.Parse(scheduleID); err != nil {
+		return ErrFeeScheduleNotFound
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin fee schedule delete: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var count int
+	if err := tx.QueryRow(ctx, `
+		SELECT COUNT(*) FROM broker_fee_schedules WHERE broker_id = $1 AND user_id = $2
+	`, brokerID, userID).Scan(&count); err != nil {
+		return fmt.Errorf("counting fee schedules: %w", err)
+	}
+
+	result, err := tx.Exec(ctx, `
+		DELETE FROM broker_fee_schedules WHERE id = $1 AND broker_id = $2 AND user_id = $3
+	`, scheduleID, brokerID, userID)
+	if err != nil {
+		return fmt.Errorf("deleting fee schedule: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrFeeScheduleNotFound
+	}
+	// The delete is rolled back when it would leave the broker without fees.
+	if count <= 1 {
+		return ErrLastFeeSchedule
+	}
+
+	if err := syncLegacyBrokerFees(ctx, tx, brokerID); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit fee schedule delete: %w", err)
+	}
+	return nil
+}
+
+// attachCurrentFeeSchedules sets FeeSchedule on each broker to the version in
+// force today.
+func (s *BrokerService) attachCurrentFeeSchedules(ctx context.Context, userID string, brokers []models.Broker) error {
+	if len(brokers) == 0 {
+		return nil
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT DISTINCT ON (broker_id) `+feeScheduleColumns+`
+		FROM broker_fee_schedules
+		WHERE user_id = $1 AND effective_from <= CURRENT_DATE
+		ORDER BY broker_id, effective_from DESC
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("querying current fee schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schedules, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.BrokerFeeSchedule])
+	if err != nil {
+		return fmt.Errorf("collecting current fee schedules: %w", err)
+	}
+
+	byBroker := make(map[string]*models.BrokerFeeSchedule, len(schedules))
+	for i := range schedules {
+		byBroker[schedules[i].BrokerID] = &schedules[i]
+	}
+	for i := range brokers {
+		brokers[i].FeeSchedule = byBroker[brokers[i].ID]
+	}
+	return nil
+}
+
+func upsertFeeSchedule(ctx context.Context, tx pgx.Tx, userID, brokerID string, effectiveFrom time.Time, input models.BrokerFeeScheduleInput) (*models.BrokerFeeSchedule, error) {
+	rows, err := tx.Query(ctx, `
+		INSERT INTO broker_fee_schedules (
+			broker_id, user_id, effective_from, deposit_fee, withdrawal_fee, commissions, fx_spread, notes
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (broker_id, effective_from) DO UPDATE SET
+			deposit_fee = EXCLUDED.deposit_fee,
+			withdrawal_fee = EXCLUDED.withdrawal_fee,
+			commissions = EXCLUDED.commissions,
+			fx_spread = EXCLUDED.fx_spread,
+			notes = EXCLUDED.notes,
+			updated_at = NOW()
+		RETURNING `+feeScheduleColumns,
+		brokerID, userID, effectiveFrom, input.DepositFee, input.WithdrawalFee, input.Commissions, input.FXSpread, input.Notes)
+	if err != nil {
+		return nil, fmt.Errorf("saving fee schedule: %w", err)
+	}
+	defer rows.Close()
+
+	schedule, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.BrokerFeeSchedule])
+	if err != nil {
+		return nil, fmt.Errorf("collecting fee schedule: %w", err)
+	}
+	return &schedule, nil
+}
+
+// syncLegacyBrokerFees mirrors the transfer fees in force today into the
+// brokers.*_fee_* columns. Tiered rules have no single value and mirror as 0.
+func syncLegacyBrokerFees(ctx context.Context, tx pgx.Tx, brokerID string) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE brokers b
+		SET deposit_fee_type = s.deposit_fee->>'type',
+		    deposit_fee_value = CASE WHEN s.deposit_fee->>'type' IN ('flat', 'percentage')
+		                             THEN COALESCE(NULLIF(s.deposit_fee->>'value', ''), '0')::numeric ELSE 0 END,
+		    withdrawal_fee_type = s.withdrawal_fee->>'type',
+		    withdrawal_fee_value = CASE WHEN s.withdrawal_fee->>'type' IN ('flat', 'percentage')
+		                                THEN COALESCE(NULLIF(s.withdrawal_fee->>'value', ''), '0')::numeric ELSE 0 END,
+		    updated_at = NOW()
+		FROM (
+			SELECT deposit_fee, withdrawal_fee
+			FROM broker_fee_schedules
+			WHERE broker_id = $1 AND effective_from <= CURRENT_DATE
+			ORDER BY effective_from DESC
+			LIMIT 1
+		) s
+		WHERE b.id = $1
+	`, brokerID)
+	if err != nil {
+		return fmt.Errorf("syncing broker fee columns: %w", err)
+	}
+	return nil
+}
+
+// validateFeeScheduleInput checks every rule and normalizes empty values. It
+// returns the parsed effective date.
+func validateFeeScheduleInput(input *models.BrokerFeeScheduleInput) (time.Time, error) {
+	effectiveFrom, err := time.Parse("2006-01-02", strings.TrimSpace(input.EffectiveFrom))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: effective_from must be YYYY-MM-DD", ErrInvalidFeeSchedule)
+	}
+
+	if err := normalizeFeeRule("deposit_fee", &input.DepositFee); err != nil {
+		return time.Time{}, err
+	}
+	if err := normalizeFeeRule("withdrawal_fee", &input.WithdrawalFee); err != nil {
+		return time.Time{}, err
+	}
+
+	if input.Commissions == nil {
+		input.Commissions = map[string]models.BrokerFeeRule{}
+	}
+	for assetType, rule := range input.Commissions {
+		switch assetType {
+		case "stock", "etf", "crypto":
+		default:
+			return time.Time{}, apperror.Detailf(ErrInvalidFeeSchedule, "commissions: unknown asset type %q", assetType)
+		}
+		if err := normalizeFeeRule("commissions."+assetType, &rule); err != nil {
+			return time.Time{}, err
+		}
+		input.Commissions[assetType] = rule
+	}
+
+	if strings.TrimSpace(input.FXSpread) == "" {
+		input.FXSpread = "0"
+	}
+	spread, err := decimal.NewFromString(strings.TrimSpace(input.FXSpread))
+	if err != nil || spread.IsNegative() || spread.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+		return time.Time{}, fmt.Errorf("%w: fx_spread must be a fraction between 0 and 1", ErrInvalidFeeSchedule)
+	}
+	input.FXSpread = spread.String()
+
+	return effectiveFrom, nil
+}
+
+// normalizeFeeRule checks the rule at path (such as "deposit_fee") and
+// normalizes its empty values.
+func normalizeFeeRule(path string, rule *models.BrokerFeeRule) error {
+	if rule.Type == "" {
+		rule.Type = string(config.BrokerFeeTypeNone)
+	}
+
+	nonNegative := func(field, value string) error {
+		d, err := decimal.NewFromString(strings.TrimSpace(value))
+		if err != nil || d.IsNegative() {
+			return apperror.Detailf(ErrInvalidFeeSchedule, "%s: %s must be a non-negative number", path, field)
+		}
+		return nil
+	}
+
+	switch config.BrokerFeeType(rule.Type) {
+	case config.BrokerFeeTypeNone:
+		*rule = models.BrokerFeeRule{Type: rule.Type}
+		return nil
+	case config.BrokerFeeTypeFlat:
+		rule.Min, rule.Max, rule.Tiers = nil, nil, nil
+		return nonNegative("value", rule.Value)
+	case config.BrokerFeeTypePercentage:
+		rule.Tiers = nil
+		if err := nonNegative("value", rule.Value); err != nil {
+			return err
+		}
+	case config.BrokerFeeTypeTiered:
+		rule.Value = ""
+		if len(rule.Tiers) == 0 {
+			return apperror.Detailf(ErrInvalidFeeSchedule, "%s: tiers are required", path)
+		}
+		prev := decimal.Zero
+		for i, tier := range rule.Tiers {
+			if err := nonNegative("tier rate", tier.Rate); err != nil {
+				return err
+			}
+			if tier.Flat != "" {
+				if err := nonNegative("tier flat", tier.Flat); err != nil {
+					return err
+				}
+			}
+			if tier.UpTo == nil {
+				if i != len(rule.Tiers)-1 {
+					return apperror.Detailf(ErrInvalidFeeSchedule, "%s: only the last tier may omit up_to", path)
+				}
+				continue
+			}
+			upTo, err := decimal.NewFromString(strings.TrimSpace(*tier.UpTo))
+			if err != nil || !upTo.GreaterThan(prev) {
+				return apperror.Detailf(ErrInvalidFeeSchedule, "%s: tier up_to values must be increasing", path)
+			}
+			prev = upTo
+		}
+	default:
+		return apperror.Detailf(ErrInvalidFeeSchedule, "%s: unsupported fee type %q", path, rule.Type)
+	}
+
+	if rule.Min != nil {
+		if err := nonNegative("min", *rule.Min); err != nil {
+			return err
+		}
+	}
+	if rule.Max != nil {
+		if err := nonNegative("max", *rule.Max); err != nil {
+			return err
+		}
+	}
+	if rule.Min != nil && rule.Max != nil {
+		min, _ := decimal.NewFromString(*rule.Min)
+		max, _ := decimal.NewFromString(*rule.Max)
+		if min.GreaterThan(max) {
+			return apperror.Detailf(ErrInvalidFeeSchedule, "%s: min must not exceed max", path)
+		}
+	}
+	return nil
+}
+
+// computeFeeRule returns the fee charged by rule on amount, rounded to cents.
+func computeFeeRule(rule models.BrokerFeeRule, amount decimal.Decimal) (decimal.Decimal, error) {
+	parse := func(field, value string) (decimal.Decimal, error) {
+		d, err := decimal.NewFromString(strings.TrimSpace(value))
+		if err != nil {
+			return decimal.Zero, fmt.Errorf("invalid %s fee value: %w", field, err)
+		}
+		return d, nil
+	}
+
+	var fee decimal.Decimal
+	switch config.BrokerFeeType(rule.Type) {
+	case config.BrokerFeeTypeNone, "":
+		return decimal.Zero, nil
+	case config.BrokerFeeTypeFlat:
+		value, err := parse("flat", rule.Value)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		return value.Round(2), nil
+	case config.BrokerFeeTypePercentage:
+		rate, err := parse("percentage", rule.Value)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		fee = amount.Mul(rate)
+	case config.BrokerFeeTypeTiered:
+		tier, ok := feeTierFor(rule.Tiers, amount)
+		if !ok {
+			return decimal.Zero, errors.New("amount is above the last fee tier")
+		}
+		rate, err := parse("tier rate", tier.Rate)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		fee = amount.Mul(rate)
+		if tier.Flat != "" {
+			flat, err := parse("tier flat", tier.Flat)
+			if err != nil {
+				return decimal.Zero, err
+			}
+			fee = fee.Add(flat)
+		}
+	default:
+		return decimal.Zero, fmt.Errorf("unsupported fee type %q", rule.Type)
+	}
+
+	if rule.Min != nil {
+		min, err := parse("min", *rule.Min)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		fee = decimal.Max(fee, min)
+	}
+	if rule.Max != nil {
+		max, err := parse("max", *rule.Max)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		fee = decimal.Min(fee, max)
+	}
+	return fee.Round(2), nil
+}
+
+func feeTierFor(tiers []models.BrokerFeeTier, amount decimal.Decimal) (models.BrokerFeeTier, bool) {
+	for _, tier := range tiers {
+		if tier.UpTo == nil {
+			return tier, true
+		}
+		upTo, err := decimal.NewFromString(strings.TrimSpace(*tier.UpTo))
+		if err == nil && amount.LessThanOrEqual(upTo) {
+			return tier, true
+		}
+	}
+	return models.BrokerFeeTier{}, false
+}
+
+// ComputeCommissionUSD returns the trading commission for a trade of notional
+// USD in assetType under schedule. Asset types without a rule are free.
+func (s *BrokerService) ComputeCommissionUSD(schedule models.BrokerFeeSchedule, assetType string, notional decimal.Decimal) (decimal.Decimal, error) {
+	rule, ok := schedule.Commissions[assetType]
+	if !ok {
+		return decimal.Zero, nil
+	}
+	return computeFeeRule(rule, notional.Abs())
+}
+
+// ComputeFXSpreadUSD returns the cost of the schedule's FX spread on usdAmount.
+// A schedule without a spread costs nothing.
+func (s *BrokerService) ComputeFXSpreadUSD(schedule models.BrokerFeeSchedule, usdAmount decimal.Decimal) (decimal.Decimal, error) {
+	if strings.TrimSpace(schedule.FXSpread) == "" {
+		return decimal.Zero, nil
+	}
+	spread, err := decimal.NewFromString(strings.TrimSpace(schedule.FXSpread))
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("invalid fx spread: %w", err)
+	}
+	return usdAmount.Abs().Mul(spread).Round(2), nil
+}
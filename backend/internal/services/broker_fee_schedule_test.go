@@ -0,0 +1,213 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestComputeFeeRule(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   models.BrokerFeeRule
+		amount string
+		want   string
+	}{
+		{name: "none", rule: models.BrokerFeeRule{Type: "none"}, amount: "1000", want: "0"},
+		{name: "flat", rule: models.BrokerFeeRule{Type: "flat", Value: "4.99"}, amount: "1000", want: "4.99"},
+		{name: "percentage", rule: models.BrokerFeeRule{Type: "percentage", Value: "0.009"}, amount: "1000", want: "9"},
+		{
+			name:   "percentage below min",
+			rule:   models.BrokerFeeRule{Type: "percentage", Value: "0.01", Min: strPtr("2")},
+			amount: "50",
+			want:   "2",
+		},
+		{
+			name:   "percentage above max",
+			rule:   models.BrokerFeeRule{Type: "percentage", Value: "0.01", Max: strPtr("15")},
+			amount: "5000",
+			want:   "15",
+		},
+		{
+			name: "tiered first bracket",
+			rule: models.BrokerFeeRule{Type: "tiered", Tiers: []models.BrokerFeeTier{
+				{UpTo: strPtr("1000"), Rate: "0.01"},
+				{UpTo: nil, Rate: "0.005", Flat: "1"},
+			}},
+			amount: "800",
+			want:   "8",
+		},
+		{
+			name: "tiered open bracket with flat",
+			rule: models.BrokerFeeRule{Type: "tiered", Tiers: []models.BrokerFeeTier{
+				{UpTo: strPtr("1000"), Rate: "0.01"},
+				{UpTo: nil, Rate: "0.005", Flat: "1"},
+			}},
+			amount: "4000",
+			want:   "21",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := computeFeeRule(tt.rule, dec(tt.amount))
+			require.NoError(t, err)
+			assert.True(t, got.Equal(dec(tt.want)), "got %s, want %s", got, tt.want)
+		})
+	}
+}
+
+func TestComputeFeeRule_AboveLastTier(t *testing.T) {
+	rule := models.BrokerFeeRule{Type: "tiered", Tiers: []models.BrokerFeeTier{{UpTo: strPtr("100"), Rate: "0.01"}}}
+	_, err := computeFeeRule(rule, dec("101"))
+	require.Error(t, err)
+}
+
+func TestValidateFeeScheduleInput(t *testing.T) {
+	valid := models.BrokerFeeScheduleInput{
+		EffectiveFrom: "2024-03-01",
+		DepositFee:    models.BrokerFeeRule{Type: "percentage", Value: "0.009", Max: strPtr("20")},
+		Commissions: map[string]models.BrokerFeeRule{
+			"crypto": {Type: "percentage", Value: "0.015"},
+		},
+	}
+	_, err := validateFeeScheduleInput(&valid)
+	require.NoError(t, err)
+	assert.Equal(t, "none", valid.WithdrawalFee.Type)
+	assert.Equal(t, "0", valid.FXSpread)
+
+	invalid := []models.BrokerFeeScheduleInput{
+		{EffectiveFrom: "03/01/2024"},
+		{EffectiveFrom: "2024-03-01", DepositFee: models.BrokerFeeRule{Type: "weekly"}},
+		{EffectiveFrom: "2024-03-01", DepositFee: models.BrokerFeeRule{Type: "flat", Value: "-1"}},
+		{EffectiveFrom: "2024-03-01", DepositFee: models.BrokerFeeRule{Type: "percentage", Value: "0.01", Min: strPtr("5"), Max: strPtr("1")}},
+		{EffectiveFrom: "2024-03-01", DepositFee: models.BrokerFeeRule{Type: "tiered"}},
+		{EffectiveFrom: "2024-03-01", DepositFee: models.BrokerFeeRule{Type: "tiered", Tiers: []models.BrokerFeeTier{
+			{UpTo: nil, Rate: "0.01"},
+			{UpTo: strPtr("100"), Rate: "0.02"},
+		}}},
+		{EffectiveFrom: "2024-03-01", DepositFee: models.BrokerFeeRule{Type: "tiered", Tiers: []models.BrokerFeeTier{
+			{UpTo: strPtr("500"), Rate: "0.01"},
+			{UpTo: strPtr("100"), Rate: "0.02"},
+		}}},
+		{EffectiveFrom: "2024-03-01", Commissions: map[string]models.BrokerFeeRule{"bond": {Type: "flat", Value: "1"}}},
+		{EffectiveFrom: "2024-03-01", FXSpread: "1.5"},
+	}
+	for i := range invalid {
+		_, err := validateFeeScheduleInput(&invalid[i])
+		assert.True(t, errors.Is(err, ErrInvalidFeeSchedule), "case %d: err = %v", i, err)
+	}
+}
+
+func TestComputeDepositFeeUSD_PrefersFeeSchedule(t *testing.T) {
+	s := NewBrokerService(nil)
+	broker := models.Broker{
+		DepositFeeType:  "percentage",
+		DepositFeeValue: "0.009",
+		FeeSchedule: &models.BrokerFeeSchedule{
+			DepositFee: models.BrokerFeeRule{Type: "percentage", Value: "0.009", Max: strPtr("5")},
+		},
+	}
+
+	got, err := s.ComputeDepositFeeUSD("1000", broker)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, "5.00", *got)
+}
+
+func TestComputeCommissionAndFXSpread(t *testing.T) {
+	s := NewBrokerService(nil)
+	schedule := models.BrokerFeeSchedule{
+		Commissions: map[string]models.BrokerFeeRule{
+			"crypto": {Type: "percentage", Value: "0.01", Min: strPtr("1")},
+		},
+		FXSpread: "0.015",
+	}
+
+	crypto, err := s.ComputeCommissionUSD(schedule, "crypto", dec("-250"))
+	require.NoError(t, err)
+	assert.Equal(t, "2.5", crypto.String())
+
+	stock, err := s.ComputeCommissionUSD(schedule, "stock", dec("250"))
+	require.NoError(t, err)
+	assert.True(t, stock.IsZero())
+
+	spread, err := s.ComputeFXSpreadUSD(schedule, dec("1000"))
+	require.NoError(t, err)
+	assert.Equal(t, "15", spread.String())
+}
+
+func TestBuildTransferFeeQuote_UsesScheduleInEffect(t *testing.T) {
+	t.Parallel()
+
+	broker := models.Broker{
+		ID:              "broker-hapi",
+		DepositFeeType:  "percentage",
+		DepositFeeValue: "0.05",
+		FeeSchedule: &models.BrokerFeeSchedule{
+			ID:            "schedule-2024",
+			DepositFee:    models.BrokerFeeRule{Type: "percentage", Value: "0.009"},
+			WithdrawalFee: models.BrokerFeeRule{Type: "flat", Value: "4.99"},
+		},
+	}
+
+	deposit, err := NewBrokerService(nil).buildTransferFeeQuote("deposit", broker, dec("1000"))
+	require.NoError(t, err)
+	assert.Equal(t, "9.00", deposit.FeeUSD)
+	assert.Equal(t, "991", deposit.NetUSD)
+	require.NotNil(t, deposit.FeeScheduleID)
+	assert.Equal(t, "schedule-2024", *deposit.FeeScheduleID)
+
+	withdrawal, err := NewBrokerService(nil).buildTransferFeeQuote("withdrawal", broker, dec("200"))
+	require.NoError(t, err)
+	assert.Equal(t, "4.99", withdrawal.FeeUSD)
+	assert.Equal(t, "195.01", withdrawal.NetUSD)
+}
+
+func TestBuildTransferFeeQuote_AddsFXSpread(t *testing.T) {
+	t.Parallel()
+
+	broker := models.Broker{
+		ID: "broker-spread",
+		FeeSchedule: &models.BrokerFeeSchedule{
+			ID:         "schedule-spread",
+			DepositFee: models.BrokerFeeRule{Type: "flat", Value: "2"},
+			FXSpread:   "0.01",
+		},
+	}
+
+	deposit, err := NewBrokerService(nil).buildTransferFeeQuote("deposit", broker, dec("1000"))
+	require.NoError(t, err)
+	assert.Equal(t, "10.00", deposit.FXSpreadUSD)
+	assert.Equal(t, "12.00", deposit.FeeUSD)
+	assert.Equal(t, "988", deposit.NetUSD)
+}
+
+func TestBuildTransferFeeQuote_FallsBackToLegacyColumns(t *testing.T) {
+	t.Parallel()
+
+	broker := models.Broker{
+		ID:                 "broker-legacy",
+		DepositFeeType:     "percentage",
+		DepositFeeValue:    "0.01",
+		WithdrawalFeeType:  "none",
+		WithdrawalFeeValue: "0",
+	}
+
+	deposit, err := NewBrokerService(nil).buildTransferFeeQuote("deposit", broker, dec("500"))
+	require.NoError(t, err)
+	assert.Equal(t, "5.00", deposit.FeeUSD)
+	assert.Nil(t, deposit.FeeScheduleID)
+
+	withdrawal, err := NewBrokerService(nil).buildTransferFeeQuote("withdrawal", broker, dec("500"))
+	require.NoError(t, err)
+	assert.Equal(t, "0.00", withdrawal.FeeUSD)
+	assert.Equal(t, "0.00", withdrawal.FXSpreadUSD)
+	assert.Equal(t, "500", withdrawal.NetUSD)
+}
@@ -5,15 +5,33 @@ import (
 	"fmt"
 	"strings"
 
+	"fintu-tracking-backend/internal/apperror"
 	"fintu-tracking-backend/internal/config"
 	"fintu-tracking-backend/internal/models"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/shopspring/decimal"
 )
 
-// BrokerService manages user broker records built from built-in presets.
+var (
+	ErrBrokerNotFound = apperror.New(apperror.NotFound, "broker not found")
+	ErrInvalidBroker  = apperror.New(apperror.Validation, "invalid broker")
+	ErrBrokerInUse    = apperror.New(apperror.Conflict, "broker has trades or cash flows")
+)
+
+// customBrokerPresetID is the preset_id stored on user-defined brokers.
+const customBrokerPresetID = "custom"
+
+const brokerColumns = `
+	id, user_id, preset_id, name, country, base_currency, local_currency,
+	deposit_fee_type, deposit_fee_value, withdrawal_fee_type, withdrawal_fee_value,
+	is_custom, created_at, updated_at
+`
+
+// BrokerService manages user broker records built from built-in presets or
+// defined by the user, together with their versioned fee schedules.
 type BrokerService struct {
 	pool *pgxpool.Pool
 }
@@ -23,12 +41,10 @@ func NewBrokerService(pool *pgxpool.Pool) *BrokerService {
 	return &BrokerService{pool: pool}
 }
 
-// ListBrokers returns every broker row owned by the user.
+// ListBrokers returns every broker row owned by the user with its current fee schedule.
 func (s *BrokerService) ListBrokers(ctx context.Context, userID string) ([]models.Broker, error) {
 	rows, err := s.pool.Query(ctx, `
-		SELECT id, user_id, preset_id, name, country, base_currency, local_currency,
-		       deposit_fee_type, deposit_fee_value, withdrawal_fee_type, withdrawal_fee_value,
-		       created_at, updated_at
+		SELECT `+brokerColumns+`
 		FROM brokers
 		WHERE user_id = $1
 		ORDER BY created_at ASC
@@ -38,15 +54,24 @@ func (s *BrokerService) ListBrokers(ctx context.Context, userID string) ([]model
 	}
 	defer rows.Close()
 
-	return pgx.CollectRows(rows, pgx.RowToStructByName[models.Broker])
+	brokers, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.Broker])
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachCurrentFeeSchedules(ctx, userID, brokers); err != nil {
+		return nil, err
+	}
+	return brokers, nil
 }
 
 // GetBrokerByID returns a broker row if it exists and belongs to the user.
 func (s *BrokerService) GetBrokerByID(ctx context.Context, userID, brokerID string) (*models.Broker, error) {
+	if _, err := uuid.Parse(brokerID); err != nil {
+		return nil, nil
+	}
+
 	row, err := s.pool.Query(ctx, `
-		SELECT id, user_id, preset_id, name, country, base_currency, local_currency,
-		       deposit_fee_type, deposit_fee_value, withdrawal_fee_type, withdrawal_fee_value,
-		       created_at, updated_at
+		SELECT `+brokerColumns+`
 		FROM brokers
 		WHERE id = $1 AND user_id = $2
 	`, brokerID, userID)
@@ -62,7 +87,11 @@ func (s *BrokerService) GetBrokerByID(ctx context.Context, userID, brokerID stri
 		}
 		return nil, fmt.Errorf("collecting broker: %w", err)
 	}
-	return &broker, nil
+	brokers := []models.Broker{broker}
+	if err := s.attachCurrentFeeSchedules(ctx, userID, brokers); err != nil {
+		return nil, err
+	}
+	return &brokers[0], nil
 }
 
 // GetOrCreateBrokerFromPreset returns an existing user broker for the preset or
@@ -73,13 +102,20 @@ func (s *BrokerService) GetOrCreateBrokerFromPreset(ctx context.Context, userID,
 		return nil, fmt.Errorf("unknown broker preset %q", presetID)
 	}
 
-	rows, err := s.pool.Query(ctx, `
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin broker upsert: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var brokerID string
+	err = tx.QueryRow(ctx, `
 		INSERT INTO brokers (
 			user_id, preset_id, name, country, base_currency, local_currency,
 			deposit_fee_type, deposit_fee_value, withdrawal_fee_type, withdrawal_fee_value
 		)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		ON CONFLICT (user_id, preset_id) DO UPDATE SET
+		ON CONFLICT (user_id, preset_id) WHERE NOT is_custom DO UPDATE SET
 			name = EXCLUDED.name,
 			country = EXCLUDED.country,
 			base_currency = EXCLUDED.base_currency,
@@ -89,36 +125,192 @@ func (s *BrokerService) GetOrCreateBrokerFromPreset(ctx context.Context, userID,
 			withdrawal_fee_type = EXCLUDED.withdrawal_fee_type,
 			withdrawal_fee_value = EXCLUDED.withdrawal_fee_value,
 			updated_at = NOW()
-		RETURNING id, user_id, preset_id, name, country, base_currency, local_currency,
-		          deposit_fee_type, deposit_fee_value, withdrawal_fee_type, withdrawal_fee_value,
-		          created_at, updated_at
+		RETURNING id
 	`, userID, preset.ID, preset.Name, preset.Country, preset.BaseCurrency, preset.LocalCurrency,
-		preset.DepositFee.Type, preset.DepositFee.Value, preset.WithdrawalFee.Type, preset.WithdrawalFee.Value)
+		preset.DepositFee.Type, preset.DepositFee.Value, preset.WithdrawalFee.Type, preset.WithdrawalFee.Value).Scan(&brokerID)
 	if err != nil {
 		return nil, fmt.Errorf("upserting broker: %w", err)
 	}
-	defer rows.Close()
 
-	broker, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Broker])
+	// The preset seeds the first fee schedule; schedules the user added later
+	// stay authoritative and are mirrored back over the preset values.
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO broker_fee_schedules (broker_id, user_id, effective_from, deposit_fee, withdrawal_fee)
+		SELECT $1, $2, $3, $4, $5
+		WHERE NOT EXISTS (SELECT 1 FROM broker_fee_schedules WHERE broker_id = $1)
+	`, brokerID, userID, legacyScheduleEffectiveFrom,
+		models.BrokerFeeRule{Type: string(preset.DepositFee.Type), Value: preset.DepositFee.Value},
+		models.BrokerFeeRule{Type: string(preset.WithdrawalFee.Type), Value: preset.WithdrawalFee.Value},
+	); err != nil {
+		return nil, fmt.Errorf("seeding preset fee schedule: %w", err)
+	}
+	if err := syncLegacyBrokerFees(ctx, tx, brokerID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit broker upsert: %w", err)
+	}
+
+	return s.GetBrokerByID(ctx, userID, brokerID)
+}
+
+// CreateCustomBroker creates a user-defined broker and its first fee schedule.
+// Without a schedule the broker charges no fees. A schedule without an
+// effective date applies to all past transactions.
+func (s *BrokerService) CreateCustomBroker(ctx context.Context, userID string, req models.CreateCustomBrokerRequest) (*models.Broker, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidBroker)
+	}
+	country := strings.ToLower(strings.TrimSpace(req.Country))
+	if country == "" {
+		country = "co"
+	}
+	baseCurrency := strings.ToUpper(strings.TrimSpace(req.BaseCurrency))
+	if baseCurrency == "" {
+		baseCurrency = config.BaseCurrency
+	}
+	localCurrency := strings.ToUpper(strings.TrimSpace(req.LocalCurrency))
+	if localCurrency == "" {
+		localCurrency = config.LocalCurrency
+	}
+
+	schedule := models.BrokerFeeScheduleInput{}
+	if req.FeeSchedule != nil {
+		schedule = *req.FeeSchedule
+	}
+	if strings.TrimSpace(schedule.EffectiveFrom) == "" {
+		schedule.EffectiveFrom = legacyScheduleEffectiveFrom.Format("2006-01-02")
+	}
+	effectiveFrom, err := validateFeeScheduleInput(&schedule)
 	if err != nil {
-		return nil, fmt.Errorf("collecting broker: %w", err)
+		return nil, err
 	}
-	return &broker, nil
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin custom broker: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var brokerID string
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO brokers (
+			user_id, preset_id, name, country, base_currency, local_currency,
+			deposit_fee_type, deposit_fee_value, withdrawal_fee_type, withdrawal_fee_value, is_custom
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, 'none', 0, 'none', 0, true)
+		RETURNING id
+	`, userID, customBrokerPresetID, name, country, baseCurrency, localCurrency).Scan(&brokerID); err != nil {
+		return nil, fmt.Errorf("inserting custom broker: %w", err)
+	}
+	if _, err := upsertFeeSchedule(ctx, tx, userID, brokerID, effectiveFrom, schedule); err != nil {
+		return nil, err
+	}
+	if err := syncLegacyBrokerFees(ctx, tx, brokerID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit custom broker: %w", err)
+	}
+
+	return s.GetBrokerByID(ctx, userID, brokerID)
+}
+
+// UpdateBroker edits a broker's name, country or currencies.
+func (s *BrokerService) UpdateBroker(ctx context.Context, userID, brokerID string, req models.UpdateBrokerRequest) (*models.Broker, error) {
+	if err := s.requireBroker(ctx, userID, brokerID); err != nil {
+		return nil, err
+	}
+	if req.Name != nil && strings.TrimSpace(*req.Name) == "" {
+		return nil, fmt.Errorf("%w: name cannot be empty", ErrInvalidBroker)
+	}
+
+	trimmed := func(v *string, normalize func(string) string) *string {
+		if v == nil || strings.TrimSpace(*v) == "" {
+			return nil
+		}
+		out := normalize(strings.TrimSpace(*v))
+		return &out
+	}
+	keep := func(v string) string { return v }
+
+	if _, err := s.pool.Exec(ctx, `
+		UPDATE brokers
+		SET name = COALESCE($3, name),
+		    country = COALESCE($4, country),
+		    base_currency = COALESCE($5, base_currency),
+		    local_currency = COALESCE($6, local_currency),
+		    updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+	`, brokerID, userID,
+		trimmed(req.Name, keep),
+		trimmed(req.Country, strings.ToLower),
+		trimmed(req.BaseCurrency, strings.ToUpper),
+		trimmed(req.LocalCurrency, strings.ToUpper),
+	); err != nil {
+		return nil, fmt.Errorf("updating broker: %w", err)
+	}
+
+	return s.GetBrokerByID(ctx, userID, brokerID)
+}
+
+// DeleteBroker deletes a broker and its fee schedules. Brokers referenced by
+// trades or cash flows are kept so historical fees stay attributable.
+func (s *BrokerService) DeleteBroker(ctx context.Context, userID, brokerID string) error {
+	if err := s.requireBroker(ctx, userID, brokerID); err != nil {
+		return err
+	}
+
+	var inUse bool
+	if err := s.pool.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM trades WHERE broker_id = $1 AND user_id = $2)
+		    OR EXISTS (SELECT 1 FROM cash_flows WHERE broker_id = $1 AND user_id = $2)
+	`, brokerID, userID).Scan(&inUse); err != nil {
+		return fmt.Errorf("checking broker usage: %w", err)
+	}
+	if inUse {
+		return ErrBrokerInUse
+	}
+
+	if _, err := s.pool.Exec(ctx, `DELETE FROM brokers WHERE id = $1 AND user_id = $2`, brokerID, userID); err != nil {
+		return fmt.Errorf("deleting broker: %w", err)
+	}
+	return nil
+}
+
+func (s *BrokerService) requireBroker(ctx context.Context, userID, brokerID string) error {
+	broker, err := s.GetBrokerByID(ctx, userID, brokerID)
+	if err != nil {
+		return err
+	}
+	if broker == nil {
+		return ErrBrokerNotFound
+	}
+	return nil
 }
 
 // ComputeDepositFeeUSD calculates the USD fee for depositing netUsd with the
 // given broker. It returns nil when no fee applies or inputs are invalid.
+// The broker's FeeSchedule is used when loaded, else the legacy fee columns.
 func (s *BrokerService) ComputeDepositFeeUSD(netUsd string, broker models.Broker) (*string, error) {
-	return computeBrokerFeeUSD(netUsd, broker.DepositFeeType, broker.DepositFeeValue)
+	if broker.FeeSchedule != nil {
+		return computeBrokerFeeUSD(netUsd, broker.FeeSchedule.DepositFee)
+	}
+	return computeBrokerFeeUSD(netUsd, models.BrokerFeeRule{Type: broker.DepositFeeType, Value: broker.DepositFeeValue})
 }
 
 // ComputeWithdrawalFeeUSD calculates the USD fee for withdrawing netUsd with the
 // given broker. It returns nil when no fee applies or inputs are invalid.
+// The broker's FeeSchedule is used when loaded, else the legacy fee columns.
 func (s *BrokerService) ComputeWithdrawalFeeUSD(netUsd string, broker models.Broker) (*string, error) {
-	return computeBrokerFeeUSD(netUsd, broker.WithdrawalFeeType, broker.WithdrawalFeeValue)
+	if broker.FeeSchedule != nil {
+		return computeBrokerFeeUSD(netUsd, broker.FeeSchedule.WithdrawalFee)
+	}
+	return computeBrokerFeeUSD(netUsd, models.BrokerFeeRule{Type: broker.WithdrawalFeeType, Value: broker.WithdrawalFeeValue})
 }
 
-func computeBrokerFeeUSD(netUsd, feeType, feeValue string) (*string, error) {
+func computeBrokerFeeUSD(netUsd string, rule models.BrokerFeeRule) (*string, error) {
 	trimmed := strings.TrimSpace(netUsd)
 	if trimmed == "" {
 		return nil, nil
@@ -132,25 +324,10 @@ func computeBrokerFeeUSD(netUsd, feeType, feeValue string) (*string, error) {
 		return nil, nil
 	}
 
-	switch config.BrokerFeeType(feeType) {
-	case config.BrokerFeeTypeNone:
-		zero := "0.00"
-		return &zero, nil
-	case config.BrokerFeeTypeFlat:
-		value, err := decimal.NewFromString(strings.TrimSpace(feeValue))
-		if err != nil {
-			return nil, fmt.Errorf("invalid flat fee value: %w", err)
-		}
-		result := value.StringFixed(2)
-		return &result, nil
-	case config.BrokerFeeTypePercentage:
-		value, err := decimal.NewFromString(strings.TrimSpace(feeValue))
-		if err != nil {
-			return nil, fmt.Errorf("invalid percentage fee value: %w", err)
-		}
-		result := net.Mul(value).StringFixed(2)
-		return &result, nil
-	default:
-		return nil, fmt.Errorf("unsupported fee type %q", feeType)
+	fee, err := computeFeeRule(rule, net)
+	if err != nil {
+		return nil, err
 	}
+	result := fee.StringFixed(2)
+	return &result, nil
 }
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// QuoteTransferFee previews the broker fee for a deposit or withdrawal of
+// grossUsd on date, using the fee schedule version in effect that day. Brokers
+// without a schedule on or before date fall back to their legacy fee columns,
+// which have no FX spread.
+func (s *BrokerService) QuoteTransferFee(ctx context.Context, userID, brokerID, flowType string, date time.Time, grossUsd decimal.Decimal) (*models.TransferFeeQuote, error) {
+	if flowType != "deposit" && flowType != "withdrawal" {
+		return nil, fmt.Errorf("%w: fees can only be quoted for deposits and withdrawals", ErrInvalidBroker)
+	}
+
+	broker, err := s.GetBrokerByID(ctx, userID, brokerID)
+	if err != nil {
+		return nil, err
+	}
+	if broker == nil {
+		return nil, ErrBrokerNotFound
+	}
+
+	schedule, err := s.FeeScheduleAt(ctx, userID, brokerID, date)
+	if err != nil {
+		return nil, err
+	}
+	broker.FeeSchedule = schedule
+
+	return s.buildTransferFeeQuote(flowType, *broker, grossUsd)
+}
+
+// buildTransferFeeQuote computes the fee for grossUsd from the broker's loaded
+// FeeSchedule, or its legacy fee columns when no schedule is attached. The
+// schedule's FX spread on the converted amount is added to the fee.
+func (s *BrokerService) buildTransferFeeQuote(flowType string, broker models.Broker, grossUsd decimal.Decimal) (*models.TransferFeeQuote, error) {
+	quote := &models.TransferFeeQuote{
+		Type:        flowType,
+		BrokerID:    broker.ID,
+		GrossUSD:    grossUsd.String(),
+		FXSpreadUSD: "0.00",
+	}
+
+	switch {
+	case broker.FeeSchedule != nil && flowType == "withdrawal":
+		quote.FeeRule = broker.FeeSchedule.WithdrawalFee
+	case broker.FeeSchedule != nil:
+		quote.FeeRule = broker.FeeSchedule.DepositFee
+	case flowType == "withdrawal":
+		quote.FeeRule = models.BrokerFeeRule{Type: broker.WithdrawalFeeType, Value: broker.WithdrawalFeeValue}
+	default:
+		quote.FeeRule = models.BrokerFeeRule{Type: broker.DepositFeeType, Value: broker.DepositFeeValue}
+	}
+	if broker.FeeSchedule != nil {
+		quote.FeeScheduleID = &broker.FeeSchedule.ID
+		quote.EffectiveFrom = &broker.FeeSchedule.EffectiveFrom
+	}
+
+	fee := decimal.Zero
+	feeStr, err := computeBrokerFeeUSD(grossUsd.String(), quote.FeeRule)
+	if err != nil {
+		return nil, err
+	}
+	if feeStr != nil {
+		fee, err = decimal.NewFromString(*feeStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse broker fee %q: %w", *feeStr, err)
+		}
+	}
+
+	if broker.FeeSchedule != nil {
+		spread, err := s.ComputeFXSpreadUSD(*broker.FeeSchedule, grossUsd)
+		if err != nil {
+			return nil, err
+		}
+		quote.FXSpreadUSD = spread.StringFixed(2)
+		fee = fee.Add(spread)
+	}
+
+	quote.FeeUSD = fee.StringFixed(2)
+	quote.NetUSD = grossUsd.Sub(fee).String()
+	return quote, nil
+}
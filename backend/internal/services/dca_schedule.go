@@ -0,0 +1,304 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// dcaOccurrence returns the n-th contribution date of a plan (n = 0 is the start
+// date). Monthly plans keep the start day of month, clamped to shorter months.
+func dcaOccurrence(frequency string, start time.Time, n int) time.Time {
+	switch frequency {
+	case "weekly":
+		return start.AddDate(0, 0, 7*n)
+	case "biweekly":
+		return start.AddDate(0, 0, 14*n)
+	default:
+		first := time.Date(start.Year(), start.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+		lastDay := first.AddDate(0, 1, -1).Day()
+		return time.Date(first.Year(), first.Month(), min(start.Day(), lastDay), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// dcaDueDates returns the plan's contribution dates after its last scheduled
+// date up to and including asOf (and its end date), at most limit of them.
+func dcaDueDates(plan models.DCAPlan, asOf time.Time, limit int) []time.Time {
+	dates := make([]time.Time, 0)
+	for n := 0; len(dates) < limit; n++ {
+		d := dcaOccurrence(plan.Frequency, plan.StartDate, n)
+		if d.After(asOf) || (plan.EndDate != nil && d.After(*plan.EndDate)) {
+			break
+		}
+		if plan.LastScheduledDate == nil || d.After(*plan.LastScheduledDate) {
+			dates = append(dates, d)
+		}
+	}
+	return dates
+}
+
+// nextDCARunDate is the first contribution date not generated yet, or nil when
+// the plan is paused or has ended.
+func nextDCARunDate(plan models.DCAPlan) *time.Time {
+	if !plan.Active {
+		return nil
+	}
+	for n := 0; ; n++ {
+		d := dcaOccurrence(plan.Frequency, plan.StartDate, n)
+		if plan.EndDate != nil && d.After(*plan.EndDate) {
+			return nil
+		}
+		if plan.LastScheduledDate == nil || d.After(*plan.LastScheduledDate) {
+			return &d
+		}
+	}
+}
+
+// dcaPlannedOrders splits amount across the plan's targets. Amounts are rounded
+// to cents and the last target absorbs the rounding so they add up to amount.
+func dcaPlannedOrders(amount decimal.Decimal, targets []models.DCATarget) []models.DCAPlannedOrder {
+	orders := make([]models.DCAPlannedOrder, 0, len(targets))
+	remaining := amount
+	for i, t := range targets {
+		weight, _ := decimal.NewFromString(t.Weight)
+		share := amount.Mul(weight).Round(2)
+		if i == len(targets)-1 {
+			share = remaining
+		}
+		remaining = remaining.Sub(share)
+		orders = append(orders, models.DCAPlannedOrder{
+			Ticker:    t.Ticker,
+			AssetType: t.AssetType,
+			Weight:    t.Weight,
+			Amount:    share.StringFixed(2),
+		})
+	}
+	return orders
+}
+
+// parseDCAAmount parses a positive contribution amount.
+func parseDCAAmount(value string) (decimal.Decimal, error) {
+	amount, err := decimal.NewFromString(strings.TrimSpace(value))
+	if err != nil || !amount.IsPositive() {
+		return decimal.Zero, fmt.Errorf("%w: amount must be a positive number", ErrInvalidDCAPlan)
+	}
+	return amount.Round(2), nil
+}
+
+// parseDCADate parses an optional YYYY-MM-DD date; an empty value is nil.
+func parseDCADate(field string, value *string) (*time.Time, error) {
+	if value == nil || strings.TrimSpace(*value) == "" {
+		return nil, nil
+	}
+	date, err := time.Parse("2006-01-02", strings.TrimSpace(*value))
+	if err != nil {
+		return nil, apperror.Detailf(ErrInvalidDCAPlan, "%s must be YYYY-MM-DD", field)
+	}
+	return &date, nil
+}
+
+// normalizeDCATargets upper-cases tickers, defaults asset types to etf and
+// checks that each ticker appears once with a positive weight and that the
+// weights sum to 1.
+func normalizeDCATargets(targets []models.DCATarget) ([]models.DCATarget, error) {
+	if len(targets) == 0 || len(targets) > config.MaxDCATargets {
+		return nil, apperror.Detailf(ErrInvalidDCAPlan, "between 1 and %d targets are required", config.MaxDCATargets)
+	}
+
+	seen := make(map[string]bool, len(targets))
+	total := decimal.Zero
+	out := make([]models.DCATarget, 0, len(targets))
+	for _, t := range targets {
+		ticker := strings.ToUpper(strings.TrimSpace(t.Ticker))
+		if ticker == "" {
+			return nil, fmt.Errorf("%w: target ticker is required", ErrInvalidDCAPlan)
+		}
+		if seen[ticker] {
+			return nil, apperror.Detailf(ErrInvalidDCAPlan, "%s appears more than once", ticker)
+		}
+		seen[ticker] = true
+
+		weight, err := decimal.NewFromString(strings.TrimSpace(t.Weight))
+		if err != nil || !weight.IsPositive() {
+			return nil, apperror.Detailf(ErrInvalidDCAPlan, "weight for %s must be a positive number", ticker)
+		}
+		total = total.Add(weight)
+
+		assetType := strings.ToLower(strings.TrimSpace(t.AssetType))
+		if assetType == "" {
+			assetType = "etf"
+		}
+		if !slices.Contains(config.DCAAssetTypes, assetType) {
+			return nil, apperror.Detailf(ErrInvalidDCAPlan, "asset_type for %s must be one of %s", ticker, strings.Join(config.DCAAssetTypes, ", "))
+		}
+		out = append(out, models.DCATarget{Ticker: ticker, Weight: weight.String(), AssetType: assetType})
+	}
+	if !total.Equal(decimal.NewFromInt(1)) {
+		return nil, apperror.Detailf(ErrInvalidDCAPlan, "weights must sum to 1 (got %s)", total)
+	}
+	return out, nil
+}
+
+// dcaFill is a validated fill of an installment.
+type dcaFill struct {
+	ticker     string
+	assetType  string
+	quantity   decimal.Decimal
+	price      decimal.Decimal
+	tradingFee decimal.Decimal
+}
+
+// parseDCAFills validates the fills of a confirmation: at least one, each for a
+// ticker of the installment's planned orders with a positive quantity and price.
+func parseDCAFills(fills []models.DCAFill, orders []models.DCAPlannedOrder) ([]dcaFill, error) {
+	if len(fills) == 0 {
+		return nil, fmt.Errorf("%w: at least one fill is required", ErrInvalidDCAConfirmation)
+	}
+	assetTypes := make(map[string]string, len(orders))
+	for _, o := range orders {
+		assetTypes[o.Ticker] = o.AssetType
+	}
+
+	out := make([]dcaFill, 0, len(fills))
+	for _, f := range fills {
+		ticker := strings.ToUpper(strings.TrimSpace(f.Ticker))
+		assetType, ok := assetTypes[ticker]
+		if !ok {
+			return nil, apperror.Detailf(ErrInvalidDCAConfirmation, "%q is not planned for this installment", ticker)
+		}
+		quantity, err := decimal.NewFromString(strings.TrimSpace(f.Quantity))
+		if err != nil || !quantity.IsPositive() {
+			return nil, apperror.Detailf(ErrInvalidDCAConfirmation, "quantity for %s must be a positive number", ticker)
+		}
+		price, err := decimal.NewFromString(strings.TrimSpace(f.Price))
+		if err != nil || !price.IsPositive() {
+			return nil, apperror.Detailf(ErrInvalidDCAConfirmation, "price for %s must be a positive number", ticker)
+		}
+		fee := decimal.Zero
+		if f.TradingFee != nil && strings.TrimSpace(*f.TradingFee) != "" {
+			fee, err = decimal.NewFromString(strings.TrimSpace(*f.TradingFee))
+			if err != nil || fee.IsNegative() {
+				return nil, apperror.Detailf(ErrInvalidDCAConfirmation, "trading_fee for %s must be zero or positive", ticker)
+			}
+		}
+		out = append(out, dcaFill{ticker: ticker, assetType: assetType, quantity: quantity, price: price, tradingFee: fee})
+	}
+	return out, nil
+}
+
+// buildDCAAdherenceReport summarizes the plan's installments in [from, to].
+// investedUSD is the buy amount per ticker of the confirmed installments and
+// marketRate the mean of the user's fx_rates over the period (nil without rates).
+func buildDCAAdherenceReport(plan models.DCAPlan, installments []models.DCAInstallment, investedUSD map[string]decimal.Decimal, marketRate *decimal.Decimal, from, to time.Time) *models.DCAAdherenceReport {
+	report := &models.DCAAdherenceReport{
+		PlanID:   plan.ID,
+		From:     from,
+		To:       to,
+		Currency: plan.Currency,
+		Targets:  []models.DCATargetAdherence{},
+	}
+
+	planned, contributed, contributedUSD := decimal.Zero, decimal.Zero, decimal.Zero
+	copConverted, usdConverted := decimal.Zero, decimal.Zero
+	for _, inst := range installments {
+		report.Scheduled++
+		amount, _ := decimal.NewFromString(inst.PlannedAmount)
+		planned = planned.Add(amount)
+
+		switch inst.Status {
+		case "skipped":
+			report.Skipped++
+		case "planned":
+			report.Pending++
+		case "confirmed":
+			report.Confirmed++
+			actual := amount
+			if inst.ActualAmount != nil {
+				actual, _ = decimal.NewFromString(*inst.ActualAmount)
+			}
+			contributed = contributed.Add(actual)
+			if inst.UsdAmount != nil {
+				usd, _ := decimal.NewFromString(*inst.UsdAmount)
+				contributedUSD = contributedUSD.Add(usd)
+				if inst.FxRate != nil {
+					copConverted = copConverted.Add(actual)
+					usdConverted = usdConverted.Add(usd)
+				}
+			}
+		}
+	}
+
+	report.AdherencePct = "0.00"
+	if report.Scheduled > 0 {
+		report.AdherencePct = decimal.NewFromInt(int64(report.Confirmed)).
+			Div(decimal.NewFromInt(int64(report.Scheduled))).Mul(decimal.NewFromInt(100)).StringFixed(2)
+	}
+	report.PlannedAmount = planned.StringFixed(2)
+	report.ContributedAmount = contributed.StringFixed(2)
+	report.ContributedUSD = contributedUSD.StringFixed(2)
+
+	// The achieved rate weights each deposit by its COP amount: total pesos
+	// over total dollars received.
+	if usdConverted.IsPositive() {
+		achieved := copConverted.Div(usdConverted)
+		value := achieved.StringFixed(2)
+		report.AvgAchievedRate = &value
+		if marketRate != nil && marketRate.IsPositive() {
+			diff := achieved.Sub(*marketRate).Div(*marketRate).Mul(decimal.NewFromInt(100)).StringFixed(2)
+			report.RateDifferencePct = &diff
+		}
+	}
+	if marketRate != nil {
+		value := marketRate.StringFixed(2)
+		report.AvgMarketRate = &value
+	}
+
+	totalInvested := decimal.Zero
+	for _, v := range investedUSD {
+		totalInvested = totalInvested.Add(v)
+	}
+	addTarget := func(ticker string, weight decimal.Decimal) {
+		invested := investedUSD[ticker]
+		actual := decimal.Zero
+		if totalInvested.IsPositive() {
+			actual = invested.Div(totalInvested)
+		}
+		report.Targets = append(report.Targets, models.DCATargetAdherence{
+			Ticker:       ticker,
+			TargetWeight: weight.StringFixed(4),
+			ActualWeight: actual.StringFixed(4),
+			InvestedUSD:  invested.StringFixed(2),
+		})
+	}
+	targeted := make(map[string]bool, len(plan.Targets))
+	for _, t := range plan.Targets {
+		weight, _ := decimal.NewFromString(t.Weight)
+		targeted[t.Ticker] = true
+		addTarget(t.Ticker, weight)
+	}
+	// Tickers bought under earlier versions of the plan's targets.
+	former := make([]string, 0)
+	for ticker := range investedUSD {
+		if !targeted[ticker] {
+			former = append(former, ticker)
+		}
+	}
+	sort.Strings(former)
+	for _, ticker := range former {
+		addTarget(ticker, decimal.Zero)
+	}
+	return report
+}
+
+// dcaDate truncates t to its UTC calendar date.
+func dcaDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
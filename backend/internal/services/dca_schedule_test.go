@@ -0,0 +1,261 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+func dcaDay(s string) time.Time {
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func formatDCADates(dates []time.Time) []string {
+	out := make([]string, len(dates))
+	for i, d := range dates {
+		out[i] = d.Format("2006-01-02")
+	}
+	return out
+}
+
+func TestDCAOccurrence_MonthlyClampsToMonthEnd(t *testing.T) {
+	t.Parallel()
+
+	start := dcaDay("2024-01-31")
+	want := []string{"2024-01-31", "2024-02-29", "2024-03-31", "2024-04-30", "2025-01-31"}
+	for i, n := range []int{0, 1, 2, 3, 12} {
+		if got := dcaOccurrence("monthly", start, n).Format("2006-01-02"); got != want[i] {
+			t.Errorf("occurrence %d = %s, want %s", n, got, want[i])
+		}
+	}
+	if got := dcaOccurrence("biweekly", start, 2).Format("2006-01-02"); got != "2024-02-28" {
+		t.Errorf("biweekly occurrence 2 = %s, want 2024-02-28", got)
+	}
+}
+
+func TestDCADueDates(t *testing.T) {
+	t.Parallel()
+
+	last := dcaDay("2024-02-15")
+	end := dcaDay("2024-05-01")
+	tests := []struct {
+		name  string
+		plan  models.DCAPlan
+		asOf  string
+		limit int
+		want  []string
+	}{
+		{
+			name:  "backdated plan catches up to as-of date",
+			plan:  models.DCAPlan{Frequency: "monthly", StartDate: dcaDay("2024-01-15")},
+			asOf:  "2024-03-20",
+			limit: 10,
+			want:  []string{"2024-01-15", "2024-02-15", "2024-03-15"},
+		},
+		{
+			name:  "resumes after last scheduled date",
+			plan:  models.DCAPlan{Frequency: "monthly", StartDate: dcaDay("2024-01-15"), LastScheduledDate: &last},
+			asOf:  "2024-03-15",
+			limit: 10,
+			want:  []string{"2024-03-15"},
+		},
+		{
+			name:  "stops at end date",
+			plan:  models.DCAPlan{Frequency: "weekly", StartDate: dcaDay("2024-04-17"), EndDate: &end},
+			asOf:  "2024-06-01",
+			limit: 10,
+			want:  []string{"2024-04-17", "2024-04-24", "2024-05-01"},
+		},
+		{
+			name:  "caps catch-up",
+			plan:  models.DCAPlan{Frequency: "weekly", StartDate: dcaDay("2024-01-01")},
+			asOf:  "2024-12-31",
+			limit: 2,
+			want:  []string{"2024-01-01", "2024-01-08"},
+		},
+		{
+			name:  "nothing due before start",
+			plan:  models.DCAPlan{Frequency: "monthly", StartDate: dcaDay("2024-07-01")},
+			asOf:  "2024-06-30",
+			limit: 10,
+			want:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := formatDCADates(dcaDueDates(tt.plan, dcaDay(tt.asOf), tt.limit))
+			if len(got) != len(tt.want) {
+				t.Fatalf("due dates = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("due dates = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestNextDCARunDate(t *testing.T) {
+	t.Parallel()
+
+	last := dcaDay("2024-03-15")
+	end := dcaDay("2024-04-01")
+	plan := models.DCAPlan{Frequency: "monthly", StartDate: dcaDay("2024-01-15"), Active: true, LastScheduledDate: &last}
+
+	if got := nextDCARunDate(plan); got == nil || got.Format("2006-01-02") != "2024-04-15" {
+		t.Errorf("next run = %v, want 2024-04-15", got)
+	}
+	plan.EndDate = &end
+	if got := nextDCARunDate(plan); got != nil {
+		t.Errorf("next run after end date = %v, want nil", got)
+	}
+	plan.EndDate = nil
+	plan.Active = false
+	if got := nextDCARunDate(plan); got != nil {
+		t.Errorf("next run of paused plan = %v, want nil", got)
+	}
+}
+
+func TestDCAPlannedOrders_AddUpToAmount(t *testing.T) {
+	t.Parallel()
+
+	orders := dcaPlannedOrders(dec("1000000"), []models.DCATarget{
+		{Ticker: "VOO", Weight: "0.3333", AssetType: "etf"},
+		{Ticker: "QQQ", Weight: "0.3333", AssetType: "etf"},
+		{Ticker: "VXUS", Weight: "0.3334", AssetType: "etf"},
+	})
+
+	want := []string{"333300.00", "333300.00", "333400.00"}
+	total := decimal.Zero
+	for i, o := range orders {
+		if o.Amount != want[i] {
+			t.Errorf("%s amount = %s, want %s", o.Ticker, o.Amount, want[i])
+		}
+		total = total.Add(dec(o.Amount))
+	}
+	if !total.Equal(dec("1000000")) {
+		t.Errorf("planned total = %s, want 1000000", total)
+	}
+}
+
+func TestNormalizeDCATargets(t *testing.T) {
+	t.Parallel()
+
+	targets, err := normalizeDCATargets([]models.DCATarget{
+		{Ticker: " voo ", Weight: "0.6"},
+		{Ticker: "aapl", Weight: "0.40", AssetType: "STOCK"},
+	})
+	if err != nil {
+		t.Fatalf("normalizeDCATargets: %v", err)
+	}
+	if targets[0].Ticker != "VOO" || targets[0].AssetType != "etf" || targets[1].AssetType != "stock" || targets[1].Weight != "0.4" {
+		t.Errorf("targets = %+v", targets)
+	}
+
+	invalid := [][]models.DCATarget{
+		nil,
+		{{Ticker: "VOO", Weight: "0.5"}},
+		{{Ticker: "VOO", Weight: "0.5"}, {Ticker: "voo", Weight: "0.5"}},
+		{{Ticker: "VOO", Weight: "-1"}, {Ticker: "QQQ", Weight: "2"}},
+		{{Ticker: "BTC", Weight: "1", AssetType: "crypto"}},
+	}
+	for _, in := range invalid {
+		if _, err := normalizeDCATargets(in); !errors.Is(err, ErrInvalidDCAPlan) {
+			t.Errorf("normalizeDCATargets(%+v) error = %v, want ErrInvalidDCAPlan", in, err)
+		}
+	}
+}
+
+func TestParseDCAFills(t *testing.T) {
+	t.Parallel()
+
+	orders := []models.DCAPlannedOrder{{Ticker: "VOO", AssetType: "etf"}, {Ticker: "AAPL", AssetType: "stock"}}
+	fills, err := parseDCAFills([]models.DCAFill{
+		{Ticker: "voo", Quantity: "0.25", Price: "480.10", TradingFee: strPtr("1")},
+		{Ticker: "AAPL", Quantity: "1", Price: "190"},
+	}, orders)
+	if err != nil {
+		t.Fatalf("parseDCAFills: %v", err)
+	}
+	if fills[0].ticker != "VOO" || fills[0].assetType != "etf" || !fills[0].tradingFee.Equal(dec("1")) || fills[1].assetType != "stock" {
+		t.Errorf("fills = %+v", fills)
+	}
+
+	invalid := [][]models.DCAFill{
+		nil,
+		{{Ticker: "QQQ", Quantity: "1", Price: "400"}},
+		{{Ticker: "VOO", Quantity: "0", Price: "480"}},
+		{{Ticker: "VOO", Quantity: "1", Price: "abc"}},
+		{{Ticker: "VOO", Quantity: "1", Price: "480", TradingFee: strPtr("-1")}},
+	}
+	for _, in := range invalid {
+		if _, err := parseDCAFills(in, orders); !errors.Is(err, ErrInvalidDCAConfirmation) {
+			t.Errorf("parseDCAFills(%+v) error = %v, want ErrInvalidDCAConfirmation", in, err)
+		}
+	}
+}
+
+func TestBuildDCAAdherenceReport(t *testing.T) {
+	t.Parallel()
+
+	plan := models.DCAPlan{
+		ID:       "plan-1",
+		Currency: "COP",
+		Targets: []models.DCATarget{
+			{Ticker: "VOO", Weight: "0.7", AssetType: "etf"},
+			{Ticker: "QQQ", Weight: "0.3", AssetType: "etf"},
+		},
+	}
+	installments := []models.DCAInstallment{
+		{Status: "confirmed", PlannedAmount: "1000000.00", ActualAmount: strPtr("1000000.00"), FxRate: strPtr("4000"), UsdAmount: strPtr("250.00")},
+		{Status: "confirmed", PlannedAmount: "1000000.00", ActualAmount: strPtr("1200000.00"), FxRate: strPtr("4000"), UsdAmount: strPtr("300.00")},
+		{Status: "skipped", PlannedAmount: "1000000.00"},
+		{Status: "planned", PlannedAmount: "1000000.00"},
+	}
+	invested := map[string]decimal.Decimal{"VOO": dec("360"), "QQQ": dec("140"), "VTI": dec("50")}
+	market := dec("4100")
+
+	report := buildDCAAdherenceReport(plan, installments, invested, &market, dcaDay("2024-01-01"), dcaDay("2024-04-30"))
+
+	if report.Scheduled != 4 || report.Confirmed != 2 || report.Skipped != 1 || report.Pending != 1 {
+		t.Errorf("counts = %d/%d/%d/%d, want 4/2/1/1", report.Scheduled, report.Confirmed, report.Skipped, report.Pending)
+	}
+	if report.AdherencePct != "50.00" || report.PlannedAmount != "4000000.00" || report.ContributedAmount != "2200000.00" || report.ContributedUSD != "550.00" {
+		t.Errorf("report = %+v", report)
+	}
+	if report.AvgAchievedRate == nil || *report.AvgAchievedRate != "4000.00" {
+		t.Errorf("avg achieved rate = %v, want 4000.00", report.AvgAchievedRate)
+	}
+	// 4000 vs 4100: dollars were bought 2.44% cheaper than the period average.
+	if report.RateDifferencePct == nil || *report.RateDifferencePct != "-2.44" {
+		t.Errorf("rate difference = %v, want -2.44", report.RateDifferencePct)
+	}
+	if len(report.Targets) != 3 || report.Targets[0].ActualWeight != "0.6545" || report.Targets[2].Ticker != "VTI" || report.Targets[2].TargetWeight != "0.0000" {
+		t.Errorf("targets = %+v", report.Targets)
+	}
+}
+
+func TestBuildDCAAdherenceReport_NoRates(t *testing.T) {
+	t.Parallel()
+
+	plan := models.DCAPlan{Currency: "USD", Targets: []models.DCATarget{{Ticker: "VOO", Weight: "1", AssetType: "etf"}}}
+	report := buildDCAAdherenceReport(plan, nil, map[string]decimal.Decimal{}, nil, dcaDay("2024-01-01"), dcaDay("2024-01-31"))
+
+	if report.AdherencePct != "0.00" || report.AvgAchievedRate != nil || report.AvgMarketRate != nil || report.RateDifferencePct != nil {
+		t.Errorf("report = %+v", report)
+	}
+	if len(report.Targets) != 1 || report.Targets[0].ActualWeight != "0.0000" {
+		t.Errorf("targets = %+v", report.Targets)
+	}
+}
@@ -0,0 +1,698 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrDCAPlanNotFound          = apperror.New(apperror.NotFound, "DCA plan not found")
+	ErrInvalidDCAPlan           = apperror.New(apperror.Validation, "invalid DCA plan")
+	ErrDCAPlanNameTaken         = apperror.New(apperror.Conflict, "a DCA plan with that name already exists")
+	ErrDCAInstallmentNotFound   = apperror.New(apperror.NotFound, "DCA installment not found")
+	ErrDCAInstallmentNotPending = apperror.New(apperror.Conflict, "DCA installment is not pending")
+	ErrInvalidDCAConfirmation   = apperror.New(apperror.Validation, "invalid DCA confirmation")
+	ErrInvalidDCAReportPeriod   = apperror.New(apperror.Validation, "invalid DCA report period")
+)
+
+// dcaPlanNameMaxLength caps plan names.
+const dcaPlanNameMaxLength = 100
+
+const dcaPlanColumns = `id, portfolio_id, broker_id, name, amount::text AS amount, currency, frequency,
+	start_date, end_date, targets, active, last_scheduled_date, created_at, updated_at`
+
+const dcaInstallmentColumns = `id, plan_id, scheduled_date, status, planned_amount::text AS planned_amount,
+	currency, planned_orders, executed_date, actual_amount::text AS actual_amount, fx_rate::text AS fx_rate,
+	usd_amount::text AS usd_amount, cash_flow_id, trade_ids::text[] AS trade_ids, confirmed_at, created_at, updated_at`
+
+// DCAService manages recurring contribution plans. A scheduler generates one
+// planned installment per due date; confirming an installment records the
+// deposit and buy trades that actually happened.
+type DCAService struct {
+	pool       *pgxpool.Pool
+	portfolios *PortfolioService
+	brokers    *BrokerService
+}
+
+// NewDCAService creates a DCAService backed by the given DB pool.
+func NewDCAService(pool *pgxpool.Pool) *DCAService {
+	return &DCAService{
+		pool:       pool,
+		portfolios: NewPortfolioService(pool),
+		brokers:    NewBrokerService(pool),
+	}
+}
+
+// ListPlans returns the user's DCA plans ordered by name.
+func (s *DCAService) ListPlans(ctx context.Context, userID string) ([]models.DCAPlan, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+dcaPlanColumns+`
+		FROM dca_plans
+		WHERE user_id = $1
+		ORDER BY name
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying DCA plans: %w", err)
+	}
+	plans, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.DCAPlan])
+	if err != nil {
+		return nil, fmt.Errorf("collecting DCA plans: %w", err)
+	}
+	for i := range plans {
+		plans[i].NextRunDate = nextDCARunDate(plans[i])
+	}
+	return plans, nil
+}
+
+// GetPlan returns one of the user's DCA plans.
+func (s *DCAService) GetPlan(ctx context.Context, userID, planID string) (*models.DCAPlan, error) {
+	if _, err := uuid.Parse(planID); err != nil {
+		return nil, ErrDCAPlanNotFound
+	}
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+dcaPlanColumns+`
+		FROM dca_plans
+		WHERE id = $1 AND user_id = $2
+	`, planID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying DCA plan: %w", err)
+	}
+	plan, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.DCAPlan])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDCAPlanNotFound
+		}
+		return nil, fmt.Errorf("collecting DCA plan: %w", err)
+	}
+	plan.NextRunDate = nextDCARunDate(plan)
+	return &plan, nil
+}
+
+// CreatePlan saves a DCA plan and generates the installments already due, so a
+// backdated plan immediately lists the contributions to confirm.
+func (s *DCAService) CreatePlan(ctx context.Context, userID string, req models.CreateDCAPlanRequest) (*models.DCAPlan, error) {
+	name, err := normalizeDCAPlanName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := parseDCAAmount(req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if currency == "" {
+		currency = config.LocalCurrency
+	}
+	if currency != config.LocalCurrency && currency != config.BaseCurrency {
+		return nil, apperror.Detailf(ErrInvalidDCAPlan, "currency must be %s or %s", config.LocalCurrency, config.BaseCurrency)
+	}
+	frequency := strings.ToLower(strings.TrimSpace(req.Frequency))
+	if frequency == "" {
+		frequency = "monthly"
+	}
+	if !slices.Contains(config.DCAFrequencies, frequency) {
+		return nil, apperror.Detailf(ErrInvalidDCAPlan, "frequency must be one of %s", strings.Join(config.DCAFrequencies, ", "))
+	}
+	startDate, err := parseDCADate("start_date", &req.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	if startDate == nil {
+		return nil, fmt.Errorf("%w: start_date is required", ErrInvalidDCAPlan)
+	}
+	endDate, err := parseDCADate("end_date", req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	if endDate != nil && endDate.Before(*startDate) {
+		return nil, fmt.Errorf("%w: end_date must be on or after start_date", ErrInvalidDCAPlan)
+	}
+	targets, err := normalizeDCATargets(req.Targets)
+	if err != nil {
+		return nil, err
+	}
+
+	portfolioID := ""
+	if req.PortfolioID != nil {
+		portfolioID = strings.TrimSpace(*req.PortfolioID)
+	}
+	if portfolioID == "" {
+		if portfolioID, err = s.portfolios.EnsureDefaultPortfolio(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	portfolio, err := s.portfolios.GetPortfolio(ctx, userID, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	brokerID := portfolio.BrokerID
+	if req.BrokerID != nil && strings.TrimSpace(*req.BrokerID) != "" {
+		id := strings.TrimSpace(*req.BrokerID)
+		if err := s.brokers.requireBroker(ctx, userID, id); err != nil {
+			return nil, err
+		}
+		brokerID = &id
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		INSERT INTO dca_plans (user_id, portfolio_id, broker_id, name, amount, currency, frequency, start_date, end_date, targets)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING `+dcaPlanColumns,
+		userID, portfolio.ID, brokerID, name, amount.StringFixed(2), currency, frequency, *startDate, endDate, targets)
+	if err != nil {
+		return nil, fmt.Errorf("creating DCA plan: %w", err)
+	}
+	plan, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.DCAPlan])
+	if err != nil {
+		return nil, mapDCAPlanWriteError(err)
+	}
+
+	if _, err := s.generateForPlan(ctx, &plan, dcaDate(time.Now())); err != nil {
+		return nil, err
+	}
+	plan.NextRunDate = nextDCARunDate(plan)
+	return &plan, nil
+}
+
+// UpdatePlan changes a plan's name, amount, end date, targets or active flag.
+// Installments already generated keep their planned amounts. Resuming a paused
+// plan does not generate the contributions missed while it was paused.
+func (s *DCAService) UpdatePlan(ctx context.Context, userID, planID string, req models.UpdateDCAPlanRequest) (*models.DCAPlan, error) {
+	plan, err := s.GetPlan(ctx, userID, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		if plan.Name, err = normalizeDCAPlanName(*req.Name); err != nil {
+			return nil, err
+		}
+	}
+	if req.Amount != nil {
+		amount, err := parseDCAAmount(*req.Amount)
+		if err != nil {
+			return nil, err
+		}
+		plan.Amount = amount.StringFixed(2)
+	}
+	if req.EndDate != nil {
+		if plan.EndDate, err = parseDCADate("end_date", req.EndDate); err != nil {
+			return nil, err
+		}
+		if plan.EndDate != nil && plan.EndDate.Before(plan.StartDate) {
+			return nil, fmt.Errorf("%w: end_date must be on or after start_date", ErrInvalidDCAPlan)
+		}
+	}
+	if req.Targets != nil {
+		if plan.Targets, err = normalizeDCATargets(req.Targets); err != nil {
+			return nil, err
+		}
+	}
+	if req.Active != nil {
+		plan.Active = *req.Active
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		UPDATE dca_plans SET
+			name = $3,
+			amount = $4,
+			end_date = $5,
+			targets = $6,
+			last_scheduled_date = CASE
+				WHEN $7 AND NOT active THEN GREATEST(COALESCE(last_scheduled_date, start_date - 1), $8::date - 1)
+				ELSE last_scheduled_date
+			END,
+			active = $7
+		WHERE id = $1 AND user_id = $2
+		RETURNING `+dcaPlanColumns,
+		planID, userID, plan.Name, plan.Amount, plan.EndDate, plan.Targets, plan.Active, dcaDate(time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("updating DCA plan: %w", err)
+	}
+	updated, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.DCAPlan])
+	if err != nil {
+		return nil, mapDCAPlanWriteError(err)
+	}
+	updated.NextRunDate = nextDCARunDate(updated)
+	return &updated, nil
+}
+
+// DeletePlan removes a plan and its installments. Deposits and trades recorded
+// by confirmed installments are kept.
+func (s *DCAService) DeletePlan(ctx context.Context, userID, planID string) error {
+	if _, err := uuid.Parse(planID); err != nil {
+		return ErrDCAPlanNotFound
+	}
+	tag, err := s.pool.Exec(ctx, `DELETE FROM dca_plans WHERE id = $1 AND user_id = $2`, planID, userID)
+	if err != nil {
+		return fmt.Errorf("deleting DCA plan: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrDCAPlanNotFound
+	}
+	return nil
+}
+
+// GenerateDueInstallments creates the planned installments of the user's active
+// plans that are due on or before asOf's date. It returns how many were
+// created.
+func (s *DCAService) GenerateDueInstallments(ctx context.Context, userID string, asOf time.Time) (int, error) {
+	return s.generateDue(ctx, &userID, asOf)
+}
+
+// GenerateAllDueInstallments is GenerateDueInstallments for every user.
+func (s *DCAService) GenerateAllDueInstallments(ctx context.Context, asOf time.Time) (int, error) {
+	return s.generateDue(ctx, nil, asOf)
+}
+
+// RunScheduler generates due installments for all users now and then every
+// interval until ctx is cancelled.
+func (s *DCAService) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if created, err := s.GenerateAllDueInstallments(ctx, dcaDate(time.Now())); err != nil {
+			slog.ErrorContext(ctx, "dca scheduler failed", "error", err)
+		} else if created > 0 {
+			slog.InfoContext(ctx, "dca scheduler generated installments", "count", created)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *DCAService) generateDue(ctx context.Context, userID *string, asOf time.Time) (int, error) {
+	asOf = dcaDate(asOf)
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+dcaPlanColumns+`
+		FROM dca_plans
+		WHERE active AND ($1::uuid IS NULL OR user_id = $1::uuid)
+		ORDER BY id
+	`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("querying active DCA plans: %w", err)
+	}
+	plans, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.DCAPlan])
+	if err != nil {
+		return 0, fmt.Errorf("collecting active DCA plans: %w", err)
+	}
+
+	created := 0
+	for i := range plans {
+		n, err := s.generateForPlan(ctx, &plans[i], asOf)
+		if err != nil {
+			return created, err
+		}
+		created += n
+	}
+	return created, nil
+}
+
+// generateForPlan inserts the plan's due installments and advances its
+// last_scheduled_date. Installments that already exist are left untouched, so
+// concurrent runs are harmless.
+func (s *DCAService) generateForPlan(ctx context.Context, plan *models.DCAPlan, asOf time.Time) (int, error) {
+	if !plan.Active {
+		return 0, nil
+	}
+	dates := dcaDueDates(*plan, asOf, config.MaxDCACatchUpInstallments)
+	if len(dates) == 0 {
+		return 0, nil
+	}
+	amount, err := decimal.NewFromString(plan.Amount)
+	if err != nil {
+		return 0, fmt.Errorf("parsing DCA plan amount: %w", err)
+	}
+	orders := dcaPlannedOrders(amount, plan.Targets)
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin DCA schedule: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	created := 0
+	for _, date := range dates {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO dca_installments (plan_id, user_id, scheduled_date, planned_amount, currency, planned_orders)
+			SELECT id, user_id, $2, $3, $4, $5 FROM dca_plans WHERE id = $1
+			ON CONFLICT (plan_id, scheduled_date) DO NOTHING
+		`, plan.ID, date, amount.StringFixed(2), plan.Currency, orders)
+		if err != nil {
+			return 0, fmt.Errorf("inserting DCA installment: %w", err)
+		}
+		created += int(tag.RowsAffected())
+	}
+	last := dates[len(dates)-1]
+	if _, err := tx.Exec(ctx, `
+		UPDATE dca_plans SET last_scheduled_date = $2
+		WHERE id = $1 AND (last_scheduled_date IS NULL OR last_scheduled_date < $2)
+	`, plan.ID, last); err != nil {
+		return 0, fmt.Errorf("advancing DCA schedule: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit DCA schedule: %w", err)
+	}
+	plan.LastScheduledDate = &last
+	return created, nil
+}
+
+// ListInstallments returns the user's installments, newest first, optionally
+// limited to one plan and one status. It only reads: installments are created
+// by the scheduler and GenerateDueInstallments.
+func (s *DCAService) ListInstallments(ctx context.Context, userID, planID, status string) ([]models.DCAInstallment, error) {
+	if planID != "" {
+		if _, err := s.GetPlan(ctx, userID, planID); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+dcaInstallmentColumns+`
+		FROM dca_installments
+		WHERE user_id = $1
+		  AND ($2 = '' OR plan_id::text = $2)
+		  AND ($3 = '' OR status = $3)
+		ORDER BY scheduled_date DESC, plan_id
+	`, userID, planID, status)
+	if err != nil {
+		return nil, fmt.Errorf("querying DCA installments: %w", err)
+	}
+	installments, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.DCAInstallment])
+	if err != nil {
+		return nil, fmt.Errorf("collecting DCA installments: %w", err)
+	}
+	return installments, nil
+}
+
+// ConfirmInstallment records what actually happened for a planned installment:
+// for COP plans a deposit at the given FX rate, then one buy trade per fill,
+// all in the plan's portfolio and broker. USD plans buy with the portfolio's
+// existing cash and record no deposit.
+func (s *DCAService) ConfirmInstallment(ctx context.Context, userID, installmentID string, req models.ConfirmDCAInstallmentRequest) (*models.DCAInstallment, error) {
+	if _, err := uuid.Parse(installmentID); err != nil {
+		return nil, ErrDCAInstallmentNotFound
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin DCA confirmation: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	installment, err := lockPendingInstallment(ctx, tx, userID, installmentID)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := s.GetPlan(ctx, userID, installment.PlanID)
+	if err != nil {
+		return nil, err
+	}
+
+	fills, err := parseDCAFills(req.Fills, installment.PlannedOrders)
+	if err != nil {
+		return nil, err
+	}
+	date := installment.ScheduledDate
+	if req.Date != nil && strings.TrimSpace(*req.Date) != "" {
+		parsed, err := time.Parse("2006-01-02", strings.TrimSpace(*req.Date))
+		if err != nil {
+			return nil, fmt.Errorf("%w: date must be YYYY-MM-DD", ErrInvalidDCAConfirmation)
+		}
+		date = parsed
+	}
+	amount, _ := decimal.NewFromString(installment.PlannedAmount)
+	if req.Amount != nil && strings.TrimSpace(*req.Amount) != "" {
+		amount, err = decimal.NewFromString(strings.TrimSpace(*req.Amount))
+		if err != nil || !amount.IsPositive() {
+			return nil, fmt.Errorf("%w: amount must be a positive number", ErrInvalidDCAConfirmation)
+		}
+	}
+	notes := req.Notes
+	if notes == nil || strings.TrimSpace(*notes) == "" {
+		value := "DCA: " + plan.Name
+		notes = &value
+	}
+
+	var fxRate *decimal.Decimal
+	usdAmount := amount
+	var cashFlowID *string
+	if installment.Currency == config.LocalCurrency {
+		if req.FxRate == nil || strings.TrimSpace(*req.FxRate) == "" {
+			return nil, apperror.Detailf(ErrInvalidDCAConfirmation, "fx_rate is required for %s plans", config.LocalCurrency)
+		}
+		rate, err := decimal.NewFromString(strings.TrimSpace(*req.FxRate))
+		if err != nil || !rate.IsPositive() {
+			return nil, fmt.Errorf("%w: fx_rate must be a positive number", ErrInvalidDCAConfirmation)
+		}
+		fxRate = &rate
+		usdAmount = amount.Div(rate).Round(2)
+
+		var id string
+		if err := tx.QueryRow(ctx, `
+			INSERT INTO cash_flows (user_id, portfolio_id, broker_id, date, type, currency, amount, fx_rate, usd_amount, notes)
+			VALUES ($1, $2, $3, $4, 'deposit', $5, $6, $7, $8, $9)
+			RETURNING id
+		`, userID, plan.PortfolioID, plan.BrokerID, date, config.LocalCurrency, amount.StringFixed(2),
+			rate.String(), usdAmount.StringFixed(2), notes).Scan(&id); err != nil {
+			return nil, fmt.Errorf("inserting DCA deposit: %w", err)
+		}
+		cashFlowID = &id
+	} else if req.FxRate != nil && strings.TrimSpace(*req.FxRate) != "" {
+		return nil, apperror.Detailf(ErrInvalidDCAConfirmation, "fx_rate only applies to %s plans", config.LocalCurrency)
+	}
+
+	tradeIDs := make([]string, 0, len(fills))
+	for _, f := range fills {
+		var id string
+		if err := tx.QueryRow(ctx, `
+			INSERT INTO trades (user_id, portfolio_id, broker_id, date, ticker, asset_type, side, quantity, price, trading_fee, notes)
+			VALUES ($1, $2, $3, $4, $5, $6, 'buy', $7, $8, $9, $10)
+			RETURNING id
+		`, userID, plan.PortfolioID, plan.BrokerID, date, f.ticker, f.assetType, f.quantity.String(),
+			f.price.String(), f.tradingFee.StringFixed(2), notes).Scan(&id); err != nil {
+			return nil, fmt.Errorf("inserting DCA trade: %w", err)
+		}
+		tradeIDs = append(tradeIDs, id)
+	}
+
+	var fxRateValue *string
+	if fxRate != nil {
+		value := fxRate.String()
+		fxRateValue = &value
+	}
+	rows, err := tx.Query(ctx, `
+		UPDATE dca_installments SET
+			status = 'confirmed',
+			executed_date = $3,
+			actual_amount = $4,
+			fx_rate = $5,
+			usd_amount = $6,
+			cash_flow_id = $7,
+			trade_ids = $8::text[]::uuid[],
+			confirmed_at = NOW()
+		WHERE id = $1 AND user_id = $2
+		RETURNING `+dcaInstallmentColumns,
+		installmentID, userID, date, amount.StringFixed(2), fxRateValue, usdAmount.StringFixed(2), cashFlowID, tradeIDs)
+	if err != nil {
+		return nil, fmt.Errorf("confirming DCA installment: %w", err)
+	}
+	confirmed, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.DCAInstallment])
+	if err != nil {
+		return nil, fmt.Errorf("collecting DCA installment: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit DCA confirmation: %w", err)
+	}
+	return &confirmed, nil
+}
+
+// SkipInstallment marks a planned installment as skipped; it still counts as
+// scheduled in the adherence report.
+func (s *DCAService) SkipInstallment(ctx context.Context, userID, installmentID string) (*models.DCAInstallment, error) {
+	if _, err := uuid.Parse(installmentID); err != nil {
+		return nil, ErrDCAInstallmentNotFound
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin DCA skip: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := lockPendingInstallment(ctx, tx, userID, installmentID); err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(ctx, `
+		UPDATE dca_installments SET status = 'skipped'
+		WHERE id = $1 AND user_id = $2
+		RETURNING `+dcaInstallmentColumns,
+		installmentID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("skipping DCA installment: %w", err)
+	}
+	skipped, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.DCAInstallment])
+	if err != nil {
+		return nil, fmt.Errorf("collecting DCA installment: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit DCA skip: %w", err)
+	}
+	return &skipped, nil
+}
+
+// lockPendingInstallment loads an installment for update and checks that it is
+// still planned.
+func lockPendingInstallment(ctx context.Context, tx pgx.Tx, userID, installmentID string) (*models.DCAInstallment, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT `+dcaInstallmentColumns+`
+		FROM dca_installments
+		WHERE id = $1 AND user_id = $2
+		FOR UPDATE
+	`, installmentID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying DCA installment: %w", err)
+	}
+	installment, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.DCAInstallment])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDCAInstallmentNotFound
+		}
+		return nil, fmt.Errorf("collecting DCA installment: %w", err)
+	}
+	if installment.Status != "planned" {
+		return nil, apperror.Detailf(ErrDCAInstallmentNotPending, "it is already %s", installment.Status)
+	}
+	return &installment, nil
+}
+
+// GetAdherenceReport compares a plan's scheduled installments in [from, to]
+// with what was confirmed, and the COP/USD rate achieved by its deposits with
+// the average of the user's fx_rates over the same period. from defaults to
+// the plan's start date and to to today (or the plan's end date).
+func (s *DCAService) GetAdherenceReport(ctx context.Context, userID, planID string, from, to *time.Time) (*models.DCAAdherenceReport, error) {
+	plan, err := s.GetPlan(ctx, userID, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	start := plan.StartDate
+	if from != nil {
+		start = *from
+	}
+	end := dcaDate(time.Now())
+	if plan.EndDate != nil && plan.EndDate.Before(end) {
+		end = *plan.EndDate
+	}
+	if to != nil {
+		end = *to
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("%w: from must be on or before to", ErrInvalidDCAReportPeriod)
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+dcaInstallmentColumns+`
+		FROM dca_installments
+		WHERE plan_id = $1 AND user_id = $2 AND scheduled_date BETWEEN $3 AND $4
+		ORDER BY scheduled_date
+	`, planID, userID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("querying DCA installments: %w", err)
+	}
+	installments, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.DCAInstallment])
+	if err != nil {
+		return nil, fmt.Errorf("collecting DCA installments: %w", err)
+	}
+
+	invested, err := s.investedByTicker(ctx, userID, planID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var avgRate *string
+	if err := s.pool.QueryRow(ctx, `
+		SELECT AVG(rate)::text FROM fx_rates
+		WHERE user_id = $1 AND date BETWEEN $2 AND $3
+	`, userID, start, end).Scan(&avgRate); err != nil {
+		return nil, fmt.Errorf("querying average FX rate: %w", err)
+	}
+	var marketRate *decimal.Decimal
+	if avgRate != nil {
+		if rate, err := decimal.NewFromString(*avgRate); err == nil {
+			marketRate = &rate
+		}
+	}
+
+	return buildDCAAdherenceReport(*plan, installments, invested, marketRate, start, end), nil
+}
+
+// investedByTicker sums the USD bought per ticker by the plan's confirmed
+// installments scheduled in [from, to].
+func (s *DCAService) investedByTicker(ctx context.Context, userID, planID string, from, to time.Time) (map[string]decimal.Decimal, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT t.ticker, SUM(t.quantity * t.price)::text
+		FROM dca_installments i
+		JOIN trades t ON t.id = ANY(i.trade_ids) AND t.user_id = i.user_id
+		WHERE i.plan_id = $1 AND i.user_id = $2 AND i.status = 'confirmed'
+		  AND i.scheduled_date BETWEEN $3 AND $4
+		GROUP BY t.ticker
+	`, planID, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("querying DCA trades: %w", err)
+	}
+	defer rows.Close()
+
+	invested := make(map[string]decimal.Decimal)
+	for rows.Next() {
+		var ticker, amount string
+		if err := rows.Scan(&ticker, &amount); err != nil {
+			return nil, fmt.Errorf("scanning DCA trades: %w", err)
+		}
+		value, err := decimal.NewFromString(amount)
+		if err != nil {
+			return nil, fmt.Errorf("parsing DCA trade amount: %w", err)
+		}
+		invested[ticker] = value
+	}
+	return invested, rows.Err()
+}
+
+func normalizeDCAPlanName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > dcaPlanNameMaxLength {
+		return "", apperror.Detailf(ErrInvalidDCAPlan, "name is required (at most %d characters)", dcaPlanNameMaxLength)
+	}
+	return name, nil
+}
+
+func mapDCAPlanWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrDCAPlanNameTaken
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrDCAPlanNotFound
+	}
+	return fmt.Errorf("writing DCA plan: %w", err)
+}
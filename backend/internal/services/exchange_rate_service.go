@@ -3,16 +3,17 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
-	"os"
 	"strings"
 	"time"
 
 	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/telemetry"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/shopspring/decimal"
@@ -21,16 +22,19 @@ import (
 // ExchangeRateService fetches USD/COP rates from Twelve Data using a shared
 // Postgres TTL cache backed by the fx_rates table.
 type ExchangeRateService struct {
+	apiKey     string
 	store      MarketDataStore
 	httpClient *http.Client
 	baseURL    string
 }
 
-// NewExchangeRateService creates a new ExchangeRateService backed by the given DB pool.
-func NewExchangeRateService(pool *pgxpool.Pool) *ExchangeRateService {
+// NewExchangeRateService creates a new ExchangeRateService backed by the given
+// DB pool. An empty apiKey leaves it serving cached and stored rates only.
+func NewExchangeRateService(pool *pgxpool.Pool, apiKey string) *ExchangeRateService {
 	return &ExchangeRateService{
+		apiKey:     apiKey,
 		store:      NewPostgresMarketDataStore(pool),
-		httpClient: &http.Client{Timeout: 10 * time.Second},
+		httpClient: telemetry.NewHTTPClient(10 * time.Second),
 		baseURL:    config.TwelveDataBaseURL,
 	}
 }
@@ -80,15 +84,19 @@ func (s *ExchangeRateService) FetchCurrentRate(ctx context.Context, userID strin
 	dateStr := today.Format("2006-01-02")
 
 	if row, ok, err := s.store.GetFxRate(ctx, userID, dateStr, config.TwelveDataSource); err != nil {
-		log.Printf("exchange_rate_service: failed to read cached rate: %v", err)
-	} else if ok && isFresh(row.CachedAt, defaultCacheTTL()) {
-		return row, nil
+		slog.WarnContext(ctx, "fx rate cache read failed", "error", err)
+	} else {
+		hit := ok && isFresh(row.CachedAt, defaultCacheTTL())
+		telemetry.RecordCacheLookup(fxRatesCache, hit)
+		if hit {
+			return row, nil
+		}
 	}
 
 	rate, err := s.fetchFromAPI(ctx)
 	if err != nil {
 		if row, ok, fallbackErr := s.store.GetLatestFxRate(ctx, userID); fallbackErr != nil {
-			log.Printf("exchange_rate_service: failed to read fallback rate: %v", fallbackErr)
+			slog.WarnContext(ctx, "fx rate fallback read failed", "error", fallbackErr)
 		} else if ok {
 			return row, nil
 		}
@@ -96,17 +104,19 @@ func (s *ExchangeRateService) FetchCurrentRate(ctx context.Context, userID strin
 	}
 
 	if dbErr := s.store.UpsertFxRate(ctx, userID, today, rate, config.TwelveDataSource); dbErr != nil {
-		log.Printf("exchange_rate_service: failed to persist rate to DB: %v", dbErr)
+		slog.WarnContext(ctx, "fx rate cache write failed", "error", dbErr)
 	}
 	return RateResult{Rate: rate, Date: dateStr, Source: config.TwelveDataSource}, nil
 }
 
-func (s *ExchangeRateService) fetchFromAPI(ctx context.Context) (string, error) {
-	apiKey := os.Getenv("TWELVE_DATA_API_KEY")
-	if apiKey == "" {
-		return "", fmt.Errorf("TWELVE_DATA_API_KEY environment variable is not set")
+func (s *ExchangeRateService) fetchFromAPI(ctx context.Context) (_ string, err error) {
+	if s.apiKey == "" {
+		return "", ErrMarketDataNotConfigured
 	}
 
+	ctx, done := telemetry.StartProviderCall(ctx, twelveDataProvider, "exchange_rate", config.DefaultCurrencyPair)
+	defer func() { done(err) }()
+
 	base := s.baseURL
 	if base == "" {
 		base = config.TwelveDataBaseURL
@@ -116,7 +126,7 @@ func (s *ExchangeRateService) fetchFromAPI(ctx context.Context) (string, error)
 		"%s/exchange_rate?symbol=%s&apikey=%s",
 		strings.TrimRight(base, "/"),
 		url.QueryEscape(config.DefaultCurrencyPair),
-		url.QueryEscape(apiKey),
+		url.QueryEscape(s.apiKey),
 	)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
@@ -126,34 +136,34 @@ func (s *ExchangeRateService) fetchFromAPI(ctx context.Context) (string, error)
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("http request failed: %w", err)
+		return "", providerError(fmt.Errorf("http request failed: %w", err))
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return "", providerError(fmt.Errorf("failed to read response: %w", err))
 	}
 
 	var result twelveDataExchangeRateResponse
 	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+		return "", providerError(fmt.Errorf("failed to decode response: %w", err))
 	}
 
 	if resp.StatusCode == http.StatusTooManyRequests {
-		return "", fmt.Errorf("rate limit: %s", result.errorMessage())
+		return "", fmt.Errorf("%w: %s", ErrProviderRateLimited, result.errorMessage())
 	}
 
 	if strings.EqualFold(strings.TrimSpace(result.Status), "error") {
-		return "", fmt.Errorf("API error: %s", result.errorMessage())
+		return "", fmt.Errorf("%w: %s", ErrProviderRejected, result.errorMessage())
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API returned HTTP %d", resp.StatusCode)
+		return "", providerError(fmt.Errorf("API returned HTTP %d", resp.StatusCode))
 	}
 
 	if result.Rate <= 0 {
-		return "", fmt.Errorf("missing or invalid rate in response")
+		return "", providerError(errors.New("missing or invalid rate in response"))
 	}
 
 	return decimal.NewFromFloat(result.Rate).StringFixed(2), nil
@@ -177,7 +187,7 @@ func twelveDataErrorMessage(status string, code int, message string) string {
 }
 
 // FetchDailyHistory returns daily USD/COP close prices from Twelve Data time_series.
-func (s *ExchangeRateService) FetchDailyHistory(ctx context.Context, days int) ([]FxRateChartPoint, error) {
+func (s *ExchangeRateService) FetchDailyHistory(ctx context.Context, days int) (_ []FxRateChartPoint, err error) {
 	if days <= 0 {
 		days = config.DefaultFXRateDays
 	}
@@ -185,11 +195,13 @@ func (s *ExchangeRateService) FetchDailyHistory(ctx context.Context, days int) (
 		days = config.MaxFXRateDays
 	}
 
-	apiKey := os.Getenv("TWELVE_DATA_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("TWELVE_DATA_API_KEY environment variable is not set")
+	if s.apiKey == "" {
+		return nil, ErrMarketDataNotConfigured
 	}
 
+	ctx, done := telemetry.StartProviderCall(ctx, twelveDataProvider, "time_series", config.DefaultCurrencyPair)
+	defer func() { done(err) }()
+
 	base := s.baseURL
 	if base == "" {
 		base = config.TwelveDataBaseURL
@@ -200,7 +212,7 @@ func (s *ExchangeRateService) FetchDailyHistory(ctx context.Context, days int) (
 		strings.TrimRight(base, "/"),
 		url.QueryEscape(config.DefaultCurrencyPair),
 		days,
-		url.QueryEscape(apiKey),
+		url.QueryEscape(s.apiKey),
 	)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
@@ -210,30 +222,30 @@ func (s *ExchangeRateService) FetchDailyHistory(ctx context.Context, days int) (
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("http request failed: %w", err)
+		return nil, providerError(fmt.Errorf("http request failed: %w", err))
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, providerError(fmt.Errorf("failed to read response: %w", err))
 	}
 
 	var result twelveDataTimeSeriesResponse
 	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, providerError(fmt.Errorf("failed to decode response: %w", err))
 	}
 
 	if resp.StatusCode == http.StatusTooManyRequests {
-		return nil, fmt.Errorf("rate limit: %s", twelveDataErrorMessage(result.Status, result.Code, result.Message))
+		return nil, fmt.Errorf("%w: %s", ErrProviderRateLimited, twelveDataErrorMessage(result.Status, result.Code, result.Message))
 	}
 
 	if strings.EqualFold(strings.TrimSpace(result.Status), "error") {
-		return nil, fmt.Errorf("API error: %s", twelveDataErrorMessage(result.Status, result.Code, result.Message))
+		return nil, fmt.Errorf("%w: %s", ErrProviderRejected, twelveDataErrorMessage(result.Status, result.Code, result.Message))
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned HTTP %d", resp.StatusCode)
+		return nil, providerError(fmt.Errorf("API returned HTTP %d", resp.StatusCode))
 	}
 
 	points := make([]FxRateChartPoint, 0, len(result.Values))
@@ -257,7 +269,7 @@ func (s *ExchangeRateService) FetchDailyHistory(ctx context.Context, days int) (
 	}
 
 	if len(points) == 0 {
-		return nil, fmt.Errorf("no historical rate data returned")
+		return nil, providerError(errors.New("no historical rate data returned"))
 	}
 
 	return points, nil
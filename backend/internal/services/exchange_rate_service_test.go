@@ -20,6 +20,7 @@ type fakeMarketDataStore struct {
 	lastRefresh      map[string]time.Time
 	upsertFxCalls    []upsertFxCall
 	upsertPriceCalls []upsertPriceCall
+	dailyPrices      []models.DailyPrice
 }
 
 type upsertFxCall struct {
@@ -88,6 +89,25 @@ func (f *fakeMarketDataStore) UpsertMarketPrice(_ context.Context, ticker, price
 	return nil
 }
 
+func (f *fakeMarketDataStore) UpsertDailyPrices(_ context.Context, prices []models.DailyPrice) error {
+	f.dailyPrices = append(f.dailyPrices, prices...)
+	return nil
+}
+
+func (f *fakeMarketDataStore) GetDailyPrices(_ context.Context, tickers []string, since time.Time) ([]models.DailyPrice, error) {
+	wanted := make(map[string]bool, len(tickers))
+	for _, ticker := range tickers {
+		wanted[ticker] = true
+	}
+	prices := make([]models.DailyPrice, 0)
+	for _, p := range f.dailyPrices {
+		if wanted[p.Ticker] && !p.Date.Before(since) {
+			prices = append(prices, p)
+		}
+	}
+	return prices, nil
+}
+
 func (f *fakeMarketDataStore) RecordMarketPriceRefresh(_ context.Context, userID string) error {
 	f.lastRefresh[userID] = time.Now()
 	return nil
@@ -99,8 +119,6 @@ func (f *fakeMarketDataStore) GetLastMarketPriceRefresh(_ context.Context, userI
 }
 
 func TestFetchCurrentRate_returnsFreshCachedRateWithoutCallingAPI(t *testing.T) {
-	t.Setenv("TWELVE_DATA_API_KEY", "should-not-be-used")
-
 	today := time.Now().UTC().Format("2006-01-02")
 	store := newFakeMarketDataStore()
 	store.fxRates["user-1|"+today+"|twelve-data"] = RateResult{
@@ -110,7 +128,7 @@ func TestFetchCurrentRate_returnsFreshCachedRateWithoutCallingAPI(t *testing.T)
 		CachedAt: time.Now(),
 	}
 
-	svc := &ExchangeRateService{store: store}
+	svc := &ExchangeRateService{apiKey: "should-not-be-used", store: store}
 
 	result, err := svc.FetchCurrentRate(context.Background(), "user-1")
 	if err != nil {
@@ -142,12 +160,12 @@ func TestFetchCurrentRate_callsAPIAndStoresResultWhenCacheMiss(t *testing.T) {
 
 	store := newFakeMarketDataStore()
 	svc := &ExchangeRateService{
+		apiKey:     "test-key",
 		store:      store,
 		httpClient: server.Client(),
 		baseURL:    server.URL,
 	}
 
-	t.Setenv("TWELVE_DATA_API_KEY", "test-key")
 	result, err := svc.FetchCurrentRate(context.Background(), "user-1")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -183,12 +201,12 @@ func TestFetchCurrentRate_refetchesWhenCacheIsStale(t *testing.T) {
 	}
 
 	svc := &ExchangeRateService{
+		apiKey:     "test-key",
 		store:      store,
 		httpClient: server.Client(),
 		baseURL:    server.URL,
 	}
 
-	t.Setenv("TWELVE_DATA_API_KEY", "test-key")
 	result, err := svc.FetchCurrentRate(context.Background(), "user-1")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -218,12 +236,12 @@ func TestFetchCurrentRate_manualRowIsNotATwelveDataCacheHit(t *testing.T) {
 	}
 
 	svc := &ExchangeRateService{
+		apiKey:     "test-key",
 		store:      store,
 		httpClient: server.Client(),
 		baseURL:    server.URL,
 	}
 
-	t.Setenv("TWELVE_DATA_API_KEY", "test-key")
 	result, err := svc.FetchCurrentRate(context.Background(), "user-1")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -251,12 +269,12 @@ func TestFetchCurrentRate_fallsBackToLatestRowOnAPIFailure(t *testing.T) {
 	}
 
 	svc := &ExchangeRateService{
+		apiKey:     "test-key",
 		store:      store,
 		httpClient: server.Client(),
 		baseURL:    server.URL,
 	}
 
-	t.Setenv("TWELVE_DATA_API_KEY", "test-key")
 	result, err := svc.FetchCurrentRate(context.Background(), "user-1")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -280,12 +298,12 @@ func TestFetchDailyHistory_buildsCorrectURL(t *testing.T) {
 	defer server.Close()
 
 	svc := &ExchangeRateService{
+		apiKey:     "test-key",
 		store:      newFakeMarketDataStore(),
 		httpClient: server.Client(),
 		baseURL:    server.URL,
 	}
 
-	t.Setenv("TWELVE_DATA_API_KEY", "test-key")
 	points, err := svc.FetchDailyHistory(context.Background(), 1)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/i18n"
+	"fintu-tracking-backend/internal/models"
+	"fintu-tracking-backend/internal/telemetry"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrInvalidExposureThreshold is returned for a concentration threshold outside (0, 100].
+var ErrInvalidExposureThreshold = apperror.New(apperror.Validation, "invalid concentration threshold")
+
+// exposureSlice is part of a holding attributed to one name: the whole holding,
+// an ETF constituent, or the remainder of an ETF not covered by its constituents.
+type exposureSlice struct {
+	ticker    string
+	assetType string
+	value     decimal.Decimal
+	meta      *models.SymbolMetadata
+	fund      bool // diversified fund value; not a single-name concentration
+}
+
+// GetExposure classifies current holdings by sector, country, region, market cap,
+// listing currency and asset class using the symbol metadata store, looking
+// through ETFs whose constituents are known. thresholdPct is the single-name
+// share, in percent, above which a concentration warning is raised.
+func (s *AnalyticsService) GetExposure(ctx context.Context, userID string, thresholdPct decimal.Decimal) (*models.ExposureReport, error) {
+	ctx, span := telemetry.StartSpan(ctx, "AnalyticsService.GetExposure")
+	defer span.End()
+
+	if !thresholdPct.IsPositive() || thresholdPct.GreaterThan(decimal.NewFromInt(100)) {
+		return nil, fmt.Errorf("%w: must be a percentage between 0 and 100", ErrInvalidExposureThreshold)
+	}
+
+	holdings, err := s.GetCurrentHoldings(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load holdings: %w", err)
+	}
+
+	store := NewPostgresSymbolMetadataStore(s.pool)
+	tickers := make([]string, 0, len(holdings))
+	for _, h := range holdings {
+		tickers = appendUniqueTicker(tickers, h.Ticker)
+	}
+	constituents, err := store.GetETFConstituents(ctx, tickers)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range constituents {
+		tickers = appendUniqueTicker(tickers, c.Ticker)
+	}
+	metadata, err := store.GetSymbolMetadata(ctx, tickers)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildExposureReport(i18n.FromContext(ctx), holdings, metadata, constituents, thresholdPct), nil
+}
+
+func buildExposureReport(loc i18n.Locale, holdings []models.Holding, metadata []models.SymbolMetadata, constituents []models.ETFConstituent, thresholdPct decimal.Decimal) *models.ExposureReport {
+	metaByTicker := make(map[string]*models.SymbolMetadata, len(metadata))
+	for i := range metadata {
+		metaByTicker[metadata[i].Ticker] = &metadata[i]
+	}
+	constituentsByETF := make(map[string][]models.ETFConstituent)
+	for _, c := range constituents {
+		constituentsByETF[c.ETFTicker] = append(constituentsByETF[c.ETFTicker], c)
+	}
+
+	report := &models.ExposureReport{
+		LookedThrough: []string{},
+		Unclassified:  []string{},
+		Warnings:      []models.ConcentrationWarning{},
+	}
+
+	slices := make([]exposureSlice, 0, len(holdings))
+	total := decimal.Zero
+	for _, h := range holdings {
+		value, err := decimal.NewFromString(h.MarketValue)
+		if err != nil || !value.IsPositive() {
+			continue
+		}
+		total = total.Add(value)
+
+		covered := decimal.Zero
+		if parts := constituentsByETF[h.Ticker]; len(parts) > 0 {
+			report.LookedThrough = append(report.LookedThrough, h.Ticker)
+			for _, c := range parts {
+				weight, err := decimal.NewFromString(c.Weight)
+				if err != nil || !weight.IsPositive() {
+					continue
+				}
+				covered = covered.Add(weight)
+				slices = append(slices, exposureSlice{ticker: c.Ticker, assetType: "stock", value: value.Mul(weight), meta: metaByTicker[c.Ticker]})
+			}
+		}
+		if remainder := value.Mul(decimal.NewFromInt(1).Sub(covered)); remainder.IsPositive() {
+			slices = append(slices, exposureSlice{
+				ticker:    h.Ticker,
+				assetType: h.AssetType,
+				value:     remainder,
+				meta:      metaByTicker[h.Ticker],
+				fund:      h.AssetType == "etf",
+			})
+		}
+	}
+	report.HoldingsValue = total.StringFixed(2)
+
+	var bySector, byCountry, byRegion, byMarketCap, byCurrency, byAssetClass, byName exposureTotals
+	funds := make(map[string]bool)
+	unclassified := make(map[string]bool)
+	for _, e := range slices {
+		var meta models.SymbolMetadata
+		if e.meta != nil {
+			meta = *e.meta
+		}
+		bySector.add(exposureLabel(e, meta.Sector), e.value)
+		byCountry.add(exposureLabel(e, meta.Country), e.value)
+		byRegion.add(exposureLabel(e, meta.Region), e.value)
+		byMarketCap.add(exposureLabel(e, meta.MarketCap), e.value)
+		byCurrency.add(exposureCurrency(e), e.value)
+		byAssetClass.add(exposureAssetClass(e), e.value)
+		byName.add(e.ticker, e.value)
+		if e.fund {
+			funds[e.ticker] = true
+		}
+		if e.meta == nil {
+			unclassified[e.ticker] = true
+		}
+	}
+
+	report.BySector = bySector.buckets(total)
+	report.ByCountry = byCountry.buckets(total)
+	report.ByRegion = byRegion.buckets(total)
+	report.ByMarketCap = byMarketCap.buckets(total)
+	report.ByCurrency = byCurrency.buckets(total)
+	report.ByAssetClass = byAssetClass.buckets(total)
+	report.ByName = byName.buckets(total)
+
+	for ticker := range unclassified {
+		report.Unclassified = append(report.Unclassified, ticker)
+	}
+	sort.Strings(report.Unclassified)
+	sort.Strings(report.LookedThrough)
+
+	threshold := thresholdPct.StringFixed(2)
+	for _, b := range report.ByName {
+		if funds[b.Name] {
+			continue
+		}
+		if pct, _ := decimal.NewFromString(b.Percent); pct.GreaterThan(thresholdPct) {
+			report.Warnings = append(report.Warnings, models.ConcentrationWarning{
+				Ticker:    b.Name,
+				Percent:   b.Percent,
+				Threshold: threshold,
+				Message: loc.Sprintf("%s is %s%% of your holdings, above the %s%% concentration threshold",
+					b.Name, loc.Decimal(pct, 2), loc.Decimal(thresholdPct, 2)),
+			})
+		}
+	}
+
+	return report
+}
+
+// exposureLabel is the bucket for a metadata field. Crypto without metadata is
+// still bucketed as "Crypto" rather than unclassified.
+func exposureLabel(e exposureSlice, value *string) string {
+	if value != nil && *value != "" {
+		return *value
+	}
+	if e.meta == nil && e.assetType == "crypto" {
+		return "Crypto"
+	}
+	return config.UnclassifiedExposure
+}
+
+func exposureCurrency(e exposureSlice) string {
+	if e.meta != nil && e.meta.Currency != "" {
+		return e.meta.Currency
+	}
+	return config.DefaultMarketCurrency
+}
+
+func exposureAssetClass(e exposureSlice) string {
+	if e.meta != nil && e.meta.AssetClass != "" {
+		return e.meta.AssetClass
+	}
+	if e.assetType == "crypto" {
+		return "crypto"
+	}
+	return config.UnclassifiedExposure
+}
+
+// exposureTotals sums holdings value per bucket name.
+type exposureTotals map[string]decimal.Decimal
+
+func (t *exposureTotals) add(name string, value decimal.Decimal) {
+	if *t == nil {
+		*t = make(exposureTotals)
+	}
+	(*t)[name] = (*t)[name].Add(value)
+}
+
+// buckets returns the totals with percentages of total, largest first (ties by
+// name so responses are stable).
+func (t exposureTotals) buckets(total decimal.Decimal) []models.ExposureBucket {
+	names := make([]string, 0, len(t))
+	for name := range t {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if !t[names[i]].Equal(t[names[j]]) {
+			return t[names[i]].GreaterThan(t[names[j]])
+		}
+		return names[i] < names[j]
+	})
+
+	buckets := make([]models.ExposureBucket, 0, len(names))
+	for _, name := range names {
+		pct := decimal.Zero
+		if total.IsPositive() {
+			pct = t[name].Div(total).Mul(decimal.NewFromInt(100))
+		}
+		buckets = append(buckets, models.ExposureBucket{
+			Name:    name,
+			Value:   t[name].StringFixed(2),
+			Percent: pct.StringFixed(2),
+		})
+	}
+	return buckets
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"testing"
+
+	"fintu-tracking-backend/internal/i18n"
+	"fintu-tracking-backend/internal/models"
+)
+
+func exposureBucket(t *testing.T, buckets []models.ExposureBucket, name string) models.ExposureBucket {
+	t.Helper()
+	for _, b := range buckets {
+		if b.Name == name {
+			return b
+		}
+	}
+	t.Fatalf("bucket %q missing from %+v", name, buckets)
+	return models.ExposureBucket{}
+}
+
+func TestBuildExposureReport_LooksThroughETFs(t *testing.T) {
+	t.Parallel()
+
+	holdings := []models.Holding{
+		{Ticker: "VOO", AssetType: "etf", MarketValue: "8000"},
+		{Ticker: "AAPL", AssetType: "stock", MarketValue: "2000"},
+	}
+	metadata := []models.SymbolMetadata{
+		{Ticker: "VOO", AssetClass: "equity", Sector: strPtr("Diversified"), Country: strPtr("United States"), Region: strPtr("North America"), MarketCap: strPtr("large"), Currency: "USD"},
+		{Ticker: "AAPL", AssetClass: "equity", Sector: strPtr("Technology"), Country: strPtr("United States"), Region: strPtr("North America"), MarketCap: strPtr("mega"), Currency: "USD"},
+		{Ticker: "MSFT", AssetClass: "equity", Sector: strPtr("Technology"), Country: strPtr("United States"), Region: strPtr("North America"), MarketCap: strPtr("mega"), Currency: "USD"},
+	}
+	constituents := []models.ETFConstituent{
+		{ETFTicker: "VOO", Ticker: "AAPL", Weight: "0.25"},
+		{ETFTicker: "VOO", Ticker: "MSFT", Weight: "0.25"},
+	}
+
+	report := buildExposureReport(i18n.English, holdings, metadata, constituents, dec("20"))
+
+	if report.HoldingsValue != "10000.00" {
+		t.Errorf("holdings value = %s, want 10000.00", report.HoldingsValue)
+	}
+	// AAPL: 2000 direct + 2000 through VOO.
+	if got := exposureBucket(t, report.ByName, "AAPL"); got.Value != "4000.00" || got.Percent != "40.00" {
+		t.Errorf("AAPL = %+v, want 4000.00 / 40.00", got)
+	}
+	if got := exposureBucket(t, report.ByName, "VOO"); got.Value != "4000.00" {
+		t.Errorf("VOO remainder = %s, want 4000.00", got.Value)
+	}
+	if got := exposureBucket(t, report.BySector, "Technology"); got.Percent != "60.00" {
+		t.Errorf("technology = %s%%, want 60.00", got.Percent)
+	}
+	if got := exposureBucket(t, report.ByRegion, "North America"); got.Percent != "100.00" {
+		t.Errorf("north america = %s%%, want 100.00", got.Percent)
+	}
+	if len(report.LookedThrough) != 1 || report.LookedThrough[0] != "VOO" {
+		t.Errorf("looked through = %v, want [VOO]", report.LookedThrough)
+	}
+
+	// AAPL (40%) is flagged; MSFT (20%) is not above the threshold and the VOO
+	// remainder is a diversified fund, not a single name.
+	if len(report.Warnings) != 1 || report.Warnings[0].Ticker != "AAPL" {
+		t.Fatalf("warnings = %+v, want only AAPL", report.Warnings)
+	}
+	if got := report.Warnings[0].Message; got != "AAPL is 40.00% of your holdings, above the 20.00% concentration threshold" {
+		t.Errorf("warning message = %q", got)
+	}
+	report = buildExposureReport(i18n.SpanishColombia, holdings, metadata, constituents, dec("20"))
+	if got := report.Warnings[0].Message; got != "AAPL es el 40,00% de sus posiciones, por encima del umbral de concentración de 20,00%" {
+		t.Errorf("es-CO warning message = %q", got)
+	}
+}
+
+func TestBuildExposureReport_UnclassifiedAndCrypto(t *testing.T) {
+	t.Parallel()
+
+	holdings := []models.Holding{
+		{Ticker: "XYZ", AssetType: "stock", MarketValue: "500"},
+		{Ticker: "SOL/USD", AssetType: "crypto", MarketValue: "500"},
+		{Ticker: "OLD", AssetType: "stock", MarketValue: "0"},
+	}
+
+	report := buildExposureReport(i18n.English, holdings, nil, nil, dec("60"))
+
+	if got := exposureBucket(t, report.BySector, "Crypto"); got.Percent != "50.00" {
+		t.Errorf("crypto sector = %s%%, want 50.00", got.Percent)
+	}
+	if got := exposureBucket(t, report.BySector, "Unclassified"); got.Percent != "50.00" {
+		t.Errorf("unclassified sector = %s%%, want 50.00", got.Percent)
+	}
+	if got := exposureBucket(t, report.ByCurrency, "USD"); got.Percent != "100.00" {
+		t.Errorf("USD = %s%%, want 100.00 (default listing currency)", got.Percent)
+	}
+	if len(report.Unclassified) != 2 || report.Unclassified[0] != "SOL/USD" || report.Unclassified[1] != "XYZ" {
+		t.Errorf("unclassified = %v, want [SOL/USD XYZ]", report.Unclassified)
+	}
+	if len(report.Warnings) != 0 {
+		t.Errorf("warnings = %+v, want none below a 60%% threshold", report.Warnings)
+	}
+}
@@ -2,17 +2,24 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/shopspring/decimal"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/i18n"
 	"fintu-tracking-backend/internal/models"
+	"fintu-tracking-backend/internal/telemetry"
 )
 
 // FeeService handles fee attribution, reconciliation, and analysis
 type FeeService struct {
-	pool *pgxpool.Pool
+	pool        *pgxpool.Pool
+	portfolioID string
 }
 
 // NewFeeService creates a new fee service
@@ -20,6 +27,12 @@ func NewFeeService(pool *pgxpool.Pool) *FeeService {
 	return &FeeService{pool: pool}
 }
 
+// ForPortfolio returns a copy of the service scoped to one portfolio.
+// An empty ID keeps the consolidated view.
+func (s *FeeService) ForPortfolio(portfolioID string) *FeeService {
+	return &FeeService{pool: s.pool, portfolioID: portfolioID}
+}
+
 // DateRange represents a time period for analysis
 type DateRange struct {
 	StartDate *time.Time
@@ -28,16 +41,19 @@ type DateRange struct {
 
 // GetTotalFeesByType returns aggregate fees broken down by type
 func (s *FeeService) GetTotalFeesByType(ctx context.Context, userID string, dateRange *DateRange) (models.FeeBreakdown, error) {
+	ctx, span := telemetry.StartSpan(ctx, "FeeService.GetTotalFeesByType")
+	defer span.End()
+
 	query := `
 		SELECT 
 			COALESCE(fee_type, 'other') as fee_type,
 			SUM(usd_amount) as total
 		FROM cash_flows
-		WHERE user_id = $1 AND type = 'fee'
+		WHERE user_id = $1 AND type = 'fee' AND ` + portfolioScopeSQL("portfolio_id", 2) + `
 	`
 
-	args := []interface{}{userID}
-	argCount := 1
+	args := []interface{}{userID, portfolioScopeArg(s.portfolioID)}
+	argCount := 2
 	query, args, argCount = appendCashFlowFeeDateRange(query, args, argCount, dateRange)
 
 	query += " GROUP BY fee_type"
@@ -56,6 +72,7 @@ func (s *FeeService) GetTotalFeesByType(ctx context.Context, userID string, date
 		OtherFees:       "0",
 		TotalFees:       "0",
 		FeesByMonth:     make(map[string]string),
+		ByBroker:        []models.BrokerFees{},
 	}
 
 	totalFees := decimal.Zero
@@ -89,6 +106,10 @@ func (s *FeeService) GetTotalFeesByType(ctx context.Context, userID string, date
 		return breakdown, err
 	}
 
+	if err := s.populateFeesByBroker(ctx, userID, dateRange, &breakdown); err != nil {
+		return breakdown, err
+	}
+
 	return breakdown, rows.Err()
 }
 
@@ -120,9 +141,9 @@ func appendCashFlowFeeDateRange(query string, args []interface{}, argCount int,
 }
 
 func (s *FeeService) populateFeesByMonth(ctx context.Context, userID string, dateRange *DateRange, breakdown *models.FeeBreakdown) error {
-	monthQuery := feesByMonthSQL()
-	monthArgs := []interface{}{userID}
-	monthQuery, monthArgs, _ = appendCashFlowFeeDateRange(monthQuery, monthArgs, 1, dateRange)
+	monthQuery := feesByMonthSQL() + " AND " + portfolioScopeSQL("portfolio_id", 2)
+	monthArgs := []interface{}{userID, portfolioScopeArg(s.portfolioID)}
+	monthQuery, monthArgs, _ = appendCashFlowFeeDateRange(monthQuery, monthArgs, 2, dateRange)
 	monthQuery += " GROUP BY date_trunc('month', date) ORDER BY date_trunc('month', date)"
 
 	monthRows, err := s.pool.Query(ctx, monthQuery, monthArgs...)
@@ -142,8 +163,89 @@ func (s *FeeService) populateFeesByMonth(ctx context.Context, userID string, dat
 	return monthRows.Err()
 }
 
-// GetFeeImpactOnReturn calculates how fees affected returns for a specific ticker
-func (s *FeeService) GetFeeImpactOnReturn(ctx context.Context, userID, ticker string) (map[string]string, error) {
+func feesByBrokerSQL() string {
+	return `
+		SELECT
+			` + cashFlowBrokerExpr + ` AS broker_id,
+			COALESCE(fee_type, 'other') as fee_type,
+			SUM(usd_amount) as total
+		FROM cash_flows
+		WHERE user_id = $1 AND type = 'fee' AND ` + portfolioScopeSQL("portfolio_id", 2) + `
+	`
+}
+
+func (s *FeeService) populateFeesByBroker(ctx context.Context, userID string, dateRange *DateRange, breakdown *models.FeeBreakdown) error {
+	names, err := loadBrokerNames(ctx, s.pool, userID)
+	if err != nil {
+		return err
+	}
+
+	query, args, _ := appendCashFlowFeeDateRange(feesByBrokerSQL(), []interface{}{userID, portfolioScopeArg(s.portfolioID)}, 2, dateRange)
+	query += " GROUP BY 1, 2"
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query fees by broker: %w", err)
+	}
+	defer rows.Close()
+
+	type brokerFeeTotals struct {
+		brokerID *string
+		byType   map[string]decimal.Decimal
+		total    decimal.Decimal
+	}
+	totals := make(map[string]*brokerFeeTotals)
+	for rows.Next() {
+		var brokerID *string
+		var feeType, amount string
+		if err := rows.Scan(&brokerID, &feeType, &amount); err != nil {
+			return fmt.Errorf("failed to scan fees by broker: %w", err)
+		}
+		amt, _ := decimal.NewFromString(amount)
+
+		key := brokerKey(brokerID)
+		t, ok := totals[key]
+		if !ok {
+			t = &brokerFeeTotals{brokerID: brokerID, byType: make(map[string]decimal.Decimal)}
+			totals[key] = t
+		}
+		switch feeType {
+		case "deposit", "trading", "closing", "maintenance":
+		default:
+			feeType = "other"
+		}
+		t.byType[feeType] = t.byType[feeType].Add(amt)
+		t.total = t.total.Add(amt)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, t := range totals {
+		breakdown.ByBroker = append(breakdown.ByBroker, models.BrokerFees{
+			BrokerID:        t.brokerID,
+			BrokerName:      brokerDisplayName(names, t.brokerID),
+			DepositFees:     t.byType["deposit"].String(),
+			TradingFees:     t.byType["trading"].String(),
+			ClosingFees:     t.byType["closing"].String(),
+			MaintenanceFees: t.byType["maintenance"].String(),
+			OtherFees:       t.byType["other"].String(),
+			TotalFees:       t.total.String(),
+		})
+	}
+	sort.Slice(breakdown.ByBroker, func(i, j int) bool {
+		a, b := breakdown.ByBroker[i], breakdown.ByBroker[j]
+		return brokerLess(a.BrokerID, a.BrokerName, b.BrokerID, b.BrokerName)
+	})
+	return nil
+}
+
+// GetFeeImpactOnReturn returns the fees paid on a ticker relative to its
+// purchase cost.
+func (s *FeeService) GetFeeImpactOnReturn(ctx context.Context, userID, ticker string) (models.FeeImpact, error) {
+	ctx, span := telemetry.StartSpan(ctx, "FeeService.GetFeeImpactOnReturn")
+	defer span.End()
+
 	query := `
 		SELECT 
 			SUM(CASE WHEN side = 'buy' THEN quantity ELSE -quantity END) as net_quantity,
@@ -151,51 +253,42 @@ func (s *FeeService) GetFeeImpactOnReturn(ctx context.Context, userID, ticker st
 			SUM(COALESCE(total_fees, 0)) as total_fees,
 			COUNT(*) as trade_count
 		FROM trades
-		WHERE user_id = $1 AND ticker = $2
+		WHERE user_id = $1 AND ticker = $2 AND ` + portfolioScopeSQL("portfolio_id", 3) + `
 		GROUP BY ticker
 	`
 
-	var netQty, totalCost, totalFees string
-	var tradeCount int
-
-	err := s.pool.QueryRow(ctx, query, userID, ticker).Scan(&netQty, &totalCost, &totalFees, &tradeCount)
+	impact := models.FeeImpact{Ticker: ticker}
+	err := s.pool.QueryRow(ctx, query, userID, ticker, portfolioScopeArg(s.portfolioID)).
+		Scan(&impact.NetQuantity, &impact.TotalCost, &impact.TotalFees, &impact.TradeCount)
+	if errors.Is(err, pgx.ErrNoRows) {
+		impact.TotalFees, impact.TotalCost, impact.FeeImpactPct = "0", "0", "0"
+		return impact, nil
+	}
 	if err != nil {
-		if err != nil && err.Error() == "no rows in result set" {
-			return map[string]string{
-				"total_fees":       "0",
-				"total_cost":       "0",
-				"fee_impact_pct":   "0",
-				"trade_count":      "0",
-			}, nil
-		}
-		return nil, fmt.Errorf("failed to calculate fee impact: %w", err)
+		return impact, fmt.Errorf("failed to calculate fee impact: %w", err)
 	}
 
-	cost, _ := decimal.NewFromString(totalCost)
-	fees, _ := decimal.NewFromString(totalFees)
-	
-	feeImpactPct := "0"
+	cost, _ := decimal.NewFromString(impact.TotalCost)
+	fees, _ := decimal.NewFromString(impact.TotalFees)
+	impact.FeeImpactPct = "0"
 	if !cost.IsZero() {
-		feeImpactPct = fees.Div(cost).Mul(decimal.NewFromInt(100)).String()
+		impact.FeeImpactPct = fees.Div(cost).Mul(decimal.NewFromInt(100)).String()
 	}
-
-	return map[string]string{
-		"total_fees":     totalFees,
-		"total_cost":     totalCost,
-		"fee_impact_pct": feeImpactPct,
-		"trade_count":    fmt.Sprintf("%d", tradeCount),
-		"net_quantity":   netQty,
-	}, nil
+	return impact, nil
 }
 
 // ReconcileCashFlowFees checks that all trade fees have corresponding cash flows
 func (s *FeeService) ReconcileCashFlowFees(ctx context.Context, userID string) (models.ReconciliationReport, error) {
+	ctx, span := telemetry.StartSpan(ctx, "FeeService.ReconcileCashFlowFees")
+	defer span.End()
+
 	report := models.ReconciliationReport{
 		IsReconciled:      true,
 		MissingLinks:      []string{},
 		OrphanedCashFlows: []string{},
 		UnlinkedCashFlows: []string{},
 		Discrepancies:     []models.ReconciliationIssue{},
+		ByBroker:          []models.BrokerReconciliation{},
 	}
 
 	// Get total fees from trades (dual-track: deposit + trading + closing via total_fees)
@@ -203,8 +296,8 @@ func (s *FeeService) ReconcileCashFlowFees(ctx context.Context, userID string) (
 	err := s.pool.QueryRow(ctx, `
 		SELECT COALESCE(SUM(total_fees), 0)
 		FROM trades
-		WHERE user_id = $1
-	`, userID).Scan(&totalTradeFees)
+		WHERE user_id = $1 AND `+portfolioScopeSQL("portfolio_id", 2)+`
+	`, userID, portfolioScopeArg(s.portfolioID)).Scan(&totalTradeFees)
 	if err != nil {
 		return report, fmt.Errorf("failed to get total trade fees: %w", err)
 	}
@@ -216,7 +309,8 @@ func (s *FeeService) ReconcileCashFlowFees(ctx context.Context, userID string) (
 		SELECT COALESCE(SUM(usd_amount), 0)
 		FROM cash_flows
 		WHERE user_id = $1 AND type = 'fee' AND related_type = 'trade'
-	`, userID).Scan(&totalCashFlowFees)
+		  AND `+portfolioScopeSQL("portfolio_id", 2)+`
+	`, userID, portfolioScopeArg(s.portfolioID)).Scan(&totalCashFlowFees)
 	if err != nil {
 		return report, fmt.Errorf("failed to get total cash flow fees: %w", err)
 	}
@@ -233,6 +327,7 @@ func (s *FeeService) ReconcileCashFlowFees(ctx context.Context, userID string) (
 		SELECT t.id
 		FROM trades t
 		WHERE t.user_id = $1 
+		  AND `+portfolioScopeSQL("t.portfolio_id", 2)+`
 		  AND t.total_fees > 0
 		  AND NOT EXISTS (
 			SELECT 1 FROM cash_flows cf 
@@ -240,7 +335,7 @@ func (s *FeeService) ReconcileCashFlowFees(ctx context.Context, userID string) (
 			  AND cf.type = 'fee'
 			  AND cf.related_type = 'trade'
 		  )
-	`, userID)
+	`, userID, portfolioScopeArg(s.portfolioID))
 	if err != nil {
 		return report, fmt.Errorf("failed to check missing links: %w", err)
 	}
@@ -254,7 +349,7 @@ func (s *FeeService) ReconcileCashFlowFees(ctx context.Context, userID string) (
 		}
 	}
 
-	orphanedRows, err := s.pool.Query(ctx, reconcileOrphanedCashFlowsSQL(), userID)
+	orphanedRows, err := s.pool.Query(ctx, reconcileOrphanedCashFlowsSQL(), userID, portfolioScopeArg(s.portfolioID))
 	if err != nil {
 		return report, fmt.Errorf("failed to check orphaned cash flows: %w", err)
 	}
@@ -273,10 +368,11 @@ func (s *FeeService) ReconcileCashFlowFees(ctx context.Context, userID string) (
 		SELECT cf.id
 		FROM cash_flows cf
 		WHERE cf.user_id = $1
+		  AND `+portfolioScopeSQL("cf.portfolio_id", 2)+`
 		  AND cf.type = 'fee'
 		  AND cf.related_type = 'trade'
 		  AND cf.related_trade_id IS NULL
-	`, userID)
+	`, userID, portfolioScopeArg(s.portfolioID))
 	if err != nil {
 		return report, fmt.Errorf("failed to check unlinked cash flows: %w", err)
 	}
@@ -290,7 +386,7 @@ func (s *FeeService) ReconcileCashFlowFees(ctx context.Context, userID string) (
 		}
 	}
 
-	discRows, err := s.pool.Query(ctx, reconcileDiscrepanciesSQL(), userID)
+	discRows, err := s.pool.Query(ctx, reconcileDiscrepanciesSQL(), userID, portfolioScopeArg(s.portfolioID))
 	if err != nil {
 		return report, fmt.Errorf("failed to check discrepancies: %w", err)
 	}
@@ -299,15 +395,16 @@ func (s *FeeService) ReconcileCashFlowFees(ctx context.Context, userID string) (
 	for discRows.Next() {
 		var issue models.ReconciliationIssue
 		var date time.Time
+		var reconciliationDiff string
 		
-		if err := discRows.Scan(&issue.TradeID, &issue.Ticker, &date, &issue.ExpectedFees, &issue.ActualCashFlowFees); err == nil {
+		if err := discRows.Scan(&issue.TradeID, &issue.Ticker, &date, &issue.BrokerID, &issue.ExpectedFees, &issue.ActualCashFlowFees, &reconciliationDiff); err == nil {
 			issue.Date = date.Format("2006-01-02")
 			
 			expected, _ := decimal.NewFromString(issue.ExpectedFees)
 			actual, _ := decimal.NewFromString(issue.ActualCashFlowFees)
 			diff := expected.Sub(actual)
 			issue.Difference = diff.String()
-			issue.Description = fmt.Sprintf("Trade fee (%s) doesn't match cash flow fees (%s)", issue.ExpectedFees, issue.ActualCashFlowFees)
+			issue.Description = reconciliationIssueDescription(i18n.FromContext(ctx), issue)
 			
 			report.Discrepancies = append(report.Discrepancies, issue)
 			report.IsReconciled = false
@@ -318,75 +415,137 @@ func (s *FeeService) ReconcileCashFlowFees(ctx context.Context, userID string) (
 		report.IsReconciled = false
 	}
 
+	if err := s.populateReconciliationByBroker(ctx, userID, &report); err != nil {
+		return report, err
+	}
+
 	return report, nil
 }
 
+// reconciliationIssueDescription explains a fee discrepancy in the locale of
+// the request, with both amounts formatted as dollars.
+func reconciliationIssueDescription(loc i18n.Locale, issue models.ReconciliationIssue) string {
+	return loc.Sprintf("Trade fee (%s) doesn't match cash flow fees (%s)",
+		loc.MoneyString(config.BaseCurrency, issue.ExpectedFees),
+		loc.MoneyString(config.BaseCurrency, issue.ActualCashFlowFees))
+}
+
+// reconcileByBrokerSQL totals trade fees and their fee cash flows per broker.
+// Fee cash flows follow the broker of their trade, falling back to their own
+// broker_id once the trade is gone.
+func reconcileByBrokerSQL() string {
+	return `
+		SELECT broker_id, COALESCE(SUM(trade_fees), 0), COALESCE(SUM(cash_flow_fees), 0)
+		FROM (
+			SELECT broker_id, COALESCE(total_fees, 0) AS trade_fees, 0 AS cash_flow_fees
+			FROM trades
+			WHERE user_id = $1 AND ` + portfolioScopeSQL("portfolio_id", 2) + `
+			UNION ALL
+			SELECT COALESCE(t.broker_id, cf.broker_id), 0, cf.usd_amount
+			FROM cash_flows cf
+			LEFT JOIN trades t ON t.id = cf.related_trade_id
+			WHERE cf.user_id = $1 AND cf.type = 'fee' AND cf.related_type = 'trade'
+			  AND ` + portfolioScopeSQL("cf.portfolio_id", 2) + `
+		) fees
+		GROUP BY broker_id
+	`
+}
+
+func (s *FeeService) populateReconciliationByBroker(ctx context.Context, userID string, report *models.ReconciliationReport) error {
+	names, err := loadBrokerNames(ctx, s.pool, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := s.pool.Query(ctx, reconcileByBrokerSQL(), userID, portfolioScopeArg(s.portfolioID))
+	if err != nil {
+		return fmt.Errorf("failed to reconcile fees by broker: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var brokerID *string
+		var tradeFees, cashFlowFees string
+		if err := rows.Scan(&brokerID, &tradeFees, &cashFlowFees); err != nil {
+			return fmt.Errorf("failed to scan broker reconciliation: %w", err)
+		}
+		tradeDec, _ := decimal.NewFromString(tradeFees)
+		cashDec, _ := decimal.NewFromString(cashFlowFees)
+		difference := tradeDec.Sub(cashDec)
+
+		report.ByBroker = append(report.ByBroker, models.BrokerReconciliation{
+			BrokerID:          brokerID,
+			BrokerName:        brokerDisplayName(names, brokerID),
+			IsReconciled:      !feeTotalsMismatch(difference),
+			TotalTradeFees:    tradeFees,
+			TotalCashFlowFees: cashFlowFees,
+			Difference:        difference.String(),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	sort.Slice(report.ByBroker, func(i, j int) bool {
+		a, b := report.ByBroker[i], report.ByBroker[j]
+		return brokerLess(a.BrokerID, a.BrokerName, b.BrokerID, b.BrokerName)
+	})
+	return nil
+}
+
 // feeTotalsMismatch reports whether aggregate trade vs cash-flow fee totals differ beyond tolerance.
 func feeTotalsMismatch(difference decimal.Decimal) bool {
 	return !difference.IsZero() && difference.Abs().GreaterThan(decimal.NewFromFloat(0.01))
 }
 
-// GetFeeEfficiency calculates fee efficiency metrics by ticker or period
-func (s *FeeService) GetFeeEfficiency(ctx context.Context, userID string, groupBy string) (map[string]interface{}, error) {
-	// This is a placeholder for more complex fee efficiency calculations
-	// Can be expanded based on specific needs
-	
-	if groupBy == "ticker" {
-		query := `
-			SELECT 
-				ticker,
-				COUNT(*) as trade_count,
-				SUM(COALESCE(total_fees, 0)) as total_fees,
-				SUM(quantity * price) as total_value,
-				AVG(COALESCE(total_fees, 0) / NULLIF(quantity * price, 0) * 100) as avg_fee_pct
-			FROM trades
-			WHERE user_id = $1 AND COALESCE(total_fees, 0) > 0
-			GROUP BY ticker
-			ORDER BY SUM(COALESCE(total_fees, 0)) DESC
-		`
-
-		rows, err := s.pool.Query(ctx, query, userID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to calculate fee efficiency: %w", err)
-		}
-		defer rows.Close()
-
-		results := make(map[string]interface{})
-		tickers := []map[string]string{}
-
-		for rows.Next() {
-			var ticker string
-			var tradeCount int
-			var totalFees, totalValue, avgFeePct string
-
-			if err := rows.Scan(&ticker, &tradeCount, &totalFees, &totalValue, &avgFeePct); err == nil {
-				tickers = append(tickers, map[string]string{
-					"ticker":       ticker,
-					"trade_count":  fmt.Sprintf("%d", tradeCount),
-					"total_fees":   totalFees,
-					"total_value":  totalValue,
-					"avg_fee_pct":  avgFeePct,
-				})
-			}
-		}
+// GetFeeEfficiency returns the fees paid per ticker, highest first. Only
+// trades that paid fees are counted.
+func (s *FeeService) GetFeeEfficiency(ctx context.Context, userID string) (models.FeeEfficiency, error) {
+	ctx, span := telemetry.StartSpan(ctx, "FeeService.GetFeeEfficiency")
+	defer span.End()
 
-		results["by_ticker"] = tickers
-		return results, nil
+	query := `
+		SELECT 
+			ticker,
+			COUNT(*) as trade_count,
+			SUM(COALESCE(total_fees, 0)) as total_fees,
+			SUM(quantity * price) as total_value,
+			COALESCE(AVG(COALESCE(total_fees, 0) / NULLIF(quantity * price, 0) * 100), 0) as avg_fee_pct
+		FROM trades
+		WHERE user_id = $1 AND COALESCE(total_fees, 0) > 0 AND ` + portfolioScopeSQL("portfolio_id", 2) + `
+		GROUP BY ticker
+		ORDER BY SUM(COALESCE(total_fees, 0)) DESC
+	`
+
+	efficiency := models.FeeEfficiency{ByTicker: []models.TickerFeeEfficiency{}}
+	rows, err := s.pool.Query(ctx, query, userID, portfolioScopeArg(s.portfolioID))
+	if err != nil {
+		return efficiency, fmt.Errorf("failed to calculate fee efficiency: %w", err)
 	}
+	defer rows.Close()
 
-	return map[string]interface{}{}, nil
+	for rows.Next() {
+		var t models.TickerFeeEfficiency
+		if err := rows.Scan(&t.Ticker, &t.TradeCount, &t.TotalFees, &t.TotalValue, &t.AvgFeePct); err != nil {
+			return efficiency, fmt.Errorf("failed to scan fee efficiency: %w", err)
+		}
+		efficiency.ByTicker = append(efficiency.ByTicker, t)
+	}
+	return efficiency, rows.Err()
 }
 
 func reconcileDiscrepanciesSQL() string {
 	return `
-		SELECT trade_id, ticker, date, trade_total_fees, cash_flow_total_fees, reconciliation_diff
+		SELECT trade_id, ticker, date,
+		       (SELECT broker_id FROM trades WHERE trades.id = fee_reconciliation_summary.trade_id),
+		       trade_total_fees, cash_flow_total_fees, reconciliation_diff
 		FROM fee_reconciliation_summary
-		WHERE user_id = $1 AND reconciliation_diff <> 0
+		WHERE user_id = $1 AND reconciliation_diff <> 0 AND ` + portfolioScopeSQL("portfolio_id", 2) + `
 	`
 }
 
 func reconcileOrphanedCashFlowsSQL() string {
 	return `
-		SELECT id FROM orphaned_fee_cash_flows WHERE user_id = $1
+		SELECT id FROM orphaned_fee_cash_flows WHERE user_id = $1 AND ` + portfolioScopeSQL("portfolio_id", 2) + `
 	`
 }
@@ -4,6 +4,9 @@ import (
 	"testing"
 	"time"
 
+	"fintu-tracking-backend/internal/i18n"
+	"fintu-tracking-backend/internal/models"
+
 	"github.com/shopspring/decimal"
 )
 
@@ -82,3 +85,18 @@ func TestReconcileQueriesUseSQLViews(t *testing.T) {
 		"orphaned_fee_cash_flows",
 	})
 }
+
+func TestReconciliationIssueDescription(t *testing.T) {
+	t.Parallel()
+
+	issue := models.ReconciliationIssue{ExpectedFees: "1234.5", ActualCashFlowFees: "0"}
+	tests := map[i18n.Locale]string{
+		i18n.English:         "Trade fee ($1,234.50) doesn't match cash flow fees ($0.00)",
+		i18n.SpanishColombia: "La comisión de la operación (US$1.234,50) no coincide con las comisiones registradas en el flujo de caja (US$0,00)",
+	}
+	for loc, want := range tests {
+		if got := reconciliationIssueDescription(loc, issue); got != want {
+			t.Errorf("%s: got %q, want %q", loc, got, want)
+		}
+	}
+}
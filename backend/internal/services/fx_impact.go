@@ -6,10 +6,14 @@ import (
 
 	"github.com/shopspring/decimal"
 	"fintu-tracking-backend/internal/models"
+	"fintu-tracking-backend/internal/telemetry"
 )
 
 // CalculateFXImpact analyzes the impact of exchange rate changes
 func (s *AnalyticsService) CalculateFXImpact(ctx context.Context, userID string) (models.FXImpactReport, error) {
+	ctx, span := telemetry.StartSpan(ctx, "AnalyticsService.CalculateFXImpact")
+	defer span.End()
+
 	report := models.FXImpactReport{
 		AvgInvestmentRate: "0",
 		CurrentRate:       "0",
@@ -30,7 +34,8 @@ func (s *AnalyticsService) CalculateFXImpact(ctx context.Context, userID string)
 		WHERE cf.user_id = $1 
 			AND cf.type = 'deposit' 
 			AND cf.fx_rate IS NOT NULL
-	`, userID).Scan(&avgRate)
+			AND `+portfolioScopeSQL("cf.portfolio_id", 2)+`
+	`, userID, s.portfolioArg()).Scan(&avgRate)
 	if err != nil {
 		avgRate = "0"
 	}
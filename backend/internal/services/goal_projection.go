@@ -0,0 +1,170 @@
+package services
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"time"
+
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// goalProjectionInput holds everything a projection needs, in USD. Returns and
+// volatility are annual decimal fractions.
+type goalProjectionInput struct {
+	asOf         time.Time
+	targetDate   time.Time
+	current      float64
+	contribution float64 // added at the end of every month
+	target       float64
+	annualReturn float64
+	annualVol    float64
+	simulations  int
+	seed         uint64
+}
+
+type goalProjectionResult struct {
+	months      int
+	projected   float64 // deterministic
+	median      float64
+	probability float64 // share of simulated paths reaching the target
+	required    *float64
+	series      []models.GoalProjectionPoint
+}
+
+// projectGoal runs the deterministic projection (every month grows at the
+// expected return) and a Monte Carlo simulation with lognormal monthly returns
+// whose mean matches the expected return. The simulation is seeded, so the
+// same input always gives the same result.
+func projectGoal(in goalProjectionInput) goalProjectionResult {
+	monthlyRate := math.Pow(1+in.annualReturn, 1.0/12) - 1
+	sigma := in.annualVol / math.Sqrt(12)
+	mu := math.Log(1+in.annualReturn)/12 - sigma*sigma/2
+
+	rng := rand.New(rand.NewPCG(in.seed, in.seed^0x9e3779b97f4a7c15))
+	paths := make([]float64, in.simulations)
+	for i := range paths {
+		paths[i] = in.current
+	}
+
+	result := goalProjectionResult{series: []models.GoalProjectionPoint{}}
+	deterministic := in.current
+	sorted := make([]float64, len(paths))
+	for month := 1; ; month++ {
+		date := dcaOccurrence("monthly", in.asOf, month)
+		if date.After(in.targetDate) {
+			break
+		}
+		result.months = month
+
+		deterministic = deterministic*(1+monthlyRate) + in.contribution
+		for i := range paths {
+			paths[i] = paths[i]*math.Exp(mu+sigma*rng.NormFloat64()) + in.contribution
+		}
+
+		copy(sorted, paths)
+		sort.Float64s(sorted)
+		result.series = append(result.series, models.GoalProjectionPoint{
+			Date:          date,
+			Deterministic: formatGoalUSD(deterministic),
+			P10:           formatGoalUSD(percentileOf(sorted, 0.10)),
+			P50:           formatGoalUSD(percentileOf(sorted, 0.50)),
+			P90:           formatGoalUSD(percentileOf(sorted, 0.90)),
+		})
+	}
+
+	result.projected = deterministic
+	copy(sorted, paths)
+	sort.Float64s(sorted)
+	result.median = percentileOf(sorted, 0.50)
+	reached := 0
+	for _, v := range paths {
+		if v >= in.target {
+			reached++
+		}
+	}
+	if len(paths) > 0 {
+		result.probability = float64(reached) / float64(len(paths))
+	}
+	result.required = requiredMonthlyContribution(in.current, in.target, monthlyRate, result.months)
+	return result
+}
+
+// requiredMonthlyContribution solves the future value of an annuity for the
+// end-of-month contribution that reaches target in months at monthlyRate. It
+// is zero when the current value already gets there, and nil with no months left.
+func requiredMonthlyContribution(current, target, monthlyRate float64, months int) *float64 {
+	if months <= 0 {
+		return nil
+	}
+	growth := math.Pow(1+monthlyRate, float64(months))
+	gap := target - current*growth
+	required := 0.0
+	if gap > 0 {
+		if monthlyRate == 0 {
+			required = gap / float64(months)
+		} else {
+			required = gap * monthlyRate / (growth - 1)
+		}
+	}
+	return &required
+}
+
+// percentileOf returns the p-th percentile of sorted values, interpolating
+// between neighbouring ranks.
+func percentileOf(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := p * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+}
+
+// estimateGoalAssumptions annualizes the portfolio's daily returns. With fewer
+// than config.MinGoalReturnObservations returns the configured long-run
+// defaults are used instead.
+func estimateGoalAssumptions(dailyReturns []float64) (models.GoalAssumptions, float64, float64) {
+	annualReturn, _ := decimal.RequireFromString(config.DefaultGoalAnnualReturn).Float64()
+	annualVol, _ := decimal.RequireFromString(config.DefaultGoalAnnualVolatility).Float64()
+	assumptions := models.GoalAssumptions{Source: "default", Observations: len(dailyReturns)}
+
+	if len(dailyReturns) >= config.MinGoalReturnObservations {
+		mean := meanOf(dailyReturns)
+		// Bounded so a short lucky (or unlucky) streak cannot dominate decades.
+		annualReturn = math.Max(-0.5, math.Min(1, math.Pow(1+mean, config.TradingDaysPerYear)-1))
+		annualVol = sampleStdDev(dailyReturns, mean) * math.Sqrt(config.TradingDaysPerYear)
+		assumptions.Source = "history"
+	}
+	assumptions.ExpectedAnnualReturnPct = formatRiskPct(annualReturn)
+	assumptions.AnnualVolatilityPct = formatRiskPct(annualVol)
+	return assumptions, annualReturn, annualVol
+}
+
+// dcaMonthlyAmount converts a DCA plan's per-period amount to a monthly amount.
+func dcaMonthlyAmount(amount decimal.Decimal, frequency string) decimal.Decimal {
+	switch frequency {
+	case "weekly":
+		return amount.Mul(decimal.NewFromInt(52)).Div(decimal.NewFromInt(12))
+	case "biweekly":
+		return amount.Mul(decimal.NewFromInt(26)).Div(decimal.NewFromInt(12))
+	default:
+		return amount
+	}
+}
+
+// goalSeed derives a stable simulation seed from the goal ID.
+func goalSeed(goalID string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(goalID))
+	return h.Sum64()
+}
+
+func formatGoalUSD(v float64) string {
+	return decimal.NewFromFloat(v).StringFixed(2)
+}
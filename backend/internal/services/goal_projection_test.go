@@ -0,0 +1,140 @@
+package services
+
+import (
+	"math"
+	"testing"
+)
+
+func TestProjectGoal_DeterministicWithoutVolatility(t *testing.T) {
+	t.Parallel()
+
+	result := projectGoal(goalProjectionInput{
+		asOf:         dcaDay("2024-01-15"),
+		targetDate:   dcaDay("2025-01-15"),
+		current:      10000,
+		contribution: 500,
+		target:       16000,
+		annualReturn: 0,
+		annualVol:    0,
+		simulations:  50,
+		seed:         1,
+	})
+
+	if result.months != 12 || len(result.series) != 12 {
+		t.Fatalf("months = %d (series %d), want 12", result.months, len(result.series))
+	}
+	if result.projected != 16000 || result.median != 16000 {
+		t.Errorf("projected/median = %v/%v, want 16000", result.projected, result.median)
+	}
+	if result.probability != 1 {
+		t.Errorf("probability = %v, want 1", result.probability)
+	}
+	if result.required == nil || *result.required != 500 {
+		t.Errorf("required = %v, want 500", result.required)
+	}
+	last := result.series[11]
+	if last.Date.Format("2006-01-02") != "2025-01-15" || last.Deterministic != "16000.00" || last.P10 != "16000.00" {
+		t.Errorf("last point = %+v", last)
+	}
+}
+
+func TestProjectGoal_SeededSimulation(t *testing.T) {
+	t.Parallel()
+
+	in := goalProjectionInput{
+		asOf:         dcaDay("2024-01-31"),
+		targetDate:   dcaDay("2034-01-31"),
+		current:      20000,
+		contribution: 300,
+		target:       80000,
+		annualReturn: 0.07,
+		annualVol:    0.15,
+		simulations:  1000,
+		seed:         goalSeed("goal-1"),
+	}
+	first := projectGoal(in)
+	second := projectGoal(in)
+
+	if first.probability != second.probability || first.median != second.median {
+		t.Errorf("same seed gave %v/%v and %v/%v", first.probability, first.median, second.probability, second.median)
+	}
+	if first.months != 120 {
+		t.Errorf("months = %d, want 120", first.months)
+	}
+	if first.probability <= 0 || first.probability >= 1 {
+		t.Errorf("probability = %v, want strictly between 0 and 1", first.probability)
+	}
+	// The lognormal median sits below the mean-matching deterministic path.
+	if first.median >= first.projected {
+		t.Errorf("median %v should be below deterministic %v", first.median, first.projected)
+	}
+	for _, p := range first.series {
+		if dec(p.P10).GreaterThan(dec(p.P50)) || dec(p.P50).GreaterThan(dec(p.P90)) {
+			t.Fatalf("P10 above P50 at %s: %+v", p.Date.Format("2006-01-02"), p)
+		}
+	}
+}
+
+func TestRequiredMonthlyContribution(t *testing.T) {
+	t.Parallel()
+
+	rate := math.Pow(1.07, 1.0/12) - 1
+	required := requiredMonthlyContribution(10000, 100000, rate, 120)
+	if required == nil {
+		t.Fatal("required = nil")
+	}
+	// Check it by compounding the contributions forward.
+	value := 10000.0
+	for range 120 {
+		value = value*(1+rate) + *required
+	}
+	if math.Abs(value-100000) > 0.01 {
+		t.Errorf("contributing %v reaches %v, want 100000", *required, value)
+	}
+
+	if got := requiredMonthlyContribution(200000, 100000, rate, 120); got == nil || *got != 0 {
+		t.Errorf("already funded required = %v, want 0", got)
+	}
+	if got := requiredMonthlyContribution(10000, 100000, rate, 0); got != nil {
+		t.Errorf("no months left required = %v, want nil", *got)
+	}
+}
+
+func TestEstimateGoalAssumptions(t *testing.T) {
+	t.Parallel()
+
+	assumptions, annualReturn, annualVol := estimateGoalAssumptions([]float64{0.01, -0.01})
+	if assumptions.Source != "default" || assumptions.Observations != 2 || annualReturn != 0.07 || annualVol != 0.15 {
+		t.Errorf("short history = %+v (%v, %v), want defaults", assumptions, annualReturn, annualVol)
+	}
+
+	returns := make([]float64, 252)
+	for i := range returns {
+		returns[i] = 0.0004
+		if i%2 == 1 {
+			returns[i] = 0.0002
+		}
+	}
+	assumptions, annualReturn, _ = estimateGoalAssumptions(returns)
+	if assumptions.Source != "history" || assumptions.ExpectedAnnualReturnPct != "7.85" {
+		t.Errorf("history = %+v (%v)", assumptions, annualReturn)
+	}
+
+	for i := range returns {
+		returns[i] = 0.01
+	}
+	if _, annualReturn, _ = estimateGoalAssumptions(returns); annualReturn != 1 {
+		t.Errorf("annual return = %v, want clamped to 1", annualReturn)
+	}
+}
+
+func TestDCAMonthlyAmount(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{"weekly": "520", "biweekly": "260", "monthly": "120"}
+	for frequency, want := range tests {
+		if got := dcaMonthlyAmount(dec("120"), frequency); !got.Equal(dec(want)) {
+			t.Errorf("%s monthly amount = %s, want %s", frequency, got, want)
+		}
+	}
+}
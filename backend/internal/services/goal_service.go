@@ -0,0 +1,409 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrGoalNotFound       = apperror.New(apperror.NotFound, "goal not found")
+	ErrInvalidGoal        = apperror.New(apperror.Validation, "invalid goal")
+	ErrGoalNameTaken      = apperror.New(apperror.Conflict, "a goal with that name already exists")
+	ErrGoalFxRateRequired = apperror.New(apperror.Conflict, "a COP/USD rate is required to project COP amounts; record an FX rate first")
+)
+
+// goalNameMaxLength caps goal names.
+const goalNameMaxLength = 100
+
+const goalColumns = `id, portfolio_id, name, target_amount::text AS target_amount, currency, target_date,
+	monthly_contribution::text AS monthly_contribution, created_at, updated_at`
+
+// GoalService manages investment goals and projects whether they will be met.
+type GoalService struct {
+	pool       *pgxpool.Pool
+	portfolios *PortfolioService
+}
+
+// NewGoalService creates a GoalService backed by the given DB pool.
+func NewGoalService(pool *pgxpool.Pool) *GoalService {
+	return &GoalService{pool: pool, portfolios: NewPortfolioService(pool)}
+}
+
+// ListGoals returns the user's goals, nearest target date first.
+func (s *GoalService) ListGoals(ctx context.Context, userID string) ([]models.Goal, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+goalColumns+`
+		FROM goals
+		WHERE user_id = $1
+		ORDER BY target_date, name
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying goals: %w", err)
+	}
+	goals, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.Goal])
+	if err != nil {
+		return nil, fmt.Errorf("collecting goals: %w", err)
+	}
+	return goals, nil
+}
+
+// GetGoal returns one of the user's goals.
+func (s *GoalService) GetGoal(ctx context.Context, userID, goalID string) (*models.Goal, error) {
+	if _, err := uuid.Parse(goalID); err != nil {
+		return nil, ErrGoalNotFound
+	}
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+goalColumns+`
+		FROM goals
+		WHERE id = $1 AND user_id = $2
+	`, goalID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying goal: %w", err)
+	}
+	goal, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Goal])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrGoalNotFound
+		}
+		return nil, fmt.Errorf("collecting goal: %w", err)
+	}
+	return &goal, nil
+}
+
+// CreateGoal saves a goal. Without portfolio_id it tracks the consolidated net worth.
+func (s *GoalService) CreateGoal(ctx context.Context, userID string, req models.CreateGoalRequest) (*models.Goal, error) {
+	goal := models.Goal{Currency: strings.ToUpper(strings.TrimSpace(req.Currency))}
+	if goal.Currency == "" {
+		goal.Currency = config.LocalCurrency
+	}
+	if goal.Currency != config.LocalCurrency && goal.Currency != config.BaseCurrency {
+		return nil, apperror.Detailf(ErrInvalidGoal, "currency must be %s or %s", config.LocalCurrency, config.BaseCurrency)
+	}
+	if err := applyGoalFields(&goal, &req.Name, &req.TargetAmount, &req.TargetDate, req.MonthlyContribution); err != nil {
+		return nil, err
+	}
+	if req.PortfolioID != nil && strings.TrimSpace(*req.PortfolioID) != "" {
+		portfolio, err := s.portfolios.GetPortfolio(ctx, userID, strings.TrimSpace(*req.PortfolioID))
+		if err != nil {
+			return nil, err
+		}
+		goal.PortfolioID = &portfolio.ID
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		INSERT INTO goals (user_id, portfolio_id, name, target_amount, currency, target_date, monthly_contribution)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+goalColumns,
+		userID, goal.PortfolioID, goal.Name, goal.TargetAmount, goal.Currency, goal.TargetDate, goal.MonthlyContribution)
+	if err != nil {
+		return nil, fmt.Errorf("creating goal: %w", err)
+	}
+	created, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Goal])
+	if err != nil {
+		return nil, mapGoalWriteError(err)
+	}
+	return &created, nil
+}
+
+// UpdateGoal changes a goal's name, target or monthly contribution.
+func (s *GoalService) UpdateGoal(ctx context.Context, userID, goalID string, req models.UpdateGoalRequest) (*models.Goal, error) {
+	goal, err := s.GetGoal(ctx, userID, goalID)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyGoalFields(goal, req.Name, req.TargetAmount, req.TargetDate, req.MonthlyContribution); err != nil {
+		return nil, err
+	}
+	if req.MonthlyContribution != nil && strings.TrimSpace(*req.MonthlyContribution) == "" {
+		goal.MonthlyContribution = nil
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		UPDATE goals SET name = $3, target_amount = $4, target_date = $5, monthly_contribution = $6
+		WHERE id = $1 AND user_id = $2
+		RETURNING `+goalColumns,
+		goalID, userID, goal.Name, goal.TargetAmount, goal.TargetDate, goal.MonthlyContribution)
+	if err != nil {
+		return nil, fmt.Errorf("updating goal: %w", err)
+	}
+	updated, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Goal])
+	if err != nil {
+		return nil, mapGoalWriteError(err)
+	}
+	return &updated, nil
+}
+
+// DeleteGoal removes one of the user's goals.
+func (s *GoalService) DeleteGoal(ctx context.Context, userID, goalID string) error {
+	if _, err := uuid.Parse(goalID); err != nil {
+		return ErrGoalNotFound
+	}
+	tag, err := s.pool.Exec(ctx, `DELETE FROM goals WHERE id = $1 AND user_id = $2`, goalID, userID)
+	if err != nil {
+		return fmt.Errorf("deleting goal: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrGoalNotFound
+	}
+	return nil
+}
+
+// ProjectGoal projects the tracked net worth to the goal's target date. It
+// starts from the current NetWorthSummary, uses the portfolio's historical
+// daily returns (or long-run defaults when there is too little history) and
+// adds the goal's monthly contribution, or else the monthly equivalent of the
+// active DCA plans of the tracked portfolio. Everything runs on stored data.
+func (s *GoalService) ProjectGoal(ctx context.Context, userID, goalID string, simulations int) (*models.GoalProjection, error) {
+	if simulations <= 0 || simulations > config.MaxGoalSimulations {
+		return nil, apperror.Detailf(ErrInvalidGoal, "simulations must be between 1 and %d", config.MaxGoalSimulations)
+	}
+	goal, err := s.GetGoal(ctx, userID, goalID)
+	if err != nil {
+		return nil, err
+	}
+	portfolioID := ""
+	if goal.PortfolioID != nil {
+		portfolioID = *goal.PortfolioID
+	}
+	asOf := dcaDate(time.Now())
+
+	analytics := NewAnalyticsService(s.pool).ForPortfolio(portfolioID)
+	summary, err := analytics.GetNetWorthSummary(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := decimal.NewFromString(summary.NetWorth)
+	if err != nil {
+		return nil, fmt.Errorf("parsing net worth: %w", err)
+	}
+	returns, err := analytics.dailyPortfolioReturns(ctx, userID, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	projection := &models.GoalProjection{
+		GoalID:          goal.ID,
+		AsOf:            asOf,
+		TargetDate:      goal.TargetDate,
+		Currency:        goal.Currency,
+		TargetAmount:    goal.TargetAmount,
+		CurrentValueUSD: current.StringFixed(2),
+		Simulations:     simulations,
+	}
+
+	// The latest recorded rate converts COP targets and contributions.
+	var fxRate *decimal.Decimal
+	rate, ok, err := NewPostgresMarketDataStore(s.pool).GetLatestFxRate(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if parsed, err := decimal.NewFromString(rate.Rate); err == nil && parsed.IsPositive() {
+			fxRate = &parsed
+			value := parsed.String()
+			projection.FxRate = &value
+		}
+	}
+	toUSD := func(amount decimal.Decimal, currency string) (decimal.Decimal, error) {
+		if currency != config.LocalCurrency {
+			return amount, nil
+		}
+		if fxRate == nil {
+			return decimal.Zero, ErrGoalFxRateRequired
+		}
+		return amount.Div(*fxRate), nil
+	}
+
+	target, err := toUSD(decimal.RequireFromString(goal.TargetAmount), goal.Currency)
+	if err != nil {
+		return nil, err
+	}
+	projection.TargetAmountUSD = target.StringFixed(2)
+
+	contribution := decimal.Zero
+	projection.ContributionSource = "none"
+	if goal.MonthlyContribution != nil {
+		projection.ContributionSource = "goal"
+		if contribution, err = toUSD(decimal.RequireFromString(*goal.MonthlyContribution), goal.Currency); err != nil {
+			return nil, err
+		}
+	} else {
+		plans, err := s.activeDCAContributions(ctx, userID, portfolioID, asOf)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range plans {
+			usd, err := toUSD(dcaMonthlyAmount(p.amount, p.frequency), p.currency)
+			if err != nil {
+				return nil, err
+			}
+			contribution = contribution.Add(usd)
+			projection.ContributionSource = "dca_plans"
+		}
+	}
+	projection.MonthlyContributionUSD = contribution.StringFixed(2)
+
+	assumptions, annualReturn, annualVol := estimateGoalAssumptions(returns)
+	projection.Assumptions = assumptions
+
+	currentF, _ := current.Float64()
+	contributionF, _ := contribution.Float64()
+	targetF, _ := target.Float64()
+	result := projectGoal(goalProjectionInput{
+		asOf:         asOf,
+		targetDate:   goal.TargetDate,
+		current:      currentF,
+		contribution: contributionF,
+		target:       targetF,
+		annualReturn: annualReturn,
+		annualVol:    annualVol,
+		simulations:  simulations,
+		seed:         goalSeed(goal.ID),
+	})
+
+	projection.Months = result.months
+	projection.ProjectedValueUSD = formatGoalUSD(result.projected)
+	projection.MedianValueUSD = formatGoalUSD(result.median)
+	projection.OnTrack = result.projected >= targetF
+	projection.OnTrackProbabilityPct = formatRiskPct(result.probability)
+	projection.Series = result.series
+	if result.required != nil {
+		requiredUSD := decimal.NewFromFloat(*result.required)
+		usd := requiredUSD.StringFixed(2)
+		projection.RequiredMonthlyContributionUSD = &usd
+		inCurrency := usd
+		if goal.Currency == config.LocalCurrency {
+			inCurrency = requiredUSD.Mul(*fxRate).StringFixed(2)
+		}
+		projection.RequiredMonthlyContribution = &inCurrency
+	}
+	return projection, nil
+}
+
+type dcaContribution struct {
+	amount    decimal.Decimal
+	currency  string
+	frequency string
+}
+
+// activeDCAContributions lists the active, unfinished DCA plans of the
+// portfolio (all portfolios when portfolioID is empty).
+func (s *GoalService) activeDCAContributions(ctx context.Context, userID, portfolioID string, asOf time.Time) ([]dcaContribution, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT amount::text, currency, frequency
+		FROM dca_plans
+		WHERE user_id = $1 AND active AND (end_date IS NULL OR end_date >= $3)
+		  AND `+portfolioScopeSQL("portfolio_id", 2),
+		userID, portfolioScopeArg(portfolioID), asOf)
+	if err != nil {
+		return nil, fmt.Errorf("querying DCA plans: %w", err)
+	}
+	defer rows.Close()
+
+	var plans []dcaContribution
+	for rows.Next() {
+		var amount string
+		var p dcaContribution
+		if err := rows.Scan(&amount, &p.currency, &p.frequency); err != nil {
+			return nil, fmt.Errorf("scanning DCA plan: %w", err)
+		}
+		if p.amount, err = decimal.NewFromString(amount); err != nil {
+			return nil, fmt.Errorf("parsing DCA plan amount: %w", err)
+		}
+		plans = append(plans, p)
+	}
+	return plans, rows.Err()
+}
+
+// dailyPortfolioReturns returns the flow-adjusted daily returns of the
+// portfolio from its daily valuations, as used by the risk metrics.
+func (s *AnalyticsService) dailyPortfolioReturns(ctx context.Context, userID string, asOf time.Time) ([]float64, error) {
+	activity, err := s.loadPerformanceActivity(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	eventDates := activity.collectEventDates()
+	if len(eventDates) == 0 {
+		return nil, nil
+	}
+	tickers := make([]string, 0)
+	for _, tr := range activity.Trades {
+		tickers = appendUniqueTicker(tickers, tr.Ticker)
+	}
+	closes, err := NewPostgresMarketDataStore(s.pool).GetDailyPrices(ctx, tickers, eventDates[0])
+	if err != nil {
+		return nil, err
+	}
+	valuations, _ := buildDailyValuations(activity, closes, asOf)
+	dated := valuationReturns(valuations)
+	returns := make([]float64, len(dated))
+	for i, r := range dated {
+		returns[i] = r.r
+	}
+	return returns, nil
+}
+
+// applyGoalFields validates and applies the editable fields that are set.
+func applyGoalFields(goal *models.Goal, name, targetAmount, targetDate, monthlyContribution *string) error {
+	if name != nil {
+		trimmed := strings.TrimSpace(*name)
+		if trimmed == "" || len(trimmed) > goalNameMaxLength {
+			return apperror.Detailf(ErrInvalidGoal, "name is required (at most %d characters)", goalNameMaxLength)
+		}
+		goal.Name = trimmed
+	}
+	if targetAmount != nil {
+		amount, err := decimal.NewFromString(strings.TrimSpace(*targetAmount))
+		if err != nil || !amount.IsPositive() {
+			return fmt.Errorf("%w: target_amount must be a positive number", ErrInvalidGoal)
+		}
+		goal.TargetAmount = amount.StringFixed(2)
+	}
+	if targetDate != nil {
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(*targetDate))
+		if err != nil {
+			return fmt.Errorf("%w: target_date must be YYYY-MM-DD", ErrInvalidGoal)
+		}
+		today := dcaDate(time.Now())
+		if !date.After(today) {
+			return fmt.Errorf("%w: target_date must be in the future", ErrInvalidGoal)
+		}
+		if date.After(today.AddDate(config.MaxGoalHorizonYears, 0, 0)) {
+			return apperror.Detailf(ErrInvalidGoal, "target_date must be within %d years", config.MaxGoalHorizonYears)
+		}
+		goal.TargetDate = date
+	}
+	if monthlyContribution != nil && strings.TrimSpace(*monthlyContribution) != "" {
+		amount, err := decimal.NewFromString(strings.TrimSpace(*monthlyContribution))
+		if err != nil || amount.IsNegative() {
+			return fmt.Errorf("%w: monthly_contribution must be zero or positive", ErrInvalidGoal)
+		}
+		value := amount.StringFixed(2)
+		goal.MonthlyContribution = &value
+	}
+	return nil
+}
+
+func mapGoalWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrGoalNameTaken
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrGoalNotFound
+	}
+	return fmt.Errorf("writing goal: %w", err)
+}
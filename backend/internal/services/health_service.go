@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Readiness check names and statuses reported by /readyz.
+const (
+	ReadinessDatabase   = "database"
+	ReadinessMigrations = "migrations"
+	ReadinessMarketData = "market_data"
+
+	ReadinessOK          = "ok"
+	ReadinessFailed      = "failed"
+	ReadinessDisabled    = "disabled"
+	ReadinessUnavailable = "unavailable"
+)
+
+// HealthStore is the database access behind readiness checks.
+type HealthStore interface {
+	Ping(ctx context.Context) error
+	// MigrationVersion returns the applied schema version and whether the
+	// last migration failed part way. It is 0 before any migration.
+	MigrationVersion(ctx context.Context) (version uint, dirty bool, err error)
+}
+
+type postgresHealthStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresHealthStore creates a store that checks the given DB pool and
+// the golang-migrate schema_migrations table.
+func NewPostgresHealthStore(pool *pgxpool.Pool) HealthStore {
+	return &postgresHealthStore{pool: pool}
+}
+
+func (s *postgresHealthStore) Ping(ctx context.Context) error {
+	if s.pool == nil {
+		return errors.New("database pool is not initialized")
+	}
+	return s.pool.Ping(ctx)
+}
+
+func (s *postgresHealthStore) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	err := s.pool.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return uint(version), dirty, nil
+}
+
+// HealthService reports whether the API can serve traffic.
+type HealthService struct {
+	store             HealthStore
+	migrationVersion  uint
+	marketDataEnabled bool
+}
+
+// NewHealthService creates a HealthService. migrationVersion is the latest
+// version shipped with this build; marketDataEnabled reports whether a
+// market data API key is configured.
+func NewHealthService(store HealthStore, migrationVersion uint, marketDataEnabled bool) *HealthService {
+	return &HealthService{
+		store:             store,
+		migrationVersion:  migrationVersion,
+		marketDataEnabled: marketDataEnabled,
+	}
+}
+
+// Readiness checks the database connection and schema version. The market
+// data provider is optional: without it the API still serves stored data,
+// so a missing key is reported as disabled rather than failing readiness.
+// Failure details are logged rather than returned, since the probe is
+// unauthenticated.
+func (s *HealthService) Readiness(ctx context.Context) models.Readiness {
+	ctx, cancel := context.WithTimeout(ctx, config.ReadinessCheckTimeout)
+	defer cancel()
+
+	checks := map[string]models.ReadinessCheck{
+		ReadinessDatabase:   s.checkDatabase(ctx),
+		ReadinessMigrations: s.checkMigrations(ctx),
+		ReadinessMarketData: s.checkMarketData(),
+	}
+	status := ReadinessOK
+	for _, check := range checks {
+		if check.Status == ReadinessFailed {
+			status = ReadinessUnavailable
+		}
+	}
+	return models.Readiness{Status: status, Checks: checks}
+}
+
+func (s *HealthService) checkDatabase(ctx context.Context) models.ReadinessCheck {
+	if err := s.store.Ping(ctx); err != nil {
+		slog.WarnContext(ctx, "readiness: database ping failed", "error", err)
+		return models.ReadinessCheck{Status: ReadinessFailed, Detail: "database is unreachable"}
+	}
+	return models.ReadinessCheck{Status: ReadinessOK}
+}
+
+func (s *HealthService) checkMigrations(ctx context.Context) models.ReadinessCheck {
+	version, dirty, err := s.store.MigrationVersion(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "readiness: reading migration version failed", "error", err)
+		return models.ReadinessCheck{Status: ReadinessFailed, Detail: "migration version is unavailable"}
+	}
+	if dirty {
+		return models.ReadinessCheck{Status: ReadinessFailed, Detail: fmt.Sprintf("migration %d is dirty", version)}
+	}
+	// A newer replica may already have migrated further during a rolling
+	// deploy, so only an older schema fails.
+	if version < s.migrationVersion {
+		return models.ReadinessCheck{
+			Status: ReadinessFailed,
+			Detail: fmt.Sprintf("schema is at version %d, want %d", version, s.migrationVersion),
+		}
+	}
+	return models.ReadinessCheck{Status: ReadinessOK, Detail: fmt.Sprintf("version %d", version)}
+}
+
+func (s *HealthService) checkMarketData() models.ReadinessCheck {
+	if !s.marketDataEnabled {
+		return models.ReadinessCheck{Status: ReadinessDisabled, Detail: "TWELVE_DATA_API_KEY is not set"}
+	}
+	return models.ReadinessCheck{Status: ReadinessOK}
+}
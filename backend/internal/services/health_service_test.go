@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakeHealthStore struct {
+	pingErr    error
+	version    uint
+	dirty      bool
+	versionErr error
+}
+
+func (f fakeHealthStore) Ping(context.Context) error {
+	return f.pingErr
+}
+
+func (f fakeHealthStore) MigrationVersion(context.Context) (uint, bool, error) {
+	return f.version, f.dirty, f.versionErr
+}
+
+func TestHealthService_Readiness(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		store      fakeHealthStore
+		marketData bool
+		status     string
+		checks     map[string]string
+	}{
+		{
+			name:       "ready",
+			store:      fakeHealthStore{version: 16},
+			marketData: true,
+			status:     ReadinessOK,
+			checks: map[string]string{
+				ReadinessDatabase:   ReadinessOK,
+				ReadinessMigrations: ReadinessOK,
+				ReadinessMarketData: ReadinessOK,
+			},
+		},
+		{
+			name:   "market data disabled is still ready",
+			store:  fakeHealthStore{version: 16},
+			status: ReadinessOK,
+			checks: map[string]string{ReadinessMarketData: ReadinessDisabled},
+		},
+		{
+			name:       "newer schema is ready",
+			store:      fakeHealthStore{version: 17},
+			marketData: true,
+			status:     ReadinessOK,
+			checks:     map[string]string{ReadinessMigrations: ReadinessOK},
+		},
+		{
+			name:       "database down",
+			store:      fakeHealthStore{pingErr: errors.New("connection refused"), versionErr: errors.New("connection refused")},
+			marketData: true,
+			status:     ReadinessUnavailable,
+			checks: map[string]string{
+				ReadinessDatabase:   ReadinessFailed,
+				ReadinessMigrations: ReadinessFailed,
+			},
+		},
+		{
+			name:       "schema behind",
+			store:      fakeHealthStore{version: 15},
+			marketData: true,
+			status:     ReadinessUnavailable,
+			checks:     map[string]string{ReadinessMigrations: ReadinessFailed},
+		},
+		{
+			name:       "dirty migration",
+			store:      fakeHealthStore{version: 16, dirty: true},
+			marketData: true,
+			status:     ReadinessUnavailable,
+			checks:     map[string]string{ReadinessMigrations: ReadinessFailed},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := NewHealthService(tc.store, 16, tc.marketData).Readiness(context.Background())
+			if got.Status != tc.status {
+				t.Errorf("status = %q, want %q", got.Status, tc.status)
+			}
+			for name, want := range tc.checks {
+				if got.Checks[name].Status != want {
+					t.Errorf("%s = %+v, want %q", name, got.Checks[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestHealthService_ReadinessHidesErrors(t *testing.T) {
+	t.Parallel()
+
+	store := fakeHealthStore{pingErr: errors.New(`password authentication failed for user "postgres"`)}
+	got := NewHealthService(store, 1, true).Readiness(context.Background())
+	if detail := got.Checks[ReadinessDatabase].Detail; detail != "database is unreachable" {
+		t.Errorf("detail = %q, want a generic message", detail)
+	}
+}
@@ -19,6 +19,7 @@ type holdingTradeRow struct {
 	Quantity  decimal.Decimal
 	Price     decimal.Decimal
 	TotalFees decimal.Decimal
+	BrokerID  *string
 }
 
 type holdingPosition struct {
@@ -90,11 +91,11 @@ func (s *AnalyticsService) GetCurrentHoldingsByMarketValue(ctx context.Context,
 
 func (s *AnalyticsService) loadHoldingTrades(ctx context.Context, userID string) ([]holdingTradeRow, error) {
 	rows, err := s.pool.Query(ctx, `
-		SELECT date, created_at, ticker, asset_type, side, quantity, price, COALESCE(total_fees, 0)
+		SELECT date, created_at, ticker, asset_type, side, quantity, price, COALESCE(total_fees, 0), broker_id
 		FROM trades
-		WHERE user_id = $1
+		WHERE user_id = $1 AND `+portfolioScopeSQL("portfolio_id", 2)+`
 		ORDER BY date ASC, created_at ASC
-	`, userID)
+	`, userID, s.portfolioArg())
 	if err != nil {
 		return nil, fmt.Errorf("load holding trades: %w", err)
 	}
@@ -113,6 +114,7 @@ func (s *AnalyticsService) loadHoldingTrades(ctx context.Context, userID string)
 			&qtyStr,
 			&priceStr,
 			&feesStr,
+			&tr.BrokerID,
 		); err != nil {
 			return nil, fmt.Errorf("scan holding trade: %w", err)
 		}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Idempotency record states.
+const (
+	IdempotencyStateInProgress = "in_progress"
+	IdempotencyStateCompleted  = "completed"
+)
+
+// IdempotencyRecord is the stored outcome of the first request sent with a
+// given Idempotency-Key.
+type IdempotencyRecord struct {
+	UserID        string
+	Key           string
+	RequestMethod string
+	RequestPath   string
+	RequestHash   string
+	State         string
+	StatusCode    int
+	ContentType   string
+	ResponseBody  []byte
+	ExpiresAt     time.Time
+}
+
+// IdempotencyStore persists Idempotency-Key reservations and their responses.
+type IdempotencyStore interface {
+	// Reserve claims key for userID. When the key is unused (or its previous
+	// record expired) it stores rec as in-progress until rec.ExpiresAt and
+	// returns created=true. Otherwise it returns the existing record and
+	// created=false.
+	Reserve(ctx context.Context, rec IdempotencyRecord) (existing IdempotencyRecord, created bool, err error)
+	// Complete stores the response for a reserved key so retries can replay it
+	// until expiresAt.
+	Complete(ctx context.Context, userID, key string, statusCode int, contentType string, body []byte, expiresAt time.Time) error
+	// Release drops a reservation so the request can be retried from scratch.
+	Release(ctx context.Context, userID, key string) error
+}
+
+// postgresIdempotencyStore implements IdempotencyStore on top of pgxpool.Pool.
+type postgresIdempotencyStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresIdempotencyStore creates a store backed by the idempotency_keys table.
+func NewPostgresIdempotencyStore(pool *pgxpool.Pool) IdempotencyStore {
+	return &postgresIdempotencyStore{pool: pool}
+}
+
+func (s *postgresIdempotencyStore) Reserve(ctx context.Context, rec IdempotencyRecord) (IdempotencyRecord, bool, error) {
+	if s.pool == nil {
+		return IdempotencyRecord{}, false, fmt.Errorf("database pool is not initialized")
+	}
+
+	// Expired keys are dropped lazily so a key can be reused after its TTL, and
+	// an in-progress reservation whose request died can be retried once its
+	// lease runs out.
+	if _, err := s.pool.Exec(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE user_id = $1 AND expires_at < NOW()
+	`, rec.UserID); err != nil {
+		return IdempotencyRecord{}, false, fmt.Errorf("purge expired idempotency keys: %w", err)
+	}
+
+	tag, err := s.pool.Exec(ctx, `
+		INSERT INTO idempotency_keys (
+			user_id, idempotency_key, request_method, request_path, request_hash, state, expires_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id, idempotency_key) DO NOTHING
+	`, rec.UserID, rec.Key, rec.RequestMethod, rec.RequestPath, rec.RequestHash, IdempotencyStateInProgress, rec.ExpiresAt)
+	if err != nil {
+		return IdempotencyRecord{}, false, fmt.Errorf("reserve idempotency key: %w", err)
+	}
+	if tag.RowsAffected() == 1 {
+		rec.State = IdempotencyStateInProgress
+		return rec, true, nil
+	}
+
+	existing, err := s.get(ctx, rec.UserID, rec.Key)
+	if err != nil {
+		return IdempotencyRecord{}, false, err
+	}
+	return existing, false, nil
+}
+
+func (s *postgresIdempotencyStore) get(ctx context.Context, userID, key string) (IdempotencyRecord, error) {
+	var rec IdempotencyRecord
+	var statusCode *int
+	var contentType *string
+	err := s.pool.QueryRow(ctx, `
+		SELECT user_id, idempotency_key, request_method, request_path, request_hash, state,
+		       status_code, content_type, response_body, expires_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2
+	`, userID, key).Scan(
+		&rec.UserID, &rec.Key, &rec.RequestMethod, &rec.RequestPath, &rec.RequestHash, &rec.State,
+		&statusCode, &contentType, &rec.ResponseBody, &rec.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return IdempotencyRecord{}, fmt.Errorf("idempotency key %q disappeared during reservation", key)
+		}
+		return IdempotencyRecord{}, fmt.Errorf("get idempotency key: %w", err)
+	}
+	if statusCode != nil {
+		rec.StatusCode = *statusCode
+	}
+	if contentType != nil {
+		rec.ContentType = *contentType
+	}
+	return rec, nil
+}
+
+func (s *postgresIdempotencyStore) Complete(ctx context.Context, userID, key string, statusCode int, contentType string, body []byte, expiresAt time.Time) error {
+	if s.pool == nil {
+		return fmt.Errorf("database pool is not initialized")
+	}
+
+	_, err := s.pool.Exec(ctx, `
+		UPDATE idempotency_keys
+		SET state = $3, status_code = $4, content_type = $5, response_body = $6,
+		    completed_at = NOW(), expires_at = $7
+		WHERE user_id = $1 AND idempotency_key = $2
+	`, userID, key, IdempotencyStateCompleted, statusCode, contentType, body, expiresAt)
+	if err != nil {
+		return fmt.Errorf("complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (s *postgresIdempotencyStore) Release(ctx context.Context, userID, key string) error {
+	if s.pool == nil {
+		return fmt.Errorf("database pool is not initialized")
+	}
+
+	_, err := s.pool.Exec(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2 AND state = $3
+	`, userID, key, IdempotencyStateInProgress)
+	if err != nil {
+		return fmt.Errorf("release idempotency key: %w", err)
+	}
+	return nil
+}
@@ -23,10 +23,7 @@ func initSvcTestDB(t *testing.T) {
 		if testURL == "" {
 			return
 		}
-		if err := os.Setenv("DATABASE_URL", testURL); err != nil {
-			t.Fatalf("set DATABASE_URL: %v", err)
-		}
-		if err := database.Connect(); err != nil {
+		if err := database.Connect(testURL); err != nil {
 			t.Fatalf("connect to TEST_DATABASE_URL: %v", err)
 		}
 	})
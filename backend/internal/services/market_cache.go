@@ -7,6 +7,12 @@ import (
 	"fintu-tracking-backend/internal/config"
 )
 
+// Cache names used in fintu_cache_lookups_total.
+const (
+	marketPricesCache = "market_prices"
+	fxRatesCache      = "fx_rates"
+)
+
 // cacheFreshness determines whether a cached value is still valid.
 func isFresh(cachedAt time.Time, ttl time.Duration) bool {
 	return time.Since(cachedAt) <= ttl
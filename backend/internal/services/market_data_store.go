@@ -24,6 +24,9 @@ type MarketDataStore interface {
 	GetMarketPrices(ctx context.Context, tickers []string) ([]models.MarketPrice, error)
 	UpsertMarketPrice(ctx context.Context, ticker, price, currency string) error
 
+	UpsertDailyPrices(ctx context.Context, prices []models.DailyPrice) error
+	GetDailyPrices(ctx context.Context, tickers []string, since time.Time) ([]models.DailyPrice, error)
+
 	RecordMarketPriceRefresh(ctx context.Context, userID string) error
 	GetLastMarketPriceRefresh(ctx context.Context, userID string) (time.Time, bool, error)
 }
@@ -197,6 +200,60 @@ func (s *postgresMarketDataStore) UpsertMarketPrice(ctx context.Context, ticker,
 	return err
 }
 
+func (s *postgresMarketDataStore) UpsertDailyPrices(ctx context.Context, prices []models.DailyPrice) error {
+	if s.pool == nil {
+		return fmt.Errorf("database pool is not initialized")
+	}
+	if len(prices) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, p := range prices {
+		batch.Queue(`
+			INSERT INTO daily_prices (ticker, price_date, close, currency, updated_at)
+			VALUES ($1, $2, $3, $4, NOW())
+			ON CONFLICT (ticker, price_date) DO UPDATE
+			SET close = EXCLUDED.close, currency = EXCLUDED.currency, updated_at = NOW()
+		`, p.Ticker, p.Date, p.Close, p.Currency)
+	}
+	if err := s.pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("upsert daily prices: %w", err)
+	}
+	return nil
+}
+
+// GetDailyPrices returns closes for tickers on or after since, oldest first.
+func (s *postgresMarketDataStore) GetDailyPrices(ctx context.Context, tickers []string, since time.Time) ([]models.DailyPrice, error) {
+	if s.pool == nil || len(tickers) == 0 {
+		return []models.DailyPrice{}, nil
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT ticker, price_date, close::text, currency
+		FROM daily_prices
+		WHERE ticker = ANY($1) AND price_date >= $2
+		ORDER BY price_date, ticker
+	`, tickers, since)
+	if err != nil {
+		return nil, fmt.Errorf("get daily prices: %w", err)
+	}
+	defer rows.Close()
+
+	prices := make([]models.DailyPrice, 0)
+	for rows.Next() {
+		var p models.DailyPrice
+		if err := rows.Scan(&p.Ticker, &p.Date, &p.Close, &p.Currency); err != nil {
+			return nil, fmt.Errorf("scan daily price: %w", err)
+		}
+		prices = append(prices, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate daily prices: %w", err)
+	}
+	return prices, nil
+}
+
 func (s *postgresMarketDataStore) RecordMarketPriceRefresh(ctx context.Context, userID string) error {
 	if s.pool == nil {
 		return fmt.Errorf("database pool is not initialized")
@@ -7,11 +7,15 @@ import (
 
 	"fintu-tracking-backend/internal/config"
 	"fintu-tracking-backend/internal/models"
+	"fintu-tracking-backend/internal/telemetry"
 	"github.com/shopspring/decimal"
 )
 
 // GetNetWorthSummary provides complete financial position
 func (s *AnalyticsService) GetNetWorthSummary(ctx context.Context, userID string) (models.NetWorthSummary, error) {
+	ctx, span := telemetry.StartSpan(ctx, "AnalyticsService.GetNetWorthSummary")
+	defer span.End()
+
 	summary := models.NetWorthSummary{
 		HoldingsValue:     "0",
 		CashBalance:       "0",
@@ -27,6 +31,7 @@ func (s *AnalyticsService) GetNetWorthSummary(ctx context.Context, userID string
 			ByAssetType: make(map[string]string),
 			ByTicker:    make(map[string]string),
 			TopHoldings: []models.Holding{},
+			ByBroker:    []models.BrokerNetWorth{},
 		},
 	}
 
@@ -56,13 +61,13 @@ func (s *AnalyticsService) GetNetWorthSummary(ctx context.Context, userID string
 	summary.HoldingsValue = totalHoldingsValue.String()
 
 	var cashFlowsBalance string
-	err = s.pool.QueryRow(ctx, cashFlowsBalanceSQL(), userID).Scan(&cashFlowsBalance)
+	err = s.pool.QueryRow(ctx, cashFlowsBalanceSQL(), userID, s.portfolioArg()).Scan(&cashFlowsBalance)
 	if err != nil {
 		cashFlowsBalance = "0"
 	}
 
 	var tradeCosts string
-	if err := s.pool.QueryRow(ctx, netTradeCashFlowSQL(), userID).Scan(&tradeCosts); err != nil {
+	if err := s.pool.QueryRow(ctx, netTradeCashFlowSQL(), userID, s.portfolioArg()).Scan(&tradeCosts); err != nil {
 		tradeCosts = "0"
 	}
 
@@ -75,27 +80,23 @@ func (s *AnalyticsService) GetNetWorthSummary(ctx context.Context, userID string
 	summary.NetWorth = netWorth.String()
 
 	var totalInvested string
-	s.pool.QueryRow(ctx, netInvestedSQL(), userID).Scan(&totalInvested)
+	s.pool.QueryRow(ctx, netInvestedSQL(), userID, s.portfolioArg()).Scan(&totalInvested)
 	summary.TotalInvested = totalInvested
 
 	var transferFees string
 	s.pool.QueryRow(ctx, `
-		SELECT COALESCE(SUM(usd_amount), 0)
+		SELECT COALESCE(SUM(`+transferFeesCaseExpr+`), 0)
 		FROM cash_flows
 		WHERE user_id = $1
-		  AND type = 'fee'
-		  AND (
-		    related_cash_flow_id IS NOT NULL
-		    OR fee_type IN ('deposit', 'withdrawal')
-		  )
-	`, userID).Scan(&transferFees)
+		  AND `+portfolioScopeSQL("portfolio_id", 2)+`
+	`, userID, s.portfolioArg()).Scan(&transferFees)
 
 	var tradeFees string
 	s.pool.QueryRow(ctx, `
 		SELECT COALESCE(SUM(total_fees), 0)
 		FROM trades
-		WHERE user_id = $1
-	`, userID).Scan(&tradeFees)
+		WHERE user_id = $1 AND `+portfolioScopeSQL("portfolio_id", 2)+`
+	`, userID, s.portfolioArg()).Scan(&tradeFees)
 
 	transferFeesDec, _ := decimal.NewFromString(transferFees)
 	tradeFeesDec, _ := decimal.NewFromString(tradeFees)
@@ -110,17 +111,22 @@ func (s *AnalyticsService) GetNetWorthSummary(ctx context.Context, userID string
 		summary.TotalGainLossPct = gainLossPct.String()
 	}
 
-	xirrRate, err := calculateXIRR(ctx, s.pool, userID, netWorth, time.Now())
+	xirrRate, err := calculateXIRR(ctx, s.pool, userID, s.portfolioID, netWorth, time.Now())
 	if err == nil && !xirrRate.IsZero() {
 		summary.XIRR = xirrRate.Mul(decimal.NewFromInt(100)).StringFixed(2)
 	}
 
+	summary.Breakdown.ByBroker, err = s.GetBrokerBreakdown(ctx, userID)
+	if err != nil {
+		return summary, fmt.Errorf("failed to build broker breakdown: %w", err)
+	}
+
 	if err := s.pool.QueryRow(ctx, fmt.Sprintf(`
 		SELECT
 			COALESCE(SUM(CASE WHEN type = 'deposit' AND currency = '%s' THEN amount ELSE 0 END), 0),
 			COALESCE(SUM(CASE WHEN type = 'withdrawal' AND currency = '%s' THEN amount ELSE 0 END), 0)
-		FROM cash_flows WHERE user_id = $1
-	`, config.LocalCurrency, config.LocalCurrency), userID).Scan(&summary.TotalDepositedCOP, &summary.TotalWithdrawnCOP); err != nil {
+		FROM cash_flows WHERE user_id = $1 AND %s
+	`, config.LocalCurrency, config.LocalCurrency, portfolioScopeSQL("portfolio_id", 2)), userID, s.portfolioArg()).Scan(&summary.TotalDepositedCOP, &summary.TotalWithdrawnCOP); err != nil {
 		return summary, fmt.Errorf("failed to sum %s deposits and withdrawals: %w", config.LocalCurrency, err)
 	}
 
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrNotificationNotFound = apperror.New(apperror.NotFound, "notification not found")
+
+const notificationColumns = `id, type, title, body, data, read_at, created_at`
+
+// NotificationInput is a notification to add to a user's inbox.
+type NotificationInput struct {
+	Type  string
+	Title string
+	Body  string
+	Data  map[string]string
+
+	// SkipIfUnread drops the notification while the user still has an unread
+	// one of the same type, for producers that see the same problem repeatedly.
+	SkipIfUnread bool
+}
+
+// NotificationService stores in-app notifications and pushes new ones to the
+// user's open SSE streams on every API instance.
+type NotificationService struct {
+	pool *pgxpool.Pool
+	hub  *notificationHub
+}
+
+// NewNotificationService creates a NotificationService backed by the given DB pool.
+func NewNotificationService(pool *pgxpool.Pool) *NotificationService {
+	return &NotificationService{pool: pool, hub: newNotificationHub()}
+}
+
+// Notify adds a notification to the user's inbox and announces it on
+// config.NotificationChannel, so every instance's RunListener publishes it
+// to the user's live streams. It returns nil when SkipIfUnread suppressed it.
+func (s *NotificationService) Notify(ctx context.Context, userID string, in NotificationInput) (*models.Notification, error) {
+	data := in.Data
+	if data == nil {
+		data = map[string]string{}
+	}
+	rows, err := s.pool.Query(ctx, `
+		INSERT INTO notifications (user_id, type, title, body, data)
+		SELECT $1::uuid, $2::text, $3::text, $4::text, $5::jsonb
+		WHERE NOT $6::boolean OR NOT EXISTS (
+			SELECT 1 FROM notifications
+			WHERE user_id = $1::uuid AND type = $2::text AND read_at IS NULL
+		)
+		RETURNING `+notificationColumns,
+		userID, in.Type, in.Title, in.Body, data, in.SkipIfUnread)
+	if err != nil {
+		return nil, fmt.Errorf("creating notification: %w", err)
+	}
+	notification, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Notification])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("collecting notification: %w", err)
+	}
+	// A failed announcement only delays the notification until the inbox is
+	// read again.
+	if _, err := s.pool.Exec(ctx, `SELECT pg_notify($1, $2)`,
+		config.NotificationChannel, userID+" "+notification.ID); err != nil {
+		slog.WarnContext(ctx, "announcing notification failed", "notification_id", notification.ID, "error", err)
+	}
+	return &notification, nil
+}
+
+// RunListener delivers the notifications announced by Notify on any API
+// instance to the streams open on this one, until ctx is done. It listens on
+// a dedicated connection and reconnects after config.NotificationListenRetry
+// when it loses it; notifications created meanwhile stay in the inbox.
+func (s *NotificationService) RunListener(ctx context.Context) {
+	for {
+		if err := s.listen(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "notification listener failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(config.NotificationListenRetry):
+		}
+	}
+}
+
+func (s *NotificationService) listen(ctx context.Context) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring listener connection: %w", err)
+	}
+	// LISTEN belongs to the session, so the connection never goes back to
+	// the pool.
+	listener := conn.Hijack()
+	defer listener.Close(context.Background())
+
+	if _, err := listener.Exec(ctx, "LISTEN "+config.NotificationChannel); err != nil {
+		return fmt.Errorf("listening for notifications: %w", err)
+	}
+	for {
+		announcement, err := listener.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("waiting for notifications: %w", err)
+		}
+		userID, notificationID, ok := strings.Cut(announcement.Payload, " ")
+		if !ok || !s.hub.hasSubscribers(userID) {
+			continue
+		}
+		rows, err := s.pool.Query(ctx, `
+			SELECT `+notificationColumns+`
+			FROM notifications
+			WHERE id = $1 AND user_id = $2
+		`, notificationID, userID)
+		if err != nil {
+			return fmt.Errorf("loading announced notification: %w", err)
+		}
+		notification, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Notification])
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			}
+			return fmt.Errorf("collecting announced notification: %w", err)
+		}
+		s.hub.publish(userID, notification)
+	}
+}
+
+// ListNotifications returns one page of the user's notifications, newest
+// first. unreadOnly limits the page and total to unread notifications. Pages
+// past the end are clamped to the last page.
+func (s *NotificationService) ListNotifications(ctx context.Context, userID string, unreadOnly bool, page, pageSize int) (*models.PaginatedResponse[models.Notification], error) {
+	var total int
+	if err := s.pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+	`, userID, unreadOnly).Scan(&total); err != nil {
+		return nil, fmt.Errorf("counting notifications: %w", err)
+	}
+	if totalPages := (total + pageSize - 1) / pageSize; page > totalPages {
+		page = max(totalPages, 1)
+	}
+
+	notifications, err := s.ListNotificationsFrom(ctx, userID, unreadOnly, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &models.PaginatedResponse[models.Notification]{
+		Items:    notifications,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// ListNotificationsFrom returns up to limit of the user's notifications,
+// newest first, skipping the first offset.
+func (s *NotificationService) ListNotificationsFrom(ctx context.Context, userID string, unreadOnly bool, offset, limit int) ([]models.Notification, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+notificationColumns+`
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC, id
+		LIMIT $3 OFFSET $4
+	`, userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("querying notifications: %w", err)
+	}
+	notifications, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.Notification])
+	if err != nil {
+		return nil, fmt.Errorf("collecting notifications: %w", err)
+	}
+	return notifications, nil
+}
+
+// UnreadCount returns how many of the user's notifications are unread.
+func (s *NotificationService) UnreadCount(ctx context.Context, userID string) (int, error) {
+	var count int
+	if err := s.pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL
+	`, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("counting unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// MarkRead marks one notification as read. Reading it again is a no-op.
+func (s *NotificationService) MarkRead(ctx context.Context, userID, notificationID string) (*models.Notification, error) {
+	if _, err := uuid.Parse(notificationID); err != nil {
+		return nil, ErrNotificationNotFound
+	}
+	rows, err := s.pool.Query(ctx, `
+		UPDATE notifications SET read_at = COALESCE(read_at, NOW())
+		WHERE id = $1 AND user_id = $2
+		RETURNING `+notificationColumns,
+		notificationID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("marking notification read: %w", err)
+	}
+	notification, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Notification])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotificationNotFound
+		}
+		return nil, fmt.Errorf("collecting notification: %w", err)
+	}
+	return &notification, nil
+}
+
+// MarkAllRead marks every unread notification as read and returns how many
+// changed.
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID string) (int64, error) {
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL
+	`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("marking notifications read: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// Subscribe streams the user's new notifications until cancel is called.
+// Callers read anything they show alongside the stream, such as the unread
+// count, after subscribing so nothing created in between is missed.
+func (s *NotificationService) Subscribe(userID string) (<-chan models.Notification, func()) {
+	return s.hub.subscribe(userID)
+}
+
+// CloseStreams ends every open stream by closing its channel. Shutdown calls
+// it so SSE connections finish instead of holding the server open.
+func (s *NotificationService) CloseStreams() {
+	s.hub.closeAll()
+}
+
+// notificationHub fans new notifications out to the SSE streams open on this
+// API instance.
+type notificationHub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan models.Notification]struct{}
+}
+
+func newNotificationHub() *notificationHub {
+	return &notificationHub{subscribers: map[string]map[chan models.Notification]struct{}{}}
+}
+
+func (h *notificationHub) subscribe(userID string) (<-chan models.Notification, func()) {
+	ch := make(chan models.Notification, config.NotificationStreamBuffer)
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = map[chan models.Notification]struct{}{}
+	}
+	h.subscribers[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	cancel := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		// closeAll may already have closed the channel.
+		if _, ok := h.subscribers[userID][ch]; !ok {
+			return
+		}
+		delete(h.subscribers[userID], ch)
+		if len(h.subscribers[userID]) == 0 {
+			delete(h.subscribers, userID)
+		}
+		close(ch)
+	}
+	return ch, cancel
+}
+
+func (h *notificationHub) hasSubscribers(userID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers[userID]) > 0
+}
+
+func (h *notificationHub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, channels := range h.subscribers {
+		for ch := range channels {
+			close(ch)
+		}
+	}
+	h.subscribers = map[string]map[chan models.Notification]struct{}{}
+}
+
+// publish never blocks: a stream that is too far behind misses the
+// notification, which is still in the inbox.
+func (h *notificationHub) publish(userID string, notification models.Notification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers[userID] {
+		select {
+		case ch <- notification:
+		default:
+		}
+	}
+}
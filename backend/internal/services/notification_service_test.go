@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/models"
+)
+
+func TestNotificationHub_PublishesToUserStreams(t *testing.T) {
+	t.Parallel()
+
+	hub := newNotificationHub()
+	first, cancelFirst := hub.subscribe("user-1")
+	second, cancelSecond := hub.subscribe("user-1")
+	other, cancelOther := hub.subscribe("user-2")
+	defer cancelSecond()
+	defer cancelOther()
+
+	hub.publish("user-1", models.Notification{ID: "n-1"})
+	for _, ch := range []<-chan models.Notification{first, second} {
+		if got := <-ch; got.ID != "n-1" {
+			t.Errorf("received %q, want n-1", got.ID)
+		}
+	}
+	select {
+	case got := <-other:
+		t.Errorf("other user received %q", got.ID)
+	default:
+	}
+
+	if !hub.hasSubscribers("user-1") || hub.hasSubscribers("user-3") {
+		t.Error("hasSubscribers does not match the open streams")
+	}
+
+	cancelFirst()
+	cancelFirst()
+	if _, ok := <-first; ok {
+		t.Error("cancelled stream still open")
+	}
+	hub.publish("user-1", models.Notification{ID: "n-2"})
+	if got := <-second; got.ID != "n-2" {
+		t.Errorf("received %q, want n-2", got.ID)
+	}
+}
+
+func TestNotificationHub_DropsWhenStreamIsFull(t *testing.T) {
+	t.Parallel()
+
+	hub := newNotificationHub()
+	ch, cancel := hub.subscribe("user-1")
+	defer cancel()
+
+	for range config.NotificationStreamBuffer + 5 {
+		hub.publish("user-1", models.Notification{ID: "n"})
+	}
+	if len(ch) != config.NotificationStreamBuffer {
+		t.Errorf("buffered = %d, want %d", len(ch), config.NotificationStreamBuffer)
+	}
+}
@@ -0,0 +1,19 @@
+package services
+
+import "fmt"
+
+// portfolioScopeSQL restricts column to the portfolio bound at placeholder $n.
+// Binding NULL (see portfolioScopeArg) keeps every portfolio, which is the
+// consolidated view.
+func portfolioScopeSQL(column string, n int) string {
+	return fmt.Sprintf("($%d::uuid IS NULL OR %s = $%d::uuid)", n, column, n)
+}
+
+// portfolioScopeArg is the value bound for portfolioScopeSQL. An empty ID
+// binds NULL so the query spans all of the user's portfolios.
+func portfolioScopeArg(portfolioID string) any {
+	if portfolioID == "" {
+		return nil
+	}
+	return portfolioID
+}
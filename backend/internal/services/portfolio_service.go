@@ -0,0 +1,366 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/models"
+	"fintu-tracking-backend/internal/telemetry"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+// Portfolio errors surfaced to handlers.
+var (
+	ErrPortfolioNotFound     = apperror.New(apperror.NotFound, "portfolio not found")
+	ErrTransferNotFound      = apperror.New(apperror.NotFound, "transfer not found")
+	ErrPortfolioNameTaken    = apperror.New(apperror.Conflict, "a portfolio with that name already exists")
+	ErrDefaultPortfolio      = apperror.New(apperror.Conflict, "the default portfolio cannot be deleted")
+	ErrPortfolioNotEmpty     = apperror.New(apperror.Conflict, "portfolio still has trades or cash flows")
+	ErrInvalidPortfolioName  = apperror.New(apperror.Validation, "portfolio name is required")
+	ErrInvalidTransfer       = apperror.New(apperror.Validation, "transfer must move a positive amount between two different portfolios")
+	ErrUnsetDefaultPortfolio = apperror.New(apperror.Validation, "mark another portfolio as default instead")
+)
+
+const portfolioColumns = `id, user_id, name, broker_id, is_default, created_at, updated_at`
+
+// portfolioNameMaxLength bounds user-chosen portfolio names.
+const portfolioNameMaxLength = 100
+
+// PortfolioService manages named portfolios and internal transfers between them.
+type PortfolioService struct {
+	pool *pgxpool.Pool
+}
+
+// NewPortfolioService creates a PortfolioService backed by the given DB pool.
+func NewPortfolioService(pool *pgxpool.Pool) *PortfolioService {
+	return &PortfolioService{pool: pool}
+}
+
+// ListPortfolios returns the user's portfolios, default first. The default
+// portfolio is created on first access so the list is never empty.
+func (s *PortfolioService) ListPortfolios(ctx context.Context, userID string) ([]models.Portfolio, error) {
+	if _, err := s.EnsureDefaultPortfolio(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+portfolioColumns+`
+		FROM portfolios
+		WHERE user_id = $1
+		ORDER BY is_default DESC, created_at ASC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying portfolios: %w", err)
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.Portfolio])
+}
+
+// EnsureDefaultPortfolio returns the ID of the user's default portfolio,
+// creating a "Main" portfolio when the user has none yet.
+func (s *PortfolioService) EnsureDefaultPortfolio(ctx context.Context, userID string) (string, error) {
+	var id string
+	if err := s.pool.QueryRow(ctx, `SELECT ensure_default_portfolio($1)`, userID).Scan(&id); err != nil {
+		return "", fmt.Errorf("ensuring default portfolio: %w", err)
+	}
+	return id, nil
+}
+
+// GetPortfolio returns a portfolio owned by the user, or ErrPortfolioNotFound.
+func (s *PortfolioService) GetPortfolio(ctx context.Context, userID, portfolioID string) (*models.Portfolio, error) {
+	if _, err := uuid.Parse(portfolioID); err != nil {
+		return nil, ErrPortfolioNotFound
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+portfolioColumns+`
+		FROM portfolios
+		WHERE id = $1 AND user_id = $2
+	`, portfolioID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying portfolio: %w", err)
+	}
+	defer rows.Close()
+
+	portfolio, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Portfolio])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPortfolioNotFound
+		}
+		return nil, fmt.Errorf("collecting portfolio: %w", err)
+	}
+	return &portfolio, nil
+}
+
+// CreatePortfolio creates a portfolio. Marking it as default moves the default
+// flag away from the previous default portfolio.
+func (s *PortfolioService) CreatePortfolio(ctx context.Context, userID string, req models.CreatePortfolioRequest) (*models.Portfolio, error) {
+	name, err := normalizePortfolioName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin create portfolio: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if req.IsDefault {
+		if err := clearDefaultPortfolio(ctx, tx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := tx.Query(ctx, `
+		INSERT INTO portfolios (user_id, name, broker_id, is_default)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+portfolioColumns, userID, name, req.BrokerID, req.IsDefault)
+	if err != nil {
+		return nil, fmt.Errorf("inserting portfolio: %w", err)
+	}
+	portfolio, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Portfolio])
+	if err != nil {
+		return nil, mapPortfolioWriteError(err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit create portfolio: %w", err)
+	}
+	return &portfolio, nil
+}
+
+// UpdatePortfolio renames a portfolio, changes its broker, or makes it the default.
+func (s *PortfolioService) UpdatePortfolio(ctx context.Context, userID, portfolioID string, req models.UpdatePortfolioRequest) (*models.Portfolio, error) {
+	existing, err := s.GetPortfolio(ctx, userID, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name, err := normalizePortfolioName(*req.Name)
+		if err != nil {
+			return nil, err
+		}
+		existing.Name = name
+	}
+	if req.BrokerID != nil {
+		if *req.BrokerID == "" {
+			existing.BrokerID = nil
+		} else {
+			existing.BrokerID = req.BrokerID
+		}
+	}
+	if req.IsDefault != nil {
+		if !*req.IsDefault && existing.IsDefault {
+			return nil, ErrUnsetDefaultPortfolio
+		}
+	}
+	makeDefault := req.IsDefault != nil && *req.IsDefault && !existing.IsDefault
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin update portfolio: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if makeDefault {
+		if err := clearDefaultPortfolio(ctx, tx, userID); err != nil {
+			return nil, err
+		}
+		existing.IsDefault = true
+	}
+
+	rows, err := tx.Query(ctx, `
+		UPDATE portfolios
+		SET name = $1, broker_id = $2, is_default = $3, updated_at = NOW()
+		WHERE id = $4 AND user_id = $5
+		RETURNING `+portfolioColumns,
+		existing.Name, existing.BrokerID, existing.IsDefault, portfolioID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("updating portfolio: %w", err)
+	}
+	portfolio, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Portfolio])
+	if err != nil {
+		return nil, mapPortfolioWriteError(err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit update portfolio: %w", err)
+	}
+	return &portfolio, nil
+}
+
+// DeletePortfolio removes an empty, non-default portfolio.
+func (s *PortfolioService) DeletePortfolio(ctx context.Context, userID, portfolioID string) error {
+	existing, err := s.GetPortfolio(ctx, userID, portfolioID)
+	if err != nil {
+		return err
+	}
+	if existing.IsDefault {
+		return ErrDefaultPortfolio
+	}
+
+	var inUse bool
+	if err := s.pool.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM trades WHERE portfolio_id = $1)
+		    OR EXISTS (SELECT 1 FROM cash_flows WHERE portfolio_id = $1)
+	`, portfolioID).Scan(&inUse); err != nil {
+		return fmt.Errorf("checking portfolio usage: %w", err)
+	}
+	if inUse {
+		return ErrPortfolioNotEmpty
+	}
+
+	if _, err := s.pool.Exec(ctx, `DELETE FROM portfolios WHERE id = $1 AND user_id = $2`, portfolioID, userID); err != nil {
+		return fmt.Errorf("deleting portfolio: %w", err)
+	}
+	return nil
+}
+
+// CreateTransfer moves USD cash between two of the user's portfolios. It writes
+// a transfer_out leg on the source and a transfer_in leg on the destination in
+// one transaction; neither leg counts as a deposit or withdrawal.
+func (s *PortfolioService) CreateTransfer(ctx context.Context, userID string, req models.CreatePortfolioTransferRequest) (*models.PortfolioTransfer, error) {
+	amount, err := decimal.NewFromString(strings.TrimSpace(req.Amount))
+	if err != nil || !amount.GreaterThan(decimal.Zero) || req.FromPortfolioID == req.ToPortfolioID {
+		return nil, ErrInvalidTransfer
+	}
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid date", ErrInvalidTransfer)
+	}
+	if _, err := s.GetPortfolio(ctx, userID, req.FromPortfolioID); err != nil {
+		return nil, err
+	}
+	if _, err := s.GetPortfolio(ctx, userID, req.ToPortfolioID); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin transfer: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	transferID := uuid.New().String()
+	amountStr := amount.StringFixed(2)
+	out, err := insertTransferLeg(ctx, tx, userID, req.FromPortfolioID, transferID, "transfer_out", date, amountStr, req.Notes)
+	if err != nil {
+		return nil, err
+	}
+	in, err := insertTransferLeg(ctx, tx, userID, req.ToPortfolioID, transferID, "transfer_in", date, amountStr, req.Notes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit transfer: %w", err)
+	}
+	return &models.PortfolioTransfer{TransferID: transferID, Out: out, In: in}, nil
+}
+
+// DeleteTransfer removes both legs of an internal transfer.
+func (s *PortfolioService) DeleteTransfer(ctx context.Context, userID, transferID string) error {
+	if _, err := uuid.Parse(transferID); err != nil {
+		return ErrTransferNotFound
+	}
+	result, err := s.pool.Exec(ctx, `
+		DELETE FROM cash_flows
+		WHERE user_id = $1 AND transfer_id = $2
+	`, userID, transferID)
+	if err != nil {
+		return fmt.Errorf("deleting transfer: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrTransferNotFound
+	}
+	return nil
+}
+
+// ConsolidatedView returns the net worth of each portfolio next to the
+// consolidated summary across all of them.
+func (s *PortfolioService) ConsolidatedView(ctx context.Context, userID string) (models.ConsolidatedPortfolioView, error) {
+	ctx, span := telemetry.StartSpan(ctx, "PortfolioService.ConsolidatedView")
+	defer span.End()
+
+	view := models.ConsolidatedPortfolioView{Portfolios: []models.PortfolioNetWorth{}}
+
+	portfolios, err := s.ListPortfolios(ctx, userID)
+	if err != nil {
+		return view, err
+	}
+
+	analytics := NewAnalyticsService(s.pool)
+	view.Consolidated, err = analytics.GetNetWorthSummary(ctx, userID)
+	if err != nil {
+		return view, fmt.Errorf("consolidated net worth: %w", err)
+	}
+
+	for _, p := range portfolios {
+		summary, err := analytics.ForPortfolio(p.ID).GetNetWorthSummary(ctx, userID)
+		if err != nil {
+			return view, fmt.Errorf("net worth for portfolio %s: %w", p.ID, err)
+		}
+		view.Portfolios = append(view.Portfolios, models.PortfolioNetWorth{Portfolio: p, Summary: summary})
+	}
+	return view, nil
+}
+
+func insertTransferLeg(ctx context.Context, tx pgx.Tx, userID, portfolioID, transferID, flowType string, date time.Time, amount string, notes *string) (models.CashFlow, error) {
+	var cf models.CashFlow
+	err := tx.QueryRow(ctx, `
+		INSERT INTO cash_flows (user_id, portfolio_id, transfer_id, date, type, currency, amount, usd_amount, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7, $8)
+		RETURNING id, user_id, date, type, currency, amount, fx_rate, usd_amount, portfolio_id, broker_id, notes,
+		          fee_type, related_trade_id, related_cash_flow_id, related_type, transfer_id, created_at, updated_at
+	`, userID, portfolioID, transferID, date, flowType, config.BaseCurrency, amount, notes).Scan(
+		&cf.ID, &cf.UserID, &cf.Date, &cf.Type, &cf.Currency, &cf.Amount, &cf.FxRate, &cf.UsdAmount,
+		&cf.PortfolioID, &cf.BrokerID, &cf.Notes, &cf.FeeType, &cf.RelatedTradeID, &cf.RelatedCashFlowID,
+		&cf.RelatedType, &cf.TransferID, &cf.CreatedAt, &cf.UpdatedAt,
+	)
+	if err != nil {
+		return cf, fmt.Errorf("inserting %s leg: %w", flowType, err)
+	}
+	return cf, nil
+}
+
+func clearDefaultPortfolio(ctx context.Context, tx pgx.Tx, userID string) error {
+	if _, err := tx.Exec(ctx, `
+		UPDATE portfolios SET is_default = false, updated_at = NOW()
+		WHERE user_id = $1 AND is_default
+	`, userID); err != nil {
+		return fmt.Errorf("clearing default portfolio: %w", err)
+	}
+	return nil
+}
+
+func normalizePortfolioName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > portfolioNameMaxLength {
+		return "", ErrInvalidPortfolioName
+	}
+	return name, nil
+}
+
+func mapPortfolioWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrPortfolioNameTaken
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrPortfolioNotFound
+	}
+	return fmt.Errorf("writing portfolio: %w", err)
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"fintu-tracking-backend/internal/database"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestPortfolioScopeSQL(t *testing.T) {
+	t.Parallel()
+
+	assertSQLFragments(t, portfolioScopeSQL("t.portfolio_id", 3), []string{
+		"$3::uuid IS NULL",
+		"t.portfolio_id = $3::uuid",
+	})
+	if got := portfolioScopeArg(""); got != nil {
+		t.Fatalf("portfolioScopeArg(\"\") = %v, want nil for the consolidated view", got)
+	}
+	if got := portfolioScopeArg("p-1"); got != "p-1" {
+		t.Fatalf("portfolioScopeArg(\"p-1\") = %v, want p-1", got)
+	}
+}
+
+func TestTransferPair_NetsToZeroWhenConsolidated(t *testing.T) {
+	t.Parallel()
+
+	balance := sumCashFlowsBalance([]cashFlowBalanceRow{
+		{Type: "deposit", USDAmount: dec("1000")},
+		{Type: "transfer_out", USDAmount: dec("250")},
+		{Type: "transfer_in", USDAmount: dec("250")},
+	})
+	if want := dec("1000"); !balance.Equal(want) {
+		t.Fatalf("cash balance = %s, want %s", balance, want)
+	}
+
+	invested := sumNetInvested([]netInvestedFlow{
+		{Type: "deposit", USDAmount: dec("1000")},
+		{Type: "transfer_out", USDAmount: dec("250")},
+		{Type: "transfer_in", USDAmount: dec("250")},
+	})
+	if want := dec("1000"); !invested.Equal(want) {
+		t.Fatalf("net invested = %s, want %s", invested, want)
+	}
+}
+
+func TestTransferLegs_MoveCapitalPerPortfolio(t *testing.T) {
+	t.Parallel()
+
+	source := sumNetInvested([]netInvestedFlow{
+		{Type: "deposit", USDAmount: dec("1000")},
+		{Type: "transfer_out", USDAmount: dec("250")},
+	})
+	if want := dec("750"); !source.Equal(want) {
+		t.Fatalf("source net invested = %s, want %s", source, want)
+	}
+
+	destination := sumCashFlowsBalance([]cashFlowBalanceRow{
+		{Type: "transfer_in", USDAmount: dec("250")},
+	})
+	if want := dec("250"); !destination.Equal(want) {
+		t.Fatalf("destination cash = %s, want %s", destination, want)
+	}
+}
+
+func TestCreateTransfer_RejectsInvalidInput(t *testing.T) {
+	t.Parallel()
+
+	svc := NewPortfolioService(nil)
+	cases := []models.CreatePortfolioTransferRequest{
+		{FromPortfolioID: "a", ToPortfolioID: "b", Date: "2024-01-01", Amount: "0"},
+		{FromPortfolioID: "a", ToPortfolioID: "b", Date: "2024-01-01", Amount: "abc"},
+		{FromPortfolioID: "a", ToPortfolioID: "a", Date: "2024-01-01", Amount: "10"},
+		{FromPortfolioID: "a", ToPortfolioID: "b", Date: "01/01/2024", Amount: "10"},
+	}
+	for _, req := range cases {
+		if _, err := svc.CreateTransfer(context.Background(), "user-1", req); !errors.Is(err, ErrInvalidTransfer) {
+			t.Errorf("CreateTransfer(%+v) error = %v, want ErrInvalidTransfer", req, err)
+		}
+	}
+}
+
+func TestPortfolioService_TransferScopesNetWorth(t *testing.T) {
+	skipIfNoSvcTestDB(t)
+
+	ctx := context.Background()
+	userID := uuid.New().String()
+	seedSvcAuthUser(t, userID)
+	t.Cleanup(func() {
+		execSvcSQL(t, "DELETE FROM cash_flows WHERE user_id = $1", userID)
+		execSvcSQL(t, "DELETE FROM portfolios WHERE user_id = $1", userID)
+		execSvcSQL(t, "DELETE FROM auth.users WHERE id = $1", userID)
+	})
+
+	seedSvcCashFlow(t, userID, "1000")
+
+	svc := NewPortfolioService(database.GetPool())
+	mainID, err := svc.EnsureDefaultPortfolio(ctx, userID)
+	if err != nil {
+		t.Fatalf("EnsureDefaultPortfolio() error = %v", err)
+	}
+	kids, err := svc.CreatePortfolio(ctx, userID, models.CreatePortfolioRequest{Name: "Kids"})
+	if err != nil {
+		t.Fatalf("CreatePortfolio() error = %v", err)
+	}
+
+	if _, err := svc.CreateTransfer(ctx, userID, models.CreatePortfolioTransferRequest{
+		FromPortfolioID: mainID,
+		ToPortfolioID:   kids.ID,
+		Date:            "2024-01-15",
+		Amount:          "250",
+	}); err != nil {
+		t.Fatalf("CreateTransfer() error = %v", err)
+	}
+
+	view, err := svc.ConsolidatedView(ctx, userID)
+	if err != nil {
+		t.Fatalf("ConsolidatedView() error = %v", err)
+	}
+	if view.Consolidated.CashBalance != "1000" {
+		t.Errorf("consolidated cash = %q, want 1000", view.Consolidated.CashBalance)
+	}
+	if view.Consolidated.TotalInvested != "1000" {
+		t.Errorf("consolidated invested = %q, want 1000 (transfers are not deposits)", view.Consolidated.TotalInvested)
+	}
+
+	byID := map[string]models.NetWorthSummary{}
+	for _, p := range view.Portfolios {
+		byID[p.Portfolio.ID] = p.Summary
+	}
+	if got := byID[mainID].CashBalance; got != "750" {
+		t.Errorf("main cash = %q, want 750", got)
+	}
+	if got := byID[kids.ID].CashBalance; got != "250" {
+		t.Errorf("kids cash = %q, want 250", got)
+	}
+
+	if err := svc.DeletePortfolio(ctx, userID, kids.ID); !errors.Is(err, ErrPortfolioNotEmpty) {
+		t.Errorf("DeletePortfolio(non-empty) error = %v, want ErrPortfolioNotEmpty", err)
+	}
+}
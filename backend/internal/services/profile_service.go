@@ -2,8 +2,12 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
+	"fintu-tracking-backend/internal/config"
 	"fintu-tracking-backend/internal/models"
 
 	"github.com/jackc/pgx/v5"
@@ -15,11 +19,23 @@ type ProfileService struct {
 	pool    *pgxpool.Pool
 	billing *BillingService
 	brokers *BrokerService
+
+	mu      sync.Mutex
+	locales map[string]cachedProfileLocale
+}
+
+type cachedProfileLocale struct {
+	locale    string
+	expiresAt time.Time
 }
 
+// profileLocaleCacheSweep is the cache size at which expired locales are
+// dropped.
+const profileLocaleCacheSweep = 1024
+
 // NewProfileService creates a ProfileService backed by the given DB pool, billing, and broker services.
 func NewProfileService(pool *pgxpool.Pool, billing *BillingService, brokers *BrokerService) *ProfileService {
-	return &ProfileService{pool: pool, billing: billing, brokers: brokers}
+	return &ProfileService{pool: pool, billing: billing, brokers: brokers, locales: make(map[string]cachedProfileLocale)}
 }
 
 // GetOrCreateProfile returns the user's profile, inserting a default row if missing
@@ -29,7 +45,7 @@ func (s *ProfileService) GetOrCreateProfile(ctx context.Context, userID string)
 		INSERT INTO profiles (user_id, country, onboarding_completed, onboarding_step)
 		VALUES ($1, 'co', false, 'welcome')
 		ON CONFLICT (user_id) DO UPDATE SET updated_at = NOW()
-		RETURNING id, user_id, country, broker_preset_id, onboarding_completed, onboarding_step, plan_id, subscription_status, created_at, updated_at
+		RETURNING id, user_id, country, broker_preset_id, locale, onboarding_completed, onboarding_step, plan_id, subscription_status, created_at, updated_at
 	`, userID)
 	if err != nil {
 		return nil, fmt.Errorf("upserting profile: %w", err)
@@ -60,7 +76,7 @@ func (s *ProfileService) GetOrCreateProfile(ctx context.Context, userID string)
 // GetProfile returns the user's profile by ID.
 func (s *ProfileService) GetProfile(ctx context.Context, userID string) (*models.Profile, error) {
 	rows, err := s.pool.Query(ctx, `
-		SELECT id, user_id, country, broker_preset_id, onboarding_completed, onboarding_step,
+		SELECT id, user_id, country, broker_preset_id, locale, onboarding_completed, onboarding_step,
 		       plan_id, subscription_status, created_at, updated_at
 		FROM profiles
 		WHERE user_id = $1
@@ -91,7 +107,7 @@ func (s *ProfileService) UpdateOnboarding(ctx context.Context, userID string, re
 		    onboarding_step = 'completed',
 		    updated_at = NOW()
 		WHERE user_id = $1
-		RETURNING id, user_id, country, broker_preset_id, onboarding_completed, onboarding_step, plan_id, subscription_status, created_at, updated_at
+		RETURNING id, user_id, country, broker_preset_id, locale, onboarding_completed, onboarding_step, plan_id, subscription_status, created_at, updated_at
 	`, userID, req.Country, req.BrokerPresetID)
 	if err != nil {
 		return nil, fmt.Errorf("updating onboarding: %w", err)
@@ -105,7 +121,8 @@ func (s *ProfileService) UpdateOnboarding(ctx context.Context, userID string, re
 	return &profile, nil
 }
 
-// UpdateProfile updates country and broker preset without changing onboarding state.
+// UpdateProfile updates country and broker preset without changing onboarding
+// state, and the locale when the request sets one.
 func (s *ProfileService) UpdateProfile(ctx context.Context, userID string, req models.UpdateProfileRequest) (*models.Profile, error) {
 	current, err := s.GetOrCreateProfile(ctx, userID)
 	if err != nil {
@@ -123,10 +140,11 @@ func (s *ProfileService) UpdateProfile(ctx context.Context, userID string, req m
 		UPDATE profiles
 		SET country = $2,
 		    broker_preset_id = $3,
+		    locale = COALESCE($4, locale),
 		    updated_at = NOW()
 		WHERE user_id = $1
-		RETURNING id, user_id, country, broker_preset_id, onboarding_completed, onboarding_step, plan_id, subscription_status, created_at, updated_at
-	`, userID, req.Country, req.BrokerPresetID)
+		RETURNING id, user_id, country, broker_preset_id, locale, onboarding_completed, onboarding_step, plan_id, subscription_status, created_at, updated_at
+	`, userID, req.Country, req.BrokerPresetID, req.Locale)
 	if err != nil {
 		return nil, fmt.Errorf("updating profile: %w", err)
 	}
@@ -136,5 +154,46 @@ func (s *ProfileService) UpdateProfile(ctx context.Context, userID string, req m
 	if err != nil {
 		return nil, fmt.Errorf("collecting updated profile: %w", err)
 	}
+	s.cacheLocale(userID, profile.Locale)
 	return &profile, nil
 }
+
+// ProfileLocale returns the locale code the user chose, or "" when they have
+// not chosen one or have no profile yet. It is cached for
+// config.ProfileLocaleCacheTTL.
+func (s *ProfileService) ProfileLocale(ctx context.Context, userID string) (string, error) {
+	s.mu.Lock()
+	cached, ok := s.locales[userID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.locale, nil
+	}
+
+	var locale *string
+	err := s.pool.QueryRow(ctx, `SELECT locale FROM profiles WHERE user_id = $1`, userID).Scan(&locale)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("fetching profile locale: %w", err)
+	}
+	return s.cacheLocale(userID, locale), nil
+}
+
+// cacheLocale caches the user's locale and returns it as a code.
+func (s *ProfileService) cacheLocale(userID string, locale *string) string {
+	code := ""
+	if locale != nil {
+		code = *locale
+	}
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.locales) >= profileLocaleCacheSweep {
+		for id, cached := range s.locales {
+			if now.After(cached.expiresAt) {
+				delete(s.locales, id)
+			}
+		}
+	}
+	s.locales[userID] = cachedProfileLocale{locale: code, expiresAt: now.Add(config.ProfileLocaleCacheTTL)}
+	return code
+}
@@ -0,0 +1,19 @@
+package services
+
+import (
+	"fintu-tracking-backend/internal/apperror"
+)
+
+// Market data provider errors. Their messages are safe to show clients.
+var (
+	ErrMarketDataNotConfigured = apperror.New(apperror.UpstreamUnavailable, "market data provider is not configured: TWELVE_DATA_API_KEY is not set")
+	ErrProviderRateLimited     = apperror.New(apperror.RateLimited, "market data provider rate limit reached")
+	ErrProviderRejected        = apperror.New(apperror.UpstreamUnavailable, "market data provider rejected the request")
+)
+
+// providerError classifies a failed market data provider call as
+// upstream_unavailable. The transport or API detail in err is kept as the
+// cause for logs and is not shown to clients.
+func providerError(err error) error {
+	return apperror.Wrap(apperror.UpstreamUnavailable, "market data provider is unavailable", err)
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"fintu-tracking-backend/internal/config"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// RateLimitStore persists token buckets.
+type RateLimitStore interface {
+	// Take refills the bucket at key for the time since it was last used,
+	// capped at quota.Burst, and removes one token if a whole one is left.
+	// It returns the tokens left afterwards and whether a token was taken.
+	// A new bucket starts full.
+	Take(ctx context.Context, key string, quota config.RateLimitQuota) (tokens float64, allowed bool, err error)
+	// Prune deletes buckets unused since before idleSince.
+	Prune(ctx context.Context, idleSince time.Time) (int64, error)
+}
+
+// postgresRateLimitStore implements RateLimitStore on top of pgxpool.Pool so
+// every API instance draws from the same buckets.
+type postgresRateLimitStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresRateLimitStore creates a store backed by the rate_limit_buckets table.
+func NewPostgresRateLimitStore(pool *pgxpool.Pool) RateLimitStore {
+	return &postgresRateLimitStore{pool: pool}
+}
+
+func (s *postgresRateLimitStore) Take(ctx context.Context, key string, quota config.RateLimitQuota) (float64, bool, error) {
+	if s.pool == nil {
+		return 0, false, fmt.Errorf("database pool is not initialized")
+	}
+
+	// The refill is computed from the database clock, so replicas with
+	// drifting clocks still agree. In the UPDATE, b.* are the old values.
+	var (
+		tokens  float64
+		allowed bool
+	)
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO rate_limit_buckets AS b (bucket_key, tokens, allowed, updated_at)
+		VALUES ($1, $2::float8 - 1, true, NOW())
+		ON CONFLICT (bucket_key) DO UPDATE SET
+			tokens = LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at)::float8 * $3::float8)
+				- CASE WHEN LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at)::float8 * $3::float8) >= 1 THEN 1 ELSE 0 END,
+			allowed = LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at)::float8 * $3::float8) >= 1,
+			updated_at = NOW()
+		RETURNING tokens, allowed
+	`, key, float64(quota.Burst), quota.PerMinute/60).Scan(&tokens, &allowed)
+	if err != nil {
+		return 0, false, fmt.Errorf("taking rate limit token: %w", err)
+	}
+	return tokens, allowed, nil
+}
+
+func (s *postgresRateLimitStore) Prune(ctx context.Context, idleSince time.Time) (int64, error) {
+	if s.pool == nil {
+		return 0, fmt.Errorf("database pool is not initialized")
+	}
+	tag, err := s.pool.Exec(ctx, `DELETE FROM rate_limit_buckets WHERE updated_at < $1`, idleSince)
+	if err != nil {
+		return 0, fmt.Errorf("pruning rate limit buckets: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"fintu-tracking-backend/internal/config"
+)
+
+// PlanFeaturesSource returns the features JSON of a user's plan, or nil when
+// the user has no subscription. BillingService implements it.
+type PlanFeaturesSource interface {
+	PlanFeatures(ctx context.Context, userID string) (json.RawMessage, error)
+}
+
+// RateLimitResult is the outcome of taking a token for one request.
+type RateLimitResult struct {
+	Allowed   bool
+	Quota     config.RateLimitQuota
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next token, when the request was denied.
+	RetryAfter time.Duration
+}
+
+// RateLimiter enforces per-user, per-route-group token buckets with quotas
+// from the user's plan.
+type RateLimiter struct {
+	store RateLimitStore
+	plans PlanFeaturesSource
+	now   func() time.Time
+
+	mu     sync.Mutex
+	quotas map[string]cachedRateLimits
+}
+
+type cachedRateLimits struct {
+	quotas    map[string]config.RateLimitQuota
+	expiresAt time.Time
+}
+
+// NewRateLimiter creates a RateLimiter that keeps buckets in store and reads
+// plan quotas from plans.
+func NewRateLimiter(store RateLimitStore, plans PlanFeaturesSource) *RateLimiter {
+	return &RateLimiter{
+		store:  store,
+		plans:  plans,
+		now:    time.Now,
+		quotas: make(map[string]cachedRateLimits),
+	}
+}
+
+// Allow takes a token from the user's bucket for group.
+func (l *RateLimiter) Allow(ctx context.Context, userID, group string) (RateLimitResult, error) {
+	quotas, err := l.userQuotas(ctx, userID)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	quota := rateLimitQuotaFor(quotas, group)
+	tokens, allowed, err := l.store.Take(ctx, userID+":"+group, quota)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	return newRateLimitResult(quota, tokens, allowed), nil
+}
+
+// RunPruner deletes idle buckets and expired plan quotas every interval until
+// ctx is cancelled.
+func (l *RateLimiter) RunPruner(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := l.store.Prune(ctx, l.now().Add(-config.RateLimitBucketTTL)); err != nil {
+			slog.WarnContext(ctx, "rate limit bucket prune failed", "error", err)
+		}
+		l.mu.Lock()
+		now := l.now()
+		for userID, cached := range l.quotas {
+			if now.After(cached.expiresAt) {
+				delete(l.quotas, userID)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
+
+// userQuotas returns the user's quotas, cached for config.RateLimitPlanCacheTTL.
+func (l *RateLimiter) userQuotas(ctx context.Context, userID string) (map[string]config.RateLimitQuota, error) {
+	l.mu.Lock()
+	cached, ok := l.quotas[userID]
+	l.mu.Unlock()
+	if ok && l.now().Before(cached.expiresAt) {
+		return cached.quotas, nil
+	}
+
+	features, err := l.plans.PlanFeatures(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	quotas := planRateLimits(features)
+
+	l.mu.Lock()
+	l.quotas[userID] = cachedRateLimits{quotas: quotas, expiresAt: l.now().Add(config.RateLimitPlanCacheTTL)}
+	l.mu.Unlock()
+	return quotas, nil
+}
+
+// planRateLimits merges the "rate_limits" entry of a plan's features over
+// config.DefaultRateLimits. A group may set just burst or just per_minute;
+// invalid or unparsable entries keep the default.
+func planRateLimits(features json.RawMessage) map[string]config.RateLimitQuota {
+	quotas := make(map[string]config.RateLimitQuota, len(config.DefaultRateLimits))
+	for group, quota := range config.DefaultRateLimits {
+		quotas[group] = quota
+	}
+	if len(features) == 0 {
+		return quotas
+	}
+
+	var parsed struct {
+		RateLimits map[string]json.RawMessage `json:"rate_limits"`
+	}
+	if err := json.Unmarshal(features, &parsed); err != nil {
+		return quotas
+	}
+	for group, raw := range parsed.RateLimits {
+		quota := rateLimitQuotaFor(quotas, group)
+		if err := json.Unmarshal(raw, &quota); err != nil || quota.Burst < 1 || quota.PerMinute <= 0 {
+			continue
+		}
+		quotas[group] = quota
+	}
+	return quotas
+}
+
+// rateLimitQuotaFor returns the quota for group, falling back to the default
+// group's.
+func rateLimitQuotaFor(quotas map[string]config.RateLimitQuota, group string) config.RateLimitQuota {
+	if quota, ok := quotas[group]; ok {
+		return quota
+	}
+	return quotas[config.RateLimitGroupDefault]
+}
+
+// newRateLimitResult describes a bucket left with tokens after a request.
+func newRateLimitResult(quota config.RateLimitQuota, tokens float64, allowed bool) RateLimitResult {
+	perSecond := quota.PerMinute / 60
+	result := RateLimitResult{
+		Allowed:   allowed,
+		Quota:     quota,
+		Remaining: max(0, int(math.Floor(tokens))),
+		Reset:     secondsCeil((float64(quota.Burst) - tokens) / perSecond),
+	}
+	if !allowed {
+		result.RetryAfter = max(time.Second, secondsCeil((1-tokens)/perSecond))
+	}
+	return result
+}
+
+// secondsCeil rounds a number of seconds up to a whole-second duration.
+func secondsCeil(seconds float64) time.Duration {
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(seconds)) * time.Second
+}
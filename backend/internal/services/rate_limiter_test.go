@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"fintu-tracking-backend/internal/config"
+)
+
+type recordingRateLimitStore struct {
+	keys   []string
+	quotas []config.RateLimitQuota
+}
+
+func (s *recordingRateLimitStore) Take(_ context.Context, key string, quota config.RateLimitQuota) (float64, bool, error) {
+	s.keys = append(s.keys, key)
+	s.quotas = append(s.quotas, quota)
+	return float64(quota.Burst) - 1, true, nil
+}
+
+func (s *recordingRateLimitStore) Prune(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+type countingPlanFeatures struct {
+	features json.RawMessage
+	calls    int
+}
+
+func (p *countingPlanFeatures) PlanFeatures(context.Context, string) (json.RawMessage, error) {
+	p.calls++
+	return p.features, nil
+}
+
+func TestPlanRateLimits(t *testing.T) {
+	t.Parallel()
+
+	got := planRateLimits(json.RawMessage(`{
+		"max_trades": null,
+		"rate_limits": {
+			"analytics": {"burst": 60, "per_minute": 120},
+			"market_data": {"per_minute": 4},
+			"default": {"burst": 0},
+			"exports": {"burst": 2, "per_minute": 1}
+		}
+	}`))
+
+	want := map[string]config.RateLimitQuota{
+		config.RateLimitGroupAnalytics:  {Burst: 60, PerMinute: 120},
+		config.RateLimitGroupMarketData: {Burst: config.DefaultRateLimits[config.RateLimitGroupMarketData].Burst, PerMinute: 4},
+		config.RateLimitGroupDefault:    config.DefaultRateLimits[config.RateLimitGroupDefault],
+		"exports":                       {Burst: 2, PerMinute: 1},
+	}
+	for group, quota := range want {
+		if got[group] != quota {
+			t.Errorf("%s quota = %+v, want %+v", group, got[group], quota)
+		}
+	}
+
+	for _, features := range []string{``, `{}`, `not json`, `{"rate_limits": []}`} {
+		got := planRateLimits(json.RawMessage(features))
+		if got[config.RateLimitGroupAnalytics] != config.DefaultRateLimits[config.RateLimitGroupAnalytics] {
+			t.Errorf("planRateLimits(%q) analytics = %+v, want default", features, got[config.RateLimitGroupAnalytics])
+		}
+	}
+	if config.DefaultRateLimits[config.RateLimitGroupAnalytics].Burst == 60 {
+		t.Fatal("planRateLimits modified config.DefaultRateLimits")
+	}
+}
+
+func TestNewRateLimitResult(t *testing.T) {
+	t.Parallel()
+
+	quota := config.RateLimitQuota{Burst: 20, PerMinute: 30} // one token every 2s
+
+	allowed := newRateLimitResult(quota, 14.5, true)
+	if !allowed.Allowed || allowed.Remaining != 14 || allowed.Reset != 11*time.Second || allowed.RetryAfter != 0 {
+		t.Errorf("allowed result = %+v", allowed)
+	}
+
+	denied := newRateLimitResult(quota, 0.25, false)
+	if denied.Allowed || denied.Remaining != 0 || denied.RetryAfter != 2*time.Second || denied.Reset != 40*time.Second {
+		t.Errorf("denied result = %+v", denied)
+	}
+
+	almost := newRateLimitResult(quota, 0.9999, false)
+	if almost.RetryAfter != time.Second {
+		t.Errorf("RetryAfter = %v, want at least 1s", almost.RetryAfter)
+	}
+}
+
+func TestRateLimiter_CachesPlanQuotas(t *testing.T) {
+	t.Parallel()
+
+	store := &recordingRateLimitStore{}
+	plans := &countingPlanFeatures{features: json.RawMessage(`{"rate_limits": {"analytics": {"burst": 60, "per_minute": 120}}}`)}
+	limiter := NewRateLimiter(store, plans)
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	for _, group := range []string{config.RateLimitGroupAnalytics, config.RateLimitGroupDefault, "unknown"} {
+		result, err := limiter.Allow(ctx, "user-1", group)
+		if err != nil {
+			t.Fatalf("Allow(%s): %v", group, err)
+		}
+		if !result.Allowed {
+			t.Errorf("Allow(%s) denied", group)
+		}
+	}
+	if plans.calls != 1 {
+		t.Errorf("plan lookups = %d, want 1", plans.calls)
+	}
+	if store.keys[0] != "user-1:analytics" || store.quotas[0].Burst != 60 {
+		t.Errorf("analytics take = %s %+v", store.keys[0], store.quotas[0])
+	}
+	if store.quotas[2] != config.DefaultRateLimits[config.RateLimitGroupDefault] {
+		t.Errorf("unknown group quota = %+v, want the default group's", store.quotas[2])
+	}
+
+	now = now.Add(config.RateLimitPlanCacheTTL)
+	if _, err := limiter.Allow(ctx, "user-1", config.RateLimitGroupAnalytics); err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	if plans.calls != 2 {
+		t.Errorf("plan lookups after TTL = %d, want 2", plans.calls)
+	}
+}
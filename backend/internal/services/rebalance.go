@@ -0,0 +1,277 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// fractionalQuantityPlaces is the precision of fractional order quantities.
+const fractionalQuantityPlaces = 6
+
+type rebalanceInput struct {
+	allocation models.TargetAllocation
+	holdings   []models.Holding
+	cash       decimal.Decimal
+	prices     map[string]decimal.Decimal // latest prices of ticker targets
+	allowSells bool
+	// commission estimates the fee of an order; nil when the broker is unknown.
+	commission func(assetType string, notional decimal.Decimal) decimal.Decimal
+}
+
+// rebalanceMember is a ticker that can be traded to move its bucket.
+type rebalanceMember struct {
+	ticker    string
+	assetType string
+	qty       decimal.Decimal
+	price     decimal.Decimal
+	value     decimal.Decimal
+}
+
+// rebalanceBucket is one target key (ticker, asset type or cash) and the
+// holdings that make it up.
+type rebalanceBucket struct {
+	key       string
+	target    decimal.Decimal
+	band      decimal.Decimal
+	value     decimal.Decimal
+	members   []rebalanceMember
+	drift     decimal.Decimal
+	outOfBand bool
+}
+
+// planRebalance suggests the fewest orders that bring out-of-band positions back
+// to target: overweight positions outside their band are trimmed (unless sells
+// are disabled), and the available cash above the cash target then buys
+// underweight positions, those outside their band first. Positions inside their
+// band are only topped up when there is excess cash to deploy.
+func planRebalance(in rebalanceInput) *models.RebalancePlan {
+	plan := &models.RebalancePlan{
+		Basis:            in.allocation.Basis,
+		TotalValue:       "0.00",
+		CashBefore:       in.cash.StringFixed(2),
+		CashAfter:        in.cash.StringFixed(2),
+		AllowSells:       in.allowSells,
+		FractionalShares: in.allocation.FractionalShares,
+		Positions:        []models.RebalancePosition{},
+		Orders:           []models.RebalanceOrder{},
+		EstimatedFees:    "0.00",
+		Warnings:         []string{},
+	}
+
+	buckets, cashBucket := buildRebalanceBuckets(in, plan)
+	total := in.cash
+	for _, b := range buckets {
+		total = total.Add(b.value)
+	}
+	plan.TotalValue = total.StringFixed(2)
+	if !total.IsPositive() {
+		plan.Warnings = append(plan.Warnings, "portfolio has no value to rebalance")
+		return plan
+	}
+
+	for _, b := range append(buckets, cashBucket) {
+		b.drift = b.value.Div(total).Sub(b.target)
+		b.outOfBand = b.drift.Abs().GreaterThan(b.band)
+		plan.NeedsRebalance = plan.NeedsRebalance || b.outOfBand
+		plan.Positions = append(plan.Positions, models.RebalancePosition{
+			Key:           b.key,
+			CurrentValue:  b.value.StringFixed(2),
+			CurrentWeight: b.value.Div(total).StringFixed(4),
+			TargetWeight:  b.target.StringFixed(4),
+			Drift:         b.drift.StringFixed(4),
+			DriftBand:     b.band.String(),
+			OutOfBand:     b.outOfBand,
+		})
+	}
+	if !plan.NeedsRebalance {
+		return plan
+	}
+
+	cash := in.cash
+	fees := decimal.Zero
+	sold := false
+	for _, b := range buckets {
+		if !b.outOfBand || !b.drift.IsPositive() {
+			continue
+		}
+		if !in.allowSells {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s is above its band but sells are disabled", b.key))
+			continue
+		}
+		excess := b.value.Sub(b.target.Mul(total))
+		for _, m := range b.members {
+			qty := m.qty
+			if !b.target.IsZero() {
+				qty = orderQuantity(excess.Mul(m.value).Div(b.value).Div(m.price), in.allocation.FractionalShares, m.assetType)
+			}
+			qty = decimal.Min(qty, m.qty)
+			if !qty.IsPositive() {
+				continue
+			}
+			order, amount, fee := newRebalanceOrder(in, m, "sell", qty)
+			plan.Orders = append(plan.Orders, order)
+			cash = cash.Add(amount).Sub(fee)
+			fees = fees.Add(fee)
+			sold = true
+		}
+	}
+
+	// Buy with cash above the cash target, out-of-band positions first.
+	budget := cash.Sub(cashBucket.target.Mul(total))
+	candidates := make([]*rebalanceBucket, 0, len(buckets))
+	for _, b := range buckets {
+		if b.drift.IsNegative() && (b.outOfBand || cashBucket.outOfBand || sold) {
+			candidates = append(candidates, b)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].outOfBand != candidates[j].outOfBand {
+			return candidates[i].outOfBand
+		}
+		return candidates[i].drift.LessThan(candidates[j].drift)
+	})
+	for _, b := range candidates {
+		if !budget.IsPositive() {
+			if b.outOfBand {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("not enough cash to buy %s; deposit cash or allow sells", b.key))
+			}
+			continue
+		}
+		if len(b.members) == 0 {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("no holding or market price for %s; buy it manually", b.key))
+			continue
+		}
+		spend := decimal.Min(b.target.Mul(total).Sub(b.value), budget)
+		for _, m := range b.members {
+			share := spend
+			if b.value.IsPositive() {
+				share = spend.Mul(m.value).Div(b.value)
+			}
+			qty := orderQuantity(share.Div(m.price), in.allocation.FractionalShares, m.assetType)
+			if fee := estimateCommission(in, m.assetType, qty.Mul(m.price)); qty.Mul(m.price).Add(fee).GreaterThan(budget) {
+				qty = orderQuantity(budget.Sub(fee).Div(m.price), in.allocation.FractionalShares, m.assetType)
+			}
+			if !qty.IsPositive() {
+				continue
+			}
+			order, amount, fee := newRebalanceOrder(in, m, "buy", qty)
+			plan.Orders = append(plan.Orders, order)
+			budget = budget.Sub(amount).Sub(fee)
+			cash = cash.Sub(amount).Sub(fee)
+			fees = fees.Add(fee)
+		}
+	}
+
+	plan.CashAfter = cash.StringFixed(2)
+	plan.EstimatedFees = fees.StringFixed(2)
+	return plan
+}
+
+// buildRebalanceBuckets groups holdings by target key. Targets come first in the
+// allocation's order, then held positions without a target (target weight 0).
+// Cash is returned separately.
+func buildRebalanceBuckets(in rebalanceInput, plan *models.RebalancePlan) ([]*rebalanceBucket, *rebalanceBucket) {
+	defaultBand, err := decimal.NewFromString(in.allocation.DriftBand)
+	if err != nil {
+		defaultBand, _ = decimal.NewFromString(config.DefaultDriftBand)
+	}
+
+	cashBucket := &rebalanceBucket{key: config.CashAllocationKey, band: defaultBand, value: in.cash}
+	byKey := make(map[string]*rebalanceBucket)
+	buckets := make([]*rebalanceBucket, 0, len(in.allocation.Targets))
+	for _, t := range in.allocation.Targets {
+		b := cashBucket
+		if t.Key != config.CashAllocationKey {
+			b = &rebalanceBucket{key: t.Key, band: defaultBand}
+			byKey[t.Key] = b
+			buckets = append(buckets, b)
+		}
+		b.target, _ = decimal.NewFromString(t.Weight)
+		if t.DriftBand != nil {
+			if band, err := decimal.NewFromString(*t.DriftBand); err == nil {
+				b.band = band
+			}
+		}
+	}
+
+	untargeted := make([]*rebalanceBucket, 0)
+	for _, h := range in.holdings {
+		qty, errQty := decimal.NewFromString(h.Quantity)
+		value, errValue := decimal.NewFromString(h.MarketValue)
+		if errQty != nil || errValue != nil || !qty.IsPositive() || !value.IsPositive() {
+			continue
+		}
+		key := h.Ticker
+		if in.allocation.Basis == "asset_type" {
+			key = h.AssetType
+		}
+		b, ok := byKey[key]
+		if !ok {
+			b = &rebalanceBucket{key: key, band: defaultBand}
+			byKey[key] = b
+			untargeted = append(untargeted, b)
+		}
+		b.value = b.value.Add(value)
+		b.members = append(b.members, rebalanceMember{
+			ticker:    h.Ticker,
+			assetType: h.AssetType,
+			qty:       qty,
+			price:     value.Div(qty),
+			value:     value,
+		})
+	}
+	sort.Slice(untargeted, func(i, j int) bool { return untargeted[i].key < untargeted[j].key })
+
+	// Ticker targets that are not held yet can be bought at the latest price.
+	if in.allocation.Basis == "ticker" {
+		for _, t := range in.allocation.Targets {
+			b := byKey[t.Key]
+			if b == nil || len(b.members) > 0 {
+				continue
+			}
+			price, ok := in.prices[t.Key]
+			if !ok {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("no market price for %s; refresh market prices", t.Key))
+				continue
+			}
+			b.members = append(b.members, rebalanceMember{ticker: t.Key, assetType: t.AssetType, price: price})
+		}
+	}
+
+	return append(buckets, untargeted...), cashBucket
+}
+
+// orderQuantity rounds a quantity down to what the broker can trade: whole
+// shares unless fractional shares are enabled. Crypto is always fractional.
+func orderQuantity(qty decimal.Decimal, fractional bool, assetType string) decimal.Decimal {
+	if fractional || assetType == "crypto" {
+		return qty.Truncate(fractionalQuantityPlaces)
+	}
+	return qty.Floor()
+}
+
+func estimateCommission(in rebalanceInput, assetType string, notional decimal.Decimal) decimal.Decimal {
+	if in.commission == nil {
+		return decimal.Zero
+	}
+	return in.commission(assetType, notional)
+}
+
+func newRebalanceOrder(in rebalanceInput, m rebalanceMember, side string, qty decimal.Decimal) (models.RebalanceOrder, decimal.Decimal, decimal.Decimal) {
+	amount := qty.Mul(m.price)
+	fee := estimateCommission(in, m.assetType, amount)
+	return models.RebalanceOrder{
+		Ticker:       m.ticker,
+		AssetType:    m.assetType,
+		Side:         side,
+		Quantity:     qty.String(),
+		Price:        m.price.Round(4).String(),
+		Amount:       amount.StringFixed(2),
+		EstimatedFee: fee.StringFixed(2),
+	}, amount, fee
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+func flatCommission(assetType string, notional decimal.Decimal) decimal.Decimal {
+	return decimal.NewFromInt(1)
+}
+
+func rebalanceAllocation(fractional bool, targets ...models.AllocationTarget) models.TargetAllocation {
+	return models.TargetAllocation{Basis: "ticker", DriftBand: "0.05", FractionalShares: fractional, Targets: targets}
+}
+
+func TestPlanRebalance_DeploysExcessCashIntoUnheldTarget(t *testing.T) {
+	t.Parallel()
+
+	plan := planRebalance(rebalanceInput{
+		allocation: rebalanceAllocation(false,
+			models.AllocationTarget{Key: "VOO", Weight: "0.7", AssetType: "etf"},
+			models.AllocationTarget{Key: "QQQ", Weight: "0.2", AssetType: "etf"},
+			models.AllocationTarget{Key: "CASH", Weight: "0.1"},
+		),
+		holdings:   []models.Holding{{Ticker: "VOO", AssetType: "etf", Quantity: "10", MarketValue: "1000"}},
+		cash:       dec("500"),
+		prices:     map[string]decimal.Decimal{"QQQ": dec("50")},
+		allowSells: true,
+		commission: flatCommission,
+	})
+
+	if !plan.NeedsRebalance {
+		t.Fatal("needs rebalance = false, want true (QQQ and cash are out of band)")
+	}
+	if len(plan.Orders) != 1 {
+		t.Fatalf("orders = %+v, want one QQQ buy", plan.Orders)
+	}
+	order := plan.Orders[0]
+	if order.Ticker != "QQQ" || order.Side != "buy" || order.Quantity != "6" || order.Amount != "300.00" {
+		t.Errorf("order = %+v, want buy 6 QQQ for 300.00", order)
+	}
+	// VOO is inside its band and 49 left after the QQQ buy cannot buy a whole share.
+	if plan.CashAfter != "199.00" || plan.EstimatedFees != "1.00" {
+		t.Errorf("cash after = %s, fees = %s; want 199.00 and 1.00", plan.CashAfter, plan.EstimatedFees)
+	}
+}
+
+func TestPlanRebalance_SellsOverweightToFundUnderweight(t *testing.T) {
+	t.Parallel()
+
+	allocation := rebalanceAllocation(true,
+		models.AllocationTarget{Key: "VOO", Weight: "0.5", AssetType: "etf"},
+		models.AllocationTarget{Key: "BND", Weight: "0.5", AssetType: "etf"},
+	)
+	holdings := []models.Holding{
+		{Ticker: "VOO", AssetType: "etf", Quantity: "8", MarketValue: "800"},
+		{Ticker: "BND", AssetType: "etf", Quantity: "2", MarketValue: "200"},
+	}
+
+	plan := planRebalance(rebalanceInput{allocation: allocation, holdings: holdings, cash: decimal.Zero, allowSells: true, commission: flatCommission})
+	if len(plan.Orders) != 2 {
+		t.Fatalf("orders = %+v, want a sell and a buy", plan.Orders)
+	}
+	if o := plan.Orders[0]; o.Ticker != "VOO" || o.Side != "sell" || o.Quantity != "3" {
+		t.Errorf("first order = %+v, want sell 3 VOO", o)
+	}
+	// 299 left after the sell fee; the buy is shrunk so amount plus fee fits.
+	if o := plan.Orders[1]; o.Ticker != "BND" || o.Side != "buy" || o.Quantity != "2.98" {
+		t.Errorf("second order = %+v, want buy 2.98 BND", o)
+	}
+	if plan.CashAfter != "0.00" {
+		t.Errorf("cash after = %s, want 0.00", plan.CashAfter)
+	}
+
+	cashOnly := planRebalance(rebalanceInput{allocation: allocation, holdings: holdings, cash: decimal.Zero, allowSells: false, commission: flatCommission})
+	if len(cashOnly.Orders) != 0 {
+		t.Errorf("cash-only orders = %+v, want none without cash", cashOnly.Orders)
+	}
+	if len(cashOnly.Warnings) != 2 || !strings.Contains(cashOnly.Warnings[0], "sells are disabled") {
+		t.Errorf("cash-only warnings = %v, want sells disabled and not enough cash", cashOnly.Warnings)
+	}
+}
+
+func TestPlanRebalance_WithinBandsAndUntargetedHoldings(t *testing.T) {
+	t.Parallel()
+
+	allocation := rebalanceAllocation(false,
+		models.AllocationTarget{Key: "VOO", Weight: "0.9", AssetType: "etf"},
+		models.AllocationTarget{Key: "CASH", Weight: "0.1"},
+	)
+
+	inBand := planRebalance(rebalanceInput{
+		allocation: allocation,
+		holdings:   []models.Holding{{Ticker: "VOO", AssetType: "etf", Quantity: "9", MarketValue: "920"}},
+		cash:       dec("80"),
+		allowSells: true,
+	})
+	if inBand.NeedsRebalance || len(inBand.Orders) != 0 {
+		t.Errorf("plan = %+v, want no orders inside the bands", inBand)
+	}
+
+	untargeted := planRebalance(rebalanceInput{
+		allocation: allocation,
+		holdings: []models.Holding{
+			{Ticker: "VOO", AssetType: "etf", Quantity: "9", MarketValue: "900"},
+			{Ticker: "TSLA", AssetType: "stock", Quantity: "1.5", MarketValue: "300"},
+		},
+		cash:       decimal.Zero,
+		allowSells: true,
+	})
+	if len(untargeted.Orders) == 0 || untargeted.Orders[0].Ticker != "TSLA" || untargeted.Orders[0].Quantity != "1.5" {
+		t.Errorf("orders = %+v, want the whole untargeted TSLA position sold first", untargeted.Orders)
+	}
+}
+
+func TestPlanRebalance_AssetTypeBasisSplitsAcrossHoldings(t *testing.T) {
+	t.Parallel()
+
+	plan := planRebalance(rebalanceInput{
+		allocation: models.TargetAllocation{Basis: "asset_type", DriftBand: "0.05", FractionalShares: true, Targets: []models.AllocationTarget{
+			{Key: "etf", Weight: "0.8"},
+			{Key: "crypto", Weight: "0.2"},
+		}},
+		holdings: []models.Holding{
+			{Ticker: "VOO", AssetType: "etf", Quantity: "3", MarketValue: "300"},
+			{Ticker: "QQQ", AssetType: "etf", Quantity: "1", MarketValue: "100"},
+			{Ticker: "BTC/USD", AssetType: "crypto", Quantity: "0.01", MarketValue: "100"},
+		},
+		cash:       dec("500"),
+		allowSells: false,
+	})
+
+	// 1000 total: etf needs 400 more, split 3:1 by value; crypto needs 100.
+	want := map[string]string{"VOO": "300.00", "QQQ": "100.00", "BTC/USD": "100.00"}
+	if len(plan.Orders) != len(want) {
+		t.Fatalf("orders = %+v, want %d buys", plan.Orders, len(want))
+	}
+	for _, o := range plan.Orders {
+		if o.Side != "buy" || o.Amount != want[o.Ticker] {
+			t.Errorf("order = %+v, want buy %s", o, want[o.Ticker])
+		}
+	}
+}
+
+func TestNormalizeTargetAllocation(t *testing.T) {
+	t.Parallel()
+
+	got, err := normalizeTargetAllocation(models.SetTargetAllocationRequest{Targets: []models.AllocationTarget{
+		{Key: " voo ", Weight: "0.70"},
+		{Key: "qqq", Weight: "0.2", AssetType: "ETF"},
+		{Key: "cash", Weight: "0.1"},
+	}})
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if got.Basis != "ticker" || got.DriftBand != "0.05" || !got.FractionalShares {
+		t.Errorf("defaults = %+v, want ticker basis, 0.05 band and fractional shares", got)
+	}
+	if got.Targets[0].Key != "VOO" || got.Targets[0].AssetType != "stock" || got.Targets[1].AssetType != "etf" || got.Targets[2].Key != "CASH" {
+		t.Errorf("targets = %+v", got.Targets)
+	}
+
+	invalid := []models.SetTargetAllocationRequest{
+		{Targets: []models.AllocationTarget{{Key: "VOO", Weight: "0.5"}}},
+		{Targets: []models.AllocationTarget{{Key: "VOO", Weight: "0.5"}, {Key: "voo", Weight: "0.5"}}},
+		{Basis: "sector", Targets: []models.AllocationTarget{{Key: "Tech", Weight: "1"}}},
+		{Basis: "asset_type", Targets: []models.AllocationTarget{{Key: "bonds", Weight: "1"}}},
+		{DriftBand: "1.5", Targets: []models.AllocationTarget{{Key: "VOO", Weight: "1"}}},
+		{Targets: nil},
+	}
+	for i, req := range invalid {
+		if _, err := normalizeTargetAllocation(req); !errors.Is(err, ErrInvalidTargetAllocation) {
+			t.Errorf("case %d: err = %v, want ErrInvalidTargetAllocation", i, err)
+		}
+	}
+}
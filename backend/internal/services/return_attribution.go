@@ -6,10 +6,14 @@ import (
 
 	"github.com/shopspring/decimal"
 	"fintu-tracking-backend/internal/models"
+	"fintu-tracking-backend/internal/telemetry"
 )
 
 // CalculateReturnAttribution decomposes portfolio returns into components
 func (s *AnalyticsService) CalculateReturnAttribution(ctx context.Context, userID string) (models.ReturnAttribution, error) {
+	ctx, span := telemetry.StartSpan(ctx, "AnalyticsService.CalculateReturnAttribution")
+	defer span.End()
+
 	attribution := models.ReturnAttribution{
 		StartingCapital:    "0",
 		MarketGains:        "0",
@@ -26,7 +30,7 @@ func (s *AnalyticsService) CalculateReturnAttribution(ctx context.Context, userI
 	}
 
 	var startingCapitalStr string
-	err := s.pool.QueryRow(ctx, netInvestedSQL(), userID).Scan(&startingCapitalStr)
+	err := s.pool.QueryRow(ctx, netInvestedSQL(), userID, s.portfolioArg()).Scan(&startingCapitalStr)
 	if err != nil {
 		return attribution, fmt.Errorf("failed to calculate total invested: %w", err)
 	}
@@ -44,8 +48,8 @@ func (s *AnalyticsService) CalculateReturnAttribution(ctx context.Context, userI
 			COALESCE(SUM(CASE WHEN fee_type = 'closing' THEN usd_amount ELSE 0 END), 0) as closing_fees,
 			COALESCE(SUM(usd_amount), 0) as total_fees
 		FROM cash_flows
-		WHERE user_id = $1 AND type = 'fee'
-	`, userID).Scan(
+		WHERE user_id = $1 AND type = 'fee' AND `+portfolioScopeSQL("portfolio_id", 2)+`
+	`, userID, s.portfolioArg()).Scan(
 		&attribution.DepositFeesImpact,
 		&attribution.TradingFeesImpact,
 		&attribution.ClosingFeesImpact,
@@ -57,7 +61,7 @@ func (s *AnalyticsService) CalculateReturnAttribution(ctx context.Context, userI
 
 	totalFees, _ := decimal.NewFromString(attribution.TotalFeesImpact)
 
-	rows, err := s.pool.Query(ctx, returnAttributionHoldingsSQL(), userID)
+	rows, err := s.pool.Query(ctx, returnAttributionHoldingsSQL(), userID, s.portfolioArg())
 	if err != nil {
 		return attribution, fmt.Errorf("failed to query holdings: %w", err)
 	}
@@ -88,13 +92,13 @@ func (s *AnalyticsService) CalculateReturnAttribution(ctx context.Context, userI
 	}
 
 	var cashFlowsBalance string
-	err = s.pool.QueryRow(ctx, cashFlowsBalanceSQL(), userID).Scan(&cashFlowsBalance)
+	err = s.pool.QueryRow(ctx, cashFlowsBalanceSQL(), userID, s.portfolioArg()).Scan(&cashFlowsBalance)
 	if err != nil {
 		cashFlowsBalance = "0"
 	}
 
 	var tradeCosts string
-	if err := s.pool.QueryRow(ctx, netTradeCashFlowSQL(), userID).Scan(&tradeCosts); err != nil {
+	if err := s.pool.QueryRow(ctx, netTradeCashFlowSQL(), userID, s.portfolioArg()).Scan(&tradeCosts); err != nil {
 		tradeCosts = "0"
 	}
 
@@ -0,0 +1,494 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/models"
+	"fintu-tracking-backend/internal/telemetry"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrInvalidRiskOptions is returned for an out-of-range risk-free rate or VaR confidence.
+var ErrInvalidRiskOptions = apperror.New(apperror.Validation, "invalid risk options")
+
+// RiskOptions configures GetRiskMetrics; start from DefaultRiskOptions.
+type RiskOptions struct {
+	RiskFreeRate  decimal.Decimal // annual, decimal fraction (0.04 = 4%)
+	VaRConfidence decimal.Decimal // e.g. 0.95
+	AsOf          time.Time
+}
+
+// DefaultRiskOptions returns the configured risk-free rate and VaR confidence as of now.
+func DefaultRiskOptions() RiskOptions {
+	return RiskOptions{
+		RiskFreeRate:  decimal.RequireFromString(config.DefaultRiskFreeRate),
+		VaRConfidence: decimal.RequireFromString(config.DefaultVaRConfidence),
+		AsOf:          time.Now(),
+	}
+}
+
+type datedReturn struct {
+	date time.Time
+	r    float64
+}
+
+type dailyValuation struct {
+	date  time.Time
+	value decimal.Decimal
+	flow  decimal.Decimal // external cash moved in (+) or out (-) that day
+}
+
+// GetRiskMetrics computes volatility, drawdown, Sharpe/Sortino, beta and
+// correlation against the benchmark, and one-day VaR from daily valuations of
+// the portfolio, and from daily closes for each open holding.
+func (s *AnalyticsService) GetRiskMetrics(ctx context.Context, userID string, opts RiskOptions) (*models.RiskReport, error) {
+	ctx, span := telemetry.StartSpan(ctx, "AnalyticsService.GetRiskMetrics")
+	defer span.End()
+
+	if err := validateRiskOptions(opts); err != nil {
+		return nil, err
+	}
+
+	report := &models.RiskReport{
+		AsOf:            opts.AsOf,
+		Benchmark:       config.DefaultBenchmarkTicker,
+		RiskFreeRatePct: opts.RiskFreeRate.Mul(decimal.NewFromInt(100)).String(),
+		VaRConfidence:   opts.VaRConfidence.String(),
+		Portfolio:       emptyRiskMetrics(),
+		Holdings:        []models.HoldingRiskMetrics{},
+	}
+
+	activity, err := s.loadPerformanceActivity(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	eventDates := activity.collectEventDates()
+	if len(eventDates) == 0 {
+		return report, nil
+	}
+
+	tickers := []string{config.DefaultBenchmarkTicker}
+	for _, tr := range activity.Trades {
+		tickers = appendUniqueTicker(tickers, tr.Ticker)
+	}
+	closes, err := NewPostgresMarketDataStore(s.pool).GetDailyPrices(ctx, tickers, eventDates[0])
+	if err != nil {
+		return nil, err
+	}
+
+	rf, _ := opts.RiskFreeRate.Float64()
+	confidence, _ := opts.VaRConfidence.Float64()
+	byTicker := groupDailyPrices(closes)
+	benchmark := priceReturns(byTicker[config.DefaultBenchmarkTicker])
+
+	valuations, positions := buildDailyValuations(activity, closes, opts.AsOf)
+	if n := len(valuations); n > 0 {
+		report.Portfolio = computeRiskMetrics(valuationReturns(valuations), benchmark, rf, confidence, valuations[n-1].value)
+	}
+
+	for _, ticker := range sortedTickers(positions) {
+		pos := positions[ticker]
+		series := byTicker[ticker]
+		price := pos.price
+		if len(series) > 0 {
+			price = series[len(series)-1].close
+		}
+		marketValue := pos.qty.Mul(price)
+
+		since := pos.openedAt
+		held := make([]tickerClose, 0, len(series))
+		for _, c := range series {
+			if !c.date.Before(since) {
+				held = append(held, c)
+			}
+		}
+		report.Holdings = append(report.Holdings, models.HoldingRiskMetrics{
+			Ticker:      ticker,
+			MarketValue: marketValue.String(),
+			RiskMetrics: computeRiskMetrics(priceReturns(held), benchmark, rf, confidence, marketValue),
+		})
+	}
+
+	return report, nil
+}
+
+type tickerClose struct {
+	date  time.Time
+	close decimal.Decimal
+}
+
+func groupDailyPrices(prices []models.DailyPrice) map[string][]tickerClose {
+	out := make(map[string][]tickerClose)
+	for _, p := range prices {
+		c, err := decimal.NewFromString(p.Close)
+		if err != nil || !c.IsPositive() {
+			continue
+		}
+		out[p.Ticker] = append(out[p.Ticker], tickerClose{date: truncateToUTCDate(p.Date), close: c})
+	}
+	for ticker := range out {
+		series := out[ticker]
+		sort.Slice(series, func(i, j int) bool { return series[i].date.Before(series[j].date) })
+	}
+	return out
+}
+
+// priceReturns turns consecutive closes into simple daily returns, each keyed by
+// the later close's date.
+func priceReturns(series []tickerClose) []datedReturn {
+	if len(series) < 2 {
+		return nil
+	}
+	out := make([]datedReturn, 0, len(series)-1)
+	for i := 1; i < len(series); i++ {
+		prev, _ := series[i-1].close.Float64()
+		cur, _ := series[i].close.Float64()
+		if prev <= 0 {
+			continue
+		}
+		out = append(out, datedReturn{date: series[i].date, r: cur/prev - 1})
+	}
+	return out
+}
+
+type riskPosition struct {
+	qty      decimal.Decimal
+	price    decimal.Decimal // last trade price, used when no daily close exists
+	openedAt time.Time
+}
+
+// buildDailyValuations values the portfolio (cash plus holdings at the latest
+// close on or before each day) on every trading day with a stored close and
+// every activity day, from the first activity through asOf. It also returns the
+// positions still open at the end.
+func buildDailyValuations(activity performanceActivity, closes []models.DailyPrice, asOf time.Time) ([]dailyValuation, map[string]*riskPosition) {
+	asOf = truncateToUTCDate(asOf)
+	positions := make(map[string]*riskPosition)
+
+	eventDates := activity.collectEventDates()
+	if len(eventDates) == 0 {
+		return nil, positions
+	}
+	start := eventDates[0]
+
+	seen := make(map[time.Time]bool)
+	var dates []time.Time
+	addDate := func(d time.Time) {
+		d = truncateToUTCDate(d)
+		if d.Before(start) || d.After(asOf) || seen[d] {
+			return
+		}
+		seen[d] = true
+		dates = append(dates, d)
+	}
+	for _, d := range eventDates {
+		addDate(d)
+	}
+	for _, p := range closes {
+		addDate(p.Date)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	cashFlows := append([]performanceCashFlow(nil), activity.CashFlows...)
+	sort.SliceStable(cashFlows, func(i, j int) bool { return cashFlows[i].Date.Before(cashFlows[j].Date) })
+	trades := append([]performanceTrade(nil), activity.Trades...)
+	sort.SliceStable(trades, func(i, j int) bool { return trades[i].Date.Before(trades[j].Date) })
+	sortedCloses := append([]models.DailyPrice(nil), closes...)
+	sort.SliceStable(sortedCloses, func(i, j int) bool { return sortedCloses[i].Date.Before(sortedCloses[j].Date) })
+
+	lastClose := make(map[string]decimal.Decimal)
+	cash := decimal.Zero
+	var cfIdx, trIdx, pxIdx int
+
+	out := make([]dailyValuation, 0, len(dates))
+	for _, d := range dates {
+		flow := decimal.Zero
+
+		for ; cfIdx < len(cashFlows) && !truncateToUTCDate(cashFlows[cfIdx].Date).After(d); cfIdx++ {
+			cf := cashFlows[cfIdx]
+			switch cf.Type {
+			case "deposit", "transfer_in", "cash_adjustment":
+				cash = cash.Add(cf.USDAmount)
+				flow = flow.Add(cf.USDAmount)
+			case "withdrawal", "transfer_out":
+				cash = cash.Sub(cf.USDAmount)
+				flow = flow.Sub(cf.USDAmount)
+			case "fee":
+				if cf.RelatedTradeID == nil && cf.RelatedCashFlowID == nil {
+					cash = cash.Sub(cf.USDAmount)
+				}
+			}
+		}
+
+		for ; trIdx < len(trades) && !truncateToUTCDate(trades[trIdx].Date).After(d); trIdx++ {
+			tr := trades[trIdx]
+			pos, ok := positions[tr.Ticker]
+			if !ok || !pos.qty.IsPositive() {
+				pos = &riskPosition{openedAt: truncateToUTCDate(tr.Date)}
+				positions[tr.Ticker] = pos
+			}
+			notional := tr.Quantity.Mul(tr.Price)
+			pos.price = tr.Price
+			switch tr.Side {
+			case "buy":
+				pos.qty = pos.qty.Add(tr.Quantity)
+				if tr.IsOpeningPosition {
+					// Shares brought in from elsewhere are a contribution, not a gain.
+					flow = flow.Add(notional)
+				} else {
+					cash = cash.Sub(notional.Add(tr.TotalFees))
+				}
+			case "sell":
+				pos.qty = pos.qty.Sub(tr.Quantity)
+				cash = cash.Add(notional.Sub(tr.TotalFees))
+			}
+		}
+
+		for ; pxIdx < len(sortedCloses) && !truncateToUTCDate(sortedCloses[pxIdx].Date).After(d); pxIdx++ {
+			if c, err := decimal.NewFromString(sortedCloses[pxIdx].Close); err == nil && c.IsPositive() {
+				lastClose[sortedCloses[pxIdx].Ticker] = c
+			}
+		}
+
+		value := cash
+		for ticker, pos := range positions {
+			if !pos.qty.IsPositive() {
+				continue
+			}
+			price := pos.price
+			if c, ok := lastClose[ticker]; ok {
+				price = c
+			}
+			value = value.Add(pos.qty.Mul(price))
+		}
+		out = append(out, dailyValuation{date: d, value: value, flow: flow})
+	}
+
+	for ticker, pos := range positions {
+		if !pos.qty.IsPositive() {
+			delete(positions, ticker)
+		}
+	}
+	return out, positions
+}
+
+// valuationReturns strips external flows out of day-over-day value changes so
+// deposits and withdrawals do not show up as gains or losses.
+func valuationReturns(valuations []dailyValuation) []datedReturn {
+	out := make([]datedReturn, 0, len(valuations))
+	for i := 1; i < len(valuations); i++ {
+		prev, _ := valuations[i-1].value.Float64()
+		if prev <= 0 {
+			continue
+		}
+		cur, _ := valuations[i].value.Sub(valuations[i].flow).Float64()
+		out = append(out, datedReturn{date: valuations[i].date, r: cur/prev - 1})
+	}
+	return out
+}
+
+func emptyRiskMetrics() models.RiskMetrics {
+	return models.RiskMetrics{
+		AnnualizedReturnPct:     "0",
+		AnnualizedVolatilityPct: "0",
+		MaxDrawdownPct:          "0",
+		SharpeRatio:             "0",
+		SortinoRatio:            "0",
+		Beta:                    "0",
+		Correlation:             "0",
+		ValueAtRiskPct:          "0",
+		ValueAtRiskUSD:          "0",
+	}
+}
+
+// computeRiskMetrics derives the risk suite from daily returns. rf is the annual
+// risk-free rate and currentValue scales VaR into USD. Fewer than two returns
+// yields zeros.
+func computeRiskMetrics(returns, benchmark []datedReturn, rf, confidence float64, currentValue decimal.Decimal) models.RiskMetrics {
+	m := emptyRiskMetrics()
+	m.Observations = len(returns)
+	if len(returns) < 2 {
+		return m
+	}
+
+	days := float64(config.TradingDaysPerYear)
+	values := make([]float64, len(returns))
+	for i, r := range returns {
+		values[i] = r.r
+	}
+
+	mean := meanOf(values)
+	sd := sampleStdDev(values, mean)
+	dailyRF := rf / days
+
+	growth := 1.0
+	for _, r := range values {
+		growth *= 1 + r
+	}
+	m.AnnualizedReturnPct = formatRiskPct(math.Pow(growth, days/float64(len(values))) - 1)
+	m.AnnualizedVolatilityPct = formatRiskPct(sd * math.Sqrt(days))
+
+	if sd > 0 {
+		m.SharpeRatio = formatRiskRatio((mean - dailyRF) / sd * math.Sqrt(days))
+	}
+	downside := 0.0
+	for _, r := range values {
+		if excess := r - dailyRF; excess < 0 {
+			downside += excess * excess
+		}
+	}
+	if downside > 0 {
+		m.SortinoRatio = formatRiskRatio((mean - dailyRF) / math.Sqrt(downside/float64(len(values))) * math.Sqrt(days))
+	}
+
+	drawdown, peak, trough := maxDrawdown(returns)
+	if drawdown > 0 {
+		m.MaxDrawdownPct = formatRiskPct(drawdown)
+		m.MaxDrawdownPeakDate = &peak
+		m.MaxDrawdownTroughDate = &trough
+	}
+
+	beta, corr := betaAndCorrelation(returns, benchmark)
+	m.Beta = formatRiskRatio(beta)
+	m.Correlation = formatRiskRatio(corr)
+
+	loss := historicalVaR(values, confidence)
+	m.ValueAtRiskPct = formatRiskPct(loss)
+	m.ValueAtRiskUSD = currentValue.Mul(decimal.NewFromFloat(loss)).StringFixed(2)
+	return m
+}
+
+// maxDrawdown returns the largest peak-to-trough fall of the compounded return
+// index as a positive fraction, with the peak and trough dates. The peak can be
+// the day before the first return.
+func maxDrawdown(returns []datedReturn) (float64, time.Time, time.Time) {
+	index, peakIndex := 1.0, 1.0
+	peakDate := returns[0].date.AddDate(0, 0, -1)
+	var worst float64
+	var worstPeak, worstTrough time.Time
+	for _, r := range returns {
+		index *= 1 + r.r
+		if index > peakIndex {
+			peakIndex = index
+			peakDate = r.date
+			continue
+		}
+		if dd := 1 - index/peakIndex; dd > worst {
+			worst = dd
+			worstPeak = peakDate
+			worstTrough = r.date
+		}
+	}
+	return worst, worstPeak, worstTrough
+}
+
+// betaAndCorrelation pairs returns with benchmark returns from the same date.
+func betaAndCorrelation(returns, benchmark []datedReturn) (float64, float64) {
+	byDate := make(map[time.Time]float64, len(benchmark))
+	for _, b := range benchmark {
+		byDate[b.date] = b.r
+	}
+	var xs, ys []float64
+	for _, r := range returns {
+		if b, ok := byDate[r.date]; ok {
+			xs = append(xs, r.r)
+			ys = append(ys, b)
+		}
+	}
+	if len(xs) < 2 {
+		return 0, 0
+	}
+
+	mx, my := meanOf(xs), meanOf(ys)
+	var cov, vx, vy float64
+	for i := range xs {
+		cov += (xs[i] - mx) * (ys[i] - my)
+		vx += (xs[i] - mx) * (xs[i] - mx)
+		vy += (ys[i] - my) * (ys[i] - my)
+	}
+	if vy == 0 {
+		return 0, 0
+	}
+	beta := cov / vy
+	if vx == 0 {
+		return beta, 0
+	}
+	return beta, cov / math.Sqrt(vx*vy)
+}
+
+// historicalVaR is the one-day loss not exceeded with the given confidence,
+// read from the empirical return distribution, as a positive fraction.
+func historicalVaR(values []float64, confidence float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	// The tail holds ceil((1-confidence)*n) observations; VaR is the best of them.
+	// The epsilon keeps 0.05*100 from rounding up to 6.
+	idx := int(math.Ceil((1-confidence)*float64(len(sorted))-1e-9)) - 1
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	if idx < 0 {
+		idx = 0
+	}
+	return math.Max(0, -sorted[idx])
+}
+
+func meanOf(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func sampleStdDev(values []float64, mean float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	var ss float64
+	for _, v := range values {
+		ss += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(ss / float64(len(values)-1))
+}
+
+func formatRiskPct(fraction float64) string {
+	if math.IsNaN(fraction) || math.IsInf(fraction, 0) {
+		return "0"
+	}
+	return decimal.NewFromFloat(fraction * 100).StringFixed(2)
+}
+
+func formatRiskRatio(v float64) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return "0"
+	}
+	return decimal.NewFromFloat(v).StringFixed(4)
+}
+
+func sortedTickers(positions map[string]*riskPosition) []string {
+	tickers := make([]string, 0, len(positions))
+	for ticker := range positions {
+		tickers = append(tickers, ticker)
+	}
+	sort.Strings(tickers)
+	return tickers
+}
+
+// validateRiskOptions rejects rates and confidence levels outside sensible bounds.
+func validateRiskOptions(opts RiskOptions) error {
+	if opts.RiskFreeRate.LessThan(decimal.NewFromInt(-1)) || opts.RiskFreeRate.GreaterThan(decimal.NewFromInt(1)) {
+		return fmt.Errorf("%w: risk_free_rate must be a decimal fraction between -1 and 1", ErrInvalidRiskOptions)
+	}
+	if !opts.VaRConfidence.GreaterThan(decimal.NewFromFloat(0.5)) || !opts.VaRConfidence.LessThan(decimal.NewFromInt(1)) {
+		return fmt.Errorf("%w: confidence must be between 0.5 and 1", ErrInvalidRiskOptions)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"fintu-tracking-backend/internal/models"
+)
+
+func riskDay(day int) time.Time {
+	return time.Date(2024, 3, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestValuationReturns_IgnoresExternalFlows(t *testing.T) {
+	t.Parallel()
+
+	returns := valuationReturns([]dailyValuation{
+		{date: riskDay(1), value: dec("1000")},
+		{date: riskDay(2), value: dec("1510"), flow: dec("500")},
+		{date: riskDay(3), value: dec("1359"), flow: dec("0")},
+	})
+	if len(returns) != 2 {
+		t.Fatalf("len = %d, want 2", len(returns))
+	}
+	if math.Abs(returns[0].r-0.01) > 1e-9 {
+		t.Errorf("day 2 return = %v, want 0.01 (deposit excluded)", returns[0].r)
+	}
+	if math.Abs(returns[1].r+0.1) > 1e-9 {
+		t.Errorf("day 3 return = %v, want -0.10", returns[1].r)
+	}
+}
+
+func TestBuildDailyValuations_UsesDailyCloses(t *testing.T) {
+	t.Parallel()
+
+	activity := performanceActivity{
+		CashFlows: []performanceCashFlow{{Date: riskDay(1), Type: "deposit", USDAmount: dec("1000")}},
+		Trades:    []performanceTrade{{Date: riskDay(1), Side: "buy", Ticker: "VOO", Quantity: dec("2"), Price: dec("400")}},
+	}
+	closes := []models.DailyPrice{
+		{Ticker: "VOO", Date: riskDay(1), Close: "400"},
+		{Ticker: "VOO", Date: riskDay(4), Close: "450"},
+	}
+
+	valuations, positions := buildDailyValuations(activity, closes, riskDay(10))
+	if len(valuations) != 2 {
+		t.Fatalf("len = %d, want 2 (activity day and price day)", len(valuations))
+	}
+	if got := valuations[0].value.String(); got != "1000" {
+		t.Errorf("day 1 value = %s, want 1000", got)
+	}
+	if got := valuations[0].flow.String(); got != "1000" {
+		t.Errorf("day 1 flow = %s, want 1000", got)
+	}
+	if got := valuations[1].value.String(); got != "1100" {
+		t.Errorf("day 4 value = %s, want 1100 (200 cash + 2 x 450)", got)
+	}
+	if pos := positions["VOO"]; pos == nil || pos.qty.String() != "2" {
+		t.Errorf("open VOO position = %+v, want qty 2", pos)
+	}
+}
+
+func TestComputeRiskMetrics_DrawdownVolatilityAndVaR(t *testing.T) {
+	t.Parallel()
+
+	returns := []datedReturn{
+		{date: riskDay(1), r: 0.10},
+		{date: riskDay(2), r: -0.20},
+		{date: riskDay(3), r: 0.05},
+		{date: riskDay(4), r: 0.10},
+	}
+	m := computeRiskMetrics(returns, nil, 0, 0.75, dec("1000"))
+
+	if m.Observations != 4 {
+		t.Errorf("observations = %d, want 4", m.Observations)
+	}
+	if m.MaxDrawdownPct != "20.00" {
+		t.Errorf("max drawdown = %s, want 20.00", m.MaxDrawdownPct)
+	}
+	if m.MaxDrawdownPeakDate == nil || !m.MaxDrawdownPeakDate.Equal(riskDay(1)) {
+		t.Errorf("peak date = %v, want %v", m.MaxDrawdownPeakDate, riskDay(1))
+	}
+	if m.MaxDrawdownTroughDate == nil || !m.MaxDrawdownTroughDate.Equal(riskDay(2)) {
+		t.Errorf("trough date = %v, want %v", m.MaxDrawdownTroughDate, riskDay(2))
+	}
+	// Sample stdev of the returns is 0.14361, annualized by sqrt(252).
+	if m.AnnualizedVolatilityPct != "227.98" {
+		t.Errorf("volatility = %s, want 227.98", m.AnnualizedVolatilityPct)
+	}
+	if m.ValueAtRiskPct != "20.00" || m.ValueAtRiskUSD != "200.00" {
+		t.Errorf("VaR = %s%% / %s USD, want 20.00%% / 200.00", m.ValueAtRiskPct, m.ValueAtRiskUSD)
+	}
+	if m.Beta != "0.0000" || m.Correlation != "0.0000" {
+		t.Errorf("beta/correlation without benchmark = %s/%s, want 0", m.Beta, m.Correlation)
+	}
+}
+
+func TestBetaAndCorrelation_LeveredBenchmark(t *testing.T) {
+	t.Parallel()
+
+	benchmark := []datedReturn{
+		{date: riskDay(1), r: 0.01},
+		{date: riskDay(2), r: -0.02},
+		{date: riskDay(3), r: 0.015},
+	}
+	portfolio := make([]datedReturn, len(benchmark))
+	for i, b := range benchmark {
+		portfolio[i] = datedReturn{date: b.date, r: 2 * b.r}
+	}
+
+	beta, corr := betaAndCorrelation(portfolio, benchmark)
+	if math.Abs(beta-2) > 1e-9 {
+		t.Errorf("beta = %v, want 2", beta)
+	}
+	if math.Abs(corr-1) > 1e-9 {
+		t.Errorf("correlation = %v, want 1", corr)
+	}
+}
+
+func TestComputeRiskMetrics_TooFewReturns(t *testing.T) {
+	t.Parallel()
+
+	m := computeRiskMetrics([]datedReturn{{date: riskDay(1), r: 0.05}}, nil, 0.04, 0.95, dec("100"))
+	if m.Observations != 1 || m.AnnualizedVolatilityPct != "0" || m.MaxDrawdownPeakDate != nil {
+		t.Errorf("metrics = %+v, want zeros for a single observation", m)
+	}
+}
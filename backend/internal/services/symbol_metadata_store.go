@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SymbolMetadataStore reads the shared symbol classification and ETF
+// constituent reference data used by exposure analytics.
+type SymbolMetadataStore interface {
+	GetSymbolMetadata(ctx context.Context, tickers []string) ([]models.SymbolMetadata, error)
+	GetETFConstituents(ctx context.Context, etfTickers []string) ([]models.ETFConstituent, error)
+}
+
+// postgresSymbolMetadataStore implements SymbolMetadataStore on top of pgxpool.Pool.
+type postgresSymbolMetadataStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresSymbolMetadataStore creates a store backed by the given DB pool.
+func NewPostgresSymbolMetadataStore(pool *pgxpool.Pool) SymbolMetadataStore {
+	return &postgresSymbolMetadataStore{pool: pool}
+}
+
+func (s *postgresSymbolMetadataStore) GetSymbolMetadata(ctx context.Context, tickers []string) ([]models.SymbolMetadata, error) {
+	if s.pool == nil || len(tickers) == 0 {
+		return []models.SymbolMetadata{}, nil
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT ticker, name, asset_class, sector, country, region, market_cap, currency
+		FROM symbol_metadata
+		WHERE ticker = ANY($1)
+	`, tickers)
+	if err != nil {
+		return nil, fmt.Errorf("get symbol metadata: %w", err)
+	}
+	metadata, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.SymbolMetadata])
+	if err != nil {
+		return nil, fmt.Errorf("collect symbol metadata: %w", err)
+	}
+	return metadata, nil
+}
+
+func (s *postgresSymbolMetadataStore) GetETFConstituents(ctx context.Context, etfTickers []string) ([]models.ETFConstituent, error) {
+	if s.pool == nil || len(etfTickers) == 0 {
+		return []models.ETFConstituent{}, nil
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT etf_ticker, constituent_ticker, weight::text AS weight
+		FROM etf_constituents
+		WHERE etf_ticker = ANY($1)
+		ORDER BY etf_ticker, weight DESC
+	`, etfTickers)
+	if err != nil {
+		return nil, fmt.Errorf("get etf constituents: %w", err)
+	}
+	constituents, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.ETFConstituent])
+	if err != nil {
+		return nil, fmt.Errorf("collect etf constituents: %w", err)
+	}
+	return constituents, nil
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/models"
+	"fintu-tracking-backend/internal/telemetry"
+)
+
+type timeSeriesResponse struct {
+	Meta struct {
+		Symbol   string `json:"symbol"`
+		Currency string `json:"currency"`
+	} `json:"meta"`
+	Values []struct {
+		Datetime string `json:"datetime"`
+		Close    string `json:"close"`
+	} `json:"values"`
+	Status  string `json:"status"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// FetchDailyPrices returns up to days daily closes for ticker via the
+// /time_series endpoint, oldest first.
+func (s *TwelveDataService) FetchDailyPrices(ctx context.Context, ticker string, days int) (prices []models.DailyPrice, err error) {
+	if s.apiKey == "" {
+		return nil, ErrMarketDataNotConfigured
+	}
+
+	ticker = strings.TrimSpace(strings.ToUpper(ticker))
+	if ticker == "" {
+		return nil, fmt.Errorf("ticker is required")
+	}
+
+	ctx, done := telemetry.StartProviderCall(ctx, twelveDataProvider, "time_series", ticker)
+	defer func() { done(err) }()
+
+	base := s.baseURL
+	if base == "" {
+		base = config.TwelveDataBaseURL
+	}
+
+	apiURL := fmt.Sprintf(
+		"%s/time_series?symbol=%s&interval=1day&outputsize=%d&apikey=%s",
+		strings.TrimRight(base, "/"),
+		url.QueryEscape(ticker),
+		days,
+		url.QueryEscape(s.apiKey),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, providerError(fmt.Errorf("http request failed: %w", err))
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, providerError(fmt.Errorf("failed to read response: %w", err))
+	}
+
+	var result timeSeriesResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, providerError(fmt.Errorf("failed to decode response: %w", err))
+	}
+
+	apiErr := quoteResponse{Status: result.Status, Code: result.Code, Message: result.Message}
+	if resp.StatusCode == http.StatusTooManyRequests || (apiErr.isError() && apiErr.Code == http.StatusTooManyRequests) {
+		return nil, fmt.Errorf("%w: %s", ErrProviderRateLimited, apiErr.errorMessage())
+	}
+	if apiErr.isError() {
+		return nil, fmt.Errorf("%w: %s", ErrProviderRejected, apiErr.errorMessage())
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, providerError(fmt.Errorf("API returned HTTP %d", resp.StatusCode))
+	}
+
+	currency := strings.TrimSpace(result.Meta.Currency)
+	if currency == "" {
+		currency = config.DefaultMarketCurrency
+	}
+
+	// Twelve Data returns newest first.
+	prices = make([]models.DailyPrice, 0, len(result.Values))
+	for i := len(result.Values) - 1; i >= 0; i-- {
+		v := result.Values[i]
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(v.Datetime))
+		if err != nil {
+			continue
+		}
+		closePrice := strings.TrimSpace(v.Close)
+		if closePrice == "" {
+			continue
+		}
+		prices = append(prices, models.DailyPrice{Ticker: ticker, Date: date, Close: closePrice, Currency: currency})
+	}
+	return prices, nil
+}
+
+// BackfillDailyPrices loads config.DailyPriceHistoryDays of daily closes for the
+// user's held tickers, the default benchmark and extraTickers (e.g. benchmark
+// components), so risk metrics and benchmark charts have a history to work
+// with. It shares the market price refresh cooldown.
+func (s *TwelveDataService) BackfillDailyPrices(ctx context.Context, userID string, extraTickers []string) (RefreshResult, error) {
+	ctx, span := telemetry.StartSpan(ctx, "TwelveDataService.BackfillDailyPrices")
+	defer span.End()
+
+	result := RefreshResult{
+		Tickers: []string{},
+		Errors:  []string{},
+	}
+
+	if err := s.checkCooldown(ctx, userID); err != nil {
+		return result, err
+	}
+
+	tickers, err := s.store.ListHeldTickers(ctx, userID)
+	if err != nil {
+		return result, err
+	}
+	tickers = appendUniqueTicker(tickers, config.DefaultBenchmarkTicker)
+	for _, ticker := range extraTickers {
+		tickers = appendUniqueTicker(tickers, ticker)
+	}
+
+	for i, ticker := range tickers {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return result, ctx.Err()
+			case <-time.After(500 * time.Millisecond):
+			}
+		}
+
+		prices, fetchErr := s.FetchDailyPrices(ctx, ticker, config.DailyPriceHistoryDays)
+		if fetchErr != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", ticker, apperror.Message(fetchErr)))
+			if errors.Is(fetchErr, ErrProviderRateLimited) {
+				return result, fetchErr
+			}
+			continue
+		}
+
+		if upsertErr := s.store.UpsertDailyPrices(ctx, prices); upsertErr != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", ticker, apperror.Message(upsertErr)))
+			continue
+		}
+
+		result.Updated++
+		result.Tickers = append(result.Tickers, ticker)
+	}
+
+	if recordErr := s.store.RecordMarketPriceRefresh(ctx, userID); recordErr != nil {
+		return result, fmt.Errorf("record refresh: %w", recordErr)
+	}
+
+	return result, nil
+}
+
+func appendUniqueTicker(tickers []string, ticker string) []string {
+	for _, t := range tickers {
+		if t == ticker {
+			return tickers
+		}
+	}
+	return append(tickers, ticker)
+}
@@ -3,19 +3,25 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"os"
 	"strings"
 	"time"
 
+	"fintu-tracking-backend/internal/apperror"
 	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/models"
+	"fintu-tracking-backend/internal/telemetry"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// twelveDataProvider labels Twelve Data calls in metrics and traces.
+const twelveDataProvider = "twelve_data"
+
 // RefreshResult summarizes a market price refresh run.
 type RefreshResult struct {
 	Updated int      `json:"updated"`
@@ -32,12 +38,14 @@ type TwelveDataService struct {
 	baseURL    string
 }
 
-// NewTwelveDataService creates a service backed by the given DB pool.
-func NewTwelveDataService(pool *pgxpool.Pool) *TwelveDataService {
+// NewTwelveDataService creates a service backed by the given DB pool. An empty
+// apiKey leaves the service unconfigured; quote fetches then fail with
+// ErrMarketDataNotConfigured.
+func NewTwelveDataService(pool *pgxpool.Pool, apiKey string) *TwelveDataService {
 	return &TwelveDataService{
-		apiKey:     os.Getenv("TWELVE_DATA_API_KEY"),
+		apiKey:     apiKey,
 		store:      NewPostgresMarketDataStore(pool),
-		httpClient: &http.Client{Timeout: 15 * time.Second},
+		httpClient: telemetry.NewHTTPClient(15 * time.Second),
 		baseURL:    config.TwelveDataBaseURL,
 	}
 }
@@ -69,7 +77,7 @@ func (r *quoteResponse) errorMessage() string {
 // FetchQuote returns the latest price, trading day, and currency via the /quote endpoint.
 func (s *TwelveDataService) FetchQuote(ctx context.Context, ticker string) (price, latestDay, currency string, err error) {
 	if s.apiKey == "" {
-		return "", "", "", fmt.Errorf("TWELVE_DATA_API_KEY environment variable is not set")
+		return "", "", "", ErrMarketDataNotConfigured
 	}
 
 	ticker = strings.TrimSpace(strings.ToUpper(ticker))
@@ -77,6 +85,9 @@ func (s *TwelveDataService) FetchQuote(ctx context.Context, ticker string) (pric
 		return "", "", "", fmt.Errorf("ticker is required")
 	}
 
+	ctx, done := telemetry.StartProviderCall(ctx, twelveDataProvider, "quote", ticker)
+	defer func() { done(err) }()
+
 	base := s.baseURL
 	if base == "" {
 		base = config.TwelveDataBaseURL
@@ -96,38 +107,38 @@ func (s *TwelveDataService) FetchQuote(ctx context.Context, ticker string) (pric
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return "", "", "", fmt.Errorf("http request failed: %w", err)
+		return "", "", "", providerError(fmt.Errorf("http request failed: %w", err))
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", "", "", fmt.Errorf("failed to read response: %w", err)
+		return "", "", "", providerError(fmt.Errorf("failed to read response: %w", err))
 	}
 
 	var result quoteResponse
 	if err := json.Unmarshal(body, &result); err != nil {
-		return "", "", "", fmt.Errorf("failed to decode response: %w", err)
+		return "", "", "", providerError(fmt.Errorf("failed to decode response: %w", err))
 	}
 
 	if resp.StatusCode == http.StatusTooManyRequests {
-		return "", "", "", fmt.Errorf("twelve data rate limit: %s", result.errorMessage())
+		return "", "", "", fmt.Errorf("%w: %s", ErrProviderRateLimited, result.errorMessage())
 	}
 
 	if result.isError() {
 		if result.Code == http.StatusTooManyRequests {
-			return "", "", "", fmt.Errorf("twelve data rate limit: %s", result.errorMessage())
+			return "", "", "", fmt.Errorf("%w: %s", ErrProviderRateLimited, result.errorMessage())
 		}
-		return "", "", "", fmt.Errorf("twelve data API error: %s", result.errorMessage())
+		return "", "", "", fmt.Errorf("%w: %s", ErrProviderRejected, result.errorMessage())
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", "", "", fmt.Errorf("API returned HTTP %d", resp.StatusCode)
+		return "", "", "", providerError(fmt.Errorf("API returned HTTP %d", resp.StatusCode))
 	}
 
 	price = strings.TrimSpace(result.Close)
 	if price == "" {
-		return "", "", "", fmt.Errorf("missing close price in quote for %s", ticker)
+		return "", "", "", providerError(fmt.Errorf("missing close price in quote for %s", ticker))
 	}
 
 	latestDay = strings.TrimSpace(result.Datetime)
@@ -142,6 +153,9 @@ func (s *TwelveDataService) FetchQuote(ctx context.Context, ticker string) (pric
 // RefreshMarketPrices fetches quotes for held tickers whose cached prices are stale or
 // missing, then upserts market_prices. Tickers with fresh cached prices are skipped.
 func (s *TwelveDataService) RefreshMarketPrices(ctx context.Context, userID string) (RefreshResult, error) {
+	ctx, span := telemetry.StartSpan(ctx, "TwelveDataService.RefreshMarketPrices")
+	defer span.End()
+
 	result := RefreshResult{
 		Tickers: []string{},
 		Errors:  []string{},
@@ -174,20 +188,28 @@ func (s *TwelveDataService) RefreshMarketPrices(ctx context.Context, userID stri
 			}
 		}
 
-		price, _, currency, fetchErr := s.FetchQuote(ctx, ticker)
+		price, latestDay, currency, fetchErr := s.FetchQuote(ctx, ticker)
 		if fetchErr != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", ticker, fetchErr))
-			if strings.Contains(strings.ToLower(fetchErr.Error()), "rate limit") {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", ticker, apperror.Message(fetchErr)))
+			if errors.Is(fetchErr, ErrProviderRateLimited) {
 				return result, fetchErr
 			}
 			continue
 		}
 
 		if upsertErr := s.store.UpsertMarketPrice(ctx, ticker, price, currency); upsertErr != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", ticker, upsertErr))
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", ticker, apperror.Message(upsertErr)))
 			continue
 		}
 
+		// Keep the quote's close as that day's entry in the daily price history.
+		if day, parseErr := time.Parse("2006-01-02", latestDay); parseErr == nil {
+			daily := models.DailyPrice{Ticker: ticker, Date: day, Close: price, Currency: currency}
+			if dailyErr := s.store.UpsertDailyPrices(ctx, []models.DailyPrice{daily}); dailyErr != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", ticker, apperror.Message(dailyErr)))
+			}
+		}
+
 		result.Updated++
 		result.Tickers = append(result.Tickers, ticker)
 	}
@@ -234,6 +256,7 @@ func (s *TwelveDataService) listStaleTickers(ctx context.Context, tickers []stri
 
 	stale := make([]string, 0, len(tickers))
 	for _, ticker := range tickers {
+		telemetry.RecordCacheLookup(marketPricesCache, fresh[ticker])
 		if !fresh[ticker] {
 			stale = append(stale, ticker)
 		}
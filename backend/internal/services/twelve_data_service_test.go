@@ -107,11 +107,10 @@ func TestFetchQuote_missingAPIKey(t *testing.T) {
 	}
 }
 
-func TestNewTwelveDataService_readsEnvKey(t *testing.T) {
-	t.Setenv("TWELVE_DATA_API_KEY", "from-env")
-	svc := NewTwelveDataService(nil)
-	if svc.apiKey != "from-env" {
-		t.Errorf("apiKey = %q, want from-env", svc.apiKey)
+func TestNewTwelveDataService_usesConfiguredKey(t *testing.T) {
+	svc := NewTwelveDataService(nil, "configured-key")
+	if svc.apiKey != "configured-key" {
+		t.Errorf("apiKey = %q, want configured-key", svc.apiKey)
 	}
 }
 
@@ -285,3 +284,48 @@ func TestRefreshMarketPrices_propagatesRateLimit(t *testing.T) {
 	}
 }
 
+func TestFetchDailyPrices_returnsOldestFirst(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/time_series" || r.URL.Query().Get("interval") != "1day" {
+			t.Errorf("unexpected request: %s", r.URL.String())
+		}
+		_, _ = w.Write([]byte(`{"meta":{"symbol":"SPY","currency":"USD"},"values":[
+			{"datetime":"2026-06-26","close":"550.10"},
+			{"datetime":"2026-06-25","close":"548.00"}
+		],"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	svc := &TwelveDataService{apiKey: "test-key", httpClient: server.Client(), baseURL: server.URL}
+	prices, err := svc.FetchDailyPrices(context.Background(), "spy", 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(prices) != 2 {
+		t.Fatalf("len = %d, want 2", len(prices))
+	}
+	if prices[0].Date.Format("2006-01-02") != "2026-06-25" || prices[1].Close != "550.10" {
+		t.Errorf("prices = %+v, want 2026-06-25 first and 550.10 last", prices)
+	}
+	if prices[0].Ticker != "SPY" {
+		t.Errorf("ticker = %q, want SPY", prices[0].Ticker)
+	}
+}
+
+func TestRefreshMarketPrices_recordsDailyClose(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"symbol":"AAPL","currency":"USD","datetime":"2026-06-27","close":"181.00"}`))
+	}))
+	defer server.Close()
+
+	store := newFakeMarketDataStore()
+	store.heldTickers = []string{"AAPL"}
+
+	svc := &TwelveDataService{store: store, apiKey: "test-key", httpClient: server.Client(), baseURL: server.URL}
+	if _, err := svc.RefreshMarketPrices(context.Background(), "user-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(store.dailyPrices) != 1 || store.dailyPrices[0].Close != "181.00" || store.dailyPrices[0].Date.Format("2006-01-02") != "2026-06-27" {
+		t.Errorf("daily prices = %+v, want one AAPL close for 2026-06-27", store.dailyPrices)
+	}
+}
@@ -0,0 +1,411 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrWebhookEndpointNotFound = apperror.New(apperror.NotFound, "webhook endpoint not found")
+	ErrInvalidWebhookEndpoint  = apperror.New(apperror.Validation, "invalid webhook endpoint")
+	ErrWebhookEndpointExists   = apperror.New(apperror.Conflict, "a webhook endpoint with that url already exists")
+	ErrWebhookEndpointLimit    = apperror.Newf(apperror.Conflict, "at most %d webhook endpoints are allowed", config.MaxWebhookEndpoints)
+)
+
+const webhookEndpointColumns = `id, url, description, events, active, created_at, updated_at`
+
+const webhookDeliveryColumns = `id, endpoint_id, event_id, event_type, payload, status, attempts,
+	response_status, error, next_attempt_at, last_attempt_at, created_at`
+
+// WebhookService manages user webhook endpoints and delivers events to them.
+// Events are stored as pending deliveries first, so a failed or interrupted
+// attempt is retried by the dispatcher of any API instance. Deliveries only
+// connect to public addresses.
+type WebhookService struct {
+	pool   *pgxpool.Pool
+	client *http.Client
+	wake   chan struct{}
+}
+
+// NewWebhookService creates a WebhookService backed by the given DB pool.
+func NewWebhookService(pool *pgxpool.Pool) *WebhookService {
+	return &WebhookService{
+		pool:   pool,
+		client: newPublicHTTPClient(config.WebhookTimeout),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// ListEndpoints returns the user's webhook endpoints, oldest first.
+func (s *WebhookService) ListEndpoints(ctx context.Context, userID string) ([]models.WebhookEndpoint, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+webhookEndpointColumns+`
+		FROM webhook_endpoints
+		WHERE user_id = $1
+		ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying webhook endpoints: %w", err)
+	}
+	endpoints, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.WebhookEndpoint])
+	if err != nil {
+		return nil, fmt.Errorf("collecting webhook endpoints: %w", err)
+	}
+	return endpoints, nil
+}
+
+// GetEndpoint returns one of the user's webhook endpoints.
+func (s *WebhookService) GetEndpoint(ctx context.Context, userID, endpointID string) (*models.WebhookEndpoint, error) {
+	if _, err := uuid.Parse(endpointID); err != nil {
+		return nil, ErrWebhookEndpointNotFound
+	}
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+webhookEndpointColumns+`
+		FROM webhook_endpoints
+		WHERE id = $1 AND user_id = $2
+	`, endpointID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying webhook endpoint: %w", err)
+	}
+	endpoint, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.WebhookEndpoint])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrWebhookEndpointNotFound
+		}
+		return nil, fmt.Errorf("collecting webhook endpoint: %w", err)
+	}
+	return &endpoint, nil
+}
+
+// CreateEndpoint registers a webhook endpoint with a new signing secret,
+// which is returned only in this response.
+func (s *WebhookService) CreateEndpoint(ctx context.Context, userID string, req models.CreateWebhookEndpointRequest) (*models.WebhookEndpoint, error) {
+	endpoint := &models.WebhookEndpoint{}
+	if req.Events == nil {
+		req.Events = []string{}
+	}
+	if err := applyWebhookEndpointFields(endpoint, models.UpdateWebhookEndpointRequest{
+		URL:         &req.URL,
+		Description: req.Description,
+		Events:      req.Events,
+	}); err != nil {
+		return nil, err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	var count int
+	if err := s.pool.QueryRow(ctx, `SELECT COUNT(*) FROM webhook_endpoints WHERE user_id = $1`, userID).Scan(&count); err != nil {
+		return nil, fmt.Errorf("counting webhook endpoints: %w", err)
+	}
+	if count >= config.MaxWebhookEndpoints {
+		return nil, ErrWebhookEndpointLimit
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		INSERT INTO webhook_endpoints (user_id, url, description, events, secret)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+webhookEndpointColumns,
+		userID, endpoint.URL, endpoint.Description, endpoint.Events, secret)
+	if err != nil {
+		return nil, fmt.Errorf("creating webhook endpoint: %w", err)
+	}
+	created, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.WebhookEndpoint])
+	if err != nil {
+		return nil, mapWebhookEndpointWriteError(err)
+	}
+	created.Secret = secret
+	return &created, nil
+}
+
+// UpdateEndpoint changes an endpoint's url, description, events or active
+// state. Pausing an endpoint stops new events; queued retries still run.
+func (s *WebhookService) UpdateEndpoint(ctx context.Context, userID, endpointID string, req models.UpdateWebhookEndpointRequest) (*models.WebhookEndpoint, error) {
+	endpoint, err := s.GetEndpoint(ctx, userID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyWebhookEndpointFields(endpoint, req); err != nil {
+		return nil, err
+	}
+	if req.Active != nil {
+		endpoint.Active = *req.Active
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		UPDATE webhook_endpoints SET url = $3, description = $4, events = $5, active = $6
+		WHERE id = $1 AND user_id = $2
+		RETURNING `+webhookEndpointColumns,
+		endpointID, userID, endpoint.URL, endpoint.Description, endpoint.Events, endpoint.Active)
+	if err != nil {
+		return nil, fmt.Errorf("updating webhook endpoint: %w", err)
+	}
+	updated, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.WebhookEndpoint])
+	if err != nil {
+		return nil, mapWebhookEndpointWriteError(err)
+	}
+	return &updated, nil
+}
+
+// DeleteEndpoint removes an endpoint and its delivery log.
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, userID, endpointID string) error {
+	if _, err := uuid.Parse(endpointID); err != nil {
+		return ErrWebhookEndpointNotFound
+	}
+	tag, err := s.pool.Exec(ctx, `DELETE FROM webhook_endpoints WHERE id = $1 AND user_id = $2`, endpointID, userID)
+	if err != nil {
+		return fmt.Errorf("deleting webhook endpoint: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrWebhookEndpointNotFound
+	}
+	return nil
+}
+
+// ListDeliveries returns the endpoint's most recent deliveries, newest first.
+func (s *WebhookService) ListDeliveries(ctx context.Context, userID, endpointID string, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := s.GetEndpoint(ctx, userID, endpointID); err != nil {
+		return nil, err
+	}
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+webhookDeliveryColumns+`
+		FROM webhook_deliveries
+		WHERE endpoint_id = $1 AND user_id = $2
+		ORDER BY created_at DESC
+		LIMIT $3
+	`, endpointID, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("querying webhook deliveries: %w", err)
+	}
+	deliveries, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.WebhookDelivery])
+	if err != nil {
+		return nil, fmt.Errorf("collecting webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// Publish queues an event for every active endpoint of the user subscribed
+// to eventType and wakes the dispatcher to send it.
+func (s *WebhookService) Publish(ctx context.Context, userID, eventType string, data any) error {
+	_, err := s.queue(ctx, userID, eventType, data, nil)
+	return err
+}
+
+// queue stores an event as pending deliveries and returns how many endpoints
+// will receive it. With url set, only the user's active endpoint registered
+// with that url receives it, whatever its subscriptions.
+func (s *WebhookService) queue(ctx context.Context, userID, eventType string, data any, url *string) (int64, error) {
+	payload, err := newWebhookPayload(eventType, data)
+	if err != nil {
+		return 0, err
+	}
+	tag, err := s.pool.Exec(ctx, `
+		INSERT INTO webhook_deliveries (endpoint_id, user_id, event_id, event_type, payload)
+		SELECT id, user_id, $2::uuid, $3::text, $4::jsonb
+		FROM webhook_endpoints
+		WHERE user_id = $1 AND active
+		  AND CASE WHEN $5::text IS NULL THEN $3::text = ANY(events) ELSE url = $5::text END
+	`, userID, payload.ID, eventType, payload.body, url)
+	if err != nil {
+		return 0, fmt.Errorf("queueing %s webhooks: %w", eventType, err)
+	}
+	if tag.RowsAffected() > 0 {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	return tag.RowsAffected(), nil
+}
+
+// hasEndpointURL reports whether the user registered a webhook endpoint with
+// exactly this url.
+func (s *WebhookService) hasEndpointURL(ctx context.Context, userID, url string) (bool, error) {
+	var exists bool
+	if err := s.pool.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM webhook_endpoints WHERE user_id = $1 AND url = $2)
+	`, userID, url).Scan(&exists); err != nil {
+		return false, fmt.Errorf("looking up webhook endpoint: %w", err)
+	}
+	return exists, nil
+}
+
+// Ping sends a ping event to the endpoint right away, whether or not it is
+// active, and returns the delivery. Pings are not retried.
+func (s *WebhookService) Ping(ctx context.Context, userID, endpointID string) (*models.WebhookDelivery, error) {
+	endpoint, err := s.GetEndpoint(ctx, userID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := newWebhookPayload(config.WebhookEventPing, map[string]string{"endpoint_id": endpoint.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	// The claim lease keeps the dispatcher away while the ping is sent here.
+	attempt := webhookAttempt{EventType: config.WebhookEventPing, Payload: payload.body}
+	err = s.pool.QueryRow(ctx, `
+		WITH inserted AS (
+			INSERT INTO webhook_deliveries (endpoint_id, user_id, event_id, event_type, payload, next_attempt_at)
+			VALUES ($1, $2, $3, $4, $5, NOW() + $6::interval)
+			RETURNING id, endpoint_id
+		)
+		SELECT inserted.id, e.url, e.secret
+		FROM inserted JOIN webhook_endpoints e ON e.id = inserted.endpoint_id
+	`, endpoint.ID, userID, payload.ID, config.WebhookEventPing, payload.body, config.WebhookClaimLease).
+		Scan(&attempt.DeliveryID, &attempt.URL, &attempt.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("queueing webhook ping: %w", err)
+	}
+	return s.attempt(ctx, attempt, 1)
+}
+
+// RunDispatcher sends due deliveries now, then whenever an event is published
+// and every interval for retries, until ctx is cancelled.
+func (s *WebhookService) RunDispatcher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.DeliverDue(ctx); err != nil {
+			slog.ErrorContext(ctx, "webhook delivery failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// DeliverDue claims and sends pending deliveries whose next attempt is due,
+// batch by batch, until none are left.
+func (s *WebhookService) DeliverDue(ctx context.Context) error {
+	for {
+		attempts, err := s.claimDue(ctx)
+		if err != nil {
+			return err
+		}
+		for _, attempt := range attempts {
+			if _, err := s.attempt(ctx, attempt, config.WebhookMaxAttempts); err != nil {
+				return err
+			}
+		}
+		if len(attempts) < config.WebhookDispatchBatch {
+			return nil
+		}
+	}
+}
+
+// claimDue pushes the next attempt of a batch of due deliveries out by the
+// claim lease, so concurrent dispatchers skip them, and returns them.
+func (s *WebhookService) claimDue(ctx context.Context) ([]webhookAttempt, error) {
+	rows, err := s.pool.Query(ctx, `
+		WITH due AS (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d SET next_attempt_at = NOW() + $2::interval
+		FROM due, webhook_endpoints e
+		WHERE d.id = due.id AND e.id = d.endpoint_id
+		RETURNING d.id, d.event_type, d.payload, d.attempts, e.url, e.secret
+	`, config.WebhookDispatchBatch, config.WebhookClaimLease)
+	if err != nil {
+		return nil, fmt.Errorf("claiming due webhook deliveries: %w", err)
+	}
+	attempts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (webhookAttempt, error) {
+		var a webhookAttempt
+		err := row.Scan(&a.DeliveryID, &a.EventType, &a.Payload, &a.Attempts, &a.URL, &a.Secret)
+		return a, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("collecting due webhook deliveries: %w", err)
+	}
+	return attempts, nil
+}
+
+// attempt sends one delivery and records the outcome, scheduling a retry
+// with exponential backoff while attempts remain.
+func (s *WebhookService) attempt(ctx context.Context, attempt webhookAttempt, maxAttempts int) (*models.WebhookDelivery, error) {
+	sendCtx, cancel := context.WithTimeout(ctx, config.WebhookTimeout)
+	responseStatus, sendErr := sendWebhook(sendCtx, s.client, attempt, time.Now())
+	cancel()
+
+	var statusArg *int
+	if responseStatus != 0 {
+		statusArg = &responseStatus
+	}
+	var errorArg *string
+	if sendErr != nil {
+		msg := sendErr.Error()
+		errorArg = &msg
+	}
+	status, next := nextWebhookAttempt(sendErr == nil, attempt.Attempts+1, maxAttempts, time.Now())
+
+	rows, err := s.pool.Query(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = attempts + 1, response_status = $3, error = $4,
+			next_attempt_at = $5, last_attempt_at = NOW()
+		WHERE id = $1
+		RETURNING `+webhookDeliveryColumns,
+		attempt.DeliveryID, status, statusArg, errorArg, next)
+	if err != nil {
+		return nil, fmt.Errorf("recording webhook delivery: %w", err)
+	}
+	delivery, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.WebhookDelivery])
+	if err != nil {
+		return nil, fmt.Errorf("collecting webhook delivery: %w", err)
+	}
+	return &delivery, nil
+}
+
+// webhookPayload is an event envelope encoded once and shared by every
+// endpoint that receives it.
+type webhookPayload struct {
+	ID   string
+	body []byte
+}
+
+func newWebhookPayload(eventType string, data any) (webhookPayload, error) {
+	event := models.WebhookEvent{
+		ID:        uuid.New().String(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return webhookPayload{}, fmt.Errorf("encoding %s webhook payload: %w", eventType, err)
+	}
+	return webhookPayload{ID: event.ID, body: body}, nil
+}
+
+func mapWebhookEndpointWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrWebhookEndpointExists
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrWebhookEndpointNotFound
+	}
+	return fmt.Errorf("writing webhook endpoint: %w", err)
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"fintu-tracking-backend/internal/apperror"
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/models"
+)
+
+const webhookDescriptionMaxLength = 200
+
+// applyWebhookEndpointFields validates and applies the fields set in req.
+// Event wildcards such as "cash_flow.*" are expanded to the matching events.
+func applyWebhookEndpointFields(endpoint *models.WebhookEndpoint, req models.UpdateWebhookEndpointRequest) error {
+	if req.URL != nil {
+		raw := strings.TrimSpace(*req.URL)
+		if _, err := checkPublicURL(raw); errors.Is(err, errNonPublicAddress) {
+			return fmt.Errorf("%w: url must point to a public address", ErrInvalidWebhookEndpoint)
+		} else if err != nil {
+			return fmt.Errorf("%w: url must be an http(s) URL", ErrInvalidWebhookEndpoint)
+		}
+		endpoint.URL = raw
+	}
+	if req.Description != nil {
+		description := strings.TrimSpace(*req.Description)
+		if len(description) > webhookDescriptionMaxLength {
+			return apperror.Detailf(ErrInvalidWebhookEndpoint, "description must be at most %d characters", webhookDescriptionMaxLength)
+		}
+		endpoint.Description = description
+	}
+	if req.Events != nil {
+		events, err := expandWebhookEvents(req.Events)
+		if err != nil {
+			return err
+		}
+		endpoint.Events = events
+	}
+	return nil
+}
+
+// expandWebhookEvents resolves wildcards, drops duplicates and returns the
+// events in config.WebhookEvents order.
+func expandWebhookEvents(requested []string) ([]string, error) {
+	selected := make(map[string]bool, len(config.WebhookEvents))
+	for _, raw := range requested {
+		event := strings.ToLower(strings.TrimSpace(raw))
+		matched := false
+		for _, known := range config.WebhookEvents {
+			if known == event || (strings.HasSuffix(event, ".*") && strings.HasPrefix(known, strings.TrimSuffix(event, "*"))) {
+				selected[known] = true
+				matched = true
+			}
+		}
+		if !matched {
+			return nil, apperror.Detailf(ErrInvalidWebhookEndpoint, "unknown event %q (supported: %s)", raw, strings.Join(config.WebhookEvents, ", "))
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("%w: at least one event is required", ErrInvalidWebhookEndpoint)
+	}
+	events := make([]string, 0, len(selected))
+	for _, known := range config.WebhookEvents {
+		if selected[known] {
+			events = append(events, known)
+		}
+	}
+	return events, nil
+}
+
+// newWebhookSecret returns a random signing secret.
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generating webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// signWebhookPayload returns the signature header value for body sent at
+// timestamp: the HMAC-SHA256 of "<timestamp>.<body>" keyed by the secret.
+// Receivers recompute it and reject stale timestamps to stop replays.
+func signWebhookPayload(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookRetryDelay is the wait after the given number of failed attempts.
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := config.WebhookRetryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= config.WebhookRetryMaxDelay {
+			return config.WebhookRetryMaxDelay
+		}
+	}
+	return delay
+}
+
+// nextWebhookAttempt returns the delivery status after an attempt and, while
+// it is still pending, when to try again.
+func nextWebhookAttempt(succeeded bool, attempts, maxAttempts int, now time.Time) (string, *time.Time) {
+	switch {
+	case succeeded:
+		return "succeeded", nil
+	case attempts >= maxAttempts:
+		return "failed", nil
+	default:
+		next := now.Add(webhookRetryDelay(attempts))
+		return "pending", &next
+	}
+}
+
+// webhookAttempt is one delivery ready to be sent.
+type webhookAttempt struct {
+	DeliveryID string
+	EventType  string
+	Payload    []byte
+	Attempts   int
+	URL        string
+	Secret     string
+}
+
+// sendWebhook POSTs the payload with its signature and returns the response
+// status. Any non-2xx response is an error.
+func sendWebhook(ctx context.Context, client *http.Client, attempt webhookAttempt, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, attempt.URL, bytes.NewReader(attempt.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("building webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "fintu-webhooks/1")
+	req.Header.Set("X-Fintu-Event", attempt.EventType)
+	req.Header.Set("X-Fintu-Delivery", attempt.DeliveryID)
+	req.Header.Set(config.WebhookSignatureHeader, signWebhookPayload(attempt.Secret, now, attempt.Payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	// Drain a little of the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint returned HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
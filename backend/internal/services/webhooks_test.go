@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"fintu-tracking-backend/internal/config"
+	"fintu-tracking-backend/internal/models"
+)
+
+func TestExpandWebhookEvents(t *testing.T) {
+	t.Parallel()
+
+	got, err := expandWebhookEvents([]string{"price.refreshed", "Cash_Flow.*", "trade.created", "cash_flow.updated"})
+	if err != nil {
+		t.Fatalf("expandWebhookEvents: %v", err)
+	}
+	want := []string{"trade.created", "cash_flow.created", "cash_flow.updated", "cash_flow.deleted", "price.refreshed"}
+	if !slices.Equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+
+	for _, bad := range [][]string{{}, {"trade.exploded"}, {"ping"}, {"*"}} {
+		if _, err := expandWebhookEvents(bad); !errors.Is(err, ErrInvalidWebhookEndpoint) {
+			t.Errorf("expandWebhookEvents(%v) error = %v, want ErrInvalidWebhookEndpoint", bad, err)
+		}
+	}
+}
+
+func TestApplyWebhookEndpointFields(t *testing.T) {
+	t.Parallel()
+
+	endpoint := &models.WebhookEndpoint{URL: "https://old.example.com", Events: []string{"trade.created"}}
+	url := " https://hooks.example.com/fintu "
+	description := "  Notion sync "
+	if err := applyWebhookEndpointFields(endpoint, models.UpdateWebhookEndpointRequest{URL: &url, Description: &description}); err != nil {
+		t.Fatalf("applyWebhookEndpointFields: %v", err)
+	}
+	if endpoint.URL != "https://hooks.example.com/fintu" || endpoint.Description != "Notion sync" || len(endpoint.Events) != 1 {
+		t.Errorf("endpoint = %+v", endpoint)
+	}
+
+	for _, raw := range []string{
+		"", "hooks.example.com", "ftp://example.com", "https://",
+		"http://localhost:8080/hook", "http://api.localhost", "http://127.0.0.1/hook", "http://10.0.0.5",
+		"http://169.254.169.254/latest/meta-data", "http://[::1]:3000", "http://[fd00::1]", "http://0.0.0.0",
+	} {
+		if err := applyWebhookEndpointFields(endpoint, models.UpdateWebhookEndpointRequest{URL: &raw}); !errors.Is(err, ErrInvalidWebhookEndpoint) {
+			t.Errorf("url %q error = %v, want ErrInvalidWebhookEndpoint", raw, err)
+		}
+	}
+	long := strings.Repeat("x", webhookDescriptionMaxLength+1)
+	if err := applyWebhookEndpointFields(endpoint, models.UpdateWebhookEndpointRequest{Description: &long}); !errors.Is(err, ErrInvalidWebhookEndpoint) {
+		t.Errorf("long description error = %v, want ErrInvalidWebhookEndpoint", err)
+	}
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	t.Parallel()
+
+	body := []byte(`{"type":"ping"}`)
+	at := time.Unix(1700000000, 0)
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write([]byte("1700000000." + string(body)))
+	want := "t=1700000000,v1=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := signWebhookPayload("whsec_test", at, body); got != want {
+		t.Errorf("signature = %q, want %q", got, want)
+	}
+	if signWebhookPayload("other", at, body) == want {
+		t.Error("signature does not depend on the secret")
+	}
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	t.Parallel()
+
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute}
+	for i, d := range want {
+		if got := webhookRetryDelay(i + 1); got != d {
+			t.Errorf("webhookRetryDelay(%d) = %v, want %v", i+1, got, d)
+		}
+	}
+	if got := webhookRetryDelay(20); got != config.WebhookRetryMaxDelay {
+		t.Errorf("webhookRetryDelay(20) = %v, want cap %v", got, config.WebhookRetryMaxDelay)
+	}
+}
+
+func TestNextWebhookAttempt(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if status, next := nextWebhookAttempt(true, 3, 8, now); status != "succeeded" || next != nil {
+		t.Errorf("success = %s %v", status, next)
+	}
+	if status, next := nextWebhookAttempt(false, 8, 8, now); status != "failed" || next != nil {
+		t.Errorf("last attempt = %s %v", status, next)
+	}
+	status, next := nextWebhookAttempt(false, 2, 8, now)
+	if status != "pending" || next == nil || !next.Equal(now.Add(time.Minute)) {
+		t.Errorf("retry = %s %v, want pending at %v", status, next, now.Add(time.Minute))
+	}
+}
+
+func TestSendWebhook_SignsPayload(t *testing.T) {
+	t.Parallel()
+
+	payload, err := newWebhookPayload(config.WebhookEventTradeCreated, map[string]string{"id": "trade-1"})
+	if err != nil {
+		t.Fatalf("newWebhookPayload: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	attempt := webhookAttempt{DeliveryID: "delivery-1", EventType: config.WebhookEventTradeCreated, Payload: payload.body, Secret: "whsec_test"}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if got := r.Header.Get(config.WebhookSignatureHeader); got != signWebhookPayload("whsec_test", now, body) {
+			t.Errorf("signature = %q", got)
+		}
+		if r.Header.Get("X-Fintu-Event") != "trade.created" || r.Header.Get("X-Fintu-Delivery") != "delivery-1" {
+			t.Errorf("headers = %v", r.Header)
+		}
+		var event models.WebhookEvent
+		if err := json.Unmarshal(body, &event); err != nil || event.ID != payload.ID || event.Type != "trade.created" {
+			t.Errorf("event = %+v (%v)", event, err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	attempt.URL = server.URL
+	status, err := sendWebhook(context.Background(), server.Client(), attempt, now)
+	if err != nil || status != http.StatusAccepted {
+		t.Errorf("sendWebhook = %d, %v", status, err)
+	}
+}
+
+func TestSendWebhook_FailsOnErrorStatus(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	status, err := sendWebhook(context.Background(), server.Client(), webhookAttempt{URL: server.URL}, time.Now())
+	if status != http.StatusBadGateway || err == nil || !strings.Contains(err.Error(), "HTTP 502") {
+		t.Errorf("sendWebhook = %d, %v", status, err)
+	}
+}
@@ -16,16 +16,29 @@ type xirrCashFlow struct {
 }
 
 // calculateXIRR approximates money-weighted return from deposit/withdrawal cash flows.
+// Transfers count as external flows of a single portfolio and cancel out when
+// portfolioID is empty (consolidated view).
 // Returns annualized rate as decimal fraction (e.g. 0.12 = 12%). Zero if not computable.
-func calculateXIRR(ctx context.Context, pool *pgxpool.Pool, userID string, terminalValue decimal.Decimal, asOf time.Time) (decimal.Decimal, error) {
+func calculateXIRR(ctx context.Context, pool *pgxpool.Pool, userID, portfolioID string, terminalValue decimal.Decimal, asOf time.Time) (decimal.Decimal, error) {
+	flows, err := loadExternalCashFlows(ctx, pool, userID, portfolioID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return xirrWithTerminalValue(flows, terminalValue, asOf), nil
+}
+
+// loadExternalCashFlows returns the signed money moved into (+) and out of (-)
+// the portfolio by deposits, withdrawals and transfers, oldest first.
+func loadExternalCashFlows(ctx context.Context, pool *pgxpool.Pool, userID, portfolioID string) ([]xirrCashFlow, error) {
 	rows, err := pool.Query(ctx, `
 		SELECT date, type, usd_amount
 		FROM cash_flows
-		WHERE user_id = $1 AND type IN ('deposit', 'withdrawal')
+		WHERE user_id = $1 AND `+portfolioScopeSQL("portfolio_id", 2)+`
+		  AND type IN ('deposit', 'withdrawal', 'transfer_in', 'transfer_out')
 		ORDER BY date ASC
-	`, userID)
+	`, userID, portfolioScopeArg(portfolioID))
 	if err != nil {
-		return decimal.Zero, fmt.Errorf("load cash flows for xirr: %w", err)
+		return nil, fmt.Errorf("load cash flows for xirr: %w", err)
 	}
 	defer rows.Close()
 
@@ -35,33 +48,38 @@ func calculateXIRR(ctx context.Context, pool *pgxpool.Pool, userID string, termi
 		var flowType string
 		var amount string
 		if err := rows.Scan(&date, &flowType, &amount); err != nil {
-			return decimal.Zero, err
+			return nil, err
 		}
 		amt, err := decimal.NewFromString(amount)
 		if err != nil {
 			continue
 		}
-		switch flowType {
-		case "deposit":
-			flows = append(flows, xirrCashFlow{date: date, amount: amt})
-		case "withdrawal":
-			flows = append(flows, xirrCashFlow{date: date, amount: amt.Neg()})
+		if contribution := netInvestedContribution(flowType, amt, nil); !contribution.IsZero() {
+			flows = append(flows, xirrCashFlow{date: date, amount: contribution})
 		}
 	}
 	if err := rows.Err(); err != nil {
-		return decimal.Zero, err
+		return nil, err
 	}
+	return flows, nil
+}
+
+// xirrWithTerminalValue solves XIRR for signed external flows closed out by
+// terminalValue at asOf. Zero if there are no flows or the solver fails.
+func xirrWithTerminalValue(flows []xirrCashFlow, terminalValue decimal.Decimal, asOf time.Time) decimal.Decimal {
 	if len(flows) == 0 {
-		return decimal.Zero, nil
+		return decimal.Zero
 	}
 
-	flows = append(flows, xirrCashFlow{date: asOf, amount: terminalValue.Neg()})
+	closed := make([]xirrCashFlow, 0, len(flows)+1)
+	closed = append(closed, flows...)
+	closed = append(closed, xirrCashFlow{date: asOf, amount: terminalValue.Neg()})
 
-	rate, ok := solveXIRR(flows)
+	rate, ok := solveXIRR(closed)
 	if !ok {
-		return decimal.Zero, nil
+		return decimal.Zero
 	}
-	return rate, nil
+	return rate
 }
 
 func solveXIRR(flows []xirrCashFlow) (decimal.Decimal, bool) {
@@ -0,0 +1,161 @@
+// Package telemetry holds the Prometheus metrics and OpenTelemetry tracing
+// shared by the API's handlers, services and database layer.
+package telemetry
+
+import (
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Registry holds every metric served on /metrics, plus Go runtime and
+// process metrics.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+var (
+	// HTTPRequests counts API requests by route template, so /api/trades/:id
+	// is one series however many trades there are.
+	HTTPRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "fintu_http_requests_total",
+		Help: "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration observes API latency by route template.
+	HTTPRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "fintu_http_request_duration_seconds",
+		Help:    "HTTP request latency by method and route.",
+		Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"method", "route"})
+
+	// ProviderRequests counts calls to external market data providers.
+	// result is "ok", "rate_limited" or "error".
+	ProviderRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "fintu_provider_requests_total",
+		Help: "External provider calls by provider, operation, ticker and result.",
+	}, []string{"provider", "operation", "ticker", "result"})
+
+	// ProviderRequestDuration observes external provider latency.
+	ProviderRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "fintu_provider_request_duration_seconds",
+		Help:    "External provider call latency by provider and operation.",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 15},
+	}, []string{"provider", "operation"})
+
+	// CacheLookups counts market data cache checks; hits / (hits + misses)
+	// is the hit ratio.
+	CacheLookups = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "fintu_cache_lookups_total",
+		Help: "Market data cache lookups by cache and result (hit or miss).",
+	}, []string{"cache", "result"})
+
+	// RateLimitDecisions counts API rate limiter outcomes by route group.
+	RateLimitDecisions = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "fintu_rate_limit_decisions_total",
+		Help: "API rate limiter decisions by route group and result (allowed or limited).",
+	}, []string{"group", "result"})
+
+	// DBQueryDuration observes database query latency by SQL operation.
+	DBQueryDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "fintu_db_query_duration_seconds",
+		Help:    "Database query latency by operation (SELECT, INSERT, ...) and result.",
+		Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+	}, []string{"operation", "result"})
+)
+
+// RecordCacheLookup counts a hit or miss for cache.
+func RecordCacheLookup(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	CacheLookups.WithLabelValues(cache, result).Inc()
+}
+
+// RegisterPoolMetrics exports the pool's connection statistics. It is called
+// once after the pool is created.
+func RegisterPoolMetrics(pool *pgxpool.Pool) {
+	Registry.MustRegister(newPoolCollector(pool.Stat))
+}
+
+// poolCollector reads pgxpool statistics at scrape time.
+type poolCollector struct {
+	stat func() *pgxpool.Stat
+
+	acquired, idle, total, max, constructing  *prometheus.Desc
+	acquires, emptyAcquires, canceledAcquires *prometheus.Desc
+	acquireSeconds, emptyAcquireWaitSeconds   *prometheus.Desc
+}
+
+func newPoolCollector(stat func() *pgxpool.Stat) *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc("fintu_db_pool_"+name, help, nil, nil)
+	}
+	return &poolCollector{
+		stat:                    stat,
+		acquired:                desc("acquired_connections", "Connections currently checked out of the pool."),
+		idle:                    desc("idle_connections", "Idle connections in the pool."),
+		total:                   desc("total_connections", "Connections in the pool, including ones being opened."),
+		max:                     desc("max_connections", "Maximum size of the pool."),
+		constructing:            desc("constructing_connections", "Connections currently being opened."),
+		acquires:                desc("acquires_total", "Successful connection acquisitions."),
+		emptyAcquires:           desc("empty_acquires_total", "Acquisitions that had to wait because the pool was empty."),
+		canceledAcquires:        desc("canceled_acquires_total", "Acquisitions canceled by their context."),
+		acquireSeconds:          desc("acquire_seconds_total", "Total time spent acquiring connections."),
+		emptyAcquireWaitSeconds: desc("empty_acquire_wait_seconds_total", "Total time spent waiting on an empty pool."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		c.acquired, c.idle, c.total, c.max, c.constructing,
+		c.acquires, c.emptyAcquires, c.canceledAcquires,
+		c.acquireSeconds, c.emptyAcquireWaitSeconds,
+	} {
+		ch <- d
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stat()
+	gauge := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v)
+	}
+	counter := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, v)
+	}
+	gauge(c.acquired, float64(s.AcquiredConns()))
+	gauge(c.idle, float64(s.IdleConns()))
+	gauge(c.total, float64(s.TotalConns()))
+	gauge(c.max, float64(s.MaxConns()))
+	gauge(c.constructing, float64(s.ConstructingConns()))
+	counter(c.acquires, float64(s.AcquireCount()))
+	counter(c.emptyAcquires, float64(s.EmptyAcquireCount()))
+	counter(c.canceledAcquires, float64(s.CanceledAcquireCount()))
+	counter(c.acquireSeconds, s.AcquireDuration().Seconds())
+	counter(c.emptyAcquireWaitSeconds, s.EmptyAcquireWaitTime().Seconds())
+}
+
+// sqlOperation returns the leading keyword of a statement (SELECT, INSERT,
+// WITH, ...) as a low-cardinality label.
+func sqlOperation(sql string) string {
+	for _, line := range strings.Split(sql, "\n") {
+		fields := strings.Fields(strings.TrimLeft(strings.TrimSpace(line), "("))
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "--") {
+			continue
+		}
+		return strings.ToUpper(fields[0])
+	}
+	return "UNKNOWN"
+}
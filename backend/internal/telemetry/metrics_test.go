@@ -0,0 +1,50 @@
+package telemetry
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestSQLOperation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"SELECT 1", "SELECT"},
+		{"\n\t\tselect id FROM trades", "SELECT"},
+		{"-- refresh cache\nINSERT INTO market_prices VALUES ($1)", "INSERT"},
+		{"WITH x AS (SELECT 1) SELECT * FROM x", "WITH"},
+		{"(SELECT 1) UNION (SELECT 2)", "SELECT"},
+		{"   ", "UNKNOWN"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.want, func(t *testing.T) {
+			t.Parallel()
+			if got := sqlOperation(tc.sql); got != tc.want {
+				t.Errorf("sqlOperation(%q) = %q, want %q", tc.sql, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRecordCacheLookup(t *testing.T) {
+	t.Parallel()
+
+	hits := testutil.ToFloat64(CacheLookups.WithLabelValues("test_cache", "hit"))
+	misses := testutil.ToFloat64(CacheLookups.WithLabelValues("test_cache", "miss"))
+
+	RecordCacheLookup("test_cache", true)
+	RecordCacheLookup("test_cache", false)
+	RecordCacheLookup("test_cache", false)
+
+	if got := testutil.ToFloat64(CacheLookups.WithLabelValues("test_cache", "hit")) - hits; got != 1 {
+		t.Errorf("hits = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(CacheLookups.WithLabelValues("test_cache", "miss")) - misses; got != 2 {
+		t.Errorf("misses = %v, want 2", got)
+	}
+}
@@ -0,0 +1,85 @@
+package telemetry
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"fintu-tracking-backend/internal/config"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// QueryTracer is a pgx.QueryTracer that opens a span per query, observes
+// fintu_db_query_duration_seconds and logs queries slower than
+// config.SlowQueryThreshold. Query arguments are never recorded.
+type QueryTracer struct{}
+
+var _ pgx.QueryTracer = QueryTracer{}
+
+type queryTraceKey struct{}
+
+type queryTrace struct {
+	sql       string
+	operation string
+	start     time.Time
+	span      trace.Span
+}
+
+// TraceQueryStart implements pgx.QueryTracer.
+func (QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	operation := sqlOperation(data.SQL)
+	statement := truncateStatement(data.SQL)
+	ctx, span := StartSpan(ctx, "db "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "postgresql"),
+			attribute.String("db.operation.name", operation),
+			attribute.String("db.query.text", statement),
+		),
+	)
+	return context.WithValue(ctx, queryTraceKey{}, &queryTrace{
+		sql:       statement,
+		operation: operation,
+		start:     time.Now(),
+		span:      span,
+	})
+}
+
+// TraceQueryEnd implements pgx.QueryTracer.
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	qt, ok := ctx.Value(queryTraceKey{}).(*queryTrace)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(qt.start)
+
+	result := "ok"
+	if data.Err != nil {
+		result = "error"
+		qt.span.RecordError(data.Err)
+		qt.span.SetStatus(codes.Error, data.Err.Error())
+	} else {
+		qt.span.SetAttributes(attribute.Int64("db.response.rows_affected", data.CommandTag.RowsAffected()))
+	}
+	qt.span.End()
+	DBQueryDuration.WithLabelValues(qt.operation, result).Observe(elapsed.Seconds())
+
+	if elapsed >= config.SlowQueryThreshold {
+		slog.WarnContext(ctx, "slow query",
+			"duration", elapsed.Round(time.Millisecond),
+			"operation", qt.operation,
+			"statement", qt.sql,
+		)
+	}
+}
+
+func truncateStatement(sql string) string {
+	if len(sql) <= config.TracedStatementMaxLength {
+		return sql
+	}
+	return sql[:config.TracedStatementMaxLength] + "..."
+}
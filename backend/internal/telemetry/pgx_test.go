@@ -0,0 +1,60 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestQueryTracerObservesDuration(t *testing.T) {
+	t.Parallel()
+
+	// DELETE and TRUNCATE are not used elsewhere in these tests, so the
+	// histogram counts below belong to this test alone.
+	var tracer QueryTracer
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "DELETE FROM trades WHERE id = $1"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("DELETE 1")})
+
+	ctx = tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "TRUNCATE trades"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: errors.New("permission denied")})
+
+	wantCounts := map[[2]string]uint64{
+		{"DELETE", "ok"}:      1,
+		{"TRUNCATE", "error"}: 1,
+	}
+	for labels, want := range wantCounts {
+		if got := histogramCount(t, labels[0], labels[1]); got != want {
+			t.Errorf("%s/%s observations = %d, want %d", labels[0], labels[1], got, want)
+		}
+	}
+}
+
+func TestTruncateStatement(t *testing.T) {
+	t.Parallel()
+
+	long := make([]byte, 1500)
+	for i := range long {
+		long[i] = 'x'
+	}
+	if got := truncateStatement(string(long)); len(got) != 1003 {
+		t.Errorf("len(truncateStatement) = %d, want 1003", len(got))
+	}
+	if got := truncateStatement("SELECT 1"); got != "SELECT 1" {
+		t.Errorf("truncateStatement(short) = %q", got)
+	}
+}
+
+func histogramCount(t *testing.T, operation, result string) uint64 {
+	t.Helper()
+
+	var m dto.Metric
+	if err := DBQueryDuration.WithLabelValues(operation, result).(prometheus.Histogram).Write(&m); err != nil {
+		t.Fatalf("read histogram: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
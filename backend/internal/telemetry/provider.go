@@ -0,0 +1,70 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"fintu-tracking-backend/internal/apperror"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StartProviderCall opens a span for a call to an external data provider and
+// returns a function that ends it and records fintu_provider_requests_total
+// and fintu_provider_request_duration_seconds. Call it with the call's error:
+//
+//	ctx, done := telemetry.StartProviderCall(ctx, "twelve_data", "quote", ticker)
+//	defer func() { done(err) }()
+func StartProviderCall(ctx context.Context, provider, operation, ticker string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := StartSpan(ctx, provider+" "+operation,
+		trace.WithAttributes(
+			attribute.String("provider", provider),
+			attribute.String("provider.operation", operation),
+			attribute.String("ticker", ticker),
+		),
+	)
+	return ctx, func(err error) {
+		result := providerResult(err)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.SetAttributes(attribute.String("provider.result", result))
+		span.End()
+		ProviderRequests.WithLabelValues(provider, operation, ticker, result).Inc()
+		ProviderRequestDuration.WithLabelValues(provider, operation).Observe(time.Since(start).Seconds())
+	}
+}
+
+// providerResult classifies a provider error. Rate limit errors are kept
+// apart from other failures.
+func providerResult(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case apperror.KindOf(err) == apperror.RateLimited:
+		return "rate_limited"
+	default:
+		return "error"
+	}
+}
+
+// NewHTTPClient returns an HTTP client whose requests are traced as client
+// spans and carry the trace context to the remote service.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	return NewHTTPClientWithTransport(timeout, http.DefaultTransport)
+}
+
+// NewHTTPClientWithTransport is NewHTTPClient sending through base instead of
+// http.DefaultTransport.
+func NewHTTPClientWithTransport(timeout time.Duration, base http.RoundTripper) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: otelhttp.NewTransport(base),
+	}
+}
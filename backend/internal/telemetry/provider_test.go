@@ -0,0 +1,39 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"fintu-tracking-backend/internal/apperror"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestStartProviderCall(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		ticker string
+		err    error
+		result string
+	}{
+		{name: "ok", ticker: "TEST.OK", result: "ok"},
+		{name: "rate limited", ticker: "TEST.RL", err: apperror.New(apperror.RateLimited, "market data provider rate limit reached"), result: "rate_limited"},
+		{name: "error", ticker: "TEST.ERR", err: errors.New("API returned HTTP 500"), result: "error"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, done := StartProviderCall(context.Background(), "test_provider", "quote", tc.ticker)
+			done(tc.err)
+
+			if got := testutil.ToFloat64(ProviderRequests.WithLabelValues("test_provider", "quote", tc.ticker, tc.result)); got != 1 {
+				t.Errorf("fintu_provider_requests_total{result=%q} = %v, want 1", tc.result, got)
+			}
+		})
+	}
+}
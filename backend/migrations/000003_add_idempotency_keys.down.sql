@@ -0,0 +1,3 @@
+-- Revert Idempotency-Key storage.
+
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,36 @@
+-- Idempotency-Key support for write endpoints (POST /api/trades, POST /api/cash-flows).
+-- Each row remembers the first response returned for a (user, key) pair so client
+-- retries on flaky networks replay it instead of creating duplicate rows.
+
+-- ============================================================================
+-- Tables
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+  idempotency_key TEXT NOT NULL,
+  request_method TEXT NOT NULL,
+  request_path TEXT NOT NULL,
+  request_hash TEXT NOT NULL,
+  state TEXT NOT NULL DEFAULT 'in_progress' CHECK (state IN ('in_progress', 'completed')),
+  status_code INTEGER,
+  content_type TEXT,
+  response_body BYTEA,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  completed_at TIMESTAMPTZ,
+  expires_at TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (user_id, idempotency_key)
+);
+
+-- ============================================================================
+-- Indexes
+-- ============================================================================
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+
+-- ============================================================================
+-- Row Level Security
+-- ============================================================================
+
+-- Backend-only table: RLS on with no policies keeps it out of the public API.
+ALTER TABLE idempotency_keys ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,61 @@
+-- Revert named portfolios. Transfer legs have no meaning without portfolios and are removed.
+
+DROP VIEW IF EXISTS orphaned_fee_cash_flows;
+DROP VIEW IF EXISTS fee_reconciliation_summary;
+
+DROP TRIGGER IF EXISTS assign_cash_flow_portfolio_before_insert ON cash_flows;
+DROP TRIGGER IF EXISTS assign_trade_portfolio_before_insert ON trades;
+DROP TRIGGER IF EXISTS update_portfolios_updated_at ON portfolios;
+
+DROP FUNCTION IF EXISTS assign_cash_flow_portfolio();
+DROP FUNCTION IF EXISTS assign_trade_portfolio();
+DROP FUNCTION IF EXISTS ensure_default_portfolio(UUID);
+
+DELETE FROM cash_flows WHERE type IN ('transfer_in', 'transfer_out');
+
+ALTER TABLE cash_flows DROP CONSTRAINT IF EXISTS cash_flows_transfer_id_check;
+ALTER TABLE cash_flows DROP CONSTRAINT IF EXISTS cash_flows_type_check;
+ALTER TABLE cash_flows ADD CONSTRAINT cash_flows_type_check
+  CHECK (type IN ('deposit', 'withdrawal', 'fee', 'cash_adjustment'));
+
+DROP INDEX IF EXISTS idx_cash_flows_transfer_id;
+DROP INDEX IF EXISTS idx_cash_flows_portfolio_id;
+DROP INDEX IF EXISTS idx_trades_portfolio_id;
+
+ALTER TABLE cash_flows DROP COLUMN IF EXISTS transfer_id;
+ALTER TABLE cash_flows DROP COLUMN IF EXISTS portfolio_id;
+ALTER TABLE trades DROP COLUMN IF EXISTS portfolio_id;
+
+DROP TABLE IF EXISTS portfolios;
+
+CREATE OR REPLACE VIEW fee_reconciliation_summary AS
+SELECT
+  t.user_id,
+  t.id as trade_id,
+  t.ticker,
+  t.date,
+  t.side,
+  t.total_fees as trade_total_fees,
+  COALESCE(SUM(cf.usd_amount), 0) as cash_flow_total_fees,
+  t.total_fees - COALESCE(SUM(cf.usd_amount), 0) as reconciliation_diff
+FROM trades t
+LEFT JOIN cash_flows cf ON cf.related_trade_id = t.id AND cf.type = 'fee' AND cf.related_type = 'trade'
+WHERE t.total_fees > 0
+GROUP BY t.user_id, t.id, t.ticker, t.date, t.side, t.total_fees;
+
+CREATE OR REPLACE VIEW orphaned_fee_cash_flows AS
+SELECT
+  cf.id,
+  cf.user_id,
+  cf.date,
+  cf.fee_type,
+  cf.usd_amount,
+  cf.related_trade_id,
+  cf.notes
+FROM cash_flows cf
+WHERE cf.type = 'fee'
+  AND cf.related_type = 'trade'
+  AND cf.related_trade_id IS NOT NULL
+  AND NOT EXISTS (
+    SELECT 1 FROM trades t WHERE t.id = cf.related_trade_id
+  );
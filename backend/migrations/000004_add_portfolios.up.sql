@@ -0,0 +1,204 @@
+-- Named portfolios ("Retirement", "Kids", one per broker, ...) that own trades and
+-- cash flows. Every user keeps exactly one default portfolio; rows inserted without
+-- a portfolio land there so older clients keep working unchanged.
+-- Moving cash between portfolios is recorded as a transfer_out/transfer_in pair
+-- sharing a transfer_id. The pair nets to zero in the consolidated view and is
+-- never counted as a deposit or withdrawal.
+
+-- ============================================================================
+-- Tables
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS portfolios (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  broker_id UUID REFERENCES brokers(id) ON DELETE SET NULL,
+  is_default BOOLEAN NOT NULL DEFAULT false,
+  created_at TIMESTAMPTZ DEFAULT NOW(),
+  updated_at TIMESTAMPTZ DEFAULT NOW(),
+  UNIQUE (user_id, name)
+);
+
+ALTER TABLE trades
+  ADD COLUMN IF NOT EXISTS portfolio_id UUID REFERENCES portfolios(id);
+
+ALTER TABLE cash_flows
+  ADD COLUMN IF NOT EXISTS portfolio_id UUID REFERENCES portfolios(id),
+  ADD COLUMN IF NOT EXISTS transfer_id UUID;
+
+ALTER TABLE cash_flows DROP CONSTRAINT IF EXISTS cash_flows_type_check;
+ALTER TABLE cash_flows ADD CONSTRAINT cash_flows_type_check
+  CHECK (type IN ('deposit', 'withdrawal', 'fee', 'cash_adjustment', 'transfer_in', 'transfer_out'));
+
+ALTER TABLE cash_flows DROP CONSTRAINT IF EXISTS cash_flows_transfer_id_check;
+ALTER TABLE cash_flows ADD CONSTRAINT cash_flows_transfer_id_check
+  CHECK ((type IN ('transfer_in', 'transfer_out')) = (transfer_id IS NOT NULL));
+
+-- ============================================================================
+-- Indexes
+-- ============================================================================
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_portfolios_one_default_per_user
+  ON portfolios(user_id) WHERE is_default;
+CREATE INDEX IF NOT EXISTS idx_trades_portfolio_id ON trades(portfolio_id);
+CREATE INDEX IF NOT EXISTS idx_cash_flows_portfolio_id ON cash_flows(portfolio_id);
+CREATE INDEX IF NOT EXISTS idx_cash_flows_transfer_id ON cash_flows(transfer_id)
+  WHERE transfer_id IS NOT NULL;
+
+-- ============================================================================
+-- Row Level Security
+-- ============================================================================
+ALTER TABLE portfolios ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "Users can view their own portfolios" ON portfolios;
+CREATE POLICY "Users can view their own portfolios"
+  ON portfolios FOR SELECT USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can insert their own portfolios" ON portfolios;
+CREATE POLICY "Users can insert their own portfolios"
+  ON portfolios FOR INSERT WITH CHECK (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can update their own portfolios" ON portfolios;
+CREATE POLICY "Users can update their own portfolios"
+  ON portfolios FOR UPDATE USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can delete their own portfolios" ON portfolios;
+CREATE POLICY "Users can delete their own portfolios"
+  ON portfolios FOR DELETE USING (auth.uid() = user_id);
+
+-- ============================================================================
+-- Helper functions
+-- ============================================================================
+
+-- ensure_default_portfolio returns the user's default portfolio, creating a
+-- "Main" portfolio the first time it is needed.
+CREATE OR REPLACE FUNCTION ensure_default_portfolio(p_user_id UUID)
+RETURNS UUID AS $$
+DECLARE
+  v_id UUID;
+BEGIN
+  SELECT id INTO v_id FROM portfolios WHERE user_id = p_user_id AND is_default;
+  IF v_id IS NOT NULL THEN
+    RETURN v_id;
+  END IF;
+
+  INSERT INTO portfolios (user_id, name, is_default)
+  VALUES (p_user_id, 'Main', true)
+  ON CONFLICT (user_id, name) DO UPDATE SET is_default = true
+  RETURNING id INTO v_id;
+  RETURN v_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Trades without an explicit portfolio go to the default one.
+CREATE OR REPLACE FUNCTION assign_trade_portfolio()
+RETURNS TRIGGER AS $$
+BEGIN
+  IF NEW.portfolio_id IS NULL THEN
+    NEW.portfolio_id := ensure_default_portfolio(NEW.user_id);
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Cash flows inherit the portfolio of the trade or transfer they are linked to
+-- (this covers the fee rows created by create_fee_cash_flows_for_trade), and
+-- otherwise fall back to the default portfolio.
+CREATE OR REPLACE FUNCTION assign_cash_flow_portfolio()
+RETURNS TRIGGER AS $$
+BEGIN
+  IF NEW.portfolio_id IS NULL AND NEW.related_trade_id IS NOT NULL THEN
+    SELECT portfolio_id INTO NEW.portfolio_id FROM trades WHERE id = NEW.related_trade_id;
+  END IF;
+  IF NEW.portfolio_id IS NULL AND NEW.related_cash_flow_id IS NOT NULL THEN
+    SELECT portfolio_id INTO NEW.portfolio_id FROM cash_flows WHERE id = NEW.related_cash_flow_id;
+  END IF;
+  IF NEW.portfolio_id IS NULL THEN
+    NEW.portfolio_id := ensure_default_portfolio(NEW.user_id);
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- ============================================================================
+-- Triggers
+-- ============================================================================
+DROP TRIGGER IF EXISTS update_portfolios_updated_at ON portfolios;
+CREATE TRIGGER update_portfolios_updated_at
+  BEFORE UPDATE ON portfolios
+  FOR EACH ROW
+  EXECUTE FUNCTION update_updated_at_column();
+
+DROP TRIGGER IF EXISTS assign_trade_portfolio_before_insert ON trades;
+CREATE TRIGGER assign_trade_portfolio_before_insert
+  BEFORE INSERT ON trades
+  FOR EACH ROW
+  EXECUTE FUNCTION assign_trade_portfolio();
+
+DROP TRIGGER IF EXISTS assign_cash_flow_portfolio_before_insert ON cash_flows;
+CREATE TRIGGER assign_cash_flow_portfolio_before_insert
+  BEFORE INSERT ON cash_flows
+  FOR EACH ROW
+  EXECUTE FUNCTION assign_cash_flow_portfolio();
+
+-- ============================================================================
+-- Views
+-- ============================================================================
+-- portfolio_id is appended last so CREATE OR REPLACE keeps existing columns intact.
+CREATE OR REPLACE VIEW fee_reconciliation_summary AS
+SELECT
+  t.user_id,
+  t.id as trade_id,
+  t.ticker,
+  t.date,
+  t.side,
+  t.total_fees as trade_total_fees,
+  COALESCE(SUM(cf.usd_amount), 0) as cash_flow_total_fees,
+  t.total_fees - COALESCE(SUM(cf.usd_amount), 0) as reconciliation_diff,
+  t.portfolio_id
+FROM trades t
+LEFT JOIN cash_flows cf ON cf.related_trade_id = t.id AND cf.type = 'fee' AND cf.related_type = 'trade'
+WHERE t.total_fees > 0
+GROUP BY t.user_id, t.id, t.ticker, t.date, t.side, t.total_fees, t.portfolio_id;
+
+CREATE OR REPLACE VIEW orphaned_fee_cash_flows AS
+SELECT
+  cf.id,
+  cf.user_id,
+  cf.date,
+  cf.fee_type,
+  cf.usd_amount,
+  cf.related_trade_id,
+  cf.notes,
+  cf.portfolio_id
+FROM cash_flows cf
+WHERE cf.type = 'fee'
+  AND cf.related_type = 'trade'
+  AND cf.related_trade_id IS NOT NULL
+  AND NOT EXISTS (
+    SELECT 1 FROM trades t WHERE t.id = cf.related_trade_id
+  );
+
+-- ============================================================================
+-- Backfill existing rows into each user's default portfolio
+-- ============================================================================
+SELECT ensure_default_portfolio(user_id)
+FROM (
+  SELECT user_id FROM trades
+  UNION
+  SELECT user_id FROM cash_flows
+) AS owners;
+
+UPDATE trades t
+SET portfolio_id = p.id
+FROM portfolios p
+WHERE p.user_id = t.user_id AND p.is_default AND t.portfolio_id IS NULL;
+
+UPDATE cash_flows cf
+SET portfolio_id = p.id
+FROM portfolios p
+WHERE p.user_id = cf.user_id AND p.is_default AND cf.portfolio_id IS NULL;
+
+ALTER TABLE trades ALTER COLUMN portfolio_id SET NOT NULL;
+ALTER TABLE cash_flows ALTER COLUMN portfolio_id SET NOT NULL;
@@ -0,0 +1,22 @@
+-- Revert custom brokers and fee schedules. Custom brokers have no preset to fall
+-- back to and are removed; their trades and cash flows become unassigned.
+
+DROP TRIGGER IF EXISTS update_broker_fee_schedules_updated_at ON broker_fee_schedules;
+DROP TABLE IF EXISTS broker_fee_schedules;
+
+DELETE FROM brokers WHERE is_custom;
+
+DROP INDEX IF EXISTS idx_brokers_user_preset;
+ALTER TABLE brokers ADD CONSTRAINT brokers_user_id_preset_id_key UNIQUE (user_id, preset_id);
+
+UPDATE brokers SET deposit_fee_type = 'none', deposit_fee_value = 0 WHERE deposit_fee_type = 'tiered';
+UPDATE brokers SET withdrawal_fee_type = 'none', withdrawal_fee_value = 0 WHERE withdrawal_fee_type = 'tiered';
+
+ALTER TABLE brokers DROP CONSTRAINT IF EXISTS brokers_deposit_fee_type_check;
+ALTER TABLE brokers ADD CONSTRAINT brokers_deposit_fee_type_check
+  CHECK (deposit_fee_type IN ('percentage', 'flat', 'none'));
+ALTER TABLE brokers DROP CONSTRAINT IF EXISTS brokers_withdrawal_fee_type_check;
+ALTER TABLE brokers ADD CONSTRAINT brokers_withdrawal_fee_type_check
+  CHECK (withdrawal_fee_type IN ('percentage', 'flat', 'none'));
+
+ALTER TABLE brokers DROP COLUMN IF EXISTS is_custom;
@@ -0,0 +1,93 @@
+-- User-defined brokers and versioned fee schedules.
+-- Custom brokers use preset_id 'custom' and may exist many times per user; preset
+-- brokers stay unique per (user, preset). Fee rules live in broker_fee_schedules,
+-- one row per effective date, so fees computed for past dates keep using the
+-- schedule that applied at the time. The brokers.*_fee_* columns mirror the
+-- schedule in force today for older readers.
+
+-- ============================================================================
+-- Tables
+-- ============================================================================
+
+ALTER TABLE brokers
+  ADD COLUMN IF NOT EXISTS is_custom BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE brokers DROP CONSTRAINT IF EXISTS brokers_user_id_preset_id_key;
+
+ALTER TABLE brokers DROP CONSTRAINT IF EXISTS brokers_deposit_fee_type_check;
+ALTER TABLE brokers ADD CONSTRAINT brokers_deposit_fee_type_check
+  CHECK (deposit_fee_type IN ('percentage', 'flat', 'tiered', 'none'));
+ALTER TABLE brokers DROP CONSTRAINT IF EXISTS brokers_withdrawal_fee_type_check;
+ALTER TABLE brokers ADD CONSTRAINT brokers_withdrawal_fee_type_check
+  CHECK (withdrawal_fee_type IN ('percentage', 'flat', 'tiered', 'none'));
+
+-- deposit_fee/withdrawal_fee hold a fee rule:
+--   {"type": "none"|"flat"|"percentage"|"tiered", "value": "...", "min": "...", "max": "...",
+--    "tiers": [{"up_to": "...", "rate": "...", "flat": "..."}]}
+-- commissions maps an asset type (stock, etf, crypto) to a fee rule applied to trade notional.
+-- fx_spread is the fraction lost converting local currency to USD (0.01 = 1%).
+CREATE TABLE IF NOT EXISTS broker_fee_schedules (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  broker_id UUID NOT NULL REFERENCES brokers(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+  effective_from DATE NOT NULL,
+  deposit_fee JSONB NOT NULL DEFAULT '{"type": "none"}',
+  withdrawal_fee JSONB NOT NULL DEFAULT '{"type": "none"}',
+  commissions JSONB NOT NULL DEFAULT '{}',
+  fx_spread NUMERIC(10, 6) NOT NULL DEFAULT 0 CHECK (fx_spread >= 0 AND fx_spread < 1),
+  notes TEXT,
+  created_at TIMESTAMPTZ DEFAULT NOW(),
+  updated_at TIMESTAMPTZ DEFAULT NOW(),
+  UNIQUE (broker_id, effective_from)
+);
+
+-- ============================================================================
+-- Indexes
+-- ============================================================================
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_brokers_user_preset
+  ON brokers(user_id, preset_id) WHERE NOT is_custom;
+CREATE INDEX IF NOT EXISTS idx_broker_fee_schedules_user_id ON broker_fee_schedules(user_id);
+
+-- ============================================================================
+-- Row Level Security
+-- ============================================================================
+ALTER TABLE broker_fee_schedules ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "Users can view their own broker fee schedules" ON broker_fee_schedules;
+CREATE POLICY "Users can view their own broker fee schedules"
+  ON broker_fee_schedules FOR SELECT USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can insert their own broker fee schedules" ON broker_fee_schedules;
+CREATE POLICY "Users can insert their own broker fee schedules"
+  ON broker_fee_schedules FOR INSERT WITH CHECK (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can update their own broker fee schedules" ON broker_fee_schedules;
+CREATE POLICY "Users can update their own broker fee schedules"
+  ON broker_fee_schedules FOR UPDATE USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can delete their own broker fee schedules" ON broker_fee_schedules;
+CREATE POLICY "Users can delete their own broker fee schedules"
+  ON broker_fee_schedules FOR DELETE USING (auth.uid() = user_id);
+
+-- ============================================================================
+-- Triggers
+-- ============================================================================
+DROP TRIGGER IF EXISTS update_broker_fee_schedules_updated_at ON broker_fee_schedules;
+CREATE TRIGGER update_broker_fee_schedules_updated_at
+  BEFORE UPDATE ON broker_fee_schedules
+  FOR EACH ROW
+  EXECUTE FUNCTION update_updated_at_column();
+
+-- ============================================================================
+-- Backfill: every existing broker gets an open-ended schedule from its columns
+-- ============================================================================
+INSERT INTO broker_fee_schedules (broker_id, user_id, effective_from, deposit_fee, withdrawal_fee)
+SELECT
+  b.id,
+  b.user_id,
+  DATE '1970-01-01',
+  jsonb_build_object('type', b.deposit_fee_type, 'value', COALESCE(b.deposit_fee_value, 0)::text),
+  jsonb_build_object('type', b.withdrawal_fee_type, 'value', COALESCE(b.withdrawal_fee_value, 0)::text)
+FROM brokers b
+WHERE NOT EXISTS (SELECT 1 FROM broker_fee_schedules s WHERE s.broker_id = b.id);
@@ -0,0 +1,3 @@
+-- Revert daily price history.
+
+DROP TABLE IF EXISTS daily_prices;
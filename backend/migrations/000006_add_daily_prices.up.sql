@@ -0,0 +1,26 @@
+-- Daily closing prices per ticker. market_prices only keeps the latest quote;
+-- risk metrics (volatility, drawdown, beta) need a daily valuation history.
+
+-- ============================================================================
+-- Tables
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS daily_prices (
+  ticker TEXT NOT NULL,
+  price_date DATE NOT NULL,
+  close NUMERIC(18, 4) NOT NULL,
+  currency TEXT NOT NULL DEFAULT 'USD',
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (ticker, price_date)
+);
+
+-- ============================================================================
+-- Row Level Security
+-- ============================================================================
+
+-- Shared market data, like market_prices: readable by everyone, written by the backend.
+ALTER TABLE daily_prices ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "Anyone can view daily prices" ON daily_prices;
+CREATE POLICY "Anyone can view daily prices"
+  ON daily_prices FOR SELECT TO authenticated USING (true);
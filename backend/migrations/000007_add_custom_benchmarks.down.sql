@@ -0,0 +1,3 @@
+-- Revert custom benchmark baskets.
+
+DROP TABLE IF EXISTS custom_benchmarks;
@@ -0,0 +1,48 @@
+-- User-defined benchmark baskets for performance comparisons. Built-in
+-- benchmarks (SPY, QQQ, VT, COLCAP proxy, 60/40) live in code; this table only
+-- holds custom weighted baskets.
+
+-- ============================================================================
+-- Tables
+-- ============================================================================
+
+-- components is a list of {"ticker": "...", "weight": "..."} whose weights sum to 1.
+CREATE TABLE IF NOT EXISTS custom_benchmarks (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  components JSONB NOT NULL,
+  created_at TIMESTAMPTZ DEFAULT NOW(),
+  updated_at TIMESTAMPTZ DEFAULT NOW(),
+  UNIQUE (user_id, name)
+);
+
+-- ============================================================================
+-- Row Level Security
+-- ============================================================================
+ALTER TABLE custom_benchmarks ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "Users can view their own custom benchmarks" ON custom_benchmarks;
+CREATE POLICY "Users can view their own custom benchmarks"
+  ON custom_benchmarks FOR SELECT USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can insert their own custom benchmarks" ON custom_benchmarks;
+CREATE POLICY "Users can insert their own custom benchmarks"
+  ON custom_benchmarks FOR INSERT WITH CHECK (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can update their own custom benchmarks" ON custom_benchmarks;
+CREATE POLICY "Users can update their own custom benchmarks"
+  ON custom_benchmarks FOR UPDATE USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can delete their own custom benchmarks" ON custom_benchmarks;
+CREATE POLICY "Users can delete their own custom benchmarks"
+  ON custom_benchmarks FOR DELETE USING (auth.uid() = user_id);
+
+-- ============================================================================
+-- Triggers
+-- ============================================================================
+DROP TRIGGER IF EXISTS update_custom_benchmarks_updated_at ON custom_benchmarks;
+CREATE TRIGGER update_custom_benchmarks_updated_at
+  BEFORE UPDATE ON custom_benchmarks
+  FOR EACH ROW
+  EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,4 @@
+-- Revert symbol metadata and ETF constituents.
+
+DROP TABLE IF EXISTS etf_constituents;
+DROP TABLE IF EXISTS symbol_metadata;
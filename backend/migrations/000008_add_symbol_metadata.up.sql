@@ -0,0 +1,132 @@
+-- Symbol classification used by exposure analytics: sector, country/region,
+-- market cap bucket and listing currency per ticker, plus the largest
+-- constituents of common ETFs so exposure can look through funds. Both tables
+-- are shared reference data maintained with migrations, like market_prices.
+
+-- ============================================================================
+-- Tables
+-- ============================================================================
+
+-- asset_class: equity, fixed_income, crypto or multi_asset.
+-- market_cap: mega, large, mid or small; NULL when not applicable (bonds, crypto).
+CREATE TABLE IF NOT EXISTS symbol_metadata (
+  ticker TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  asset_class TEXT NOT NULL,
+  sector TEXT,
+  country TEXT,
+  region TEXT,
+  market_cap TEXT CHECK (market_cap IN ('mega', 'large', 'mid', 'small')),
+  currency TEXT NOT NULL DEFAULT 'USD',
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- weight is a decimal fraction of the ETF. Only the top holdings are stored;
+-- the remainder is classified with the ETF's own metadata.
+CREATE TABLE IF NOT EXISTS etf_constituents (
+  etf_ticker TEXT NOT NULL,
+  constituent_ticker TEXT NOT NULL,
+  weight NUMERIC(9, 6) NOT NULL CHECK (weight > 0 AND weight <= 1),
+  as_of DATE NOT NULL,
+  PRIMARY KEY (etf_ticker, constituent_ticker)
+);
+
+-- ============================================================================
+-- Row Level Security
+-- ============================================================================
+
+ALTER TABLE symbol_metadata ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "Anyone can view symbol metadata" ON symbol_metadata;
+CREATE POLICY "Anyone can view symbol metadata"
+  ON symbol_metadata FOR SELECT TO authenticated USING (true);
+
+ALTER TABLE etf_constituents ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "Anyone can view ETF constituents" ON etf_constituents;
+CREATE POLICY "Anyone can view ETF constituents"
+  ON etf_constituents FOR SELECT TO authenticated USING (true);
+
+-- ============================================================================
+-- Seed data
+-- ============================================================================
+
+INSERT INTO symbol_metadata (ticker, name, asset_class, sector, country, region, market_cap, currency) VALUES
+  ('AAPL', 'Apple', 'equity', 'Technology', 'United States', 'North America', 'mega', 'USD'),
+  ('MSFT', 'Microsoft', 'equity', 'Technology', 'United States', 'North America', 'mega', 'USD'),
+  ('NVDA', 'NVIDIA', 'equity', 'Technology', 'United States', 'North America', 'mega', 'USD'),
+  ('AVGO', 'Broadcom', 'equity', 'Technology', 'United States', 'North America', 'mega', 'USD'),
+  ('AMZN', 'Amazon', 'equity', 'Consumer Discretionary', 'United States', 'North America', 'mega', 'USD'),
+  ('TSLA', 'Tesla', 'equity', 'Consumer Discretionary', 'United States', 'North America', 'mega', 'USD'),
+  ('GOOGL', 'Alphabet Class A', 'equity', 'Communication Services', 'United States', 'North America', 'mega', 'USD'),
+  ('GOOG', 'Alphabet Class C', 'equity', 'Communication Services', 'United States', 'North America', 'mega', 'USD'),
+  ('META', 'Meta Platforms', 'equity', 'Communication Services', 'United States', 'North America', 'mega', 'USD'),
+  ('NFLX', 'Netflix', 'equity', 'Communication Services', 'United States', 'North America', 'large', 'USD'),
+  ('BRK.B', 'Berkshire Hathaway', 'equity', 'Financials', 'United States', 'North America', 'mega', 'USD'),
+  ('JPM', 'JPMorgan Chase', 'equity', 'Financials', 'United States', 'North America', 'mega', 'USD'),
+  ('V', 'Visa', 'equity', 'Financials', 'United States', 'North America', 'mega', 'USD'),
+  ('KO', 'Coca-Cola', 'equity', 'Consumer Staples', 'United States', 'North America', 'large', 'USD'),
+  ('TSM', 'Taiwan Semiconductor', 'equity', 'Technology', 'Taiwan', 'Asia Pacific', 'mega', 'USD'),
+  ('EC', 'Ecopetrol', 'equity', 'Energy', 'Colombia', 'Latin America', 'large', 'USD'),
+  ('CIB', 'Bancolombia', 'equity', 'Financials', 'Colombia', 'Latin America', 'mid', 'USD'),
+  ('SPY', 'SPDR S&P 500 ETF', 'equity', 'Diversified', 'United States', 'North America', 'large', 'USD'),
+  ('VOO', 'Vanguard S&P 500 ETF', 'equity', 'Diversified', 'United States', 'North America', 'large', 'USD'),
+  ('IVV', 'iShares Core S&P 500 ETF', 'equity', 'Diversified', 'United States', 'North America', 'large', 'USD'),
+  ('VTI', 'Vanguard Total Stock Market ETF', 'equity', 'Diversified', 'United States', 'North America', 'large', 'USD'),
+  ('QQQ', 'Invesco QQQ Trust', 'equity', 'Technology', 'United States', 'North America', 'large', 'USD'),
+  ('VT', 'Vanguard Total World Stock ETF', 'equity', 'Diversified', 'Global', 'Global', 'large', 'USD'),
+  ('VXUS', 'Vanguard Total International Stock ETF', 'equity', 'Diversified', 'Global ex-US', 'Global', 'large', 'USD'),
+  ('VWO', 'Vanguard FTSE Emerging Markets ETF', 'equity', 'Diversified', 'Emerging Markets', 'Emerging Markets', 'large', 'USD'),
+  ('GXG', 'Global X MSCI Colombia ETF', 'equity', 'Diversified', 'Colombia', 'Latin America', 'mid', 'USD'),
+  ('BND', 'Vanguard Total Bond Market ETF', 'fixed_income', 'Fixed Income', 'United States', 'North America', NULL, 'USD'),
+  ('AGG', 'iShares Core US Aggregate Bond ETF', 'fixed_income', 'Fixed Income', 'United States', 'North America', NULL, 'USD'),
+  ('BTC/USD', 'Bitcoin', 'crypto', 'Crypto', NULL, 'Global', NULL, 'USD'),
+  ('ETH/USD', 'Ethereum', 'crypto', 'Crypto', NULL, 'Global', NULL, 'USD')
+ON CONFLICT (ticker) DO NOTHING;
+
+-- Approximate top holdings; refresh with a new migration when they drift.
+INSERT INTO etf_constituents (etf_ticker, constituent_ticker, weight, as_of) VALUES
+  ('SPY', 'NVDA', 0.070, '2025-06-30'),
+  ('SPY', 'MSFT', 0.068, '2025-06-30'),
+  ('SPY', 'AAPL', 0.058, '2025-06-30'),
+  ('SPY', 'AMZN', 0.040, '2025-06-30'),
+  ('SPY', 'META', 0.030, '2025-06-30'),
+  ('SPY', 'AVGO', 0.024, '2025-06-30'),
+  ('SPY', 'GOOGL', 0.020, '2025-06-30'),
+  ('SPY', 'TSLA', 0.018, '2025-06-30'),
+  ('SPY', 'BRK.B', 0.017, '2025-06-30'),
+  ('SPY', 'GOOG', 0.016, '2025-06-30'),
+  ('VOO', 'NVDA', 0.070, '2025-06-30'),
+  ('VOO', 'MSFT', 0.068, '2025-06-30'),
+  ('VOO', 'AAPL', 0.058, '2025-06-30'),
+  ('VOO', 'AMZN', 0.040, '2025-06-30'),
+  ('VOO', 'META', 0.030, '2025-06-30'),
+  ('VOO', 'AVGO', 0.024, '2025-06-30'),
+  ('VOO', 'GOOGL', 0.020, '2025-06-30'),
+  ('VOO', 'TSLA', 0.018, '2025-06-30'),
+  ('VOO', 'BRK.B', 0.017, '2025-06-30'),
+  ('VOO', 'GOOG', 0.016, '2025-06-30'),
+  ('IVV', 'NVDA', 0.070, '2025-06-30'),
+  ('IVV', 'MSFT', 0.068, '2025-06-30'),
+  ('IVV', 'AAPL', 0.058, '2025-06-30'),
+  ('IVV', 'AMZN', 0.040, '2025-06-30'),
+  ('IVV', 'META', 0.030, '2025-06-30'),
+  ('QQQ', 'NVDA', 0.090, '2025-06-30'),
+  ('QQQ', 'MSFT', 0.088, '2025-06-30'),
+  ('QQQ', 'AAPL', 0.074, '2025-06-30'),
+  ('QQQ', 'AMZN', 0.055, '2025-06-30'),
+  ('QQQ', 'AVGO', 0.050, '2025-06-30'),
+  ('QQQ', 'META', 0.037, '2025-06-30'),
+  ('QQQ', 'NFLX', 0.030, '2025-06-30'),
+  ('QQQ', 'TSLA', 0.027, '2025-06-30'),
+  ('QQQ', 'GOOGL', 0.025, '2025-06-30'),
+  ('QQQ', 'GOOG', 0.024, '2025-06-30'),
+  ('VT', 'NVDA', 0.040, '2025-06-30'),
+  ('VT', 'MSFT', 0.038, '2025-06-30'),
+  ('VT', 'AAPL', 0.033, '2025-06-30'),
+  ('VT', 'AMZN', 0.022, '2025-06-30'),
+  ('VT', 'META', 0.016, '2025-06-30'),
+  ('VT', 'TSM', 0.010, '2025-06-30'),
+  ('GXG', 'CIB', 0.120, '2025-06-30'),
+  ('GXG', 'EC', 0.080, '2025-06-30')
+ON CONFLICT (etf_ticker, constituent_ticker) DO NOTHING;
@@ -0,0 +1,3 @@
+-- Revert target allocations.
+
+DROP TABLE IF EXISTS target_allocations;
@@ -0,0 +1,56 @@
+-- Target allocation per portfolio, e.g. 70% VOO / 20% QQQ / 10% cash, used by the
+-- rebalancing planner. Targets are keyed by ticker or by asset type (basis), and
+-- a position only needs rebalancing once it drifts outside its band.
+
+-- ============================================================================
+-- Tables
+-- ============================================================================
+
+-- targets is a list of {"key": "...", "weight": "...", "drift_band": "...",
+-- "asset_type": "..."} whose weights sum to 1. The key "CASH" targets uninvested cash.
+CREATE TABLE IF NOT EXISTS target_allocations (
+  portfolio_id UUID PRIMARY KEY REFERENCES portfolios(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+  basis TEXT NOT NULL CHECK (basis IN ('ticker', 'asset_type')),
+  drift_band NUMERIC(5, 4) NOT NULL DEFAULT 0.05 CHECK (drift_band > 0 AND drift_band < 1),
+  fractional_shares BOOLEAN NOT NULL DEFAULT true,
+  targets JSONB NOT NULL,
+  created_at TIMESTAMPTZ DEFAULT NOW(),
+  updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- ============================================================================
+-- Indexes
+-- ============================================================================
+
+CREATE INDEX IF NOT EXISTS idx_target_allocations_user_id ON target_allocations(user_id);
+
+-- ============================================================================
+-- Row Level Security
+-- ============================================================================
+ALTER TABLE target_allocations ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "Users can view their own target allocations" ON target_allocations;
+CREATE POLICY "Users can view their own target allocations"
+  ON target_allocations FOR SELECT USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can insert their own target allocations" ON target_allocations;
+CREATE POLICY "Users can insert their own target allocations"
+  ON target_allocations FOR INSERT WITH CHECK (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can update their own target allocations" ON target_allocations;
+CREATE POLICY "Users can update their own target allocations"
+  ON target_allocations FOR UPDATE USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can delete their own target allocations" ON target_allocations;
+CREATE POLICY "Users can delete their own target allocations"
+  ON target_allocations FOR DELETE USING (auth.uid() = user_id);
+
+-- ============================================================================
+-- Triggers
+-- ============================================================================
+DROP TRIGGER IF EXISTS update_target_allocations_updated_at ON target_allocations;
+CREATE TRIGGER update_target_allocations_updated_at
+  BEFORE UPDATE ON target_allocations
+  FOR EACH ROW
+  EXECUTE FUNCTION update_updated_at_column();
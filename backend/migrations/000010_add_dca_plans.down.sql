@@ -0,0 +1,4 @@
+-- Revert DCA plans.
+
+DROP TABLE IF EXISTS dca_installments;
+DROP TABLE IF EXISTS dca_plans;
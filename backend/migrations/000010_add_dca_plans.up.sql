@@ -0,0 +1,115 @@
+-- Recurring contribution (DCA) plans: deposit a fixed amount every week, two
+-- weeks or month and split it across target tickers. The scheduler creates one
+-- "planned" installment per due date; the user confirms it with the actual
+-- deposit FX rate and fill prices, which records the deposit and buy trades.
+
+-- ============================================================================
+-- Tables
+-- ============================================================================
+
+-- targets is a list of {"ticker": "...", "weight": "...", "asset_type": "..."}
+-- whose weights sum to 1. last_scheduled_date is the latest date an installment
+-- was generated for; the scheduler resumes after it.
+CREATE TABLE IF NOT EXISTS dca_plans (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+  portfolio_id UUID NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+  broker_id UUID REFERENCES brokers(id) ON DELETE SET NULL,
+  name TEXT NOT NULL,
+  amount NUMERIC(18, 2) NOT NULL CHECK (amount > 0),
+  currency TEXT NOT NULL CHECK (currency IN ('COP', 'USD')),
+  frequency TEXT NOT NULL CHECK (frequency IN ('weekly', 'biweekly', 'monthly')),
+  start_date DATE NOT NULL,
+  end_date DATE CHECK (end_date IS NULL OR end_date >= start_date),
+  targets JSONB NOT NULL,
+  active BOOLEAN NOT NULL DEFAULT true,
+  last_scheduled_date DATE,
+  created_at TIMESTAMPTZ DEFAULT NOW(),
+  updated_at TIMESTAMPTZ DEFAULT NOW(),
+  UNIQUE(user_id, name)
+);
+
+-- One row per scheduled contribution. planned_orders is the plan's split of
+-- amount at generation time: [{"ticker", "asset_type", "weight", "amount"}].
+-- Confirming links the recorded deposit (COP plans only) and trades.
+CREATE TABLE IF NOT EXISTS dca_installments (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  plan_id UUID NOT NULL REFERENCES dca_plans(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+  scheduled_date DATE NOT NULL,
+  status TEXT NOT NULL DEFAULT 'planned' CHECK (status IN ('planned', 'confirmed', 'skipped')),
+  planned_amount NUMERIC(18, 2) NOT NULL,
+  currency TEXT NOT NULL CHECK (currency IN ('COP', 'USD')),
+  planned_orders JSONB NOT NULL,
+  executed_date DATE,
+  actual_amount NUMERIC(18, 2),
+  fx_rate NUMERIC(12, 4),
+  usd_amount NUMERIC(18, 2),
+  cash_flow_id UUID REFERENCES cash_flows(id) ON DELETE SET NULL,
+  trade_ids UUID[] NOT NULL DEFAULT '{}',
+  confirmed_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ DEFAULT NOW(),
+  updated_at TIMESTAMPTZ DEFAULT NOW(),
+  UNIQUE(plan_id, scheduled_date)
+);
+
+-- ============================================================================
+-- Indexes
+-- ============================================================================
+
+CREATE INDEX IF NOT EXISTS idx_dca_plans_user_id ON dca_plans(user_id);
+CREATE INDEX IF NOT EXISTS idx_dca_plans_active ON dca_plans(active) WHERE active;
+CREATE INDEX IF NOT EXISTS idx_dca_installments_user_status ON dca_installments(user_id, status);
+
+-- ============================================================================
+-- Row Level Security
+-- ============================================================================
+ALTER TABLE dca_plans ENABLE ROW LEVEL SECURITY;
+ALTER TABLE dca_installments ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "Users can view their own DCA plans" ON dca_plans;
+CREATE POLICY "Users can view their own DCA plans"
+  ON dca_plans FOR SELECT USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can insert their own DCA plans" ON dca_plans;
+CREATE POLICY "Users can insert their own DCA plans"
+  ON dca_plans FOR INSERT WITH CHECK (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can update their own DCA plans" ON dca_plans;
+CREATE POLICY "Users can update their own DCA plans"
+  ON dca_plans FOR UPDATE USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can delete their own DCA plans" ON dca_plans;
+CREATE POLICY "Users can delete their own DCA plans"
+  ON dca_plans FOR DELETE USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can view their own DCA installments" ON dca_installments;
+CREATE POLICY "Users can view their own DCA installments"
+  ON dca_installments FOR SELECT USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can insert their own DCA installments" ON dca_installments;
+CREATE POLICY "Users can insert their own DCA installments"
+  ON dca_installments FOR INSERT WITH CHECK (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can update their own DCA installments" ON dca_installments;
+CREATE POLICY "Users can update their own DCA installments"
+  ON dca_installments FOR UPDATE USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can delete their own DCA installments" ON dca_installments;
+CREATE POLICY "Users can delete their own DCA installments"
+  ON dca_installments FOR DELETE USING (auth.uid() = user_id);
+
+-- ============================================================================
+-- Triggers
+-- ============================================================================
+DROP TRIGGER IF EXISTS update_dca_plans_updated_at ON dca_plans;
+CREATE TRIGGER update_dca_plans_updated_at
+  BEFORE UPDATE ON dca_plans
+  FOR EACH ROW
+  EXECUTE FUNCTION update_updated_at_column();
+
+DROP TRIGGER IF EXISTS update_dca_installments_updated_at ON dca_installments;
+CREATE TRIGGER update_dca_installments_updated_at
+  BEFORE UPDATE ON dca_installments
+  FOR EACH ROW
+  EXECUTE FUNCTION update_updated_at_column();
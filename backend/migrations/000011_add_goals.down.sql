@@ -0,0 +1,3 @@
+-- Revert investment goals.
+
+DROP TABLE IF EXISTS goals;
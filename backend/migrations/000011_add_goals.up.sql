@@ -0,0 +1,59 @@
+-- Investment goals ("Apartment down payment", "Retirement"): a target amount by a
+-- target date, tracked against one portfolio or, without portfolio_id, against
+-- the consolidated net worth. Projections are computed on demand and not stored.
+
+-- ============================================================================
+-- Tables
+-- ============================================================================
+
+-- monthly_contribution (in the goal currency) overrides the contributions
+-- derived from the active DCA plans of the tracked portfolio.
+CREATE TABLE IF NOT EXISTS goals (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+  portfolio_id UUID REFERENCES portfolios(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  target_amount NUMERIC(18, 2) NOT NULL CHECK (target_amount > 0),
+  currency TEXT NOT NULL CHECK (currency IN ('COP', 'USD')),
+  target_date DATE NOT NULL,
+  monthly_contribution NUMERIC(18, 2) CHECK (monthly_contribution IS NULL OR monthly_contribution >= 0),
+  created_at TIMESTAMPTZ DEFAULT NOW(),
+  updated_at TIMESTAMPTZ DEFAULT NOW(),
+  UNIQUE(user_id, name)
+);
+
+-- ============================================================================
+-- Indexes
+-- ============================================================================
+
+CREATE INDEX IF NOT EXISTS idx_goals_user_id ON goals(user_id);
+
+-- ============================================================================
+-- Row Level Security
+-- ============================================================================
+ALTER TABLE goals ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "Users can view their own goals" ON goals;
+CREATE POLICY "Users can view their own goals"
+  ON goals FOR SELECT USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can insert their own goals" ON goals;
+CREATE POLICY "Users can insert their own goals"
+  ON goals FOR INSERT WITH CHECK (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can update their own goals" ON goals;
+CREATE POLICY "Users can update their own goals"
+  ON goals FOR UPDATE USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can delete their own goals" ON goals;
+CREATE POLICY "Users can delete their own goals"
+  ON goals FOR DELETE USING (auth.uid() = user_id);
+
+-- ============================================================================
+-- Triggers
+-- ============================================================================
+DROP TRIGGER IF EXISTS update_goals_updated_at ON goals;
+CREATE TRIGGER update_goals_updated_at
+  BEFORE UPDATE ON goals
+  FOR EACH ROW
+  EXECUTE FUNCTION update_updated_at_column();
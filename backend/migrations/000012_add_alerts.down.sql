@@ -0,0 +1,4 @@
+-- Revert alert rules and events.
+
+DROP TABLE IF EXISTS alert_events;
+DROP TABLE IF EXISTS alert_rules;
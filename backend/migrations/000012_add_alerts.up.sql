@@ -0,0 +1,97 @@
+-- Alert rules evaluated after every price/FX refresh, and the events they fire.
+-- Level rules (price_cross, holding_pl, drawdown) fire once when their condition
+-- becomes true and re-arm when it turns false again. fx_move rules compare the
+-- latest USD/COP rate with reference_value and move the reference when they fire.
+
+-- ============================================================================
+-- Tables
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS alert_rules (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+  portfolio_id UUID REFERENCES portfolios(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  kind TEXT NOT NULL CHECK (kind IN ('price_cross', 'holding_pl', 'fx_move', 'drawdown')),
+  ticker TEXT,
+  direction TEXT NOT NULL CHECK (direction IN ('above', 'below', 'any')),
+  threshold NUMERIC(18, 6) NOT NULL,
+  channels TEXT[] NOT NULL DEFAULT '{in_app}',
+  email TEXT,
+  webhook_url TEXT,
+  active BOOLEAN NOT NULL DEFAULT true,
+  triggered BOOLEAN NOT NULL DEFAULT false,
+  reference_value NUMERIC(18, 6),
+  last_value NUMERIC(18, 6),
+  last_evaluated_at TIMESTAMPTZ,
+  last_triggered_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ DEFAULT NOW(),
+  updated_at TIMESTAMPTZ DEFAULT NOW(),
+  UNIQUE (user_id, name),
+  CHECK ((kind IN ('price_cross', 'holding_pl')) = (ticker IS NOT NULL))
+);
+
+-- deliveries is a list of {"channel": "...", "status": "sent|skipped|failed",
+-- "error": "..."}, one per channel of the rule when it fired.
+CREATE TABLE IF NOT EXISTS alert_events (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  rule_id UUID NOT NULL REFERENCES alert_rules(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+  kind TEXT NOT NULL,
+  message TEXT NOT NULL,
+  value NUMERIC(18, 6) NOT NULL,
+  threshold NUMERIC(18, 6) NOT NULL,
+  deliveries JSONB NOT NULL DEFAULT '[]',
+  triggered_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- ============================================================================
+-- Indexes
+-- ============================================================================
+
+CREATE INDEX IF NOT EXISTS idx_alert_rules_user_id ON alert_rules(user_id);
+CREATE INDEX IF NOT EXISTS idx_alert_events_user_triggered ON alert_events(user_id, triggered_at DESC);
+CREATE INDEX IF NOT EXISTS idx_alert_events_rule_id ON alert_events(rule_id);
+
+-- ============================================================================
+-- Row Level Security
+-- ============================================================================
+ALTER TABLE alert_rules ENABLE ROW LEVEL SECURITY;
+ALTER TABLE alert_events ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "Users can view their own alert rules" ON alert_rules;
+CREATE POLICY "Users can view their own alert rules"
+  ON alert_rules FOR SELECT USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can insert their own alert rules" ON alert_rules;
+CREATE POLICY "Users can insert their own alert rules"
+  ON alert_rules FOR INSERT WITH CHECK (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can update their own alert rules" ON alert_rules;
+CREATE POLICY "Users can update their own alert rules"
+  ON alert_rules FOR UPDATE USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can delete their own alert rules" ON alert_rules;
+CREATE POLICY "Users can delete their own alert rules"
+  ON alert_rules FOR DELETE USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can view their own alert events" ON alert_events;
+CREATE POLICY "Users can view their own alert events"
+  ON alert_events FOR SELECT USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can insert their own alert events" ON alert_events;
+CREATE POLICY "Users can insert their own alert events"
+  ON alert_events FOR INSERT WITH CHECK (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can delete their own alert events" ON alert_events;
+CREATE POLICY "Users can delete their own alert events"
+  ON alert_events FOR DELETE USING (auth.uid() = user_id);
+
+-- ============================================================================
+-- Triggers
+-- ============================================================================
+DROP TRIGGER IF EXISTS update_alert_rules_updated_at ON alert_rules;
+CREATE TRIGGER update_alert_rules_updated_at
+  BEFORE UPDATE ON alert_rules
+  FOR EACH ROW
+  EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,3 @@
+-- Revert notifications.
+
+DROP TABLE IF EXISTS notifications;
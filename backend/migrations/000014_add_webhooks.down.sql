@@ -0,0 +1,4 @@
+-- Revert outbound webhook endpoints and deliveries.
+
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_endpoints;
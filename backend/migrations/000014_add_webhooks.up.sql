@@ -0,0 +1,92 @@
+-- Outbound webhooks: endpoints users register for trade, cash flow, price and
+-- subscription events, and the log of every delivery. Deliveries are retried
+-- with exponential backoff until they succeed or run out of attempts; the
+-- dispatcher picks up pending rows whose next_attempt_at has passed.
+
+-- ============================================================================
+-- Tables
+-- ============================================================================
+
+-- secret signs every payload (HMAC-SHA256) and is only returned on creation.
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+  url TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  events TEXT[] NOT NULL,
+  secret TEXT NOT NULL,
+  active BOOLEAN NOT NULL DEFAULT true,
+  created_at TIMESTAMPTZ DEFAULT NOW(),
+  updated_at TIMESTAMPTZ DEFAULT NOW(),
+  UNIQUE (user_id, url)
+);
+
+-- One row per event and endpoint. payload is the exact JSON body sent; every
+-- endpoint receiving the same event gets the same event_id.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  endpoint_id UUID NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+  event_id UUID NOT NULL,
+  event_type TEXT NOT NULL,
+  payload JSONB NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+  attempts INTEGER NOT NULL DEFAULT 0,
+  response_status INTEGER,
+  error TEXT,
+  next_attempt_at TIMESTAMPTZ DEFAULT NOW(),
+  last_attempt_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- ============================================================================
+-- Indexes
+-- ============================================================================
+
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_user_id ON webhook_endpoints(user_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint_created ON webhook_deliveries(endpoint_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at)
+  WHERE status = 'pending';
+
+-- ============================================================================
+-- Row Level Security
+-- ============================================================================
+ALTER TABLE webhook_endpoints ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhook_deliveries ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "Users can view their own webhook endpoints" ON webhook_endpoints;
+CREATE POLICY "Users can view their own webhook endpoints"
+  ON webhook_endpoints FOR SELECT USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can insert their own webhook endpoints" ON webhook_endpoints;
+CREATE POLICY "Users can insert their own webhook endpoints"
+  ON webhook_endpoints FOR INSERT WITH CHECK (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can update their own webhook endpoints" ON webhook_endpoints;
+CREATE POLICY "Users can update their own webhook endpoints"
+  ON webhook_endpoints FOR UPDATE USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can delete their own webhook endpoints" ON webhook_endpoints;
+CREATE POLICY "Users can delete their own webhook endpoints"
+  ON webhook_endpoints FOR DELETE USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can view their own webhook deliveries" ON webhook_deliveries;
+CREATE POLICY "Users can view their own webhook deliveries"
+  ON webhook_deliveries FOR SELECT USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can insert their own webhook deliveries" ON webhook_deliveries;
+CREATE POLICY "Users can insert their own webhook deliveries"
+  ON webhook_deliveries FOR INSERT WITH CHECK (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can delete their own webhook deliveries" ON webhook_deliveries;
+CREATE POLICY "Users can delete their own webhook deliveries"
+  ON webhook_deliveries FOR DELETE USING (auth.uid() = user_id);
+
+-- ============================================================================
+-- Triggers
+-- ============================================================================
+DROP TRIGGER IF EXISTS update_webhook_endpoints_updated_at ON webhook_endpoints;
+CREATE TRIGGER update_webhook_endpoints_updated_at
+  BEFORE UPDATE ON webhook_endpoints
+  FOR EACH ROW
+  EXECUTE FUNCTION update_updated_at_column();
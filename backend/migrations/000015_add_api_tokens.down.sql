@@ -0,0 +1,3 @@
+-- Revert personal API tokens.
+
+DROP TABLE IF EXISTS api_tokens;
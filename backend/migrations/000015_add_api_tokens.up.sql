@@ -0,0 +1,44 @@
+-- Personal API tokens for scripts and integrations. Only the SHA-256 hash of
+-- a token is stored; prefix keeps its first characters so users can tell
+-- tokens apart. Revoked and expired tokens stay listed until deleted with the
+-- account.
+
+-- ============================================================================
+-- Tables
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS api_tokens (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  prefix TEXT NOT NULL,
+  token_hash TEXT NOT NULL UNIQUE,
+  scopes TEXT[] NOT NULL,
+  expires_at TIMESTAMPTZ NOT NULL,
+  last_used_at TIMESTAMPTZ,
+  revoked_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- ============================================================================
+-- Indexes
+-- ============================================================================
+
+CREATE INDEX IF NOT EXISTS idx_api_tokens_user_id ON api_tokens(user_id);
+
+-- ============================================================================
+-- Row Level Security
+-- ============================================================================
+ALTER TABLE api_tokens ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "Users can view their own api tokens" ON api_tokens;
+CREATE POLICY "Users can view their own api tokens"
+  ON api_tokens FOR SELECT USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can insert their own api tokens" ON api_tokens;
+CREATE POLICY "Users can insert their own api tokens"
+  ON api_tokens FOR INSERT WITH CHECK (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can update their own api tokens" ON api_tokens;
+CREATE POLICY "Users can update their own api tokens"
+  ON api_tokens FOR UPDATE USING (auth.uid() = user_id);
@@ -0,0 +1,8 @@
+-- Revert API rate limit buckets and plan quotas.
+
+UPDATE plans
+SET features = features - 'rate_limits',
+    updated_at = NOW()
+WHERE features ? 'rate_limits';
+
+DROP TABLE IF EXISTS rate_limit_buckets;
@@ -0,0 +1,39 @@
+-- Token buckets for per-user API rate limiting, shared by every API instance.
+-- Each row is one user's bucket for one route group; it is refilled and drawn
+-- from in a single upsert, so concurrent requests on different replicas
+-- cannot overspend it. Pro and closed beta plans get larger analytics quotas
+-- through plans.features.
+
+-- ============================================================================
+-- Tables
+-- ============================================================================
+
+-- allowed records whether the latest request took a token.
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+  bucket_key TEXT PRIMARY KEY,
+  tokens DOUBLE PRECISION NOT NULL,
+  allowed BOOLEAN NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- ============================================================================
+-- Indexes
+-- ============================================================================
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated_at ON rate_limit_buckets(updated_at);
+
+-- ============================================================================
+-- Row Level Security
+-- ============================================================================
+
+-- Backend-only table: RLS on with no policies keeps it out of the public API.
+ALTER TABLE rate_limit_buckets ENABLE ROW LEVEL SECURITY;
+
+-- ============================================================================
+-- Plan quotas
+-- ============================================================================
+
+UPDATE plans
+SET features = features || '{"rate_limits": {"analytics": {"burst": 60, "per_minute": 120}, "market_data": {"burst": 5, "per_minute": 4}}}'::jsonb,
+    updated_at = NOW()
+WHERE id IN ('closed_beta', 'pro_monthly', 'pro_annual');
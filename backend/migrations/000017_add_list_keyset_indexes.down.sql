@@ -0,0 +1,7 @@
+-- Revert keyset list indexes.
+
+CREATE INDEX IF NOT EXISTS idx_trades_user_date ON trades(user_id, date DESC);
+CREATE INDEX IF NOT EXISTS idx_cash_flows_user_date ON cash_flows(user_id, date DESC);
+
+DROP INDEX IF EXISTS idx_trades_user_keyset;
+DROP INDEX IF EXISTS idx_cash_flows_user_keyset;
@@ -0,0 +1,14 @@
+-- Indexes for keyset-paginated trade and cash flow lists. Pages are ordered
+-- by (date, created_at, id) within a user, optionally after a sort column,
+-- and continue from the key of the previous page's last row.
+
+-- ============================================================================
+-- Indexes
+-- ============================================================================
+
+CREATE INDEX IF NOT EXISTS idx_trades_user_keyset ON trades(user_id, date DESC, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_cash_flows_user_keyset ON cash_flows(user_id, date DESC, created_at DESC, id DESC);
+
+-- The date-only indexes are prefixes of the keyset ones.
+DROP INDEX IF EXISTS idx_trades_user_date;
+DROP INDEX IF EXISTS idx_cash_flows_user_date;
@@ -0,0 +1,3 @@
+-- Revert the profile locale.
+
+ALTER TABLE profiles DROP COLUMN IF EXISTS locale;
@@ -0,0 +1,10 @@
+-- Language of the text the server writes for each user: error messages,
+-- activity summaries, reconciliation descriptions and notifications. NULL
+-- follows the Accept-Language header of each request.
+
+-- ============================================================================
+-- Columns
+-- ============================================================================
+
+ALTER TABLE profiles
+  ADD COLUMN IF NOT EXISTS locale TEXT CHECK (locale IN ('en', 'es-CO'));
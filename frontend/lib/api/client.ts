@@ -10,6 +10,11 @@ export interface ApiErrorResponse {
   retry_after?: number
 }
 
+export interface RequestOptions {
+  /** Sent as Idempotency-Key so a retried create is applied only once. */
+  idempotencyKey?: string
+}
+
 export class ApiError extends Error {
   status: number
   retryAfter?: number
@@ -67,9 +72,25 @@ export class ApiClient {
       )
     }
 
+    if (response.status === 204) {
+      return undefined as T
+    }
     return response.json()
   }
 
+  /** Sends a JSON request; used by the generated client in ./generated. */
+  async send<T>(method: string, endpoint: string, data?: unknown, options: RequestOptions = {}): Promise<T> {
+    const headers: Record<string, string> = {}
+    if (options.idempotencyKey) {
+      headers["Idempotency-Key"] = options.idempotencyKey
+    }
+    return this.request<T>(endpoint, {
+      method,
+      headers,
+      body: data === undefined ? undefined : JSON.stringify(data),
+    })
+  }
+
   async get<T>(endpoint: string): Promise<T> {
     return this.request<T>(endpoint, {
       method: "GET",
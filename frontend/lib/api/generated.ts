@@ -0,0 +1,1735 @@
+// Code generated by go run ./cmd/openapi. DO NOT EDIT.
+// Types and client functions for every operation of Fintu Tracking API 1.0.0.
+
+import { apiClient, type RequestOptions } from "./client"
+
+export interface APIToken {
+  created_at: string
+  expires_at: string
+  id: string
+  last_used_at?: string | null
+  name: string
+  prefix: string
+  revoked_at?: string | null
+  scopes: string[]
+  token?: string
+}
+
+export interface ActivityItem {
+  amount_usd: string
+  date: string
+  details: string
+  direction: string
+  id: string
+  kind: string
+  sub_kind: string
+  ticker: string
+}
+
+export interface AlertDelivery {
+  channel: string
+  error?: string
+  status: string
+}
+
+export interface AlertEvaluation {
+  evaluated: number
+  skipped: number
+  triggered: AlertEvent[]
+}
+
+export interface AlertEvent {
+  deliveries: AlertDelivery[]
+  id: string
+  kind: string
+  message: string
+  rule_id: string
+  threshold: string
+  triggered_at: string
+  value: string
+}
+
+export interface AlertRule {
+  active: boolean
+  channels: string[]
+  created_at: string
+  direction: string
+  email?: string | null
+  id: string
+  kind: string
+  last_evaluated_at?: string | null
+  last_triggered_at?: string | null
+  last_value?: string | null
+  name: string
+  portfolio_id?: string | null
+  reference_value?: string | null
+  threshold: string
+  ticker?: string | null
+  triggered: boolean
+  updated_at: string
+  webhook_url?: string | null
+}
+
+export interface AllocationTarget {
+  asset_type?: string
+  drift_band?: string | null
+  key: string
+  weight: string
+}
+
+export interface Benchmark {
+  components: BenchmarkComponent[]
+  created_at?: string | null
+  id: string
+  is_custom: boolean
+  name: string
+  updated_at?: string | null
+}
+
+export interface BenchmarkComparison {
+  benchmark: Benchmark
+  benchmark_value: string
+  benchmark_xirr: string
+  difference: string
+  missing_prices?: string[]
+  net_contributed: string
+  portfolio_value: string
+  portfolio_xirr: string
+  series: BenchmarkWhatIfPoint[]
+  skipped_flows?: number
+}
+
+export interface BenchmarkComponent {
+  ticker: string
+  weight: string
+}
+
+export interface BenchmarkWhatIfPoint {
+  benchmark_value: string
+  date: string
+  net_contributed: string
+}
+
+export interface Broker {
+  base_currency: string
+  country: string
+  created_at: string
+  deposit_fee_type: string
+  deposit_fee_value: string
+  fee_schedule?: BrokerFeeSchedule | null
+  id: string
+  is_custom: boolean
+  local_currency: string
+  name: string
+  preset_id: string
+  updated_at: string
+  user_id: string
+  withdrawal_fee_type: string
+  withdrawal_fee_value: string
+}
+
+export interface BrokerFee {
+  type: string
+  value: string
+}
+
+export interface BrokerFeeRule {
+  max?: string | null
+  min?: string | null
+  tiers?: BrokerFeeTier[]
+  type?: string
+  value?: string
+}
+
+export interface BrokerFeeSchedule {
+  broker_id: string
+  commissions: Record<string, BrokerFeeRule>
+  created_at: string
+  deposit_fee: BrokerFeeRule
+  effective_from: string
+  fx_spread: string
+  id: string
+  notes?: string | null
+  updated_at: string
+  user_id: string
+  withdrawal_fee: BrokerFeeRule
+}
+
+export interface BrokerFeeScheduleInput {
+  commissions?: Record<string, BrokerFeeRule>
+  deposit_fee?: BrokerFeeRule
+  effective_from: string
+  fx_spread?: string
+  notes?: string | null
+  withdrawal_fee?: BrokerFeeRule
+}
+
+export interface BrokerFeeTier {
+  flat?: string
+  rate: string
+  up_to?: string | null
+}
+
+export interface BrokerFees {
+  broker_id?: string | null
+  broker_name: string
+  closing_fees: string
+  deposit_fees: string
+  maintenance_fees: string
+  other_fees: string
+  total_fees: string
+  trading_fees: string
+}
+
+export interface BrokerHoldings {
+  broker_id?: string | null
+  broker_name: string
+  holdings: Holding[]
+  market_value: string
+}
+
+export interface BrokerNetWorth {
+  broker_id?: string | null
+  broker_name: string
+  cash_balance: string
+  fee_drag_pct: string
+  holdings_value: string
+  net_worth: string
+  total_fees: string
+  total_invested: string
+  xirr: string
+}
+
+export interface BrokerPreset {
+  base_currency: string
+  country: string
+  deposit_fee: BrokerFee
+  id: string
+  local_currency: string
+  name: string
+  withdrawal_fee: BrokerFee
+}
+
+export interface BrokerReconciliation {
+  broker_id?: string | null
+  broker_name: string
+  difference: string
+  is_reconciled: boolean
+  total_cash_flow_fees: string
+  total_trade_fees: string
+}
+
+export interface CashFlow {
+  amount: string
+  auto_fee?: CashFlow | null
+  broker_id?: string | null
+  created_at: string
+  currency: string
+  date: string
+  fee_type?: string | null
+  fx_rate?: string | null
+  id: string
+  notes?: string | null
+  portfolio_id: string
+  related_cash_flow_id?: string | null
+  related_trade_id?: string | null
+  related_type?: string | null
+  transfer_id?: string | null
+  type: string
+  updated_at: string
+  usd_amount: string
+  user_id: string
+}
+
+export interface ConcentrationWarning {
+  message: string
+  percent: string
+  threshold: string
+  ticker: string
+}
+
+export interface ConfirmDCAInstallmentRequest {
+  amount?: string | null
+  date?: string | null
+  fills?: DCAFill[]
+  fx_rate?: string | null
+  notes?: string | null
+}
+
+export interface ConsolidatedPortfolioView {
+  consolidated: NetWorthSummary
+  portfolios: PortfolioNetWorth[]
+}
+
+export interface CreateAPITokenRequest {
+  expires_in_days?: number | null
+  name: string
+  scopes: string[]
+}
+
+export interface CreateAlertRuleRequest {
+  channels?: string[]
+  direction?: string
+  email?: string | null
+  kind: string
+  name: string
+  portfolio_id?: string | null
+  threshold: string
+  ticker?: string | null
+  webhook_url?: string | null
+}
+
+export interface CreateBenchmarkRequest {
+  components: BenchmarkComponent[]
+  name: string
+}
+
+export interface CreateBrokerRequest {
+  base_currency?: string
+  country?: string
+  fee_schedule?: BrokerFeeScheduleInput | null
+  local_currency?: string
+  name?: string
+  preset_id: string
+}
+
+export interface CreateCashFlowRequest {
+  amount: string
+  auto_fee?: boolean
+  broker_id?: string | null
+  currency?: string
+  date: string
+  fee_type?: string | null
+  fx_rate?: string | null
+  notes?: string | null
+  portfolio_id?: string | null
+  related_cash_flow_id?: string | null
+  related_trade_id?: string | null
+  related_type?: string | null
+  type: "deposit" | "withdrawal" | "fee" | "cash_adjustment"
+}
+
+export interface CreateDCAPlanRequest {
+  amount: string
+  broker_id?: string | null
+  currency?: string
+  end_date?: string | null
+  frequency?: string
+  name: string
+  portfolio_id?: string | null
+  start_date: string
+  targets: DCATarget[]
+}
+
+export interface CreateFxRateRequest {
+  date: string
+  rate: string
+  source?: string
+}
+
+export interface CreateGoalRequest {
+  currency?: string
+  monthly_contribution?: string | null
+  name: string
+  portfolio_id?: string | null
+  target_amount: string
+  target_date: string
+}
+
+export interface CreatePortfolioRequest {
+  broker_id?: string | null
+  is_default?: boolean
+  name: string
+}
+
+export interface CreatePortfolioTransferRequest {
+  amount: string
+  date: string
+  from_portfolio_id: string
+  notes?: string | null
+  to_portfolio_id: string
+}
+
+export interface CreateSubscriptionRequest {
+  billing_provider: string
+  plan_id: string
+}
+
+export interface CreateTradeRequest {
+  asset_type: "stock" | "etf"
+  broker_id?: string | null
+  closing_fee?: string | null
+  date: string
+  deposit_fee?: string | null
+  fee?: string | null
+  is_opening_position?: boolean | null
+  notes?: string | null
+  portfolio_id?: string | null
+  price: string
+  quantity: string
+  side: "buy" | "sell"
+  ticker: string
+  trading_fee?: string | null
+}
+
+export interface CreateWebhookEndpointRequest {
+  description?: string | null
+  events?: string[]
+  url: string
+}
+
+export interface CurrentRateResponse {
+  date: string
+  from: string
+  rate: string
+  source: string
+  to: string
+}
+
+export interface DCAAdherenceReport {
+  adherence_pct: string
+  avg_achieved_rate?: string | null
+  avg_market_rate?: string | null
+  confirmed: number
+  contributed_amount: string
+  contributed_usd: string
+  currency: string
+  from: string
+  pending: number
+  plan_id: string
+  planned_amount: string
+  rate_difference_pct?: string | null
+  scheduled: number
+  skipped: number
+  targets: DCATargetAdherence[]
+  to: string
+}
+
+export interface DCAFill {
+  price: string
+  quantity: string
+  ticker: string
+  trading_fee?: string | null
+}
+
+export interface DCAInstallment {
+  actual_amount?: string | null
+  cash_flow_id?: string | null
+  confirmed_at?: string | null
+  created_at: string
+  currency: string
+  executed_date?: string | null
+  fx_rate?: string | null
+  id: string
+  plan_id: string
+  planned_amount: string
+  planned_orders: DCAPlannedOrder[]
+  scheduled_date: string
+  status: string
+  trade_ids: string[]
+  updated_at: string
+  usd_amount?: string | null
+}
+
+export interface DCAPlan {
+  active: boolean
+  amount: string
+  broker_id?: string | null
+  created_at: string
+  currency: string
+  end_date?: string | null
+  frequency: string
+  id: string
+  last_scheduled_date?: string | null
+  name: string
+  next_run_date?: string | null
+  portfolio_id: string
+  start_date: string
+  targets: DCATarget[]
+  updated_at: string
+}
+
+export interface DCAPlannedOrder {
+  amount: string
+  asset_type: string
+  ticker: string
+  weight: string
+}
+
+export interface DCATarget {
+  asset_type?: string
+  ticker: string
+  weight: string
+}
+
+export interface DCATargetAdherence {
+  actual_weight: string
+  invested_usd: string
+  target_weight: string
+  ticker: string
+}
+
+export interface ErrorResponse {
+  code: string
+  error: string
+  request_id?: string
+}
+
+export interface ExposureBucket {
+  name: string
+  percent: string
+  value: string
+}
+
+export interface ExposureReport {
+  by_asset_class: ExposureBucket[]
+  by_country: ExposureBucket[]
+  by_currency: ExposureBucket[]
+  by_market_cap: ExposureBucket[]
+  by_name: ExposureBucket[]
+  by_region: ExposureBucket[]
+  by_sector: ExposureBucket[]
+  holdings_value: string
+  looked_through: string[]
+  unclassified: string[]
+  warnings: ConcentrationWarning[]
+}
+
+export interface FXImpactReport {
+  avg_investment_rate: string
+  current_rate: string
+  fx_impact_pct: string
+  fx_impact_usd: string
+  impact_by_period: Record<string, string>
+  rate_change_pct: string
+}
+
+export interface FeeBreakdown {
+  by_broker: BrokerFees[]
+  closing_fees: string
+  deposit_fees: string
+  fees_by_month: Record<string, string>
+  maintenance_fees: string
+  other_fees: string
+  total_fees: string
+  trading_fees: string
+}
+
+export interface FxRate {
+  created_at: string
+  date: string
+  id: string
+  rate: string
+  source: string
+  updated_at: string
+  user_id: string
+}
+
+export interface FxRateChartPoint {
+  date: string
+  rate: string
+}
+
+export interface GenerateDCAInstallmentsResponse {
+  created: number
+}
+
+export interface Goal {
+  created_at: string
+  currency: string
+  id: string
+  monthly_contribution?: string | null
+  name: string
+  portfolio_id?: string | null
+  target_amount: string
+  target_date: string
+  updated_at: string
+}
+
+export interface GoalAssumptions {
+  annual_volatility_pct: string
+  expected_annual_return_pct: string
+  observations: number
+  source: string
+}
+
+export interface GoalProjection {
+  as_of: string
+  assumptions: GoalAssumptions
+  contribution_source: string
+  currency: string
+  current_value_usd: string
+  fx_rate?: string | null
+  goal_id: string
+  median_value_usd: string
+  monthly_contribution_usd: string
+  months: number
+  on_track: boolean
+  on_track_probability_pct: string
+  projected_value_usd: string
+  required_monthly_contribution?: string | null
+  required_monthly_contribution_usd?: string | null
+  series: GoalProjectionPoint[]
+  simulations: number
+  target_amount: string
+  target_amount_usd: string
+  target_date: string
+}
+
+export interface GoalProjectionPoint {
+  date: string
+  deterministic: string
+  p10: string
+  p50: string
+  p90: string
+}
+
+export interface Holding {
+  assetType: string
+  avgCost: string
+  avgCostWithFees: string
+  avgCostWithoutFees: string
+  feeImpactPercent: string
+  marketValue: string
+  priceAsOf?: string | null
+  quantity: string
+  ticker: string
+  totalFees: string
+  totalInvested: string
+  totalInvestedWithFees: string
+  unrealizedPL: string
+  unrealizedPLPercent: string
+}
+
+export interface HoldingRiskMetrics {
+  annualized_return_pct: string
+  annualized_volatility_pct: string
+  beta: string
+  correlation: string
+  market_value: string
+  max_drawdown_pct: string
+  max_drawdown_peak_date?: string | null
+  max_drawdown_trough_date?: string | null
+  observations: number
+  sharpe_ratio: string
+  sortino_ratio: string
+  ticker: string
+  value_at_risk_pct: string
+  value_at_risk_usd: string
+}
+
+export interface ListBrokersResponse {
+  brokers: Broker[]
+  presets: BrokerPreset[]
+}
+
+export interface Liveness {
+  service: string
+  status: string
+}
+
+export interface MarketPrice {
+  currency: string
+  price: string
+  ticker: string
+  updated_at: string
+}
+
+export interface MessageResponse {
+  message: string
+}
+
+export interface NetWorthBreakdown {
+  by_asset_type: Record<string, string>
+  by_broker: BrokerNetWorth[]
+  by_ticker: Record<string, string>
+  top_holdings: Holding[]
+}
+
+export interface NetWorthSummary {
+  breakdown: NetWorthBreakdown
+  cash_balance: string
+  holdings_value: string
+  net_worth: string
+  total_deposited_cop: string
+  total_fees: string
+  total_gain_loss: string
+  total_gain_loss_pct: string
+  total_invested: string
+  total_withdrawn_cop: string
+  xirr: string
+}
+
+export interface Notification {
+  body: string
+  created_at: string
+  data: Record<string, string>
+  id: string
+  read_at?: string | null
+  title: string
+  type: string
+}
+
+export interface NotificationsMarkedRead {
+  updated: number
+}
+
+export interface PaginatedResponseCashFlow {
+  items: CashFlow[]
+  page: number
+  page_size: number
+  total: number
+}
+
+export interface PaginatedResponseHolding {
+  items: Holding[]
+  page: number
+  page_size: number
+  total: number
+}
+
+export interface PaginatedResponseNotification {
+  items: Notification[]
+  page: number
+  page_size: number
+  total: number
+}
+
+export interface PaginatedResponseTrade {
+  items: Trade[]
+  page: number
+  page_size: number
+  total: number
+}
+
+export interface PerformancePoint {
+  benchmarks?: Record<string, string>
+  cumulative_fees: string
+  cumulative_fx_impact: string
+  date: string
+  invested_capital: string
+  net_return: string
+  net_return_pct: string
+  portfolio_value: string
+  spy_indexed?: string
+}
+
+export interface Plan {
+  created_at: string
+  currency: string
+  description?: string | null
+  features: unknown
+  id: string
+  is_public: boolean
+  name: string
+  price_annual_usd?: string | null
+  price_monthly_usd?: string | null
+  tier: string
+  updated_at: string
+}
+
+export interface Portfolio {
+  broker_id?: string | null
+  created_at: string
+  id: string
+  is_default: boolean
+  name: string
+  updated_at: string
+  user_id: string
+}
+
+export interface PortfolioNetWorth {
+  portfolio: Portfolio
+  summary: NetWorthSummary
+}
+
+export interface PortfolioTransfer {
+  in: CashFlow
+  out: CashFlow
+  transfer_id: string
+}
+
+export interface Profile {
+  broker_preset_id?: string | null
+  country: string
+  created_at: string
+  id: string
+  locale?: string | null
+  onboarding_completed: boolean
+  onboarding_step: string
+  plan_id?: string | null
+  subscription_status?: string | null
+  updated_at: string
+  user_id: string
+}
+
+export interface Readiness {
+  checks: Record<string, ReadinessCheck>
+  status: string
+}
+
+export interface ReadinessCheck {
+  detail?: string
+  status: string
+}
+
+export interface RebalanceOrder {
+  amount: string
+  asset_type: string
+  estimated_fee: string
+  price: string
+  quantity: string
+  side: string
+  ticker: string
+}
+
+export interface RebalancePlan {
+  allow_sells: boolean
+  basis: string
+  cash_after: string
+  cash_before: string
+  estimated_fees: string
+  fractional_shares: boolean
+  needs_rebalance: boolean
+  orders: RebalanceOrder[]
+  portfolio_id: string
+  positions: RebalancePosition[]
+  total_value: string
+  warnings: string[]
+}
+
+export interface RebalancePosition {
+  current_value: string
+  current_weight: string
+  drift: string
+  drift_band: string
+  key: string
+  out_of_band: boolean
+  target_weight: string
+}
+
+export interface ReconciliationIssue {
+  actual_cash_flow_fees: string
+  broker_id?: string | null
+  date: string
+  description: string
+  difference: string
+  expected_fees: string
+  ticker: string
+  trade_id: string
+}
+
+export interface ReconciliationReport {
+  by_broker: BrokerReconciliation[]
+  difference: string
+  discrepancies: ReconciliationIssue[]
+  is_reconciled: boolean
+  missing_links: string[]
+  orphaned_cash_flows: string[]
+  total_cash_flow_fees: string
+  total_trade_fees: string
+  unlinked_cash_flows: string[]
+}
+
+export interface RefreshResult {
+  errors: string[]
+  tickers: string[]
+  updated: number
+}
+
+export interface ReturnAttribution {
+  closing_fees_impact: string
+  deposit_fees_impact: string
+  fx_impact: string
+  fx_impact_pct: string
+  market_gains: string
+  market_gains_pct: string
+  net_position: string
+  net_return_pct: string
+  starting_capital: string
+  total_fees_impact: string
+  total_fees_impact_pct: string
+  trading_fees_impact: string
+}
+
+export interface RiskMetrics {
+  annualized_return_pct: string
+  annualized_volatility_pct: string
+  beta: string
+  correlation: string
+  max_drawdown_pct: string
+  max_drawdown_peak_date?: string | null
+  max_drawdown_trough_date?: string | null
+  observations: number
+  sharpe_ratio: string
+  sortino_ratio: string
+  value_at_risk_pct: string
+  value_at_risk_usd: string
+}
+
+export interface RiskReport {
+  as_of: string
+  benchmark: string
+  holdings: HoldingRiskMetrics[]
+  portfolio: RiskMetrics
+  risk_free_rate_pct: string
+  var_confidence: string
+}
+
+export interface SetTargetAllocationRequest {
+  basis?: string
+  drift_band?: string
+  fractional_shares?: boolean | null
+  targets: AllocationTarget[]
+}
+
+export interface Subscription {
+  billing_provider: string
+  cancel_at_period_end: boolean
+  created_at: string
+  current_period_end?: string | null
+  current_period_start?: string | null
+  id: string
+  plan?: Plan | null
+  plan_id: string
+  provider_subscription_id?: string | null
+  status: string
+  trial_end?: string | null
+  trial_start?: string | null
+  updated_at: string
+  user_id: string
+}
+
+export interface TargetAllocation {
+  basis: string
+  created_at: string
+  drift_band: string
+  fractional_shares: boolean
+  portfolio_id: string
+  targets: AllocationTarget[]
+  updated_at: string
+}
+
+export interface Trade {
+  asset_type: string
+  broker_id?: string | null
+  closing_fee: string
+  created_at: string
+  date: string
+  deposit_fee: string
+  id: string
+  is_opening_position: boolean
+  notes?: string | null
+  portfolio_id: string
+  price: string
+  quantity: string
+  realized_pl?: string | null
+  realized_pl_pct?: string | null
+  side: string
+  ticker: string
+  total: string
+  total_fees: string
+  trading_fee: string
+  updated_at: string
+  user_id: string
+}
+
+export interface TransferFeeQuote {
+  broker_id: string
+  effective_from?: string | null
+  fee_rule: BrokerFeeRule
+  fee_schedule_id?: string | null
+  fee_usd: string
+  fx_spread_usd: string
+  gross_usd: string
+  net_usd: string
+  type: string
+}
+
+export interface UnreadNotificationCount {
+  unread: number
+}
+
+export interface UpdateAlertRuleRequest {
+  active?: boolean | null
+  channels?: string[]
+  direction?: string | null
+  email?: string | null
+  name?: string | null
+  threshold?: string | null
+  webhook_url?: string | null
+}
+
+export interface UpdateBrokerRequest {
+  base_currency?: string | null
+  country?: string | null
+  local_currency?: string | null
+  name?: string | null
+}
+
+export interface UpdateCashFlowRequest {
+  amount?: string | null
+  broker_id?: string | null
+  currency?: string | null
+  date?: string | null
+  fee_type?: string | null
+  fx_rate?: string | null
+  notes?: string | null
+  portfolio_id?: string | null
+  related_cash_flow_id?: string | null
+  related_trade_id?: string | null
+  related_type?: string | null
+  type?: "deposit" | "withdrawal" | "fee" | "cash_adjustment" | null
+}
+
+export interface UpdateDCAPlanRequest {
+  active?: boolean | null
+  amount?: string | null
+  end_date?: string | null
+  name?: string | null
+  targets?: DCATarget[]
+}
+
+export interface UpdateFxRateRequest {
+  date?: string | null
+  rate?: string | null
+  source?: string | null
+}
+
+export interface UpdateGoalRequest {
+  monthly_contribution?: string | null
+  name?: string | null
+  target_amount?: string | null
+  target_date?: string | null
+}
+
+export interface UpdateOnboardingRequest {
+  broker_preset_id: string
+  country: string
+}
+
+export interface UpdatePortfolioRequest {
+  broker_id?: string | null
+  is_default?: boolean | null
+  name?: string | null
+}
+
+export interface UpdateProfileRequest {
+  broker_preset_id: string
+  country: string
+  locale?: "en" | "es-CO" | null
+}
+
+export interface UpdateTradeRequest {
+  asset_type?: "stock" | "etf" | null
+  broker_id?: string | null
+  closing_fee?: string | null
+  date?: string | null
+  deposit_fee?: string | null
+  fee?: string | null
+  is_opening_position?: boolean | null
+  notes?: string | null
+  portfolio_id?: string | null
+  price?: string | null
+  quantity?: string | null
+  side?: "buy" | "sell" | null
+  ticker?: string | null
+  trading_fee?: string | null
+}
+
+export interface UpdateWebhookEndpointRequest {
+  active?: boolean | null
+  description?: string | null
+  events?: string[]
+  url?: string | null
+}
+
+export interface WebhookDelivery {
+  attempts: number
+  created_at: string
+  endpoint_id: string
+  error?: string | null
+  event_id: string
+  event_type: string
+  id: string
+  last_attempt_at?: string | null
+  next_attempt_at?: string | null
+  payload: unknown
+  response_status?: number | null
+  status: string
+}
+
+export interface WebhookEndpoint {
+  active: boolean
+  created_at: string
+  description: string
+  events: string[]
+  id: string
+  secret?: string
+  updated_at: string
+  url: string
+}
+
+type QueryValue = string | number | boolean | null | undefined
+
+function toQuery(query?: Record<string, QueryValue>): string {
+  const search = new URLSearchParams()
+  for (const [key, value] of Object.entries(query ?? {})) {
+    if (value !== undefined && value !== null && value !== "") search.set(key, String(value))
+  }
+  const encoded = search.toString()
+  return encoded ? `?${encoded}` : ""
+}
+
+/** Liveness probe */
+export function livez(): Promise<Liveness> {
+  return apiClient.send<Liveness>("GET", `/livez`)
+}
+
+/** Readiness probe */
+export function readyz(): Promise<Readiness> {
+  return apiClient.send<Readiness>("GET", `/readyz`)
+}
+
+/** Readiness probe (legacy path) */
+export function health(): Promise<Readiness> {
+  return apiClient.send<Readiness>("GET", `/health`)
+}
+
+/** This OpenAPI document */
+export function getOpenAPI(): Promise<Record<string, unknown>> {
+  return apiClient.send<Record<string, unknown>>("GET", `/api/openapi.json`)
+}
+
+/** Get or create the current user's profile */
+export function getMe(): Promise<Profile> {
+  return apiClient.send<Profile>("GET", `/api/me`)
+}
+
+/** Complete onboarding */
+export function updateOnboarding(body: UpdateOnboardingRequest): Promise<Profile> {
+  return apiClient.send<Profile>("PATCH", `/api/me/onboarding`, body)
+}
+
+/** Update the profile */
+export function updateProfile(body: UpdateProfileRequest): Promise<Profile> {
+  return apiClient.send<Profile>("PATCH", `/api/me/profile`, body)
+}
+
+/** List plans */
+export function listPlans(): Promise<Plan[]> {
+  return apiClient.send<Plan[]>("GET", `/api/plans`)
+}
+
+/** Get the current subscription */
+export function getSubscription(): Promise<Subscription> {
+  return apiClient.send<Subscription>("GET", `/api/subscriptions/current`)
+}
+
+/** Subscribe to a plan */
+export function createSubscription(body: CreateSubscriptionRequest): Promise<Subscription> {
+  return apiClient.send<Subscription>("POST", `/api/subscriptions`, body)
+}
+
+/** Cancel a subscription */
+export function cancelSubscription(id: string): Promise<Subscription> {
+  return apiClient.send<Subscription>("PATCH", `/api/subscriptions/${encodeURIComponent(id)}/cancel`)
+}
+
+/** List the user's brokers and the built-in presets */
+export function listBrokers(): Promise<ListBrokersResponse> {
+  return apiClient.send<ListBrokersResponse>("GET", `/api/brokers`)
+}
+
+/** Add a broker from a preset or a custom definition */
+export function createBroker(body: CreateBrokerRequest): Promise<Broker> {
+  return apiClient.send<Broker>("POST", `/api/brokers`, body)
+}
+
+/** Update a broker */
+export function updateBroker(id: string, body: UpdateBrokerRequest): Promise<Broker> {
+  return apiClient.send<Broker>("PATCH", `/api/brokers/${encodeURIComponent(id)}`, body)
+}
+
+/** Delete a broker */
+export function deleteBroker(id: string): Promise<MessageResponse> {
+  return apiClient.send<MessageResponse>("DELETE", `/api/brokers/${encodeURIComponent(id)}`)
+}
+
+/** List a broker's fee schedule versions */
+export function listBrokerFeeSchedules(id: string): Promise<BrokerFeeSchedule[]> {
+  return apiClient.send<BrokerFeeSchedule[]>("GET", `/api/brokers/${encodeURIComponent(id)}/fee-schedules`)
+}
+
+/** Add a fee schedule version */
+export function createBrokerFeeSchedule(id: string, body: BrokerFeeScheduleInput): Promise<BrokerFeeSchedule> {
+  return apiClient.send<BrokerFeeSchedule>("POST", `/api/brokers/${encodeURIComponent(id)}/fee-schedules`, body)
+}
+
+/** Delete a fee schedule version */
+export function deleteBrokerFeeSchedule(id: string, scheduleId: string): Promise<MessageResponse> {
+  return apiClient.send<MessageResponse>("DELETE", `/api/brokers/${encodeURIComponent(id)}/fee-schedules/${encodeURIComponent(scheduleId)}`)
+}
+
+export type ListNotificationsQuery = {
+  page?: number
+  page_size?: number
+  unread?: "true" | "false"
+}
+
+/** List notifications */
+export function listNotifications(query?: ListNotificationsQuery): Promise<PaginatedResponseNotification> {
+  return apiClient.send<PaginatedResponseNotification>("GET", `/api/notifications${toQuery(query)}`)
+}
+
+/** Count unread notifications */
+export function getUnreadNotificationCount(): Promise<UnreadNotificationCount> {
+  return apiClient.send<UnreadNotificationCount>("GET", `/api/notifications/unread-count`)
+}
+
+/** Mark every notification read */
+export function markAllNotificationsRead(): Promise<NotificationsMarkedRead> {
+  return apiClient.send<NotificationsMarkedRead>("POST", `/api/notifications/read-all`)
+}
+
+/** Mark a notification read */
+export function markNotificationRead(id: string): Promise<Notification> {
+  return apiClient.send<Notification>("POST", `/api/notifications/${encodeURIComponent(id)}/read`)
+}
+
+/** List API tokens */
+export function listAPITokens(): Promise<APIToken[]> {
+  return apiClient.send<APIToken[]>("GET", `/api/tokens`)
+}
+
+/** Create an API token */
+export function createAPIToken(body: CreateAPITokenRequest): Promise<APIToken> {
+  return apiClient.send<APIToken>("POST", `/api/tokens`, body)
+}
+
+/** Revoke an API token */
+export function revokeAPIToken(id: string): Promise<APIToken> {
+  return apiClient.send<APIToken>("DELETE", `/api/tokens/${encodeURIComponent(id)}`)
+}
+
+export type GetCurrentRateQuery = {
+  from?: string
+  to?: string
+}
+
+/** Get the current USD/COP or COP/USD rate */
+export function getCurrentRate(query?: GetCurrentRateQuery): Promise<CurrentRateResponse> {
+  return apiClient.send<CurrentRateResponse>("GET", `/api/fx-rates/current${toQuery(query)}`)
+}
+
+export type GetFxRateChartQuery = {
+  days?: number
+}
+
+/** Daily USD/COP closes for charting */
+export function getFxRateChart(query?: GetFxRateChartQuery): Promise<FxRateChartPoint[]> {
+  return apiClient.send<FxRateChartPoint[]>("GET", `/api/fx-rates/chart${toQuery(query)}`)
+}
+
+/** List recorded FX rates */
+export function listFxRates(): Promise<FxRate[]> {
+  return apiClient.send<FxRate[]>("GET", `/api/fx-rates`)
+}
+
+/** Record an FX rate */
+export function createFxRate(body: CreateFxRateRequest): Promise<FxRate> {
+  return apiClient.send<FxRate>("POST", `/api/fx-rates`, body)
+}
+
+/** Update an FX rate */
+export function updateFxRate(id: string, body: UpdateFxRateRequest): Promise<MessageResponse> {
+  return apiClient.send<MessageResponse>("PUT", `/api/fx-rates/${encodeURIComponent(id)}`, body)
+}
+
+/** Delete an FX rate */
+export function deleteFxRate(id: string): Promise<MessageResponse> {
+  return apiClient.send<MessageResponse>("DELETE", `/api/fx-rates/${encodeURIComponent(id)}`)
+}
+
+export type ListCashFlowsQuery = {
+  from?: string
+  to?: string
+  type?: string
+  currency?: string
+  exclude_mirrored?: boolean
+  sort?: "date" | "-date" | "usd_amount" | "-usd_amount"
+  portfolio_id?: string
+  page?: number
+  page_size?: number
+}
+
+/** List cash flows */
+export function listCashFlows(query?: ListCashFlowsQuery): Promise<CashFlow[] | PaginatedResponseCashFlow> {
+  return apiClient.send<CashFlow[] | PaginatedResponseCashFlow>("GET", `/api/cash-flows${toQuery(query)}`)
+}
+
+/** Record a cash flow */
+export function createCashFlow(body: CreateCashFlowRequest, options?: RequestOptions): Promise<CashFlow> {
+  return apiClient.send<CashFlow>("POST", `/api/cash-flows`, body, options)
+}
+
+/** Quote the broker fee of a deposit or withdrawal */
+export function quoteCashFlowFee(body: CreateCashFlowRequest): Promise<TransferFeeQuote> {
+  return apiClient.send<TransferFeeQuote>("POST", `/api/cash-flows/fee-quote`, body)
+}
+
+/** Update a cash flow */
+export function updateCashFlow(id: string, body: UpdateCashFlowRequest): Promise<MessageResponse> {
+  return apiClient.send<MessageResponse>("PUT", `/api/cash-flows/${encodeURIComponent(id)}`, body)
+}
+
+/** Delete a cash flow */
+export function deleteCashFlow(id: string): Promise<MessageResponse> {
+  return apiClient.send<MessageResponse>("DELETE", `/api/cash-flows/${encodeURIComponent(id)}`)
+}
+
+/** List the tickers the user has traded */
+export function listTradeTickers(): Promise<string[]> {
+  return apiClient.send<string[]>("GET", `/api/trade-tickers`)
+}
+
+export type ListTradesQuery = {
+  from?: string
+  to?: string
+  side?: string
+  asset_type?: string
+  ticker?: string
+  sort?: "date" | "-date" | "ticker" | "-ticker" | "total" | "-total"
+  portfolio_id?: string
+  page?: number
+  page_size?: number
+}
+
+/** List trades */
+export function listTrades(query?: ListTradesQuery): Promise<Trade[] | PaginatedResponseTrade> {
+  return apiClient.send<Trade[] | PaginatedResponseTrade>("GET", `/api/trades${toQuery(query)}`)
+}
+
+/** Record a trade */
+export function createTrade(body: CreateTradeRequest, options?: RequestOptions): Promise<Trade> {
+  return apiClient.send<Trade>("POST", `/api/trades`, body, options)
+}
+
+/** Update a trade */
+export function updateTrade(id: string, body: UpdateTradeRequest): Promise<MessageResponse> {
+  return apiClient.send<MessageResponse>("PUT", `/api/trades/${encodeURIComponent(id)}`, body)
+}
+
+/** Delete a trade */
+export function deleteTrade(id: string): Promise<MessageResponse> {
+  return apiClient.send<MessageResponse>("DELETE", `/api/trades/${encodeURIComponent(id)}`)
+}
+
+/** List cached market prices */
+export function listMarketPrices(): Promise<MarketPrice[]> {
+  return apiClient.send<MarketPrice[]>("GET", `/api/market-prices`)
+}
+
+/** Get a ticker's cached price */
+export function getMarketPrice(ticker: string): Promise<MarketPrice> {
+  return apiClient.send<MarketPrice>("GET", `/api/market-prices/${encodeURIComponent(ticker)}`)
+}
+
+/** Refresh quotes for held tickers */
+export function refreshMarketPrices(): Promise<RefreshResult> {
+  return apiClient.send<RefreshResult>("POST", `/api/market-prices/refresh`)
+}
+
+/** Backfill daily closes */
+export function refreshDailyPrices(): Promise<RefreshResult> {
+  return apiClient.send<RefreshResult>("POST", `/api/market-prices/history/refresh`)
+}
+
+export type GetHoldingsQuery = {
+  group_by?: "broker"
+  portfolio_id?: string
+  page?: number
+  page_size?: number
+}
+
+/** Current holdings */
+export function getHoldings(query?: GetHoldingsQuery): Promise<Holding[] | BrokerHoldings[] | PaginatedResponseHolding> {
+  return apiClient.send<Holding[] | BrokerHoldings[] | PaginatedResponseHolding>("GET", `/api/portfolio/holdings${toQuery(query)}`)
+}
+
+/** List portfolios */
+export function listPortfolios(): Promise<Portfolio[]> {
+  return apiClient.send<Portfolio[]>("GET", `/api/portfolios`)
+}
+
+/** Every portfolio side by side with totals */
+export function getConsolidatedPortfolios(): Promise<ConsolidatedPortfolioView> {
+  return apiClient.send<ConsolidatedPortfolioView>("GET", `/api/portfolios/consolidated`)
+}
+
+/** Create a portfolio */
+export function createPortfolio(body: CreatePortfolioRequest): Promise<Portfolio> {
+  return apiClient.send<Portfolio>("POST", `/api/portfolios`, body)
+}
+
+/** Move USD cash between portfolios */
+export function createPortfolioTransfer(body: CreatePortfolioTransferRequest, options?: RequestOptions): Promise<PortfolioTransfer> {
+  return apiClient.send<PortfolioTransfer>("POST", `/api/portfolios/transfers`, body, options)
+}
+
+export type DeletePortfolioTransferQuery = {
+  portfolio_id?: string
+}
+
+/** Delete a transfer */
+export function deletePortfolioTransfer(id: string, query?: DeletePortfolioTransferQuery): Promise<MessageResponse> {
+  return apiClient.send<MessageResponse>("DELETE", `/api/portfolios/transfers/${encodeURIComponent(id)}${toQuery(query)}`)
+}
+
+/** Update a portfolio */
+export function updatePortfolio(id: string, body: UpdatePortfolioRequest): Promise<Portfolio> {
+  return apiClient.send<Portfolio>("PATCH", `/api/portfolios/${encodeURIComponent(id)}`, body)
+}
+
+/** Delete a portfolio */
+export function deletePortfolio(id: string): Promise<MessageResponse> {
+  return apiClient.send<MessageResponse>("DELETE", `/api/portfolios/${encodeURIComponent(id)}`)
+}
+
+/** Get a portfolio's target allocation */
+export function getTargetAllocation(id: string): Promise<TargetAllocation> {
+  return apiClient.send<TargetAllocation>("GET", `/api/portfolios/${encodeURIComponent(id)}/target-allocation`)
+}
+
+/** Set a portfolio's target allocation */
+export function setTargetAllocation(id: string, body: SetTargetAllocationRequest): Promise<TargetAllocation> {
+  return apiClient.send<TargetAllocation>("PUT", `/api/portfolios/${encodeURIComponent(id)}/target-allocation`, body)
+}
+
+/** Delete a portfolio's target allocation */
+export function deleteTargetAllocation(id: string): Promise<MessageResponse> {
+  return apiClient.send<MessageResponse>("DELETE", `/api/portfolios/${encodeURIComponent(id)}/target-allocation`)
+}
+
+export type GetRebalancePlanQuery = {
+  allow_sells?: boolean
+}
+
+/** Plan trades back to the target allocation */
+export function getRebalancePlan(id: string, query?: GetRebalancePlanQuery): Promise<RebalancePlan> {
+  return apiClient.send<RebalancePlan>("GET", `/api/portfolios/${encodeURIComponent(id)}/rebalance${toQuery(query)}`)
+}
+
+export type GetFeeBreakdownQuery = {
+  start_date?: string
+  end_date?: string
+  portfolio_id?: string
+}
+
+/** Fees by type */
+export function getFeeBreakdown(query?: GetFeeBreakdownQuery): Promise<FeeBreakdown> {
+  return apiClient.send<FeeBreakdown>("GET", `/api/analytics/fee-breakdown${toQuery(query)}`)
+}
+
+export type GetFeeImpactQuery = {
+  ticker?: string
+  portfolio_id?: string
+}
+
+/** Fee impact on return */
+export function getFeeImpact(query?: GetFeeImpactQuery): Promise<Record<string, string>> {
+  return apiClient.send<Record<string, string>>("GET", `/api/analytics/fee-impact${toQuery(query)}`)
+}
+
+export type GetFeeEfficiencyQuery = {
+  group_by?: string
+  portfolio_id?: string
+}
+
+/** Fees relative to invested capital */
+export function getFeeEfficiency(query?: GetFeeEfficiencyQuery): Promise<Record<string, unknown>> {
+  return apiClient.send<Record<string, unknown>>("GET", `/api/analytics/fee-efficiency${toQuery(query)}`)
+}
+
+export type GetReturnAttributionQuery = {
+  portfolio_id?: string
+}
+
+/** Return split into price, FX and fees */
+export function getReturnAttribution(query?: GetReturnAttributionQuery): Promise<ReturnAttribution> {
+  return apiClient.send<ReturnAttribution>("GET", `/api/analytics/return-attribution${toQuery(query)}`)
+}
+
+export type GetFXImpactQuery = {
+  portfolio_id?: string
+}
+
+/** Effect of USD/COP moves on the portfolio */
+export function getFXImpact(query?: GetFXImpactQuery): Promise<FXImpactReport> {
+  return apiClient.send<FXImpactReport>("GET", `/api/analytics/fx-impact${toQuery(query)}`)
+}
+
+export type GetPerformanceTimeSeriesQuery = {
+  interval?: string
+  benchmarks?: string
+  portfolio_id?: string
+}
+
+/** Portfolio value over time */
+export function getPerformanceTimeSeries(query?: GetPerformanceTimeSeriesQuery): Promise<PerformancePoint[]> {
+  return apiClient.send<PerformancePoint[]>("GET", `/api/analytics/performance-time-series${toQuery(query)}`)
+}
+
+export type GetNetWorthQuery = {
+  portfolio_id?: string
+}
+
+/** Net worth summary */
+export function getNetWorth(query?: GetNetWorthQuery): Promise<NetWorthSummary> {
+  return apiClient.send<NetWorthSummary>("GET", `/api/analytics/net-worth${toQuery(query)}`)
+}
+
+export type GetCashReconciliationQuery = {
+  portfolio_id?: string
+}
+
+/** Check cash flows against trades */
+export function getCashReconciliation(query?: GetCashReconciliationQuery): Promise<ReconciliationReport> {
+  return apiClient.send<ReconciliationReport>("GET", `/api/analytics/cash-reconciliation${toQuery(query)}`)
+}
+
+export type GetRiskMetricsQuery = {
+  risk_free_rate?: string
+  confidence?: string
+  portfolio_id?: string
+}
+
+/** Volatility, drawdown and value at risk */
+export function getRiskMetrics(query?: GetRiskMetricsQuery): Promise<RiskReport> {
+  return apiClient.send<RiskReport>("GET", `/api/analytics/risk${toQuery(query)}`)
+}
+
+export type GetBenchmarkComparisonQuery = {
+  benchmarks?: string
+  portfolio_id?: string
+}
+
+/** Compare returns with benchmarks */
+export function getBenchmarkComparison(query?: GetBenchmarkComparisonQuery): Promise<BenchmarkComparison[]> {
+  return apiClient.send<BenchmarkComparison[]>("GET", `/api/analytics/benchmark-comparison${toQuery(query)}`)
+}
+
+export type GetExposureQuery = {
+  concentration_threshold?: string
+  portfolio_id?: string
+}
+
+/** Exposure by asset type, sector and currency */
+export function getExposure(query?: GetExposureQuery): Promise<ExposureReport> {
+  return apiClient.send<ExposureReport>("GET", `/api/analytics/exposure${toQuery(query)}`)
+}
+
+/** List benchmarks */
+export function listBenchmarks(): Promise<Benchmark[]> {
+  return apiClient.send<Benchmark[]>("GET", `/api/benchmarks`)
+}
+
+/** Create a custom benchmark */
+export function createBenchmark(body: CreateBenchmarkRequest): Promise<Benchmark> {
+  return apiClient.send<Benchmark>("POST", `/api/benchmarks`, body)
+}
+
+/** Delete a custom benchmark */
+export function deleteBenchmark(id: string): Promise<void> {
+  return apiClient.send<void>("DELETE", `/api/benchmarks/${encodeURIComponent(id)}`)
+}
+
+/** List DCA plans */
+export function listDCAPlans(): Promise<DCAPlan[]> {
+  return apiClient.send<DCAPlan[]>("GET", `/api/dca-plans`)
+}
+
+/** Create a DCA plan */
+export function createDCAPlan(body: CreateDCAPlanRequest, options?: RequestOptions): Promise<DCAPlan> {
+  return apiClient.send<DCAPlan>("POST", `/api/dca-plans`, body, options)
+}
+
+/** Get a DCA plan */
+export function getDCAPlan(id: string): Promise<DCAPlan> {
+  return apiClient.send<DCAPlan>("GET", `/api/dca-plans/${encodeURIComponent(id)}`)
+}
+
+/** Update a DCA plan */
+export function updateDCAPlan(id: string, body: UpdateDCAPlanRequest): Promise<DCAPlan> {
+  return apiClient.send<DCAPlan>("PATCH", `/api/dca-plans/${encodeURIComponent(id)}`, body)
+}
+
+/** Delete a DCA plan */
+export function deleteDCAPlan(id: string): Promise<void> {
+  return apiClient.send<void>("DELETE", `/api/dca-plans/${encodeURIComponent(id)}`)
+}
+
+export type ListDCAPlanInstallmentsQuery = {
+  status?: string
+}
+
+/** List a plan's installments */
+export function listDCAPlanInstallments(id: string, query?: ListDCAPlanInstallmentsQuery): Promise<DCAInstallment[]> {
+  return apiClient.send<DCAInstallment[]>("GET", `/api/dca-plans/${encodeURIComponent(id)}/installments${toQuery(query)}`)
+}
+
+export type GetDCAReportQuery = {
+  from?: string
+  to?: string
+}
+
+/** Adherence report */
+export function getDCAReport(id: string, query?: GetDCAReportQuery): Promise<DCAAdherenceReport> {
+  return apiClient.send<DCAAdherenceReport>("GET", `/api/dca-plans/${encodeURIComponent(id)}/report${toQuery(query)}`)
+}
+
+export type ListDCAInstallmentsQuery = {
+  status?: string
+}
+
+/** List installments of every plan */
+export function listDCAInstallments(query?: ListDCAInstallmentsQuery): Promise<DCAInstallment[]> {
+  return apiClient.send<DCAInstallment[]>("GET", `/api/dca-installments${toQuery(query)}`)
+}
+
+/** Create due installments now */
+export function generateDCAInstallments(): Promise<GenerateDCAInstallmentsResponse> {
+  return apiClient.send<GenerateDCAInstallmentsResponse>("POST", `/api/dca-installments/generate`)
+}
+
+/** Confirm an installment and record its trades */
+export function confirmDCAInstallment(id: string, body: ConfirmDCAInstallmentRequest, options?: RequestOptions): Promise<DCAInstallment> {
+  return apiClient.send<DCAInstallment>("POST", `/api/dca-installments/${encodeURIComponent(id)}/confirm`, body, options)
+}
+
+/** Skip an installment */
+export function skipDCAInstallment(id: string): Promise<DCAInstallment> {
+  return apiClient.send<DCAInstallment>("POST", `/api/dca-installments/${encodeURIComponent(id)}/skip`)
+}
+
+/** List goals */
+export function listGoals(): Promise<Goal[]> {
+  return apiClient.send<Goal[]>("GET", `/api/goals`)
+}
+
+/** Create a goal */
+export function createGoal(body: CreateGoalRequest, options?: RequestOptions): Promise<Goal> {
+  return apiClient.send<Goal>("POST", `/api/goals`, body, options)
+}
+
+/** Get a goal */
+export function getGoal(id: string): Promise<Goal> {
+  return apiClient.send<Goal>("GET", `/api/goals/${encodeURIComponent(id)}`)
+}
+
+/** Update a goal */
+export function updateGoal(id: string, body: UpdateGoalRequest): Promise<Goal> {
+  return apiClient.send<Goal>("PATCH", `/api/goals/${encodeURIComponent(id)}`, body)
+}
+
+/** Delete a goal */
+export function deleteGoal(id: string): Promise<void> {
+  return apiClient.send<void>("DELETE", `/api/goals/${encodeURIComponent(id)}`)
+}
+
+export type GetGoalProjectionQuery = {
+  simulations?: number
+}
+
+/** Monte Carlo projection of a goal */
+export function getGoalProjection(id: string, query?: GetGoalProjectionQuery): Promise<GoalProjection> {
+  return apiClient.send<GoalProjection>("GET", `/api/goals/${encodeURIComponent(id)}/projection${toQuery(query)}`)
+}
+
+/** List alert rules */
+export function listAlertRules(): Promise<AlertRule[]> {
+  return apiClient.send<AlertRule[]>("GET", `/api/alerts`)
+}
+
+/** Create an alert rule */
+export function createAlertRule(body: CreateAlertRuleRequest, options?: RequestOptions): Promise<AlertRule> {
+  return apiClient.send<AlertRule>("POST", `/api/alerts`, body, options)
+}
+
+export type ListAlertEventsQuery = {
+  rule_id?: string
+  limit?: number
+}
+
+/** List fired alerts */
+export function listAlertEvents(query?: ListAlertEventsQuery): Promise<AlertEvent[]> {
+  return apiClient.send<AlertEvent[]>("GET", `/api/alerts/events${toQuery(query)}`)
+}
+
+/** Evaluate the user's alert rules now */
+export function evaluateAlerts(): Promise<AlertEvaluation> {
+  return apiClient.send<AlertEvaluation>("POST", `/api/alerts/evaluate`)
+}
+
+/** Get an alert rule */
+export function getAlertRule(id: string): Promise<AlertRule> {
+  return apiClient.send<AlertRule>("GET", `/api/alerts/${encodeURIComponent(id)}`)
+}
+
+/** Update an alert rule */
+export function updateAlertRule(id: string, body: UpdateAlertRuleRequest): Promise<AlertRule> {
+  return apiClient.send<AlertRule>("PATCH", `/api/alerts/${encodeURIComponent(id)}`, body)
+}
+
+/** Delete an alert rule */
+export function deleteAlertRule(id: string): Promise<void> {
+  return apiClient.send<void>("DELETE", `/api/alerts/${encodeURIComponent(id)}`)
+}
+
+/** List webhook endpoints */
+export function listWebhooks(): Promise<WebhookEndpoint[]> {
+  return apiClient.send<WebhookEndpoint[]>("GET", `/api/webhooks`)
+}
+
+/** Register a webhook endpoint */
+export function createWebhook(body: CreateWebhookEndpointRequest, options?: RequestOptions): Promise<WebhookEndpoint> {
+  return apiClient.send<WebhookEndpoint>("POST", `/api/webhooks`, body, options)
+}
+
+/** Get a webhook endpoint */
+export function getWebhook(id: string): Promise<WebhookEndpoint> {
+  return apiClient.send<WebhookEndpoint>("GET", `/api/webhooks/${encodeURIComponent(id)}`)
+}
+
+/** Update a webhook endpoint */
+export function updateWebhook(id: string, body: UpdateWebhookEndpointRequest): Promise<WebhookEndpoint> {
+  return apiClient.send<WebhookEndpoint>("PATCH", `/api/webhooks/${encodeURIComponent(id)}`, body)
+}
+
+/** Delete a webhook endpoint */
+export function deleteWebhook(id: string): Promise<void> {
+  return apiClient.send<void>("DELETE", `/api/webhooks/${encodeURIComponent(id)}`)
+}
+
+export type ListWebhookDeliveriesQuery = {
+  limit?: number
+}
+
+/** List recent deliveries */
+export function listWebhookDeliveries(id: string, query?: ListWebhookDeliveriesQuery): Promise<WebhookDelivery[]> {
+  return apiClient.send<WebhookDelivery[]>("GET", `/api/webhooks/${encodeURIComponent(id)}/deliveries${toQuery(query)}`)
+}
+
+/** Send a test delivery */
+export function pingWebhook(id: string): Promise<WebhookDelivery> {
+  return apiClient.send<WebhookDelivery>("POST", `/api/webhooks/${encodeURIComponent(id)}/ping`)
+}
+
+export type GetActivityFeedQuery = {
+  limit?: number
+}
+
+/** Recent trades and cash flows */
+export function getActivityFeed(query?: GetActivityFeedQuery): Promise<ActivityItem[]> {
+  return apiClient.send<ActivityItem[]>("GET", `/api/activity/feed${toQuery(query)}`)
+}